@@ -1,17 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/joelhooks/agent-secrets/internal/adapters"
+	"github.com/joelhooks/agent-secrets/internal/adapters/cache"
+	"github.com/joelhooks/agent-secrets/internal/adapters/dotenv"
 	"github.com/joelhooks/agent-secrets/internal/adapters/vercel"
+	"github.com/joelhooks/agent-secrets/internal/project"
+	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
 // getAdapter returns the appropriate adapter for the given source name.
-func getAdapter(source string) (adapters.SourceAdapter, error) {
+// projectDir is the directory containing .secrets.json, used by file-based sources.
+func getAdapter(source, projectDir string) (adapters.SourceAdapter, error) {
 	switch source {
 	case "vercel":
 		return vercel.New(), nil
+	case "dotenv":
+		return dotenv.New(projectDir), nil
 	case "doppler":
 		// TODO: Implement Doppler adapter
 		return nil, fmt.Errorf("doppler adapter not yet implemented")
@@ -19,3 +28,106 @@ func getAdapter(source string) (adapters.SourceAdapter, error) {
 		return nil, fmt.Errorf("unknown source: %q", source)
 	}
 }
+
+// withCache wraps adapter with the on-disk pull cache configured in the
+// daemon config. Caching is skipped if the config cannot be loaded or the
+// cache TTL is zero, and for dotenv sources: reading a local file is cheap,
+// and the cache key does not cover the project directory its path is
+// relative to.
+func withCache(adapter adapters.SourceAdapter) adapters.SourceAdapter {
+	if _, local := adapter.(*dotenv.DotenvAdapter); local {
+		return adapter
+	}
+	cfg, err := loadConfig()
+	if err != nil || cfg.AdapterCacheTTL <= 0 {
+		return adapter
+	}
+	return cache.New(adapter, cfg.AdapterCacheDir(), cfg.AdapterCacheTTL)
+}
+
+// purgeCache deletes every cached pull, for --no-cache, so later runs do not
+// reuse values fetched before it. Failures are ignored; entries still expire.
+func purgeCache() {
+	cfg, err := loadConfig()
+	if err != nil {
+		return
+	}
+	_ = cache.Purge(cfg.AdapterCacheDir())
+}
+
+// preflightError converts a failed adapter Check into a UserError that says
+// what is missing and how to install or log in to the provider.
+func preflightError(source string, err error) *types.UserError {
+	reason := err.Error()
+	var notAvailable types.ErrAdapterNotAvailable
+	if errors.As(err, &notAvailable) {
+		reason = notAvailable.Reason
+	}
+
+	suggestion := fmt.Sprintf("Make sure the %s source is set up, then retry.", source)
+	switch source {
+	case "vercel":
+		suggestion = "Install the Vercel CLI and log in:\n  npm i -g vercel\n  vercel login"
+	}
+
+	return types.NewUserError(
+		fmt.Sprintf("Source %q is not ready", source),
+		"Secrets cannot be fetched until the provider CLI is installed and authenticated.",
+		suggestion,
+		"secrets env --help",
+	).WithContext("Reason", reason)
+}
+
+// pullSources pulls from every source in cfg, in order, and merges the
+// results with later sources overriding earlier ones. It returns the merged
+// vars and, for each var, the label of the source it came from. Each adapter
+// is preflight-checked before it is pulled from; useCache wraps adapters with
+// the pull cache, which checks only on a cache miss.
+func pullSources(cfg *project.ProjectConfig, projectDir string, useCache bool) (map[string]string, map[string]string, error) {
+	vars := make(map[string]string)
+	provenance := make(map[string]string)
+
+	for _, spec := range cfg.SourceList() {
+		adapter, err := getAdapter(spec.Source, projectDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get adapter: %w", err)
+		}
+		if useCache {
+			adapter = withCache(adapter)
+		}
+		// Preflight: verify the provider tool before pulling
+		if _, cached := adapter.(*cache.CachingAdapter); !cached {
+			if err := adapter.Check(); err != nil {
+				return nil, nil, preflightError(spec.Source, err)
+			}
+		}
+
+		pulled, err := adapter.Pull(spec.Project, spec.Scope)
+		if err != nil {
+			var checkErr *cache.CheckError
+			if errors.As(err, &checkErr) {
+				return nil, nil, preflightError(spec.Source, checkErr.Err)
+			}
+			return nil, nil, fmt.Errorf("failed to pull secrets from %s: %w", spec.Label(), err)
+		}
+		for key, value := range pulled {
+			vars[key] = value
+			provenance[key] = spec.Label()
+		}
+	}
+
+	return vars, provenance, nil
+}
+
+// sourceHeader returns the "# secrets-source" value for cfg: the source name
+// for single-source configs, or the comma-separated source labels otherwise.
+func sourceHeader(cfg *project.ProjectConfig) string {
+	if len(cfg.Sources) == 0 {
+		return cfg.Source
+	}
+	labels := make([]string, 0, len(cfg.Sources))
+	for _, spec := range cfg.Sources {
+		labels = append(labels, spec.Label())
+	}
+	return strings.Join(labels, ",")
+}
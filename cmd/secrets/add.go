@@ -1,12 +1,13 @@
 package main
 
 import (
-	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
-	"syscall"
+	"time"
 
 	"github.com/joelhooks/agent-secrets/internal/daemon"
 	"github.com/joelhooks/agent-secrets/internal/output"
@@ -15,56 +16,125 @@ import (
 	"golang.org/x/term"
 )
 
+// maxValueSize bounds a secret value read from stdin
+const maxValueSize = 1 << 20
+
 var (
-	addValue     string
-	addRotateVia string
+	addValue       string
+	addRotateVia   string
+	addPropagateTo []string
+	addExpiresIn   time.Duration
+	addLabels      []string
+	addGenerateVia string
+	addRevokeVia   string
+	addRotateEvery string
 )
 
 var addCmd = &cobra.Command{
 	Use:   "add <name>",
 	Short: "Add a secret to the store",
-	Long: `Add a new secret to the encrypted store. The secret value can be provided via:
-  - The --value flag
-  - Piped from stdin (e.g., echo "secret" | secrets add name)
-  - Interactive prompt (secure, no echo)`,
+	Long: `Add a new secret to the encrypted store. Without --value, the value is
+read from stdin when piped (e.g. echo "$TOKEN" | secrets add name), or
+prompted for on the terminal with echo off. Prefer these to --value, which
+leaves the secret in shell history and process listings. Piped values may
+span several lines, e.g. a PEM key; surrounding whitespace is trimmed.
+
+Use --propagate-to to keep managed env files (written by 'secrets env') in
+sync: after each successful rotation the daemon rewrites the variable in
+each listed file, leaving other variables and the TTL header untouched.
+The variable name defaults to the secret name.
+
+Use --rotate-every with --rotate-via to have the daemon run the hook on a
+schedule, e.g. 720h or 30d. See 'secrets rotate-policy' to change it later.
+
+Use --label to tag secrets for filtering with 'secrets list --label'.
+
+Use --expires-in for short-lived keys: once the duration passes the secret
+can no longer be read or leased, and the daemon deletes it.
+
+Use --generate-via for dynamic credentials: each lease runs the command and
+hands out its output instead of a stored value, so no value is needed. The
+command sees AGENT_SECRETS_SECRET and AGENT_SECRETS_CLIENT_ID. When the
+lease is revoked or expires, --revoke-via runs with AGENT_SECRETS_LEASE_ID
+and the issued value in AGENT_SECRETS_VALUE.
+
+Examples:
+  secrets add API_KEY --rotate-via './rotate.sh' --propagate-to .env.local
+  secrets add API_KEY --rotate-via './rotate.sh' --rotate-every 30d
+  secrets add prod::db --propagate-to ./app/.env.local:DATABASE_URL
+  secrets add DEPLOY_TOKEN --expires-in 24h
+  secrets add STRIPE_KEY --label env=prod --label team=billing
+  secrets add DB_CREDS --generate-via './mint-db-user.sh' --revoke-via './drop-db-user.sh'`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 		value := addValue
 
-		// If no value provided via flag, check stdin or prompt
-		if value == "" {
-			stat, _ := os.Stdin.Stat()
-			if (stat.Mode() & os.ModeCharDevice) == 0 {
-				// Data is being piped in
-				scanner := bufio.NewScanner(os.Stdin)
-				if scanner.Scan() {
-					value = scanner.Text()
-				}
-				if err := scanner.Err(); err != nil {
-					return fmt.Errorf("failed to read from stdin: %w", err)
-				}
-			} else {
-				// Interactive prompt
-				fmt.Printf("Enter secret value for '%s': ", name)
-				byteValue, err := term.ReadPassword(int(syscall.Stdin))
-				if err != nil {
-					return fmt.Errorf("failed to read password: %w", err)
-				}
-				fmt.Println() // Add newline after hidden input
-				value = string(byteValue)
+		// If no value provided via flag, read stdin or prompt
+		if value == "" && addGenerateVia == "" {
+			var err error
+			if value, err = readSecretValue(name); err != nil {
+				output.Print(output.Error(err))
+				return err
 			}
 		}
 
 		value = strings.TrimSpace(value)
-		if value == "" {
-			return fmt.Errorf("secret value cannot be empty")
+		if value == "" && addGenerateVia == "" {
+			err := fmt.Errorf("secret value cannot be empty")
+			output.Print(output.Error(err))
+			return err
+		}
+		if addRevokeVia != "" && addGenerateVia == "" {
+			err := fmt.Errorf("--revoke-via requires --generate-via")
+			output.Print(output.Error(err))
+			return err
+		}
+
+		var rotateEvery time.Duration
+		if addRotateEvery != "" {
+			if addRotateVia == "" {
+				err := fmt.Errorf("--rotate-every requires --rotate-via")
+				output.Print(output.Error(err))
+				return err
+			}
+			var err error
+			if rotateEvery, err = parseInterval(addRotateEvery); err != nil {
+				err = fmt.Errorf("invalid --rotate-every: %w", err)
+				output.Print(output.Error(err))
+				return err
+			}
+		}
+
+		targets, err := parsePropagateTargets(name, addPropagateTo)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		labels, err := parseLabels(addLabels)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
 		}
 
 		params := daemon.AddParams{
-			Name:      name,
-			Value:     value,
-			RotateVia: addRotateVia,
+			Name:        name,
+			Value:       value,
+			RotateVia:   addRotateVia,
+			PropagateTo: targets,
+			Labels:      labels,
+			GenerateVia: addGenerateVia,
+			RevokeVia:   addRevokeVia,
+			RotateEvery: rotateEvery,
+		}
+		if addExpiresIn < 0 {
+			err := fmt.Errorf("--expires-in must be positive, got %s", addExpiresIn)
+			output.Print(output.Error(err))
+			return err
+		}
+		if addExpiresIn > 0 {
+			params.ExpiresAt = time.Now().Add(addExpiresIn)
 		}
 
 		resp, err := rpcCall(socketPath, daemon.MethodAdd, params)
@@ -101,12 +171,30 @@ var addCmd = &cobra.Command{
 				msg += fmt.Sprintf(" with rotation via: %s", addRotateVia)
 			}
 
+			data := map[string]interface{}{
+				"name":         name,
+				"rotate_via":   addRotateVia,
+				"propagate_to": targets,
+			}
+			if rotateEvery > 0 {
+				msg += fmt.Sprintf(" every %s", addRotateEvery)
+				data["rotate_every"] = rotateEvery.String()
+			}
+			if len(labels) > 0 {
+				data["labels"] = labels
+			}
+			if addGenerateVia != "" {
+				data["generate_via"] = addGenerateVia
+				data["revoke_via"] = addRevokeVia
+			}
+			if !params.ExpiresAt.IsZero() {
+				msg += fmt.Sprintf(" (expires in %s)", addExpiresIn)
+				data["expires_at"] = params.ExpiresAt.Format(time.RFC3339)
+			}
+
 			output.Print(output.Success(
 				msg,
-				map[string]interface{}{
-					"name":       name,
-					"rotate_via": addRotateVia,
-				},
+				data,
 				output.ActionsAfterAdd(name)...,
 			))
 		} else {
@@ -119,6 +207,76 @@ var addCmd = &cobra.Command{
 }
 
 func init() {
-	addCmd.Flags().StringVar(&addValue, "value", "", "Secret value (if not provided, will prompt or read from stdin)")
+	addCmd.Flags().StringVar(&addValue, "value", "", "Secret value; visible in shell history, so prefer stdin or the prompt")
 	addCmd.Flags().StringVar(&addRotateVia, "rotate-via", "", "Command to execute for automatic rotation")
+	addCmd.Flags().StringSliceVar(&addPropagateTo, "propagate-to", nil, "Managed env file to refresh after rotation, as PATH[:VAR] (repeatable)")
+	addCmd.Flags().StringVar(&addRotateEvery, "rotate-every", "", "Rotate automatically at this interval, e.g. 720h or 30d (requires --rotate-via)")
+	addCmd.Flags().StringArrayVar(&addLabels, "label", nil, "Label to tag the secret with, as KEY=VALUE (repeatable)")
+	addCmd.Flags().DurationVar(&addExpiresIn, "expires-in", 0, "Delete the secret after this duration, e.g. 24h (default: never)")
+	addCmd.Flags().StringVar(&addGenerateVia, "generate-via", "", "Command whose output is the value of each lease, for dynamic credentials")
+	addCmd.Flags().StringVar(&addRevokeVia, "revoke-via", "", "Command to revoke a generated value when its lease ends (requires --generate-via)")
+}
+
+// readSecretValue reads the value for name without it appearing in argv:
+// all of stdin when piped, or a prompt on the terminal with echo off. The
+// prompt goes to stderr to keep stdout parseable.
+func readSecretValue(name string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, maxValueSize+1))
+		if err != nil {
+			return "", fmt.Errorf("failed to read from stdin: %w", err)
+		}
+		if len(data) > maxValueSize {
+			return "", fmt.Errorf("secret value on stdin exceeds %d bytes", maxValueSize)
+		}
+		return string(data), nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Enter secret value for '%s': ", name)
+	value, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr) // Add newline after hidden input
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+	return string(value), nil
+}
+
+// parsePropagateTargets parses --propagate-to values of the form PATH[:VAR].
+// Paths are made absolute because the daemon may run in another directory.
+// VAR defaults to the secret's name without its namespace.
+func parsePropagateTargets(secretName string, specs []string) ([]types.EnvTarget, error) {
+	_, defaultVar := types.SplitRef(secretName)
+
+	targets := make([]types.EnvTarget, 0, len(specs))
+	for _, spec := range specs {
+		path, envVar := spec, defaultVar
+		if i := strings.LastIndex(spec, ":"); i > 0 && i < len(spec)-1 {
+			path, envVar = spec[:i], spec[i+1:]
+		}
+
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --propagate-to path %q: %w", path, err)
+		}
+		targets = append(targets, types.EnvTarget{Path: abs, Var: envVar})
+	}
+	return targets, nil
+}
+
+// parseLabels parses --label values of the form KEY=VALUE. VALUE may be
+// empty; KEY may not.
+func parseLabels(specs []string) (map[string]string, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --label %q: expected KEY=VALUE", spec)
+		}
+		labels[key] = strings.TrimSpace(value)
+	}
+	return labels, nil
 }
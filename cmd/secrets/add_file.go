@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	addFileRotateVia   string
+	addFilePropagateTo []string
+	addFileExpiresIn   time.Duration
+	addFileLabels      []string
+)
+
+var addFileCmd = &cobra.Command{
+	Use:   "add-file <name> <path>",
+	Short: "Add a file, such as a TLS key, as a secret",
+	Long: `Add the contents of a file to the store as a binary secret, for TLS keys,
+service-account JSON and other values that are not short strings. Any bytes
+are kept exactly; the value is stored base64-encoded.
+
+Leases of a binary secret return the base64 form, marked "binary": true.
+Use 'secrets get-file' to write the original file back out. Binary secrets
+are never inlined into env files: 'secrets files' writes their bytes, and
+--propagate-to writes them to a file in .secrets-files beside the env file
+and sets the variable to its path.
+
+Examples:
+  secrets add-file tls_key ./server.key
+  secrets add-file prod::gcp_sa ./service-account.json --label env=prod
+  secrets get-file tls_key ./server.key`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, path := args[0], args[1]
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to read file: %w", err)))
+			return err
+		}
+
+		targets, err := parsePropagateTargets(name, addFilePropagateTo)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		labels, err := parseLabels(addFileLabels)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		params := daemon.AddParams{
+			Name:        name,
+			Value:       store.EncodeBinary(content),
+			Binary:      true,
+			RotateVia:   addFileRotateVia,
+			PropagateTo: targets,
+			Labels:      labels,
+		}
+		if addFileExpiresIn < 0 {
+			err := fmt.Errorf("--expires-in must be positive, got %s", addFileExpiresIn)
+			output.Print(output.Error(err))
+			return err
+		}
+		if addFileExpiresIn > 0 {
+			params.ExpiresAt = time.Now().Add(addFileExpiresIn)
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodAdd, params)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be added.",
+					"To start it:\n  secrets serve &",
+					"secrets add-file --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to add secret: %w", err)))
+			return fmt.Errorf("failed to add secret: %w", err)
+		}
+
+		var result daemon.AddResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"name":   name,
+			"file":   path,
+			"bytes":  len(content),
+			"binary": true,
+		}
+		if addFileRotateVia != "" {
+			data["rotate_via"] = addFileRotateVia
+		}
+		if len(targets) > 0 {
+			data["propagate_to"] = targets
+		}
+		if len(labels) > 0 {
+			data["labels"] = labels
+		}
+		if !params.ExpiresAt.IsZero() {
+			data["expires_at"] = params.ExpiresAt.Format(time.RFC3339)
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Secret '%s' added from %s (%d bytes)", name, path, len(content)),
+			data,
+			output.Action{
+				Name:        "get-file",
+				Description: "Write the file back out",
+				Command:     fmt.Sprintf("secrets get-file %s <path>", name),
+			},
+			output.ActionStatus(),
+		))
+		return nil
+	},
+}
+
+func init() {
+	addFileCmd.Flags().StringVar(&addFileRotateVia, "rotate-via", "", "Command to execute for automatic rotation")
+	addFileCmd.Flags().StringSliceVar(&addFilePropagateTo, "propagate-to", nil, "Managed env file to point at the file after rotation, as PATH[:VAR] (repeatable)")
+	addFileCmd.Flags().StringArrayVar(&addFileLabels, "label", nil, "Label to tag the secret with, as KEY=VALUE (repeatable)")
+	addFileCmd.Flags().DurationVar(&addFileExpiresIn, "expires-in", 0, "Delete the secret after this duration, e.g. 24h (default: never)")
+}
@@ -3,13 +3,27 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/daemon"
 	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
 	"github.com/spf13/cobra"
 )
 
-var auditTail int
+var (
+	auditTail int
+	auditRole string
+
+	auditSince  string
+	auditUntil  string
+	auditAction string
+	auditSecret string
+	auditClient string
+	auditFormat string
+)
 
 var auditCmd = &cobra.Command{
 	Use:   "audit",
@@ -17,10 +31,59 @@ var auditCmd = &cobra.Command{
 	Long: `Display audit log entries showing all operations performed on secrets and leases.
 Use --tail to limit the number of entries shown.
 
-The response includes suggested filtering actions to help narrow down results.`,
+Use --role restricted to hide which secrets were touched (secret names and
+details are omitted) while keeping actions, timestamps and outcomes; useful
+when sharing audit data with consumers who shouldn't see secret names.
+
+Filter entries with --action, --secret and --client, and bound them in time
+with --since and --until. Times are RFC3339 timestamps, dates such as
+2024-01-02, or durations before now such as 2h or 7d. --tail then keeps the
+most recent matches.
+
+Use --format csv to export the entries as CSV instead of the usual response.
+
+The response includes suggested filtering actions to help narrow down results.
+
+Examples:
+  secrets audit --since 2h --action lease_acquire
+  secrets audit --secret github_token --since 2024-01-01 --until 2024-02-01
+  secrets audit --client agent-1 --tail 0 --format csv > audit.csv`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if auditFormat != "" && auditFormat != "json" && auditFormat != "csv" {
+			err := fmt.Errorf("invalid format: %s (must be json or csv)", auditFormat)
+			output.Print(output.Error(err))
+			return err
+		}
+		if auditFormat == "json" {
+			output.OutputFormat = string(output.ModeJSON)
+		}
+
 		params := daemon.AuditParams{
-			Tail: auditTail,
+			Tail:       auditTail,
+			Role:       auditRole,
+			Action:     auditAction,
+			SecretName: auditSecret,
+			ClientID:   auditClient,
+		}
+
+		now := time.Now()
+		if auditSince != "" {
+			since, err := audit.ParseTime(auditSince, now)
+			if err != nil {
+				err = fmt.Errorf("invalid --since: %w", err)
+				output.Print(output.Error(err))
+				return err
+			}
+			params.Since = since
+		}
+		if auditUntil != "" {
+			until, err := audit.ParseTime(auditUntil, now)
+			if err != nil {
+				err = fmt.Errorf("invalid --until: %w", err)
+				output.Print(output.Error(err))
+				return err
+			}
+			params.Until = until
 		}
 
 		resp, err := rpcCall(socketPath, daemon.MethodAudit, params)
@@ -40,6 +103,30 @@ The response includes suggested filtering actions to help narrow down results.`,
 			return nil
 		}
 
+		// CSV replaces the standard response entirely
+		if auditFormat == "csv" {
+			entries := make([]*types.AuditEntry, len(result.Entries))
+			for i, e := range result.Entries {
+				entries[i] = &types.AuditEntry{
+					Timestamp:  e.Timestamp,
+					Action:     types.Action(e.Action),
+					SecretName: e.SecretName,
+					ClientID:   e.ClientID,
+					LeaseID:    e.LeaseID,
+					Details:    e.Details,
+					Success:    e.Success,
+					RemoteAddr: e.RemoteAddr,
+					ClientCN:   e.ClientCN,
+					Trigger:    types.RotationTrigger(e.Trigger),
+				}
+			}
+			if err := audit.WriteCSV(os.Stdout, entries); err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to write csv: %w", err)))
+				return err
+			}
+			return nil
+		}
+
 		if len(result.Entries) == 0 {
 			output.Print(output.Success("No audit entries found", nil))
 			return nil
@@ -56,12 +143,16 @@ The response includes suggested filtering actions to help narrow down results.`,
 				"client_id":   entry.ClientID,
 				"details":     entry.Details,
 			}
+			if entry.Trigger != "" {
+				entries[i]["trigger"] = entry.Trigger
+			}
 		}
 
 		auditData := map[string]interface{}{
 			"entries":       entries,
 			"total_shown":   len(entries),
 			"tail_limit":    auditTail,
+			"role":          auditRole,
 		}
 
 		// Suggest filtering actions
@@ -76,11 +167,30 @@ The response includes suggested filtering actions to help narrow down results.`,
 			output.ActionStatus(),
 		}
 
-		output.Print(output.Success("Audit log retrieved", auditData, actions...))
+		rows := make([][]string, len(result.Entries))
+		for i, entry := range result.Entries {
+			rows[i] = []string{
+				formatTableTime(entry.Timestamp),
+				entry.Action,
+				formatBool(entry.Success),
+				entry.SecretName,
+				entry.ClientID,
+				entry.Details,
+			}
+		}
+		output.Print(output.Success("Audit log retrieved", auditData, actions...).
+			WithTable([]string{"TIME", "ACTION", "OK", "SECRET", "CLIENT", "DETAILS"}, rows))
 		return nil
 	},
 }
 
 func init() {
 	auditCmd.Flags().IntVar(&auditTail, "tail", 50, "Number of recent entries to show (0 = all)")
+	auditCmd.Flags().StringVar(&auditRole, "role", "full", "Viewer role: full or restricted (omits secret names)")
+	auditCmd.Flags().StringVar(&auditSince, "since", "", "Only entries at or after this time (RFC3339, date, or duration ago like 2h)")
+	auditCmd.Flags().StringVar(&auditUntil, "until", "", "Only entries at or before this time (RFC3339, date, or duration ago like 2h)")
+	auditCmd.Flags().StringVar(&auditAction, "action", "", "Only entries with this action, e.g. lease_acquire")
+	auditCmd.Flags().StringVar(&auditSecret, "secret", "", "Only entries for this secret")
+	auditCmd.Flags().StringVar(&auditClient, "client", "", "Only entries for this client ID")
+	auditCmd.Flags().StringVar(&auditFormat, "format", "", "Export format: json or csv (default: standard output format)")
 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var restoreIdentity string
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <file>",
+	Short: "Write an encrypted backup of the store",
+	Long: `Write a backup of the encrypted store to a file. Secrets are copied
+still encrypted, along with a manifest recording the identity they are
+encrypted to, so the backup is only useful together with that identity.
+Keep a copy of your identity file somewhere safe.
+
+Examples:
+  secrets backup ~/backups/agent-secrets.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := loadLocalStore()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		path := args[0]
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to create backup: %w", err)))
+			return fmt.Errorf("failed to create backup: %w", err)
+		}
+		if err := st.ExportBackup(f); err != nil {
+			f.Close()
+			output.Print(output.Error(err))
+			return err
+		}
+		if err := f.Close(); err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to write backup: %w", err)))
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Backup written to %s", path),
+			map[string]interface{}{
+				"path": path,
+			},
+			output.Action{
+				Name:        "restore",
+				Description: "Restore the store from this backup",
+				Command:     "secrets restore " + path,
+			},
+		))
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file>",
+	Short: "Restore the store from a backup",
+	Long: `Replace the secrets in the store with those in a backup written by
+"secrets backup". The daemon must be stopped first.
+
+A backup is only restored as-is if it is encrypted to the store's current
+identity. To restore into a store with a different identity, for example
+after running "secrets init" on a new machine, pass the identity the backup
+was made with; the secrets are then re-encrypted to the current identity.
+
+Examples:
+  secrets restore ~/backups/agent-secrets.json
+  secrets restore ~/backups/agent-secrets.json --identity ~/old-identity.age`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// A running daemon would overwrite the restored file on its next save
+		if daemonRunning() {
+			userErr := types.NewUserError(
+				"The daemon is running",
+				"A running daemon keeps the secrets in memory and would overwrite the restored store.",
+				"Stop the daemon, restore, then start it again:\n  secrets restore "+args[0]+"\n  secrets serve &",
+				"secrets restore --help",
+			).WithContext("Socket path", socketPath)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+
+		st, err := loadLocalStore()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		path := args[0]
+		f, err := os.Open(path)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to open backup: %w", err)))
+			return fmt.Errorf("failed to open backup: %w", err)
+		}
+		defer f.Close()
+
+		if restoreIdentity != "" {
+			identity, _, err := store.LoadIdentityFile(restoreIdentity)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to load identity: %w", err)))
+				return fmt.Errorf("failed to load identity: %w", err)
+			}
+			err = st.ImportBackupWithIdentity(f, identity)
+		} else {
+			err = st.ImportBackup(f)
+		}
+		if err != nil {
+			if errors.Is(err, types.ErrRecipientMismatch) {
+				userErr := types.NewUserError(
+					"Backup is for a different identity",
+					"The backup was encrypted to another identity than this store's, so it cannot be restored as-is.",
+					"Pass the identity the backup was made with:\n  secrets restore "+path+" --identity <identity-file>",
+					"secrets restore --help",
+				).WithContext("Backup", path)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(err))
+			return err
+		}
+
+		secrets, err := st.List()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Restored %d secrets from %s", len(secrets), path),
+			map[string]interface{}{
+				"path":    path,
+				"secrets": len(secrets),
+			},
+		))
+		return nil
+	},
+}
+
+// daemonRunning reports whether a daemon answers on the socket. Commands
+// that rewrite the store file directly refuse to run while it does.
+func daemonRunning() bool {
+	_, err := rpcCall(socketPath, daemon.MethodHealth, daemon.HealthParams{})
+	return err == nil
+}
+
+// loadLocalStore opens the store directly rather than through the daemon.
+func loadLocalStore() (*store.Store, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	st := store.NewWithOptions(cfg, skipPermissionCheck)
+	if err := st.Load(); err != nil {
+		if errors.Is(err, types.ErrIdentityNotFound) {
+			return nil, types.NewUserError(
+				"Store not initialized",
+				"No identity was found, so the store has not been created yet.",
+				"Initialize the store first:\n  secrets init",
+				"secrets init --help",
+			).WithContext("Identity path", cfg.IdentityPath)
+		}
+		return nil, fmt.Errorf("failed to load store: %w", err)
+	}
+	return st, nil
+}
+
+func init() {
+	restoreCmd.Flags().StringVar(&restoreIdentity, "identity", "", "Identity file the backup was encrypted with, if not the store's")
+}
@@ -23,7 +23,9 @@ var (
 var cleanupCmd = &cobra.Command{
 	Use:   "cleanup",
 	Short: "Clean up expired .env files",
-	Long: `Scan directories for expired .env files and remove them.
+	Long: `Scan directories for expired .env files and remove them. Directories
+of secret files written by "secrets files" are removed once their
+manifest expires.
 
 By default, performs a one-time check of the current directory.
 Use --watch to run continuously.
@@ -22,7 +22,7 @@ func rpcCall(socketPath, method string, params interface{}) (*types.RPCResponse,
 	defer cancel()
 
 	if socketPath == "" {
-		cfg, err := config.Load()
+		cfg, err := loadConfig()
 		if err != nil {
 			return nil, fmt.Errorf("failed to load config: %w", err)
 		}
@@ -81,6 +81,47 @@ func rpcCall(socketPath, method string, params interface{}) (*types.RPCResponse,
 	return &resp, nil
 }
 
+// loadConfig loads the daemon config, honoring the --config override and
+// then the selected profile.
+func loadConfig() (*config.Config, error) {
+	if configPath != "" {
+		return config.LoadFrom(configPath)
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		return config.LoadProfile(profile)
+	}
+	return config.Load()
+}
+
+// defaultConfig returns the defaults for the selected profile, or the
+// store without a profile.
+func defaultConfig() (*config.Config, error) {
+	profile, err := currentProfile()
+	if err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		return config.DefaultProfileConfig(profile)
+	}
+	return config.DefaultConfig(), nil
+}
+
+// currentProfile returns the --profile flag, or else the profile recorded
+// by 'secrets profile use'. The empty string means no profile.
+func currentProfile() (string, error) {
+	if profileName == config.DefaultProfile {
+		return "", nil
+	}
+	if profileName != "" {
+		return profileName, nil
+	}
+	return config.ActiveProfile()
+}
+
 // isTimeoutError checks if the error is a network timeout error
 func isTimeoutError(err error) bool {
 	if netErr, ok := err.(net.Error); ok {
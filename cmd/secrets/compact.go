@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Reclaim space in the encrypted store",
+	Long: `Compact the encrypted store by purging stale entries and rewriting
+the secrets file. Live secrets are left unchanged.
+
+Examples:
+  secrets compact`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodCompact, daemon.CompactParams{})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, the store cannot be compacted.",
+					"To start it:\n  secrets serve &",
+					"secrets compact --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to compact store: %w", err)))
+			return fmt.Errorf("failed to compact store: %w", err)
+		}
+
+		var result daemon.CompactResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"reclaimed": result.Reclaimed,
+		}
+		output.Print(output.Success(fmt.Sprintf("Store compacted (%d entries reclaimed)", result.Reclaimed), data))
+		return nil
+	},
+}
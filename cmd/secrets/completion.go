@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Generate a shell completion script",
+	Long: `Generate a completion script for bash, zsh or fish. Besides commands and
+flags, secret names are completed for lease, get-file, rotate and rename
+while the daemon is running.
+
+Examples:
+  source <(secrets completion bash)                     # Current bash session
+  secrets completion bash > /etc/bash_completion.d/secrets
+  secrets completion zsh > "${fpath[1]}/_secrets"       # Then restart zsh
+  secrets completion fish > ~/.config/fish/completions/secrets.fish`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		default:
+			return fmt.Errorf("unsupported shell: %s (must be bash, zsh, or fish)", args[0])
+		}
+	},
+}
+
+// completeSecretNames completes the first argument with the names of the
+// secrets in the daemon. If the daemon cannot be reached, nothing is
+// completed rather than reporting an error in the middle of the prompt.
+func completeSecretNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	resp, err := rpcCall(socketPath, daemon.MethodList, daemon.ListParams{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var result daemon.ListResult
+	if err := decodeResult(resp, &result); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, s := range result.Secrets {
+		if strings.HasPrefix(s.Name, toComplete) {
+			names = append(names, s.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	leaseCmd.ValidArgsFunction = completeSecretNames
+	rotateCmd.ValidArgsFunction = completeSecretNames
+	renameCmd.ValidArgsFunction = completeSecretNames
+	getFileCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		// The second argument is the path to write to
+		if len(args) == 1 {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return completeSecretNames(cmd, args, toComplete)
+	}
+}
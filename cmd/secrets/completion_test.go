@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// serveRPC runs a fake daemon on a Unix socket; handle returns the result
+// for each request, or nil for an unknown method.
+func serveRPC(t *testing.T, handle func(req types.RPCRequest) interface{}) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "secrets-cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	// Socket paths are limited to ~100 bytes, so avoid the long t.TempDir()
+	path := filepath.Join(dir, "s.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			var req types.RPCRequest
+			if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err == nil {
+				resp := types.RPCResponse{JSONRPC: "2.0", ID: req.ID}
+				if result := handle(req); result != nil {
+					resp.Result = result
+				} else {
+					resp.Error = &types.RPCError{Code: types.RPCMethodNotFound, Message: "method not found"}
+				}
+				_ = json.NewEncoder(conn).Encode(resp)
+			}
+			conn.Close()
+		}
+	}()
+
+	return path
+}
+
+// serveList answers secrets.list requests with names.
+func serveList(t *testing.T, names ...string) string {
+	t.Helper()
+
+	var result daemon.ListResult
+	for _, name := range names {
+		result.Secrets = append(result.Secrets, daemon.SecretMetadata{Name: name})
+	}
+	return serveRPC(t, func(req types.RPCRequest) interface{} {
+		if req.Method != daemon.MethodList {
+			return nil
+		}
+		return result
+	})
+}
+
+// withSocket points the CLI at path for the duration of the test.
+func withSocket(t *testing.T, path string) {
+	t.Helper()
+	old := socketPath
+	socketPath = path
+	t.Cleanup(func() { socketPath = old })
+}
+
+func TestCompleteSecretNames(t *testing.T) {
+	withSocket(t, serveList(t, "openai_key", "github_token", "gh/deploy_key"))
+
+	names, directive := leaseCmd.ValidArgsFunction(leaseCmd, nil, "")
+	if want := []string{"gh/deploy_key", "github_token", "openai_key"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("directive = %v, want NoFileComp", directive)
+	}
+
+	names, _ = rotateCmd.ValidArgsFunction(rotateCmd, nil, "g")
+	if want := []string{"gh/deploy_key", "github_token"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names with prefix = %v, want %v", names, want)
+	}
+
+	// Only the first argument is a secret name
+	if names, _ := renameCmd.ValidArgsFunction(renameCmd, []string{"openai_key"}, ""); len(names) != 0 {
+		t.Errorf("expected no completions for the new name, got %v", names)
+	}
+	names, directive = getFileCmd.ValidArgsFunction(getFileCmd, []string{"openai_key"}, "")
+	if len(names) != 0 || directive != cobra.ShellCompDirectiveDefault {
+		t.Errorf("expected file completion for the path, got %v, %v", names, directive)
+	}
+}
+
+func TestCompleteSecretNames_NoDaemon(t *testing.T) {
+	withSocket(t, filepath.Join(t.TempDir(), "missing.sock"))
+
+	names, directive := leaseCmd.ValidArgsFunction(leaseCmd, nil, "")
+	if len(names) != 0 {
+		t.Errorf("expected no completions without a daemon, got %v", names)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("directive = %v, want NoFileComp", directive)
+	}
+}
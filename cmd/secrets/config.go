@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/project"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configProjectDir string
+	configFile       string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect agent-secrets configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the daemon and project configuration",
+	Long: `Validate the daemon config (--file, --config, or the active profile's) and,
+if present, the project's .secrets.json merged with .secrets.local.json.
+
+Every invalid field is reported, not just the first, with a suggested fix.
+Settings that are valid but unusual, such as a max_lease_ttl over 7 days,
+are reported as warnings. The command exits 1 if any file is invalid, so
+it can be used as a CI gate; use --output json for a machine-readable
+report.
+
+Examples:
+  secrets config validate
+  secrets config validate --project-dir ./app
+  secrets config validate --file ./ci/config.json --output json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		checked := []string{}
+		errs := []map[string]string{}
+		warnings := []map[string]string{}
+		var problems []string
+		report := func(source, file string, err error) {
+			var daemonErrs config.ValidationErrors
+			var projectErrs project.ValidationErrors
+			switch {
+			case errors.As(err, &daemonErrs):
+				for _, e := range daemonErrs {
+					errs = append(errs, map[string]string{"source": source, "file": file, "field": e.Field, "message": e.Message, "suggestion": e.Suggestion()})
+					problems = append(problems, types.NewUserError(
+						fmt.Sprintf("Invalid %s: %s", e.Field, e.Message),
+						"The daemon will not start until this is fixed.",
+						e.Suggestion(),
+						"",
+					).WithContext("File", file).Error())
+				}
+			case errors.As(err, &projectErrs):
+				for _, e := range projectErrs {
+					errs = append(errs, map[string]string{"source": source, "file": file, "field": e.Field, "message": e.Message})
+					problems = append(problems, types.NewUserError(
+						fmt.Sprintf("Invalid %s: %s", e.Field, e.Message),
+						"Project commands such as 'secrets env' fail until this is fixed.",
+						"",
+						"",
+					).WithContext("File", file).Error())
+				}
+			default:
+				// The file could not be read or parsed
+				errs = append(errs, map[string]string{"source": source, "file": file, "message": err.Error()})
+				problems = append(problems, types.NewUserError(
+					err.Error(),
+					"",
+					"Check that the file exists and is valid JSON; durations are in nanoseconds.",
+					"",
+				).WithContext("File", file).Error())
+			}
+		}
+
+		defaults, err := defaultConfig()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		daemonPath := configPath
+		if configFile != "" {
+			daemonPath = configFile
+		}
+		if daemonPath == "" {
+			daemonPath = filepath.Join(defaults.Directory, config.DefaultConfigFile)
+		}
+		cfg, err := config.LoadFrom(daemonPath)
+		if os.IsNotExist(err) && daemonPath != configPath && daemonPath != configFile {
+			// No config file; the daemon runs with the defaults
+			cfg, err = defaults, nil
+		} else {
+			checked = append(checked, daemonPath)
+		}
+		if err == nil {
+			for _, w := range cfg.Warnings() {
+				warnings = append(warnings, map[string]string{"source": "daemon", "file": daemonPath, "field": w.Field, "message": w.Message})
+			}
+			err = cfg.Validate()
+		}
+		if err != nil {
+			report("daemon", daemonPath, err)
+		}
+
+		projectPath := filepath.Join(configProjectDir, project.DefaultProjectConfigFile)
+		if _, err := os.Stat(projectPath); err == nil {
+			checked = append(checked, projectPath)
+			if _, err := project.LoadWithOverrides(configProjectDir); err != nil {
+				report("project", projectPath, err)
+			}
+		}
+
+		data := map[string]interface{}{
+			"valid":    len(errs) == 0,
+			"checked":  checked,
+			"errors":   errs,
+			"warnings": warnings,
+		}
+		if len(errs) > 0 {
+			resp := output.ErrorMsg(fmt.Sprintf("%d configuration errors\n\n%s", len(errs), strings.Join(problems, "\n")))
+			resp.Data = data
+			output.Print(resp)
+
+			// The errors were already reported; this is not a usage error
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d configuration errors", len(errs))
+		}
+
+		resp := output.Success("Configuration is valid", data)
+		if len(warnings) > 0 {
+			resp.Message = fmt.Sprintf("Configuration is valid, with %d warnings", len(warnings))
+			rows := make([][]string, len(warnings))
+			for i, w := range warnings {
+				rows[i] = []string{w["field"], w["message"]}
+			}
+			resp = resp.WithTable([]string{"FIELD", "WARNING"}, rows)
+		}
+		output.Print(resp)
+		return nil
+	},
+}
+
+func init() {
+	configValidateCmd.Flags().StringVar(&configProjectDir, "project-dir", ".", "Directory containing .secrets.json")
+	configValidateCmd.Flags().StringVar(&configFile, "file", "", "Daemon config file to validate (default: --config, or the active profile's)")
+	configCmd.AddCommand(configValidateCmd)
+}
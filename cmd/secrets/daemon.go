@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var daemonInstallForce bool
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run the daemon as a login service",
+	Long: `Install or remove a per-user service that starts the daemon at login: a
+systemd user unit on Linux, or a launchd agent on macOS. The service runs
+'secrets serve' from the current executable with the configured socket
+path, and with --profile, that profile's daemon.
+
+Examples:
+  secrets daemon install
+  secrets --profile work daemon install
+  secrets daemon install --force        # Replace an existing unit
+  secrets daemon uninstall`,
+}
+
+var daemonInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Write a service unit that starts the daemon at login",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		unit, err := daemonUnit()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		path, err := service.Install(runtime.GOOS, unit, daemonInstallForce)
+		if errors.Is(err, service.ErrExists) {
+			output.Print(output.ErrorMsg(
+				fmt.Sprintf("service unit already exists: %s (use --force to overwrite)", path),
+				output.Action{
+					Name:        "force_install",
+					Description: "Replace the existing unit",
+					Command:     "secrets daemon install --force",
+				},
+			))
+			// Already reported; this is not a usage error
+			cmd.SilenceUsage = true
+			return err
+		}
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to install service: %w", err)))
+			return err
+		}
+
+		enable := service.EnableCommands(runtime.GOOS, unit.Name, path)
+		output.Print(output.Success(
+			fmt.Sprintf("Installed %s; to start it now and at every login, run:\n  %s", path, strings.Join(enable, "\n  ")),
+			map[string]interface{}{
+				"unit_file":  path,
+				"executable": unit.Executable,
+				"socket":     unit.SocketPath,
+				"commands":   enable,
+			},
+			output.Action{
+				Name:        "enable",
+				Description: "Start the service now and at login",
+				Command:     strings.Join(enable, " && "),
+			},
+		))
+		return nil
+	},
+}
+
+var daemonUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the daemon's service unit",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, err := daemonServiceName()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		path, removed, err := service.Uninstall(runtime.GOOS, name)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to uninstall service: %w", err)))
+			return err
+		}
+		if !removed {
+			output.Print(output.Success(
+				fmt.Sprintf("No service unit at %s", path),
+				map[string]interface{}{"unit_file": path, "removed": false},
+			))
+			return nil
+		}
+
+		// The unit file is gone, but a loaded service keeps running
+		disable := service.DisableCommands(runtime.GOOS, name, path)
+		output.Print(output.Success(
+			fmt.Sprintf("Removed %s; if the service is running, stop it with:\n  %s", path, strings.Join(disable, "\n  ")),
+			map[string]interface{}{
+				"unit_file": path,
+				"removed":   true,
+				"commands":  disable,
+			},
+		))
+		return nil
+	},
+}
+
+// daemonServiceName names the service for the selected profile, so each
+// profile's daemon gets its own unit.
+func daemonServiceName() (string, error) {
+	profile, err := currentProfile()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" {
+		return service.DefaultName, nil
+	}
+	return service.DefaultName + "-" + profile, nil
+}
+
+// daemonUnit describes the service for the current executable and config.
+func daemonUnit() (service.Unit, error) {
+	name, err := daemonServiceName()
+	if err != nil {
+		return service.Unit{}, err
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return service.Unit{}, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return service.Unit{}, fmt.Errorf("failed to find the secrets executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	// The service does not inherit this shell's flags or environment, so
+	// pass on whatever selected the config
+	args := []string{"serve"}
+	env := map[string]string{}
+	if dir := os.Getenv(config.EnvDir); dir != "" {
+		env[config.EnvDir] = dir
+	}
+	profile, err := currentProfile()
+	if err != nil {
+		return service.Unit{}, err
+	}
+	switch {
+	case configPath != "":
+		abs, err := filepath.Abs(configPath)
+		if err != nil {
+			return service.Unit{}, fmt.Errorf("failed to resolve --config: %w", err)
+		}
+		args = append(args, "--config", abs)
+	case profile != "":
+		args = append(args, "--profile", profile)
+	}
+	if configPath != "" || profile == "" {
+		// Profiles ignore the socket and identity overrides
+		env[config.EnvSocket] = cfg.SocketPath
+		if identity := os.Getenv(config.EnvIdentity); identity != "" {
+			env[config.EnvIdentity] = identity
+		}
+	}
+
+	return service.Unit{
+		Name:       name,
+		Executable: exe,
+		Args:       args,
+		Env:        env,
+		SocketPath: cfg.SocketPath,
+		LogFile:    filepath.Join(cfg.Directory, "daemon.log"),
+	}, nil
+}
+
+func init() {
+	daemonInstallCmd.Flags().BoolVar(&daemonInstallForce, "force", false, "Overwrite an existing service unit")
+
+	daemonCmd.AddCommand(daemonInstallCmd)
+	daemonCmd.AddCommand(daemonUninstallCmd)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var dedupeList bool
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe --list",
+	Short: "Find secrets that hold the same value",
+	Long: `Find secrets stored under several names with the same value. Such
+copies drift apart when only one of them is rotated.
+
+With --list, each group of names sharing a value is printed. Values are
+compared by fingerprint inside the daemon and are never shown. Consolidate
+duplicates by hand so that each value lives under one name.
+
+Examples:
+  secrets dedupe --list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodDuplicates, daemon.DuplicatesParams{})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be compared.",
+					"To start it:\n  secrets serve &",
+					"secrets dedupe --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to find duplicates: %w", err)))
+			return fmt.Errorf("failed to find duplicates: %w", err)
+		}
+
+		var result daemon.DuplicatesResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"groups": result.Groups,
+			"count":  len(result.Groups),
+		}
+		if len(result.Groups) == 0 {
+			output.Print(output.Success("No duplicate secrets found", data))
+			return nil
+		}
+		output.Print(output.Success(fmt.Sprintf("%d groups of secrets share a value", len(result.Groups)), data))
+		return nil
+	},
+}
+
+func init() {
+	dedupeCmd.Flags().BoolVar(&dedupeList, "list", false, "List groups of secrets that share a value")
+	_ = dedupeCmd.MarkFlagRequired("list")
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joelhooks/agent-secrets/internal/envfile"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/project"
+	"github.com/spf13/cobra"
+)
+
+var diffNoCache bool
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show how the env file differs from its source",
+	Long: `Pull from the sources configured in .secrets.json and compare the result
+with the existing env file (default: .env.local).
+
+Keys are reported as added (in the source but not the file), removed (in the
+file but no longer in the source) or changed. Values are never shown. Vars in
+the user section kept by 'secrets env --merge' are listed as manual and not
+compared. Nothing is written; run 'secrets env --force' to apply the changes.
+
+Examples:
+  secrets diff                  # Compare .env.local with the source
+  secrets diff --no-cache       # Always fetch fresh from the source
+  secrets diff --output json | jq .data.changed`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Find project configuration
+		cfg, projectDir, err := project.FindProjectConfig()
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to find project config: %w", err)))
+			return err
+		}
+
+		envFilePath := filepath.Join(projectDir, cfg.GetEnvFile())
+		current, err := envfile.Read(envFilePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			output.Print(output.Error(fmt.Errorf("failed to read env file: %w", err)))
+			return err
+		}
+		exists := err == nil
+
+		if diffNoCache {
+			purgeCache()
+		}
+		secrets, _, err := pullSources(cfg, projectDir, !diffNoCache)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		source := sourceHeader(cfg)
+
+		d := envfile.Compare(current, secrets)
+		data := map[string]interface{}{
+			"source":    source,
+			"project":   cfg.Project,
+			"scope":     cfg.Scope,
+			"env_file":  envFilePath,
+			"exists":    exists,
+			"added":     d.Added,
+			"removed":   d.Removed,
+			"changed":   d.Changed,
+			"unchanged": d.Unchanged,
+		}
+		if len(d.Manual) > 0 {
+			data["manual"] = d.Manual
+		}
+
+		rows := make([][]string, 0, len(d.Added)+len(d.Removed)+len(d.Changed)+len(d.Manual))
+		for _, key := range d.Added {
+			rows = append(rows, []string{key, "added"})
+		}
+		for _, key := range d.Removed {
+			rows = append(rows, []string{key, "removed"})
+		}
+		for _, key := range d.Changed {
+			rows = append(rows, []string{key, "changed"})
+		}
+		for _, key := range d.Manual {
+			rows = append(rows, []string{key, "manual"})
+		}
+
+		if d.Empty() {
+			output.Print(output.Success(
+				fmt.Sprintf("%s is up to date with %s (%d vars)", envFilePath, source, d.Unchanged),
+				data,
+			).WithTable([]string{"KEY", "CHANGE"}, rows))
+			return nil
+		}
+
+		sync := output.Action{
+			Name:        "sync",
+			Description: "Write the env file from the source",
+			Command:     "secrets env",
+		}
+		if exists {
+			sync = output.ActionEnvForce()
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("%s differs from %s: %d added, %d removed, %d changed", envFilePath, source, len(d.Added), len(d.Removed), len(d.Changed)),
+			data,
+			sync,
+			output.Action{
+				Name:        "merge_sync",
+				Description: "Update the env file, keeping manual vars",
+				Command:     "secrets env --merge",
+			},
+		).WithTable([]string{"KEY", "CHANGE"}, rows))
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffNoCache, "no-cache", false, "Clear the adapter cache and fetch from the source")
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/joelhooks/agent-secrets/internal/envfile"
@@ -13,9 +16,14 @@ import (
 )
 
 var (
-	envForce  bool
-	envTTL    string
-	envDryRun bool
+	envForce   bool
+	envTTL     string
+	envDryRun  bool
+	envNoCache bool
+	envFormat  string
+	envMerge   bool
+	envDirenv  bool
+	envWatch   bool
 )
 
 var envCmd = &cobra.Command{
@@ -27,15 +35,69 @@ Reads .secrets.json from the current directory (or parent directories),
 fetches secrets from the configured source (e.g., Vercel), and writes
 them to .env.local with a time-to-live (TTL) expiration.
 
+If .secrets.json lists several "sources", each is pulled in order and the
+results are merged, with later sources overriding earlier ones. The env file
+then records which source each variable came from.
+
+A .secrets.local.json beside .secrets.json (keep it gitignored) overrides
+"scope", "ttl" and "env_file" for your checkout, e.g.
+  {"scope": "development", "ttl": "15m"}
+
 The .env.local file includes metadata headers for TTL tracking and
 will automatically expire after the configured duration.
 
+Pulls are cached briefly (adapter_cache_ttl in the daemon config, default
+60s) so a --dry-run followed by a real sync only contacts the source once.
+
+Use --format to print the variables to stdout instead of writing the env
+file: "json" prints a {"KEY": "value"} object, and "shell" prints export
+lines for eval. The default, "dotenv", writes .env.local as above.
+
+With --merge, an existing env file is updated rather than replaced: pulled
+vars overwrite the managed ones, and managed vars that were not pulled are
+kept. Vars below the "# secrets-user:" line are yours; merges never touch
+them, even if the source has a var of the same name.
+
+With --direnv, a "dotenv .env.local" line is also added to the project's
+.envrc (created if absent) so direnv loads the env file automatically. A
+comment above it records when the env file expires; repeat runs update the
+comment instead of adding another line.
+
+With --watch, secrets env keeps running after the write: shortly before the
+TTL (--ttl or the config's) runs out, it pulls again and rewrites the env
+file, until interrupted with Ctrl-C. A failed pull is retried while the
+current file is still valid.
+
 Examples:
   secrets env                           # Sync with config defaults
   secrets env --force                   # Overwrite existing .env.local
+  secrets env --merge                   # Update, keeping manual vars
+  secrets env --direnv                  # Also load it from .envrc
+  secrets env --watch --ttl 30m         # Refresh until interrupted
   secrets env --ttl 2h                  # Override TTL to 2 hours
-  secrets env --dry-run                 # Preview without writing`,
+  secrets env --dry-run                 # Preview without writing
+  secrets env --no-cache                # Always fetch fresh from the source
+  secrets env --format json > vars.json # Print variables as JSON
+  eval "$(secrets env --format shell)"  # Export into the current shell`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		switch envFormat {
+		case "dotenv", "json", "shell":
+		default:
+			err := fmt.Errorf("invalid format: %s (must be dotenv, json, or shell)", envFormat)
+			output.Print(output.Error(err))
+			return err
+		}
+		// json and shell print to stdout rather than writing the env file
+		toStdout := envFormat != "dotenv"
+		if envWatch && (toStdout || envDryRun) {
+			err := fmt.Errorf("--watch rewrites the env file, so it cannot be combined with --dry-run or --format %s", envFormat)
+			if !toStdout {
+				err = fmt.Errorf("--watch rewrites the env file, so it cannot be combined with --dry-run")
+			}
+			output.Print(output.Error(err))
+			return err
+		}
+
 		// Find project configuration
 		cfg, projectDir, err := project.FindProjectConfig()
 		if err != nil {
@@ -54,7 +116,7 @@ Examples:
 		envFilePath := filepath.Join(projectDir, cfg.GetEnvFile())
 
 		// Check if env file exists and handle --force
-		if !envForce && !envDryRun {
+		if !envForce && !envMerge && !envDryRun && !toStdout {
 			if _, err := os.Stat(envFilePath); err == nil {
 				output.Print(output.ErrorMsg(
 					fmt.Sprintf("env file already exists: %s (use --force to overwrite)", envFilePath),
@@ -63,6 +125,11 @@ Examples:
 						Description: "Overwrite existing env file",
 						Command:     "secrets env --force",
 					},
+					output.Action{
+						Name:        "merge_sync",
+						Description: "Update the env file, keeping manual vars",
+						Command:     "secrets env --merge",
+					},
 					output.Action{
 						Name:        "check_expiry",
 						Description: "Check if env file is expired",
@@ -73,19 +140,17 @@ Examples:
 			}
 		}
 
-		// Get adapter based on source
-		adapter, err := getAdapter(cfg.Source)
-		if err != nil {
-			output.Print(output.Error(fmt.Errorf("failed to get adapter: %w", err)))
-			return err
+		if envNoCache {
+			purgeCache()
 		}
 
-		// Pull secrets from source
-		secrets, err := adapter.Pull(cfg.Project, cfg.Scope)
+		// Pull and merge secrets from every configured source
+		secrets, provenance, err := pullSources(cfg, projectDir, !envNoCache)
 		if err != nil {
-			output.Print(output.Error(fmt.Errorf("failed to pull secrets: %w", err)))
+			output.Print(output.Error(err))
 			return err
 		}
+		source := sourceHeader(cfg)
 
 		// Check for required vars
 		if len(cfg.RequiredVars) > 0 {
@@ -104,7 +169,7 @@ Examples:
 		// Dry-run: show what would be written
 		if envDryRun {
 			data := map[string]interface{}{
-				"source":      cfg.Source,
+				"source":      source,
 				"project":     cfg.Project,
 				"scope":       cfg.Scope,
 				"ttl":         ttl.String(),
@@ -113,9 +178,12 @@ Examples:
 				"vars":        getVarNames(secrets),
 				"would_write": true,
 			}
+			if len(cfg.Sources) > 0 {
+				data["provenance"] = provenance
+			}
 
 			output.Print(output.Success(
-				fmt.Sprintf("Would sync %d vars from %s (dry-run)", len(secrets), cfg.Source),
+				fmt.Sprintf("Would sync %d vars from %s (dry-run)", len(secrets), source),
 				data,
 				output.Action{
 					Name:        "sync",
@@ -126,16 +194,52 @@ Examples:
 			return nil
 		}
 
-		// Write to env file with TTL
-		if err := envfile.WriteWithTTL(envFilePath, secrets, ttl, cfg.Source); err != nil {
+		switch envFormat {
+		case "json":
+			data, err := envfile.FormatJSON(secrets)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to format vars: %w", err)))
+				return err
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		case "shell":
+			exports, err := envfile.FormatShell(secrets)
+			if err != nil {
+				output.Print(output.Error(err))
+				return err
+			}
+			_, err = fmt.Print(exports)
+			return err
+		}
+
+		// Write to env file with TTL; per-var provenance only matters when
+		// more than one source contributed
+		if len(cfg.Sources) == 0 {
+			provenance = nil
+		}
+		write := envfile.WriteWithProvenance
+		if envMerge {
+			write = envfile.WriteMerged
+		}
+		if err := write(envFilePath, secrets, provenance, ttl, source); err != nil {
 			output.Print(output.Error(fmt.Errorf("failed to write env file: %w", err)))
 			return err
 		}
 
 		// Success response
 		expiresAt := time.Now().Add(ttl)
+
+		var envrcPath string
+		if envDirenv {
+			envrcPath = filepath.Join(projectDir, ".envrc")
+			if err := envfile.WriteDirenv(envrcPath, cfg.GetEnvFile(), expiresAt); err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to update .envrc: %w", err)))
+				return err
+			}
+		}
 		data := map[string]interface{}{
-			"source":     cfg.Source,
+			"source":     source,
 			"project":    cfg.Project,
 			"scope":      cfg.Scope,
 			"ttl":        ttl.String(),
@@ -143,6 +247,12 @@ Examples:
 			"env_file":   envFilePath,
 			"var_count":  len(secrets),
 		}
+		if provenance != nil {
+			data["provenance"] = provenance
+		}
+		if envrcPath != "" {
+			data["envrc"] = envrcPath
+		}
 
 		output.Print(output.Success(
 			fmt.Sprintf("Synced %d environment variables to %s", len(secrets), envFilePath),
@@ -160,14 +270,54 @@ Examples:
 			output.ActionScan(),
 		))
 
+		if envWatch {
+			pull := func() (map[string]string, map[string]string, error) {
+				vars, provenance, err := pullSources(cfg, projectDir, !envNoCache)
+				if len(cfg.Sources) == 0 {
+					provenance = nil
+				}
+				return vars, provenance, err
+			}
+			watchEnvFile(envfile.NewRefresher(envFilePath, pull, ttl, source, envMerge))
+		}
+
 		return nil
 	},
 }
 
+// watchEnvFile refreshes the env file before each expiry until interrupted.
+func watchEnvFile(r *envfile.Refresher) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	r.Run(ctx, func(event envfile.RefreshEvent) {
+		if event.Err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to refresh env file, retrying in %s: %w", r.Lead(), event.Err)))
+			return
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Refreshed %d environment variables in %s", event.VarCount, event.Path),
+			map[string]interface{}{
+				"env_file":   event.Path,
+				"var_count":  event.VarCount,
+				"expires_at": event.ExpiresAt.Format(time.RFC3339),
+			},
+		))
+	})
+
+	output.Print(output.Success("Env file watcher stopped", nil))
+}
+
 func init() {
+	envCmd.AddCommand(envReEncryptCmd)
 	envCmd.Flags().BoolVar(&envForce, "force", false, "Overwrite existing .env.local file")
 	envCmd.Flags().StringVar(&envTTL, "ttl", "", "Override TTL from config (e.g., '1h', '30m')")
 	envCmd.Flags().BoolVar(&envDryRun, "dry-run", false, "Show what would be fetched without writing")
+	envCmd.Flags().BoolVar(&envNoCache, "no-cache", false, "Clear the adapter cache and fetch from the source")
+	envCmd.Flags().BoolVar(&envMerge, "merge", false, "Update the existing env file, keeping vars not managed by secrets")
+	envCmd.Flags().BoolVar(&envDirenv, "direnv", false, "Also add a 'dotenv' line for the env file to .envrc")
+	envCmd.Flags().BoolVar(&envWatch, "watch", false, "Keep running and refresh the env file before it expires")
+	envCmd.Flags().StringVar(&envFormat, "format", "dotenv", "Output format: dotenv (write the env file), json, or shell (print to stdout)")
 }
 
 // parseTTL determines the TTL to use (flag overrides config)
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/envfile"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	envReEncryptAll      bool
+	envReEncryptIdentity string
+)
+
+var envReEncryptCmd = &cobra.Command{
+	Use:   "re-encrypt [path...]",
+	Short: "Re-encrypt age-encrypted env files to the store's recipients",
+	Long: `Re-encrypt committed env files such as .env.local.age after the store's
+recipients change. Each file is decrypted with --identity (default: the
+store's identity) and encrypted again to the current recipient set, as shown
+by "secrets recipients list". Keys that are no longer recipients cannot read
+the new files.
+
+With --all, every .env*.age file under the current directory is swept,
+skipping .git, node_modules and vendor. The daemon does not need to be
+running.
+
+Examples:
+  secrets env re-encrypt .env.local.age
+  secrets env re-encrypt --all
+  secrets env re-encrypt --all --identity ~/old-identity.age`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths := args
+		if envReEncryptAll {
+			found, err := envfile.FindEncrypted(".")
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to search for encrypted env files: %w", err)))
+				return err
+			}
+			paths = append(paths, found...)
+		}
+		if len(paths) == 0 {
+			userErr := types.NewUserError(
+				"No env files to re-encrypt",
+				"No paths were given, and --all found no .env*.age files under the current directory.",
+				"Pass the files to re-encrypt, or run from the project root:\n  secrets env re-encrypt --all",
+				"secrets env re-encrypt --help",
+			)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+
+		st, err := loadLocalStore()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		recipients, err := storeRecipients(st)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		identityPath := envReEncryptIdentity
+		if identityPath == "" {
+			cfg, err := loadConfig()
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to load config: %w", err)))
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			identityPath = cfg.IdentityPath
+		}
+		identity, _, err := store.LoadIdentityFile(identityPath)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to load identity: %w", err)))
+			return fmt.Errorf("failed to load identity: %w", err)
+		}
+
+		done := make([]string, 0, len(paths))
+		failed := make(map[string]string)
+		for _, path := range paths {
+			if err := envfile.ReEncrypt(path, identity, recipients); err != nil {
+				failed[path] = err.Error()
+				continue
+			}
+			done = append(done, path)
+		}
+
+		data := map[string]interface{}{
+			"files":      done,
+			"recipients": len(recipients),
+		}
+		if len(failed) > 0 {
+			data["failed"] = failed
+			resp := output.ErrorMsg(fmt.Sprintf("Failed to re-encrypt %d of %d env files", len(failed), len(paths)))
+			resp.Data = data
+			output.Print(resp)
+
+			// The failures were already reported; this is not a usage error
+			cmd.SilenceUsage = true
+			return fmt.Errorf("failed to re-encrypt %d env files", len(failed))
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Re-encrypted %d env files to %d recipients", len(done), len(recipients)),
+			data,
+			output.Action{
+				Name:        "recipients",
+				Description: "List the recipients the files are encrypted to",
+				Command:     "secrets recipients list",
+			},
+		))
+		return nil
+	},
+}
+
+// storeRecipients parses the recipients the store is encrypted to.
+func storeRecipients(st *store.Store) ([]age.Recipient, error) {
+	list, err := st.Recipients()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipients: %w", err)
+	}
+	recipients := make([]age.Recipient, 0, len(list))
+	for _, r := range list {
+		parsed, err := store.ParseRecipient(r.Recipient)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, parsed)
+	}
+	return recipients, nil
+}
+
+func init() {
+	envReEncryptCmd.Flags().BoolVar(&envReEncryptAll, "all", false, "Re-encrypt every .env*.age file under the current directory")
+	envReEncryptCmd.Flags().StringVar(&envReEncryptIdentity, "identity", "", "Identity file the env files are currently encrypted to (default: the store's identity)")
+}
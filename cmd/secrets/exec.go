@@ -7,18 +7,26 @@ import (
 	"os/exec"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/joelhooks/agent-secrets/internal/daemon"
 	"github.com/joelhooks/agent-secrets/internal/output"
 	"github.com/joelhooks/agent-secrets/internal/project"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
 	"github.com/spf13/cobra"
 )
 
 var (
-	execTTL string
+	execTTL    string
+	execLeases []string
 )
 
+// execLeaseTTL is how long --lease leases last without --ttl
+const execLeaseTTL = 15 * time.Minute
+
 var execCmd = &cobra.Command{
 	Use:   "exec [flags] -- command [args...]",
 	Short: "Execute command with secrets injected as environment variables",
@@ -29,56 +37,95 @@ The command requires a .secrets.json file in the current directory or a parent.
 Secrets are fetched from the configured source (e.g., Vercel) and injected into
 the subprocess environment.
 
+With --lease, secrets come from the daemon instead: each named secret is
+leased (for --ttl, or 15m), set as an environment variable named after the
+secret without its namespace, and every lease is revoked as soon as the
+command exits, including when it is interrupted. No .secrets.json is needed.
+
 Examples:
   secrets exec -- npm run dev                    # Run with injected secrets
   secrets exec --ttl 1h -- ./my-script.sh        # Kill after 1 hour
-  secrets exec -- printenv | grep API            # View injected vars`,
+  secrets exec -- printenv | grep API            # View injected vars
+  secrets exec --lease GITHUB_TOKEN,prod::NPM_TOKEN -- npm publish`,
 	Args:                  cobra.MinimumNArgs(1),
 	DisableFlagsInUseLine: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Find .secrets.json
-		cfg, projectDir, err := project.FindProjectConfig()
-		if err != nil {
-			output.Print(output.Error(fmt.Errorf("failed to find project config: %w", err)))
-			return err
+		var ttl time.Duration
+		if execTTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(execTTL); err != nil {
+				output.Print(output.Error(fmt.Errorf("invalid ttl: %w", err)))
+				return err
+			}
 		}
 
-		// Get adapter for the configured source
-		adapter, err := getAdapter(cfg.Source)
-		if err != nil {
-			output.Print(output.Error(fmt.Errorf("failed to initialize adapter: %w", err)))
-			return err
-		}
+		var secrets map[string]string
+		var data map[string]interface{}
+		var projectDir string
+		// revoke ends the --lease leases; it is safe to call more than once
+		revoke := func() int { return 0 }
 
-		// Pull secrets
-		secrets, err := adapter.Pull(cfg.Project, cfg.Scope)
-		if err != nil {
-			output.Print(output.Error(fmt.Errorf("failed to pull secrets: %w", err)))
-			return err
-		}
+		if len(execLeases) > 0 {
+			leaseTTL := execLeaseTTL
+			if ttl > 0 {
+				leaseTTL = ttl
+			}
+			leased, leaseIDs, err := leaseForExec(execLeases, leaseTTL, "exec "+args[0])
+			if err != nil {
+				output.Print(output.Error(err))
+				return err
+			}
+			var once sync.Once
+			var revoked int
+			revoke = func() int {
+				once.Do(func() { revoked = revokeExecLeases(leaseIDs) })
+				return revoked
+			}
+			defer revoke()
 
-		if len(secrets) == 0 {
-			output.Print(output.Error(fmt.Errorf("no secrets found for project %q in scope %q", cfg.Project, cfg.Scope)))
-			return nil
+			secrets = leased
+			data = map[string]interface{}{
+				"leases": len(leaseIDs),
+			}
+		} else {
+			// Find .secrets.json
+			cfg, dir, err := project.FindProjectConfig()
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to find project config: %w", err)))
+				return err
+			}
+			projectDir = dir
+
+			// Pull and merge secrets from every configured source
+			secrets, _, err = pullSources(cfg, projectDir, false)
+			if err != nil {
+				output.Print(output.Error(err))
+				return err
+			}
+
+			if len(secrets) == 0 {
+				output.Print(output.Error(fmt.Errorf("no secrets found for project %q in scope %q", cfg.Project, cfg.Scope)))
+				return nil
+			}
+			data = map[string]interface{}{
+				"source":  sourceHeader(cfg),
+				"project": cfg.Project,
+				"scope":   cfg.Scope,
+			}
 		}
 
 		// Build environment variables
-		env := os.Environ()
+		env := store.ChildEnv()
 		secretKeys := make([]string, 0, len(secrets))
 		for key, value := range secrets {
 			env = append(env, fmt.Sprintf("%s=%s", key, value))
 			secretKeys = append(secretKeys, key)
 		}
 
-		// Parse optional TTL
+		// Apply the optional TTL
 		var ctx context.Context
 		var cancel context.CancelFunc
-		if execTTL != "" {
-			ttl, err := time.ParseDuration(execTTL)
-			if err != nil {
-				output.Print(output.Error(fmt.Errorf("invalid ttl: %w", err)))
-				return err
-			}
+		if ttl > 0 {
 			ctx, cancel = context.WithTimeout(context.Background(), ttl)
 		} else {
 			ctx, cancel = context.WithCancel(context.Background())
@@ -91,11 +138,12 @@ Examples:
 		command.Stdin = os.Stdin
 		command.Stdout = os.Stdout
 		command.Stderr = os.Stderr
-		command.Dir = projectDir // Run in project directory
+		command.Dir = projectDir // Run in project directory, if there is one
 
 		// Handle signals (SIGINT, SIGTERM) to propagate to child
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigChan)
 
 		// Start subprocess
 		if err := command.Start(); err != nil {
@@ -130,7 +178,8 @@ Examples:
 			if err != nil {
 				// Check if it's an exit error
 				if exitErr, ok := err.(*exec.ExitError); ok {
-					// Return exit code
+					// Return exit code; os.Exit skips the deferred revoke
+					revoke()
 					os.Exit(exitErr.ExitCode())
 				}
 				output.Print(output.Error(fmt.Errorf("command failed: %w", err)))
@@ -138,13 +187,11 @@ Examples:
 			}
 
 			// Success
-			data := map[string]interface{}{
-				"command":      strings.Join(args, " "),
-				"source":       cfg.Source,
-				"project":      cfg.Project,
-				"scope":        cfg.Scope,
-				"secrets_count": len(secrets),
-				"secret_keys":  secretKeys,
+			data["command"] = strings.Join(args, " ")
+			data["secrets_count"] = len(secrets)
+			data["secret_keys"] = secretKeys
+			if len(execLeases) > 0 {
+				data["leases_revoked"] = revoke()
 			}
 
 			output.Print(output.Success("Command executed with injected secrets", data))
@@ -161,6 +208,75 @@ Examples:
 	},
 }
 
+// leaseForExec leases each named secret for ttl, returning the values keyed
+// by environment variable name and the lease IDs. If any lease fails, the
+// ones already acquired are revoked.
+func leaseForExec(names []string, ttl time.Duration, reason string) (map[string]string, []string, error) {
+	clientID, err := os.Hostname()
+	if err != nil {
+		clientID = "unknown"
+	}
+
+	vars := make(map[string]string, len(names))
+	var leaseIDs []string
+	fail := func(err error) (map[string]string, []string, error) {
+		revokeExecLeases(leaseIDs)
+		return nil, nil, err
+	}
+
+	for _, name := range names {
+		_, envVar := types.SplitRef(name)
+		if _, dup := vars[envVar]; dup {
+			return fail(fmt.Errorf("--lease %s: %s is already set by another secret", name, envVar))
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodLease, daemon.LeaseParams{
+			SecretName: name,
+			ClientID:   clientID,
+			TTL:        ttl.String(),
+			Reason:     reason,
+		})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				return fail(types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be leased.",
+					"To start it:\n  secrets serve &",
+					"secrets exec --help",
+				).WithContext("Socket path", socketPath))
+			}
+			return fail(fmt.Errorf("failed to lease %s: %w", name, err))
+		}
+
+		var result daemon.LeaseResult
+		if err := decodeResult(resp, &result); err != nil {
+			return fail(err)
+		}
+		leaseIDs = append(leaseIDs, result.LeaseID)
+		// Binary values are base64 and don't belong in the environment
+		if result.Binary {
+			return fail(fmt.Errorf("--lease %s: binary secrets cannot be set as environment variables; use 'secrets get-file'", name))
+		}
+		vars[envVar] = result.Value
+	}
+	return vars, leaseIDs, nil
+}
+
+// revokeExecLeases revokes the leases of an exec and returns how many were
+// revoked. Failures go to stderr, since the command's output owns stdout.
+func revokeExecLeases(leaseIDs []string) int {
+	revoked := 0
+	for _, id := range leaseIDs {
+		if _, err := rpcCall(socketPath, daemon.MethodRevoke, daemon.RevokeParams{LeaseID: id}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to revoke lease %s: %v\n", id, err)
+			continue
+		}
+		revoked++
+	}
+	return revoked
+}
+
 func init() {
 	execCmd.Flags().StringVar(&execTTL, "ttl", "", "Maximum subprocess duration (e.g., 1h, 30m)")
+	execCmd.Flags().StringSliceVar(&execLeases, "lease", nil, "Lease these secrets from the daemon instead of the project source, revoking them on exit (comma-separated)")
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// leaseDaemon hands out leases on every secret in values and records
+// revocations.
+type leaseDaemon struct {
+	values  map[string]string
+	mu      sync.Mutex
+	leased  []string
+	revoked []string
+}
+
+func (d *leaseDaemon) handle(req types.RPCRequest) interface{} {
+	data, _ := json.Marshal(req.Params)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch req.Method {
+	case daemon.MethodLease:
+		var p daemon.LeaseParams
+		_ = json.Unmarshal(data, &p)
+		value, ok := d.values[p.SecretName]
+		if !ok {
+			return nil
+		}
+		d.leased = append(d.leased, p.SecretName)
+		return daemon.LeaseResult{LeaseID: "lease-" + p.SecretName, Value: value}
+	case daemon.MethodRevoke:
+		var p daemon.RevokeParams
+		_ = json.Unmarshal(data, &p)
+		d.revoked = append(d.revoked, p.LeaseID)
+		return daemon.RevokeResult{Success: true}
+	}
+	return nil
+}
+
+// withLeases sets --lease for the duration of the test.
+func withLeases(t *testing.T, names ...string) {
+	t.Helper()
+	old := execLeases
+	execLeases = names
+	t.Cleanup(func() { execLeases = old })
+}
+
+// captureStdout sends stdout to a file for the duration of the test and
+// returns a func that reads what was written.
+func captureStdout(t *testing.T) func() string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdout")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdout
+	os.Stdout = f
+	t.Cleanup(func() {
+		os.Stdout = old
+		f.Close()
+	})
+	return func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+}
+
+func TestExec_LeaseRevokedOnExit(t *testing.T) {
+	d := &leaseDaemon{values: map[string]string{
+		"GITHUB_TOKEN":    "ghp_exec_test",
+		"prod::NPM_TOKEN": "npm_exec_test",
+	}}
+	withSocket(t, serveRPC(t, d.handle))
+	withLeases(t, "GITHUB_TOKEN", "prod::NPM_TOKEN")
+	stdout := captureStdout(t)
+
+	err := execCmd.RunE(execCmd, []string{"sh", "-c", `echo "child:$GITHUB_TOKEN:$NPM_TOKEN"`})
+	if err != nil {
+		t.Fatalf("exec failed: %v", err)
+	}
+
+	if out := stdout(); !strings.Contains(out, "child:ghp_exec_test:npm_exec_test") {
+		t.Errorf("child did not see the leased values, got:\n%s", out)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.leased) != 2 {
+		t.Errorf("leased = %v, want both secrets", d.leased)
+	}
+	want := map[string]bool{"lease-GITHUB_TOKEN": true, "lease-prod::NPM_TOKEN": true}
+	if len(d.revoked) != len(want) {
+		t.Fatalf("revoked = %v, want %v", d.revoked, want)
+	}
+	for _, id := range d.revoked {
+		if !want[id] {
+			t.Errorf("unexpected revocation of %s", id)
+		}
+	}
+}
+
+func TestExec_LeaseFailureRevokesEarlierLeases(t *testing.T) {
+	d := &leaseDaemon{values: map[string]string{"GITHUB_TOKEN": "ghp_exec_test"}}
+	withSocket(t, serveRPC(t, d.handle))
+	withLeases(t, "GITHUB_TOKEN", "missing")
+	stdout := captureStdout(t)
+
+	if err := execCmd.RunE(execCmd, []string{"sh", "-c", "echo child-ran"}); err == nil {
+		t.Fatal("expected an error for a secret that cannot be leased")
+	}
+	if strings.Contains(stdout(), "child-ran") {
+		t.Error("the command ran without all its secrets")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.revoked) != 1 || d.revoked[0] != "lease-GITHUB_TOKEN" {
+		t.Errorf("revoked = %v, want the lease already acquired", d.revoked)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/envfile"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	filesNamespace string
+	filesTTL       time.Duration
+)
+
+var filesCmd = &cobra.Command{
+	Use:   "files <dir> [name...]",
+	Short: "Write secrets as individual files for Docker/Compose",
+	Long: `Write secrets from the store into a directory, one file per secret, in the
+style of Docker and Compose secrets: each file is named after the secret and
+contains only its value. Secrets added with 'secrets add-file' are written
+as the original file's bytes. Files are created 0600 and the directory 0700.
+
+A manifest (.secrets-manifest.json) records the TTL, so "secrets cleanup"
+removes the files once it expires. Running the command again refreshes the
+TTL and removes files for secrets that are no longer selected.
+
+By default every secret in the default namespace is written; use
+--namespace to select a named namespace, or pass secret names to write
+only those.
+
+Examples:
+  secrets files ./secrets                        # Default namespace, 1h TTL
+  secrets files ./secrets db_password api_key    # Only these secrets
+  secrets files ./secrets --namespace prod --ttl 15m
+
+Then reference them from compose.yaml:
+  secrets:
+    db_password:
+      file: ./secrets/db_password`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if filesTTL <= 0 || filesTTL > 24*time.Hour {
+			err := fmt.Errorf("invalid TTL: duration must be positive and at most 24h")
+			output.Print(output.Error(err))
+			return err
+		}
+
+		dir, err := filepath.Abs(args[0])
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to resolve path: %w", err)))
+			return err
+		}
+
+		secrets, err := fetchStoreSecrets("secrets-files", filesNamespace, args[1:], true)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be read from the store.",
+					"To start it:\n  secrets serve &",
+					"secrets files --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to read secrets from store: %w", err)))
+			return err
+		}
+
+		if len(secrets) == 0 {
+			output.Print(output.ErrorMsg(
+				fmt.Sprintf("no secrets to write in namespace %q", namespaceOrDefault(filesNamespace)),
+				output.ActionsWhenEmpty()...,
+			))
+			return fmt.Errorf("no secrets to write")
+		}
+
+		source := "store:" + namespaceOrDefault(filesNamespace)
+		if err := envfile.WriteSecretDir(dir, secrets, filesTTL, source); err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to write secret files: %w", err)))
+			return err
+		}
+
+		names := getVarNames(secrets)
+		sort.Strings(names)
+
+		expiresAt := time.Now().Add(filesTTL)
+		data := map[string]interface{}{
+			"dir":        dir,
+			"files":      names,
+			"count":      len(names),
+			"namespace":  namespaceOrDefault(filesNamespace),
+			"ttl":        filesTTL.String(),
+			"expires_at": expiresAt.Format(time.RFC3339),
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Wrote %d secret files to %s (expires in %s)", len(names), dir, filesTTL),
+			data,
+			output.Action{
+				Name:        "cleanup",
+				Description: "Remove the files once the TTL expires",
+				Command:     "secrets cleanup --path " + dir,
+			},
+			output.ActionAudit(),
+		))
+		return nil
+	},
+}
+
+func init() {
+	filesCmd.Flags().StringVar(&filesNamespace, "namespace", "", "Write secrets from this namespace (default: the default namespace)")
+	filesCmd.Flags().DurationVar(&filesTTL, "ttl", 1*time.Hour, "Time until cleanup removes the files (max 24h)")
+}
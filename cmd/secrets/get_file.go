@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var getFileForce bool
+
+var getFileCmd = &cobra.Command{
+	Use:   "get-file <name> <path>",
+	Short: "Write a secret to a file",
+	Long: `Write a secret's contents to a file, created 0600. Secrets added with
+'secrets add-file' are decoded back to their original bytes; other secrets
+are written as their value.
+
+The value is read with a short lease that is revoked once the file is
+written, so the read is audited like any other. An existing file is not
+replaced unless --force is given.
+
+Examples:
+  secrets get-file tls_key ./server.key
+  secrets get-file prod::gcp_sa /run/secrets/sa.json --force`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, path := args[0], args[1]
+
+		if !getFileForce {
+			if _, err := os.Stat(path); err == nil {
+				err := fmt.Errorf("file already exists: %s (use --force to replace it)", path)
+				output.Print(output.Error(err))
+				return err
+			}
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodLease, daemon.LeaseParams{
+			SecretName: name,
+			ClientID:   "secrets-get-file",
+			TTL:        "1m",
+		})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be read from the store.",
+					"To start it:\n  secrets serve &",
+					"secrets get-file --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to read secret: %w", err)))
+			return fmt.Errorf("failed to read secret: %w", err)
+		}
+
+		var lease daemon.LeaseResult
+		if err := decodeResult(resp, &lease); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		// Best effort: the lease expires on its own if this fails
+		defer rpcCall(socketPath, daemon.MethodRevoke, daemon.RevokeParams{LeaseID: lease.LeaseID})
+
+		content := []byte(lease.Value)
+		if lease.Binary {
+			if content, err = store.DecodeBinary(lease.Value); err != nil {
+				output.Print(output.Error(err))
+				return err
+			}
+		}
+
+		if err := writeFileRestricted(path, content); err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to write file: %w", err)))
+			return err
+		}
+
+		abs, _ := filepath.Abs(path)
+		output.Print(output.Success(
+			fmt.Sprintf("Wrote '%s' to %s (%d bytes)", name, path, len(content)),
+			map[string]interface{}{
+				"name":   name,
+				"file":   abs,
+				"bytes":  len(content),
+				"binary": lease.Binary,
+			},
+			output.ActionAudit(),
+		))
+		return nil
+	},
+}
+
+// writeFileRestricted replaces path with content, readable only by the
+// owner. The content is written to a temp file and renamed into place so an
+// existing file with looser permissions is not reused.
+func writeFileRestricted(path string, content []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func init() {
+	getFileCmd.Flags().BoolVar(&getFileForce, "force", false, "Replace the file if it already exists")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "List the kept versions of a secret",
+	Long: `List the versions of a secret kept in the store: the current value as
+version 0, then each previous value, newest first, with when it was set.
+Values are never shown.
+
+Updates keep up to history_limit previous values per secret (default 5;
+0 disables history). Use "secrets rollback" to restore the previous value.
+
+Examples:
+  secrets history api_key
+  secrets history prod::db_password`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodHistory, daemon.HistoryParams{Name: args[0]})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secret history cannot be read.",
+					"To start it:\n  secrets serve &",
+					"secrets history --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to read history: %w", err)))
+			return fmt.Errorf("failed to read history: %w", err)
+		}
+
+		var result daemon.HistoryResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"name":     result.Name,
+			"versions": result.Versions,
+		}
+		previous := len(result.Versions) - 1
+		if previous <= 0 {
+			output.Print(output.Success(fmt.Sprintf("%s has no previous versions", args[0]), data))
+			return nil
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("%s has %d previous versions", args[0], previous),
+			data,
+			output.Action{
+				Name:        "rollback",
+				Description: "Restore the previous value",
+				Command:     "secrets rollback " + args[0],
+			},
+		))
+		return nil
+	},
+}
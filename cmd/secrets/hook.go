@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/scanner"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookPath   string
+	hookFailOn string
+	hookForce  bool
+)
+
+var installHookCmd = &cobra.Command{
+	Use:   "install-hook",
+	Short: "Install a git pre-commit hook that blocks committed secrets",
+	Long: `Install a git pre-commit hook that runs "secrets scan --staged" and
+blocks the commit when a staged file contains a secret at or above the
+--fail-on severity.
+
+The hook is written to the repository's hooks directory (honoring
+core.hooksPath) unless --path is given. An existing hook that was not
+installed by this command is left alone unless --force is set; a hook
+installed by it is updated in place.
+
+Examples:
+  secrets install-hook                             # Block high and critical findings
+  secrets install-hook --fail-on critical          # Only block critical findings
+  secrets install-hook --path .githooks/pre-commit
+  secrets install-hook --force                     # Replace an existing hook`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failOn, err := scanner.ParseSeverity(hookFailOn)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("invalid --fail-on: %w", err)))
+			return err
+		}
+
+		path := hookPath
+		if path == "" {
+			path, err = scanner.HookPath(".")
+			if err != nil {
+				output.Print(output.Error(err))
+				return err
+			}
+		}
+		path, err = filepath.Abs(path)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to resolve path: %w", err)))
+			return err
+		}
+
+		replaced, err := scanner.InstallHook(path, failOn, hookForce)
+		if err != nil {
+			if errors.Is(err, scanner.ErrHookExists) {
+				userErr := types.NewUserError(
+					"A pre-commit hook already exists",
+					"The existing hook was not installed by secrets, so it was left unchanged.",
+					"Call \"secrets scan --staged --fail-on "+failOn.String()+"\" from your existing hook, or replace it:\n  secrets install-hook --force",
+					"secrets install-hook --help",
+				).WithContext("Hook path", path)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(err))
+			return err
+		}
+
+		msg := "Installed pre-commit hook"
+		if replaced {
+			msg = "Updated pre-commit hook"
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("%s at %s", msg, path),
+			map[string]interface{}{
+				"path":     path,
+				"fail_on":  failOn.String(),
+				"replaced": replaced,
+			},
+			output.Action{
+				Name:        "scan_staged",
+				Description: "Run the same check the hook runs",
+				Command:     "secrets scan --staged --fail-on " + failOn.String(),
+			},
+		))
+		return nil
+	},
+}
+
+func init() {
+	installHookCmd.Flags().StringVar(&hookPath, "path", "", "Hook file to write (default: pre-commit in the repository's hooks directory)")
+	installHookCmd.Flags().StringVar(&hookFailOn, "fail-on", "high", "Block commits with findings at or above this severity (low, medium, high, critical)")
+	installHookCmd.Flags().BoolVar(&hookForce, "force", false, "Replace an existing hook not installed by secrets")
+}
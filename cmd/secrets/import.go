@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/envfile"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importNamespace string
+	importRotateVia string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Add every variable in a .env file as a secret",
+	Long: `Import the variables of a .env file as secrets, one per KEY=value line.
+Each secret is named after its variable, in --namespace if given. Variables
+whose name already holds a secret are skipped and left unchanged, so an
+import can be re-run safely; variables with an empty value are skipped too.
+Quoted values are unquoted; double-quoted ones may use escapes such as \n,
+as written by 'secrets list --export-values'.
+
+Examples:
+  secrets import .env
+  secrets import .env.production --namespace prod
+  secrets import .env --rotate-via 'scripts/rotate.sh'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := envfile.Read(args[0])
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to read %s: %w", args[0], err)))
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		vars := make(map[string]string, len(file.Vars))
+		empty := []string{}
+		for key, value := range file.Vars {
+			value = envfile.Unquote(value)
+			if value == "" {
+				empty = append(empty, key)
+				continue
+			}
+			vars[key] = value
+		}
+		sort.Strings(empty)
+
+		params := daemon.ImportParams{
+			Vars:      vars,
+			Namespace: importNamespace,
+			RotateVia: importRotateVia,
+		}
+		resp, err := rpcCall(socketPath, daemon.MethodImport, params)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be imported.",
+					"To start it:\n  secrets serve &",
+					"secrets import --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to import secrets: %w", err)))
+			return fmt.Errorf("failed to import secrets: %w", err)
+		}
+
+		var result daemon.ImportResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"file":    args[0],
+			"added":   result.Added,
+			"skipped": result.Skipped,
+			"empty":   empty,
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Imported %d secrets from %s (%d skipped)", len(result.Added), args[0], len(result.Skipped)+len(empty)),
+			data,
+			output.Action{
+				Name:        "list",
+				Description: "List secrets in the store",
+				Command:     "secrets list",
+			},
+		))
+		return nil
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importNamespace, "namespace", "", "Namespace to import the secrets into (default: the default namespace)")
+	importCmd.Flags().StringVar(&importRotateVia, "rotate-via", "", "Command to execute for automatic rotation of every imported secret")
+}
@@ -2,27 +2,57 @@ package main
 
 import (
 	"fmt"
+	"os"
 
-	"github.com/joelhooks/agent-secrets/internal/config"
 	"github.com/joelhooks/agent-secrets/internal/output"
 	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
 	"github.com/spf13/cobra"
 )
 
+var initPassphrase bool
+
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize the encrypted credential store",
 	Long: `Initialize the agent-secrets encrypted store. This creates a new age identity
-and sets up the required directory structure.`,
+and sets up the required directory structure.
+
+With --passphrase, the new identity is encrypted with a passphrase (prompted
+for, or read from AGENT_SECRETS_PASSPHRASE). Commands that load it prompt for
+the passphrase; the daemon reads it from AGENT_SECRETS_PASSPHRASE.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load config (creates defaults if needed)
-		cfg := config.DefaultConfig()
+		cfg, err := defaultConfig()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
 
 		// Create store instance
 		st := store.New(cfg)
 
+		var passphrase string
+		if initPassphrase {
+			if _, err := os.Stat(cfg.IdentityPath); err == nil {
+				userErr := types.NewUserError(
+					"Identity already exists",
+					"--passphrase only applies when init generates a new identity.",
+					"Encrypt the existing identity with age instead:\n  age -p -a -o identity.tmp "+cfg.IdentityPath+" && mv identity.tmp "+cfg.IdentityPath,
+					"secrets init --help",
+				).WithContext("identity", cfg.IdentityPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			var err error
+			if passphrase, err = newPassphrase(); err != nil {
+				output.Print(output.Error(err))
+				return err
+			}
+		}
+
 		// Initialize store (creates directories, identity, and empty secrets file)
-		if err := st.Init(); err != nil {
+		if err := st.InitWithPassphrase(passphrase); err != nil {
 			output.Print(output.Error(fmt.Errorf("failed to initialize: %w", err)))
 			return fmt.Errorf("failed to initialize: %w", err)
 		}
@@ -38,3 +68,7 @@ and sets up the required directory structure.`,
 		return nil
 	},
 }
+
+func init() {
+	initCmd.Flags().BoolVar(&initPassphrase, "passphrase", false, "Encrypt the new identity with a passphrase")
+}
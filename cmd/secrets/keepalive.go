@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/killswitch"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var keepaliveCmd = &cobra.Command{
+	Use:   "keepalive",
+	Short: "Reset the dead-man switch",
+	Long: `Touch the dead-man switch's keep-alive file. With dead_man_file and
+dead_man_interval configured, the daemon activates the killswitch (the
+heartbeat fail_action, or revoking all leases) when the file has not been
+touched for longer than the interval. Run this from a cron job or by hand
+to prove someone is still in control of the machine.
+
+If the daemon is not running, the file is touched directly.
+
+Examples:
+  secrets keepalive`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodKeepAlive, nil)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				return touchKeepAliveLocally()
+			}
+			output.Print(output.Error(fmt.Errorf("failed to reset dead-man switch: %w", err)))
+			return fmt.Errorf("failed to reset dead-man switch: %w", err)
+		}
+
+		var result daemon.KeepAliveResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"file":       result.File,
+			"touched_at": result.TouchedAt.Format(time.RFC3339),
+			"deadline":   result.Deadline.Format(time.RFC3339),
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Dead-man switch reset until %s", result.Deadline.Format(time.RFC3339)),
+			data,
+			output.ActionStatus(),
+		))
+		return nil
+	},
+}
+
+// touchKeepAliveLocally touches the configured keep-alive file without the
+// daemon.
+func touchKeepAliveLocally() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		output.Print(output.Error(fmt.Errorf("failed to load config: %w", err)))
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.DeadManFile == "" {
+		userErr := types.NewUserError(
+			"Dead-man switch is not configured",
+			"There is no keep-alive file to touch.",
+			"Set dead_man_file and dead_man_interval in the config, then restart the daemon:\n  secrets serve &",
+			"secrets keepalive --help",
+		)
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+
+	touched, err := killswitch.TouchKeepAlive(cfg.DeadManFile)
+	if err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	deadline := touched.Add(cfg.DeadManInterval)
+	data := map[string]interface{}{
+		"file":       cfg.DeadManFile,
+		"touched_at": touched.Format(time.RFC3339),
+		"deadline":   deadline.Format(time.RFC3339),
+		"daemon":     false,
+	}
+	output.Print(output.Success(
+		fmt.Sprintf("Dead-man switch reset until %s", deadline.Format(time.RFC3339)),
+		data,
+	))
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var killswitchArmFor string
+
+var killswitchCmd = &cobra.Command{
+	Use:   "killswitch",
+	Short: "Arm or disarm the killswitch",
+	Long: `Arm or disarm the killswitch. The killswitch only rotates secrets or
+wipes the store while it is armed; when it fires unarmed, those actions are
+downgraded to revoking all leases and a warning is audited. This stops a
+flaky heartbeat or a stale keep-alive file from destroying secrets by
+accident.
+
+Arming lasts for arm_window from the config (until disarmed if unset), or
+for --for. Set armed_by_default to arm the killswitch when the daemon starts.
+
+Examples:
+  secrets killswitch arm
+  secrets killswitch arm --for 8h
+  secrets killswitch disarm`,
+}
+
+var killswitchArmCmd = &cobra.Command{
+	Use:   "arm",
+	Short: "Allow the killswitch to rotate secrets and wipe the store",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var params daemon.ArmParams
+		if killswitchArmFor != "" {
+			window, err := parseInterval(killswitchArmFor)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("invalid --for: %w", err)))
+				return fmt.Errorf("invalid --for: %w", err)
+			}
+			params.Window = window
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodArm, params)
+		if err != nil {
+			return killswitchRPCError(err, "arm killswitch")
+		}
+
+		var result daemon.ArmResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{"armed": result.Armed}
+		msg := "Killswitch armed until disarmed"
+		if !result.ExpiresAt.IsZero() {
+			data["expires_at"] = result.ExpiresAt.Format(time.RFC3339)
+			msg = fmt.Sprintf("Killswitch armed until %s", result.ExpiresAt.Format(time.RFC3339))
+		}
+		output.Print(output.Success(msg, data, output.Action{
+			Name:        "disarm",
+			Description: "Disarm the killswitch",
+			Command:     "secrets killswitch disarm",
+		}))
+		return nil
+	},
+}
+
+var killswitchDisarmCmd = &cobra.Command{
+	Use:   "disarm",
+	Short: "Limit the killswitch to revoking leases",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodDisarm, nil)
+		if err != nil {
+			return killswitchRPCError(err, "disarm killswitch")
+		}
+
+		var result daemon.ArmResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		output.Print(output.Success("Killswitch disarmed", map[string]interface{}{"armed": result.Armed}))
+		return nil
+	},
+}
+
+// killswitchRPCError reports a failed arm or disarm call.
+func killswitchRPCError(err error, doing string) error {
+	if isDaemonConnectionError(err) {
+		userErr := types.NewUserError(
+			"Failed to connect to daemon",
+			"The daemon doesn't appear to be running. The killswitch can only be armed in a running daemon.",
+			"To start it:\n  secrets serve &",
+			"secrets killswitch --help",
+		).WithContext("Socket path", socketPath)
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+	output.Print(output.Error(fmt.Errorf("failed to %s: %w", doing, err)))
+	return fmt.Errorf("failed to %s: %w", doing, err)
+}
+
+func init() {
+	killswitchArmCmd.Flags().StringVar(&killswitchArmFor, "for", "", "How long to stay armed (e.g. 1h, 7d); defaults to arm_window")
+
+	killswitchCmd.AddCommand(killswitchArmCmd)
+	killswitchCmd.AddCommand(killswitchDisarmCmd)
+}
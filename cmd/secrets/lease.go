@@ -16,6 +16,7 @@ var (
 	leaseTTL      string
 	leaseClientID string
 	leaseRaw      bool
+	leaseReason   string
 )
 
 var leaseCmd = &cobra.Command{
@@ -26,11 +27,14 @@ temporary access to the secret value.
 
 By default, returns a JSON response with lease details and available actions.
 Use --raw to output ONLY the secret value (for piping to shell commands).
+Secrets added with 'secrets add-file' are returned base64-encoded and marked
+"binary"; use 'secrets get-file' to write their original bytes.
 
 Examples:
   secrets lease github_token                    # JSON response with details
   export TOKEN=$(secrets lease github_token --raw)  # Shell export
-  secrets lease api_key --ttl 30m               # Custom TTL`,
+  secrets lease api_key --ttl 30m               # Custom TTL
+  secrets lease deploy_key --reason "ci deploy" # Record why in the audit log`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
@@ -49,6 +53,7 @@ Examples:
 			SecretName: name,
 			ClientID:   leaseClientID,
 			TTL:        leaseTTL,
+			Reason:     leaseReason,
 		}
 
 		resp, err := rpcCall(socketPath, daemon.MethodLease, params)
@@ -94,16 +99,30 @@ Examples:
 			"ttl":         leaseTTL,
 			"client_id":   leaseClientID,
 		}
+		if leaseReason != "" {
+			leaseData["reason"] = leaseReason
+		}
 
 		// Generate environment variable name suggestion (uppercase with underscores)
 		envVarName := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
 
+		export := output.Action{
+			Name:        "export",
+			Description: "Export to environment",
+			Command:     fmt.Sprintf("export %s=$(secrets lease %s --raw)", envVarName, name),
+		}
+		// Binary values are base64 and don't belong in the environment
+		if result.Binary {
+			leaseData["binary"] = true
+			export = output.Action{
+				Name:        "get-file",
+				Description: "Write the decoded file instead",
+				Command:     fmt.Sprintf("secrets get-file %s <path>", name),
+			}
+		}
+
 		actions := []output.Action{
-			{
-				Name:        "export",
-				Description: "Export to environment",
-				Command:     fmt.Sprintf("export %s=$(secrets lease %s --raw)", envVarName, name),
-			},
+			export,
 			{
 				Name:        "revoke",
 				Description: "Revoke this lease",
@@ -122,4 +141,5 @@ func init() {
 	leaseCmd.Flags().StringVar(&leaseTTL, "ttl", "1h", "Time-to-live for the lease (e.g., 1h, 30m, 2h30m)")
 	leaseCmd.Flags().StringVar(&leaseClientID, "client-id", "", "Client identifier (defaults to hostname)")
 	leaseCmd.Flags().BoolVar(&leaseRaw, "raw", false, "Output only the secret value (for piping to shell)")
+	leaseCmd.Flags().StringVar(&leaseReason, "reason", "", "Why the lease is needed, recorded in the audit log (max 256 characters)")
 }
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/audit"
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/envfile"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listNoRotation bool
+	listNamespace  string
+	listLabels     []string
+
+	listExportValues bool
+	listConfirm      bool
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored secrets (names and metadata only)",
+	Long: `List the secrets in the store. Values are never shown, except with
+--export-values (see below).
+
+Use --no-rotation to audit rotation coverage: only secrets without a
+rotation hook (--rotate-via) are listed.
+
+Use --label to select secrets by the labels given at 'secrets add'. With
+several labels, only secrets carrying all of them are listed.
+
+For a local backup, --export-values --confirm prints NAME="value" for each
+selected secret instead, one per line, in the format 'secrets import' reads
+back. The daemon never returns values, so this reads and decrypts the local
+store directly and only works with access to the identity file. A warning
+is printed to stderr and the export is recorded in the audit log; the values
+go to stdout unencrypted, so redirect them somewhere safe.
+
+Names are printed without their namespace, so secrets from several
+namespaces cannot be exported together: pick one with --namespace and
+import it back with 'secrets import <file> --namespace <ns>'. Binary
+secrets are skipped; save them with 'secrets get-file'. For an encrypted
+backup of everything, use 'secrets backup'.
+
+Examples:
+  secrets list                                # All secrets
+  secrets list --no-rotation                  # Secrets missing a rotation hook
+  secrets list --no-rotation --namespace prod # Scoped to one namespace
+  secrets list --label env=prod --label team=billing
+  secrets list --export-values --confirm --namespace prod > prod.env`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		labels, err := parseLabels(listLabels)
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		if listExportValues {
+			return exportValues(cmd, labels)
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodList, daemon.ListParams{
+			NoRotation: listNoRotation,
+			Labels:     labels,
+		})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be listed.",
+					"To start it:\n  secrets serve &",
+					"secrets list --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to list secrets: %w", err)))
+			return fmt.Errorf("failed to list secrets: %w", err)
+		}
+
+		var result daemon.ListResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		secrets := make([]daemon.SecretMetadata, 0, len(result.Secrets))
+		for _, s := range result.Secrets {
+			if listNamespace != "" {
+				if ns, _ := types.SplitRef(s.Name); ns != listNamespace {
+					continue
+				}
+			}
+			secrets = append(secrets, s)
+		}
+		sort.Slice(secrets, func(i, j int) bool {
+			return secrets[i].Name < secrets[j].Name
+		})
+
+		names := make([]string, len(secrets))
+		for i, s := range secrets {
+			names[i] = s.Name
+		}
+
+		data := map[string]interface{}{
+			"secrets": secrets,
+			"count":   len(secrets),
+		}
+		if listNamespace != "" {
+			data["namespace"] = listNamespace
+		}
+		if len(labels) > 0 {
+			data["labels"] = labels
+		}
+
+		var msg string
+		var actions []output.Action
+		switch {
+		case listNoRotation && len(secrets) == 0:
+			msg = "All secrets have a rotation hook"
+			actions = []output.Action{output.ActionAudit()}
+		case listNoRotation:
+			msg = fmt.Sprintf("%d secrets have no rotation hook", len(secrets))
+			actions = []output.Action{output.ActionAddWithRotation()}
+		case len(labels) > 0 && len(secrets) == 0:
+			msg = "No secrets match the labels"
+			actions = output.ActionsWhenEmpty()
+		case len(secrets) == 0:
+			msg = "No secrets stored"
+			actions = output.ActionsWhenEmpty()
+		default:
+			msg = fmt.Sprintf("%d secrets", len(secrets))
+			actions = output.ActionsForSecrets(names)
+		}
+
+		rows := make([][]string, len(secrets))
+		for i, s := range secrets {
+			ns, name := types.SplitRef(s.Name)
+			rows[i] = []string{name, ns, formatTableTime(s.CreatedAt), formatTableTime(s.LastRotated)}
+		}
+		output.Print(output.Success(msg, data, actions...).
+			WithTable([]string{"NAME", "NAMESPACE", "CREATED", "LAST ROTATED"}, rows))
+		return nil
+	},
+}
+
+// exportValues prints NAME="value" for each secret selected by the list
+// flags, read from the local store rather than the daemon, and records the
+// export in the audit log.
+func exportValues(cmd *cobra.Command, labels map[string]string) error {
+	cmd.SilenceUsage = true
+	if !listConfirm {
+		userErr := types.NewUserError(
+			"Confirmation required to export values",
+			"--export-values prints secret values in plain text, which the daemon never does.",
+			"If you mean to export them, add --confirm and redirect the output:\n  secrets list --export-values --confirm > backup.env",
+			"secrets list --help",
+		)
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+
+	st, err := loadLocalStore()
+	if err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	// ListByLabel matches every secret when there are no labels
+	secrets, err := st.ListByLabel(labels)
+	if err != nil {
+		output.Print(output.Error(fmt.Errorf("failed to list secrets: %w", err)))
+		return err
+	}
+
+	var selected []types.Secret
+	var binary []string
+	namespaces := map[string]bool{}
+	for _, s := range secrets {
+		if listNoRotation && s.RotateVia != "" {
+			continue
+		}
+		ns, _ := types.SplitRef(s.Name)
+		if listNamespace != "" && ns != listNamespace {
+			continue
+		}
+		if s.Binary {
+			binary = append(binary, s.Name)
+			continue
+		}
+		namespaces[ns] = true
+		selected = append(selected, s)
+	}
+
+	if len(namespaces) > 1 {
+		userErr := types.NewUserError(
+			"Secrets in several namespaces",
+			"Exported names carry no namespace, so secrets from different namespaces could collide and would import back into one.",
+			"Export one namespace at a time:\n  secrets list --export-values --confirm --namespace <ns> > <ns>.env",
+			"secrets ns list",
+		).WithContext("Namespaces", strings.Join(slices.Sorted(maps.Keys(namespaces)), ", "))
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+
+	vars := make(map[string]string, len(selected))
+	names := make([]string, 0, len(selected))
+	for _, s := range selected {
+		value, err := st.Get(s.Name)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to read %s: %w", s.Name, err)))
+			return err
+		}
+		_, name := types.SplitRef(s.Name)
+		vars[name] = value
+		names = append(names, s.Name)
+	}
+	text, err := envfile.FormatEnv(vars)
+	if err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	logExport(names, binary)
+
+	fmt.Fprintf(os.Stderr, "WARNING: printing %d secret values in plain text. Anyone who can read this output can use them.\n", len(selected))
+	if len(binary) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d binary secrets (%s); save them with 'secrets get-file'.\n", len(binary), strings.Join(binary, ", "))
+	}
+	_, err = fmt.Fprint(os.Stdout, text)
+	return err
+}
+
+// logExport records a values export in the local audit log. An export is
+// not refused when the log cannot be opened; the daemon may hold it.
+func logExport(names, skipped []string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return
+	}
+	logger, err := audit.New(cfg.AuditPath)
+	if err != nil {
+		return
+	}
+	defer logger.Close()
+
+	details := fmt.Sprintf("exported %d values: %s", len(names), strings.Join(names, ", "))
+	if len(skipped) > 0 {
+		details += fmt.Sprintf("; skipped %d binary: %s", len(skipped), strings.Join(skipped, ", "))
+	}
+	_ = logger.Log(audit.NewEntry(types.ActionSecretExport, true).WithDetails(details).Build())
+}
+
+// formatTableTime formats t for a human-readable table, in local time.
+func formatTableTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listNoRotation, "no-rotation", false, "Only list secrets without a rotation hook")
+	listCmd.Flags().StringVar(&listNamespace, "namespace", "", "Only list secrets in this namespace")
+	listCmd.Flags().StringArrayVar(&listLabels, "label", nil, "Only list secrets with this label, as KEY=VALUE (repeatable, all must match)")
+	listCmd.Flags().BoolVar(&listExportValues, "export-values", false, "Print NAME=\"value\" for each secret, read from the local store (requires --confirm)")
+	listCmd.Flags().BoolVar(&listConfirm, "confirm", false, "Confirm printing secret values with --export-values")
+}
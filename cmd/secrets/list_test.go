@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/audit"
+	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// localStore creates an initialized store holding secrets and points the
+// CLI's config at it for the duration of the test.
+func localStore(t *testing.T, secrets map[string]string) *config.Config {
+	t.Helper()
+
+	// Short enough for the daemon's socket
+	dir, err := os.MkdirTemp("", "secrets-list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.EnvDir, dir)
+
+	cfg := config.DefaultConfig()
+	st := store.New(cfg)
+	if err := st.Init(); err != nil {
+		t.Fatalf("failed to init store: %v", err)
+	}
+	for name, value := range secrets {
+		if err := st.Add(name, value, ""); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+	return cfg
+}
+
+// withExportFlags sets --export-values and --confirm for the duration of the
+// test.
+func withExportFlags(t *testing.T, export, confirm bool) {
+	t.Helper()
+	oldExport, oldConfirm := listExportValues, listConfirm
+	listExportValues, listConfirm = export, confirm
+	t.Cleanup(func() { listExportValues, listConfirm = oldExport, oldConfirm })
+}
+
+var listTestSecrets = map[string]string{
+	"api_key":        "sk-list-test-value",
+	"prod::db_token": "db-list-test-value",
+}
+
+func TestList_ExportValuesRequiresConfirm(t *testing.T) {
+	localStore(t, listTestSecrets)
+	withExportFlags(t, true, false)
+	stdout := captureStdout(t)
+
+	if err := listCmd.RunE(listCmd, nil); err == nil {
+		t.Fatal("expected an error without --confirm")
+	}
+	out := stdout()
+	for _, value := range listTestSecrets {
+		if strings.Contains(out, value) {
+			t.Errorf("value printed without --confirm:\n%s", out)
+		}
+	}
+}
+
+// withListNamespace sets --namespace for the duration of the test.
+func withListNamespace(t *testing.T, namespace string) {
+	t.Helper()
+	old := listNamespace
+	listNamespace = namespace
+	t.Cleanup(func() { listNamespace = old })
+}
+
+func TestList_ExportValues(t *testing.T) {
+	multiline := "  line one\nline \"two\" # not a comment\t"
+	cfg := localStore(t, map[string]string{
+		"api_key":        "sk-list-test-value",
+		"prod::db_token": "db-list-test-value",
+		"prod::cert":     multiline,
+	})
+	st := store.New(cfg)
+	if err := st.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.AddBinary("prod::tls_key", []byte{0xff, 0x00, 0x01}, "", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	withExportFlags(t, true, true)
+	withListNamespace(t, "prod")
+	// The export must not go through the daemon
+	withSocket(t, serveRPC(t, func(req types.RPCRequest) interface{} {
+		t.Errorf("export called the daemon: %s", req.Method)
+		return nil
+	}))
+	stdout := captureStdout(t)
+
+	if err := listCmd.RunE(listCmd, nil); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	out := stdout()
+	want := `cert="  line one\nline \"two\" # not a comment\t"` + "\n" + `db_token="db-list-test-value"` + "\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	// The export is audited by name, without values
+	logger, err := audit.New(cfg.AuditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	entries, err := logger.Tail(1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected an audit entry, got %v (%v)", entries, err)
+	}
+	if e := entries[0]; e.Action != types.ActionSecretExport || !strings.Contains(e.Details, "prod::db_token") || !strings.Contains(e.Details, "prod::tls_key") || strings.Contains(e.Details, "db-list-test-value") {
+		t.Errorf("unexpected audit entry: %+v", e)
+	}
+
+	// The output imports back to the same values
+	path := filepath.Join(t.TempDir(), "prod.env")
+	if err := os.WriteFile(path, []byte(out), 0600); err != nil {
+		t.Fatal(err)
+	}
+	imported := make(chan daemon.ImportParams, 1)
+	withSocket(t, serveRPC(t, func(req types.RPCRequest) interface{} {
+		var params daemon.ImportParams
+		data, _ := json.Marshal(req.Params)
+		_ = json.Unmarshal(data, &params)
+		imported <- params
+		return daemon.ImportResult{Added: []string{}, Skipped: []string{}}
+	}))
+	captureStdout(t)
+	if err := importCmd.RunE(importCmd, []string{path}); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	wantVars := map[string]string{"cert": multiline, "db_token": "db-list-test-value"}
+	if got := (<-imported).Vars; !reflect.DeepEqual(got, wantVars) {
+		t.Errorf("imported %q, want %q", got, wantVars)
+	}
+}
+
+func TestList_ExportValuesSeveralNamespaces(t *testing.T) {
+	localStore(t, listTestSecrets)
+	withExportFlags(t, true, true)
+	stdout := captureStdout(t)
+
+	if err := listCmd.RunE(listCmd, nil); err == nil {
+		t.Fatal("expected an error exporting several namespaces")
+	}
+	out := stdout()
+	for _, value := range listTestSecrets {
+		if strings.Contains(out, value) {
+			t.Errorf("value printed for a mixed export:\n%s", out)
+		}
+	}
+}
+
+func TestList_DaemonNeverReturnsValues(t *testing.T) {
+	cfg := localStore(t, listTestSecrets)
+	cfg.SocketPath = cfg.Directory + "/d.sock"
+	d, err := daemon.NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { d.Stop() })
+	withSocket(t, cfg.SocketPath)
+
+	// --confirm alone does not export
+	withExportFlags(t, false, true)
+	stdout := captureStdout(t)
+
+	if err := listCmd.RunE(listCmd, nil); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	out := stdout()
+	if !strings.Contains(out, "prod::db_token") {
+		t.Fatalf("expected the secrets to be listed, got:\n%s", out)
+	}
+	for _, value := range listTestSecrets {
+		if strings.Contains(out, value) {
+			t.Errorf("daemon list exposed a value:\n%s", out)
+		}
+	}
+}
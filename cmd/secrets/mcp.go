@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joelhooks/agent-secrets/internal/mcp"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var mcpClientID string
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve secrets as MCP tools over stdio",
+	Long: `Run a Model Context Protocol server on stdin and stdout, so LLM agents can
+use the daemon through tool calls. The tools are:
+
+  list    List secret names and metadata
+  lease   Acquire a lease on a secret; the only way to read a value
+  revoke  Revoke a lease
+  status  Show the daemon status
+  scan    Scan a path for hardcoded secrets, with values redacted
+
+Tool calls go to the running daemon, so start it first. Leases are
+recorded in the audit log under --client-id.
+
+Example MCP client config:
+  {"mcpServers": {"secrets": {"command": "secrets", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		call := func(method string, params interface{}) (*types.RPCResponse, error) {
+			return rpcCall(socketPath, method, params)
+		}
+		server := mcp.NewServer(call, output.Version, mcpClientID)
+
+		// stdout carries the protocol, so errors can only go to stderr
+		if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	mcpCmd.Flags().StringVar(&mcpClientID, "client-id", "mcp", "Client identifier recorded on leases acquired through MCP")
+}
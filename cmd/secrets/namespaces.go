@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var nsDeleteConfirm bool
+
+var nsCmd = &cobra.Command{
+	Use:     "ns",
+	Aliases: []string{"namespaces"},
+	Short:   "Manage secret namespaces",
+	Long: `Manage namespaces. A secret's namespace is the part of its name before
+"::" (e.g. "prod" in "prod::DATABASE_URL"); secrets without a prefix are
+in the "default" namespace.
+
+Deleting a namespace deletes every secret in it and revokes their leases.
+It cannot be undone, so --confirm is required.
+
+Examples:
+  secrets ns list
+  secrets ns delete staging --confirm`,
+}
+
+var nsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List namespaces that hold secrets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodNamespaces, daemon.NamespacesParams{})
+		if err != nil {
+			return nsRPCError(err, "list namespaces")
+		}
+
+		var result daemon.NamespacesResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"namespaces": result.Namespaces,
+			"count":      len(result.Namespaces),
+		}
+		if len(result.Namespaces) == 0 {
+			output.Print(output.Success("No secrets stored", data, output.ActionsWhenEmpty()...))
+			return nil
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("%d namespaces", len(result.Namespaces)),
+			data,
+			output.Action{
+				Name:        "list",
+				Description: "List the secrets in a namespace",
+				Command:     "secrets list --namespace " + result.Namespaces[0],
+			},
+		))
+		return nil
+	},
+}
+
+var nsDeleteCmd = &cobra.Command{
+	Use:   "delete <namespace>",
+	Short: "Delete every secret in a namespace and revoke their leases",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		namespace := args[0]
+		if !nsDeleteConfirm {
+			userErr := types.NewUserError(
+				"Confirmation required",
+				fmt.Sprintf("Deleting namespace %q permanently deletes every secret in it and revokes their leases.", namespace),
+				fmt.Sprintf("To delete it:\n  secrets ns delete %s --confirm", namespace),
+				"secrets ns delete --help",
+			)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodDeleteNamespace, daemon.DeleteNamespaceParams{Namespace: namespace})
+		if err != nil {
+			return nsRPCError(err, "delete namespace")
+		}
+
+		var result daemon.DeleteNamespaceResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Deleted namespace %q: %d secrets, %d leases revoked", namespace, result.Deleted, result.RevokedLeases),
+			map[string]interface{}{
+				"namespace":      namespace,
+				"deleted":        result.Deleted,
+				"revoked_leases": result.RevokedLeases,
+			},
+			output.Action{
+				Name:        "list",
+				Description: "List the remaining namespaces",
+				Command:     "secrets ns list",
+			},
+		))
+		return nil
+	},
+}
+
+// nsRPCError reports a failed namespace call.
+func nsRPCError(err error, doing string) error {
+	if isDaemonConnectionError(err) {
+		userErr := types.NewUserError(
+			"Failed to connect to daemon",
+			"The daemon doesn't appear to be running. Without the daemon, namespaces cannot be managed.",
+			"To start it:\n  secrets serve &",
+			"secrets ns --help",
+		).WithContext("Socket path", socketPath)
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+	output.Print(output.Error(fmt.Errorf("failed to %s: %w", doing, err)))
+	return fmt.Errorf("failed to %s: %w", doing, err)
+}
+
+func init() {
+	nsDeleteCmd.Flags().BoolVar(&nsDeleteConfirm, "confirm", false, "Confirm deleting every secret in the namespace")
+
+	nsCmd.AddCommand(nsListCmd)
+	nsCmd.AddCommand(nsDeleteCmd)
+}
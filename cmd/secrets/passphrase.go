@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"golang.org/x/term"
+)
+
+// promptPassphrase unlocks a passphrase-protected identity. AGENT_SECRETS_PASSPHRASE
+// wins so scripts and the daemon never block; otherwise the user is asked on
+// the terminal. Prompts go to stderr to keep stdout parseable.
+func promptPassphrase(path string) (string, error) {
+	if passphrase := os.Getenv(store.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return store.EnvPassphrase(path)
+	}
+	return readPassphrase(fmt.Sprintf("Passphrase for %s: ", path))
+}
+
+// newPassphrase asks for a passphrase to protect a new identity, twice on a
+// terminal, or takes it from AGENT_SECRETS_PASSPHRASE.
+func newPassphrase() (string, error) {
+	if passphrase := os.Getenv(store.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", types.NewUserError(
+			"No passphrase for the new identity",
+			"--passphrase needs a terminal to prompt on, or the passphrase in "+store.PassphraseEnv+".",
+			"Run interactively, or set the variable:\n  "+store.PassphraseEnv+"=... secrets init --passphrase",
+			"secrets init --help",
+		)
+	}
+
+	passphrase, err := readPassphrase("New identity passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+	confirm, err := readPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm != passphrase {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr) // Add newline after hidden input
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(passphrase), nil
+}
+
+func init() {
+	store.Passphrase = promptPassphrase
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/spf13/cobra"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named profiles",
+	Long: `Manage profiles: fully separate stores, each with its own identity,
+secrets, leases, audit log and daemon socket under
+~/.agent-secrets/profiles/<name>/.
+
+Select a profile for one command with --profile, or record one with
+'secrets profile use' for every command that follows. The "default"
+profile is the store in ~/.agent-secrets itself, used when no profile is
+selected. Each profile needs its own 'secrets init' and 'secrets serve'.
+
+Examples:
+  secrets --profile work init
+  secrets profile use work
+  secrets profile list
+  secrets profile use default`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles and show the active one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := config.Profiles()
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to list profiles: %w", err)))
+			return fmt.Errorf("failed to list profiles: %w", err)
+		}
+		active, err := currentProfile()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		if active == "" {
+			active = config.DefaultProfile
+		}
+
+		profiles := append([]string{config.DefaultProfile}, names...)
+		rows := make([][]string, len(profiles))
+		for i, name := range profiles {
+			marker := ""
+			if name == active {
+				marker = "*"
+			}
+			rows[i] = []string{marker, name}
+		}
+
+		data := map[string]interface{}{
+			"profiles": profiles,
+			"active":   active,
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Active profile: %s", active),
+			data,
+			output.Action{
+				Name:        "use",
+				Description: "Switch profiles",
+				Command:     "secrets profile use <name>",
+			},
+		).WithTable([]string{"ACTIVE", "PROFILE"}, rows))
+		return nil
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a profile the default for later commands",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if err := config.SetActiveProfile(name); err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to switch profile: %w", err)))
+			return fmt.Errorf("failed to switch profile: %w", err)
+		}
+
+		dir := config.DefaultConfig().Directory
+		if name != config.DefaultProfile {
+			// SetActiveProfile has validated the name
+			dir, _ = config.ProfileDir(name)
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Switched to profile %s", name),
+			map[string]interface{}{
+				"profile":   name,
+				"directory": dir,
+			},
+			output.Action{
+				Name:        "init",
+				Description: "Initialize the profile's store if it is new",
+				Command:     "secrets init",
+			},
+			output.Action{
+				Name:        "serve",
+				Description: "Start the profile's daemon",
+				Command:     "secrets serve &",
+			},
+		))
+		return nil
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileUseCmd)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/project"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushNamespace string
+	pushDryRun    bool
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push [name...]",
+	Short: "Publish secrets from the store to the project source",
+	Long: `Publish secrets from the encrypted store back to the source configured
+in .secrets.json (e.g., Vercel).
+
+Secrets are read through the daemon using short-lived leases that are
+revoked as soon as their values have been collected. By default every secret
+in the default namespace is pushed; use --namespace to push a named namespace,
+or pass secret names to push only those keys.
+
+Keys that do not yet exist in the source are created; existing keys are
+overwritten.
+
+Examples:
+  secrets push                          # Push the default namespace
+  secrets push --namespace prod         # Push secrets stored as prod::NAME
+  secrets push API_KEY DATABASE_URL     # Push only these keys
+  secrets push --dry-run                # Show what would be created/updated`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Find project configuration
+		cfg, projectDir, err := project.FindProjectConfig()
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to find project config: %w", err)))
+			return err
+		}
+
+		// Push needs a single destination
+		specs := cfg.SourceList()
+		if len(specs) != 1 {
+			userErr := types.NewUserError(
+				"Cannot push to multiple sources",
+				fmt.Sprintf(".secrets.json lists %d sources, so there is no single destination to push to.", len(specs)),
+				"Push from a directory whose .secrets.json has a single source.",
+				"secrets push --help",
+			).WithContext("Sources", sourceHeader(cfg))
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+		spec := specs[0]
+
+		// Get adapter based on source
+		adapter, err := getAdapter(spec.Source, projectDir)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to get adapter: %w", err)))
+			return err
+		}
+
+		// Collect values from the store
+		vars, err := fetchStoreSecrets("secrets-push", pushNamespace, args, false)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be read from the store.",
+					"To start it:\n  secrets serve &",
+					"secrets --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to read secrets from store: %w", err)))
+			return err
+		}
+
+		if len(vars) == 0 {
+			output.Print(output.ErrorMsg(
+				fmt.Sprintf("no secrets to push in namespace %q", namespaceOrDefault(pushNamespace)),
+				output.ActionsWhenEmpty()...,
+			))
+			return fmt.Errorf("no secrets to push")
+		}
+
+		// Classify keys against what the source already has
+		existing, err := adapter.Pull(spec.Project, spec.Scope)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to pull existing secrets: %w", err)))
+			return err
+		}
+
+		created := []string{}
+		updated := []string{}
+		for key := range vars {
+			if _, ok := existing[key]; ok {
+				updated = append(updated, key)
+			} else {
+				created = append(created, key)
+			}
+		}
+		sort.Strings(created)
+		sort.Strings(updated)
+
+		data := map[string]interface{}{
+			"source":    spec.Source,
+			"project":   spec.Project,
+			"scope":     spec.Scope,
+			"namespace": namespaceOrDefault(pushNamespace),
+			"created":   created,
+			"updated":   updated,
+		}
+
+		if pushDryRun {
+			output.Print(output.Success(
+				fmt.Sprintf("Would push %d vars to %s: %d created, %d updated (dry-run)", len(vars), spec.Source, len(created), len(updated)),
+				data,
+				output.Action{
+					Name:        "push",
+					Description: "Run push without --dry-run",
+					Command:     "secrets push",
+				},
+			))
+			return nil
+		}
+
+		if err := adapter.Push(spec.Project, spec.Scope, vars); err != nil {
+			if errors.Is(err, types.ErrPushNotSupported) {
+				userErr := types.NewUserError(
+					fmt.Sprintf("Source %q does not support push", spec.Source),
+					"Secrets can only be pulled from this source.",
+					"Edit the source directly, or switch .secrets.json to a source that supports push (e.g., vercel).",
+					"secrets push --help",
+				).WithContext("Source", spec.Source)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to push secrets: %w", err)))
+			return err
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Pushed %d vars to %s: %d created, %d updated", len(vars), spec.Source, len(created), len(updated)),
+			data,
+			output.ActionEnvForce(),
+			output.ActionAudit(),
+		))
+		return nil
+	},
+}
+
+func init() {
+	pushCmd.Flags().StringVar(&pushNamespace, "namespace", "", "Push secrets from this namespace (default: the default namespace)")
+	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "Show which keys would be created or updated without pushing")
+}
+
+// fetchStoreSecrets reads secret values from the daemon for every secret in
+// namespace, keyed by bare name. If names is non-empty, only those secrets are
+// read. Each value is fetched with a short lease for clientID that is revoked
+// immediately. Binary secrets are returned base64-encoded, as stored, unless
+// decodeBinary is set.
+func fetchStoreSecrets(clientID, namespace string, names []string, decodeBinary bool) (map[string]string, error) {
+	resp, err := rpcCall(socketPath, daemon.MethodList, daemon.ListParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	var list daemon.ListResult
+	if err := decodeResult(resp, &list); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	vars := make(map[string]string)
+	for _, secret := range list.Secrets {
+		ns, name := types.SplitRef(secret.Name)
+		if ns != namespaceOrDefault(namespace) {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[name] {
+			continue
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodLease, daemon.LeaseParams{
+			SecretName: secret.Name,
+			ClientID:   clientID,
+			TTL:        "1m",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to lease %s: %w", secret.Name, err)
+		}
+
+		var lease daemon.LeaseResult
+		if err := decodeResult(resp, &lease); err != nil {
+			return nil, err
+		}
+		vars[name] = lease.Value
+		if lease.Binary && decodeBinary {
+			data, err := store.DecodeBinary(lease.Value)
+			if err != nil {
+				return nil, types.NewSecretError(secret.Name, err)
+			}
+			vars[name] = string(data)
+		}
+
+		// Best effort: the lease expires on its own if this fails
+		_, _ = rpcCall(socketPath, daemon.MethodRevoke, daemon.RevokeParams{LeaseID: lease.LeaseID})
+	}
+
+	for name := range wanted {
+		if _, ok := vars[name]; !ok {
+			return nil, types.NewSecretError(types.JoinRef(namespace, name), types.ErrSecretNotFound)
+		}
+	}
+
+	return vars, nil
+}
+
+// decodeResult decodes an RPC response's result into v.
+func decodeResult(resp *types.RPCResponse, v interface{}) error {
+	data, err := json.Marshal(resp.Result)
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse result: %w", err)
+	}
+	return nil
+}
+
+// namespaceOrDefault returns namespace, or the default namespace if empty.
+func namespaceOrDefault(namespace string) string {
+	if namespace == "" {
+		return types.DefaultNamespace
+	}
+	return namespace
+}
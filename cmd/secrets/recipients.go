@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var recipientsCmd = &cobra.Command{
+	Use:   "recipients",
+	Short: "Manage who can decrypt the store",
+	Long: `Manage the age recipients the store is encrypted to. Every recipient's
+identity can decrypt the secrets file, so a team can share one store. The
+store's own identity is always a recipient.
+
+The store is re-encrypted whenever a recipient is added or removed. A
+removed recipient can still read earlier copies of the file, such as
+backups, so rotate any secrets they had access to.
+
+Examples:
+  secrets recipients list
+  secrets recipients add age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+  secrets recipients remove age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p`,
+}
+
+var recipientsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the recipients the store is encrypted to",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodRecipients, daemon.RecipientsParams{})
+		if err != nil {
+			return recipientsRPCError(err, "list recipients")
+		}
+
+		var result daemon.RecipientsResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Store is encrypted to %d recipients", len(result.Recipients)),
+			map[string]interface{}{
+				"recipients": result.Recipients,
+				"count":      len(result.Recipients),
+			},
+		))
+		return nil
+	},
+}
+
+var recipientsAddCmd = &cobra.Command{
+	Use:   "add <public-key>",
+	Short: "Add a recipient and re-encrypt the store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeRecipient(daemon.MethodAddRecipient, args[0], "Recipient added")
+	},
+}
+
+var recipientsRemoveCmd = &cobra.Command{
+	Use:   "remove <public-key>",
+	Short: "Remove a recipient and re-encrypt the store",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return changeRecipient(daemon.MethodRemoveRecipient, args[0], "Recipient removed")
+	},
+}
+
+// changeRecipient calls secrets.addRecipient or secrets.removeRecipient.
+func changeRecipient(method, recipient, msg string) error {
+	resp, err := rpcCall(socketPath, method, daemon.RecipientParams{Recipient: recipient})
+	if err != nil {
+		return recipientsRPCError(err, "update recipients")
+	}
+
+	var result daemon.RecipientResult
+	if err := decodeResult(resp, &result); err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	output.Print(output.Success(
+		msg+"; store re-encrypted",
+		map[string]interface{}{
+			"recipient": recipient,
+		},
+		output.Action{
+			Name:        "list",
+			Description: "List the recipients the store is encrypted to",
+			Command:     "secrets recipients list",
+		},
+	))
+	return nil
+}
+
+// recipientsRPCError reports a failed recipients call.
+func recipientsRPCError(err error, doing string) error {
+	if isDaemonConnectionError(err) {
+		userErr := types.NewUserError(
+			"Failed to connect to daemon",
+			"The daemon doesn't appear to be running. Without the daemon, recipients cannot be managed.",
+			"To start it:\n  secrets serve &",
+			"secrets recipients --help",
+		).WithContext("Socket path", socketPath)
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+	output.Print(output.Error(fmt.Errorf("failed to %s: %w", doing, err)))
+	return fmt.Errorf("failed to %s: %w", doing, err)
+}
+
+func init() {
+	recipientsCmd.AddCommand(recipientsListCmd)
+	recipientsCmd.AddCommand(recipientsAddCmd)
+	recipientsCmd.AddCommand(recipientsRemoveCmd)
+}
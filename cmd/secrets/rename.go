@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var renameCmd = &cobra.Command{
+	Use:   "rename <old> <new>",
+	Short: "Rename a secret, keeping its value and metadata",
+	Long: `Rename a secret in place. Unlike deleting and re-adding it, the value,
+creation and rotation timestamps, rotation hook, and propagation targets
+are all kept. Either name may include a namespace, so rename can also move
+a secret between namespaces.
+
+Leases acquired under the old name are revoked; holders must lease the
+secret again under its new name.
+
+Examples:
+  secrets rename DB_PASS DATABASE_PASSWORD
+  secrets rename api_key prod::api_key        # Move into a namespace
+  secrets rename stage::db prod::db           # Move between namespaces`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		params := daemon.RenameParams{Name: args[0], NewName: args[1]}
+
+		resp, err := rpcCall(socketPath, daemon.MethodRename, params)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be renamed.",
+					"To start it:\n  secrets serve &",
+					"secrets rename --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to rename secret: %w", err)))
+			return fmt.Errorf("failed to rename secret: %w", err)
+		}
+
+		var result daemon.RenameResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Renamed %s to %s", args[0], args[1]),
+			map[string]interface{}{
+				"name":     args[1],
+				"old_name": args[0],
+			},
+			output.ActionLease(args[1]),
+		))
+		return nil
+	},
+}
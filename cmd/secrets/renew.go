@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var renewTTL string
+
+var renewCmd = &cobra.Command{
+	Use:   "renew <lease-id>",
+	Short: "Extend a lease without fetching the secret again",
+	Long: `Extend an active lease so it expires --ttl from now. The secret value is
+not returned again; keep using the one from the original lease.
+
+A lease's total lifetime, from when it was first acquired, may not exceed the
+daemon's max_lease_ttl. Expired and revoked leases cannot be renewed; acquire
+a new one with 'secrets lease'.
+
+Examples:
+  secrets renew 0b7d6c1e-...            # Extend by the default lease TTL
+  secrets renew 0b7d6c1e-... --ttl 30m`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		leaseID := args[0]
+
+		resp, err := rpcCall(socketPath, daemon.MethodRenew, daemon.RenewParams{LeaseID: leaseID, TTL: renewTTL})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, leases cannot be renewed.",
+					"To start it:\n  secrets serve &",
+					"secrets renew --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to renew lease: %w", err)))
+			return fmt.Errorf("failed to renew lease: %w", err)
+		}
+
+		var result daemon.RenewResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err, output.ActionLease("")))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"lease_id":    result.LeaseID,
+			"secret_name": result.SecretName,
+			"expires_at":  result.ExpiresAt.Format(time.RFC3339),
+			"expires_in":  time.Until(result.ExpiresAt).Round(time.Second).String(),
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Lease renewed until %s", result.ExpiresAt.Format(time.RFC3339)),
+			data,
+			output.ActionRevoke(result.LeaseID),
+			output.ActionStatus(),
+		))
+		return nil
+	},
+}
+
+func init() {
+	renewCmd.Flags().StringVar(&renewTTL, "ttl", "", "How long from now the lease should last (default: the daemon's default lease TTL)")
+}
@@ -9,7 +9,11 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var revokeAll bool
+var (
+	revokeAll      bool
+	revokeClientCN string
+	revokeClientID string
+)
 
 var revokeCmd = &cobra.Command{
 	Use:   "revoke [lease-id]",
@@ -17,9 +21,17 @@ var revokeCmd = &cobra.Command{
 	Long: `Revoke a specific lease by ID, or use --all to trigger the killswitch and
 revoke all active leases.
 
+Use --client to revoke every lease held by one client ID (e.g. when an agent
+is compromised), without touching other clients' leases.
+
+Use --client-cn to revoke every lease acquired over the TCP transport with a
+given client certificate identity (e.g. when a client cert is compromised).
+
 Examples:
-  secrets revoke lease-abc123       # Revoke specific lease
-  secrets revoke --all              # Revoke all leases (killswitch)`,
+  secrets revoke lease-abc123            # Revoke specific lease
+  secrets revoke --client build-agent    # Revoke all leases for a client ID
+  secrets revoke --client-cn ci-runner-1 # Revoke all leases for a cert identity
+  secrets revoke --all                   # Revoke all leases (killswitch)`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if revokeAll {
@@ -59,6 +71,96 @@ Examples:
 			return nil
 		}
 
+		if revokeClientID != "" {
+			params := daemon.RevokeClientParams{
+				ClientID: revokeClientID,
+			}
+
+			resp, err := rpcCall(socketPath, daemon.MethodRevokeClient, params)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to revoke leases for client %q: %w", revokeClientID, err)))
+				return nil
+			}
+
+			var result daemon.RevokeClientResult
+			data, err := json.Marshal(resp.Result)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to parse response: %w", err)))
+				return nil
+			}
+			if err := json.Unmarshal(data, &result); err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to parse result: %w", err)))
+				return nil
+			}
+
+			if !result.Success {
+				output.Print(output.ErrorMsg(result.Message))
+				return nil
+			}
+
+			revokeData := map[string]interface{}{
+				"client_id":      revokeClientID,
+				"leases_revoked": result.LeasesRevoked,
+			}
+
+			actions := []output.Action{
+				output.ActionStatus(),
+				output.ActionAudit(),
+			}
+
+			output.Print(output.Success(
+				fmt.Sprintf("Revoked %d leases for client %q", result.LeasesRevoked, revokeClientID),
+				revokeData,
+				actions...,
+			))
+			return nil
+		}
+
+		if revokeClientCN != "" {
+			params := daemon.RevokeByClientParams{
+				ClientCN: revokeClientCN,
+			}
+
+			resp, err := rpcCall(socketPath, daemon.MethodRevokeByClient, params)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to revoke leases for client %q: %w", revokeClientCN, err)))
+				return nil
+			}
+
+			var result daemon.RevokeByClientResult
+			data, err := json.Marshal(resp.Result)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to parse response: %w", err)))
+				return nil
+			}
+			if err := json.Unmarshal(data, &result); err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to parse result: %w", err)))
+				return nil
+			}
+
+			if !result.Success {
+				output.Print(output.ErrorMsg(result.Message))
+				return nil
+			}
+
+			revokeData := map[string]interface{}{
+				"client_cn":      revokeClientCN,
+				"leases_revoked": result.LeasesRevoked,
+			}
+
+			actions := []output.Action{
+				output.ActionStatus(),
+				output.ActionAudit(),
+			}
+
+			output.Print(output.Success(
+				fmt.Sprintf("Revoked %d leases for client %q", result.LeasesRevoked, revokeClientCN),
+				revokeData,
+				actions...,
+			))
+			return nil
+		}
+
 		// Revoke specific lease
 		if len(args) == 0 {
 			output.Print(output.ErrorMsg("lease-id required (or use --all for killswitch)", output.ActionHelp("revoke")))
@@ -108,4 +210,6 @@ Examples:
 
 func init() {
 	revokeCmd.Flags().BoolVar(&revokeAll, "all", false, "Trigger killswitch: revoke all active leases")
+	revokeCmd.Flags().StringVar(&revokeClientCN, "client-cn", "", "Revoke all leases acquired with this client certificate CN")
+	revokeCmd.Flags().StringVar(&revokeClientID, "client", "", "Revoke all leases held by this client ID")
 }
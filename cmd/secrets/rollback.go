@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <name>",
+	Short: "Restore the previous value of a secret",
+	Long: `Restore a secret to its previous value, as listed by "secrets history".
+The current value is discarded, so running rollback again steps further
+back. Existing leases keep the value they were granted; lease the secret
+again to get the restored one.
+
+Examples:
+  secrets rollback api_key`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		resp, err := rpcCall(socketPath, daemon.MethodRollback, daemon.RollbackParams{Name: args[0]})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be rolled back.",
+					"To start it:\n  secrets serve &",
+					"secrets rollback --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to roll back secret: %w", err)))
+			return fmt.Errorf("failed to roll back secret: %w", err)
+		}
+
+		var result daemon.RollbackResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Rolled back %s to its previous value", args[0]),
+			map[string]interface{}{
+				"name": args[0],
+			},
+			output.ActionLease(args[0]),
+		))
+		return nil
+	},
+}
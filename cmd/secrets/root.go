@@ -14,6 +14,8 @@ var (
 	noUpdateCheck       bool
 	timeoutSeconds      int
 	skipPermissionCheck bool
+	humanOutput         bool
+	profileName         string
 )
 
 var rootCmd = &cobra.Command{
@@ -22,13 +24,26 @@ var rootCmd = &cobra.Command{
 	Long: `agent-secrets provides secure, time-bounded credential management with
 audit logging, rotation hooks, and killswitch capabilities for AI agents.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// --human is an alias for --output table
+		if humanOutput && output.OutputFormat == "" {
+			output.OutputFormat = string(output.ModeTable)
+		}
 		// Validate output format flag
 		if err := output.ValidateMode(output.OutputFormat); err != nil {
 			return err
 		}
+		if err := output.ValidateTemplate(output.Template, output.AllowValuesInTemplate); err != nil {
+			return err
+		}
 
-		// Skip update check if disabled or if running the update command itself
-		if noUpdateCheck || cmd.Name() == "update" {
+		// Skip update check if disabled, if running the update command itself,
+		// while generating or answering shell completions, or when stdout
+		// carries the MCP protocol
+		switch cmd.Name() {
+		case "update", "completion", "mcp", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+			return nil
+		}
+		if noUpdateCheck {
 			return nil
 		}
 
@@ -39,10 +54,15 @@ audit logging, rotation hooks, and killswitch capabilities for AI agents.`,
 }
 
 func init() {
-	rootCmd.PersistentFlags().BoolVar(&output.HumanMode, "human", false, "Human-readable output (deprecated: use --output table)")
-	rootCmd.PersistentFlags().StringVar(&output.OutputFormat, "output", "", "Output format: json, table, or raw (default: auto-detect based on TTY)")
+	rootCmd.PersistentFlags().BoolVar(&humanOutput, "human", false, "Human-readable output (alias for --output table)")
+	rootCmd.PersistentFlags().StringVar(&output.OutputFormat, "output", "", "Output format: json, yaml, table, or raw (default: auto-detect based on TTY)")
+	rootCmd.PersistentFlags().BoolVar(&output.NoColor, "no-color", false, "Disable colored output (also set by NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&output.Quiet, "quiet", false, "Print only the data, without messages or suggested actions; errors go to stderr")
+	rootCmd.PersistentFlags().StringVar(&output.Template, "template", "", "Format output with a Go template (e.g., '{{.Data.lease_id}}')")
+	rootCmd.PersistentFlags().BoolVar(&output.AllowValuesInTemplate, "allow-values-in-template", false, "Allow --template to reference secret values")
 	rootCmd.PersistentFlags().StringVar(&socketPath, "socket", "", "Override Unix socket path")
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Override config file path")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Use a named profile's store (see 'secrets profile')")
 	rootCmd.PersistentFlags().BoolVar(&noUpdateCheck, "no-update-check", false, "Disable automatic update check (useful for CI)")
 	rootCmd.PersistentFlags().IntVar(&timeoutSeconds, "timeout", 5, "Timeout in seconds for daemon socket operations")
 	rootCmd.PersistentFlags().BoolVar(&skipPermissionCheck, "skip-permission-check", false, "Skip file permission validation (for edge cases)")
@@ -50,16 +70,47 @@ func init() {
 	// Add all subcommands
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(addFileCmd)
+	rootCmd.AddCommand(getFileCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(nsCmd)
+	rootCmd.AddCommand(renameCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(compactCmd)
+	rootCmd.AddCommand(dedupeCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(verifyStoreCmd)
+	rootCmd.AddCommand(rotateCmd)
+	rootCmd.AddCommand(rotatePolicyCmd)
+	rootCmd.AddCommand(rotateIdentityCmd)
+	rootCmd.AddCommand(recipientsCmd)
 	rootCmd.AddCommand(leaseCmd)
+	rootCmd.AddCommand(renewCmd)
 	rootCmd.AddCommand(revokeCmd)
+	rootCmd.AddCommand(keepaliveCmd)
+	rootCmd.AddCommand(killswitchCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(profileCmd)
 	rootCmd.AddCommand(auditCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(healthCmd)
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(installHookCmd)
 	rootCmd.AddCommand(cleanupCmd)
 	rootCmd.AddCommand(envCmd)
+	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(mcpCmd)
+	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(filesCmd)
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(completionCmd)
 	rootCmd.AddCommand(updateCmd)
 }
 
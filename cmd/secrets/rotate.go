@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/rotation"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rotateAll    bool
+	rotateReport string
+)
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate [name]",
+	Short: "Run a secret's rotation hook now",
+	Long: `Run the rotation hook of a secret, or of every secret that has one with
+--all. Hook output is shown but never written to the report.
+
+The hook sees the secret's name in SECRET_NAME and its current value in
+SECRET_CURRENT_VALUE, for calling the provider's rotate API. It can hand back
+the new credential on stdout, as a line SECRET_VALUE=<value> or as the JSON
+{"value": "<value>"}; that replaces the stored value. Neither value is shown
+in the hook output.
+
+--report writes a JSON report of an --all run: for each secret its trigger,
+outcome, exit code, duration and value fingerprints before and after the
+hook, plus totals. The command exits non-zero if any rotation failed.
+
+Examples:
+  secrets rotate github_token
+  secrets rotate --all
+  secrets rotate --all --report rotation-report.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if rotateAll == (len(args) == 1) {
+			userErr := types.NewUserError(
+				"Specify a secret or --all",
+				"rotate needs either a secret name or --all, but not both.",
+				"Rotate one secret:\n  secrets rotate <name>\n\nRotate every secret with a hook:\n  secrets rotate --all",
+				"secrets rotate --help",
+			)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+		if rotateReport != "" && !rotateAll {
+			userErr := types.NewUserError(
+				"--report requires --all",
+				"Reports cover a batch of rotations.",
+				"secrets rotate --all --report <file>",
+				"secrets rotate --help",
+			)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+
+		if rotateAll {
+			return runRotateAll(cmd)
+		}
+		return runRotate(args[0])
+	},
+}
+
+func init() {
+	rotateCmd.Flags().BoolVar(&rotateAll, "all", false, "Rotate every secret with a rotation hook")
+	rotateCmd.Flags().StringVar(&rotateReport, "report", "", "Write a JSON rotation report to this file (with --all)")
+}
+
+// runRotate rotates a single secret.
+func runRotate(name string) error {
+	resp, err := rpcCall(socketPath, daemon.MethodRotate, daemon.RotateParams{SecretName: name})
+	if err != nil {
+		return rotateRPCError(err)
+	}
+
+	var result daemon.RotateResult
+	if err := decodeResult(resp, &result); err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	data := map[string]interface{}{
+		"secret":          name,
+		"exit_code":       result.ExitCode,
+		"duration":        result.Duration.String(),
+		"old_fingerprint": result.OldFingerprint,
+		"new_fingerprint": result.NewFingerprint,
+		"value_updated":   result.ValueUpdated,
+		"output":          result.Output,
+	}
+	if len(result.Propagated) > 0 {
+		data["propagated"] = result.Propagated
+	}
+	output.Print(output.Success(fmt.Sprintf("Rotated %s", name), data, output.ActionLease(name)))
+	return nil
+}
+
+// runRotateAll rotates every secret with a hook and optionally writes a
+// report of the run.
+func runRotateAll(cmd *cobra.Command) error {
+	resp, err := rpcCall(socketPath, daemon.MethodRotateAll, nil)
+	if err != nil {
+		return rotateRPCError(err)
+	}
+
+	var result daemon.RotateAllResult
+	if err := decodeResult(resp, &result); err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	report := rotation.NewReport(result.Results)
+	if rotateReport != "" {
+		if err := rotation.WriteReport(rotateReport, report); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+	}
+
+	data := map[string]interface{}{
+		"rotations": report.Rotations,
+		"totals":    report.Totals,
+	}
+	if rotateReport != "" {
+		data["report"] = rotateReport
+	}
+
+	totals := report.Totals
+	if totals.Attempted == 0 {
+		output.Print(output.Success("No secrets have rotation hooks", data, output.ActionAddWithRotation()))
+		return nil
+	}
+	output.Print(output.Success(fmt.Sprintf("Rotated %d of %d secrets", totals.Succeeded, totals.Attempted), data, output.ActionAudit()))
+
+	if totals.Failed > 0 {
+		// The failures were already reported; this is not a usage error
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d rotations failed", totals.Failed)
+	}
+	return nil
+}
+
+// rotateRPCError reports a failed rotate call.
+func rotateRPCError(err error) error {
+	if isDaemonConnectionError(err) {
+		userErr := types.NewUserError(
+			"Failed to connect to daemon",
+			"The daemon doesn't appear to be running. Without the daemon, secrets cannot be rotated.",
+			"To start it:\n  secrets serve &",
+			"secrets rotate --help",
+		).WithContext("Socket path", socketPath)
+		output.Print(output.Error(userErr))
+		return userErr
+	}
+	output.Print(output.Error(fmt.Errorf("failed to rotate: %w", err)))
+	return fmt.Errorf("failed to rotate: %w", err)
+}
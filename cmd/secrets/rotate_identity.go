@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var rotateIdentityNewPath string
+
+var rotateIdentityCmd = &cobra.Command{
+	Use:   "rotate-identity",
+	Short: "Replace the store's identity and re-encrypt every secret",
+	Long: `Generate a new age identity and re-encrypt the store to it, for when the
+current identity may be compromised. The old identity is moved to the
+retired-identities directory beside the identity file and can no longer
+decrypt the store. Recipients added with "secrets recipients add" keep
+access. The daemon must be stopped first.
+
+Files are replaced atomically and the store stays readable by whichever
+identity is on disk, so an interrupted rotation can simply be run again.
+
+Backups and encrypted env files made before the rotation are still
+encrypted to the old identity. Restore them with the archived identity:
+  secrets restore backup.json --identity <retired-identity>
+  secrets env re-encrypt --all --identity <retired-identity>
+
+Examples:
+  secrets rotate-identity
+  secrets rotate-identity --new-identity ~/.agent-secrets/identity-2026.age`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// A running daemon holds the old identity and would re-encrypt to it
+		if daemonRunning() {
+			userErr := types.NewUserError(
+				"The daemon is running",
+				"A running daemon keeps the old identity in memory and would re-encrypt the store to it on its next save.",
+				"Stop the daemon, rotate, then start it again:\n  secrets rotate-identity\n  secrets serve &",
+				"secrets rotate-identity --help",
+			).WithContext("Socket path", socketPath)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+
+		st, err := loadLocalStore()
+		if err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+		cfg, err := loadConfig()
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to load config: %w", err)))
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		oldPath := cfg.IdentityPath
+
+		if err := st.RotateIdentity(rotateIdentityNewPath); err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to rotate identity: %w", err)))
+			cmd.SilenceUsage = true
+			return fmt.Errorf("failed to rotate identity: %w", err)
+		}
+
+		newPath := rotateIdentityNewPath
+		if newPath == "" {
+			newPath = oldPath
+		}
+		data := map[string]interface{}{
+			"identity":     newPath,
+			"archived_to":  filepath.Join(filepath.Dir(oldPath), store.RetiredIdentitiesDir),
+			"old_identity": oldPath,
+		}
+		msg := "Identity rotated; store re-encrypted to the new identity"
+		if newPath != oldPath {
+			msg += fmt.Sprintf(". Set identity_path to %s in the daemon config", newPath)
+		}
+
+		output.Print(output.Success(
+			msg,
+			data,
+			output.Action{
+				Name:        "start",
+				Description: "Start the daemon with the new identity",
+				Command:     "secrets serve &",
+			},
+		))
+		return nil
+	},
+}
+
+func init() {
+	rotateIdentityCmd.Flags().StringVar(&rotateIdentityNewPath, "new-identity", "", "Write the new identity here instead of replacing the current identity file")
+}
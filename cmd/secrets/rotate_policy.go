@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rotatePolicyEvery  string
+	rotatePolicyMaxAge string
+	rotatePolicyOff    bool
+)
+
+var rotatePolicyCmd = &cobra.Command{
+	Use:   "rotate-policy <name>",
+	Short: "Schedule automatic rotation of a secret",
+	Long: `Have the daemon run a secret's rotation hook whenever the value is older
+than --every, measured from the last rotation (or from when the secret was
+added). Intervals accept Go durations such as 720h, or whole days such as 30d.
+
+A failed scheduled rotation is audited and retried with exponential backoff,
+from one minute up to one hour. With --max-age, a secret older than that is
+retried on every check regardless of the backoff.
+
+The secret must have a rotation hook (see 'secrets add --rotate-via').
+
+Examples:
+  secrets rotate-policy github_token --every 30d
+  secrets rotate-policy github_token --every 720h --max-age 45d
+  secrets rotate-policy github_token --off`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		if rotatePolicyOff == (rotatePolicyEvery != "") {
+			userErr := types.NewUserError(
+				"Specify --every or --off",
+				"rotate-policy needs either an interval or --off, but not both.",
+				"Schedule rotation:\n  secrets rotate-policy <name> --every 30d\n\nTurn it off:\n  secrets rotate-policy <name> --off",
+				"secrets rotate-policy --help",
+			)
+			output.Print(output.Error(userErr))
+			return userErr
+		}
+
+		var params daemon.RotationPolicyParams
+		params.Name = name
+		if !rotatePolicyOff {
+			interval, err := parseInterval(rotatePolicyEvery)
+			if err != nil {
+				err = fmt.Errorf("invalid --every: %w", err)
+				output.Print(output.Error(err))
+				return err
+			}
+			params.Interval = interval
+			if rotatePolicyMaxAge != "" {
+				maxAge, err := parseInterval(rotatePolicyMaxAge)
+				if err != nil {
+					err = fmt.Errorf("invalid --max-age: %w", err)
+					output.Print(output.Error(err))
+					return err
+				}
+				params.MaxAge = maxAge
+			}
+		}
+
+		resp, err := rpcCall(socketPath, daemon.MethodRotationPolicy, params)
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, rotation cannot be scheduled.",
+					"To start it:\n  secrets serve &",
+					"secrets rotate-policy --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to set rotation policy: %w", err)))
+			return fmt.Errorf("failed to set rotation policy: %w", err)
+		}
+
+		var result daemon.RotationPolicyResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		data := map[string]interface{}{
+			"name": name,
+		}
+		if params.Interval > 0 {
+			data["interval"] = params.Interval.String()
+		}
+		if params.MaxAge > 0 {
+			data["max_age"] = params.MaxAge.String()
+		}
+		output.Print(output.Success(result.Message, data, output.ActionAudit()))
+		return nil
+	},
+}
+
+func init() {
+	rotatePolicyCmd.Flags().StringVar(&rotatePolicyEvery, "every", "", "Rotate when the value is older than this, e.g. 720h or 30d")
+	rotatePolicyCmd.Flags().StringVar(&rotatePolicyMaxAge, "max-age", "", "Retry failed rotations on every check once the value is older than this")
+	rotatePolicyCmd.Flags().BoolVar(&rotatePolicyOff, "off", false, "Turn scheduled rotation off")
+}
+
+// parseInterval parses a duration that may also be given in whole days,
+// such as "30d".
+func parseInterval(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid number of days %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("interval must be positive, got %s", s)
+	}
+	return d, nil
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 
 	"github.com/joelhooks/agent-secrets/internal/output"
 	"github.com/joelhooks/agent-secrets/internal/scanner"
@@ -11,9 +15,32 @@ import (
 )
 
 var (
-	scanPath      string
-	scanRecursive bool
-	scanExclude   []string
+	scanPath          string
+	scanRecursive     bool
+	scanExclude       []string
+	scanMaxLineLength int
+
+	scanPatternsFile string
+	scanPatternsOnly bool
+
+	scanBaseline       string
+	scanUpdateBaseline bool
+
+	scanFormat string
+
+	scanGitHistory bool
+	scanMaxCommits int
+	scanStaged     bool
+
+	scanFailOn string
+
+	scanWatch       string
+	scanVerify      bool
+	scanShowSecrets bool
+
+	scanEntropy          bool
+	scanEntropyThreshold float64
+	scanEntropyMinLength int
 )
 
 var scanCmd = &cobra.Command{
@@ -22,15 +49,96 @@ var scanCmd = &cobra.Command{
 	Long: `Scan files and directories for exposed secrets using pattern matching.
 
 By default, scans recursively and outputs JSON results.
-Use --human for readable output.
+Use --human for readable output, grouped by file.
 
 Examples:
   secrets scan                                    # Scan current directory
   secrets scan --path ./src                       # Scan specific directory
   secrets scan --path ./file.txt                  # Scan specific file
   secrets scan --exclude node_modules,.git        # Exclude patterns
-  secrets scan --no-recursive                     # Disable recursive scanning`,
+  secrets scan --no-recursive                     # Disable recursive scanning
+  secrets scan --entropy                          # Also flag high-entropy strings
+  secrets scan --patterns acme.json               # Add custom patterns to the defaults
+  secrets scan --patterns acme.json --patterns-only
+  secrets scan --update-baseline                  # Accept current findings
+  secrets scan --format sarif > results.sarif     # For GitHub code scanning
+  secrets scan --git-history --max-commits 500    # Secrets added in past commits
+  secrets scan --fail-on high                     # Exit 1 on high or critical findings (CI)
+  secrets scan --staged --fail-on high            # Pre-commit check (see install-hook)
+  secrets scan --watch ./src                      # Rescan files as they change
+  secrets scan --verify                           # Check which tokens are still live
+
+Matches on a line with a "secrets:ignore" comment, or on the line below
+one, are skipped. Add pattern names to only skip those, e.g.
+"# secrets:ignore GitHub Personal Access Token".
+
+Findings recorded in .secrets-baseline.json (see --baseline) are not
+reported. Run with --update-baseline to accept the current findings, e.g.
+test fixtures and examples; newly introduced secrets still surface.
+
+--verify sends GitHub tokens and AWS keys (paired with an AWS secret key
+in the same file) to the provider's API to check whether they are still
+active. It makes network requests, so it is off by default.
+
+Values in the output are redacted to their first 4 and last 2
+characters (e.g. "ghp_…xu") so scan logs don't leak secrets. Pass
+--show-secrets to print them in full.
+
+With --fail-on, the scan exits with code 1 if any finding is at or above
+the given severity; the report is still written to stdout.
+
+Custom pattern files are JSON arrays of objects:
+  [{"name": "Acme Live Key", "regex": "acme_live_[a-z0-9]{32}",
+    "description": "Acme live API key", "severity": "critical"}]`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if scanFormat != "" && scanFormat != "json" && scanFormat != "sarif" {
+			err := fmt.Errorf("invalid format: %s (must be json or sarif)", scanFormat)
+			output.Print(output.Error(err))
+			return err
+		}
+		if scanFormat == "json" {
+			output.OutputFormat = string(output.ModeJSON)
+		}
+
+		// A nil threshold never fails the scan
+		var failOn *scanner.Severity
+		if !strings.EqualFold(strings.TrimSpace(scanFailOn), "none") {
+			severity, err := scanner.ParseSeverity(scanFailOn)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("invalid --fail-on: %w", err)))
+				return err
+			}
+			failOn = &severity
+		}
+
+		if scanStaged && scanGitHistory {
+			err := fmt.Errorf("--staged cannot be combined with --git-history")
+			output.Print(output.Error(err))
+			return err
+		}
+
+		if scanWatch != "" {
+			var conflict string
+			switch {
+			case cmd.Flags().Changed("path"):
+				conflict = "--path (pass the path to --watch)"
+			case scanGitHistory:
+				conflict = "--git-history"
+			case scanStaged:
+				conflict = "--staged"
+			case scanUpdateBaseline:
+				conflict = "--update-baseline"
+			case scanFormat != "":
+				conflict = "--format"
+			}
+			if conflict != "" {
+				err := fmt.Errorf("--watch cannot be combined with %s", conflict)
+				output.Print(output.Error(err))
+				return err
+			}
+			scanPath = scanWatch
+		}
+
 		// Resolve absolute path
 		absPath, err := filepath.Abs(scanPath)
 		if err != nil {
@@ -38,38 +146,116 @@ Examples:
 			return err
 		}
 
-		// Create scanner with default patterns
-		s := scanner.NewScanner(scanner.DefaultPatterns(), scanExclude).
-			WithRecursive(scanRecursive)
+		// Build the pattern set: defaults, plus or replaced by custom patterns
+		patterns := scanner.DefaultPatterns()
+		if scanPatternsOnly && scanPatternsFile == "" {
+			err := fmt.Errorf("--patterns-only requires --patterns")
+			output.Print(output.Error(err))
+			return err
+		}
+		if scanPatternsFile != "" {
+			custom, err := scanner.LoadPatterns(scanPatternsFile)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to load patterns: %w", err)))
+				return err
+			}
+			if scanPatternsOnly {
+				patterns = custom
+			} else {
+				patterns = append(patterns, custom...)
+			}
+		}
+
+		s := scanner.NewScanner(patterns, scanExclude).
+			WithRecursive(scanRecursive).
+			WithMaxLineLength(scanMaxLineLength)
+		if scanEntropy {
+			s = s.WithEntropyDetection(scanEntropyThreshold, scanEntropyMinLength)
+		}
+
+		if !scanUpdateBaseline {
+			s = s.WithBaseline(scanBaseline)
+		}
 
 		// Run scan
-		result, err := s.Scan(absPath)
+		var result scanner.ScanResult
+		var watcher *scanner.Watcher
+		switch {
+		case scanWatch != "":
+			watcher, result, err = s.Watch(absPath, scanner.DefaultWatchDebounce)
+		case scanGitHistory:
+			result, err = s.ScanGitHistory(absPath, scanMaxCommits)
+		case scanStaged:
+			result, err = s.ScanStaged(absPath)
+		default:
+			result, err = s.Scan(absPath)
+		}
 		if err != nil {
 			output.Print(output.Error(fmt.Errorf("scan failed: %w", err)))
 			return err
 		}
 
-		// Format findings for output
-		findingsData := make([]map[string]interface{}, len(result.Findings))
-		for i, f := range result.Findings {
-			findingsData[i] = map[string]interface{}{
-				"file":         f.File,
-				"line":         f.Line,
-				"column":       f.Column,
-				"pattern_name": f.PatternName,
-				"value":        redactValue(f.Value),
-				"severity":     f.Severity.String(),
+		if scanUpdateBaseline {
+			if err := scanner.WriteBaseline(scanBaseline, result.Findings); err != nil {
+				output.Print(output.Error(err))
+				return err
 			}
+			output.Print(output.Success(
+				fmt.Sprintf("Recorded %d findings in %s", len(result.Findings), scanBaseline),
+				map[string]interface{}{
+					"baseline":      scanBaseline,
+					"findings":      len(result.Findings),
+					"scanned_files": result.ScannedFiles,
+				},
+				output.Action{
+					Name:        "scan",
+					Description: "Scan again; baselined findings are suppressed",
+					Command:     "secrets scan",
+				},
+			))
+			return nil
+		}
+
+		if scanVerify {
+			result.Findings = scanner.Verify(result.Findings)
+		}
+
+		// SARIF replaces the standard response entirely
+		if scanFormat == "sarif" {
+			data, err := scanner.ToSARIF(result)
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to encode sarif: %w", err)))
+				return err
+			}
+			fmt.Println(string(data))
+			return checkFailOn(cmd, result.Findings, failOn)
 		}
 
 		// Build response data
 		data := map[string]interface{}{
-			"findings":      findingsData,
+			"findings":      formatFindings(result.Findings),
 			"scanned_files": result.ScannedFiles,
 			"duration":      result.Duration.String(),
 			"path":          absPath,
 			"recursive":     scanRecursive,
 			"excludes":      scanExclude,
+			"entropy":       scanEntropy,
+			"patterns":      len(patterns),
+			"suppressed":    result.Suppressed,
+			"ignored":       result.IgnoredFindings,
+		}
+		if result.LongLines > 0 {
+			data["long_lines_skipped"] = result.LongLines
+		}
+		if scanVerify {
+			data["active_secrets"] = countActive(result.Findings)
+		}
+		if scanGitHistory {
+			data["git_history"] = true
+			data["scanned_commits"] = result.ScannedCommits
+		}
+		if scanStaged {
+			data["staged"] = true
 		}
 
 		// Success message
@@ -78,27 +264,121 @@ Examples:
 			msg = fmt.Sprintf("Found %d exposed secrets in %d files", len(result.Findings), result.ScannedFiles)
 		}
 
+		if scanGitHistory {
+			msg = fmt.Sprintf("Scanned %d commits", result.ScannedCommits)
+			if len(result.Findings) > 0 {
+				msg = fmt.Sprintf("Found %d secrets in %d commits", len(result.Findings), result.ScannedCommits)
+			}
+		}
+		if scanStaged {
+			msg = fmt.Sprintf("Scanned %d staged files", result.ScannedFiles)
+			if len(result.Findings) > 0 {
+				msg = fmt.Sprintf("Found %d exposed secrets in %d staged files", len(result.Findings), result.ScannedFiles)
+			}
+		}
+		if scanVerify && len(result.Findings) > 0 {
+			msg += fmt.Sprintf(" (%d verified active)", countActive(result.Findings))
+		}
+
 		output.Print(output.Success(
 			msg,
 			data,
 			output.ActionsAfterScan(len(result.Findings))...,
 		))
 
-		return nil
+		if watcher != nil {
+			return watchForFindings(watcher)
+		}
+		return checkFailOn(cmd, result.Findings, failOn)
 	},
 }
 
+// checkFailOn returns an error if any finding is at or above the --fail-on
+// severity, so the command exits with code 1. The report has already been
+// written to stdout.
+func checkFailOn(cmd *cobra.Command, findings []scanner.Finding, failOn *scanner.Severity) error {
+	if failOn == nil {
+		return nil
+	}
+
+	n := 0
+	for _, f := range findings {
+		if f.Severity.AtLeast(*failOn) {
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+
+	// The findings were already reported; this is not a usage error
+	cmd.SilenceUsage = true
+	return fmt.Errorf("%d findings at or above %s severity", n, *failOn)
+}
+
+// watchForFindings prints new findings as files change until interrupted.
+func watchForFindings(w *scanner.Watcher) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := w.Run(ctx, func(event scanner.WatchEvent) {
+		if scanVerify {
+			event.Findings = scanner.Verify(event.Findings)
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Found %d new secrets in %s", len(event.Findings), event.File),
+			map[string]interface{}{
+				"file":     event.File,
+				"findings": formatFindings(event.Findings),
+			},
+		))
+	})
+	if err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+	return nil
+}
+
+// formatFindings presents findings for output. Values are redacted unless
+// --show-secrets is set.
+func formatFindings(findings []scanner.Finding) scanner.Report {
+	return scanner.Report{
+		Findings:    findings,
+		ShowSecrets: scanShowSecrets,
+		Verified:    scanVerify,
+	}
+}
+
+// countActive returns the number of findings verified as live credentials.
+func countActive(findings []scanner.Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.Verified && f.Active {
+			n++
+		}
+	}
+	return n
+}
+
 func init() {
 	scanCmd.Flags().StringVar(&scanPath, "path", ".", "Directory or file to scan")
 	scanCmd.Flags().BoolVar(&scanRecursive, "recursive", true, "Scan directories recursively")
 	scanCmd.Flags().StringSliceVar(&scanExclude, "exclude", []string{"node_modules", ".git", ".hg", ".svn", "vendor", "dist", "build"}, "Patterns to exclude from scanning")
-}
-
-// redactValue partially redacts a secret value for display
-func redactValue(value string) string {
-	if len(value) <= 8 {
-		return strings.Repeat("*", len(value))
-	}
-	// Show first 4 and last 4 characters
-	return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+	scanCmd.Flags().IntVar(&scanMaxLineLength, "max-line-length", 0, "Skip lines longer than this many bytes, such as minified code (0 for no limit)")
+	scanCmd.Flags().StringVar(&scanPatternsFile, "patterns", "", "JSON file of custom patterns to add to the defaults")
+	scanCmd.Flags().BoolVar(&scanPatternsOnly, "patterns-only", false, "Use only the patterns from --patterns, not the defaults")
+	scanCmd.Flags().StringVar(&scanBaseline, "baseline", scanner.DefaultBaselineFile, "Baseline file of accepted findings to suppress")
+	scanCmd.Flags().BoolVar(&scanUpdateBaseline, "update-baseline", false, "Write the current findings to the baseline file instead of reporting them")
+	scanCmd.Flags().StringVar(&scanFormat, "format", "", "Report format: json or sarif (default: standard output format)")
+	scanCmd.Flags().BoolVar(&scanGitHistory, "git-history", false, "Scan lines added in the git history of --path instead of the working tree")
+	scanCmd.Flags().IntVar(&scanMaxCommits, "max-commits", 0, "Maximum number of commits for --git-history (0 for all)")
+	scanCmd.Flags().BoolVar(&scanStaged, "staged", false, "Scan only files staged for commit in the git repository at --path")
+	scanCmd.Flags().StringVar(&scanFailOn, "fail-on", "none", "Exit with code 1 if any finding is at or above this severity (low, medium, high, critical, or none)")
+	scanCmd.Flags().StringVar(&scanWatch, "watch", "", "Scan PATH, then keep watching it and report new findings as files change")
+	scanCmd.Flags().BoolVar(&scanVerify, "verify", false, "Check findings against the provider API to see if they are still active (makes network calls)")
+	scanCmd.Flags().BoolVar(&scanShowSecrets, "show-secrets", false, "Include full secret values in the output instead of redacted ones")
+	scanCmd.Flags().BoolVar(&scanEntropy, "entropy", false, "Also flag high-entropy strings that match no known pattern")
+	scanCmd.Flags().Float64Var(&scanEntropyThreshold, "entropy-threshold", scanner.DefaultEntropyThreshold, "Minimum entropy in bits per character for --entropy")
+	scanCmd.Flags().IntVar(&scanEntropyMinLength, "entropy-min-length", scanner.DefaultEntropyMinLength, "Minimum token length for --entropy")
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Find secrets by name, namespace or label (metadata only)",
+	Long: `Search the store for secrets whose name, namespace or labels match a
+query. Values are never searched or shown.
+
+A plain query matches anywhere, ignoring case. A query containing '*' is a
+glob that must match a whole name, namespace or label; labels match as
+KEY=VALUE.
+
+Examples:
+  secrets search stripe        # Names containing "stripe"
+  secrets search 'prod::*'     # Everything in the prod namespace
+  secrets search '*_TOKEN'     # Names ending in _TOKEN
+  secrets search 'team=api*'   # Labels starting with team=api`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		query := args[0]
+
+		resp, err := rpcCall(socketPath, daemon.MethodSearch, daemon.SearchParams{Query: query})
+		if err != nil {
+			if isDaemonConnectionError(err) {
+				userErr := types.NewUserError(
+					"Failed to connect to daemon",
+					"The daemon doesn't appear to be running. Without the daemon, secrets cannot be searched.",
+					"To start it:\n  secrets serve &",
+					"secrets search --help",
+				).WithContext("Socket path", socketPath)
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(fmt.Errorf("failed to search secrets: %w", err)))
+			return fmt.Errorf("failed to search secrets: %w", err)
+		}
+
+		var result daemon.SearchResult
+		if err := decodeResult(resp, &result); err != nil {
+			output.Print(output.Error(err))
+			return err
+		}
+
+		names := make([]string, len(result.Secrets))
+		for i, s := range result.Secrets {
+			names[i] = s.Name
+		}
+
+		data := map[string]interface{}{
+			"query":   query,
+			"secrets": result.Secrets,
+			"count":   len(result.Secrets),
+		}
+
+		if len(result.Secrets) == 0 {
+			output.Print(output.Success(fmt.Sprintf("No secrets match %q", query), data, output.ActionsWhenEmpty()...))
+			return nil
+		}
+		output.Print(output.Success(fmt.Sprintf("%d secrets match %q", len(result.Secrets), query), data, output.ActionsForSecrets(names)...))
+		return nil
+	},
+}
@@ -3,10 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
-	"os/signal"
 	"syscall"
 
-	"github.com/joelhooks/agent-secrets/internal/config"
 	"github.com/joelhooks/agent-secrets/internal/daemon"
 	"github.com/joelhooks/agent-secrets/internal/output"
 	"github.com/spf13/cobra"
@@ -18,12 +16,20 @@ var serveCmd = &cobra.Command{
 	Long: `Start the agent-secrets daemon in the foreground. The daemon listens on a Unix socket
 and handles all secret operations (leases, revocations, etc.).
 
+In the foreground, SIGINT (Ctrl-C) and SIGTERM (e.g. docker stop) shut the
+daemon down cleanly: in-flight requests get shutdown_grace_period to finish,
+leases are saved, and the audit log is closed.
+
 Use --background to run as a background process.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		background, _ := cmd.Flags().GetBool("background")
 
 		// Load config
-		cfg := config.DefaultConfig()
+		cfg, err := loadConfig()
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to load config: %w", err)))
+			return err
+		}
 
 		// Create and start daemon
 		d, err := daemon.NewDaemonWithOptions(cfg, skipPermissionCheck)
@@ -58,18 +64,19 @@ Use --background to run as a background process.`,
 			},
 		))
 
-		// Wait for interrupt signal
-		sigCh := make(chan os.Signal, 1)
-		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-		<-sigCh
-
-		fmt.Println("\nShutting down...")
-		if err := d.Stop(); err != nil {
+		// Block until interrupted, then stop cleanly
+		sig, err := d.RunUntilSignal(syscall.SIGINT, syscall.SIGTERM)
+		if err != nil {
 			output.Print(output.Error(fmt.Errorf("shutdown error: %w", err)))
 			return err
 		}
 
-		output.Print(output.Success("Daemon stopped", nil))
+		output.Print(output.Success(
+			"Daemon stopped",
+			map[string]interface{}{
+				"signal": sig.String(),
+			},
+		))
 		return nil
 	},
 }
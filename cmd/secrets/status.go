@@ -51,17 +51,38 @@ var statusCmd = &cobra.Command{
 			"active_leases": result.ActiveLeases,
 		}
 
+		if m := result.Leases; m != nil {
+			leases := map[string]interface{}{
+				"by_namespace":   m.ByNamespace,
+				"by_secret":      m.BySecret,
+				"total_acquired": m.TotalAcquired,
+			}
+			if m.MaxPerClient > 0 {
+				leases["max_per_client"] = m.MaxPerClient
+			}
+			if !m.SoonestExpiry.IsZero() {
+				leases["soonest_expiry"] = m.SoonestExpiry.Format(time.RFC3339)
+				leases["soonest_expires_in"] = formatDuration(time.Until(m.SoonestExpiry))
+			}
+			statusData["leases"] = leases
+		}
+
 		if result.Running {
 			uptime := time.Since(result.StartedAt)
 			statusData["started_at"] = result.StartedAt.Format(time.RFC3339)
 			statusData["uptime"] = formatDuration(uptime)
 
 			if result.Heartbeat != nil && result.Heartbeat.Enabled {
-				statusData["heartbeat"] = map[string]interface{}{
+				heartbeat := map[string]interface{}{
 					"enabled":  true,
 					"url":      result.Heartbeat.URL,
 					"interval": result.Heartbeat.Interval,
 				}
+				if len(result.Heartbeat.URLs) > 0 {
+					heartbeat["urls"] = result.Heartbeat.URLs
+					heartbeat["quorum"] = result.Heartbeat.RequiredSuccesses()
+				}
+				statusData["heartbeat"] = heartbeat
 			} else {
 				statusData["heartbeat"] = map[string]interface{}{
 					"enabled": false,
@@ -83,11 +104,34 @@ var statusCmd = &cobra.Command{
 			actions = output.ActionsWhenEmpty()
 		}
 
+		rows := [][]string{
+			{"running", formatBool(result.Running)},
+			{"secrets", fmt.Sprint(result.SecretsCount)},
+			{"active leases", fmt.Sprint(result.ActiveLeases)},
+		}
+		if m := result.Leases; m != nil && !m.SoonestExpiry.IsZero() {
+			rows = append(rows, []string{"soonest expiry", "in " + formatDuration(time.Until(m.SoonestExpiry))})
+		}
+		if result.Running {
+			rows = append(rows,
+				[]string{"started", formatTableTime(result.StartedAt)},
+				[]string{"uptime", formatDuration(time.Since(result.StartedAt))},
+			)
+			heartbeat := formatBool(false)
+			if hb := result.Heartbeat; hb != nil && hb.Enabled {
+				heartbeat = fmt.Sprintf("every %v", hb.Interval)
+				if len(hb.URLs) > 0 {
+					heartbeat += fmt.Sprintf(", %d of %d endpoints", hb.RequiredSuccesses(), len(hb.Endpoints()))
+				}
+			}
+			rows = append(rows, []string{"heartbeat", heartbeat})
+		}
+
 		output.Print(output.Success(
 			"Daemon status retrieved",
 			statusData,
 			actions...,
-		))
+		).WithTable([]string{"FIELD", "VALUE"}, rows))
 
 		return nil
 	},
@@ -10,13 +10,22 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var updateDryRun bool
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update to the latest version",
-	Long:  `Check for and install the latest version of agent-secrets from GitHub releases.`,
+	Long: `Check for and install the latest version of agent-secrets from GitHub releases.
+
+Use --dry-run (or --check-only) to see which release asset would be installed
+without downloading or replacing anything.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		currentVersion := update.GetVersion()
 
+		if updateDryRun {
+			return runUpdateDryRun(currentVersion)
+		}
+
 		// Check for update first
 		updateInfo, err := update.CheckForUpdate(currentVersion)
 		if err != nil {
@@ -107,6 +116,47 @@ var updateCmd = &cobra.Command{
 	},
 }
 
+// runUpdateDryRun reports what an update would do without touching disk
+func runUpdateDryRun(currentVersion string) error {
+	plan, err := update.PlanUpdate(currentVersion)
+	if err != nil {
+		output.Print(output.Error(err))
+		return err
+	}
+
+	if !plan.Available {
+		output.Print(output.Success(
+			fmt.Sprintf("Already at latest version %s (dry-run)", currentVersion),
+			plan,
+		))
+		return nil
+	}
+
+	if plan.DownloadURL == "" {
+		err := fmt.Errorf("release %s has no asset for %s/%s (expected %s)", plan.LatestVersion, plan.OS, plan.Arch, plan.AssetName)
+		output.Print(output.Error(
+			err,
+			output.Action{
+				Name:        "manual_update",
+				Description: "Download manually from GitHub releases",
+				Command:     "open https://github.com/joelhooks/agent-secrets/releases",
+			},
+		))
+		return err
+	}
+
+	output.Print(output.Success(
+		fmt.Sprintf("Would update from %s to %s (dry-run)", currentVersion, plan.LatestVersion),
+		plan,
+		output.Action{
+			Name:        "update",
+			Description: "Run update without --dry-run",
+			Command:     "secrets update",
+		},
+	))
+	return nil
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show version information",
@@ -128,3 +178,8 @@ var versionCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	updateCmd.Flags().BoolVar(&updateDryRun, "dry-run", false, "Show the release and asset that would be installed without downloading")
+	updateCmd.Flags().BoolVar(&updateDryRun, "check-only", false, "Alias for --dry-run")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the secrets store for corruption or tampering",
+	Long: `Decrypt the configured secrets file and check its structure: the file
+must decrypt with the store's identity and hold JSON of a supported version,
+and every secret must have a name matching the key it is stored under.
+
+All problems found are reported together, along with the number of secrets
+and namespaces. Nothing is modified and no values are printed. The daemon
+does not need to be running. To check another file or a backup, use
+"secrets verify-store".
+
+Examples:
+  secrets verify
+  secrets verify --human`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to load config: %w", err)))
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		identity, _, err := store.LoadIdentityFile(cfg.IdentityPath)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to load identity: %w", err), output.ActionInit()))
+			return fmt.Errorf("failed to load identity: %w", err)
+		}
+
+		data := map[string]interface{}{
+			"path":       cfg.SecretsPath,
+			"secrets":    0,
+			"namespaces": 0,
+			"problems":   []string{},
+		}
+
+		// No secrets file yet is an empty store, as when the daemon loads it
+		if fi, err := os.Stat(cfg.SecretsPath); os.IsNotExist(err) || (err == nil && fi.Size() == 0) {
+			output.Print(output.Success("Store is empty", data))
+			return nil
+		}
+
+		info, err := store.VerifyFile(cfg.SecretsPath, identity)
+		if info != nil {
+			data["version"] = info.Version
+			data["secrets"] = info.Secrets
+			data["namespaces"] = info.Namespaces
+			data["stale"] = info.Stale
+		}
+
+		var integrityErr *store.IntegrityError
+		switch {
+		case errors.As(err, &integrityErr):
+			data["problems"] = integrityErr.Problems
+			resp := output.ErrorMsg(fmt.Sprintf("%d problems found in %s", len(integrityErr.Problems), cfg.SecretsPath))
+			resp.ExitCode = types.ExitDataError
+			resp.Data = data
+			output.Print(resp)
+
+			// The problems were already reported; this is not a usage error
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d problems found in %s", len(integrityErr.Problems), cfg.SecretsPath)
+		case errors.Is(err, types.ErrDecryptionFailed):
+			userErr := types.NewUserError(
+				"Store could not be decrypted",
+				"The secrets file is not encrypted to the store's identity, or it has been truncated or tampered with.",
+				"Restore it from a backup:\n  secrets restore <backup-file>",
+				"secrets verify --help",
+			).WithContext("File", cfg.SecretsPath).WithContext("Identity", cfg.IdentityPath).WithContext("Reason", err.Error())
+			output.Print(output.Error(userErr))
+			return userErr
+		case err != nil:
+			output.Print(output.Error(err))
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		output.Print(output.Success(
+			fmt.Sprintf("Store is intact (%d secrets in %d namespaces)", info.Secrets, info.Namespaces),
+			data,
+		))
+		return nil
+	},
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/output"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var verifyStoreIdentity string
+
+var verifyStoreCmd = &cobra.Command{
+	Use:   "verify-store <file>",
+	Short: "Check that an encrypted store or backup is intact",
+	Long: `Decrypt a secrets file or a backup written by "secrets backup" and check
+that it is a well-formed store: valid JSON of a known version, where every
+secret has a name matching the key it is stored under.
+
+Nothing is imported or modified and no values are printed; only the store
+version and secret count are reported. Use this to confirm a backup is
+usable before restoring it. The daemon does not need to be running.
+
+Examples:
+  secrets verify-store ~/.agent-secrets/secrets.age
+  secrets verify-store backup.json --identity ~/old-identity.age`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		identityPath := verifyStoreIdentity
+		if identityPath == "" {
+			cfg, err := loadConfig()
+			if err != nil {
+				output.Print(output.Error(fmt.Errorf("failed to load config: %w", err)))
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+			identityPath = cfg.IdentityPath
+		}
+
+		identity, _, err := store.LoadIdentityFile(identityPath)
+		if err != nil {
+			output.Print(output.Error(fmt.Errorf("failed to load identity: %w", err)))
+			return fmt.Errorf("failed to load identity: %w", err)
+		}
+
+		path := args[0]
+		info, err := store.VerifyFile(path, identity)
+		if err != nil {
+			if errors.Is(err, types.ErrDecryptionFailed) {
+				userErr := types.NewUserError(
+					"Store could not be decrypted",
+					"The file is not encrypted to this identity, or it has been truncated or tampered with.",
+					"Check that --identity is the identity the store was encrypted with:\n  secrets verify-store "+path+" --identity <identity-file>",
+					"secrets verify-store --help",
+				).WithContext("File", path).WithContext("Identity", identityPath).WithContext("Reason", err.Error())
+				output.Print(output.Error(userErr))
+				return userErr
+			}
+			output.Print(output.Error(err))
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		data := map[string]interface{}{
+			"path":    path,
+			"version": info.Version,
+			"secrets": info.Secrets,
+			"stale":   info.Stale,
+		}
+		if info.Backup != nil {
+			data["backup"] = info.Backup
+		}
+		output.Print(output.Success(
+			fmt.Sprintf("Store is intact (%d secrets, version %d)", info.Secrets, info.Version),
+			data,
+		))
+		return nil
+	},
+}
+
+func init() {
+	verifyStoreCmd.Flags().StringVar(&verifyStoreIdentity, "identity", "", "Identity file to decrypt with (default: the store's identity)")
+}
@@ -1,7 +1,7 @@
-// Package adapters provides interfaces for syncing secrets from external sources.
+// Package adapters provides interfaces for syncing secrets with external sources.
 package adapters
 
-// SourceAdapter defines the interface for pulling secrets from external sources.
+// SourceAdapter defines the interface for syncing secrets with external sources.
 type SourceAdapter interface {
 	// Pull retrieves secrets from the external source.
 	// project: the project identifier (e.g., project name or ID)
@@ -9,6 +9,25 @@ type SourceAdapter interface {
 	// Returns a map of key-value pairs representing the secrets.
 	Pull(project, scope string) (map[string]string, error)
 
+	// Push publishes secrets to the external source, creating keys that do
+	// not exist and overwriting those that do.
+	// Adapters that cannot write back return types.ErrPushNotSupported.
+	Push(project, scope string, vars map[string]string) error
+
+	// Check verifies the adapter can be used, e.g. that the provider CLI is
+	// installed and authenticated. It is called before Pull so setup problems
+	// are reported separately from fetch failures.
+	Check() error
+
 	// Name returns the human-readable name of the adapter.
 	Name() string
 }
+
+// NoCheck provides a no-op Check for adapters with nothing to verify.
+// Embed it in an adapter struct to satisfy SourceAdapter.
+type NoCheck struct{}
+
+// Check always succeeds.
+func (NoCheck) Check() error {
+	return nil
+}
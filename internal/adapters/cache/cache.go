@@ -0,0 +1,172 @@
+// Package cache provides a caching wrapper around source adapters.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/adapters"
+)
+
+// DefaultTTL is how long pulled secrets are reused before refetching.
+const DefaultTTL = 60 * time.Second
+
+// CachingAdapter implements the SourceAdapter interface by delegating to an
+// inner adapter and caching Pull results on disk for a short TTL.
+// Cache files hold plaintext values and are written with 0600 permissions.
+type CachingAdapter struct {
+	inner adapters.SourceAdapter
+	dir   string
+	ttl   time.Duration
+
+	// now returns the current time; overridden in tests.
+	now func() time.Time
+}
+
+// entry is the on-disk format of a cached Pull result.
+type entry struct {
+	Source    string            `json:"source"`
+	Project   string            `json:"project"`
+	Scope     string            `json:"scope"`
+	FetchedAt time.Time         `json:"fetched_at"`
+	Vars      map[string]string `json:"vars"`
+}
+
+// New wraps inner with a cache stored in dir. A non-positive ttl uses DefaultTTL.
+func New(inner adapters.SourceAdapter, dir string, ttl time.Duration) *CachingAdapter {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &CachingAdapter{
+		inner: inner,
+		dir:   dir,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Name returns the name of the inner adapter.
+func (c *CachingAdapter) Name() string {
+	return c.inner.Name()
+}
+
+// CheckError is returned by Pull when the inner adapter fails its Check.
+type CheckError struct {
+	Err error
+}
+
+func (e *CheckError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// Check delegates to the inner adapter.
+func (c *CachingAdapter) Check() error {
+	return c.inner.Check()
+}
+
+// Pull returns cached secrets for project/scope if they were fetched within
+// the TTL, and otherwise checks and pulls from the inner adapter and
+// refreshes the cache. Cache hits skip the check, so they work without the
+// provider's tool. A failed check is returned as a *CheckError.
+func (c *CachingAdapter) Pull(project, scope string) (map[string]string, error) {
+	path := c.path(project, scope)
+
+	if cached, ok := c.read(path); ok {
+		return cached.Vars, nil
+	}
+
+	if err := c.inner.Check(); err != nil {
+		return nil, &CheckError{Err: err}
+	}
+	vars, err := c.inner.Pull(project, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// A cache write failure only costs a refetch next time
+	_ = c.write(path, &entry{
+		Source:    c.inner.Name(),
+		Project:   project,
+		Scope:     scope,
+		FetchedAt: c.now(),
+		Vars:      vars,
+	})
+
+	return vars, nil
+}
+
+// Push delegates to the inner adapter and invalidates the cached entry.
+func (c *CachingAdapter) Push(project, scope string, vars map[string]string) error {
+	if err := c.inner.Push(project, scope, vars); err != nil {
+		return err
+	}
+	_ = os.Remove(c.path(project, scope))
+	return nil
+}
+
+// path returns the cache file for a source/project/scope key.
+func (c *CachingAdapter) path(project, scope string) string {
+	key := c.inner.Name() + "/" + project + "/" + scope
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// read loads a cache entry, reporting false if it is missing, unreadable,
+// or older than the TTL. Unreadable and expired entries are deleted so that
+// plaintext values do not outlive the TTL on disk.
+func (c *CachingAdapter) read(path string) (*entry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	if c.now().Sub(e.FetchedAt) >= c.ttl {
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	return &e, true
+}
+
+// write stores a cache entry with owner-only permissions.
+func (c *CachingAdapter) write(path string, e *entry) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// Purge deletes every cache entry in dir. A missing dir is not an error.
+func Purge(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache entry: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeAdapter counts calls and returns fixed vars.
+type fakeAdapter struct {
+	vars     map[string]string
+	err      error
+	checkErr error
+	checks   int
+	pulls    int
+	pushes   int
+}
+
+func (f *fakeAdapter) Name() string { return "fake" }
+
+func (f *fakeAdapter) Check() error {
+	f.checks++
+	return f.checkErr
+}
+
+func (f *fakeAdapter) Pull(project, scope string) (map[string]string, error) {
+	f.pulls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.vars, nil
+}
+
+func (f *fakeAdapter) Push(project, scope string, vars map[string]string) error {
+	f.pushes++
+	return nil
+}
+
+// fakeClock is a controllable time source.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.t }
+
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func setupCache(t *testing.T, ttl time.Duration) (*CachingAdapter, *fakeAdapter, *fakeClock) {
+	t.Helper()
+
+	inner := &fakeAdapter{vars: map[string]string{"API_KEY": "abc123"}}
+	clock := &fakeClock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	c := New(inner, t.TempDir(), ttl)
+	c.now = clock.Now
+
+	return c, inner, clock
+}
+
+func TestCachingAdapter_Hit(t *testing.T) {
+	c, inner, clock := setupCache(t, time.Minute)
+
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	got, err := c.Pull("my-app", "development")
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	if inner.pulls != 1 {
+		t.Errorf("expected 1 inner pull, got %d", inner.pulls)
+	}
+	if got["API_KEY"] != "abc123" {
+		t.Errorf("expected cached API_KEY=abc123, got %q", got["API_KEY"])
+	}
+}
+
+func TestCachingAdapter_Expiry(t *testing.T) {
+	c, inner, clock := setupCache(t, time.Minute)
+
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	inner.vars = map[string]string{"API_KEY": "rotated"}
+
+	got, err := c.Pull("my-app", "development")
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	if inner.pulls != 2 {
+		t.Errorf("expected 2 inner pulls after expiry, got %d", inner.pulls)
+	}
+	if got["API_KEY"] != "rotated" {
+		t.Errorf("expected refreshed API_KEY=rotated, got %q", got["API_KEY"])
+	}
+}
+
+func TestCachingAdapter_KeyedByProjectAndScope(t *testing.T) {
+	c, inner, _ := setupCache(t, time.Minute)
+
+	_, _ = c.Pull("my-app", "development")
+	_, _ = c.Pull("my-app", "production")
+	_, _ = c.Pull("other-app", "development")
+
+	if inner.pulls != 3 {
+		t.Errorf("expected 3 inner pulls for distinct keys, got %d", inner.pulls)
+	}
+}
+
+func TestCachingAdapter_ErrorNotCached(t *testing.T) {
+	c, inner, _ := setupCache(t, time.Minute)
+	inner.err = errors.New("rate limited")
+
+	if _, err := c.Pull("my-app", "development"); err == nil {
+		t.Fatal("expected error from inner adapter")
+	}
+
+	inner.err = nil
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	if inner.pulls != 2 {
+		t.Errorf("expected failed pull not to be cached, got %d inner pulls", inner.pulls)
+	}
+}
+
+func TestCachingAdapter_PushInvalidates(t *testing.T) {
+	c, inner, _ := setupCache(t, time.Minute)
+
+	_, _ = c.Pull("my-app", "development")
+	if err := c.Push("my-app", "development", map[string]string{"API_KEY": "new"}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	_, _ = c.Pull("my-app", "development")
+
+	if inner.pushes != 1 {
+		t.Errorf("expected 1 inner push, got %d", inner.pushes)
+	}
+	if inner.pulls != 2 {
+		t.Errorf("expected push to invalidate cache, got %d inner pulls", inner.pulls)
+	}
+}
+
+func TestCachingAdapter_FilePermissions(t *testing.T) {
+	c, _, _ := setupCache(t, time.Minute)
+
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	info, err := os.Stat(c.path("my-app", "development"))
+	if err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected cache file permissions 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestCachingAdapter_StaleEntriesRemoved(t *testing.T) {
+	c, _, clock := setupCache(t, time.Minute)
+
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	path := c.path("my-app", "development")
+
+	clock.Advance(time.Minute)
+	if _, ok := c.read(path); ok {
+		t.Fatal("expected expired entry to miss")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected expired entry to be deleted, stat error = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.read(path); ok {
+		t.Fatal("expected corrupt entry to miss")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected corrupt entry to be deleted, stat error = %v", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	c, inner, _ := setupCache(t, time.Minute)
+
+	_, _ = c.Pull("my-app", "development")
+	_, _ = c.Pull("my-app", "production")
+
+	if err := Purge(c.dir); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	_, _ = c.Pull("my-app", "development")
+	if inner.pulls != 3 {
+		t.Errorf("expected purge to drop cached pulls, got %d inner pulls", inner.pulls)
+	}
+
+	if err := Purge(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("Purge() of a missing directory error = %v", err)
+	}
+}
+
+func TestCachingAdapter_CheckOnMissOnly(t *testing.T) {
+	c, inner, clock := setupCache(t, time.Minute)
+
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if inner.checks != 1 {
+		t.Fatalf("expected 1 check before the first pull, got %d", inner.checks)
+	}
+
+	// A cache hit works even when the provider's tool is unavailable
+	inner.checkErr = errors.New("vercel not installed")
+	if _, err := c.Pull("my-app", "development"); err != nil {
+		t.Fatalf("cached Pull() error = %v", err)
+	}
+	if inner.checks != 1 {
+		t.Errorf("expected no check on a cache hit, got %d checks", inner.checks)
+	}
+
+	clock.Advance(time.Minute)
+	_, err := c.Pull("my-app", "development")
+	var checkErr *CheckError
+	if !errors.As(err, &checkErr) || !errors.Is(err, inner.checkErr) {
+		t.Fatalf("expected a CheckError on a miss, got %v", err)
+	}
+	if inner.pulls != 1 {
+		t.Errorf("expected no pull after a failed check, got %d pulls", inner.pulls)
+	}
+}
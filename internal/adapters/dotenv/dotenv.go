@@ -0,0 +1,69 @@
+// Package dotenv provides an adapter for sourcing secrets from a plain .env file.
+package dotenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/joelhooks/agent-secrets/internal/adapters"
+	"github.com/joelhooks/agent-secrets/internal/envfile"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// DotenvAdapter implements the SourceAdapter interface for local .env files.
+// It lets `secrets env` layer TTL management on top of a gitignored env file.
+type DotenvAdapter struct {
+	// Missing files are reported by Pull, which knows the path.
+	adapters.NoCheck
+
+	// baseDir is the directory that relative file paths are resolved against.
+	// Typically the directory containing .secrets.json.
+	baseDir string
+}
+
+// New creates a new DotenvAdapter that resolves paths relative to baseDir.
+func New(baseDir string) *DotenvAdapter {
+	return &DotenvAdapter{
+		baseDir: baseDir,
+	}
+}
+
+// Name returns the name of the adapter.
+func (d *DotenvAdapter) Name() string {
+	return "dotenv"
+}
+
+// Pull reads environment variables from a .env file.
+// project: path to the .env file, relative to the project directory
+// scope: optional section name; when set, only variables outside any section
+// and those under a matching "# [scope]" marker comment are returned
+func (d *DotenvAdapter) Pull(project, scope string) (map[string]string, error) {
+	if project == "" {
+		return nil, fmt.Errorf("dotenv file path is required")
+	}
+
+	path := project
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(d.baseDir, path)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil, types.ErrAdapterNotAvailable{
+			Adapter: "dotenv",
+			Reason:  fmt.Sprintf("env file %s not readable: %v", path, err),
+		}
+	}
+
+	secrets, err := envfile.Parse(path, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env file: %w", err)
+	}
+
+	return secrets, nil
+}
+
+// Push is not supported: the env file is maintained by hand.
+func (d *DotenvAdapter) Push(project, scope string, vars map[string]string) error {
+	return types.ErrPushNotSupported
+}
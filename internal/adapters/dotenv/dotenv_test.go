@@ -0,0 +1,169 @@
+package dotenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func writeEnvFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+}
+
+func TestDotenvAdapter_Name(t *testing.T) {
+	adapter := New(t.TempDir())
+	if adapter.Name() != "dotenv" {
+		t.Errorf("expected name 'dotenv', got %q", adapter.Name())
+	}
+}
+
+func TestDotenvAdapter_Pull(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		scope   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "basic key-value pairs",
+			content: `DATABASE_URL=postgresql://localhost/mydb
+API_KEY=abc123`,
+			want: map[string]string{
+				"DATABASE_URL": "postgresql://localhost/mydb",
+				"API_KEY":      "abc123",
+			},
+		},
+		{
+			name: "quoted values",
+			content: `DOUBLE="hello world"
+SINGLE='single quoted'
+MISMATCHED="left only
+EMPTY=""`,
+			want: map[string]string{
+				"DOUBLE":     "hello world",
+				"SINGLE":     "single quoted",
+				"MISMATCHED": `"left only`,
+				"EMPTY":      "",
+			},
+		},
+		{
+			name: "comments and blank lines are skipped",
+			content: `# leading comment
+
+API_KEY=abc123
+   # indented comment
+DB_URL="postgres://x"  `,
+			want: map[string]string{
+				"API_KEY": "abc123",
+				"DB_URL":  "postgres://x",
+			},
+		},
+		{
+			name:    "value containing equals and hash",
+			content: `URL=https://example.com/?a=b#frag`,
+			want: map[string]string{
+				"URL": "https://example.com/?a=b#frag",
+			},
+		},
+		{
+			name: "no scope returns every section",
+			content: `SHARED=1
+# [development]
+DEBUG=true
+# [production]
+DEBUG=false`,
+			want: map[string]string{
+				"SHARED": "1",
+				"DEBUG":  "false",
+			},
+		},
+		{
+			name: "scope selects matching section plus shared vars",
+			content: `SHARED=1
+# [development]
+DEBUG=true
+# [production]
+DEBUG=false
+PROD_ONLY=yes`,
+			scope: "development",
+			want: map[string]string{
+				"SHARED": "1",
+				"DEBUG":  "true",
+			},
+		},
+		{
+			name:    "missing section errors",
+			content: "A=1\n# [development]\nB=2",
+			scope:   "production",
+			wantErr: true,
+		},
+		{
+			name:    "invalid line",
+			content: "NOT_A_VAR",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeEnvFile(t, dir, ".env.shared", tt.content)
+
+			got, err := New(dir).Pull(".env.shared", tt.scope)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pull() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("Pull() got %d entries, want %d: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("Pull() key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestDotenvAdapter_Pull_AbsolutePath(t *testing.T) {
+	dir := t.TempDir()
+	writeEnvFile(t, dir, "abs.env", "TOKEN=xyz")
+
+	got, err := New("/nonexistent").Pull(filepath.Join(dir, "abs.env"), "")
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+	if got["TOKEN"] != "xyz" {
+		t.Errorf("expected TOKEN=xyz, got %q", got["TOKEN"])
+	}
+}
+
+func TestDotenvAdapter_Pull_MissingFile(t *testing.T) {
+	_, err := New(t.TempDir()).Pull("missing.env", "")
+	if err == nil {
+		t.Fatal("expected error for missing file, got nil")
+	}
+
+	var adapterErr types.ErrAdapterNotAvailable
+	if !errors.As(err, &adapterErr) {
+		t.Errorf("expected ErrAdapterNotAvailable, got %T: %v", err, err)
+	}
+}
+
+func TestDotenvAdapter_Push_NotSupported(t *testing.T) {
+	err := New(t.TempDir()).Push(".env", "", map[string]string{"KEY": "value"})
+	if !errors.Is(err, types.ErrPushNotSupported) {
+		t.Errorf("expected ErrPushNotSupported, got %v", err)
+	}
+}
@@ -2,12 +2,13 @@
 package vercel
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 
+	"github.com/joelhooks/agent-secrets/internal/envfile"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
@@ -37,6 +38,24 @@ func (v *VercelAdapter) Name() string {
 	return "vercel"
 }
 
+// Check verifies the vercel CLI is installed and logged in.
+func (v *VercelAdapter) Check() error {
+	if err := v.checkVercelCLI(); err != nil {
+		return err
+	}
+
+	// vercel whoami exits non-zero when there is no valid login
+	cmd := exec.Command(v.vercelBinary, "whoami")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return types.ErrAdapterNotAvailable{
+			Adapter: "vercel",
+			Reason:  fmt.Sprintf("not logged in to vercel: %s", strings.TrimSpace(string(output))),
+		}
+	}
+
+	return nil
+}
+
 // Pull retrieves environment variables from a Vercel project.
 // project: the Vercel project name or ID
 // scope: the environment scope (production, preview, development)
@@ -47,13 +66,8 @@ func (v *VercelAdapter) Pull(project, scope string) (map[string]string, error) {
 	}
 
 	// Validate scope
-	validScopes := map[string]bool{
-		"production":  true,
-		"preview":     true,
-		"development": true,
-	}
-	if !validScopes[scope] {
-		return nil, fmt.Errorf("invalid scope %q: must be one of production, preview, development", scope)
+	if err := validateScope(scope); err != nil {
+		return nil, err
 	}
 
 	// Create a temporary file for the env output
@@ -86,7 +100,7 @@ func (v *VercelAdapter) Pull(project, scope string) (map[string]string, error) {
 	}
 
 	// Parse the .env file
-	secrets, err := parseEnvFile(tmpPath)
+	secrets, err := envfile.Parse(tmpPath, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse env file: %w", err)
 	}
@@ -94,6 +108,52 @@ func (v *VercelAdapter) Pull(project, scope string) (map[string]string, error) {
 	return secrets, nil
 }
 
+// Push publishes environment variables to a Vercel project.
+// Each key is written with `vercel env add --force`, which overwrites an
+// existing variable in place, so a failed write never leaves a key removed.
+func (v *VercelAdapter) Push(project, scope string, vars map[string]string) error {
+	// Verify vercel CLI is available
+	if err := v.checkVercelCLI(); err != nil {
+		return err
+	}
+
+	// Validate scope
+	if err := validateScope(scope); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		// vercel env add <key> <scope> --force, reading the value from
+		// stdin so it never appears in the process list
+		cmd := exec.Command(v.vercelBinary, "env", "add", key, scope, "--force")
+		cmd.Stdin = strings.NewReader(vars[key])
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("vercel env add %s failed after pushing %d of %d vars: %w (output: %s)", key, i, len(keys), err, string(output))
+		}
+	}
+
+	return nil
+}
+
+// validateScope checks that scope is a Vercel environment name.
+func validateScope(scope string) error {
+	validScopes := map[string]bool{
+		"production":  true,
+		"preview":     true,
+		"development": true,
+	}
+	if !validScopes[scope] {
+		return fmt.Errorf("invalid scope %q: must be one of production, preview, development", scope)
+	}
+	return nil
+}
+
 // checkVercelCLI verifies that the vercel CLI is available.
 func (v *VercelAdapter) checkVercelCLI() error {
 	path, err := exec.LookPath(v.vercelBinary)
@@ -122,57 +182,3 @@ func (v *VercelAdapter) checkVercelCLI() error {
 
 	return nil
 }
-
-// parseEnvFile reads a .env file and returns a map of key-value pairs.
-// Format: KEY=value or KEY="value" or KEY='value'
-func parseEnvFile(path string) (map[string]string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open env file: %w", err)
-	}
-	defer file.Close()
-
-	secrets := make(map[string]string)
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Split on first '='
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid env line %d: %q (expected KEY=value)", lineNum, line)
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Remove quotes if present
-		value = unquote(value)
-
-		secrets[key] = value
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading env file: %w", err)
-	}
-
-	return secrets, nil
-}
-
-// unquote removes surrounding single or double quotes from a string.
-func unquote(s string) string {
-	if len(s) >= 2 {
-		if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
-			return s[1 : len(s)-1]
-		}
-	}
-	return s
-}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/joelhooks/agent-secrets/internal/types"
@@ -40,142 +42,6 @@ func TestVercelAdapter_checkVercelCLI_NotFound(t *testing.T) {
 	}
 }
 
-func TestParseEnvFile(t *testing.T) {
-	tests := []struct {
-		name    string
-		content string
-		want    map[string]string
-		wantErr bool
-	}{
-		{
-			name: "basic key-value pairs",
-			content: `DATABASE_URL=postgresql://localhost/mydb
-API_KEY=abc123
-PORT=3000`,
-			want: map[string]string{
-				"DATABASE_URL": "postgresql://localhost/mydb",
-				"API_KEY":      "abc123",
-				"PORT":         "3000",
-			},
-			wantErr: false,
-		},
-		{
-			name: "with double quotes",
-			content: `NAME="My Application"
-DESCRIPTION="A test app"`,
-			want: map[string]string{
-				"NAME":        "My Application",
-				"DESCRIPTION": "A test app",
-			},
-			wantErr: false,
-		},
-		{
-			name: "with single quotes",
-			content: `PATH='/usr/local/bin'
-HOME='/home/user'`,
-			want: map[string]string{
-				"PATH": "/usr/local/bin",
-				"HOME": "/home/user",
-			},
-			wantErr: false,
-		},
-		{
-			name: "with comments and empty lines",
-			content: `# This is a comment
-DATABASE_URL=postgresql://localhost/mydb
-
-# Another comment
-API_KEY=abc123
-
-`,
-			want: map[string]string{
-				"DATABASE_URL": "postgresql://localhost/mydb",
-				"API_KEY":      "abc123",
-			},
-			wantErr: false,
-		},
-		{
-			name: "empty file",
-			content: `
-# Just comments
-`,
-			want:    map[string]string{},
-			wantErr: false,
-		},
-		{
-			name: "values with equals signs",
-			content: `BASE64_TOKEN=dGVzdD0xMjM=
-COMPLEX=key=value=pair`,
-			want: map[string]string{
-				"BASE64_TOKEN": "dGVzdD0xMjM=",
-				"COMPLEX":      "key=value=pair",
-			},
-			wantErr: false,
-		},
-		{
-			name:    "invalid format - no equals",
-			content: `INVALID LINE WITHOUT EQUALS`,
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Create a temporary file
-			tmpDir := t.TempDir()
-			tmpFile := filepath.Join(tmpDir, "test.env")
-			if err := os.WriteFile(tmpFile, []byte(tt.content), 0600); err != nil {
-				t.Fatalf("failed to write temp file: %v", err)
-			}
-
-			// Parse the file
-			got, err := parseEnvFile(tmpFile)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseEnvFile() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-
-			if !tt.wantErr {
-				if len(got) != len(tt.want) {
-					t.Errorf("parseEnvFile() got %d entries, want %d", len(got), len(tt.want))
-				}
-				for k, v := range tt.want {
-					if got[k] != v {
-						t.Errorf("parseEnvFile() key %q: got %q, want %q", k, got[k], v)
-					}
-				}
-			}
-		})
-	}
-}
-
-func TestUnquote(t *testing.T) {
-	tests := []struct {
-		input string
-		want  string
-	}{
-		{`"quoted"`, "quoted"},
-		{`'quoted'`, "quoted"},
-		{`unquoted`, "unquoted"},
-		{`"partial`, `"partial`},
-		{`partial"`, `partial"`},
-		{`""`, ""},
-		{`''`, ""},
-		{`"mixed'`, `"mixed'`},
-		{`a`, "a"},
-		{"", ""},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.input, func(t *testing.T) {
-			got := unquote(tt.input)
-			if got != tt.want {
-				t.Errorf("unquote(%q) = %q, want %q", tt.input, got, tt.want)
-			}
-		})
-	}
-}
-
 func TestVercelAdapter_Pull_InvalidScope(t *testing.T) {
 	// Skip if vercel CLI not available
 	adapter := New()
@@ -192,6 +58,212 @@ func TestVercelAdapter_Pull_InvalidScope(t *testing.T) {
 	}
 }
 
+// writeMockVercel creates a fake vercel binary that records each invocation
+// (args and stdin) to a log file. `env pull` writes existingEnv to the
+// requested path. It returns the binary path and the log path.
+func writeMockVercel(t *testing.T, existingEnv string) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("mock vercel CLI requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	envPath := filepath.Join(dir, "existing.env")
+	if err := os.WriteFile(envPath, []byte(existingEnv), 0600); err != nil {
+		t.Fatalf("failed to write existing env: %v", err)
+	}
+
+	script := `#!/bin/sh
+if [ "$1 $2" = "env pull" ]; then
+  echo "$*" >> "` + logPath + `"
+  cp "` + envPath + `" "$3"
+  exit 0
+fi
+if [ "$1 $2" = "env add" ]; then
+  echo "$* stdin=$(cat)" >> "` + logPath + `"
+  exit 0
+fi
+echo "$*" >> "` + logPath + `"
+`
+	binPath := filepath.Join(dir, "vercel")
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write mock vercel: %v", err)
+	}
+
+	return binPath, logPath
+}
+
+func readCalls(t *testing.T, logPath string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("failed to read call log: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestVercelAdapter_Pull(t *testing.T) {
+	binPath, _ := writeMockVercel(t, "# Created by Vercel CLI\nAPI_KEY=\"abc123\"\nCERT=\"line1\\nline2\"\n")
+
+	got, err := NewWithBinary(binPath).Pull("my-project", "production")
+	if err != nil {
+		t.Fatalf("Pull() error = %v", err)
+	}
+
+	// Values are unquoted with the same rules as every other env file
+	want := map[string]string{"API_KEY": "abc123", "CERT": "line1\nline2"}
+	if len(got) != len(want) {
+		t.Errorf("Pull() got %d entries, want %d: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Pull() key %q: got %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestVercelAdapter_Push(t *testing.T) {
+	binPath, logPath := writeMockVercel(t, "EXISTING_KEY=old\n")
+	adapter := NewWithBinary(binPath)
+
+	err := adapter.Push("my-project", "production", map[string]string{
+		"NEW_KEY":      "new-value",
+		"EXISTING_KEY": "updated-value",
+	})
+	if err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	// Keys are pushed in sorted order and overwritten in place, without
+	// listing or removing existing vars first
+	calls := readCalls(t, logPath)
+	want := []string{
+		"env add EXISTING_KEY production --force stdin=updated-value",
+		"env add NEW_KEY production --force stdin=new-value",
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("expected %d invocations, got %d: %v", len(want), len(calls), calls)
+	}
+	for i, w := range want {
+		if calls[i] != w {
+			t.Errorf("call %d: got %q, want %q", i, calls[i], w)
+		}
+	}
+}
+
+func TestVercelAdapter_Push_AddFails(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock vercel CLI requires a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "calls.log")
+	script := `#!/bin/sh
+echo "$*" >> "` + logPath + `"
+if [ "$3" = "B_KEY" ]; then
+  echo "quota exceeded" >&2
+  exit 1
+fi
+cat > /dev/null
+`
+	binPath := filepath.Join(dir, "vercel")
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write mock vercel: %v", err)
+	}
+
+	err := NewWithBinary(binPath).Push("my-project", "production", map[string]string{
+		"A_KEY": "a",
+		"B_KEY": "b",
+		"C_KEY": "c",
+	})
+	if err == nil {
+		t.Fatal("expected error when env add fails, got nil")
+	}
+	for _, want := range []string{"B_KEY", "1 of 3", "quota exceeded"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got: %v", want, err)
+		}
+	}
+
+	// Nothing is removed, and the push stops at the failing key
+	calls := readCalls(t, logPath)
+	for _, call := range calls {
+		if strings.HasPrefix(call, "env rm") {
+			t.Errorf("unexpected removal: %q", call)
+		}
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected push to stop after the failing key, got %v", calls)
+	}
+}
+
+func TestVercelAdapter_Push_InvalidScope(t *testing.T) {
+	binPath, logPath := writeMockVercel(t, "")
+	adapter := NewWithBinary(binPath)
+
+	err := adapter.Push("my-project", "staging", map[string]string{"KEY": "v"})
+	if err == nil {
+		t.Fatal("expected error for invalid scope, got nil")
+	}
+	if !strings.Contains(err.Error(), "invalid scope") {
+		t.Errorf("expected 'invalid scope' error, got: %v", err)
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Error("expected no vercel invocations for invalid scope")
+	}
+}
+
+func TestVercelAdapter_Push_NotFound(t *testing.T) {
+	adapter := NewWithBinary("nonexistent-vercel-binary-12345")
+	err := adapter.Push("my-project", "production", map[string]string{"KEY": "v"})
+
+	var adapterErr types.ErrAdapterNotAvailable
+	if !errors.As(err, &adapterErr) {
+		t.Errorf("expected ErrAdapterNotAvailable, got %T: %v", err, err)
+	}
+}
+
+func TestVercelAdapter_Check(t *testing.T) {
+	binPath, logPath := writeMockVercel(t, "")
+
+	if err := NewWithBinary(binPath).Check(); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if calls := readCalls(t, logPath); len(calls) != 1 || calls[0] != "whoami" {
+		t.Errorf("expected a single whoami call, got %v", calls)
+	}
+}
+
+func TestVercelAdapter_Check_NotLoggedIn(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("mock vercel CLI requires a POSIX shell")
+	}
+
+	binPath := filepath.Join(t.TempDir(), "vercel")
+	script := "#!/bin/sh\necho 'Error: No existing credentials found.' >&2\nexit 1\n"
+	if err := os.WriteFile(binPath, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewWithBinary(binPath).Check()
+	var adapterErr types.ErrAdapterNotAvailable
+	if !errors.As(err, &adapterErr) {
+		t.Fatalf("expected ErrAdapterNotAvailable, got %T: %v", err, err)
+	}
+	if !strings.Contains(adapterErr.Reason, "not logged in") {
+		t.Errorf("expected login reason, got %q", adapterErr.Reason)
+	}
+}
+
+func TestVercelAdapter_Check_NotInstalled(t *testing.T) {
+	err := NewWithBinary("nonexistent-vercel-binary-12345").Check()
+	if !errors.Is(err, types.ErrAdapterFailed) {
+		t.Errorf("expected adapter failure, got %v", err)
+	}
+}
+
 // Helper function
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && findSubstring(s, substr))
@@ -14,9 +14,10 @@ import (
 
 // Logger provides thread-safe append-only audit logging.
 type Logger struct {
-	mu   sync.Mutex
-	file *os.File
-	path string
+	mu     sync.Mutex
+	file   *os.File
+	path   string
+	syslog *syslogSink // Set by ForwardSyslog
 }
 
 // New creates a new audit logger that writes to the specified path.
@@ -33,6 +34,24 @@ func New(path string) (*Logger, error) {
 	}, nil
 }
 
+// ForwardSyslog also sends every entry logged from now on to the syslog
+// server at raddr (see config.ParseSyslogAddr). Forwarding happens in the
+// background: it never delays Log or causes it to fail.
+func (l *Logger) ForwardSyslog(network, raddr string) error {
+	w, err := dialSyslog(network, raddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.syslog != nil {
+		_ = l.syslog.close()
+	}
+	l.syslog = newSyslogSink(w)
+	return nil
+}
+
 // Log writes an audit entry to the log file as a JSON line and syncs immediately.
 func (l *Logger) Log(entry *types.AuditEntry) error {
 	l.mu.Lock()
@@ -43,6 +62,10 @@ func (l *Logger) Log(entry *types.AuditEntry) error {
 		return fmt.Errorf("failed to marshal audit entry: %w", err)
 	}
 
+	if l.syslog != nil {
+		l.syslog.send(entry)
+	}
+
 	if _, err := l.file.Write(append(data, '\n')); err != nil {
 		return fmt.Errorf("failed to write audit entry: %w", err)
 	}
@@ -54,7 +77,9 @@ func (l *Logger) Log(entry *types.AuditEntry) error {
 	return nil
 }
 
-// Tail returns the last n entries from the audit log.
+// Tail returns the last n entries from the audit log, oldest first. The
+// file is read backward from the end, so the cost depends on n rather than
+// the size of the log.
 func (l *Logger) Tail(n int) ([]*types.AuditEntry, error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -66,33 +91,23 @@ func (l *Logger) Tail(n int) ([]*types.AuditEntry, error) {
 	}
 	defer f.Close()
 
-	// Read all entries
-	var entries []*types.AuditEntry
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		var entry types.AuditEntry
-		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
-			// Skip malformed lines
-			continue
-		}
-		entries = append(entries, &entry)
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat audit log: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
+	entries, err := tailEntries(f, info.Size(), n)
+	if err != nil {
 		return nil, fmt.Errorf("failed to read audit log: %w", err)
 	}
-
-	// Return last n entries
-	if len(entries) <= n {
-		return entries, nil
-	}
-	return entries[len(entries)-n:], nil
+	return entries, nil
 }
 
 // QueryFilter defines criteria for filtering audit entries.
 type QueryFilter struct {
 	Action     *types.Action
 	SecretName *string
+	ClientID   *string
 	StartTime  *time.Time
 	EndTime    *time.Time
 }
@@ -125,6 +140,9 @@ func (l *Logger) Query(filter QueryFilter) ([]*types.AuditEntry, error) {
 		if filter.SecretName != nil && entry.SecretName != *filter.SecretName {
 			continue
 		}
+		if filter.ClientID != nil && entry.ClientID != *filter.ClientID {
+			continue
+		}
 		if filter.StartTime != nil && entry.Timestamp.Before(*filter.StartTime) {
 			continue
 		}
@@ -147,6 +165,11 @@ func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.syslog != nil {
+		_ = l.syslog.close()
+		l.syslog = nil
+	}
+
 	if l.file != nil {
 		if err := l.file.Close(); err != nil {
 			return fmt.Errorf("failed to close audit log: %w", err)
@@ -1,6 +1,8 @@
 package audit
 
 import (
+	"bytes"
+	"encoding/csv"
 	"os"
 	"path/filepath"
 	"testing"
@@ -161,8 +163,8 @@ func TestQuery(t *testing.T) {
 	entries := []*types.AuditEntry{
 		NewEntry(types.ActionSecretAdd, true).WithSecret("secret1").Build(),
 		NewEntry(types.ActionSecretAdd, true).WithSecret("secret2").Build(),
-		NewEntry(types.ActionLeaseAcquire, true).WithSecret("secret1").Build(),
-		NewEntry(types.ActionLeaseRevoke, true).WithSecret("secret1").Build(),
+		NewEntry(types.ActionLeaseAcquire, true).WithSecret("secret1").WithClient("agent-1").Build(),
+		NewEntry(types.ActionLeaseRevoke, true).WithSecret("secret1").WithClient("agent-2").Build(),
 	}
 
 	for _, entry := range entries {
@@ -194,6 +196,17 @@ func TestQuery(t *testing.T) {
 		t.Errorf("expected 3 entries for secret1, got %d", len(results))
 	}
 
+	// Test query by client
+	clientID := "agent-1"
+	results, err = logger.Query(QueryFilter{ClientID: &clientID})
+	if err != nil {
+		t.Fatalf("failed to query log: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Action != types.ActionLeaseAcquire {
+		t.Errorf("expected the lease_acquire entry for agent-1, got %+v", results)
+	}
+
 	// Test query by time range
 	startTime := now.Add(-time.Second)
 	endTime := now.Add(time.Hour)
@@ -313,3 +326,196 @@ func TestClose(t *testing.T) {
 		t.Error("expected error when logging to closed logger")
 	}
 }
+
+func TestParseViewerRole(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ViewerRole
+		wantErr bool
+	}{
+		{"", RoleFull, false},
+		{"full", RoleFull, false},
+		{"restricted", RoleRestricted, false},
+		{"admin", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseViewerRole(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseViewerRole(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseViewerRole(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	entry := NewEntry(types.ActionSecretRotate, false).
+		WithSecret("github_token").
+		WithClient("agent-1").
+		WithLease("lease-123").
+		WithDetails("rotation of github_token failed").
+		Build()
+
+	full := Mask(entry, RoleFull)
+	if full.SecretName != "github_token" || full.Details == "" {
+		t.Errorf("full role should see everything, got %+v", full)
+	}
+
+	restricted := Mask(entry, RoleRestricted)
+	if restricted.SecretName != "" || restricted.Details != "" {
+		t.Errorf("restricted role should not see secret names, got %+v", restricted)
+	}
+	if restricted.Action != entry.Action || !restricted.Timestamp.Equal(entry.Timestamp) || restricted.Success != entry.Success {
+		t.Error("restricted role should preserve action, timestamp and success")
+	}
+	if restricted.ClientID != "agent-1" || restricted.LeaseID != "lease-123" {
+		t.Error("restricted role should preserve client and lease IDs")
+	}
+
+	// The original entry is untouched
+	if entry.SecretName != "github_token" {
+		t.Error("Mask modified the original entry")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2024-03-01T08:30:00Z", want: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)},
+		{input: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{input: "2h", want: now.Add(-2 * time.Hour)},
+		{input: "90m", want: now.Add(-90 * time.Minute)},
+		{input: "7d", want: now.Add(-7 * 24 * time.Hour)},
+		{input: "-2h", wantErr: true},
+		{input: "yesterday", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseTime(tt.input, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTime(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseTime(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	entry := NewEntry(types.ActionSecretRotate, false).
+		WithSecret("github_token").
+		WithClient("agent-1").
+		WithPeer(types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "ci"}).
+		WithTrigger(types.TriggerScheduled).
+		WithDetails(`hook said "no", exit 1`).
+		Build()
+	entry.Timestamp = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []*types.AuditEntry{entry}); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and 1 row, got %d records", len(records))
+	}
+	want := []string{
+		"2024-03-10T12:00:00Z", "secret_rotate", "false", "github_token", "agent-1",
+		"", "10.0.0.1:5000", "ci", "scheduled", `hook said "no", exit 1`,
+	}
+	for i, field := range want {
+		if records[1][i] != field {
+			t.Errorf("column %s = %q, want %q", records[0][i], records[1][i], field)
+		}
+	}
+
+	// No entries still writes the header
+	buf.Reset()
+	if err := WriteCSV(&buf, nil); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if got := buf.String(); got != "timestamp,action,success,secret_name,client_id,lease_id,remote_addr,client_cn,trigger,details\n" {
+		t.Errorf("unexpected output for no entries: %q", got)
+	}
+}
+
+// blockingSyslog is a syslog writer that never returns until released.
+type blockingSyslog struct {
+	release chan struct{}
+	closed  bool
+}
+
+func (b *blockingSyslog) Info(string) error    { <-b.release; return nil }
+func (b *blockingSyslog) Warning(string) error { <-b.release; return nil }
+func (b *blockingSyslog) Close() error         { b.closed = true; return nil }
+
+func TestSyslogDoesNotBlockLog(t *testing.T) {
+	logger, err := New(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	w := &blockingSyslog{release: make(chan struct{})}
+	logger.syslog = newSyslogSink(w)
+
+	// Far more entries than the queue holds; none may wait on syslog
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2*syslogQueueSize; i++ {
+			if err := logger.Log(NewEntry(types.ActionSecretAdd, true).WithSecret("s").Build()); err != nil {
+				t.Errorf("Log failed: %v", err)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Log blocked on an unresponsive syslog server")
+	}
+
+	entries, err := logger.Query(QueryFilter{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(entries) != 2*syslogQueueSize {
+		t.Errorf("expected %d entries in the audit file, got %d", 2*syslogQueueSize, len(entries))
+	}
+
+	close(w.release)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !w.closed {
+		t.Error("expected Close to close the syslog writer")
+	}
+}
+
+func TestSyslogMessage(t *testing.T) {
+	entry := NewEntry(types.ActionSecretRotate, false).
+		WithSecret("github_token").
+		WithTrigger(types.TriggerScheduled).
+		Build()
+
+	want := "action=secret_rotate secret=github_token trigger=scheduled success=false"
+	if got := syslogMessage(entry); got != want {
+		t.Errorf("syslogMessage() = %q, want %q", got, want)
+	}
+}
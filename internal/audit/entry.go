@@ -42,6 +42,19 @@ func (b *EntryBuilder) WithLease(id string) *EntryBuilder {
 	return b
 }
 
+// WithPeer adds the transport peer identity to the audit entry.
+func (b *EntryBuilder) WithPeer(peer types.Peer) *EntryBuilder {
+	b.entry.RemoteAddr = peer.RemoteAddr
+	b.entry.ClientCN = peer.ClientCN
+	return b
+}
+
+// WithTrigger records what started the operation, e.g. a rotation.
+func (b *EntryBuilder) WithTrigger(trigger types.RotationTrigger) *EntryBuilder {
+	b.entry.Trigger = trigger
+	return b
+}
+
 // WithDetails adds additional details to the audit entry.
 func (b *EntryBuilder) WithDetails(details string) *EntryBuilder {
 	b.entry.Details = details
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// csvHeader is the column order written by WriteCSV.
+var csvHeader = []string{
+	"timestamp", "action", "success", "secret_name", "client_id",
+	"lease_id", "remote_addr", "client_cn", "trigger", "details",
+}
+
+// ParseTime parses a query bound given either as an RFC3339 timestamp, a
+// date such as 2024-01-02 (midnight UTC), or a duration before now such as
+// 2h or 7d.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	var ago time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339 or a duration like 2h or 7d", s)
+		}
+		ago = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339 or a duration like 2h or 7d", s)
+		}
+		ago = d
+	}
+	if ago < 0 {
+		return time.Time{}, fmt.Errorf("invalid time %q: duration must not be negative", s)
+	}
+	return now.Add(-ago), nil
+}
+
+// WriteCSV writes entries as CSV with a header row. Timestamps are RFC3339
+// with nanoseconds, in UTC.
+func WriteCSV(w io.Writer, entries []*types.AuditEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		record := []string{
+			e.Timestamp.UTC().Format(time.RFC3339Nano),
+			string(e.Action),
+			strconv.FormatBool(e.Success),
+			e.SecretName,
+			e.ClientID,
+			e.LeaseID,
+			e.RemoteAddr,
+			e.ClientCN,
+			string(e.Trigger),
+			e.Details,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// ViewerRole controls how much of an audit entry a consumer may see.
+type ViewerRole string
+
+const (
+	// RoleFull sees every field of every entry.
+	RoleFull ViewerRole = "full"
+	// RoleRestricted sees actions, timestamps, clients and outcomes, but not
+	// which secrets were touched.
+	RoleRestricted ViewerRole = "restricted"
+)
+
+// ParseViewerRole parses a role name. An empty name means RoleFull.
+func ParseViewerRole(s string) (ViewerRole, error) {
+	switch ViewerRole(s) {
+	case "", RoleFull:
+		return RoleFull, nil
+	case RoleRestricted:
+		return RoleRestricted, nil
+	default:
+		return "", fmt.Errorf("unknown viewer role %q: must be one of full, restricted", s)
+	}
+}
+
+// Mask returns a copy of entry with fields hidden from the given role.
+// Restricted viewers lose the secret name and free-form details, since
+// details may mention secret names (e.g. rotation output).
+func Mask(entry *types.AuditEntry, role ViewerRole) *types.AuditEntry {
+	masked := *entry
+	if role == RoleRestricted {
+		masked.SecretName = ""
+		masked.Details = ""
+	}
+	return &masked
+}
+
+// MaskAll applies Mask to every entry.
+func MaskAll(entries []*types.AuditEntry, role ViewerRole) []*types.AuditEntry {
+	masked := make([]*types.AuditEntry, len(entries))
+	for i, e := range entries {
+		masked[i] = Mask(e, role)
+	}
+	return masked
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// syslogQueueSize bounds the events waiting to be forwarded to syslog. When
+// the queue is full, further events are dropped from syslog only; the audit
+// file remains the record of truth.
+const syslogQueueSize = 256
+
+// syslogTag identifies audit events in syslog.
+const syslogTag = "agent-secrets"
+
+// syslogWriter is the subset of *syslog.Writer used by the sink.
+type syslogWriter interface {
+	Info(msg string) error
+	Warning(msg string) error
+	Close() error
+}
+
+// syslogSink forwards entries to syslog from its own goroutine, so a slow or
+// unreachable server never holds up Log.
+type syslogSink struct {
+	writer syslogWriter
+	queue  chan types.AuditEntry
+	done   chan struct{}
+}
+
+func newSyslogSink(w syslogWriter) *syslogSink {
+	s := &syslogSink{
+		writer: w,
+		queue:  make(chan types.AuditEntry, syslogQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// send queues entry for forwarding, dropping it if the queue is full.
+func (s *syslogSink) send(entry *types.AuditEntry) {
+	select {
+	case s.queue <- *entry:
+	default:
+	}
+}
+
+func (s *syslogSink) run() {
+	defer close(s.done)
+	for entry := range s.queue {
+		// Failed operations are logged at warning severity. Write errors are
+		// ignored; *syslog.Writer reconnects on the next write.
+		if entry.Success {
+			_ = s.writer.Info(syslogMessage(&entry))
+		} else {
+			_ = s.writer.Warning(syslogMessage(&entry))
+		}
+	}
+}
+
+// close forwards the queued entries and closes the writer.
+func (s *syslogSink) close() error {
+	close(s.queue)
+	<-s.done
+	return s.writer.Close()
+}
+
+// syslogMessage formats entry as space-separated key=value pairs, quoting
+// values that contain spaces, quotes or equals signs. Empty fields are
+// omitted.
+func syslogMessage(entry *types.AuditEntry) string {
+	var b strings.Builder
+	b.WriteString("action=" + syslogValue(string(entry.Action)))
+	fields := []struct{ key, value string }{
+		{"secret", entry.SecretName},
+		{"client", entry.ClientID},
+		{"lease", entry.LeaseID},
+		{"client_cn", entry.ClientCN},
+		{"remote_addr", entry.RemoteAddr},
+		{"trigger", string(entry.Trigger)},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			b.WriteString(" " + f.key + "=" + syslogValue(f.value))
+		}
+	}
+	b.WriteString(" success=" + strconv.FormatBool(entry.Success))
+	return b.String()
+}
+
+func syslogValue(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+		return strconv.Quote(v)
+	}
+	return v
+}
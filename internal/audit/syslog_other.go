@@ -0,0 +1,10 @@
+//go:build !unix
+
+package audit
+
+import "errors"
+
+// dialSyslog reports that syslog forwarding needs a Unix system.
+func dialSyslog(network, raddr string) (syslogWriter, error) {
+	return nil, errors.New("syslog forwarding is not supported on this platform")
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package audit
+
+import "log/syslog"
+
+// dialSyslog connects to the syslog server at raddr, logging to the auth
+// facility.
+func dialSyslog(network, raddr string) (syslogWriter, error) {
+	return syslog.Dial(network, raddr, syslog.LOG_AUTH|syslog.LOG_INFO, syslogTag)
+}
@@ -0,0 +1,70 @@
+//go:build unix
+
+package audit
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestForwardSyslog(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer server.Close()
+
+	logger, err := New(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	if err := logger.ForwardSyslog("udp", server.LocalAddr().String()); err != nil {
+		t.Fatalf("ForwardSyslog failed: %v", err)
+	}
+
+	entry := NewEntry(types.ActionLeaseAcquire, true).
+		WithSecret("github_token").
+		WithClient("agent 1").
+		WithDetails("TTL: 1h0m0s").
+		Build()
+	if err := logger.Log(entry); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	_ = server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := server.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no syslog message received: %v", err)
+	}
+	msg := string(buf[:n])
+
+	// <priority> is LOG_AUTH|LOG_INFO
+	if !strings.HasPrefix(msg, "<38>") {
+		t.Errorf("expected auth.info priority <38>, got %q", msg)
+	}
+	for _, want := range []string{syslogTag, "action=lease_acquire", "secret=github_token", `client="agent 1"`, "success=true"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("syslog message %q does not contain %q", msg, want)
+		}
+	}
+	if strings.Contains(msg, "TTL") {
+		t.Errorf("syslog message %q should not include details", msg)
+	}
+
+	// The entry is still written to the audit file
+	entries, err := logger.Tail(1)
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].SecretName != "github_token" {
+		t.Errorf("expected the entry in the audit file, got %+v", entries)
+	}
+}
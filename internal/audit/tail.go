@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// tailChunkSize is how much of the log tailEntries reads per step.
+const tailChunkSize = 64 * 1024
+
+// tailEntries returns the last n well-formed entries in r, oldest first,
+// reading backward from the end in chunks. Malformed lines are skipped, as
+// in Query.
+func tailEntries(r io.ReaderAt, size int64, n int) ([]*types.AuditEntry, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	// Collected newest first, then reversed
+	var entries []*types.AuditEntry
+	add := func(line []byte) {
+		var entry types.AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// Skip malformed lines
+			return
+		}
+		entries = append(entries, &entry)
+	}
+
+	// rest is the start of the earliest line read so far, which may continue
+	// in the chunk before it
+	var rest []byte
+	offset := size
+	for offset > 0 && len(entries) < n {
+		chunk := int64(tailChunkSize)
+		if chunk > offset {
+			chunk = offset
+		}
+		offset -= chunk
+
+		buf := make([]byte, chunk, int(chunk)+len(rest))
+		if _, err := r.ReadAt(buf, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(buf, rest...)
+
+		for len(entries) < n {
+			i := bytes.LastIndexByte(buf, '\n')
+			if i < 0 {
+				break
+			}
+			add(buf[i+1:])
+			buf = buf[:i]
+		}
+		rest = buf
+	}
+	// The first line of the file has no newline before it
+	if offset == 0 && len(entries) < n {
+		add(rest)
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
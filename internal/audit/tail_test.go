@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// naiveTail is the original Tail: parse the whole file and keep the last n.
+func naiveTail(path string, n int) ([]*types.AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*types.AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry types.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(entries) <= n {
+		return entries, nil
+	}
+	return entries[len(entries)-n:], nil
+}
+
+// writeLargeLog writes count entries to a new log, with a malformed line
+// every 97 entries and an entry longer than tailChunkSize every 1000.
+func writeLargeLog(tb testing.TB, count int) (*Logger, string) {
+	tb.Helper()
+
+	path := filepath.Join(tb.TempDir(), "audit.log")
+	logger, err := New(path)
+	if err != nil {
+		tb.Fatalf("failed to create logger: %v", err)
+	}
+	tb.Cleanup(func() { logger.Close() })
+
+	// Write through a buffer; Log syncs every entry, which is slow here
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < count; i++ {
+		entry := NewEntry(types.ActionLeaseAcquire, i%5 != 0).
+			WithSecret(fmt.Sprintf("secret-%d", i%17)).
+			WithClient("bench").
+			WithLease(fmt.Sprintf("lease-%d", i)).
+			Build()
+		entry.Timestamp = start.Add(time.Duration(i) * time.Second)
+		if i%1000 == 999 {
+			entry.Details = strings.Repeat("x", tailChunkSize+100)
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		w.Write(append(data, '\n'))
+		if i%97 == 0 {
+			w.WriteString("{not json\n")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tb.Fatal(err)
+	}
+	return logger, path
+}
+
+func TestTail_MatchesNaive(t *testing.T) {
+	const count = 5000
+	logger, path := writeLargeLog(t, count)
+
+	for _, n := range []int{1, 2, 96, 97, 98, 999, 1000, 1001, 2500, count - 1, count, count + 10} {
+		got, err := logger.Tail(n)
+		if err != nil {
+			t.Fatalf("Tail(%d) failed: %v", n, err)
+		}
+		want, err := naiveTail(path, n)
+		if err != nil {
+			t.Fatalf("naiveTail(%d) failed: %v", n, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Tail(%d) returned %d entries, want %d", n, len(got), len(want))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Tail(%d) differs from the naive implementation", n)
+		}
+	}
+}
+
+func TestTail_Chronological(t *testing.T) {
+	logger, _ := writeLargeLog(t, 300)
+
+	tail, err := logger.Tail(50)
+	if err != nil {
+		t.Fatalf("failed to tail log: %v", err)
+	}
+	for i := 1; i < len(tail); i++ {
+		if !tail[i].Timestamp.After(tail[i-1].Timestamp) {
+			t.Fatalf("entries out of order at %d: %v then %v", i, tail[i-1].Timestamp, tail[i].Timestamp)
+		}
+	}
+	if last := tail[len(tail)-1]; last.LeaseID != "lease-299" {
+		t.Errorf("last entry = %s, want lease-299", last.LeaseID)
+	}
+}
+
+func TestTail_NoTrailingNewline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	first, _ := json.Marshal(NewEntry(types.ActionDaemonStart, true).Build())
+	second, _ := json.Marshal(NewEntry(types.ActionDaemonStop, true).Build())
+	if err := os.WriteFile(path, []byte(string(first)+"\n"+string(second)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	logger, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	tail, err := logger.Tail(5)
+	if err != nil {
+		t.Fatalf("failed to tail log: %v", err)
+	}
+	if len(tail) != 2 || tail[0].Action != types.ActionDaemonStart || tail[1].Action != types.ActionDaemonStop {
+		t.Errorf("unexpected tail: %+v", tail)
+	}
+}
+
+func TestTail_Empty(t *testing.T) {
+	logger, err := New(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+	defer logger.Close()
+
+	for _, n := range []int{0, 10} {
+		tail, err := logger.Tail(n)
+		if err != nil {
+			t.Fatalf("Tail(%d) failed: %v", n, err)
+		}
+		if len(tail) != 0 {
+			t.Errorf("Tail(%d) on an empty log returned %d entries", n, len(tail))
+		}
+	}
+}
+
+func BenchmarkTail(b *testing.B) {
+	logger, path := writeLargeLog(b, 100000)
+
+	b.Run("reverse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := logger.Tail(100); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := naiveTail(path, 100); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
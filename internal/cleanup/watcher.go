@@ -10,7 +10,8 @@ import (
 	"github.com/joelhooks/agent-secrets/internal/envfile"
 )
 
-// Watcher monitors directories for expired .env files
+// Watcher monitors directories for expired .env files and secret file
+// directories
 type Watcher struct {
 	paths    []string
 	interval time.Duration
@@ -26,8 +27,8 @@ func New(paths []string, interval time.Duration) *Watcher {
 	}
 }
 
-// Check scans all configured paths for expired .env files and wipes them.
-// Returns a slice of wiped file paths.
+// Check scans all configured paths for expired .env files and secret file
+// directories and wipes them. Returns a slice of wiped paths.
 func (w *Watcher) Check() ([]string, error) {
 	var wiped []string
 
@@ -65,6 +66,21 @@ func (w *Watcher) Check() ([]string, error) {
 				return nil
 			}
 
+			// A manifest marks a directory of secret files, wiped as a whole
+			if filepath.Base(path) == envfile.ManifestName {
+				dir := filepath.Dir(path)
+				expired, err := envfile.IsDirExpired(dir)
+				if err != nil || !expired {
+					// If the manifest can't be read or parsed, don't wipe
+					return nil
+				}
+				if err := envfile.WipeDir(dir); err != nil {
+					return fmt.Errorf("wipe %s: %w", dir, err)
+				}
+				wiped = append(wiped, dir)
+				return filepath.SkipDir
+			}
+
 			// Only process .env files
 			if filepath.Base(path) != ".env.local" && filepath.Base(path) != ".env" {
 				return nil
@@ -250,3 +250,32 @@ func TestWatcher_Check_SingleFile(t *testing.T) {
 		t.Errorf("expected wiped file %s, got %s", expiredPath, wiped[0])
 	}
 }
+
+func TestWatcher_Check_WipesExpiredSecretDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	expiredDir := filepath.Join(tmpDir, "expired", "secrets")
+	if err := envfile.WriteSecretDir(expiredDir, map[string]string{"db_password": "a", "api_key": "b"}, -1*time.Hour, "test"); err != nil {
+		t.Fatalf("failed to create expired secrets dir: %v", err)
+	}
+	validDir := filepath.Join(tmpDir, "valid", "secrets")
+	if err := envfile.WriteSecretDir(validDir, map[string]string{"db_password": "a"}, 1*time.Hour, "test"); err != nil {
+		t.Fatalf("failed to create valid secrets dir: %v", err)
+	}
+
+	w := New([]string{tmpDir}, 1*time.Minute)
+	wiped, err := w.Check()
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+
+	if len(wiped) != 1 || wiped[0] != expiredDir {
+		t.Fatalf("expected %s to be wiped, got %v", expiredDir, wiped)
+	}
+	if _, err := os.Stat(expiredDir); !os.IsNotExist(err) {
+		t.Errorf("expected expired secrets dir to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(validDir, "db_password")); err != nil {
+		t.Errorf("expected valid secret file to be kept: %v", err)
+	}
+}
@@ -3,8 +3,12 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/joelhooks/agent-secrets/internal/types"
@@ -25,6 +29,25 @@ const (
 	DefaultConfigFile = "config.json"
 	// DefaultLeasesFile is the default leases persistence filename.
 	DefaultLeasesFile = "leases.json"
+	// DefaultAdapterCacheDir is the default directory for cached adapter pulls.
+	DefaultAdapterCacheDir = "adapter-cache"
+	// DefaultHistoryLimit is the default number of previous values kept per secret.
+	DefaultHistoryLimit = 5
+	// DefaultRotationConcurrency is the default number of rotation hooks run at once.
+	DefaultRotationConcurrency = 4
+	// DefaultIdleTimeout is how long the daemon waits for a request on an open connection.
+	DefaultIdleTimeout = 5 * time.Minute
+)
+
+// Environment variables that override the data directory, socket and
+// identity paths, e.g. to run isolated daemons side by side. They take
+// precedence over the config file, which takes precedence over the
+// defaults. AGENT_SECRETS_DIR also moves the config file itself and the
+// default location of every other file.
+const (
+	EnvDir      = "AGENT_SECRETS_DIR"
+	EnvSocket   = "AGENT_SECRETS_SOCKET"
+	EnvIdentity = "AGENT_SECRETS_IDENTITY"
 )
 
 // Config holds the daemon configuration.
@@ -53,22 +76,142 @@ type Config struct {
 	// MaxLeaseTTL is the maximum allowed TTL for leases.
 	MaxLeaseTTL time.Duration `json:"max_lease_ttl"`
 
+	// LeaseTTLJitter randomizes each granted lease TTL by up to this
+	// percentage of the requested TTL in either direction, so leases taken
+	// together do not all expire together. Zero disables jitter.
+	LeaseTTLJitter int `json:"lease_ttl_jitter,omitempty"`
+
 	// RotationTimeout is the max time allowed for rotation hooks.
 	RotationTimeout time.Duration `json:"rotation_timeout"`
 
+	// RotationConcurrency is how many rotation hooks may run at once when
+	// rotating every secret. Zero uses DefaultRotationConcurrency.
+	RotationConcurrency int `json:"rotation_concurrency,omitempty"`
+
+	// AdapterCacheTTL is how long adapter pulls are cached. Zero disables caching.
+	AdapterCacheTTL time.Duration `json:"adapter_cache_ttl"`
+
+	// IdleTimeout is how long a connection may wait between requests
+	// before the daemon closes it. Zero uses DefaultIdleTimeout.
+	IdleTimeout time.Duration `json:"idle_timeout,omitempty"`
+
+	// ShutdownGracePeriod is how long Stop waits for in-flight connections
+	// before closing them. Zero closes them immediately.
+	ShutdownGracePeriod time.Duration `json:"shutdown_grace_period"`
+
+	// HistoryLimit is how many previous values are kept per secret when it
+	// is updated, for "secrets rollback". Zero disables history.
+	HistoryLimit int `json:"history_limit"`
+
+	// MaxSecrets caps how many secrets the store holds, to catch runaway
+	// automation. Adds past it are refused. Zero means no limit.
+	MaxSecrets int `json:"max_secrets,omitempty"`
+
+	// MaxStoreBytes caps the size of the store, measured as its serialized
+	// form before encryption. Adds and updates past it are refused. Zero
+	// means no limit.
+	MaxStoreBytes int `json:"max_store_bytes,omitempty"`
+
+	// MaxLeasesPerClient caps how many active leases one client ID may hold
+	// at once, so a misbehaving agent cannot acquire leases without bound.
+	// Zero means no limit.
+	MaxLeasesPerClient int `json:"max_leases_per_client,omitempty"`
+
 	// Heartbeat configuration for optional remote monitoring.
 	Heartbeat *types.HeartbeatConfig `json:"heartbeat,omitempty"`
+
+	// DeadManFile enables an offline dead-man switch: unless the file is
+	// touched (secrets keepalive) at least every DeadManInterval, the
+	// daemon activates heartbeat.fail_action, or revokes all leases if no
+	// heartbeat is configured. The daemon touches it when it starts.
+	DeadManFile     string        `json:"dead_man_file,omitempty"`
+	DeadManInterval time.Duration `json:"dead_man_interval,omitempty"`
+
+	// KillswitchWebhook is an optional URL POSTed a JSON summary each time
+	// the killswitch fires, e.g. to page someone.
+	KillswitchWebhook string `json:"killswitch_webhook,omitempty"`
+
+	// The killswitch only rotates secrets or wipes the store while armed
+	// (secrets killswitch arm); otherwise those actions are downgraded to
+	// revoking all leases. ArmedByDefault arms it when the daemon starts.
+	// ArmWindow is how long arming lasts; zero means until disarmed.
+	ArmedByDefault bool          `json:"armed_by_default,omitempty"`
+	ArmWindow      time.Duration `json:"arm_window,omitempty"`
+
+	// TCP configures an optional mutual-TLS listener alongside the Unix socket.
+	TCP *TCPConfig `json:"tcp,omitempty"`
+
+	// ListenTCP is an optional host:port for a plain TCP listener, for
+	// clients that cannot use mutual TLS. Each connection must present
+	// AuthToken (see daemon.Daemon). Use it only on trusted networks.
+	ListenTCP string `json:"listen_tcp,omitempty"`
+
+	// AuthToken is the bearer token required on the ListenTCP listener.
+	AuthToken string `json:"auth_token,omitempty"`
+
+	// HealthAddr is an optional host:port for an HTTP listener serving
+	// GET /healthz for monitoring: 200 while the store is loaded, 503
+	// otherwise. It reveals nothing about secrets or leases.
+	HealthAddr string `json:"health_addr,omitempty"`
+
+	// SyslogAddr optionally forwards every audit event to a syslog server as
+	// well as the audit file: udp://host:port, tcp://host:port,
+	// unix:///dev/log, or a bare host:port for UDP. Unix only.
+	SyslogAddr string `json:"syslog_addr,omitempty"`
+
+	// AllowedUIDs lists user IDs besides the daemon's own that may connect
+	// to the Unix socket. Peers are identified by SO_PEERCRED on Linux and
+	// LOCAL_PEERCRED on macOS; elsewhere the check is skipped.
+	AllowedUIDs []int `json:"allowed_uids,omitempty"`
+
+	// Policies restricts which RPC methods each client may call, keyed by
+	// client identity: "cn:<name>" for the certificate common name of a TCP
+	// client, "token:<id>" for a token-authenticated TCP client (the id is
+	// the first 16 hex digits of the token's SHA-256, also logged when the
+	// daemon starts), or "uid:<n>" for a Unix socket peer. A "*" entry applies to clients not
+	// listed. Without policies every client may call every method, except
+	// that TCP clients may only delete, rename, rotate, roll back, compact
+	// or revoke all leases when their own entry grants the method.
+	Policies map[string][]string `json:"policies,omitempty"`
 }
 
-// DefaultConfig returns a Config with sensible defaults.
-func DefaultConfig() *Config {
+// TCPConfig configures the mutual-TLS TCP transport.
+// Clients must present a certificate signed by the CA in ClientCAPath.
+type TCPConfig struct {
+	// Addr is the host:port to listen on (e.g. "127.0.0.1:7443").
+	Addr string `json:"addr"`
+
+	// CertPath and KeyPath locate the server's PEM certificate and key.
+	CertPath string `json:"cert_path"`
+	KeyPath  string `json:"key_path"`
+
+	// ClientCAPath is the PEM bundle used to verify client certificates.
+	ClientCAPath string `json:"client_ca_path"`
+}
+
+// rootDir is the default data directory: AGENT_SECRETS_DIR if set, and
+// ~/.agent-secrets otherwise. Profiles live beneath it.
+func rootDir() string {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		return dir
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		homeDir = "."
 	}
+	return filepath.Join(homeDir, DefaultDir)
+}
 
-	baseDir := filepath.Join(homeDir, DefaultDir)
+// DefaultConfig returns a Config with sensible defaults, under
+// AGENT_SECRETS_DIR if set and ~/.agent-secrets otherwise.
+func DefaultConfig() *Config {
+	cfg := defaultConfigIn(rootDir())
+	applyEnv(cfg)
+	return cfg
+}
 
+// defaultConfigIn returns the defaults with every file under baseDir.
+func defaultConfigIn(baseDir string) *Config {
 	return &Config{
 		Directory:       baseDir,
 		SocketPath:      filepath.Join(baseDir, DefaultSocket),
@@ -79,10 +222,28 @@ func DefaultConfig() *Config {
 		DefaultLeaseTTL: 1 * time.Hour,
 		MaxLeaseTTL:     24 * time.Hour,
 		RotationTimeout: 30 * time.Second,
+		AdapterCacheTTL: 60 * time.Second,
+		HistoryLimit:    DefaultHistoryLimit,
+
+		ShutdownGracePeriod: 5 * time.Second,
+	}
+}
+
+// applyEnv applies the path overrides from the environment.
+func applyEnv(cfg *Config) {
+	if dir := os.Getenv(EnvDir); dir != "" {
+		cfg.Directory = dir
+	}
+	if socket := os.Getenv(EnvSocket); socket != "" {
+		cfg.SocketPath = socket
+	}
+	if identity := os.Getenv(EnvIdentity); identity != "" {
+		cfg.IdentityPath = identity
 	}
 }
 
 // Load reads configuration from the config file in the default directory.
+// Environment overrides (see EnvDir) win over the file.
 func Load() (*Config, error) {
 	cfg := DefaultConfig()
 	configPath := filepath.Join(cfg.Directory, DefaultConfigFile)
@@ -99,11 +260,13 @@ func Load() (*Config, error) {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
+	applyEnv(cfg)
 
 	return cfg, nil
 }
 
-// LoadFrom reads configuration from a specific path.
+// LoadFrom reads configuration from a specific path. Environment overrides
+// (see EnvDir) win over the file.
 func LoadFrom(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -114,6 +277,7 @@ func LoadFrom(path string) (*Config, error) {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return nil, err
 	}
+	applyEnv(cfg)
 
 	return cfg, nil
 }
@@ -138,33 +302,186 @@ func (c *Config) EnsureDirectories() error {
 	return os.MkdirAll(c.Directory, 0700)
 }
 
-// Validate checks if the configuration is valid.
+// DeadManFailAction returns what the dead-man switch does when it fires.
+func (c *Config) DeadManFailAction() types.KillswitchOptions {
+	if c.Heartbeat != nil {
+		return c.Heartbeat.FailAction
+	}
+	return types.KillswitchOptions{RevokeAll: true}
+}
+
+// AdapterCacheDir returns the directory for cached adapter pulls.
+func (c *Config) AdapterCacheDir() string {
+	return filepath.Join(c.Directory, DefaultAdapterCacheDir)
+}
+
+// ParseSyslogAddr splits a SyslogAddr into the network and address to dial.
+// unix:// addresses are datagram sockets, as /dev/log is.
+func ParseSyslogAddr(addr string) (network, raddr string, err error) {
+	network, raddr, ok := strings.Cut(addr, "://")
+	if !ok {
+		network, raddr = "udp", addr
+	}
+	switch network {
+	case "udp", "tcp":
+		if _, _, err := net.SplitHostPort(raddr); err != nil {
+			return "", "", fmt.Errorf("invalid address %q: %v", raddr, err)
+		}
+	case "unix":
+		if raddr == "" {
+			return "", "", fmt.Errorf("socket path cannot be empty")
+		}
+		network = "unixgram"
+	default:
+		return "", "", fmt.Errorf("unsupported network %q: must be udp, tcp or unix", network)
+	}
+	return network, raddr, nil
+}
+
+// Validate checks if the configuration is valid. It reports every invalid
+// field, as a ValidationErrors, rather than stopping at the first.
 func (c *Config) Validate() error {
+	var errs ValidationErrors
+	add := func(field, message string) {
+		errs = append(errs, &ConfigError{Field: field, Message: message})
+	}
+
 	if c.Directory == "" {
-		return &ConfigError{Field: "directory", Message: "cannot be empty"}
+		add("directory", "cannot be empty")
 	}
 	if c.DefaultLeaseTTL <= 0 {
-		return &ConfigError{Field: "default_lease_ttl", Message: "must be positive"}
+		add("default_lease_ttl", "must be positive")
 	}
 	if c.MaxLeaseTTL < c.DefaultLeaseTTL {
-		return &ConfigError{Field: "max_lease_ttl", Message: "must be >= default_lease_ttl"}
+		add("max_lease_ttl", "must be >= default_lease_ttl")
+	}
+	if c.LeaseTTLJitter < 0 || c.LeaseTTLJitter >= 100 {
+		add("lease_ttl_jitter", "must be a percentage from 0 to 99")
 	}
 	if c.RotationTimeout <= 0 {
-		return &ConfigError{Field: "rotation_timeout", Message: "must be positive"}
+		add("rotation_timeout", "must be positive")
+	}
+	if c.RotationConcurrency < 0 {
+		add("rotation_concurrency", "cannot be negative")
+	}
+	if c.AdapterCacheTTL < 0 {
+		add("adapter_cache_ttl", "cannot be negative")
+	}
+	if c.HistoryLimit < 0 {
+		add("history_limit", "cannot be negative")
+	}
+	if c.MaxSecrets < 0 {
+		add("max_secrets", "cannot be negative")
+	}
+	if c.MaxStoreBytes < 0 {
+		add("max_store_bytes", "cannot be negative")
+	}
+	if c.MaxLeasesPerClient < 0 {
+		add("max_leases_per_client", "cannot be negative")
+	}
+	if c.IdleTimeout < 0 {
+		add("idle_timeout", "cannot be negative")
+	}
+	if c.ShutdownGracePeriod < 0 {
+		add("shutdown_grace_period", "cannot be negative")
 	}
 
 	if c.Heartbeat != nil && c.Heartbeat.Enabled {
-		if c.Heartbeat.URL == "" {
-			return &ConfigError{Field: "heartbeat.url", Message: "required when heartbeat enabled"}
+		endpoints := len(c.Heartbeat.Endpoints())
+		if endpoints == 0 {
+			add("heartbeat.url", "url or urls required when heartbeat enabled")
+		}
+		if c.Heartbeat.Quorum < 0 {
+			add("heartbeat.quorum", "cannot be negative")
+		} else if c.Heartbeat.Quorum > endpoints {
+			add("heartbeat.quorum", "cannot exceed the number of heartbeat urls")
+		}
+		if c.Heartbeat.FailureThreshold < 0 {
+			add("heartbeat.failure_threshold", "cannot be negative")
 		}
 		if c.Heartbeat.Interval <= 0 {
-			return &ConfigError{Field: "heartbeat.interval", Message: "must be positive"}
+			add("heartbeat.interval", "must be positive")
 		}
 		if c.Heartbeat.Timeout <= 0 {
-			return &ConfigError{Field: "heartbeat.timeout", Message: "must be positive"}
+			add("heartbeat.timeout", "must be positive")
+		}
+		if c.Heartbeat.MaxInterval < 0 {
+			add("heartbeat.max_interval", "cannot be negative")
+		}
+		if c.Heartbeat.BackoffFactor != 0 && c.Heartbeat.BackoffFactor <= 1 {
+			add("heartbeat.backoff_factor", "must be greater than 1")
+		}
+		if c.Heartbeat.Jitter < 0 || c.Heartbeat.Jitter >= 100 {
+			add("heartbeat.jitter", "must be a percentage from 0 to 99")
+		}
+		switch c.Heartbeat.Method {
+		case "", "GET", "HEAD", "POST":
+		default:
+			add("heartbeat.method", "must be GET, HEAD or POST")
+		}
+		for name := range c.Heartbeat.Headers {
+			if strings.TrimSpace(name) == "" {
+				add("heartbeat.headers", "header names cannot be empty")
+				break
+			}
+		}
+	}
+
+	if c.DeadManFile != "" && c.DeadManInterval <= 0 {
+		add("dead_man_interval", "must be positive when dead_man_file is set")
+	}
+	if c.DeadManFile == "" && c.DeadManInterval != 0 {
+		add("dead_man_file", "required when dead_man_interval is set")
+	}
+
+	if c.KillswitchWebhook != "" {
+		if u, err := url.Parse(c.KillswitchWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("killswitch_webhook", "must be an http or https URL")
+		}
+	}
+
+	if c.ArmWindow < 0 {
+		add("arm_window", "cannot be negative")
+	}
+
+	if c.TCP != nil {
+		if c.TCP.Addr == "" {
+			add("tcp.addr", "cannot be empty")
+		}
+		if c.TCP.CertPath == "" || c.TCP.KeyPath == "" {
+			add("tcp.cert_path", "cert_path and key_path are required")
+		}
+		if c.TCP.ClientCAPath == "" {
+			add("tcp.client_ca_path", "required for client certificate verification")
+		}
+	}
+
+	if c.ListenTCP != "" && c.AuthToken == "" {
+		add("auth_token", "required when listen_tcp is set")
+	}
+
+	if c.SyslogAddr != "" {
+		if _, _, err := ParseSyslogAddr(c.SyslogAddr); err != nil {
+			add("syslog_addr", err.Error())
+		}
+	}
+
+	for _, uid := range c.AllowedUIDs {
+		if uid < 0 {
+			add("allowed_uids", "cannot contain negative user IDs")
+			break
 		}
 	}
 
+	for client := range c.Policies {
+		if client == "" {
+			add("policies", "client identity cannot be empty")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
@@ -177,3 +494,68 @@ type ConfigError struct {
 func (e *ConfigError) Error() string {
 	return "config: " + e.Field + " " + e.Message
 }
+
+// suggestions holds fix hints for ConfigError fields, matched by the
+// field or its top-level section (e.g. "heartbeat" for "heartbeat.url").
+var suggestions = map[string]string{
+	"directory":         `Set "directory" to the data directory, e.g. "/home/you/.agent-secrets".`,
+	"default_lease_ttl": `Set "default_lease_ttl" to a positive duration in nanoseconds, e.g. 3600000000000 for 1h.`,
+	"max_lease_ttl":     `Raise "max_lease_ttl" to at least "default_lease_ttl", or lower "default_lease_ttl".`,
+	"rotation_timeout":  `Set "rotation_timeout" to a positive duration in nanoseconds, e.g. 30000000000 for 30s.`,
+	"heartbeat":         `Fix the "heartbeat" section, or set "enabled": false to turn the monitor off.`,
+	"dead_man_file":     `Set both "dead_man_file" and "dead_man_interval", or remove both.`,
+	"dead_man_interval": `Set both "dead_man_file" and "dead_man_interval", or remove both.`,
+	"tcp":               `Fix the "tcp" section, or remove it to serve only the Unix socket.`,
+	"auth_token":        `Set "auth_token" to a long random string, e.g. from 'openssl rand -hex 32'.`,
+	"syslog_addr":       `Set "syslog_addr" to host:port, udp://host:port, tcp://host:port or unix:///dev/log.`,
+}
+
+// Suggestion returns a hint for fixing the error.
+func (e *ConfigError) Suggestion() string {
+	if s, ok := suggestions[e.Field]; ok {
+		return s
+	}
+	section, _, _ := strings.Cut(e.Field, ".")
+	if s, ok := suggestions[section]; ok {
+		return s
+	}
+	return fmt.Sprintf("Fix %q in the config file.", e.Field)
+}
+
+// maxUsualLeaseTTL is the longest max_lease_ttl Warnings accepts without
+// comment.
+const maxUsualLeaseTTL = 7 * 24 * time.Hour
+
+// Warnings reports settings that are valid but unusual enough to be a
+// likely mistake. It is independent of Validate.
+func (c *Config) Warnings() []*ConfigError {
+	var warnings []*ConfigError
+	add := func(field, message string) {
+		warnings = append(warnings, &ConfigError{Field: field, Message: message})
+	}
+
+	if c.MaxLeaseTTL > maxUsualLeaseTTL {
+		add("max_lease_ttl", fmt.Sprintf("is %v, longer than 7 days", c.MaxLeaseTTL))
+	}
+	if c.DefaultLeaseTTL > 0 && c.DefaultLeaseTTL < time.Minute {
+		add("default_lease_ttl", fmt.Sprintf("is %v; missing nanosecond digits?", c.DefaultLeaseTTL))
+	}
+	if c.RotationTimeout > 0 && c.RotationTimeout < time.Second {
+		add("rotation_timeout", fmt.Sprintf("is %v; missing nanosecond digits?", c.RotationTimeout))
+	}
+	if c.Heartbeat != nil && c.Heartbeat.Enabled && c.Heartbeat.FailAction.WipeStore && c.ArmedByDefault {
+		add("armed_by_default", "lets a failed heartbeat wipe the store")
+	}
+	return warnings
+}
+
+// ValidationErrors is every ConfigError found by Validate.
+type ValidationErrors []*ConfigError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
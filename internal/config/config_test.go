@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -52,11 +54,160 @@ func TestConfigValidate(t *testing.T) {
 			modify:  func(c *Config) { c.MaxLeaseTTL = 30 * time.Minute },
 			wantErr: true,
 		},
+		{
+			name:    "lease jitter of 100 percent",
+			modify:  func(c *Config) { c.LeaseTTLJitter = 100 },
+			wantErr: true,
+		},
+		{
+			name:    "negative lease jitter",
+			modify:  func(c *Config) { c.LeaseTTLJitter = -5 },
+			wantErr: true,
+		},
+		{
+			name:    "lease jitter",
+			modify:  func(c *Config) { c.LeaseTTLJitter = 10 },
+			wantErr: false,
+		},
 		{
 			name:    "zero rotation timeout",
 			modify:  func(c *Config) { c.RotationTimeout = 0 },
 			wantErr: true,
 		},
+		{
+			name:    "negative rotation concurrency",
+			modify:  func(c *Config) { c.RotationConcurrency = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "listen tcp without auth token",
+			modify:  func(c *Config) { c.ListenTCP = "127.0.0.1:7788" },
+			wantErr: true,
+		},
+		{
+			name: "listen tcp with auth token",
+			modify: func(c *Config) {
+				c.ListenTCP = "127.0.0.1:7788"
+				c.AuthToken = "token"
+			},
+			wantErr: false,
+		},
+		{
+			name:    "negative allowed uid",
+			modify:  func(c *Config) { c.AllowedUIDs = []int{501, -1} },
+			wantErr: true,
+		},
+		{
+			name:    "negative idle timeout",
+			modify:  func(c *Config) { c.IdleTimeout = -time.Second },
+			wantErr: true,
+		},
+		{
+			name:    "syslog udp address",
+			modify:  func(c *Config) { c.SyslogAddr = "udp://127.0.0.1:514" },
+			wantErr: false,
+		},
+		{
+			name:    "syslog bare host:port",
+			modify:  func(c *Config) { c.SyslogAddr = "logs.internal:514" },
+			wantErr: false,
+		},
+		{
+			name:    "syslog socket path",
+			modify:  func(c *Config) { c.SyslogAddr = "unix:///dev/log" },
+			wantErr: false,
+		},
+		{
+			name:    "syslog unsupported network",
+			modify:  func(c *Config) { c.SyslogAddr = "http://logs.internal:514" },
+			wantErr: true,
+		},
+		{
+			name:    "syslog address without port",
+			modify:  func(c *Config) { c.SyslogAddr = "tcp://logs.internal" },
+			wantErr: true,
+		},
+		{
+			name: "dead man switch",
+			modify: func(c *Config) {
+				c.DeadManFile = "/tmp/keepalive"
+				c.DeadManInterval = 24 * time.Hour
+			},
+			wantErr: false,
+		},
+		{
+			name:    "dead man file without interval",
+			modify:  func(c *Config) { c.DeadManFile = "/tmp/keepalive" },
+			wantErr: true,
+		},
+		{
+			name:    "dead man interval without file",
+			modify:  func(c *Config) { c.DeadManInterval = time.Hour },
+			wantErr: true,
+		},
+		{
+			name:    "killswitch webhook",
+			modify:  func(c *Config) { c.KillswitchWebhook = "https://hooks.example.com/page" },
+			wantErr: false,
+		},
+		{
+			name:    "killswitch webhook without scheme",
+			modify:  func(c *Config) { c.KillswitchWebhook = "hooks.example.com/page" },
+			wantErr: true,
+		},
+		{
+			name: "arm window",
+			modify: func(c *Config) {
+				c.ArmedByDefault = true
+				c.ArmWindow = time.Hour
+			},
+			wantErr: false,
+		},
+		{
+			name:    "negative arm window",
+			modify:  func(c *Config) { c.ArmWindow = -time.Minute },
+			wantErr: true,
+		},
+		{
+			name:    "negative history limit",
+			modify:  func(c *Config) { c.HistoryLimit = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "history disabled",
+			modify:  func(c *Config) { c.HistoryLimit = 0 },
+			wantErr: false,
+		},
+		{
+			name:    "negative max secrets",
+			modify:  func(c *Config) { c.MaxSecrets = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative max store bytes",
+			modify:  func(c *Config) { c.MaxStoreBytes = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative max leases per client",
+			modify:  func(c *Config) { c.MaxLeasesPerClient = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "negative adapter cache TTL",
+			modify:  func(c *Config) { c.AdapterCacheTTL = -time.Second },
+			wantErr: true,
+		},
+		{
+			name:    "adapter cache disabled",
+			modify:  func(c *Config) { c.AdapterCacheTTL = 0 },
+			wantErr: false,
+		},
+		{
+			name:    "negative shutdown grace period",
+			modify:  func(c *Config) { c.ShutdownGracePeriod = -time.Second },
+			wantErr: true,
+		},
 		{
 			name: "heartbeat enabled without URL",
 			modify: func(c *Config) {
@@ -80,6 +231,137 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "heartbeat urls with quorum",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:  true,
+					URLs:     []string{"https://a.example.com/hb", "https://b.example.com/hb", "https://c.example.com/hb"},
+					Quorum:   2,
+					Interval: time.Minute,
+					Timeout:  10 * time.Second,
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative heartbeat failure threshold",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:          true,
+					URL:              "https://example.com/heartbeat",
+					Interval:         time.Minute,
+					Timeout:          10 * time.Second,
+					FailureThreshold: -1,
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "heartbeat backoff",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:       true,
+					URL:           "https://example.com/heartbeat",
+					Interval:      time.Minute,
+					Timeout:       10 * time.Second,
+					MaxInterval:   10 * time.Minute,
+					BackoffFactor: 1.5,
+					Jitter:        20,
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "heartbeat backoff factor not above 1",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:       true,
+					URL:           "https://example.com/heartbeat",
+					Interval:      time.Minute,
+					Timeout:       10 * time.Second,
+					BackoffFactor: 0.5,
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "heartbeat jitter out of range",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:  true,
+					URL:      "https://example.com/heartbeat",
+					Interval: time.Minute,
+					Timeout:  10 * time.Second,
+					Jitter:   100,
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "heartbeat with method and headers",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:  true,
+					URL:      "https://example.com/heartbeat",
+					Interval: time.Minute,
+					Timeout:  10 * time.Second,
+					Method:   "POST",
+					Headers:  map[string]string{"Authorization": "Bearer $HEARTBEAT_TOKEN"},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "heartbeat unsupported method",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:  true,
+					URL:      "https://example.com/heartbeat",
+					Interval: time.Minute,
+					Timeout:  10 * time.Second,
+					Method:   "DELETE",
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "heartbeat quorum above url count",
+			modify: func(c *Config) {
+				c.Heartbeat = &types.HeartbeatConfig{
+					Enabled:  true,
+					URL:      "https://example.com/heartbeat",
+					URLs:     []string{"https://b.example.com/hb"},
+					Quorum:   3,
+					Interval: time.Minute,
+					Timeout:  10 * time.Second,
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "tcp without client CA",
+			modify: func(c *Config) {
+				c.TCP = &TCPConfig{
+					Addr:     "127.0.0.1:7443",
+					CertPath: "/tmp/server.crt",
+					KeyPath:  "/tmp/server.key",
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid tcp config",
+			modify: func(c *Config) {
+				c.TCP = &TCPConfig{
+					Addr:         "127.0.0.1:7443",
+					CertPath:     "/tmp/server.crt",
+					KeyPath:      "/tmp/server.key",
+					ClientCAPath: "/tmp/ca.crt",
+				}
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -94,6 +376,78 @@ func TestConfigValidate(t *testing.T) {
 	}
 }
 
+func TestConfigValidate_ReportsAllErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Directory = ""
+	cfg.RotationTimeout = 0
+
+	var errs ValidationErrors
+	if err := cfg.Validate(); !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	if errs[0].Field != "directory" || errs[1].Field != "rotation_timeout" {
+		t.Errorf("fields = %q, %q, want directory, rotation_timeout", errs[0].Field, errs[1].Field)
+	}
+}
+
+func TestConfigErrorSuggestion(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		field  string
+		want   string
+	}{
+		{"missing directory", func(c *Config) { c.Directory = "" }, "directory", `"directory"`},
+		{"inverted TTLs", func(c *Config) { c.MaxLeaseTTL = c.DefaultLeaseTTL / 2 }, "max_lease_ttl", "at least"},
+		{"section fallback", func(c *Config) { c.Heartbeat = &types.HeartbeatConfig{Enabled: true} }, "heartbeat.url", `"heartbeat" section`},
+		{"generic fallback", func(c *Config) { c.HistoryLimit = -1 }, "history_limit", `"history_limit"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(cfg)
+
+			var errs ValidationErrors
+			if !errors.As(cfg.Validate(), &errs) {
+				t.Fatal("expected ValidationErrors")
+			}
+			for _, e := range errs {
+				if e.Field == tt.field {
+					if !strings.Contains(e.Suggestion(), tt.want) {
+						t.Errorf("Suggestion() = %q, want it to mention %q", e.Suggestion(), tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("expected an error for %s, got %v", tt.field, errs)
+		})
+	}
+}
+
+func TestConfigWarnings(t *testing.T) {
+	cfg := DefaultConfig()
+	if w := cfg.Warnings(); len(w) != 0 {
+		t.Errorf("expected no warnings for the defaults, got %v", w)
+	}
+
+	cfg.MaxLeaseTTL = 8 * 24 * time.Hour
+	cfg.RotationTimeout = 30 // Nanoseconds, not seconds
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected unusual values to be valid, got %v", err)
+	}
+	fields := map[string]bool{}
+	for _, w := range cfg.Warnings() {
+		fields[w.Field] = true
+	}
+	if !fields["max_lease_ttl"] || !fields["rotation_timeout"] || len(fields) != 2 {
+		t.Errorf("expected max_lease_ttl and rotation_timeout warnings, got %v", cfg.Warnings())
+	}
+}
+
 func TestConfigSaveLoad(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -148,6 +502,63 @@ func TestLoadNonExistent(t *testing.T) {
 	}
 }
 
+func TestLoad_EnvOverrides(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Setenv(EnvDir, dir)
+
+	// The directory moves the defaults along with it
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Directory != dir {
+		t.Errorf("Directory = %q, want %q", cfg.Directory, dir)
+	}
+	if want := filepath.Join(dir, DefaultSocket); cfg.SocketPath != want {
+		t.Errorf("SocketPath = %q, want %q", cfg.SocketPath, want)
+	}
+	if want := filepath.Join(dir, DefaultSecretsFile); cfg.SecretsPath != want {
+		t.Errorf("SecretsPath = %q, want %q", cfg.SecretsPath, want)
+	}
+
+	// The config file is read from the overridden directory, and the
+	// environment wins over it
+	file := DefaultConfig()
+	file.SocketPath = "/from/file.sock"
+	file.IdentityPath = "/from/file.age"
+	file.MaxLeaseTTL = 2 * time.Hour
+	if err := file.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	socket := filepath.Join(t.TempDir(), "env.sock")
+	t.Setenv(EnvSocket, socket)
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.MaxLeaseTTL != 2*time.Hour {
+		t.Errorf("MaxLeaseTTL = %v, want the file's 2h", cfg.MaxLeaseTTL)
+	}
+	if cfg.SocketPath != socket {
+		t.Errorf("SocketPath = %q, want the environment's %q", cfg.SocketPath, socket)
+	}
+	if cfg.IdentityPath != "/from/file.age" {
+		t.Errorf("IdentityPath = %q, want the file's", cfg.IdentityPath)
+	}
+
+	identity := filepath.Join(t.TempDir(), "env.age")
+	t.Setenv(EnvIdentity, identity)
+	cfg, err = LoadFrom(filepath.Join(dir, DefaultConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+	if cfg.IdentityPath != identity {
+		t.Errorf("IdentityPath = %q, want the environment's %q", cfg.IdentityPath, identity)
+	}
+}
+
 func TestEnsureDirectories(t *testing.T) {
 	tmpDir := t.TempDir()
 	nestedDir := filepath.Join(tmpDir, "deeply", "nested", "dir")
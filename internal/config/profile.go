@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ProfilesDir is the directory under the data directory holding one
+	// subdirectory per profile.
+	ProfilesDir = "profiles"
+	// ActiveProfileFile records the profile used when --profile is not given.
+	ActiveProfileFile = "active_profile"
+	// DefaultProfile names the unprofiled store in ~/.agent-secrets itself.
+	DefaultProfile = "default"
+)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateProfileName checks that name can be used as a profile directory.
+func ValidateProfileName(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("profile name %q is reserved for the store without a profile", name)
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// ProfileDir returns the data directory of the named profile.
+func ProfileDir(name string) (string, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(rootDir(), ProfilesDir, name), nil
+}
+
+// DefaultProfileConfig returns the defaults for the named profile, with its
+// own identity, secrets, socket, leases and audit log under ProfileDir.
+// The AGENT_SECRETS_SOCKET and AGENT_SECRETS_IDENTITY overrides do not
+// apply, so that profiles never share a store or daemon.
+func DefaultProfileConfig(name string) (*Config, error) {
+	dir, err := ProfileDir(name)
+	if err != nil {
+		return nil, err
+	}
+	return defaultConfigIn(dir), nil
+}
+
+// LoadProfile reads the named profile's config file, falling back to the
+// profile's defaults if it has none.
+func LoadProfile(name string) (*Config, error) {
+	cfg, err := DefaultProfileConfig(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.Directory, DefaultConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Profiles lists the profiles that have a data directory, sorted by name.
+func Profiles() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(rootDir(), ProfilesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && ValidateProfileName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ActiveProfile returns the profile recorded by SetActiveProfile, or ""
+// if none is.
+func ActiveProfile() (string, error) {
+	data, err := os.ReadFile(filepath.Join(rootDir(), ActiveProfileFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SetActiveProfile records name as the profile to use by default. The
+// empty name or DefaultProfile goes back to the store without a profile.
+func SetActiveProfile(name string) error {
+	path := filepath.Join(rootDir(), ActiveProfileFile)
+	if name == "" || name == DefaultProfile {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(rootDir(), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(name+"\n"), 0600)
+}
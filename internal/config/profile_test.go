@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(EnvDir, root)
+	t.Setenv(EnvSocket, filepath.Join(root, "shared.sock"))
+
+	work, err := LoadProfile("work")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	personal, err := LoadProfile("personal")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+
+	dir := filepath.Join(root, ProfilesDir, "work")
+	want := map[string]string{
+		"Directory":    dir,
+		"SocketPath":   filepath.Join(dir, DefaultSocket),
+		"IdentityPath": filepath.Join(dir, DefaultIdentityFile),
+		"SecretsPath":  filepath.Join(dir, DefaultSecretsFile),
+		"LeasesPath":   filepath.Join(dir, DefaultLeasesFile),
+		"AuditPath":    filepath.Join(dir, DefaultAuditFile),
+	}
+	got := map[string]string{
+		"Directory":    work.Directory,
+		"SocketPath":   work.SocketPath,
+		"IdentityPath": work.IdentityPath,
+		"SecretsPath":  work.SecretsPath,
+		"LeasesPath":   work.LeasesPath,
+		"AuditPath":    work.AuditPath,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("work profile paths = %v, want %v", got, want)
+	}
+	if personal.SocketPath == work.SocketPath || personal.IdentityPath == work.IdentityPath {
+		t.Errorf("expected profiles to have separate paths, got %q and %q", personal.Directory, work.Directory)
+	}
+
+	// A profile's own config file is read
+	work.MaxLeaseTTL *= 2
+	if err := work.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := LoadProfile("work")
+	if err != nil {
+		t.Fatalf("LoadProfile() error = %v", err)
+	}
+	if reloaded.MaxLeaseTTL != work.MaxLeaseTTL {
+		t.Errorf("MaxLeaseTTL = %v, want %v", reloaded.MaxLeaseTTL, work.MaxLeaseTTL)
+	}
+
+	for _, name := range []string{"", DefaultProfile, "../escape", "a/b", ".hidden"} {
+		if _, err := LoadProfile(name); err == nil {
+			t.Errorf("LoadProfile(%q) expected error", name)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(EnvDir, root)
+
+	names, err := Profiles()
+	if err != nil || len(names) != 0 {
+		t.Fatalf("Profiles() = %v, %v; want none", names, err)
+	}
+
+	for _, name := range []string{"work", "personal"} {
+		cfg, err := DefaultProfileConfig(name)
+		if err != nil {
+			t.Fatalf("DefaultProfileConfig() error = %v", err)
+		}
+		if err := cfg.EnsureDirectories(); err != nil {
+			t.Fatalf("EnsureDirectories() error = %v", err)
+		}
+	}
+	names, err = Profiles()
+	if err != nil {
+		t.Fatalf("Profiles() error = %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"personal", "work"}) {
+		t.Errorf("Profiles() = %v, want [personal work]", names)
+	}
+}
+
+func TestActiveProfile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(EnvDir, root)
+
+	if name, err := ActiveProfile(); err != nil || name != "" {
+		t.Fatalf("ActiveProfile() = %q, %v; want none", name, err)
+	}
+
+	if err := SetActiveProfile("work"); err != nil {
+		t.Fatalf("SetActiveProfile() error = %v", err)
+	}
+	if name, _ := ActiveProfile(); name != "work" {
+		t.Errorf("ActiveProfile() = %q, want work", name)
+	}
+	info, err := os.Stat(filepath.Join(root, ActiveProfileFile))
+	if err != nil {
+		t.Fatalf("active profile not recorded: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("active profile permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	if err := SetActiveProfile("bad/name"); err == nil {
+		t.Error("expected error for an invalid profile name")
+	}
+
+	if err := SetActiveProfile(DefaultProfile); err != nil {
+		t.Fatalf("SetActiveProfile() error = %v", err)
+	}
+	if name, _ := ActiveProfile(); name != "" {
+		t.Errorf("ActiveProfile() = %q after switching back, want none", name)
+	}
+}
@@ -2,10 +2,19 @@ package daemon
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,25 +27,42 @@ import (
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
+// cleanupInterval is how often expired leases and secrets are removed.
+const cleanupInterval = 1 * time.Minute
+
+// bearerPrefix starts the optional first line of a token-authenticated
+// connection, which authenticates every request that follows.
+const bearerPrefix = "Bearer "
+
 // Daemon manages the Unix socket server and request handling.
 type Daemon struct {
-	cfg       *config.Config
-	listener  net.Listener
-	handler   *Handler
-	startedAt time.Time
-	running   bool
-	mu        sync.RWMutex
+	cfg           *config.Config
+	listener      net.Listener
+	tcpListener   net.Listener
+	tokenListener net.Listener // Plain TCP, authenticated by bearer token
+	healthServer  *http.Server // Serves /healthz on HealthAddr
+	healthAddr    net.Addr
+	handler       *Handler
+	allowedUIDs   map[string]bool // Unix socket peers that may connect
+	startedAt     time.Time
+	running       bool
+	mu            sync.RWMutex
 
 	// Components
 	store            *store.Store
 	leaseManager     *lease.Manager
 	rotationExecutor *rotation.Executor
+	scheduler        *rotation.Scheduler
 	killswitch       *killswitch.Killswitch
+	heartbeat        *killswitch.HeartbeatMonitor // nil unless heartbeat is enabled
+	deadMan          *killswitch.HeartbeatMonitor // nil unless dead_man_file is set
 	auditLogger      *audit.Logger
 
 	// Shutdown coordination
-	done chan struct{}
-	wg   sync.WaitGroup
+	done   chan struct{}
+	wg     sync.WaitGroup
+	connMu sync.Mutex
+	conns  map[net.Conn]struct{} // open connections, force-closed after the grace period
 }
 
 // NewDaemon creates a new daemon with the provided configuration.
@@ -61,6 +87,16 @@ func NewDaemonWithOptions(cfg *config.Config, skipPermissionCheck bool) (*Daemon
 	if err != nil {
 		return nil, fmt.Errorf("failed to create audit logger: %w", err)
 	}
+	if cfg.SyslogAddr != "" {
+		network, raddr, err := config.ParseSyslogAddr(cfg.SyslogAddr)
+		if err == nil {
+			err = auditLogger.ForwardSyslog(network, raddr)
+		}
+		if err != nil {
+			_ = auditLogger.Close()
+			return nil, fmt.Errorf("invalid syslog_addr: %w", err)
+		}
+	}
 
 	// Initialize store with optional permission check skip
 	st := store.NewWithOptions(cfg, skipPermissionCheck)
@@ -70,6 +106,7 @@ func NewDaemonWithOptions(cfg *config.Config, skipPermissionCheck bool) (*Daemon
 			return nil, fmt.Errorf("failed to initialize store: %w", err)
 		}
 	}
+	st.SetAuditLogger(auditLogger)
 
 	// Initialize lease manager
 	leaseManager, err := lease.NewManager(cfg, auditLogger)
@@ -82,19 +119,52 @@ func NewDaemonWithOptions(cfg *config.Config, skipPermissionCheck bool) (*Daemon
 
 	// Initialize killswitch
 	ks := killswitch.NewKillswitch(leaseManager, rotationExecutor, st, auditLogger)
+	if cfg.KillswitchWebhook != "" {
+		ks.SetWebhook(cfg.KillswitchWebhook)
+	}
+	ks.SetArmWindow(cfg.ArmWindow)
+	if cfg.ArmedByDefault {
+		ks.Arm(0)
+	}
 
 	// Create handler
+	policy, err := NewPolicy(cfg.Policies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 	handler := NewHandler(st, leaseManager, rotationExecutor, ks, auditLogger)
+	handler.SetPolicy(policy)
+
+	var heartbeat *killswitch.HeartbeatMonitor
+	if cfg.Heartbeat != nil && cfg.Heartbeat.Enabled {
+		heartbeat = killswitch.NewHeartbeatMonitor(*cfg.Heartbeat, ks, auditLogger)
+	}
+	var deadMan *killswitch.HeartbeatMonitor
+	if cfg.DeadManFile != "" {
+		deadMan = killswitch.NewDeadManMonitor(cfg.DeadManFile, cfg.DeadManInterval, cfg.DeadManFailAction(), ks, auditLogger)
+		handler.SetDeadMan(cfg.DeadManFile, cfg.DeadManInterval)
+	}
+
+	// The daemon's own user may always connect
+	allowedUIDs := map[string]bool{strconv.Itoa(os.Getuid()): true}
+	for _, uid := range cfg.AllowedUIDs {
+		allowedUIDs[strconv.Itoa(uid)] = true
+	}
 
 	return &Daemon{
 		cfg:              cfg,
 		handler:          handler,
+		allowedUIDs:      allowedUIDs,
 		store:            st,
 		leaseManager:     leaseManager,
 		rotationExecutor: rotationExecutor,
+		scheduler:        rotation.NewScheduler(rotationExecutor),
 		killswitch:       ks,
+		heartbeat:        heartbeat,
+		deadMan:          deadMan,
 		auditLogger:      auditLogger,
 		done:             make(chan struct{}),
+		conns:            make(map[net.Conn]struct{}),
 	}, nil
 }
 
@@ -106,6 +176,14 @@ func (d *Daemon) Start() error {
 		return types.ErrDaemonAlreadyRunning
 	}
 
+	// A restart counts as a keep-alive, so a stale file cannot fire at once
+	if d.cfg.DeadManFile != "" {
+		if _, err := killswitch.TouchKeepAlive(d.cfg.DeadManFile); err != nil {
+			d.mu.Unlock()
+			return err
+		}
+	}
+
 	// Remove existing socket file if present
 	if err := os.Remove(d.cfg.SocketPath); err != nil && !os.IsNotExist(err) {
 		d.mu.Unlock()
@@ -126,28 +204,190 @@ func (d *Daemon) Start() error {
 		return fmt.Errorf("failed to set socket permissions: %w", err)
 	}
 
+	// Create optional mutual-TLS listener
+	if d.cfg.TCP != nil {
+		tcpListener, err := listenTLS(d.cfg.TCP)
+		if err != nil {
+			listener.Close()
+			d.mu.Unlock()
+			return fmt.Errorf("failed to listen on tcp: %w", err)
+		}
+		d.tcpListener = tcpListener
+	}
+
+	// Create optional token-authenticated TCP listener
+	if d.cfg.ListenTCP != "" {
+		tokenListener, err := net.Listen("tcp", d.cfg.ListenTCP)
+		if err != nil {
+			if d.tcpListener != nil {
+				d.tcpListener.Close()
+				d.tcpListener = nil
+			}
+			listener.Close()
+			d.mu.Unlock()
+			return fmt.Errorf("failed to listen on tcp: %w", err)
+		}
+		d.tokenListener = tokenListener
+	}
+
+	// Create optional HTTP health listener
+	var healthListener net.Listener
+	if d.cfg.HealthAddr != "" {
+		healthListener, err = net.Listen("tcp", d.cfg.HealthAddr)
+		if err != nil {
+			if d.tcpListener != nil {
+				d.tcpListener.Close()
+				d.tcpListener = nil
+			}
+			if d.tokenListener != nil {
+				d.tokenListener.Close()
+				d.tokenListener = nil
+			}
+			listener.Close()
+			d.mu.Unlock()
+			return fmt.Errorf("failed to listen for health checks: %w", err)
+		}
+		d.healthAddr = healthListener.Addr()
+		d.healthServer = &http.Server{
+			Handler:           d.healthHandler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+
 	d.listener = listener
 	d.startedAt = time.Now()
 	d.running = true
 	d.mu.Unlock()
 
 	// Log daemon start
+	details := fmt.Sprintf("listening on %s", d.cfg.SocketPath)
+	if d.tcpListener != nil {
+		details += fmt.Sprintf(" and tcp %s", d.tcpListener.Addr())
+	}
+	if d.tokenListener != nil {
+		details += fmt.Sprintf(" and token-authenticated tcp %s as token:%s", d.tokenListener.Addr(), d.tokenID())
+	}
+	if d.healthServer != nil {
+		details += fmt.Sprintf(", health checks on http://%s/healthz", d.healthAddr)
+	}
 	entry := audit.NewEntry(types.ActionDaemonStart, true).
-		WithDetails(fmt.Sprintf("listening on %s", d.cfg.SocketPath)).
+		WithDetails(details).
 		Build()
 	_ = d.auditLogger.Log(entry)
 
-	// Start lease cleanup loop
-	d.leaseManager.StartCleanupLoop(1 * time.Minute)
+	// Start lease cleanup loop, and purge expired secrets alongside it
+	d.leaseManager.StartCleanupLoop(cleanupInterval)
+	d.wg.Add(1)
+	go d.purgeLoop(cleanupInterval)
+
+	// Run scheduled rotations on the same cadence
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.scheduler.Run(cleanupInterval, d.done)
+	}()
 
 	// Accept connections in a goroutine
 	d.wg.Add(1)
-	go d.acceptLoop()
+	go d.acceptLoop(d.listener, false)
+
+	if d.tcpListener != nil {
+		d.wg.Add(1)
+		go d.acceptLoop(d.tcpListener, false)
+	}
+	if d.tokenListener != nil {
+		d.wg.Add(1)
+		go d.acceptLoop(d.tokenListener, true)
+	}
+	if d.healthServer != nil {
+		go func() { _ = d.healthServer.Serve(healthListener) }()
+	}
+	if d.heartbeat != nil {
+		d.heartbeat.Start()
+	}
+	if d.deadMan != nil {
+		d.deadMan.Start()
+	}
 
 	return nil
 }
 
-// Stop gracefully shuts down the daemon.
+// purgeLoop deletes expired secrets every interval until shutdown.
+func (d *Daemon) purgeLoop(interval time.Duration) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.store.PurgeExpired()
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// listenTLS creates a TCP listener that requires a client certificate
+// signed by the configured CA.
+func listenTLS(tcpCfg *config.TCPConfig) (net.Listener, error) {
+	cert, err := tls.LoadX509KeyPair(tcpCfg.CertPath, tcpCfg.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(tcpCfg.ClientCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", tcpCfg.ClientCAPath)
+	}
+
+	return tls.Listen("tcp", tcpCfg.Addr, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+}
+
+// TCPAddr returns the address of the TCP listener, or nil if TCP is disabled.
+func (d *Daemon) TCPAddr() net.Addr {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.tcpListener == nil {
+		return nil
+	}
+	return d.tcpListener.Addr()
+}
+
+// HealthAddr returns the address of the HTTP health listener, or nil if
+// HealthAddr is not set.
+func (d *Daemon) HealthAddr() net.Addr {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.healthAddr
+}
+
+// TokenAddr returns the address of the token-authenticated TCP listener, or
+// nil if ListenTCP is not set.
+func (d *Daemon) TokenAddr() net.Addr {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.tokenListener == nil {
+		return nil
+	}
+	return d.tokenListener.Addr()
+}
+
+// Stop gracefully shuts down the daemon. In-flight connections get up to the
+// configured shutdown grace period to finish before they are closed. Leases
+// are saved and the audit log is closed before Stop returns.
 func (d *Daemon) Stop() error {
 	d.mu.Lock()
 	if !d.running {
@@ -157,14 +397,29 @@ func (d *Daemon) Stop() error {
 	d.running = false
 	d.mu.Unlock()
 
-	// Close the listener to stop accepting new connections
+	// Close the listeners to stop accepting new connections
 	if d.listener != nil {
 		d.listener.Close()
 	}
+	if d.tcpListener != nil {
+		d.tcpListener.Close()
+	}
+	if d.tokenListener != nil {
+		d.tokenListener.Close()
+	}
+	if d.healthServer != nil {
+		d.healthServer.Close()
+	}
+	if d.heartbeat != nil {
+		d.heartbeat.Stop()
+	}
+	if d.deadMan != nil {
+		d.deadMan.Stop()
+	}
 
 	// Signal shutdown and wait for all connections to finish
 	close(d.done)
-	d.wg.Wait()
+	d.waitForConnections(d.cfg.ShutdownGracePeriod)
 
 	// Stop lease cleanup loop
 	d.leaseManager.StopCleanupLoop()
@@ -192,12 +447,61 @@ func (d *Daemon) Stop() error {
 	return nil
 }
 
-// acceptLoop accepts incoming connections and spawns handlers.
-func (d *Daemon) acceptLoop() {
+// RunUntilSignal blocks until one of sigs is received, then stops the daemon.
+// It is used when the daemon runs in the foreground, so that Ctrl-C or a
+// container stop still flushes leases and the audit log.
+func (d *Daemon) RunUntilSignal(sigs ...os.Signal) (os.Signal, error) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sigs...)
+	defer signal.Stop(sigCh)
+
+	sig := <-sigCh
+	return sig, d.Stop()
+}
+
+// waitForConnections waits for connection handlers to return. Connections
+// still open after grace are closed so their handlers unblock.
+func (d *Daemon) waitForConnections(grace time.Duration) {
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return
+	case <-time.After(grace):
+	}
+
+	d.connMu.Lock()
+	for conn := range d.conns {
+		conn.Close()
+	}
+	d.connMu.Unlock()
+
+	<-finished
+}
+
+// trackConn records conn as open, or removes it once closed.
+func (d *Daemon) trackConn(conn net.Conn, open bool) {
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+
+	if open {
+		d.conns[conn] = struct{}{}
+	} else {
+		delete(d.conns, conn)
+	}
+}
+
+// acceptLoop accepts incoming connections and spawns handlers. Connections
+// on a listener with requireToken must authenticate with AuthToken.
+func (d *Daemon) acceptLoop(listener net.Listener, requireToken bool) {
 	defer d.wg.Done()
 
 	for {
-		conn, err := d.listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
 			// Check if we're shutting down
 			select {
@@ -211,30 +515,74 @@ func (d *Daemon) acceptLoop() {
 
 		// Handle connection in a goroutine
 		d.wg.Add(1)
-		go d.handleConnection(conn)
+		go d.handleConnection(conn, requireToken)
 	}
 }
 
 // handleConnection processes requests from a single connection.
-// Each line is expected to be a JSON-RPC request.
-func (d *Daemon) handleConnection(conn net.Conn) {
+// Each line is expected to be a JSON-RPC request. With requireToken, the
+// connection either opens with a "Bearer <token>" line or carries the
+// token in the auth field of every request; an unauthenticated request is
+// answered with RPCUnauthorized and the connection closed. Unix socket
+// peers must run as the daemon's user or one in AllowedUIDs.
+func (d *Daemon) handleConnection(conn net.Conn, requireToken bool) {
 	defer d.wg.Done()
 	defer conn.Close()
 
-	// Set a read deadline to prevent indefinite blocking
-	// Use 10s for server-side to be more generous than client default
-	if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
-		entry := audit.NewEntry(types.ActionDaemonStop, false).
+	d.trackConn(conn, true)
+	defer d.trackConn(conn, false)
+
+	// Set a read deadline so an idle client cannot hold the connection,
+	// including through a TLS handshake
+	idle := d.idleTimeout()
+	if err := conn.SetReadDeadline(time.Now().Add(idle)); err != nil {
+		entry := audit.NewEntry(types.ActionConnError, false).
 			WithDetails(fmt.Sprintf("failed to set read deadline: %v", err)).
 			Build()
 		_ = d.auditLogger.Log(entry)
 		return
 	}
 
+	peer, err := peerFromConn(conn)
+	if err != nil {
+		entry := audit.NewEntry(types.ActionAuthFailed, false).
+			WithPeer(types.Peer{RemoteAddr: conn.RemoteAddr().String()}).
+			WithDetails(fmt.Sprintf("tls handshake failed: %v", err)).
+			Build()
+		_ = d.auditLogger.Log(entry)
+		return
+	}
+	if requireToken {
+		peer = types.Peer{RemoteAddr: conn.RemoteAddr().String()}
+	}
+
 	scanner := bufio.NewScanner(conn)
 	encoder := json.NewEncoder(conn)
 
-	for scanner.Scan() {
+	// The UID is only known for Unix socket peers on supported platforms.
+	// There, a peer whose UID cannot be read is refused rather than let in
+	// unchecked.
+	if _, local := conn.(*net.UnixConn); local && peerCredSupported && peer.UID == "" {
+		d.reject(encoder, nil, peer, "peer credentials unavailable")
+		return
+	}
+	if peer.UID != "" && !d.allowedUIDs[peer.UID] {
+		d.reject(encoder, nil, peer, fmt.Sprintf("uid %s may not connect", peer.UID))
+		return
+	}
+
+	authenticated := !requireToken
+	firstLine := true
+	for {
+		// The idle timeout counts from when the daemon is ready for the
+		// next request, so slow requests do not use it up
+		if err := conn.SetReadDeadline(time.Now().Add(idle)); err != nil {
+			return
+		}
+		if !scanner.Scan() {
+			break
+		}
+
 		// Check if we're shutting down
 		select {
 		case <-d.done:
@@ -242,9 +590,17 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 		default:
 		}
 
-		// Reset read deadline after each successful read
-		if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
-			return
+		// A bearer line may open the connection in place of per-request auth
+		if requireToken && firstLine {
+			firstLine = false
+			if token, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), bearerPrefix); ok {
+				if !d.validToken(token) {
+					d.reject(encoder, nil, peer, "missing or invalid auth token")
+					return
+				}
+				authenticated = true
+				continue
+			}
 		}
 
 		// Parse JSON-RPC request
@@ -263,8 +619,19 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 			continue
 		}
 
+		if !authenticated && !d.validToken(req.Auth) {
+			d.reject(encoder, req.ID, peer, "missing or invalid auth token")
+			return
+		}
+
+		// Token clients are told apart from each other's and local leases by
+		// the token they authenticated with
+		if requireToken {
+			peer.TokenID = d.tokenID()
+		}
+
 		// Dispatch to handler
-		resp := d.handler.HandleRequest(&req)
+		resp := d.handler.HandleRequestFrom(&req, peer)
 
 		// Inject startedAt into status responses
 		if req.Method == MethodStatus && resp.Result != nil {
@@ -275,6 +642,9 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 				d.mu.RUnlock()
 			}
 		}
+		if probe, ok := resp.Result.(*ProbeResult); ok {
+			d.fillProbe(probe)
+		}
 
 		// Set write deadline for response
 		if err := conn.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
@@ -289,19 +659,97 @@ func (d *Daemon) handleConnection(conn net.Conn) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		// Check if it's a timeout error - these are expected
+		// The client went idle without closing the connection
 		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			// Silent timeout - client probably closed connection
+			entry := audit.NewEntry(types.ActionConnClosed, true).
+				WithPeer(peer).
+				WithDetails(fmt.Sprintf("closed connection idle for %s", idle)).
+				Build()
+			_ = d.auditLogger.Log(entry)
 			return
 		}
 		// Log other connection errors
-		entry := audit.NewEntry(types.ActionDaemonStop, false).
+		entry := audit.NewEntry(types.ActionConnError, false).
+			WithPeer(peer).
 			WithDetails(fmt.Sprintf("connection error: %v", err)).
 			Build()
 		_ = d.auditLogger.Log(entry)
 	}
 }
 
+// idleTimeout returns how long a connection may wait between requests.
+func (d *Daemon) idleTimeout() time.Duration {
+	if d.cfg.IdleTimeout > 0 {
+		return d.cfg.IdleTimeout
+	}
+	return config.DefaultIdleTimeout
+}
+
+// validToken reports whether token matches the configured AuthToken. The
+// comparison takes constant time, and an empty token never matches.
+func (d *Daemon) validToken(token string) bool {
+	if token == "" || d.cfg.AuthToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(d.cfg.AuthToken)) == 1
+}
+
+// tokenID returns a short fingerprint of the configured AuthToken, used as
+// the identity of token-authenticated peers without exposing the token.
+func (d *Daemon) tokenID() string {
+	sum := sha256.Sum256([]byte(d.cfg.AuthToken))
+	return hex.EncodeToString(sum[:8])
+}
+
+// reject answers a peer that may not use the daemon with RPCUnauthorized
+// and audits the attempt.
+func (d *Daemon) reject(encoder *json.Encoder, id interface{}, peer types.Peer, reason string) {
+	entry := audit.NewEntry(types.ActionAuthFailed, false).
+		WithPeer(peer).
+		WithDetails(reason).
+		Build()
+	_ = d.auditLogger.Log(entry)
+
+	resp := &types.RPCResponse{
+		JSONRPC: "2.0",
+		Error: &types.RPCError{
+			Code:    types.RPCUnauthorized,
+			Message: "unauthorized: " + reason,
+		},
+		ID: id,
+	}
+	_ = encoder.Encode(resp)
+}
+
+// lookupPeerUID reads a Unix socket peer's UID; replaced in tests.
+var lookupPeerUID = peerUID
+
+// peerFromConn derives the transport identity of a connection.
+// Unix socket connections are identified by the peer's user ID where the OS
+// reports it; TLS connections by remote address and verified client
+// certificate CN.
+func peerFromConn(conn net.Conn) (types.Peer, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		var peer types.Peer
+		if uid, ok := lookupPeerUID(conn); ok {
+			peer.UID = uid
+		}
+		return peer, nil
+	}
+
+	// Complete the handshake now so the peer certificate is available
+	if err := tlsConn.Handshake(); err != nil {
+		return types.Peer{}, err
+	}
+
+	peer := types.Peer{RemoteAddr: conn.RemoteAddr().String()}
+	if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+		peer.ClientCN = certs[0].Subject.CommonName
+	}
+	return peer, nil
+}
+
 // IsRunning returns true if the daemon is currently running.
 func (d *Daemon) IsRunning() bool {
 	d.mu.RLock()
@@ -309,19 +757,69 @@ func (d *Daemon) IsRunning() bool {
 	return d.running
 }
 
+// Probe returns the daemon's liveness and readiness, as secrets.probe does.
+func (d *Daemon) Probe() *ProbeResult {
+	probe := d.handler.handleProbe()
+	d.fillProbe(probe)
+	return probe
+}
+
+// fillProbe adds the daemon's own state to a probe result.
+func (d *Daemon) fillProbe(probe *ProbeResult) {
+	d.mu.RLock()
+	if d.running {
+		probe.Uptime = time.Since(d.startedAt)
+	} else {
+		probe.Status = ProbeUnavailable
+	}
+	d.mu.RUnlock()
+
+	probe.HeartbeatRunning = d.heartbeat != nil && d.heartbeat.IsRunning()
+}
+
+// healthHandler serves GET /healthz with the probe result: 200 while the
+// daemon is ready, 503 otherwise.
+func (d *Daemon) healthHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		probe := d.Probe()
+		w.Header().Set("Content-Type", "application/json")
+		if probe.Status != ProbeOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(probe)
+	})
+	return mux
+}
+
 // Status returns the current daemon status.
 func (d *Daemon) Status() *types.DaemonStatus {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
 	secrets, _ := d.store.List()
-	activeLeases := d.leaseManager.List()
+	metrics := d.leaseManager.Snapshot()
 
 	return &types.DaemonStatus{
 		Running:      d.running,
 		StartedAt:    d.startedAt,
 		SecretsCount: len(secrets),
-		ActiveLeases: len(activeLeases),
-		Heartbeat:    d.cfg.Heartbeat,
+		ActiveLeases: metrics.Active,
+		Heartbeat:    redactHeartbeat(d.cfg.Heartbeat),
+		Leases:       &metrics,
+	}
+}
+
+// redactHeartbeat returns a copy of cfg with header values hidden, since
+// they may hold credentials.
+func redactHeartbeat(cfg *types.HeartbeatConfig) *types.HeartbeatConfig {
+	if cfg == nil || len(cfg.Headers) == 0 {
+		return cfg
+	}
+	redacted := *cfg
+	redacted.Headers = make(map[string]string, len(cfg.Headers))
+	for name := range cfg.Headers {
+		redacted.Headers[name] = "[redacted]"
 	}
+	return &redacted
 }
@@ -2,14 +2,30 @@ package daemon
 
 import (
 	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/store"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
@@ -475,3 +491,878 @@ func TestDaemonStatus(t *testing.T) {
 		t.Error("expected non-negative ActiveLeases")
 	}
 }
+
+// writeTestPKI generates a CA, a server certificate for 127.0.0.1, and a
+// client certificate with the given CN. It returns the TCP config for the
+// daemon and a client TLS config.
+func writeTestPKI(t *testing.T, dir, clientCN string) (*config.TCPConfig, *tls.Config) {
+	t.Helper()
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		return key
+	}
+
+	caKey := newKey()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key := newKey()
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("failed to issue certificate: %v", err)
+		}
+		return der, key
+	}
+
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	marshalKey := func(key *ecdsa.PrivateKey) []byte {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %v", err)
+		}
+		return der
+	}
+
+	serverDER, serverKey := issue(2, "agent-secrets", x509.ExtKeyUsageServerAuth)
+	clientDER, clientKey := issue(3, clientCN, x509.ExtKeyUsageClientAuth)
+
+	tcpCfg := &config.TCPConfig{
+		Addr:         "127.0.0.1:0",
+		CertPath:     writePEM("server.crt", "CERTIFICATE", serverDER),
+		KeyPath:      writePEM("server.key", "EC PRIVATE KEY", marshalKey(serverKey)),
+		ClientCAPath: writePEM("ca.crt", "CERTIFICATE", caDER),
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	clientTLS := &tls.Config{
+		RootCAs: roots,
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{clientDER},
+			PrivateKey:  clientKey,
+		}},
+	}
+
+	return tcpCfg, clientTLS
+}
+
+func TestDaemonTCPRecordsClientCN(t *testing.T) {
+	tempDir := t.TempDir()
+	tcpCfg, clientTLS := writeTestPKI(t, tempDir, "ci-runner-1")
+
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		TCP:             tcpCfg,
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer d.Stop()
+
+	if err := d.store.Add("api_key", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	call := func(conn net.Conn, method string, params interface{}, id int) types.RPCResponse {
+		t.Helper()
+		req := types.RPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: id}
+		if err := json.NewEncoder(conn).Encode(req); err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		var resp types.RPCResponse
+		if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return resp
+	}
+
+	// Acquire two leases over mTLS
+	for i := 1; i <= 2; i++ {
+		conn, err := tls.Dial("tcp", d.TCPAddr().String(), clientTLS)
+		if err != nil {
+			t.Fatalf("failed to connect over tls: %v", err)
+		}
+		resp := call(conn, MethodLease, LeaseParams{SecretName: "api_key", ClientID: "runner", TTL: "1h"}, i)
+		localAddr := conn.LocalAddr().String()
+		conn.Close()
+
+		if resp.Error != nil {
+			t.Fatalf("lease over tcp failed: %v", resp.Error.Message)
+		}
+
+		var result LeaseResult
+		data, _ := json.Marshal(resp.Result)
+		_ = json.Unmarshal(data, &result)
+
+		lse, err := d.leaseManager.Get(result.LeaseID)
+		if err != nil {
+			t.Fatalf("lease not found: %v", err)
+		}
+		if lse.ClientCN != "ci-runner-1" {
+			t.Errorf("expected client CN ci-runner-1, got %q", lse.ClientCN)
+		}
+		if lse.RemoteAddr != localAddr {
+			t.Errorf("expected remote addr %s, got %q", localAddr, lse.RemoteAddr)
+		}
+	}
+
+	// A lease acquired over the Unix socket carries no peer identity
+	unixConn, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer unixConn.Close()
+	if resp := call(unixConn, MethodLease, LeaseParams{SecretName: "api_key", ClientID: "local", TTL: "1h"}, 3); resp.Error != nil {
+		t.Fatalf("lease over unix socket failed: %v", resp.Error.Message)
+	}
+
+	// Audit entries record the certificate identity
+	action := types.ActionLeaseAcquire
+	entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	withCN := 0
+	for _, e := range entries {
+		if e.ClientCN == "ci-runner-1" && e.RemoteAddr != "" {
+			withCN++
+		}
+	}
+	if withCN != 2 {
+		t.Errorf("expected 2 audit entries with client CN, got %d", withCN)
+	}
+
+	// Bulk revoke by CN from the local socket
+	resp := call(unixConn, MethodRevokeByClient, RevokeByClientParams{ClientCN: "ci-runner-1"}, 4)
+	if resp.Error != nil {
+		t.Fatalf("revoke by client failed: %v", resp.Error.Message)
+	}
+	var revoked RevokeByClientResult
+	data, _ := json.Marshal(resp.Result)
+	_ = json.Unmarshal(data, &revoked)
+	if revoked.LeasesRevoked != 2 {
+		t.Errorf("expected 2 leases revoked, got %d", revoked.LeasesRevoked)
+	}
+
+	active := d.leaseManager.List()
+	if len(active) != 1 || active[0].ClientID != "local" {
+		t.Errorf("expected only the local lease to remain, got %d active", len(active))
+	}
+}
+
+func TestDaemonTCPRejectsMissingClientCert(t *testing.T) {
+	tempDir := t.TempDir()
+	tcpCfg, clientTLS := writeTestPKI(t, tempDir, "ci-runner-1")
+
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		TCP:             tcpCfg,
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer d.Stop()
+
+	// Same CA trust, but no client certificate
+	noCert := &tls.Config{RootCAs: clientTLS.RootCAs}
+	conn, err := tls.Dial("tcp", d.TCPAddr().String(), noCert)
+	if err == nil {
+		defer conn.Close()
+		req := types.RPCRequest{JSONRPC: "2.0", Method: MethodStatus, ID: 1}
+		_ = json.NewEncoder(conn).Encode(req)
+		var resp types.RPCResponse
+		if err := json.NewDecoder(conn).Decode(&resp); err == nil {
+			t.Fatal("expected connection without client certificate to be rejected")
+		}
+	}
+
+	// The failed handshake is audited as an authentication failure
+	action := types.ActionAuthFailed
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+		if err != nil {
+			t.Fatalf("failed to query audit log: %v", err)
+		}
+		if len(entries) > 0 && strings.Contains(entries[0].Details, "tls handshake failed") && entries[0].RemoteAddr != "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected an auth_failed entry for the handshake, got %+v", entries)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDaemonRunUntilSignal(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := &config.Config{
+		Directory:           tempDir,
+		SocketPath:          tempDir + "/test.sock",
+		IdentityPath:        tempDir + "/identity.age",
+		SecretsPath:         tempDir + "/secrets.age",
+		AuditPath:           tempDir + "/audit.log",
+		LeasesPath:          tempDir + "/leases.json",
+		DefaultLeaseTTL:     1 * time.Hour,
+		MaxLeaseTTL:         24 * time.Hour,
+		RotationTimeout:     30 * time.Second,
+		ShutdownGracePeriod: 100 * time.Millisecond,
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	if err := d.store.Add("db-password", "hunter2", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	l, err := d.leaseManager.Acquire("db-password", "test-client", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	// An idle client must not hold up shutdown past the grace period
+	idle, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer idle.Close()
+
+	// Keep SIGTERM from killing the test binary before the daemon's
+	// handler is registered
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer signal.Stop(guard)
+
+	type outcome struct {
+		sig os.Signal
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		sig, err := d.RunUntilSignal(syscall.SIGTERM)
+		done <- outcome{sig, err}
+	}()
+
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got outcome
+	deadline := time.After(5 * time.Second)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+wait:
+	for {
+		select {
+		case got = <-done:
+			break wait
+		case <-ticker.C:
+			if err := self.Signal(syscall.SIGTERM); err != nil {
+				t.Skipf("cannot signal self: %v", err)
+			}
+		case <-deadline:
+			t.Fatal("daemon did not stop after SIGTERM")
+		}
+	}
+
+	if got.err != nil {
+		t.Fatalf("Stop failed: %v", got.err)
+	}
+	if got.sig != syscall.SIGTERM {
+		t.Errorf("expected SIGTERM, got %v", got.sig)
+	}
+	if d.IsRunning() {
+		t.Error("daemon should not be running after signal")
+	}
+	if _, err := os.Stat(cfg.SocketPath); !os.IsNotExist(err) {
+		t.Errorf("socket should be removed after shutdown, stat err = %v", err)
+	}
+
+	data, err := os.ReadFile(cfg.LeasesPath)
+	if err != nil {
+		t.Fatalf("leases were not persisted: %v", err)
+	}
+	if !strings.Contains(string(data), l.ID) {
+		t.Errorf("persisted leases missing %s: %s", l.ID, data)
+	}
+
+	// The audit log was flushed with a clean stop entry
+	auditLogger, err := audit.New(cfg.AuditPath)
+	if err != nil {
+		t.Fatalf("failed to reopen audit log: %v", err)
+	}
+	defer auditLogger.Close()
+	action := types.ActionDaemonStop
+	entries, err := auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) == 0 || !entries[len(entries)-1].Success {
+		t.Errorf("expected a successful daemon_stop entry, got %+v", entries)
+	}
+}
+
+func TestDaemonPolicyByPeerUID(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peer credentials are only read on linux and darwin")
+	}
+	tempDir := t.TempDir()
+
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		Policies:        map[string][]string{fmt.Sprintf("uid:%d", os.Getuid()): {"status"}},
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer d.Stop()
+
+	conn, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+
+	for i, method := range []string{MethodStatus, MethodList} {
+		if err := encoder.Encode(types.RPCRequest{JSONRPC: "2.0", Method: method, ID: i}); err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		var resp types.RPCResponse
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if method == MethodStatus && resp.Error != nil {
+			t.Errorf("status rejected: %v", resp.Error.Message)
+		}
+		if method == MethodList && (resp.Error == nil || resp.Error.Code != types.RPCUnauthorized) {
+			t.Errorf("list: expected RPCUnauthorized, got %+v", resp.Error)
+		}
+	}
+}
+
+func TestNewDaemonInvalidPolicy(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		Policies:        map[string][]string{"cn:dashboard": {"not-a-method"}},
+	}
+
+	if _, err := NewDaemon(cfg); err == nil {
+		t.Error("expected error for a policy naming an unknown method")
+	}
+}
+
+// tokenTestConfig returns a daemon config with a token-authenticated TCP
+// listener on a free loopback port.
+func tokenTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	tempDir := t.TempDir()
+
+	return &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		ListenTCP:       "127.0.0.1:0",
+		AuthToken:       "ci-runner-token",
+	}
+}
+
+func TestDaemonTokenAuth(t *testing.T) {
+	cfg := tokenTestConfig(t)
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer d.Stop()
+
+	dial := func() (net.Conn, *json.Encoder, *json.Decoder) {
+		t.Helper()
+		conn, err := net.Dial("tcp", d.TokenAddr().String())
+		if err != nil {
+			t.Fatalf("failed to connect over tcp: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn, json.NewEncoder(conn), json.NewDecoder(conn)
+	}
+	status := func(encoder *json.Encoder, decoder *json.Decoder, auth string) (types.RPCResponse, error) {
+		t.Helper()
+		if err := encoder.Encode(types.RPCRequest{JSONRPC: "2.0", Method: MethodStatus, ID: 1, Auth: auth}); err != nil {
+			return types.RPCResponse{}, err
+		}
+		var resp types.RPCResponse
+		err := decoder.Decode(&resp)
+		return resp, err
+	}
+
+	// A bearer line authenticates every request on the connection
+	conn, encoder, decoder := dial()
+	if _, err := fmt.Fprintf(conn, "Bearer %s\n", cfg.AuthToken); err != nil {
+		t.Fatalf("failed to send token: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := status(encoder, decoder, "")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		if resp.Error != nil {
+			t.Errorf("request %d rejected after bearer line: %v", i, resp.Error.Message)
+		}
+	}
+
+	// So does the auth field of a request
+	_, encoder, decoder = dial()
+	if resp, err := status(encoder, decoder, cfg.AuthToken); err != nil || resp.Error != nil {
+		t.Errorf("request with auth field rejected: %v %+v", err, resp.Error)
+	}
+
+	// Missing and wrong tokens are rejected and the connection closed
+	for _, auth := range []string{"", "wrong-token"} {
+		_, encoder, decoder = dial()
+		resp, err := status(encoder, decoder, auth)
+		if err != nil {
+			t.Fatalf("auth %q: failed to read rejection: %v", auth, err)
+		}
+		if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+			t.Errorf("auth %q: expected RPCUnauthorized, got %+v", auth, resp.Error)
+		}
+		if _, err := status(encoder, decoder, cfg.AuthToken); err == nil {
+			t.Errorf("auth %q: expected the connection to be closed", auth)
+		}
+	}
+
+	conn, _, decoder = dial()
+	if _, err := fmt.Fprintf(conn, "Bearer wrong-token\n"); err != nil {
+		t.Fatalf("failed to send token: %v", err)
+	}
+	var resp types.RPCResponse
+	if err := decoder.Decode(&resp); err != nil {
+		t.Fatalf("failed to read rejection: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+		t.Errorf("wrong bearer token: expected RPCUnauthorized, got %+v", resp.Error)
+	}
+
+	// The Unix socket needs no token
+	unixConn, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer unixConn.Close()
+	if resp, err := status(json.NewEncoder(unixConn), json.NewDecoder(unixConn), ""); err != nil || resp.Error != nil {
+		t.Errorf("unix socket request rejected: %v %+v", err, resp.Error)
+	}
+
+	// Rejections are audited with the remote address
+	action := types.ActionAuthFailed
+	entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 auth failures audited, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.RemoteAddr == "" {
+			t.Errorf("auth failure without remote address: %+v", e)
+		}
+	}
+}
+
+// TestDaemonTokenPeerRemoteDenied verifies that a token holder cannot call
+// destructive or recipient methods unless a policy grants them to its
+// token identity.
+func TestDaemonTokenPeerRemoteDenied(t *testing.T) {
+	start := func(cfg *config.Config) *Daemon {
+		t.Helper()
+		d, err := NewDaemon(cfg)
+		if err != nil {
+			t.Fatalf("NewDaemon failed: %v", err)
+		}
+		if err := d.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		t.Cleanup(func() { d.Stop() })
+		if err := d.store.Add("api_key", "secret-value", ""); err != nil {
+			t.Fatalf("failed to add secret: %v", err)
+		}
+		return d
+	}
+	call := func(d *Daemon, method string, params interface{}) types.RPCResponse {
+		t.Helper()
+		conn, err := net.Dial("tcp", d.TokenAddr().String())
+		if err != nil {
+			t.Fatalf("failed to connect over tcp: %v", err)
+		}
+		defer conn.Close()
+		req := types.RPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1, Auth: d.cfg.AuthToken}
+		if err := json.NewEncoder(conn).Encode(req); err != nil {
+			t.Fatalf("failed to send %s: %v", method, err)
+		}
+		var resp types.RPCResponse
+		if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+			t.Fatalf("failed to read %s response: %v", method, err)
+		}
+		return resp
+	}
+
+	d := start(tokenTestConfig(t))
+	for _, tc := range []struct {
+		method string
+		params interface{}
+	}{
+		{MethodDelete, DeleteParams{Name: "api_key"}},
+		{MethodRevokeAll, RevokeAllParams{}},
+		{MethodAddRecipient, RecipientParams{Recipient: "age1example"}},
+		{MethodRemoveRecipient, RecipientParams{Recipient: "age1example"}},
+	} {
+		resp := call(d, tc.method, tc.params)
+		if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+			t.Errorf("%s: expected RPCUnauthorized for a token peer, got %+v", tc.method, resp.Error)
+		}
+	}
+	if _, err := d.store.Get("api_key"); err != nil {
+		t.Errorf("secret should survive a denied delete: %v", err)
+	}
+
+	// The token identity is logged at start so operators can write a policy
+	// for it
+	action := types.ActionDaemonStart
+	entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	identity := "token:" + d.tokenID()
+	if len(entries) != 1 || !strings.Contains(entries[0].Details, identity) {
+		t.Errorf("expected daemon start entry to name %s, got %+v", identity, entries)
+	}
+
+	// An explicit grant for the token identity allows the method
+	cfg := tokenTestConfig(t)
+	cfg.Policies = map[string][]string{identity: {MethodDelete}}
+	granted := start(cfg)
+	if resp := call(granted, MethodDelete, DeleteParams{Name: "api_key"}); resp.Error != nil {
+		t.Errorf("delete with a policy grant failed: %v", resp.Error.Message)
+	}
+	if resp := call(granted, MethodRevokeAll, RevokeAllParams{}); resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+		t.Errorf("revokeAll without a grant: expected RPCUnauthorized, got %+v", resp.Error)
+	}
+}
+
+func TestDaemonTokenAuthPipe(t *testing.T) {
+	cfg := tokenTestConfig(t)
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		auth     string
+		rejected bool
+	}{
+		{"valid token", cfg.AuthToken, false},
+		{"token prefix", cfg.AuthToken[:4], true},
+		{"no token", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			d.wg.Add(1)
+			go d.handleConnection(server, true)
+
+			req := types.RPCRequest{JSONRPC: "2.0", Method: MethodStatus, ID: 7, Auth: tt.auth}
+			if err := json.NewEncoder(client).Encode(req); err != nil {
+				t.Fatalf("failed to send request: %v", err)
+			}
+			var resp types.RPCResponse
+			if err := json.NewDecoder(client).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			rejected := resp.Error != nil && resp.Error.Code == types.RPCUnauthorized
+			if rejected != tt.rejected {
+				t.Errorf("rejected = %v, want %v (error %+v)", rejected, tt.rejected, resp.Error)
+			}
+			if fmt.Sprint(resp.ID) != "7" {
+				t.Errorf("expected response ID 7, got %v", resp.ID)
+			}
+		})
+	}
+}
+
+func TestDaemonHealthz(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		HealthAddr:      "127.0.0.1:0",
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer d.Stop()
+
+	get := func(url string) (int, ProbeResult) {
+		t.Helper()
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", url, err)
+		}
+		defer resp.Body.Close()
+		var probe ProbeResult
+		_ = json.NewDecoder(resp.Body).Decode(&probe)
+		return resp.StatusCode, probe
+	}
+	healthz := "http://" + d.HealthAddr().String() + "/healthz"
+
+	code, probe := get(healthz)
+	if code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if probe.Status != ProbeOK || !probe.StoreLoaded || probe.HeartbeatRunning {
+		t.Errorf("unexpected probe: %+v", probe)
+	}
+
+	// Other methods and paths are not served
+	resp, err := http.Post(healthz, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected 405, got %d", resp.StatusCode)
+	}
+	if code, _ := get("http://" + d.HealthAddr().String() + "/status"); code != http.StatusNotFound {
+		t.Errorf("/status: expected 404, got %d", code)
+	}
+
+	// The same probe over RPC includes uptime
+	conn, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(types.RPCRequest{JSONRPC: "2.0", Method: MethodProbe, ID: 1}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	var rpcResp types.RPCResponse
+	if err := json.NewDecoder(conn).Decode(&rpcResp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rpcResp.Error != nil {
+		t.Fatalf("probe failed: %v", rpcResp.Error.Message)
+	}
+	data, _ := json.Marshal(rpcResp.Result)
+	var rpcProbe ProbeResult
+	_ = json.Unmarshal(data, &rpcProbe)
+	if rpcProbe.Status != ProbeOK || rpcProbe.Uptime <= 0 {
+		t.Errorf("expected a ready probe with uptime, got %+v", rpcProbe)
+	}
+
+	// A daemon whose store is not loaded reports 503
+	d.handler.store = store.New(cfg)
+	code, probe = get(healthz)
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for an unloaded store, got %d", code)
+	}
+	if probe.Status != ProbeUnavailable || probe.StoreLoaded {
+		t.Errorf("unexpected probe for an unloaded store: %+v", probe)
+	}
+}
+
+func TestDaemonIdleTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		IdleTimeout:     200 * time.Millisecond,
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer d.Stop()
+
+	// A client that sends nothing is disconnected once idle
+	idleConn, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer idleConn.Close()
+	start := time.Now()
+	_ = idleConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := idleConn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("expected the idle connection to be closed")
+	} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		t.Fatal("idle connection was not closed by the daemon")
+	}
+	if elapsed := time.Since(start); elapsed < cfg.IdleTimeout {
+		t.Errorf("connection closed after %v, before the %v idle timeout", elapsed, cfg.IdleTimeout)
+	}
+
+	// A client sending requests more often than the timeout stays connected
+	// well past it
+	conn, err := net.Dial("unix", cfg.SocketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+	for i := 0; i < 5; i++ {
+		time.Sleep(cfg.IdleTimeout / 2)
+		if err := encoder.Encode(types.RPCRequest{JSONRPC: "2.0", Method: MethodStatus, ID: i}); err != nil {
+			t.Fatalf("request %d: failed to send: %v", i, err)
+		}
+		var resp types.RPCResponse
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("request %d: connection closed while active: %v", i, err)
+		}
+	}
+
+	// The idle close is logged
+	action := types.ActionConnClosed
+	entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	found := false
+	for _, e := range entries {
+		if strings.Contains(e.Details, "idle") {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected the idle connection to be audited")
+	}
+
+	// An idle close is not a daemon stop
+	stop := types.ActionDaemonStop
+	if entries, _ := d.auditLogger.Query(audit.QueryFilter{Action: &stop}); len(entries) != 0 {
+		t.Errorf("idle close audited as daemon_stop: %+v", entries)
+	}
+}
@@ -3,7 +3,11 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/killswitch"
@@ -20,6 +24,16 @@ type Handler struct {
 	rotationExecutor *rotation.Executor
 	killswitch       *killswitch.Killswitch
 	auditLogger      *audit.Logger
+	policy           Policy // nil allows every client every method
+
+	// Dead-man switch keep-alive file and interval; empty unless configured
+	keepAliveFile     string
+	keepAliveInterval time.Duration
+
+	// generated holds values minted by GenerateVia hooks, by lease ID, for
+	// the secret's RevokeVia hook when the lease ends. Never persisted.
+	generatedMu sync.Mutex
+	generated   map[string]string
 }
 
 // NewHandler creates a new RPC handler with all required dependencies.
@@ -30,27 +44,63 @@ func NewHandler(
 	ks *killswitch.Killswitch,
 	al *audit.Logger,
 ) *Handler {
-	return &Handler{
+	h := &Handler{
 		store:            st,
 		leaseManager:     lm,
 		rotationExecutor: re,
 		killswitch:       ks,
 		auditLogger:      al,
+		generated:        make(map[string]string),
 	}
+	lm.SetEndHook(h.leaseEnded)
+	return h
 }
 
 // HandleRequest dispatches an RPC request to the appropriate handler method.
 func (h *Handler) HandleRequest(req *types.RPCRequest) *types.RPCResponse {
+	return h.HandleRequestFrom(req, types.Peer{})
+}
+
+// SetPolicy restricts the methods each client may call.
+func (h *Handler) SetPolicy(policy Policy) {
+	h.policy = policy
+}
+
+// SetDeadMan lets secrets.keepalive reset the dead-man switch watching path.
+func (h *Handler) SetDeadMan(path string, interval time.Duration) {
+	h.keepAliveFile = path
+	h.keepAliveInterval = interval
+}
+
+// HandleRequestFrom dispatches an RPC request received from the given peer.
+// Remote peers are recorded on leases and may only revoke their own leases.
+// Peers the policy does not allow to call the method are rejected.
+func (h *Handler) HandleRequestFrom(req *types.RPCRequest, peer types.Peer) *types.RPCResponse {
 	resp := &types.RPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
 	}
 
+	if !h.policy.Allows(peer, req.Method) {
+		resp.Error = &types.RPCError{
+			Code:    types.RPCUnauthorized,
+			Message: fmt.Sprintf("client %q is not permitted to call %s", peer.Identity(), req.Method),
+		}
+		return resp
+	}
+
 	switch req.Method {
 	case MethodInit:
 		resp.Result = h.handleInit()
 	case MethodAdd:
-		result, err := h.handleAdd(req.Params)
+		result, err := h.handleAdd(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodImport:
+		result, err := h.handleImport(req.Params, peer)
 		if err != nil {
 			resp.Error = types.RPCErrorFromError(err)
 		} else {
@@ -69,22 +119,78 @@ func (h *Handler) HandleRequest(req *types.RPCRequest) *types.RPCResponse {
 		} else {
 			resp.Result = result
 		}
+	case MethodRename:
+		result, err := h.handleRename(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
 	case MethodList:
-		result, err := h.handleList()
+		result, err := h.handleList(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodSearch:
+		result, err := h.handleSearch(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodNamespaces:
+		result, err := h.handleNamespaces(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodDeleteNamespace:
+		result, err := h.handleDeleteNamespace(req.Params)
 		if err != nil {
 			resp.Error = types.RPCErrorFromError(err)
 		} else {
 			resp.Result = result
 		}
 	case MethodLease:
-		result, err := h.handleLease(req.Params)
+		result, err := h.handleLease(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRenew:
+		result, err := h.handleRenew(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodGetByLease:
+		result, err := h.handleGetByLease(req.Params, peer)
 		if err != nil {
 			resp.Error = types.RPCErrorFromError(err)
 		} else {
 			resp.Result = result
 		}
 	case MethodRevoke:
-		result, err := h.handleRevoke(req.Params)
+		result, err := h.handleRevoke(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRevokeByClient:
+		result, err := h.handleRevokeByClient(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRevokeClient:
+		result, err := h.handleRevokeClient(req.Params, peer)
 		if err != nil {
 			resp.Error = types.RPCErrorFromError(err)
 		} else {
@@ -104,6 +210,20 @@ func (h *Handler) HandleRequest(req *types.RPCRequest) *types.RPCResponse {
 		} else {
 			resp.Result = result
 		}
+	case MethodRotateAll:
+		result, err := h.handleRotateAll()
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRotationPolicy:
+		result, err := h.handleRotationPolicy(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
 	case MethodAudit:
 		result, err := h.handleAudit(req.Params)
 		if err != nil {
@@ -125,6 +245,80 @@ func (h *Handler) HandleRequest(req *types.RPCRequest) *types.RPCResponse {
 		} else {
 			resp.Result = result
 		}
+	case MethodProbe:
+		resp.Result = h.handleProbe()
+	case MethodKeepAlive:
+		result, err := h.handleKeepAlive(peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodArm:
+		result, err := h.handleArm(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodDisarm:
+		result, err := h.handleDisarm(peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodCapabilities:
+		resp.Result = h.handleCapabilities()
+	case MethodCompact:
+		result, err := h.handleCompact()
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodDuplicates:
+		result, err := h.handleDuplicates()
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodHistory:
+		result, err := h.handleHistory(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRollback:
+		result, err := h.handleRollback(req.Params)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRecipients:
+		result, err := h.handleRecipients()
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodAddRecipient:
+		result, err := h.handleAddRecipient(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
+	case MethodRemoveRecipient:
+		result, err := h.handleRemoveRecipient(req.Params, peer)
+		if err != nil {
+			resp.Error = types.RPCErrorFromError(err)
+		} else {
+			resp.Result = result
+		}
 	default:
 		resp.Error = &types.RPCError{
 			Code:    types.RPCMethodNotFound,
@@ -151,20 +345,73 @@ func (h *Handler) handleInit() *InitResult {
 }
 
 // handleAdd adds a new secret to the store.
-func (h *Handler) handleAdd(params interface{}) (*AddResult, error) {
+func (h *Handler) handleAdd(params interface{}, peer types.Peer) (*AddResult, error) {
 	var p AddParams
 	if err := unmarshalParams(params, &p); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
+	// Hooks run and env files are written as the daemon's user, so only
+	// local clients may set them
+	if peer.IsRemote() && (p.RotateVia != "" || p.GenerateVia != "" || p.RevokeVia != "" || len(p.PropagateTo) > 0) {
+		return nil, types.ErrPeerMismatch
+	}
 
 	if p.Name == "" {
 		return nil, fmt.Errorf("secret name is required")
 	}
-	if p.Value == "" {
+	if p.Value == "" && p.GenerateVia == "" {
 		return nil, fmt.Errorf("secret value is required")
 	}
+	if p.RevokeVia != "" && p.GenerateVia == "" {
+		return nil, fmt.Errorf("revoke_via requires generate_via")
+	}
+	if p.RotateEvery < 0 {
+		return nil, fmt.Errorf("rotate_every cannot be negative")
+	}
+	if p.RotateEvery > 0 && p.RotateVia == "" {
+		return nil, fmt.Errorf("rotate_every requires rotate_via")
+	}
+	var binary []byte
+	if p.Binary {
+		if p.GenerateVia != "" {
+			return nil, fmt.Errorf("generate_via cannot be used with a binary value")
+		}
+		var err error
+		if binary, err = store.DecodeBinary(p.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, target := range p.PropagateTo {
+		if !filepath.IsAbs(target.Path) || target.Var == "" {
+			return nil, fmt.Errorf("propagate_to[%d] needs an absolute path and a variable name", i)
+		}
+	}
+	if !p.ExpiresAt.IsZero() && !p.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+	if err := validateLabels(p.Labels); err != nil {
+		return nil, err
+	}
 
-	if err := h.store.Add(p.Name, p.Value, p.RotateVia); err != nil {
+	secret := types.Secret{
+		Name:        p.Name,
+		RotateVia:   p.RotateVia,
+		ExpiresAt:   p.ExpiresAt,
+		PropagateTo: p.PropagateTo,
+		Labels:      p.Labels,
+		GenerateVia: p.GenerateVia,
+		RevokeVia:   p.RevokeVia,
+		Binary:      p.Binary,
+	}
+	if p.RotateEvery > 0 {
+		secret.RotationPolicy = &types.RotationPolicy{Interval: p.RotateEvery}
+	}
+	value := p.Value
+	if p.Binary {
+		value = store.EncodeBinary(binary)
+	}
+	if err := h.store.AddSecret(secret, value); err != nil {
 		return nil, err
 	}
 
@@ -174,6 +421,39 @@ func (h *Handler) handleAdd(params interface{}) (*AddResult, error) {
 	}, nil
 }
 
+// handleImport adds many secrets at once, skipping names already in use.
+func (h *Handler) handleImport(params interface{}, peer types.Peer) (*ImportResult, error) {
+	var p ImportParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	// As for handleAdd, rotation hooks are set by local clients only
+	if peer.IsRemote() && p.RotateVia != "" {
+		return nil, types.ErrPeerMismatch
+	}
+
+	for name, value := range p.Vars {
+		if name == "" {
+			return nil, fmt.Errorf("secret name is required")
+		}
+		if value == "" {
+			return nil, fmt.Errorf("secret %q: value is required", name)
+		}
+	}
+
+	added, skipped, err := h.store.BulkAdd(p.Vars, p.Namespace, p.RotateVia)
+	if err != nil {
+		return nil, err
+	}
+	if added == nil {
+		added = []string{}
+	}
+	if skipped == nil {
+		skipped = []string{}
+	}
+	return &ImportResult{Added: added, Skipped: skipped}, nil
+}
+
 // handleDelete removes a secret from the store.
 func (h *Handler) handleDelete(params interface{}) (*DeleteResult, error) {
 	var p DeleteParams
@@ -204,13 +484,130 @@ func (h *Handler) handleDelete(params interface{}) (*DeleteResult, error) {
 	}, nil
 }
 
-// handleList returns metadata for all secrets.
-func (h *Handler) handleList() (*ListResult, error) {
-	secrets, err := h.store.List()
+// handleNamespaces lists the namespaces that hold secrets.
+func (h *Handler) handleNamespaces(params interface{}) (*NamespacesResult, error) {
+	namespaces, err := h.store.ListNamespaces()
 	if err != nil {
 		return nil, err
 	}
+	return &NamespacesResult{Namespaces: namespaces}, nil
+}
+
+// handleDeleteNamespace deletes every secret in a namespace, revoking their
+// leases first.
+func (h *Handler) handleDeleteNamespace(params interface{}) (*DeleteNamespaceResult, error) {
+	var p DeleteNamespaceParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+
+	revoked, err := h.leaseManager.RevokeByNamespace(p.Namespace)
+	if err != nil {
+		// Log but don't fail deletion
+		_ = h.auditLogger.Log(audit.NewEntry(types.ActionSecretDelete, false).
+			WithDetails(fmt.Sprintf("failed to revoke leases in namespace %q: %v", p.Namespace, err)).
+			Build())
+	}
+
+	deleted, err := h.store.DeleteNamespace(p.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = h.auditLogger.Log(audit.NewEntry(types.ActionSecretDelete, true).
+		WithDetails(fmt.Sprintf("deleted namespace %q: %d secrets, %d leases revoked", p.Namespace, deleted, revoked)).
+		Build())
+
+	return &DeleteNamespaceResult{Deleted: deleted, RevokedLeases: revoked}, nil
+}
+
+// handleRename moves a secret to a new name, keeping its value and metadata.
+func (h *Handler) handleRename(params interface{}) (*RenameResult, error) {
+	var p RenameParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Name == "" {
+		return nil, fmt.Errorf("secret name is required")
+	}
+	if p.NewName == "" {
+		return nil, fmt.Errorf("new secret name is required")
+	}
+
+	if err := h.store.Rename(p.Name, p.NewName); err != nil {
+		return nil, err
+	}
 
+	// Leases refer to the secret by name; revoke those under the old name
+	// so later revocation by secret still covers every holder
+	if err := h.leaseManager.RevokeBySecret(p.Name); err != nil {
+		// Log but don't fail the rename
+		_ = h.auditLogger.Log(audit.NewEntry(types.ActionSecretRename, false).
+			WithSecret(p.NewName).
+			WithDetails(fmt.Sprintf("failed to revoke leases on %q: %v", p.Name, err)).
+			Build())
+	}
+
+	return &RenameResult{
+		Success: true,
+		Message: fmt.Sprintf("secret %q renamed to %q", p.Name, p.NewName),
+	}, nil
+}
+
+// handleList returns metadata for all secrets, or only those without a
+// rotation hook when requested.
+func (h *Handler) handleList(params interface{}) (*ListResult, error) {
+	var p ListParams
+	if params != nil {
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	var secrets []types.Secret
+	var err error
+	switch {
+	case len(p.Labels) > 0:
+		secrets, err = h.store.ListByLabel(p.Labels)
+		if err == nil && p.NoRotation {
+			secrets = withoutRotation(secrets)
+		}
+	case p.NoRotation:
+		secrets, err = h.store.ListWithoutRotation()
+	default:
+		secrets, err = h.store.List()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListResult{Secrets: toMetadata(secrets)}, nil
+}
+
+// handleSearch handles secrets.search. Only metadata is returned.
+func (h *Handler) handleSearch(params interface{}) (*SearchResult, error) {
+	var p SearchParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if p.Query == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+
+	secrets, err := h.store.Search(p.Query)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Secrets: toMetadata(secrets)}, nil
+}
+
+// toMetadata strips secrets down to the metadata clients may see.
+func toMetadata(secrets []types.Secret) []SecretMetadata {
 	metadata := make([]SecretMetadata, len(secrets))
 	for i, s := range secrets {
 		metadata[i] = SecretMetadata{
@@ -219,14 +616,42 @@ func (h *Handler) handleList() (*ListResult, error) {
 			UpdatedAt:   s.UpdatedAt,
 			RotateVia:   s.RotateVia,
 			LastRotated: s.LastRotated,
+			ExpiresAt:   s.ExpiresAt,
+			PropagateTo: s.PropagateTo,
+			Labels:      s.Labels,
+			GenerateVia: s.GenerateVia,
+			RevokeVia:   s.RevokeVia,
+			Binary:      s.Binary,
+
+			RotationPolicy: s.RotationPolicy,
 		}
 	}
+	return metadata
+}
 
-	return &ListResult{Secrets: metadata}, nil
+// withoutRotation keeps the secrets that have no rotation hook.
+func withoutRotation(secrets []types.Secret) []types.Secret {
+	kept := secrets[:0]
+	for _, s := range secrets {
+		if s.RotateVia == "" {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// validateLabels rejects labels with an empty key.
+func validateLabels(labels map[string]string) error {
+	for key := range labels {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("label keys must not be empty")
+		}
+	}
+	return nil
 }
 
 // handleLease acquires a lease and returns the secret value.
-func (h *Handler) handleLease(params interface{}) (*LeaseResult, error) {
+func (h *Handler) handleLease(params interface{}, peer types.Peer) (*LeaseResult, error) {
 	var p LeaseParams
 	if err := unmarshalParams(params, &p); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
@@ -249,27 +674,68 @@ func (h *Handler) handleLease(params interface{}) (*LeaseResult, error) {
 		}
 	}
 
+	// Reject an oversized reason before a dynamic secret mints a value
+	if n := utf8.RuneCountInString(p.Reason); n > lease.MaxReasonLength {
+		return nil, fmt.Errorf("%w: %d characters (max %d)", types.ErrLeaseReasonTooLong, n, lease.MaxReasonLength)
+	}
+
 	// Get the secret value first
 	value, err := h.store.Get(p.SecretName)
 	if err != nil {
 		return nil, err
 	}
 
+	// Dynamic secrets mint a fresh value for every lease
+	secret, err := h.store.GetMetadata(p.SecretName)
+	if err != nil {
+		return nil, err
+	}
+	if secret.GenerateVia != "" {
+		if value, err = h.rotationExecutor.Generate(secret, p.ClientID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Acquire the lease
-	lse, err := h.leaseManager.Acquire(p.SecretName, p.ClientID, ttl)
+	lse, err := h.leaseManager.AcquireWithReason(p.SecretName, p.ClientID, p.Reason, ttl, peer)
 	if err != nil {
+		if secret.GenerateVia != "" {
+			// Nobody will hold the minted value; revoke it right away
+			_ = h.rotationExecutor.RevokeGenerated(secret, types.Lease{SecretName: p.SecretName, ClientID: p.ClientID}, value)
+		}
 		return nil, err
 	}
+	if secret.GenerateVia != "" {
+		h.generatedMu.Lock()
+		h.generated[lse.ID] = value
+		h.generatedMu.Unlock()
+	}
 
 	return &LeaseResult{
 		LeaseID:   lse.ID,
 		Value:     value,
 		ExpiresAt: lse.ExpiresAt,
+		Binary:    secret.Binary,
 	}, nil
 }
 
+// leaseEnded runs the RevokeVia hook of a dynamic secret when one of its
+// leases is revoked or expires. Hook failures are audited by the executor.
+func (h *Handler) leaseEnded(lse types.Lease) {
+	h.generatedMu.Lock()
+	value := h.generated[lse.ID]
+	delete(h.generated, lse.ID)
+	h.generatedMu.Unlock()
+
+	secret, err := h.store.GetMetadata(lse.SecretName)
+	if err != nil || secret.GenerateVia == "" {
+		return
+	}
+	_ = h.rotationExecutor.RevokeGenerated(secret, lse, value)
+}
+
 // handleRevoke revokes a specific lease.
-func (h *Handler) handleRevoke(params interface{}) (*RevokeResult, error) {
+func (h *Handler) handleRevoke(params interface{}, peer types.Peer) (*RevokeResult, error) {
 	var p RevokeParams
 	if err := unmarshalParams(params, &p); err != nil {
 		return nil, fmt.Errorf("invalid parameters: %w", err)
@@ -279,6 +745,17 @@ func (h *Handler) handleRevoke(params interface{}) (*RevokeResult, error) {
 		return nil, fmt.Errorf("lease_id is required")
 	}
 
+	// Remote peers may only revoke leases acquired under their own identity
+	if peer.IsRemote() {
+		lse, err := h.leaseManager.Get(p.LeaseID)
+		if err != nil {
+			return nil, err
+		}
+		if !ownsLease(lse, peer) {
+			return nil, types.NewLeaseError(p.LeaseID, lse.SecretName, types.ErrPeerMismatch)
+		}
+	}
+
 	if err := h.leaseManager.Revoke(p.LeaseID); err != nil {
 		return nil, err
 	}
@@ -289,6 +766,169 @@ func (h *Handler) handleRevoke(params interface{}) (*RevokeResult, error) {
 	}, nil
 }
 
+// ownsLease reports whether peer acquired lse: over the same transport and
+// with the same, known identity.
+func ownsLease(lse *types.Lease, peer types.Peer) bool {
+	return peer.Identity() != "" &&
+		lse.PeerIdentity == peer.Identity() &&
+		(lse.RemoteAddr != "") == peer.IsRemote()
+}
+
+// handleRenew extends a lease without returning its value again.
+func (h *Handler) handleRenew(params interface{}, peer types.Peer) (*RenewResult, error) {
+	var p RenewParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.LeaseID == "" {
+		return nil, fmt.Errorf("lease_id is required")
+	}
+
+	var ttl time.Duration
+	if p.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(p.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ttl duration: %w", err)
+		}
+	}
+
+	// Remote peers may only renew leases acquired under their own identity
+	if peer.IsRemote() {
+		lse, err := h.leaseManager.Get(p.LeaseID)
+		if err != nil {
+			return nil, err
+		}
+		if !ownsLease(lse, peer) {
+			return nil, types.NewLeaseError(p.LeaseID, lse.SecretName, types.ErrPeerMismatch)
+		}
+	}
+
+	lse, err := h.leaseManager.Renew(p.LeaseID, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenewResult{
+		LeaseID:    lse.ID,
+		SecretName: lse.SecretName,
+		ExpiresAt:  lse.ExpiresAt,
+	}, nil
+}
+
+// handleGetByLease returns the value of a secret again to the holder of a
+// valid lease on it, e.g. a client that reconnected after losing the value.
+// Dynamic secrets return the value minted for that lease.
+func (h *Handler) handleGetByLease(params interface{}, peer types.Peer) (*GetByLeaseResult, error) {
+	var p GetByLeaseParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.LeaseID == "" {
+		return nil, fmt.Errorf("lease_id is required")
+	}
+	if p.SecretName == "" {
+		return nil, fmt.Errorf("secret_name is required")
+	}
+
+	// Remote peers may only read through leases acquired under their own identity
+	if peer.IsRemote() {
+		lse, err := h.leaseManager.Get(p.LeaseID)
+		if err != nil {
+			return nil, err
+		}
+		if !ownsLease(lse, peer) {
+			return nil, types.NewLeaseError(p.LeaseID, lse.SecretName, types.ErrPeerMismatch)
+		}
+	}
+
+	lse, err := h.leaseManager.Authorize(p.LeaseID, p.SecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := h.store.GetMetadata(lse.SecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	var value string
+	if secret.GenerateVia != "" {
+		h.generatedMu.Lock()
+		generated, ok := h.generated[lse.ID]
+		h.generatedMu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("value generated for lease %q is no longer held by the daemon; acquire a new lease", lse.ID)
+		}
+		value = generated
+	} else if value, err = h.store.Get(lse.SecretName); err != nil {
+		return nil, err
+	}
+
+	return &GetByLeaseResult{
+		LeaseID:    lse.ID,
+		SecretName: lse.SecretName,
+		Value:      value,
+		ExpiresAt:  lse.ExpiresAt,
+		Binary:     secret.Binary,
+	}, nil
+}
+
+// handleRevokeByClient revokes all leases acquired with a client certificate CN.
+func (h *Handler) handleRevokeByClient(params interface{}, peer types.Peer) (*RevokeByClientResult, error) {
+	var p RevokeByClientParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.ClientCN == "" {
+		return nil, fmt.Errorf("client_cn is required")
+	}
+
+	// Remote peers may only revoke leases acquired under their own identity
+	if peer.IsRemote() && p.ClientCN != peer.ClientCN {
+		return nil, types.ErrPeerMismatch
+	}
+
+	count, err := h.leaseManager.RevokeByClientCN(p.ClientCN)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevokeByClientResult{
+		Success:       true,
+		LeasesRevoked: count,
+		Message:       fmt.Sprintf("%d leases for client CN %q revoked", count, p.ClientCN),
+	}, nil
+}
+
+// handleRevokeClient revokes all leases held by a client ID.
+func (h *Handler) handleRevokeClient(params interface{}, peer types.Peer) (*RevokeClientResult, error) {
+	var p RevokeClientParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.ClientID == "" {
+		return nil, fmt.Errorf("client_id is required")
+	}
+
+	// Client IDs are self-declared, so they do not tie leases to a remote
+	// peer's identity; remote peers use secrets.revokeByClient instead
+	if peer.IsRemote() {
+		return nil, types.ErrPeerMismatch
+	}
+
+	count := h.leaseManager.RevokeByClient(p.ClientID)
+	return &RevokeClientResult{
+		Success:       true,
+		LeasesRevoked: count,
+		Message:       fmt.Sprintf("%d leases for client %q revoked", count, p.ClientID),
+	}, nil
+}
+
 // handleRevokeAll triggers killswitch to revoke all leases.
 func (h *Handler) handleRevokeAll() (*RevokeAllResult, error) {
 	// Count active leases before revoking
@@ -320,28 +960,79 @@ func (h *Handler) handleRotate(params interface{}) (*RotateResult, error) {
 		return nil, fmt.Errorf("secret_name is required")
 	}
 
-	result, err := h.rotationExecutor.Rotate(p.SecretName)
+	result, err := h.rotationExecutor.Rotate(p.SecretName, types.TriggerManual)
 	if err != nil {
 		// Return the result even on error (contains output)
 		if result != nil {
-			return &RotateResult{
-				Success:    result.Success,
-				Output:     result.Output,
-				Error:      result.Error,
-				ExecutedAt: result.ExecutedAt,
-			}, err
+			return toRotateResult(result), err
 		}
 		return nil, err
 	}
 
-	return &RotateResult{
-		Success:    result.Success,
-		Output:     result.Output,
-		Error:      result.Error,
-		ExecutedAt: result.ExecutedAt,
+	return toRotateResult(result), nil
+}
+
+// handleRotateAll rotates every secret with a rotation hook, continuing past
+// failures.
+func (h *Handler) handleRotateAll() (*RotateAllResult, error) {
+	results, err := h.rotationExecutor.RotateAll(types.TriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []types.RotationResult{}
+	}
+	return &RotateAllResult{Results: results}, nil
+}
+
+// handleRotationPolicy sets or clears a secret's rotation schedule.
+func (h *Handler) handleRotationPolicy(params interface{}) (*RotationPolicyResult, error) {
+	var p RotationPolicyParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Name == "" {
+		return nil, fmt.Errorf("secret name is required")
+	}
+
+	if p.Interval == 0 {
+		if err := h.store.SetRotationPolicy(p.Name, nil); err != nil {
+			return nil, err
+		}
+		return &RotationPolicyResult{
+			Success: true,
+			Message: fmt.Sprintf("scheduled rotation of %q turned off", p.Name),
+		}, nil
+	}
+
+	policy := &types.RotationPolicy{Interval: p.Interval, MaxAge: p.MaxAge}
+	if err := h.store.SetRotationPolicy(p.Name, policy); err != nil {
+		return nil, err
+	}
+	return &RotationPolicyResult{
+		Success: true,
+		Message: fmt.Sprintf("%q rotates every %v", p.Name, p.Interval),
 	}, nil
 }
 
+// toRotateResult converts an executor result to its RPC form.
+func toRotateResult(result *types.RotationResult) *RotateResult {
+	return &RotateResult{
+		Success:        result.Success,
+		Output:         result.Output,
+		Error:          result.Error,
+		ExecutedAt:     result.ExecutedAt,
+		Propagated:     result.Propagated,
+		Trigger:        result.Trigger,
+		ExitCode:       result.ExitCode,
+		Duration:       result.Duration,
+		OldFingerprint: result.OldFingerprint,
+		NewFingerprint: result.NewFingerprint,
+		ValueUpdated:   result.ValueUpdated,
+	}
+}
+
 // handleAudit returns recent audit log entries.
 func (h *Handler) handleAudit(params interface{}) (*AuditResult, error) {
 	var p AuditParams
@@ -354,10 +1045,29 @@ func (h *Handler) handleAudit(params interface{}) (*AuditResult, error) {
 		p.Tail = 100 // Default limit
 	}
 
-	entries, err := h.auditLogger.Tail(p.Tail)
+	role, err := audit.ParseViewerRole(p.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	// Restricted viewers may not learn which entries touched a secret
+	if role == audit.RoleRestricted && p.SecretName != "" {
+		return nil, fmt.Errorf("the restricted role cannot filter by secret name")
+	}
+
+	var entries []*types.AuditEntry
+	if filter, ok := auditFilter(p); ok {
+		entries, err = h.auditLogger.Query(filter)
+		if len(entries) > p.Tail {
+			entries = entries[len(entries)-p.Tail:]
+		}
+	} else {
+		entries, err = h.auditLogger.Tail(p.Tail)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read audit log: %w", err)
 	}
+	entries = audit.MaskAll(entries, role)
 
 	jsonEntries := make([]AuditEntryJSON, len(entries))
 	for i, e := range entries {
@@ -369,12 +1079,38 @@ func (h *Handler) handleAudit(params interface{}) (*AuditResult, error) {
 			LeaseID:    e.LeaseID,
 			Details:    e.Details,
 			Success:    e.Success,
+			RemoteAddr: e.RemoteAddr,
+			ClientCN:   e.ClientCN,
+			Trigger:    string(e.Trigger),
 		}
 	}
 
 	return &AuditResult{Entries: jsonEntries}, nil
 }
 
+// auditFilter builds a query filter from the filters set in p, reporting
+// whether any were.
+func auditFilter(p AuditParams) (audit.QueryFilter, bool) {
+	var filter audit.QueryFilter
+	if !p.Since.IsZero() {
+		filter.StartTime = &p.Since
+	}
+	if !p.Until.IsZero() {
+		filter.EndTime = &p.Until
+	}
+	if p.Action != "" {
+		action := types.Action(p.Action)
+		filter.Action = &action
+	}
+	if p.SecretName != "" {
+		filter.SecretName = &p.SecretName
+	}
+	if p.ClientID != "" {
+		filter.ClientID = &p.ClientID
+	}
+	return filter, filter != (audit.QueryFilter{})
+}
+
 // handleStatus returns the current daemon status.
 func (h *Handler) handleStatus() (*types.DaemonStatus, error) {
 	secrets, err := h.store.List()
@@ -382,13 +1118,233 @@ func (h *Handler) handleStatus() (*types.DaemonStatus, error) {
 		return nil, fmt.Errorf("failed to get secrets count: %w", err)
 	}
 
-	activeLeases := h.leaseManager.List()
+	metrics := h.leaseManager.Snapshot()
 
 	// Note: StartedAt and Running will be populated by the daemon itself
 	return &types.DaemonStatus{
 		Running:      true,
 		SecretsCount: len(secrets),
-		ActiveLeases: len(activeLeases),
+		ActiveLeases: metrics.Active,
+		Leases:       &metrics,
+	}, nil
+}
+
+// handleKeepAlive touches the dead-man switch's keep-alive file.
+func (h *Handler) handleKeepAlive(peer types.Peer) (*KeepAliveResult, error) {
+	if h.keepAliveFile == "" {
+		return nil, fmt.Errorf("dead-man switch is not configured; set dead_man_file and dead_man_interval")
+	}
+
+	touched, err := killswitch.TouchKeepAlive(h.keepAliveFile)
+	_ = h.auditLogger.Log(audit.NewEntry(types.ActionKeepAlive, err == nil).
+		WithPeer(peer).
+		WithDetails(h.keepAliveFile).
+		Build())
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeepAliveResult{
+		File:      h.keepAliveFile,
+		TouchedAt: touched,
+		Deadline:  touched.Add(h.keepAliveInterval),
+	}, nil
+}
+
+// handleArm arms the killswitch so that it may rotate secrets and wipe the
+// store when activated.
+func (h *Handler) handleArm(params interface{}, peer types.Peer) (*ArmResult, error) {
+	var p ArmParams
+	if params != nil {
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+	if p.Window < 0 {
+		return nil, fmt.Errorf("window must not be negative")
+	}
+	// Arming widens what the killswitch destroys, so it stays local
+	if peer.IsRemote() {
+		return nil, types.ErrPeerMismatch
+	}
+
+	expires := h.killswitch.Arm(p.Window)
+	details := "armed until disarmed"
+	if !expires.IsZero() {
+		details = "armed until " + expires.Format(time.RFC3339)
+	}
+	_ = h.auditLogger.Log(audit.NewEntry(types.ActionKillswitchArm, true).
+		WithPeer(peer).
+		WithDetails(details).
+		Build())
+
+	return &ArmResult{Armed: true, ExpiresAt: expires}, nil
+}
+
+// handleDisarm disarms the killswitch.
+func (h *Handler) handleDisarm(peer types.Peer) (*ArmResult, error) {
+	if peer.IsRemote() {
+		return nil, types.ErrPeerMismatch
+	}
+
+	h.killswitch.Disarm()
+	_ = h.auditLogger.Log(audit.NewEntry(types.ActionKillswitchArm, true).
+		WithPeer(peer).
+		WithDetails("disarmed").
+		Build())
+
+	return &ArmResult{Armed: false}, nil
+}
+
+// handleProbe reports whether the store can serve secrets. Uptime and
+// HeartbeatRunning are filled in by the daemon.
+func (h *Handler) handleProbe() *ProbeResult {
+	result := &ProbeResult{
+		Status:      ProbeUnavailable,
+		StoreLoaded: h.store.Loaded(),
+	}
+	if result.StoreLoaded {
+		result.Status = ProbeOK
+	}
+	return result
+}
+
+// handleCapabilities reports the protocol version and supported methods so
+// clients can feature-detect instead of probing for MethodNotFound.
+func (h *Handler) handleCapabilities() *CapabilitiesResult {
+	methods := make([]string, len(SupportedMethods))
+	copy(methods, SupportedMethods)
+
+	return &CapabilitiesResult{
+		ProtocolVersion: ProtocolVersion,
+		Methods:         methods,
+	}
+}
+
+// handleCompact purges stale entries from the store and rewrites it.
+func (h *Handler) handleCompact() (*CompactResult, error) {
+	reclaimed, err := h.store.Compact()
+	if err != nil {
+		return nil, fmt.Errorf("compaction failed: %w", err)
+	}
+
+	return &CompactResult{
+		Success:   true,
+		Reclaimed: reclaimed,
+		Message:   fmt.Sprintf("store compacted, %d entries reclaimed", reclaimed),
+	}, nil
+}
+
+// handleDuplicates reports secrets that share a value, without the values.
+func (h *Handler) handleDuplicates() (*DuplicatesResult, error) {
+	groups, err := h.store.FindDuplicates()
+	if err != nil {
+		return nil, err
+	}
+	return &DuplicatesResult{Groups: groups}, nil
+}
+
+// handleHistory lists the versions of a secret, without the values.
+func (h *Handler) handleHistory(params interface{}) (*HistoryResult, error) {
+	var p HistoryParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Name == "" {
+		return nil, fmt.Errorf("secret name is required")
+	}
+
+	versions, err := h.store.Versions(p.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &HistoryResult{Name: p.Name, Versions: versions}, nil
+}
+
+// handleRollback restores the previous value of a secret.
+func (h *Handler) handleRollback(params interface{}) (*RollbackResult, error) {
+	var p RollbackParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Name == "" {
+		return nil, fmt.Errorf("secret name is required")
+	}
+
+	err := h.store.Rollback(p.Name)
+	builder := audit.NewEntry(types.ActionSecretRollback, err == nil).WithSecret(p.Name)
+	if err != nil {
+		builder = builder.WithDetails(err.Error())
+	}
+	_ = h.auditLogger.Log(builder.Build())
+	if err != nil {
+		return nil, err
+	}
+
+	return &RollbackResult{
+		Success: true,
+		Message: fmt.Sprintf("secret %q rolled back to its previous value", p.Name),
+	}, nil
+}
+
+// handleRecipients lists the recipients the store is encrypted to.
+func (h *Handler) handleRecipients() (*RecipientsResult, error) {
+	recipients, err := h.store.Recipients()
+	if err != nil {
+		return nil, err
+	}
+	return &RecipientsResult{Recipients: recipients}, nil
+}
+
+// handleAddRecipient adds a recipient and re-encrypts the store.
+func (h *Handler) handleAddRecipient(params interface{}, peer types.Peer) (*RecipientResult, error) {
+	var p RecipientParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	// A recipient can decrypt the whole store, so recipients are managed locally
+	if peer.IsRemote() {
+		return nil, types.ErrPeerMismatch
+	}
+
+	if p.Recipient == "" {
+		return nil, fmt.Errorf("recipient is required")
+	}
+
+	if err := h.store.AddRecipient(p.Recipient); err != nil {
+		return nil, err
+	}
+
+	return &RecipientResult{
+		Success: true,
+		Message: "recipient added and store re-encrypted",
+	}, nil
+}
+
+// handleRemoveRecipient removes a recipient and re-encrypts the store.
+func (h *Handler) handleRemoveRecipient(params interface{}, peer types.Peer) (*RecipientResult, error) {
+	var p RecipientParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	// Removing a recipient locks its owner out, so it stays local too
+	if peer.IsRemote() {
+		return nil, types.ErrPeerMismatch
+	}
+
+	if p.Recipient == "" {
+		return nil, fmt.Errorf("recipient is required")
+	}
+
+	if err := h.store.RemoveRecipient(p.Recipient); err != nil {
+		return nil, err
+	}
+
+	return &RecipientResult{
+		Success: true,
+		Message: "recipient removed and store re-encrypted",
 	}, nil
 }
 
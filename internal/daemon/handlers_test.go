@@ -2,9 +2,15 @@ package daemon
 
 import (
 	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"filippo.io/age"
 	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/config"
 	"github.com/joelhooks/agent-secrets/internal/killswitch"
@@ -81,7 +87,7 @@ func TestHandleAdd(t *testing.T) {
 		RotateVia: "echo new-value",
 	}
 
-	result, err := handler.handleAdd(params)
+	result, err := handler.handleAdd(params, types.Peer{})
 	if err != nil {
 		t.Fatalf("handleAdd failed: %v", err)
 	}
@@ -105,6 +111,233 @@ func TestHandleAdd(t *testing.T) {
 	}
 }
 
+func TestHandleAdd_PropagateTo(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	target := types.EnvTarget{Path: "/srv/app/.env.local", Var: "API_KEY"}
+	if _, err := handler.handleAdd(AddParams{
+		Name:        "api-key",
+		Value:       "value",
+		PropagateTo: []types.EnvTarget{target},
+	}, types.Peer{}); err != nil {
+		t.Fatalf("handleAdd failed: %v", err)
+	}
+
+	result, err := handler.handleList(nil)
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(result.Secrets) != 1 || len(result.Secrets[0].PropagateTo) != 1 || result.Secrets[0].PropagateTo[0] != target {
+		t.Errorf("expected propagation target %v, got %+v", target, result.Secrets)
+	}
+}
+
+func TestHandleAdd_ExpiresAt(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	if _, err := handler.handleAdd(AddParams{Name: "short-lived", Value: "value", ExpiresAt: expiresAt}, types.Peer{}); err != nil {
+		t.Fatalf("handleAdd failed: %v", err)
+	}
+	result, err := handler.handleList(nil)
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(result.Secrets) != 1 || !result.Secrets[0].ExpiresAt.Equal(expiresAt) {
+		t.Errorf("expected expiry %v, got %+v", expiresAt, result.Secrets)
+	}
+
+	if _, err := handler.handleAdd(AddParams{Name: "stale", Value: "value", ExpiresAt: time.Now().Add(-time.Minute)}, types.Peer{}); err == nil {
+		t.Error("expected error for an expiry in the past")
+	}
+}
+
+func TestHandleList_NoRotation(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, p := range []AddParams{
+		{Name: "hooked", Value: "value", RotateVia: "echo new"},
+		{Name: "manual", Value: "value"},
+	} {
+		if _, err := handler.handleAdd(p, types.Peer{}); err != nil {
+			t.Fatalf("handleAdd(%s) failed: %v", p.Name, err)
+		}
+	}
+
+	result, err := handler.handleList(ListParams{NoRotation: true})
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(result.Secrets) != 1 || result.Secrets[0].Name != "manual" {
+		t.Errorf("expected only 'manual', got %+v", result.Secrets)
+	}
+
+	result, err = handler.handleList(ListParams{})
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(result.Secrets) != 2 {
+		t.Errorf("expected 2 secrets without filter, got %d", len(result.Secrets))
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, p := range []AddParams{
+		{Name: "prod::API_KEY", Value: "sk_live_value"},
+		{Name: "STRIPE_KEY", Value: "sk_stripe_value"},
+		{Name: "GITHUB_TOKEN", Value: "ghp_value"},
+	} {
+		if _, err := handler.handleAdd(p, types.Peer{}); err != nil {
+			t.Fatalf("handleAdd(%s) failed: %v", p.Name, err)
+		}
+	}
+
+	result, err := handler.handleSearch(SearchParams{Query: "*_KEY"})
+	if err != nil {
+		t.Fatalf("handleSearch failed: %v", err)
+	}
+	if len(result.Secrets) != 2 {
+		t.Errorf("expected 2 matches, got %+v", result.Secrets)
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "_value") {
+		t.Errorf("search result leaks a value: %s", data)
+	}
+
+	if _, err := handler.handleSearch(SearchParams{}); err == nil {
+		t.Error("expected error for an empty query")
+	}
+}
+
+func TestHandleDeleteNamespace(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, name := range []string{"plain", "prod::db", "prod::api", "stage::db"} {
+		if _, err := handler.handleAdd(AddParams{Name: name, Value: "value"}, types.Peer{}); err != nil {
+			t.Fatalf("handleAdd(%s) failed: %v", name, err)
+		}
+	}
+	prodLease, err := handler.handleLease(LeaseParams{SecretName: "prod::db", ClientID: "agent", TTL: "1h"}, types.Peer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stageLease, err := handler.handleLease(LeaseParams{SecretName: "stage::db", ClientID: "agent", TTL: "1h"}, types.Peer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := handler.handleDeleteNamespace(DeleteNamespaceParams{Namespace: "prod"})
+	if err != nil {
+		t.Fatalf("handleDeleteNamespace failed: %v", err)
+	}
+	if result.Deleted != 2 || result.RevokedLeases != 1 {
+		t.Errorf("result = %+v, want 2 deleted and 1 lease revoked", result)
+	}
+
+	if lse, _ := handler.leaseManager.Get(prodLease.LeaseID); !lse.Revoked {
+		t.Error("lease in the deleted namespace was not revoked")
+	}
+	if lse, _ := handler.leaseManager.Get(stageLease.LeaseID); lse.Revoked {
+		t.Error("lease in another namespace was revoked")
+	}
+
+	namespaces, err := handler.handleNamespaces(NamespacesParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{types.DefaultNamespace, "stage"}; !reflect.DeepEqual(namespaces.Namespaces, want) {
+		t.Errorf("namespaces = %v, want %v", namespaces.Namespaces, want)
+	}
+
+	if _, err := handler.handleDeleteNamespace(DeleteNamespaceParams{}); err == nil {
+		t.Error("expected error for an empty namespace")
+	}
+}
+
+func TestHandleAddBinary(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	content := []byte{0x00, 0xff, 0x10, '\n', 0x80}
+	if _, err := handler.handleAdd(AddParams{Name: "tls_key", Value: store.EncodeBinary(content), Binary: true}, types.Peer{}); err != nil {
+		t.Fatalf("handleAdd failed: %v", err)
+	}
+
+	list, err := handler.handleList(ListParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Secrets) != 1 || !list.Secrets[0].Binary {
+		t.Errorf("expected binary metadata, got %+v", list.Secrets)
+	}
+
+	// Leases carry the base64 form and say so
+	result, err := handler.handleLease(LeaseParams{SecretName: "tls_key", ClientID: "agent", TTL: "1m"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleLease failed: %v", err)
+	}
+	if !result.Binary {
+		t.Error("lease of a binary secret is not marked binary")
+	}
+	if got, err := store.DecodeBinary(result.Value); err != nil || string(got) != string(content) {
+		t.Errorf("leased value decodes to %q, %v", got, err)
+	}
+
+	for _, p := range []AddParams{
+		{Name: "bad", Value: "not base64!", Binary: true},
+		{Name: "dynamic", Value: "", Binary: true, GenerateVia: "echo x"},
+	} {
+		if _, err := handler.handleAdd(p, types.Peer{}); err == nil {
+			t.Errorf("handleAdd(%s) should fail", p.Name)
+		}
+	}
+}
+
+func TestHandleList_Labels(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, p := range []AddParams{
+		{Name: "prod-hooked", Value: "v", RotateVia: "echo new", Labels: map[string]string{"env": "prod"}},
+		{Name: "prod-manual", Value: "v", Labels: map[string]string{"env": "prod", "team": "api"}},
+		{Name: "unlabeled", Value: "v"},
+	} {
+		if _, err := handler.handleAdd(p, types.Peer{}); err != nil {
+			t.Fatalf("handleAdd(%s) failed: %v", p.Name, err)
+		}
+	}
+
+	result, err := handler.handleList(ListParams{Labels: map[string]string{"env": "prod"}})
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(result.Secrets) != 2 || result.Secrets[0].Labels["env"] != "prod" {
+		t.Errorf("expected the two prod secrets, got %+v", result.Secrets)
+	}
+
+	result, err = handler.handleList(ListParams{Labels: map[string]string{"env": "prod"}, NoRotation: true})
+	if err != nil {
+		t.Fatalf("handleList failed: %v", err)
+	}
+	if len(result.Secrets) != 1 || result.Secrets[0].Name != "prod-manual" {
+		t.Errorf("expected only 'prod-manual', got %+v", result.Secrets)
+	}
+
+	if _, err := handler.handleAdd(AddParams{Name: "bad", Value: "v", Labels: map[string]string{"": "x"}}, types.Peer{}); err == nil {
+		t.Error("expected error for an empty label key")
+	}
+}
+
 func TestHandleAddInvalidParams(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -115,11 +348,13 @@ func TestHandleAddInvalidParams(t *testing.T) {
 	}{
 		{"empty name", AddParams{Name: "", Value: "value"}},
 		{"empty value", AddParams{Name: "name", Value: ""}},
+		{"relative propagate path", AddParams{Name: "name", Value: "value", PropagateTo: []types.EnvTarget{{Path: ".env.local", Var: "NAME"}}}},
+		{"propagate without var", AddParams{Name: "name", Value: "value", PropagateTo: []types.EnvTarget{{Path: "/tmp/.env.local"}}}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := handler.handleAdd(tt.params)
+			_, err := handler.handleAdd(tt.params, types.Peer{})
 			if err == nil {
 				t.Error("expected error, got nil")
 			}
@@ -127,6 +362,41 @@ func TestHandleAddInvalidParams(t *testing.T) {
 	}
 }
 
+func TestHandleAdd_RemoteHooks(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tlsPeer := types.Peer{RemoteAddr: "10.0.0.5:4000", ClientCN: "agent"}
+	tests := []struct {
+		name   string
+		params AddParams
+	}{
+		{"rotate_via", AddParams{Name: "rotated", Value: "value", RotateVia: "echo new"}},
+		{"generate_via", AddParams{Name: "generated", GenerateVia: "echo minted"}},
+		{"revoke_via", AddParams{Name: "revoked", Value: "value", RevokeVia: "true"}},
+		{"propagate_to", AddParams{Name: "propagated", Value: "value", PropagateTo: []types.EnvTarget{{Path: "/tmp/.env.local", Var: "NAME"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := handler.handleAdd(tt.params, tlsPeer); !errors.Is(err, types.ErrPeerMismatch) {
+				t.Fatalf("expected ErrPeerMismatch, got %v", err)
+			}
+			if _, err := handler.store.GetMetadata(tt.params.Name); err == nil {
+				t.Error("rejected secret was stored")
+			}
+		})
+	}
+
+	// Plain values are still accepted over TLS
+	if _, err := handler.handleAdd(AddParams{Name: "plain", Value: "value"}, tlsPeer); err != nil {
+		t.Errorf("handleAdd of a plain value failed: %v", err)
+	}
+	if _, err := handler.handleImport(ImportParams{Vars: map[string]string{"A": "1"}, RotateVia: "echo new"}, tlsPeer); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("import with rotate_via: expected ErrPeerMismatch, got %v", err)
+	}
+}
+
 func TestHandleDelete(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -159,6 +429,64 @@ func TestHandleDelete(t *testing.T) {
 	}
 }
 
+func TestHandleRename(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("db_password", "test-value", "./rotate.sh"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := handler.store.Add("prod::taken", "other", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	lse, err := handler.leaseManager.Acquire("db_password", "agent", time.Hour)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	result, err := handler.handleRename(RenameParams{Name: "db_password", NewName: "prod::db_password"})
+	if err != nil {
+		t.Fatalf("handleRename failed: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("expected success, got: %s", result.Message)
+	}
+
+	value, err := handler.store.Get("prod::db_password")
+	if err != nil || value != "test-value" {
+		t.Errorf("renamed secret = %q, %v", value, err)
+	}
+	if got, _ := handler.leaseManager.Get(lse.ID); !got.Revoked {
+		t.Error("expected lease on the old name to be revoked")
+	}
+
+	tests := []struct {
+		name     string
+		params   RenameParams
+		wantCode int
+	}{
+		{"target exists", RenameParams{Name: "prod::db_password", NewName: "prod::taken"}, types.RPCInternalError},
+		{"source missing", RenameParams{Name: "db_password", NewName: "other"}, types.RPCSecretNotFound},
+		{"missing new name", RenameParams{Name: "prod::db_password"}, types.RPCInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := handler.HandleRequest(&types.RPCRequest{
+				JSONRPC: "2.0",
+				Method:  MethodRename,
+				Params:  tt.params,
+				ID:      1,
+			})
+			if resp.Error == nil {
+				t.Fatal("expected error")
+			}
+			if resp.Error.Code != tt.wantCode {
+				t.Errorf("error code = %d, want %d (%s)", resp.Error.Code, tt.wantCode, resp.Error.Message)
+			}
+		})
+	}
+}
+
 func TestHandleList(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -176,7 +504,7 @@ func TestHandleList(t *testing.T) {
 		}
 	}
 
-	result, err := handler.handleList()
+	result, err := handler.handleList(nil)
 	if err != nil {
 		t.Fatalf("handleList failed: %v", err)
 	}
@@ -209,7 +537,7 @@ func TestHandleLease(t *testing.T) {
 		TTL:        "1h",
 	}
 
-	result, err := handler.handleLease(params)
+	result, err := handler.handleLease(params, types.Peer{})
 	if err != nil {
 		t.Fatalf("handleLease failed: %v", err)
 	}
@@ -227,6 +555,46 @@ func TestHandleLease(t *testing.T) {
 	}
 }
 
+func TestHandleLeaseReason(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	result, err := handler.handleLease(LeaseParams{SecretName: "test-secret", ClientID: "test-client", Reason: "ci deploy"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleLease failed: %v", err)
+	}
+	lse, err := handler.leaseManager.Get(result.LeaseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lse.Reason != "ci deploy" {
+		t.Errorf("expected reason 'ci deploy', got %q", lse.Reason)
+	}
+
+	entries, err := handler.auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Action == types.ActionLeaseAcquire && entry.LeaseID == result.LeaseID {
+			found = strings.Contains(entry.Details, "ci deploy")
+		}
+	}
+	if !found {
+		t.Error("expected the reason in the lease audit entry")
+	}
+
+	_, err = handler.handleLease(LeaseParams{SecretName: "test-secret", ClientID: "test-client", Reason: strings.Repeat("x", 300)}, types.Peer{})
+	if !errors.Is(err, types.ErrLeaseReasonTooLong) {
+		t.Errorf("expected ErrLeaseReasonTooLong, got %v", err)
+	}
+}
+
 func TestHandleLeaseInvalidTTL(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -243,12 +611,54 @@ func TestHandleLeaseInvalidTTL(t *testing.T) {
 		TTL:        "invalid",
 	}
 
-	_, err = handler.handleLease(params)
+	_, err = handler.handleLease(params, types.Peer{})
 	if err == nil {
 		t.Error("expected error for invalid TTL, got nil")
 	}
 }
 
+func TestHandleLeaseGenerated(t *testing.T) {
+	handler, cfg, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	revoked := filepath.Join(cfg.Directory, "revoked")
+	_, err := handler.handleAdd(AddParams{
+		Name:        "db_creds",
+		GenerateVia: `echo "user-$AGENT_SECRETS_CLIENT_ID"`,
+		RevokeVia:   `printf '%s' "$AGENT_SECRETS_VALUE" >> ` + revoked,
+	}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleAdd failed: %v", err)
+	}
+
+	result, err := handler.handleLease(LeaseParams{SecretName: "db_creds", ClientID: "agent-1", TTL: "1h"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleLease failed: %v", err)
+	}
+	if result.Value != "user-agent-1" {
+		t.Errorf("expected generated value 'user-agent-1', got %q", result.Value)
+	}
+	if _, err := os.Stat(revoked); !os.IsNotExist(err) {
+		t.Fatal("revoke hook ran before the lease ended")
+	}
+
+	if _, err := handler.handleRevoke(RevokeParams{LeaseID: result.LeaseID}, types.Peer{}); err != nil {
+		t.Fatalf("handleRevoke failed: %v", err)
+	}
+	data, err := os.ReadFile(revoked)
+	if err != nil {
+		t.Fatalf("revoke hook did not run: %v", err)
+	}
+	if string(data) != "user-agent-1" {
+		t.Errorf("revoke hook got value %q, want 'user-agent-1'", data)
+	}
+
+	// Without a value or generator, add still fails
+	if _, err := handler.handleAdd(AddParams{Name: "empty", RevokeVia: "true"}, types.Peer{}); err == nil {
+		t.Error("expected error adding a secret with neither value nor generate_via")
+	}
+}
+
 func TestHandleRevoke(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -266,7 +676,7 @@ func TestHandleRevoke(t *testing.T) {
 
 	// Revoke it
 	params := RevokeParams{LeaseID: lse.ID}
-	result, err := handler.handleRevoke(params)
+	result, err := handler.handleRevoke(params, types.Peer{})
 	if err != nil {
 		t.Fatalf("handleRevoke failed: %v", err)
 	}
@@ -282,55 +692,216 @@ func TestHandleRevoke(t *testing.T) {
 	}
 }
 
-func TestHandleRevokeAll(t *testing.T) {
+func TestHandleRenew(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	// Add a secret and acquire multiple leases
-	err := handler.store.Add("test-secret", "test-value", "")
-	if err != nil {
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
 		t.Fatalf("failed to add secret: %v", err)
 	}
-
-	for i := 0; i < 3; i++ {
-		_, err := handler.leaseManager.Acquire("test-secret", "test-client", 1*time.Hour)
-		if err != nil {
-			t.Fatalf("failed to acquire lease: %v", err)
-		}
+	lse, err := handler.leaseManager.AcquireFrom("test-secret", "test-client", 1*time.Hour, types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "ci"})
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
 	}
+	originalExpiry := lse.ExpiresAt
 
-	result, err := handler.handleRevokeAll()
+	result, err := handler.handleRenew(RenewParams{LeaseID: lse.ID, TTL: "2h"}, types.Peer{})
 	if err != nil {
-		t.Fatalf("handleRevokeAll failed: %v", err)
+		t.Fatalf("handleRenew failed: %v", err)
+	}
+	if result.LeaseID != lse.ID || result.SecretName != "test-secret" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if !result.ExpiresAt.After(originalExpiry) {
+		t.Errorf("expected expiry after %v, got %v", originalExpiry, result.ExpiresAt)
 	}
 
-	if !result.Success {
-		t.Errorf("expected success, got: %s", result.Message)
+	// Remote peers may only renew their own leases
+	if _, err := handler.handleRenew(RenewParams{LeaseID: lse.ID}, types.Peer{RemoteAddr: "10.0.0.2:5000", ClientCN: "other"}); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("expected ErrPeerMismatch, got %v", err)
 	}
 
-	if result.LeasesRevoked != 3 {
-		t.Errorf("expected 3 leases revoked, got %d", result.LeasesRevoked)
+	// Revoked leases cannot be renewed
+	if err := handler.leaseManager.Revoke(lse.ID); err != nil {
+		t.Fatal(err)
+	}
+	resp := handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: MethodRenew, Params: RenewParams{LeaseID: lse.ID}, ID: 1})
+	if resp.Error == nil {
+		t.Fatal("expected error renewing a revoked lease")
 	}
 
-	// Verify all leases were revoked
-	active := handler.leaseManager.List()
-	if len(active) != 0 {
-		t.Errorf("expected 0 active leases, got %d", len(active))
+	if _, err := handler.handleRenew(RenewParams{}, types.Peer{}); err == nil {
+		t.Error("expected error for missing lease_id")
 	}
 }
 
-func TestHandleAudit(t *testing.T) {
+func TestHandleGetByLease(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	// Perform some operations to generate audit entries
-	handler.store.Add("test-secret", "test-value", "")
-	handler.leaseManager.Acquire("test-secret", "test-client", 1*time.Hour)
-
-	params := AuditParams{Tail: 10}
-	result, err := handler.handleAudit(params)
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := handler.store.Add("other-secret", "other-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	leased, err := handler.handleLease(LeaseParams{SecretName: "test-secret", ClientID: "test-client", TTL: "1h"}, types.Peer{})
 	if err != nil {
-		t.Fatalf("handleAudit failed: %v", err)
+		t.Fatalf("handleLease failed: %v", err)
+	}
+
+	// A valid lease returns the value again
+	result, err := handler.handleGetByLease(GetByLeaseParams{LeaseID: leased.LeaseID, SecretName: "test-secret"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleGetByLease failed: %v", err)
+	}
+	if result.Value != "test-value" || result.LeaseID != leased.LeaseID || !result.ExpiresAt.Equal(leased.ExpiresAt) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	// A lease cannot be used to read a different secret
+	resp := handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: MethodGetByLease, Params: GetByLeaseParams{LeaseID: leased.LeaseID, SecretName: "other-secret"}, ID: 1})
+	if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+		t.Errorf("expected RPCUnauthorized for a lease on another secret, got %+v", resp.Error)
+	}
+
+	// Remote peers may only read through their own leases
+	if _, err := handler.handleGetByLease(GetByLeaseParams{LeaseID: leased.LeaseID, SecretName: "test-secret"}, types.Peer{RemoteAddr: "10.0.0.2:5000", ClientCN: "other"}); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("expected ErrPeerMismatch, got %v", err)
+	}
+
+	// Expired leases are refused
+	expired, err := handler.leaseManager.Acquire("test-secret", "test-client", 1*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	resp = handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: MethodGetByLease, Params: GetByLeaseParams{LeaseID: expired.ID, SecretName: "test-secret"}, ID: 2})
+	if resp.Error == nil || resp.Error.Code != types.RPCLeaseExpired {
+		t.Errorf("expected RPCLeaseExpired for an expired lease, got %+v", resp.Error)
+	}
+
+	// Each access is audited
+	action := types.ActionLeaseFetch
+	entries, err := handler.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 audited fetches, got %d", len(entries))
+	}
+
+	if _, err := handler.handleGetByLease(GetByLeaseParams{SecretName: "test-secret"}, types.Peer{}); err == nil {
+		t.Error("expected error for missing lease_id")
+	}
+}
+
+func TestHandleGetByLeaseGenerated(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := handler.handleAdd(AddParams{Name: "db_creds", GenerateVia: `echo "user-$AGENT_SECRETS_CLIENT_ID"`}, types.Peer{}); err != nil {
+		t.Fatalf("handleAdd failed: %v", err)
+	}
+	leased, err := handler.handleLease(LeaseParams{SecretName: "db_creds", ClientID: "agent-1", TTL: "1h"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleLease failed: %v", err)
+	}
+
+	// The value minted for the lease is returned, not a new one
+	result, err := handler.handleGetByLease(GetByLeaseParams{LeaseID: leased.LeaseID, SecretName: "db_creds"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleGetByLease failed: %v", err)
+	}
+	if result.Value != leased.Value {
+		t.Errorf("expected generated value %q, got %q", leased.Value, result.Value)
+	}
+}
+
+func TestHandleRevokeClient(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	for _, client := range []string{"agent-a", "agent-a", "agent-b"} {
+		if _, err := handler.leaseManager.Acquire("test-secret", client, 1*time.Hour); err != nil {
+			t.Fatalf("failed to acquire lease: %v", err)
+		}
+	}
+
+	result, err := handler.handleRevokeClient(RevokeClientParams{ClientID: "agent-a"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleRevokeClient failed: %v", err)
+	}
+	if !result.Success || result.LeasesRevoked != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	active := handler.leaseManager.List()
+	if len(active) != 1 || active[0].ClientID != "agent-b" {
+		t.Errorf("expected only agent-b's lease to remain, got %+v", active)
+	}
+
+	// Remote peers cannot revoke by self-declared client ID
+	if _, err := handler.handleRevokeClient(RevokeClientParams{ClientID: "agent-b"}, types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "agent-a"}); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("expected ErrPeerMismatch, got %v", err)
+	}
+	if _, err := handler.handleRevokeClient(RevokeClientParams{}, types.Peer{}); err == nil {
+		t.Error("expected error for missing client_id")
+	}
+}
+
+func TestHandleRevokeAll(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Add a secret and acquire multiple leases
+	err := handler.store.Add("test-secret", "test-value", "")
+	if err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := handler.leaseManager.Acquire("test-secret", "test-client", 1*time.Hour)
+		if err != nil {
+			t.Fatalf("failed to acquire lease: %v", err)
+		}
+	}
+
+	result, err := handler.handleRevokeAll()
+	if err != nil {
+		t.Fatalf("handleRevokeAll failed: %v", err)
+	}
+
+	if !result.Success {
+		t.Errorf("expected success, got: %s", result.Message)
+	}
+
+	if result.LeasesRevoked != 3 {
+		t.Errorf("expected 3 leases revoked, got %d", result.LeasesRevoked)
+	}
+
+	// Verify all leases were revoked
+	active := handler.leaseManager.List()
+	if len(active) != 0 {
+		t.Errorf("expected 0 active leases, got %d", len(active))
+	}
+}
+
+func TestHandleAudit(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Perform some operations to generate audit entries
+	handler.store.Add("test-secret", "test-value", "")
+	handler.leaseManager.Acquire("test-secret", "test-client", 1*time.Hour)
+
+	params := AuditParams{Tail: 10}
+	result, err := handler.handleAudit(params)
+	if err != nil {
+		t.Fatalf("handleAudit failed: %v", err)
 	}
 
 	if len(result.Entries) == 0 {
@@ -338,6 +909,108 @@ func TestHandleAudit(t *testing.T) {
 	}
 }
 
+func TestHandleAudit_ViewerRoles(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	handler.store.Add("github_token", "v1", "")
+	handler.store.Add("db_password", "v2", "")
+	handler.leaseManager.Acquire("github_token", "agent-1", 1*time.Hour)
+	handler.leaseManager.Acquire("db_password", "agent-2", 1*time.Hour)
+
+	full, err := handler.handleAudit(AuditParams{Tail: 10, Role: "full"})
+	if err != nil {
+		t.Fatalf("handleAudit(full) failed: %v", err)
+	}
+	restricted, err := handler.handleAudit(AuditParams{Tail: 10, Role: "restricted"})
+	if err != nil {
+		t.Fatalf("handleAudit(restricted) failed: %v", err)
+	}
+
+	if len(full.Entries) == 0 || len(full.Entries) != len(restricted.Entries) {
+		t.Fatalf("expected identical non-zero entry counts, got full=%d restricted=%d", len(full.Entries), len(restricted.Entries))
+	}
+
+	names := map[string]bool{}
+	for _, e := range full.Entries {
+		names[e.SecretName] = true
+	}
+	if !names["github_token"] || !names["db_password"] {
+		t.Errorf("full output should include secret names, got %v", names)
+	}
+
+	for i, e := range restricted.Entries {
+		if e.SecretName != "" {
+			t.Errorf("restricted entry %d exposes secret name %q", i, e.SecretName)
+		}
+		if e.Action != full.Entries[i].Action || e.Success != full.Entries[i].Success || !e.Timestamp.Equal(full.Entries[i].Timestamp) {
+			t.Errorf("restricted entry %d should preserve action, success and timestamp", i)
+		}
+	}
+}
+
+func TestHandleAudit_Filters(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	before := time.Now()
+	handler.store.Add("github_token", "v1", "")
+	handler.store.Add("db_password", "v2", "")
+	handler.leaseManager.Acquire("github_token", "agent-1", 1*time.Hour)
+	handler.leaseManager.Acquire("db_password", "agent-2", 1*time.Hour)
+	after := time.Now()
+
+	tests := []struct {
+		name   string
+		params AuditParams
+		want   int
+	}{
+		{"action", AuditParams{Action: string(types.ActionLeaseAcquire)}, 2},
+		{"no match", AuditParams{Action: string(types.ActionSecretRotate)}, 0},
+		{"secret", AuditParams{SecretName: "github_token"}, 1},
+		{"client", AuditParams{ClientID: "agent-2"}, 1},
+		{"since", AuditParams{Since: after.Add(time.Second)}, 0},
+		{"until", AuditParams{Until: before.Add(-time.Second)}, 0},
+		{"range", AuditParams{Since: before, Until: after}, 2},
+		{"combined", AuditParams{Action: string(types.ActionLeaseAcquire), SecretName: "db_password"}, 1},
+		{"tail", AuditParams{Action: string(types.ActionLeaseAcquire), Tail: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := handler.handleAudit(tt.params)
+			if err != nil {
+				t.Fatalf("handleAudit failed: %v", err)
+			}
+			if len(result.Entries) != tt.want {
+				t.Errorf("expected %d entries, got %d: %+v", tt.want, len(result.Entries), result.Entries)
+			}
+		})
+	}
+
+	// The tail keeps the most recent matches
+	result, err := handler.handleAudit(AuditParams{Action: string(types.ActionLeaseAcquire), Tail: 1})
+	if err != nil {
+		t.Fatalf("handleAudit failed: %v", err)
+	}
+	if len(result.Entries) != 1 || result.Entries[0].ClientID != "agent-2" {
+		t.Errorf("expected the latest lease by agent-2, got %+v", result.Entries)
+	}
+
+	// Restricted viewers cannot probe for a secret's entries
+	if _, err := handler.handleAudit(AuditParams{Role: "restricted", SecretName: "github_token"}); err == nil {
+		t.Error("expected error filtering by secret with the restricted role")
+	}
+}
+
+func TestHandleAudit_UnknownRole(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := handler.handleAudit(AuditParams{Tail: 10, Role: "admin"}); err == nil {
+		t.Error("expected error for unknown viewer role")
+	}
+}
+
 func TestHandleStatus(t *testing.T) {
 	handler, _, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -493,3 +1166,775 @@ func TestJSONSerialization(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleRevoke_RemotePeerMismatch(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	owner := types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "agent-a"}
+	lse, err := handler.leaseManager.AcquireFrom("test-secret", "test-client", 1*time.Hour, owner)
+	if err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	// A different certificate identity cannot revoke the lease
+	other := types.Peer{RemoteAddr: "10.0.0.2:5000", ClientCN: "agent-b"}
+	resp := handler.HandleRequestFrom(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodRevoke,
+		Params:  RevokeParams{LeaseID: lse.ID},
+		ID:      1,
+	}, other)
+	if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+		t.Fatalf("expected unauthorized error, got %+v", resp.Error)
+	}
+
+	// The owning identity can
+	if _, err := handler.handleRevoke(RevokeParams{LeaseID: lse.ID}, owner); err != nil {
+		t.Fatalf("handleRevoke by owner failed: %v", err)
+	}
+	if active := handler.leaseManager.List(); len(active) != 0 {
+		t.Errorf("expected 0 active leases, got %d", len(active))
+	}
+}
+
+func TestHandleRevokeByClient(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	compromised := types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "agent-a"}
+	for i := 0; i < 2; i++ {
+		if _, err := handler.leaseManager.AcquireFrom("test-secret", "client", 1*time.Hour, compromised); err != nil {
+			t.Fatalf("failed to acquire lease: %v", err)
+		}
+	}
+	other := types.Peer{RemoteAddr: "10.0.0.2:5000", ClientCN: "agent-b"}
+	if _, err := handler.leaseManager.AcquireFrom("test-secret", "client", 1*time.Hour, other); err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	// Remote peers cannot revoke another identity's leases
+	if _, err := handler.handleRevokeByClient(RevokeByClientParams{ClientCN: "agent-a"}, other); err == nil {
+		t.Fatal("expected error revoking another client's leases")
+	}
+
+	// The local socket owner can
+	result, err := handler.handleRevokeByClient(RevokeByClientParams{ClientCN: "agent-a"}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleRevokeByClient failed: %v", err)
+	}
+	if result.LeasesRevoked != 2 {
+		t.Errorf("expected 2 leases revoked, got %d", result.LeasesRevoked)
+	}
+
+	active := handler.leaseManager.List()
+	if len(active) != 1 || active[0].ClientCN != "agent-b" {
+		t.Errorf("expected only agent-b's lease to remain, got %+v", active)
+	}
+}
+
+func TestHandleCapabilities(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	resp := handler.HandleRequest(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodCapabilities,
+		ID:      1,
+	})
+	if resp.Error != nil {
+		t.Fatalf("capabilities failed: %v", resp.Error.Message)
+	}
+
+	result, ok := resp.Result.(*CapabilitiesResult)
+	if !ok {
+		t.Fatalf("unexpected result type %T", resp.Result)
+	}
+	if result.ProtocolVersion != ProtocolVersion {
+		t.Errorf("ProtocolVersion = %d, want %d", result.ProtocolVersion, ProtocolVersion)
+	}
+
+	listed := make(map[string]bool)
+	for _, m := range result.Methods {
+		listed[m] = true
+	}
+	for _, m := range []string{
+		MethodInit, MethodAdd, MethodImport, MethodDelete, MethodRename, MethodList, MethodSearch, MethodNamespaces, MethodDeleteNamespace, MethodLease, MethodRenew, MethodGetByLease,
+		MethodRevoke, MethodRevokeAll, MethodRevokeByClient, MethodRevokeClient, MethodRotate, MethodRotateAll, MethodRotationPolicy,
+		MethodAudit, MethodStatus, MethodHealth, MethodProbe, MethodKeepAlive, MethodArm, MethodDisarm, MethodCapabilities,
+		MethodCompact, MethodDuplicates, MethodHistory, MethodRollback, MethodRecipients, MethodAddRecipient, MethodRemoveRecipient,
+	} {
+		if !listed[m] {
+			t.Errorf("capabilities missing %s", m)
+		}
+	}
+	if listed[MethodGet] {
+		t.Errorf("capabilities lists %s, which is always refused", MethodGet)
+	}
+
+	// Every listed method must actually be dispatched
+	for _, m := range result.Methods {
+		resp := handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: m, ID: 1})
+		if resp.Error != nil && resp.Error.Code == types.RPCMethodNotFound {
+			t.Errorf("listed method %s is not handled", m)
+		}
+	}
+}
+
+func TestHandleRotate_AuditsManualTrigger(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("rotating", "value", "echo 'rotated'"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	result, err := handler.handleRotate(RotateParams{SecretName: "rotating"})
+	if err != nil {
+		t.Fatalf("handleRotate failed: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("rotation failed: %+v", result)
+	}
+
+	entries, err := handler.auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Action == types.ActionSecretRotate && entry.SecretName == "rotating" {
+			found = true
+			if entry.Trigger != types.TriggerManual {
+				t.Errorf("expected trigger %q, got %q", types.TriggerManual, entry.Trigger)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected rotation audit entry")
+	}
+}
+
+func TestHandleRotateAll(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("good", "value", "echo 'rotated'"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := handler.store.Add("bad", "value", "exit 2"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	result, err := handler.handleRotateAll()
+	if err != nil {
+		t.Fatalf("handleRotateAll failed: %v", err)
+	}
+	if len(result.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(result.Results))
+	}
+	for _, r := range result.Results {
+		switch r.SecretName {
+		case "good":
+			if !r.Success || r.ExitCode != 0 {
+				t.Errorf("good: %+v", r)
+			}
+		case "bad":
+			if r.Success || r.ExitCode != 2 {
+				t.Errorf("bad: %+v", r)
+			}
+		}
+		if r.Trigger != types.TriggerManual {
+			t.Errorf("expected trigger %q, got %q", types.TriggerManual, r.Trigger)
+		}
+	}
+}
+
+func TestHandleRotationPolicy(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if _, err := handler.handleAdd(AddParams{Name: "scheduled", Value: "v", RotateVia: "echo rotated", RotateEvery: 720 * time.Hour}, types.Peer{}); err != nil {
+		t.Fatalf("handleAdd failed: %v", err)
+	}
+	if _, err := handler.handleAdd(AddParams{Name: "nohook", Value: "v", RotateEvery: time.Hour}, types.Peer{}); err == nil {
+		t.Error("expected rotate_every without rotate_via to fail")
+	}
+
+	metadata := func() *types.RotationPolicy {
+		t.Helper()
+		result, err := handler.handleList(ListParams{})
+		if err != nil {
+			t.Fatalf("handleList failed: %v", err)
+		}
+		for _, s := range result.Secrets {
+			if s.Name == "scheduled" {
+				return s.RotationPolicy
+			}
+		}
+		t.Fatal("secret not listed")
+		return nil
+	}
+	if policy := metadata(); policy == nil || policy.Interval != 720*time.Hour {
+		t.Fatalf("expected 720h policy from add, got %+v", policy)
+	}
+
+	resp := handler.HandleRequest(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodRotationPolicy,
+		Params:  RotationPolicyParams{Name: "scheduled", Interval: time.Hour, MaxAge: 2 * time.Hour},
+		ID:      1,
+	})
+	if resp.Error != nil {
+		t.Fatalf("rotationPolicy failed: %v", resp.Error)
+	}
+	if policy := metadata(); policy == nil || policy.Interval != time.Hour || policy.MaxAge != 2*time.Hour {
+		t.Errorf("expected 1h/2h policy, got %+v", policy)
+	}
+
+	// A zero interval turns scheduling off
+	if _, err := handler.handleRotationPolicy(RotationPolicyParams{Name: "scheduled"}); err != nil {
+		t.Fatalf("clearing policy failed: %v", err)
+	}
+	if policy := metadata(); policy != nil {
+		t.Errorf("expected no policy, got %+v", policy)
+	}
+
+	if _, err := handler.handleRotationPolicy(RotationPolicyParams{Name: "missing", Interval: time.Hour}); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestHandleKeepAlive(t *testing.T) {
+	handler, cfg, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// Without a dead-man switch there is nothing to touch
+	resp := handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: MethodKeepAlive, ID: 1})
+	if resp.Error == nil {
+		t.Fatal("expected error when the dead-man switch is not configured")
+	}
+
+	path := filepath.Join(cfg.Directory, "keepalive")
+	handler.SetDeadMan(path, time.Hour)
+	result, err := handler.handleKeepAlive(types.Peer{})
+	if err != nil {
+		t.Fatalf("handleKeepAlive failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("keep-alive file not created: %v", err)
+	}
+	if info.ModTime().Sub(result.TouchedAt).Abs() > time.Second {
+		t.Errorf("expected mtime %v, got %v", result.TouchedAt, info.ModTime())
+	}
+	if !result.Deadline.Equal(result.TouchedAt.Add(time.Hour)) {
+		t.Errorf("expected deadline an hour after the touch, got %v", result.Deadline)
+	}
+
+	action := types.ActionKeepAlive
+	entries, err := handler.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].Success {
+		t.Errorf("expected one successful keepalive audited, got %+v", entries)
+	}
+}
+
+func TestHandleArm(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if armed, _ := handler.killswitch.Armed(); armed {
+		t.Fatal("expected the killswitch to start disarmed")
+	}
+
+	result, err := handler.handleArm(ArmParams{Window: time.Hour}, types.Peer{})
+	if err != nil {
+		t.Fatalf("handleArm failed: %v", err)
+	}
+	if !result.Armed || time.Until(result.ExpiresAt) <= 59*time.Minute {
+		t.Errorf("expected armed for an hour, got %+v", result)
+	}
+	if armed, _ := handler.killswitch.Armed(); !armed {
+		t.Error("expected the killswitch to be armed")
+	}
+
+	// Remote peers can neither arm nor disarm
+	remote := types.Peer{RemoteAddr: "10.0.0.5:4000", ClientCN: "agent"}
+	if _, err := handler.handleArm(nil, remote); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("expected ErrPeerMismatch arming remotely, got %v", err)
+	}
+	if _, err := handler.handleDisarm(remote); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("expected ErrPeerMismatch disarming remotely, got %v", err)
+	}
+
+	resp := handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: MethodDisarm, ID: 1})
+	if resp.Error != nil {
+		t.Fatalf("disarm failed: %v", resp.Error.Message)
+	}
+	if armed, _ := handler.killswitch.Armed(); armed {
+		t.Error("expected the killswitch to be disarmed")
+	}
+
+	action := types.ActionKillswitchArm
+	entries, err := handler.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Details != "disarmed" {
+		t.Errorf("expected arm and disarm audited, got %+v", entries)
+	}
+}
+
+func TestHandleProbe(t *testing.T) {
+	handler, cfg, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("api_key", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	resp := handler.HandleRequest(&types.RPCRequest{JSONRPC: "2.0", Method: MethodProbe, ID: 1})
+	if resp.Error != nil {
+		t.Fatalf("probe failed: %v", resp.Error.Message)
+	}
+	probe, ok := resp.Result.(*ProbeResult)
+	if !ok {
+		t.Fatalf("expected *ProbeResult, got %T", resp.Result)
+	}
+	if probe.Status != ProbeOK || !probe.StoreLoaded {
+		t.Errorf("expected a ready probe, got %+v", probe)
+	}
+
+	// Nothing about secrets is revealed
+	data, _ := json.Marshal(probe)
+	if strings.Contains(string(data), "api_key") || strings.Contains(string(data), "secret-value") {
+		t.Errorf("probe reveals secrets: %s", data)
+	}
+
+	// A store without an identity is not ready
+	handler.store = store.New(cfg)
+	probe = handler.handleProbe()
+	if probe.Status != ProbeUnavailable || probe.StoreLoaded {
+		t.Errorf("expected an unavailable probe for an unloaded store, got %+v", probe)
+	}
+}
+
+func TestHandleCompact(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, name := range []string{"keep", "gone"} {
+		if err := handler.store.Add(name, name+"-value", ""); err != nil {
+			t.Fatalf("failed to add secret: %v", err)
+		}
+	}
+	if _, err := handler.handleDelete(DeleteParams{Name: "gone"}); err != nil {
+		t.Fatalf("failed to delete secret: %v", err)
+	}
+
+	resp := handler.HandleRequest(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodCompact,
+		ID:      1,
+	})
+	if resp.Error != nil {
+		t.Fatalf("compact failed: %v", resp.Error.Message)
+	}
+	result, ok := resp.Result.(*CompactResult)
+	if !ok {
+		t.Fatalf("unexpected result type %T", resp.Result)
+	}
+	if !result.Success || result.Reclaimed != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	value, err := handler.store.Get("keep")
+	if err != nil || value != "keep-value" {
+		t.Errorf("live secret changed by compaction: %q, %v", value, err)
+	}
+}
+
+func TestHandleImport(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("API_KEY", "existing", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	result, err := handler.handleImport(ImportParams{
+		Vars: map[string]string{"API_KEY": "imported", "DATABASE_URL": "postgres://localhost/db"},
+	}, types.Peer{})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(result.Added) != 1 || result.Added[0] != "DATABASE_URL" {
+		t.Errorf("added = %v, want [DATABASE_URL]", result.Added)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "API_KEY" {
+		t.Errorf("skipped = %v, want [API_KEY]", result.Skipped)
+	}
+
+	if _, err := handler.handleImport(ImportParams{Vars: map[string]string{"EMPTY": ""}}, types.Peer{}); err == nil {
+		t.Error("expected an error importing an empty value")
+	}
+}
+
+func TestHandleRollback(t *testing.T) {
+	handler, cfg, cleanup := setupTestHandler(t)
+	defer cleanup()
+	cfg.HistoryLimit = 5
+
+	if err := handler.store.Add("api_key", "v1", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := handler.store.Update("api_key", "v2", nil); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+
+	resp := handler.HandleRequest(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodHistory,
+		Params:  HistoryParams{Name: "api_key"},
+		ID:      1,
+	})
+	if resp.Error != nil {
+		t.Fatalf("history failed: %v", resp.Error.Message)
+	}
+	history, ok := resp.Result.(*HistoryResult)
+	if !ok {
+		t.Fatalf("unexpected result type %T", resp.Result)
+	}
+	if len(history.Versions) != 2 || history.Versions[1].Version != 1 {
+		t.Errorf("unexpected versions: %+v", history.Versions)
+	}
+
+	resp = handler.HandleRequest(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodRollback,
+		Params:  RollbackParams{Name: "api_key"},
+		ID:      2,
+	})
+	if resp.Error != nil {
+		t.Fatalf("rollback failed: %v", resp.Error.Message)
+	}
+	if value, err := handler.store.Get("api_key"); err != nil || value != "v1" {
+		t.Errorf("value after rollback = %q, %v; want v1", value, err)
+	}
+
+	// Nothing left to roll back to
+	resp = handler.HandleRequest(&types.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  MethodRollback,
+		Params:  RollbackParams{Name: "api_key"},
+		ID:      3,
+	})
+	if resp.Error == nil {
+		t.Error("expected an error rolling back without history")
+	}
+}
+
+func TestHandleRecipients(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	teammate, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey := teammate.Recipient().String()
+
+	call := func(method string, params interface{}) *types.RPCResponse {
+		return handler.HandleRequest(&types.RPCRequest{
+			JSONRPC: "2.0",
+			Method:  method,
+			Params:  params,
+			ID:      1,
+		})
+	}
+
+	if resp := call(MethodAddRecipient, RecipientParams{Recipient: pubkey}); resp.Error != nil {
+		t.Fatalf("addRecipient failed: %v", resp.Error.Message)
+	}
+	if resp := call(MethodAddRecipient, RecipientParams{}); resp.Error == nil {
+		t.Error("expected error without a recipient")
+	}
+
+	resp := call(MethodRecipients, nil)
+	if resp.Error != nil {
+		t.Fatalf("recipients failed: %v", resp.Error.Message)
+	}
+	result, ok := resp.Result.(*RecipientsResult)
+	if !ok {
+		t.Fatalf("unexpected result type %T", resp.Result)
+	}
+	if len(result.Recipients) != 2 || !result.Recipients[0].Local || result.Recipients[1].Recipient != pubkey {
+		t.Errorf("unexpected recipients: %+v", result.Recipients)
+	}
+
+	if resp := call(MethodRemoveRecipient, RecipientParams{Recipient: pubkey}); resp.Error != nil {
+		t.Fatalf("removeRecipient failed: %v", resp.Error.Message)
+	}
+	if resp := call(MethodRemoveRecipient, RecipientParams{Recipient: pubkey}); resp.Error == nil {
+		t.Error("expected error removing an unknown recipient")
+	}
+}
+
+func TestHandleRecipients_RemotePeers(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	attacker, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey := attacker.Recipient().String()
+
+	for _, peer := range []types.Peer{
+		{RemoteAddr: "10.0.0.5:4000", ClientCN: "agent"},
+		{RemoteAddr: "10.0.0.6:4000", TokenID: "0123456789abcdef"},
+	} {
+		if _, err := handler.handleAddRecipient(RecipientParams{Recipient: pubkey}, peer); !errors.Is(err, types.ErrPeerMismatch) {
+			t.Errorf("%s addRecipient: expected ErrPeerMismatch, got %v", peer.Identity(), err)
+		}
+	}
+	if recipients, _ := handler.store.Recipients(); len(recipients) != 1 {
+		t.Errorf("remote peer added a recipient: %+v", recipients)
+	}
+
+	// Remote peers cannot remove a recipient the owner added either
+	if _, err := handler.handleAddRecipient(RecipientParams{Recipient: pubkey}, types.Peer{}); err != nil {
+		t.Fatalf("local addRecipient failed: %v", err)
+	}
+	if _, err := handler.handleRemoveRecipient(RecipientParams{Recipient: pubkey}, types.Peer{RemoteAddr: "10.0.0.5:4000", ClientCN: "agent"}); !errors.Is(err, types.ErrPeerMismatch) {
+		t.Errorf("removeRecipient: expected ErrPeerMismatch, got %v", err)
+	}
+	if recipients, _ := handler.store.Recipients(); len(recipients) != 2 {
+		t.Errorf("remote peer removed a recipient: %+v", recipients)
+	}
+}
+
+func TestHandleRequest_Policy(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	policy, err := NewPolicy(map[string][]string{"cn:dashboard": {"list", "status"}})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+	handler.SetPolicy(policy)
+
+	dashboard := types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "dashboard"}
+	provisioner := types.Peer{RemoteAddr: "10.0.0.2:5000", ClientCN: "provisioner"}
+	call := func(peer types.Peer, method string, params interface{}) *types.RPCResponse {
+		return handler.HandleRequestFrom(&types.RPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1}, peer)
+	}
+
+	// Clients without a policy entry are unrestricted
+	if resp := call(provisioner, MethodAdd, AddParams{Name: "api-key", Value: "v"}); resp.Error != nil {
+		t.Fatalf("provisioner add failed: %v", resp.Error.Message)
+	}
+
+	for _, method := range []string{MethodList, MethodStatus} {
+		if resp := call(dashboard, method, nil); resp.Error != nil {
+			t.Errorf("dashboard %s failed: %v", method, resp.Error.Message)
+		}
+	}
+
+	for method, params := range map[string]interface{}{
+		MethodAdd:   AddParams{Name: "other", Value: "v"},
+		MethodLease: LeaseParams{SecretName: "api-key", ClientID: "dashboard", TTL: "1h"},
+	} {
+		resp := call(dashboard, method, params)
+		if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+			t.Errorf("dashboard %s: expected RPCUnauthorized, got %+v", method, resp.Error)
+		}
+	}
+	if _, err := handler.store.Get("other"); err == nil {
+		t.Error("rejected add still stored the secret")
+	}
+}
+
+func TestHandleRequest_RemoteDenied(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("prod::api-key", "value", "echo rotated"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handler.leaseManager.Acquire("prod::api-key", "local", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	tlsPeer := types.Peer{RemoteAddr: "10.0.0.5:4000", ClientCN: "ops"}
+	call := func(method string, params interface{}) *types.RPCResponse {
+		return handler.HandleRequestFrom(&types.RPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1}, tlsPeer)
+	}
+	denied := []struct {
+		method string
+		params interface{}
+	}{
+		{MethodDelete, DeleteParams{Name: "prod::api-key"}},
+		{MethodDeleteNamespace, DeleteNamespaceParams{Namespace: "prod"}},
+		{MethodRename, RenameParams{Name: "prod::api-key", NewName: "stolen"}},
+		{MethodRevokeAll, nil},
+		{MethodRotate, RotateParams{SecretName: "prod::api-key"}},
+		{MethodRotateAll, nil},
+		{MethodRollback, RollbackParams{Name: "prod::api-key"}},
+		{MethodCompact, nil},
+	}
+
+	// Denied without a policy, and when only the default entry allows them
+	for _, policy := range []map[string][]string{nil, {PolicyDefault: {"delete", "revokeAll", "rotate", "compact"}}} {
+		p, err := NewPolicy(policy)
+		if err != nil {
+			t.Fatalf("NewPolicy failed: %v", err)
+		}
+		handler.SetPolicy(p)
+		for _, tt := range denied {
+			if resp := call(tt.method, tt.params); resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+				t.Errorf("%s with policy %v: expected RPCUnauthorized, got %+v", tt.method, policy, resp.Error)
+			}
+		}
+	}
+	if _, err := handler.store.GetMetadata("prod::api-key"); err != nil {
+		t.Errorf("denied calls changed the secret: %v", err)
+	}
+	if active := handler.leaseManager.List(); len(active) != 1 || active[0].Revoked {
+		t.Errorf("denied revokeAll revoked leases: %+v", active)
+	}
+
+	// The same methods stay open to local clients
+	if resp := handler.HandleRequestFrom(&types.RPCRequest{JSONRPC: "2.0", Method: MethodCompact, ID: 1}, types.Peer{}); resp.Error != nil {
+		t.Errorf("local compact failed: %v", resp.Error.Message)
+	}
+
+	// An explicit grant for the client's own identity allows the method
+	p, err := NewPolicy(map[string][]string{"cn:ops": {"delete"}})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+	handler.SetPolicy(p)
+	if resp := call(MethodDelete, DeleteParams{Name: "prod::api-key"}); resp.Error != nil {
+		t.Errorf("granted delete failed: %v", resp.Error.Message)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	policy, err := NewPolicy(map[string][]string{
+		"uid:501":     {"secrets.lease", "revoke"},
+		PolicyDefault: {"health"},
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+
+	tests := []struct {
+		peer    types.Peer
+		method  string
+		allowed bool
+	}{
+		{types.Peer{UID: "501"}, MethodLease, true},
+		{types.Peer{UID: "501"}, MethodRevoke, true},
+		{types.Peer{UID: "501"}, MethodAdd, false},
+		{types.Peer{UID: "502"}, MethodHealth, true}, // falls back to "*"
+		{types.Peer{UID: "502"}, MethodLease, false},
+		{types.Peer{}, MethodList, false},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.peer, tt.method); got != tt.allowed {
+			t.Errorf("Allows(%q, %s) = %v, want %v", tt.peer.Identity(), tt.method, got, tt.allowed)
+		}
+	}
+
+	var unrestricted Policy
+	if !unrestricted.Allows(types.Peer{}, MethodRevokeAll) {
+		t.Error("nil policy should allow every method")
+	}
+
+	if _, err := NewPolicy(map[string][]string{"cn:dashboard": {"lsit"}}); err == nil {
+		t.Error("expected error for an unknown method")
+	}
+	for _, client := range []string{"", "dashboard", "uid:", "uid:root", "cn:", "host:ci"} {
+		if _, err := NewPolicy(map[string][]string{client: {"list"}}); err == nil {
+			t.Errorf("expected error for the ambiguous client %q", client)
+		}
+	}
+}
+
+func TestNewPolicy_CNCannotPassForUID(t *testing.T) {
+	policy, err := NewPolicy(map[string][]string{
+		"uid:0":       {"revokeAll"},
+		PolicyDefault: {"status"},
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy failed: %v", err)
+	}
+
+	// A certificate with CN "uid:0" is not root on the Unix socket
+	impostor := types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "uid:0"}
+	if impostor.Identity() != "cn:uid:0" {
+		t.Errorf("Identity() = %q, want cn:uid:0", impostor.Identity())
+	}
+	if policy.Allows(impostor, MethodRevokeAll) {
+		t.Error("CN uid:0 was granted the policy of uid 0")
+	}
+	if !policy.Allows(types.Peer{UID: "0"}, MethodRevokeAll) {
+		t.Error("uid 0 was denied its own policy")
+	}
+}
+
+func TestTokenPeerCannotTouchOtherLeases(t *testing.T) {
+	handler, _, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	if err := handler.store.Add("test-secret", "test-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	token := types.Peer{RemoteAddr: "10.0.0.3:5000", TokenID: "0123456789abcdef"}
+	local, err := handler.leaseManager.AcquireFrom("test-secret", "local", time.Hour, types.Peer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A TLS client without a certificate CN is not the token client either
+	tls, err := handler.leaseManager.AcquireFrom("test-secret", "tls", time.Hour, types.Peer{RemoteAddr: "10.0.0.4:5000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lse := range []*types.Lease{local, tls} {
+		if _, err := handler.handleRevoke(RevokeParams{LeaseID: lse.ID}, token); !errors.Is(err, types.ErrPeerMismatch) {
+			t.Errorf("revoke %s: expected ErrPeerMismatch, got %v", lse.ClientID, err)
+		}
+		if _, err := handler.handleRenew(RenewParams{LeaseID: lse.ID}, token); !errors.Is(err, types.ErrPeerMismatch) {
+			t.Errorf("renew %s: expected ErrPeerMismatch, got %v", lse.ClientID, err)
+		}
+		if _, err := handler.handleGetByLease(GetByLeaseParams{LeaseID: lse.ID, SecretName: "test-secret"}, token); !errors.Is(err, types.ErrPeerMismatch) {
+			t.Errorf("getByLease %s: expected ErrPeerMismatch, got %v", lse.ClientID, err)
+		}
+	}
+
+	// The token client can act on its own lease
+	own, err := handler.leaseManager.AcquireFrom("test-secret", "token", time.Hour, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own.PeerIdentity != "token:0123456789abcdef" {
+		t.Errorf("PeerIdentity = %q, want the token identity", own.PeerIdentity)
+	}
+	if _, err := handler.handleRevoke(RevokeParams{LeaseID: own.ID}, types.Peer{RemoteAddr: "10.0.0.9:6000", TokenID: "0123456789abcdef"}); err != nil {
+		t.Errorf("revoke of own lease failed: %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build darwin
+
+package daemon
+
+import (
+	"net"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCredSupported reports whether peerUID can identify Unix socket peers
+// here. Where it can, a failed lookup refuses the connection.
+const peerCredSupported = true
+
+// peerUID returns the user ID of the process on the other end of a Unix
+// socket connection, from LOCAL_PEERCRED.
+func peerUID(conn net.Conn) (string, bool) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return "", false
+	}
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return "", false
+	}
+
+	var cred *unix.Xucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	}); err != nil || credErr != nil {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(cred.Uid), 10), true
+}
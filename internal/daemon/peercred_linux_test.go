@@ -0,0 +1,120 @@
+//go:build linux
+
+package daemon
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/audit"
+	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// startPeerTestDaemon starts a daemon that also allows otherUID. If
+// foreignOnly is set, the daemon's own UID is dropped from the allowlist
+// before it starts, as if it ran as another user.
+func startPeerTestDaemon(t *testing.T, otherUID int, foreignOnly bool) (*Daemon, *config.Config) {
+	t.Helper()
+	tempDir := t.TempDir()
+	cfg := &config.Config{
+		Directory:       tempDir,
+		SocketPath:      tempDir + "/test.sock",
+		IdentityPath:    tempDir + "/identity.age",
+		SecretsPath:     tempDir + "/secrets.age",
+		AuditPath:       tempDir + "/audit.log",
+		LeasesPath:      tempDir + "/leases.json",
+		DefaultLeaseTTL: 1 * time.Hour,
+		MaxLeaseTTL:     24 * time.Hour,
+		RotationTimeout: 30 * time.Second,
+		AllowedUIDs:     []int{otherUID},
+	}
+
+	d, err := NewDaemon(cfg)
+	if err != nil {
+		t.Fatalf("NewDaemon failed: %v", err)
+	}
+	if foreignOnly {
+		delete(d.allowedUIDs, strconv.Itoa(os.Getuid()))
+	}
+	if err := d.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { _ = d.Stop() })
+	return d, cfg
+}
+
+// peerStatus sends a status request over the daemon's Unix socket.
+func peerStatus(t *testing.T, socketPath string) types.RPCResponse {
+	t.Helper()
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(types.RPCRequest{JSONRPC: "2.0", Method: MethodStatus, ID: 1}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	var resp types.RPCResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func TestDaemonPeerUIDCheck(t *testing.T) {
+	ownUID := strconv.Itoa(os.Getuid())
+	otherUID := os.Getuid() + 1000
+
+	// The daemon's own user is always let in, alongside AllowedUIDs
+	d, cfg := startPeerTestDaemon(t, otherUID, false)
+	if !d.allowedUIDs[ownUID] || !d.allowedUIDs[strconv.Itoa(otherUID)] {
+		t.Fatalf("expected own and configured UIDs allowed, got %v", d.allowedUIDs)
+	}
+	if resp := peerStatus(t, cfg.SocketPath); resp.Error != nil {
+		t.Fatalf("own UID rejected: %v", resp.Error.Message)
+	}
+
+	// A peer whose UID is not allowed is refused and audited
+	d, cfg = startPeerTestDaemon(t, otherUID, true)
+	resp := peerStatus(t, cfg.SocketPath)
+	if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+		t.Fatalf("expected RPCUnauthorized for a foreign UID, got %+v", resp.Error)
+	}
+
+	action := types.ActionAuthFailed
+	entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0].Details, "uid "+ownUID) {
+		t.Errorf("expected one audited rejection of uid %s, got %+v", ownUID, entries)
+	}
+}
+
+func TestDaemonPeerUIDLookupFailure(t *testing.T) {
+	// Unreadable credentials must not let the peer in unchecked
+	old := lookupPeerUID
+	lookupPeerUID = func(net.Conn) (string, bool) { return "", false }
+	t.Cleanup(func() { lookupPeerUID = old })
+
+	d, cfg := startPeerTestDaemon(t, os.Getuid()+1000, false)
+	resp := peerStatus(t, cfg.SocketPath)
+	if resp.Error == nil || resp.Error.Code != types.RPCUnauthorized {
+		t.Fatalf("expected RPCUnauthorized without peer credentials, got %+v", resp.Error)
+	}
+
+	action := types.ActionAuthFailed
+	entries, err := d.auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 1 || !strings.Contains(entries[0].Details, "peer credentials unavailable") {
+		t.Errorf("expected one audited rejection, got %+v", entries)
+	}
+}
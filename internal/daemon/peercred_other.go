@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package daemon
+
+import "net"
+
+// peerCredSupported reports whether peerUID can identify Unix socket peers
+// here.
+const peerCredSupported = false
+
+// peerUID is not supported on this platform; Unix socket peers have no
+// identity and only PolicyDefault applies to them.
+func peerUID(conn net.Conn) (string, bool) {
+	return "", false
+}
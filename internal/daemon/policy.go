@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// PolicyDefault is the policy key that applies to clients without an entry
+// of their own.
+const PolicyDefault = "*"
+
+// Policy maps client identities (see types.Peer.Identity) to the RPC
+// methods they may call. A nil Policy allows every client every method,
+// except the remoteDenied methods for TCP clients.
+type Policy map[string]map[string]bool
+
+// remoteDenied lists methods that destroy secrets, revoke other clients'
+// leases or run hooks on the daemon host. TCP clients may only call them
+// if their own policy entry grants them; PolicyDefault does not.
+var remoteDenied = map[string]bool{
+	MethodDelete:          true,
+	MethodDeleteNamespace: true,
+	MethodRename:          true,
+	MethodRevokeAll:       true,
+	MethodRotate:          true,
+	MethodRotateAll:       true,
+	MethodRollback:        true,
+	MethodCompact:         true,
+}
+
+// NewPolicy builds a Policy from the policies in the daemon config. Client
+// identities must be PolicyDefault or carry a "cn:", "token:" or "uid:"
+// prefix, so a bare name cannot be read as more than one kind of peer.
+// Methods may be given in full ("secrets.list") or without the "secrets."
+// prefix ("list"); unknown methods are an error.
+func NewPolicy(policies map[string][]string) (Policy, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	policy := make(Policy, len(policies))
+	for client, methods := range policies {
+		if err := checkPolicyClient(client); err != nil {
+			return nil, err
+		}
+		allowed := make(map[string]bool, len(methods))
+		for _, method := range methods {
+			if !strings.Contains(method, ".") {
+				method = "secrets." + method
+			}
+			if !slices.Contains(SupportedMethods, method) {
+				return nil, fmt.Errorf("policy for %q: unknown method %q", client, method)
+			}
+			allowed[method] = true
+		}
+		policy[client] = allowed
+	}
+	return policy, nil
+}
+
+// checkPolicyClient rejects policy keys that are not a well-formed client
+// identity.
+func checkPolicyClient(client string) error {
+	if client == "" {
+		return fmt.Errorf("policy client identity must not be empty")
+	}
+	if client == PolicyDefault {
+		return nil
+	}
+	kind, name, _ := strings.Cut(client, ":")
+	if name == "" {
+		return fmt.Errorf("policy client %q is ambiguous: use cn:<name>, token:<id> or uid:<n>", client)
+	}
+	switch kind {
+	case "cn", "token":
+	case "uid":
+		if _, err := strconv.ParseUint(name, 10, 32); err != nil {
+			return fmt.Errorf("policy client %q: uid must be a number", client)
+		}
+	default:
+		return fmt.Errorf("policy client %q is ambiguous: use cn:<name>, token:<id> or uid:<n>", client)
+	}
+	return nil
+}
+
+// Allows reports whether peer may call method. Clients without an entry
+// fall back to the PolicyDefault entry, and are allowed everything if there
+// is none. TCP clients need an entry of their own for remoteDenied methods.
+func (p Policy) Allows(peer types.Peer, method string) bool {
+	if peer.IsRemote() && remoteDenied[method] {
+		return p[peer.Identity()][method]
+	}
+	if p == nil {
+		return true
+	}
+
+	allowed, ok := p[peer.Identity()]
+	if !ok {
+		allowed, ok = p[PolicyDefault]
+	}
+	if !ok {
+		return true
+	}
+	return allowed[method]
+}
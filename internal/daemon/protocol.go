@@ -1,24 +1,95 @@
 // Package daemon implements a JSON-RPC daemon over Unix sockets.
 package daemon
 
-import "time"
+import (
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
 
 // JSON-RPC method names
 const (
-	MethodInit      = "secrets.init"
-	MethodAdd       = "secrets.add"
-	MethodGet       = "secrets.get"
-	MethodDelete    = "secrets.delete"
-	MethodList      = "secrets.list"
-	MethodLease     = "secrets.lease"
-	MethodRevoke    = "secrets.revoke"
-	MethodRevokeAll = "secrets.revokeAll"
-	MethodRotate    = "secrets.rotate"
-	MethodAudit     = "secrets.audit"
-	MethodStatus    = "secrets.status"
-	MethodHealth    = "secrets.health"
+	MethodInit            = "secrets.init"
+	MethodAdd             = "secrets.add"
+	MethodImport          = "secrets.import"
+	MethodGet             = "secrets.get"
+	MethodDelete          = "secrets.delete"
+	MethodRename          = "secrets.rename"
+	MethodList            = "secrets.list"
+	MethodSearch          = "secrets.search"
+	MethodNamespaces      = "secrets.namespaces"
+	MethodDeleteNamespace = "secrets.deleteNamespace"
+	MethodLease           = "secrets.lease"
+	MethodRenew           = "secrets.renew"
+	MethodGetByLease      = "secrets.getByLease"
+	MethodRevoke          = "secrets.revoke"
+	MethodRevokeAll       = "secrets.revokeAll"
+	MethodRevokeByClient  = "secrets.revokeByClient"
+	MethodRevokeClient    = "secrets.revokeClient"
+	MethodRotate          = "secrets.rotate"
+	MethodRotateAll       = "secrets.rotateAll"
+	MethodRotationPolicy  = "secrets.rotationPolicy"
+	MethodAudit           = "secrets.audit"
+	MethodStatus          = "secrets.status"
+	MethodHealth          = "secrets.health"
+	MethodProbe           = "secrets.probe"
+	MethodKeepAlive       = "secrets.keepalive"
+	MethodArm             = "secrets.arm"
+	MethodDisarm          = "secrets.disarm"
+	MethodCapabilities    = "secrets.capabilities"
+	MethodCompact         = "secrets.compact"
+	MethodDuplicates      = "secrets.duplicates"
+	MethodHistory         = "secrets.history"
+	MethodRollback        = "secrets.rollback"
+	MethodRecipients      = "secrets.recipients"
+	MethodAddRecipient    = "secrets.addRecipient"
+	MethodRemoveRecipient = "secrets.removeRecipient"
 )
 
+// ProtocolVersion is the RPC protocol version spoken by the daemon. It only
+// changes when existing methods change incompatibly; clients detect new
+// methods through secrets.capabilities instead.
+const ProtocolVersion = 1
+
+// SupportedMethods lists the methods the daemon serves. secrets.get is
+// omitted because it always refuses direct access.
+var SupportedMethods = []string{
+	MethodInit,
+	MethodAdd,
+	MethodImport,
+	MethodDelete,
+	MethodRename,
+	MethodList,
+	MethodSearch,
+	MethodNamespaces,
+	MethodDeleteNamespace,
+	MethodLease,
+	MethodRenew,
+	MethodGetByLease,
+	MethodRevoke,
+	MethodRevokeAll,
+	MethodRevokeByClient,
+	MethodRevokeClient,
+	MethodRotate,
+	MethodRotateAll,
+	MethodRotationPolicy,
+	MethodAudit,
+	MethodStatus,
+	MethodHealth,
+	MethodProbe,
+	MethodKeepAlive,
+	MethodArm,
+	MethodDisarm,
+	MethodCapabilities,
+	MethodCompact,
+	MethodDuplicates,
+	MethodHistory,
+	MethodRollback,
+	MethodRecipients,
+	MethodAddRecipient,
+	MethodRemoveRecipient,
+}
+
 // InitParams are parameters for secrets.init
 type InitParams struct {
 	// No parameters needed - uses default config
@@ -32,9 +103,16 @@ type InitResult struct {
 
 // AddParams are parameters for secrets.add
 type AddParams struct {
-	Name      string `json:"name"`
-	Value     string `json:"value"`
-	RotateVia string `json:"rotate_via,omitempty"`
+	Name        string            `json:"name"`
+	Value       string            `json:"value"`
+	RotateVia   string            `json:"rotate_via,omitempty"`
+	PropagateTo []types.EnvTarget `json:"propagate_to,omitempty"` // Env files to refresh after rotation
+	ExpiresAt   time.Time         `json:"expires_at,omitempty"`   // Zero if the secret never expires
+	Labels      map[string]string `json:"labels,omitempty"`
+	GenerateVia string            `json:"generate_via,omitempty"` // Mints each lease's value; Value is then optional
+	RevokeVia   string            `json:"revoke_via,omitempty"`   // Revokes a generated value when its lease ends
+	Binary      bool              `json:"binary,omitempty"`       // Value is base64-encoded file contents
+	RotateEvery time.Duration     `json:"rotate_every,omitempty"` // Schedules rotation through RotateVia; zero disables
 }
 
 // AddResult is the result of secrets.add
@@ -43,6 +121,19 @@ type AddResult struct {
 	Message string `json:"message"`
 }
 
+// ImportParams are parameters for secrets.import
+type ImportParams struct {
+	Vars      map[string]string `json:"vars"`                // Variable name to value
+	Namespace string            `json:"namespace,omitempty"` // Default namespace if empty
+	RotateVia string            `json:"rotate_via,omitempty"`
+}
+
+// ImportResult is the result of secrets.import
+type ImportResult struct {
+	Added   []string `json:"added"`
+	Skipped []string `json:"skipped"` // Names that already hold a secret
+}
+
 // GetParams are parameters for secrets.get (not allowed directly)
 type GetParams struct {
 	Name string `json:"name"`
@@ -59,9 +150,24 @@ type DeleteResult struct {
 	Message string `json:"message"`
 }
 
+// RenameParams are parameters for secrets.rename
+type RenameParams struct {
+	Name    string `json:"name"`
+	NewName string `json:"new_name"` // May be in a different namespace
+}
+
+// RenameResult is the result of secrets.rename
+type RenameResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 // ListParams are parameters for secrets.list
 type ListParams struct {
-	// No parameters needed
+	// NoRotation limits the result to secrets without a rotation hook
+	NoRotation bool `json:"no_rotation,omitempty"`
+	// Labels limits the result to secrets carrying every given label
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // ListResult is the result of secrets.list
@@ -69,6 +175,35 @@ type ListResult struct {
 	Secrets []SecretMetadata `json:"secrets"`
 }
 
+// SearchParams are parameters for secrets.search
+type SearchParams struct {
+	Query string `json:"query"` // Substring, or glob if it contains "*"
+}
+
+// SearchResult is the result of secrets.search
+type SearchResult struct {
+	Secrets []SecretMetadata `json:"secrets"`
+}
+
+// NamespacesParams are parameters for secrets.namespaces
+type NamespacesParams struct{}
+
+// NamespacesResult is the result of secrets.namespaces
+type NamespacesResult struct {
+	Namespaces []string `json:"namespaces"`
+}
+
+// DeleteNamespaceParams are parameters for secrets.deleteNamespace
+type DeleteNamespaceParams struct {
+	Namespace string `json:"namespace"`
+}
+
+// DeleteNamespaceResult is the result of secrets.deleteNamespace
+type DeleteNamespaceResult struct {
+	Deleted       int `json:"deleted"`        // Secrets deleted
+	RevokedLeases int `json:"revoked_leases"` // Leases revoked on them
+}
+
 // SecretMetadata contains non-sensitive secret information
 type SecretMetadata struct {
 	Name        string    `json:"name"`
@@ -76,13 +211,23 @@ type SecretMetadata struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	RotateVia   string    `json:"rotate_via,omitempty"`
 	LastRotated time.Time `json:"last_rotated,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
+
+	PropagateTo []types.EnvTarget `json:"propagate_to,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	GenerateVia string            `json:"generate_via,omitempty"`
+	RevokeVia   string            `json:"revoke_via,omitempty"`
+	Binary      bool              `json:"binary,omitempty"`
+
+	RotationPolicy *types.RotationPolicy `json:"rotation_policy,omitempty"`
 }
 
 // LeaseParams are parameters for secrets.lease
 type LeaseParams struct {
 	SecretName string `json:"secret_name"`
 	ClientID   string `json:"client_id"`
-	TTL        string `json:"ttl"` // Duration string like "1h", "30m"
+	TTL        string `json:"ttl"`              // Duration string like "1h", "30m"
+	Reason     string `json:"reason,omitempty"` // Why the lease is needed, for the audit log
 }
 
 // LeaseResult is the result of secrets.lease
@@ -90,6 +235,36 @@ type LeaseResult struct {
 	LeaseID   string    `json:"lease_id"`
 	Value     string    `json:"value"`
 	ExpiresAt time.Time `json:"expires_at"`
+	Binary    bool      `json:"binary,omitempty"` // Value is base64-encoded file contents
+}
+
+// RenewParams are parameters for secrets.renew
+type RenewParams struct {
+	LeaseID string `json:"lease_id"`
+	TTL     string `json:"ttl,omitempty"` // Duration string like "1h"; default lease TTL if empty
+}
+
+// RenewResult is the result of secrets.renew. The value is not returned
+// again.
+type RenewResult struct {
+	LeaseID    string    `json:"lease_id"`
+	SecretName string    `json:"secret_name"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// GetByLeaseParams are parameters for secrets.getByLease
+type GetByLeaseParams struct {
+	LeaseID    string `json:"lease_id"`
+	SecretName string `json:"secret_name"` // Must match the secret the lease was granted for
+}
+
+// GetByLeaseResult is the result of secrets.getByLease
+type GetByLeaseResult struct {
+	LeaseID    string    `json:"lease_id"`
+	SecretName string    `json:"secret_name"`
+	Value      string    `json:"value"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Binary     bool      `json:"binary,omitempty"` // Value is base64-encoded file contents
 }
 
 // RevokeParams are parameters for secrets.revoke
@@ -103,6 +278,30 @@ type RevokeResult struct {
 	Message string `json:"message"`
 }
 
+// RevokeByClientParams are parameters for secrets.revokeByClient
+type RevokeByClientParams struct {
+	ClientCN string `json:"client_cn"`
+}
+
+// RevokeByClientResult is the result of secrets.revokeByClient
+type RevokeByClientResult struct {
+	Success       bool   `json:"success"`
+	LeasesRevoked int    `json:"leases_revoked"`
+	Message       string `json:"message"`
+}
+
+// RevokeClientParams are parameters for secrets.revokeClient
+type RevokeClientParams struct {
+	ClientID string `json:"client_id"`
+}
+
+// RevokeClientResult is the result of secrets.revokeClient
+type RevokeClientResult struct {
+	Success       bool   `json:"success"`
+	LeasesRevoked int    `json:"leases_revoked"`
+	Message       string `json:"message"`
+}
+
 // RevokeAllParams are parameters for secrets.revokeAll
 type RevokeAllParams struct {
 	// No parameters needed
@@ -122,15 +321,69 @@ type RotateParams struct {
 
 // RotateResult is the result of secrets.rotate
 type RotateResult struct {
-	Success    bool      `json:"success"`
-	Output     string    `json:"output,omitempty"`
-	Error      string    `json:"error,omitempty"`
-	ExecutedAt time.Time `json:"executed_at"`
+	Success        bool                  `json:"success"`
+	Output         string                `json:"output,omitempty"`
+	Error          string                `json:"error,omitempty"`
+	ExecutedAt     time.Time             `json:"executed_at"`
+	Propagated     []string              `json:"propagated,omitempty"`
+	Trigger        types.RotationTrigger `json:"trigger"`
+	ExitCode       int                   `json:"exit_code"`
+	Duration       time.Duration         `json:"duration"`
+	OldFingerprint string                `json:"old_fingerprint,omitempty"`
+	NewFingerprint string                `json:"new_fingerprint,omitempty"`
+	ValueUpdated   bool                  `json:"value_updated,omitempty"`
+}
+
+// RotateAllResult is the result of secrets.rotateAll: one entry per secret
+// with a rotation hook, including failed rotations.
+type RotateAllResult struct {
+	Results []types.RotationResult `json:"results"`
+}
+
+// RotationPolicyParams are parameters for secrets.rotationPolicy. A zero
+// interval turns scheduled rotation off.
+type RotationPolicyParams struct {
+	Name     string        `json:"name"`
+	Interval time.Duration `json:"interval"`
+	MaxAge   time.Duration `json:"max_age,omitempty"`
+}
+
+// RotationPolicyResult is the result of secrets.rotationPolicy
+type RotationPolicyResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// KeepAliveResult is the result of secrets.keepalive
+type KeepAliveResult struct {
+	File      string    `json:"file"`
+	TouchedAt time.Time `json:"touched_at"`
+	Deadline  time.Time `json:"deadline"` // The dead-man switch fires if not touched again by then
+}
+
+// ArmParams are parameters for secrets.arm. A zero window uses the
+// configured arm_window.
+type ArmParams struct {
+	Window time.Duration `json:"window,omitempty"`
+}
+
+// ArmResult is the result of secrets.arm and secrets.disarm
+type ArmResult struct {
+	Armed     bool      `json:"armed"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero if armed until disarmed
 }
 
 // AuditParams are parameters for secrets.audit
 type AuditParams struct {
-	Tail int `json:"tail"` // Number of recent entries to return (0 = all)
+	Tail int    `json:"tail"`           // Number of recent entries to return (0 = all)
+	Role string `json:"role,omitempty"` // Viewer role: "full" (default) or "restricted"
+
+	// Filters; the zero value of each matches every entry
+	Since      time.Time `json:"since,omitempty"`
+	Until      time.Time `json:"until,omitempty"`
+	Action     string    `json:"action,omitempty"`
+	SecretName string    `json:"secret_name,omitempty"`
+	ClientID   string    `json:"client_id,omitempty"`
 }
 
 // AuditResult is the result of secrets.audit
@@ -147,6 +400,9 @@ type AuditEntryJSON struct {
 	LeaseID    string    `json:"lease_id,omitempty"`
 	Details    string    `json:"details,omitempty"`
 	Success    bool      `json:"success"`
+	RemoteAddr string    `json:"remote_addr,omitempty"`
+	ClientCN   string    `json:"client_cn,omitempty"`
+	Trigger    string    `json:"trigger,omitempty"`
 }
 
 // StatusParams are parameters for secrets.status
@@ -156,6 +412,87 @@ type StatusParams struct {
 
 // StatusResult is the result of secrets.status (uses types.DaemonStatus)
 
+// CapabilitiesParams are parameters for secrets.capabilities
+type CapabilitiesParams struct {
+	// No parameters needed
+}
+
+// CapabilitiesResult is the result of secrets.capabilities
+type CapabilitiesResult struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Methods         []string `json:"methods"`
+}
+
+// CompactParams are parameters for secrets.compact
+type CompactParams struct {
+	// No parameters needed
+}
+
+// CompactResult is the result of secrets.compact
+type CompactResult struct {
+	Success   bool   `json:"success"`
+	Reclaimed int    `json:"reclaimed"` // Entries removed from the store
+	Message   string `json:"message"`
+}
+
+// DuplicatesParams are parameters for secrets.duplicates
+type DuplicatesParams struct {
+	// No parameters needed
+}
+
+// DuplicatesResult is the result of secrets.duplicates
+type DuplicatesResult struct {
+	// Groups lists the names of secrets sharing a value, two or more per group
+	Groups [][]string `json:"groups"`
+}
+
+// HistoryParams are parameters for secrets.history
+type HistoryParams struct {
+	Name string `json:"name"`
+}
+
+// HistoryResult is the result of secrets.history
+type HistoryResult struct {
+	Name string `json:"name"`
+	// Versions lists the current value (version 0) and the kept previous
+	// values, newest first, without the values
+	Versions []types.SecretVersion `json:"versions"`
+}
+
+// RollbackParams are parameters for secrets.rollback
+type RollbackParams struct {
+	Name string `json:"name"`
+}
+
+// RollbackResult is the result of secrets.rollback
+type RollbackResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// RecipientsParams are parameters for secrets.recipients
+type RecipientsParams struct {
+	// No parameters needed
+}
+
+// RecipientsResult is the result of secrets.recipients
+type RecipientsResult struct {
+	Recipients []types.Recipient `json:"recipients"`
+}
+
+// RecipientParams are parameters for secrets.addRecipient and
+// secrets.removeRecipient
+type RecipientParams struct {
+	Recipient string `json:"recipient"` // age public key
+}
+
+// RecipientResult is the result of secrets.addRecipient and
+// secrets.removeRecipient
+type RecipientResult struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 // HealthParams are parameters for secrets.health
 type HealthParams struct {
 	// No parameters needed
@@ -171,6 +508,22 @@ type HealthResult struct {
 	Warnings     []HealthWarning `json:"warnings"`
 }
 
+// Probe statuses reported by secrets.probe.
+const (
+	ProbeOK          = "ok"
+	ProbeUnavailable = "unavailable"
+)
+
+// ProbeResult is the result of secrets.probe, a cheap liveness and
+// readiness check for monitoring. Unlike secrets.status and secrets.health
+// it reveals nothing about secrets or leases.
+type ProbeResult struct {
+	Status           string        `json:"status"` // ProbeOK once the store is loaded
+	Uptime           time.Duration `json:"uptime"`
+	StoreLoaded      bool          `json:"store_loaded"`
+	HeartbeatRunning bool          `json:"heartbeat_running"`
+}
+
 // HealthWarning represents a health check warning
 type HealthWarning struct {
 	Type       string    `json:"type"`
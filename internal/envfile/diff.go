@@ -0,0 +1,69 @@
+package envfile
+
+import "sort"
+
+// Diff is the change between an env file and freshly pulled vars. It only
+// holds key names, never values.
+type Diff struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Changed   []string `json:"changed"`
+	Unchanged int      `json:"unchanged"`
+	// Manual are the user section's keys, which WriteMerged keeps and are
+	// not compared
+	Manual []string `json:"manual,omitempty"`
+}
+
+// Empty reports whether writing the pulled vars would change no keys.
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Compare diffs the vars in current, which may be nil for a missing file,
+// against pulled. All key lists are sorted.
+func Compare(current *EnvFile, pulled map[string]string) Diff {
+	d := Diff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	existing := map[string]string{}
+	var manual map[string]string
+	if current != nil {
+		manual = current.UserVars
+		for key, value := range current.Vars {
+			if _, ok := manual[key]; ok {
+				d.Manual = append(d.Manual, key)
+				continue
+			}
+			existing[key] = value
+		}
+	}
+
+	for key, value := range pulled {
+		old, ok := existing[key]
+		switch {
+		case !ok:
+			// A pulled key the user section already sets is not added
+			if _, ok := manual[key]; !ok {
+				d.Added = append(d.Added, key)
+			}
+		case old != value:
+			d.Changed = append(d.Changed, key)
+		default:
+			d.Unchanged++
+		}
+	}
+	for key := range existing {
+		if _, ok := pulled[key]; !ok {
+			d.Removed = append(d.Removed, key)
+		}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+	sort.Strings(d.Manual)
+	return d
+}
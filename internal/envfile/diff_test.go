@@ -0,0 +1,115 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubAdapter pulls a fixed set of vars
+type stubAdapter struct {
+	vars map[string]string
+}
+
+func (a stubAdapter) Pull(project, scope string) (map[string]string, error) {
+	return a.vars, nil
+}
+
+func TestCompare(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), ".env.test")
+	current := map[string]string{
+		"API_KEY":      "old-key",
+		"DATABASE_URL": "postgres://localhost/db",
+		"LEGACY_TOKEN": "tok",
+	}
+	if err := WriteWithTTL(testFile, current, time.Hour, "vercel"); err != nil {
+		t.Fatalf("WriteWithTTL failed: %v", err)
+	}
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	adapter := stubAdapter{vars: map[string]string{
+		"API_KEY":      "new-key",
+		"DATABASE_URL": "postgres://localhost/db",
+		"NEW_FLAG":     "1",
+		"ANALYTICS_ID": "abc",
+	}}
+	pulled, err := adapter.Pull("my-app", "development")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := Compare(envFile, pulled)
+	if want := []string{"ANALYTICS_ID", "NEW_FLAG"}; !reflect.DeepEqual(d.Added, want) {
+		t.Errorf("Added = %v, want %v", d.Added, want)
+	}
+	if want := []string{"LEGACY_TOKEN"}; !reflect.DeepEqual(d.Removed, want) {
+		t.Errorf("Removed = %v, want %v", d.Removed, want)
+	}
+	if want := []string{"API_KEY"}; !reflect.DeepEqual(d.Changed, want) {
+		t.Errorf("Changed = %v, want %v", d.Changed, want)
+	}
+	if d.Unchanged != 1 {
+		t.Errorf("Unchanged = %d, want 1", d.Unchanged)
+	}
+	if d.Empty() {
+		t.Error("Empty() = true for a diff with changes")
+	}
+}
+
+func TestCompare_NoFile(t *testing.T) {
+	d := Compare(nil, map[string]string{"B": "2", "A": "1"})
+	if want := []string{"A", "B"}; !reflect.DeepEqual(d.Added, want) {
+		t.Errorf("Added = %v, want %v", d.Added, want)
+	}
+	if len(d.Removed) != 0 || len(d.Changed) != 0 {
+		t.Errorf("unexpected diff: %+v", d)
+	}
+}
+
+func TestCompare_NoChanges(t *testing.T) {
+	vars := map[string]string{"A": "1"}
+	d := Compare(&EnvFile{Vars: vars}, vars)
+	if !d.Empty() || d.Unchanged != 1 {
+		t.Errorf("expected no changes, got %+v", d)
+	}
+}
+
+func TestCompare_UserSection(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), ".env.test")
+	content := `# secrets-managed: true
+# secrets-ttl: 2024-01-15T10:00:00Z
+API_KEY=old
+
+# secrets-user: variables below this line are kept by 'secrets env --merge'
+DEBUG=true
+LOCAL_URL=http://localhost:3000
+`
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	d := Compare(envFile, map[string]string{"API_KEY": "old", "LOCAL_URL": "https://preview.example.com"})
+	if !d.Empty() {
+		t.Errorf("manual vars should not count as changes, got %+v", d)
+	}
+	if want := []string{"DEBUG", "LOCAL_URL"}; !reflect.DeepEqual(d.Manual, want) {
+		t.Errorf("Manual = %v, want %v", d.Manual, want)
+	}
+}
+
+func TestDiff_NoValues(t *testing.T) {
+	d := Compare(&EnvFile{Vars: map[string]string{"API_KEY": "old-secret"}}, map[string]string{"API_KEY": "new-secret"})
+	if s := strings.Join(append(append(d.Added, d.Removed...), d.Changed...), ","); strings.Contains(s, "secret") {
+		t.Errorf("diff contains values: %s", s)
+	}
+}
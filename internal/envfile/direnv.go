@@ -0,0 +1,68 @@
+package envfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// direnvExpiresPrefix precedes the expiry comment above the dotenv line
+const direnvExpiresPrefix = "# secrets-env-expires: "
+
+// WriteDirenv makes the .envrc at path load envFile through direnv's
+// "dotenv" directive, with a comment recording when the env file expires.
+// The .envrc is created if absent. Other lines are preserved, and repeat
+// runs update the expiry comment rather than adding another dotenv line.
+func WriteDirenv(path, envFile string, expiresAt time.Time) error {
+	var lines []string
+	perm := os.FileMode(0644)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if trimmed := strings.TrimSuffix(string(data), "\n"); trimmed != "" {
+			lines = strings.Split(trimmed, "\n")
+		}
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("read file: %w", err)
+	}
+
+	directive := "dotenv " + envFile
+	comment := direnvExpiresPrefix + expiresAt.Format(time.RFC3339)
+
+	found := false
+	for i, line := range lines {
+		if strings.TrimSpace(line) != directive {
+			continue
+		}
+		found = true
+		if i > 0 && strings.HasPrefix(strings.TrimSpace(lines[i-1]), direnvExpiresPrefix) {
+			lines[i-1] = comment
+		} else {
+			lines = append(lines[:i], append([]string{comment}, lines[i:]...)...)
+		}
+		break
+	}
+	if !found {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, comment, directive)
+	}
+
+	// Write to a temp file and rename so direnv never loads a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), perm); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteDirenv_Create(t *testing.T) {
+	envrc := filepath.Join(t.TempDir(), ".envrc")
+	expiresAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	if err := WriteDirenv(envrc, ".env.local", expiresAt); err != nil {
+		t.Fatalf("WriteDirenv failed: %v", err)
+	}
+
+	got, err := os.ReadFile(envrc)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := "# secrets-env-expires: 2024-01-15T10:00:00Z\ndotenv .env.local\n"
+	if string(got) != want {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteDirenv_Idempotent(t *testing.T) {
+	envrc := filepath.Join(t.TempDir(), ".envrc")
+	first := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	if err := WriteDirenv(envrc, ".env.local", first); err != nil {
+		t.Fatalf("WriteDirenv failed: %v", err)
+	}
+	if err := WriteDirenv(envrc, ".env.local", second); err != nil {
+		t.Fatalf("second WriteDirenv failed: %v", err)
+	}
+
+	got, err := os.ReadFile(envrc)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if n := strings.Count(string(got), "dotenv .env.local"); n != 1 {
+		t.Errorf("expected one dotenv line, got %d:\n%s", n, got)
+	}
+	want := "# secrets-env-expires: 2024-01-15T11:00:00Z\ndotenv .env.local\n"
+	if string(got) != want {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteDirenv_PreservesExisting(t *testing.T) {
+	envrc := filepath.Join(t.TempDir(), ".envrc")
+	existing := "use nix\nPATH_add bin\n"
+	if err := os.WriteFile(envrc, []byte(existing), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	expiresAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if err := WriteDirenv(envrc, ".env.local", expiresAt); err != nil {
+			t.Fatalf("WriteDirenv failed: %v", err)
+		}
+	}
+
+	got, err := os.ReadFile(envrc)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := existing + "\n# secrets-env-expires: 2024-01-15T10:00:00Z\ndotenv .env.local\n"
+	if string(got) != want {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+	}
+
+	info, err := os.Stat(envrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600 to be preserved, got %o", info.Mode().Perm())
+	}
+}
+
+func TestWriteDirenv_ExistingDirective(t *testing.T) {
+	envrc := filepath.Join(t.TempDir(), ".envrc")
+	if err := os.WriteFile(envrc, []byte("use nix\ndotenv .env.local\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	expiresAt := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	if err := WriteDirenv(envrc, ".env.local", expiresAt); err != nil {
+		t.Fatalf("WriteDirenv failed: %v", err)
+	}
+
+	got, err := os.ReadFile(envrc)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := "use nix\n# secrets-env-expires: 2024-01-15T10:00:00Z\ndotenv .env.local\n"
+	if string(got) != want {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package envfile
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/store"
+)
+
+// EncryptedSuffix marks an env file encrypted with age, such as
+// .env.local.age, which is safe to commit.
+const EncryptedSuffix = ".age"
+
+// skipDirs are not searched by FindEncrypted.
+var skipDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true}
+
+// ReEncrypt decrypts the age-encrypted env file at path with identity and
+// replaces it with a copy encrypted to recipients, keeping its permissions.
+// Identities that are not among recipients can no longer read the file.
+func ReEncrypt(path string, identity age.Identity, recipients []age.Recipient) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients to encrypt %s to", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := store.Decrypt(ciphertext, identity)
+	if err != nil {
+		return fmt.Errorf("decrypt %s: %w", path, err)
+	}
+	ciphertext, err = store.Encrypt(plaintext, recipients...)
+	if err != nil {
+		return fmt.Errorf("encrypt %s: %w", path, err)
+	}
+
+	// Write to a temp file and rename so a failed write never leaves the
+	// file unreadable to everyone
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, ciphertext, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// FindEncrypted returns the encrypted env files under root: files named
+// .env* that end in EncryptedSuffix. Dependency and VCS directories are
+// skipped.
+func FindEncrypted(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && IsEncrypted(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// IsEncrypted reports whether path is named like an encrypted env file.
+func IsEncrypted(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".env") && strings.HasSuffix(name, EncryptedSuffix)
+}
@@ -0,0 +1,127 @@
+package envfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func writeEncrypted(t *testing.T, path, content string, recipients ...age.Recipient) {
+	t.Helper()
+	ciphertext, err := store.Encrypt([]byte(content), recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, ciphertext, 0640); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReEncrypt(t *testing.T) {
+	oldKey, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".env.local.age")
+	content := "API_KEY=sk_test_12345\n"
+	writeEncrypted(t, path, content, oldKey.Recipient())
+
+	if err := ReEncrypt(path, oldKey, []age.Recipient{newKey.Recipient()}); err != nil {
+		t.Fatalf("ReEncrypt failed: %v", err)
+	}
+
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := store.Decrypt(ciphertext, newKey)
+	if err != nil {
+		t.Fatalf("new identity cannot decrypt: %v", err)
+	}
+	if string(plaintext) != content {
+		t.Errorf("content = %q, want %q", plaintext, content)
+	}
+	if _, err := store.Decrypt(ciphertext, oldKey); !errors.Is(err, types.ErrDecryptionFailed) {
+		t.Errorf("old identity decrypt error = %v, want ErrDecryptionFailed", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %o, want 640", info.Mode().Perm())
+	}
+}
+
+func TestReEncrypt_WrongIdentity(t *testing.T) {
+	owner, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stranger, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".env.age")
+	writeEncrypted(t, path, "A=1\n", owner.Recipient())
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReEncrypt(path, stranger, []age.Recipient{stranger.Recipient()}); !errors.Is(err, types.ErrDecryptionFailed) {
+		t.Fatalf("ReEncrypt error = %v, want ErrDecryptionFailed", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Error("file was modified after a failed re-encrypt")
+	}
+}
+
+func TestFindEncrypted(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		".env.local.age",
+		"apps/web/.env.production.age",
+		"apps/web/.env.local",       // not encrypted
+		"identity.age",              // not an env file
+		"node_modules/pkg/.env.age", // skipped directory
+		".git/.env.age",             // skipped directory
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := FindEncrypted(root)
+	if err != nil {
+		t.Fatalf("FindEncrypted failed: %v", err)
+	}
+	want := []string{
+		filepath.Join(root, ".env.local.age"),
+		filepath.Join(root, "apps", "web", ".env.production.age"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindEncrypted = %v, want %v", got, want)
+	}
+}
@@ -2,8 +2,10 @@ package envfile
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -14,16 +16,95 @@ type EnvFile struct {
 	ExpiresAt time.Time
 	Source    string
 	Vars      map[string]string
+
+	// Provenance maps each variable to the source it was pulled from.
+	// Only populated for files written from more than one source.
+	Provenance map[string]string
+
+	// UserVars are the variables below the user section header, which
+	// WriteMerged never replaces. They are also in Vars. UserLines holds
+	// the section's lines verbatim, comments included.
+	UserVars  map[string]string
+	UserLines []string
 }
 
 const (
 	managedHeader = "# secrets-managed: true"
 	ttlPrefix     = "# secrets-ttl: "
 	sourcePrefix  = "# secrets-source: "
+
+	// varSourcePrefix precedes "KEY source" provenance lines
+	varSourcePrefix = "# secrets-var-source: "
+
+	// userHeader starts the section of hand-written variables kept by
+	// WriteMerged
+	userHeader = "# secrets-user: variables below this line are kept by 'secrets env --merge'"
+	userPrefix = "# secrets-user:"
 )
 
 // WriteWithTTL writes an .env file with TTL metadata header
 func WriteWithTTL(path string, vars map[string]string, ttl time.Duration, source string) error {
+	return WriteWithProvenance(path, vars, nil, ttl, source)
+}
+
+// WriteWithProvenance writes an .env file like WriteWithTTL, and also records
+// which source each variable came from. provenance maps variable names to a
+// source label; variables without an entry get no provenance line.
+func WriteWithProvenance(path string, vars, provenance map[string]string, ttl time.Duration, source string) error {
+	return write(path, vars, provenance, ttl, source, nil)
+}
+
+// WriteMerged updates the env file at path like WriteWithProvenance, but
+// keeps what a developer added by hand. Freshly pulled vars replace the
+// managed ones, and managed vars that were not pulled again are kept. The
+// user section below the managed vars is kept verbatim and wins over
+// pulled vars of the same name. If path does not exist, it is written like
+// WriteWithProvenance, followed by an empty user section.
+func WriteMerged(path string, vars, provenance map[string]string, ttl time.Duration, source string) error {
+	existing, err := Read(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		existing = &EnvFile{}
+	}
+
+	merged := make(map[string]string, len(existing.Vars)+len(vars))
+	mergedProvenance := make(map[string]string)
+	for key, value := range existing.Vars {
+		if _, manual := existing.UserVars[key]; manual {
+			continue
+		}
+		merged[key] = value
+		if src, ok := existing.Provenance[key]; ok {
+			mergedProvenance[key] = src
+		}
+	}
+	for key, value := range vars {
+		if _, manual := existing.UserVars[key]; manual {
+			continue
+		}
+		merged[key] = value
+		delete(mergedProvenance, key)
+		if src, ok := provenance[key]; ok {
+			mergedProvenance[key] = src
+		}
+	}
+	if len(mergedProvenance) == 0 {
+		mergedProvenance = nil
+	}
+
+	// Always write the user section header so there is a place for manual vars
+	user := existing.UserLines
+	if user == nil {
+		user = []string{}
+	}
+	return write(path, merged, mergedProvenance, ttl, source, user)
+}
+
+// write writes the managed header and vars, then, unless user is nil, the
+// user section header and the user lines verbatim.
+func write(path string, vars, provenance map[string]string, ttl time.Duration, source string, user []string) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("create file: %w", err)
@@ -46,16 +127,105 @@ func WriteWithTTL(path string, vars map[string]string, ttl time.Duration, source
 	}
 
 	// Write environment variables
-	for key, value := range vars {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if from, ok := provenance[key]; ok {
+			if _, err := fmt.Fprintf(f, "%s%s %s\n", varSourcePrefix, key, from); err != nil {
+				return fmt.Errorf("write provenance for %s: %w", key, err)
+			}
+		}
+		value := vars[key]
 		if _, err := fmt.Fprintf(f, "%s=%s\n", key, value); err != nil {
 			return fmt.Errorf("write var %s: %w", key, err)
 		}
 	}
 
+	if user != nil {
+		if _, err := fmt.Fprintf(f, "\n%s\n", userHeader); err != nil {
+			return fmt.Errorf("write user header: %w", err)
+		}
+		for _, line := range user {
+			if _, err := fmt.Fprintln(f, line); err != nil {
+				return fmt.Errorf("write user section: %w", err)
+			}
+		}
+	}
+
 	return nil
 }
 
-// Read parses an .env file including TTL metadata
+// WriteManagedSection updates vars in an existing managed .env file in place.
+// Variables already in the file keep their position; new ones are appended.
+// Header lines (including the TTL) and all other variables are preserved.
+// The file must have been written by WriteWithTTL.
+func WriteManagedSection(path string, vars map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read file: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != managedHeader {
+		return fmt.Errorf("%s is not a secrets-managed env file", path)
+	}
+
+	written := make(map[string]bool, len(vars))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		rawKey, _, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			continue
+		}
+		key, err := parseKey(rawKey)
+		if err != nil {
+			continue
+		}
+		if value, update := vars[key]; update {
+			lines[i] = fmt.Sprintf("%s=%s", key, value)
+			written[key] = true
+		}
+	}
+
+	// Append vars not yet in the file, in a stable order
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		if !written[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s=%s", key, vars[key]))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat file: %w", err)
+	}
+
+	// Write to a temp file and rename so readers never see a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace file: %w", err)
+	}
+
+	return nil
+}
+
+// Read parses an .env file including TTL metadata. Lines other than
+// comments and KEY=value pairs are an error.
 func Read(path string) (*EnvFile, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -69,9 +239,22 @@ func Read(path string) (*EnvFile, error) {
 	}
 
 	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	inUser := false
 	for scanner.Scan() {
+		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 
+		// Everything below the user header is recorded verbatim
+		if !inUser && strings.HasPrefix(line, userPrefix) {
+			inUser = true
+			envFile.UserVars = make(map[string]string)
+			continue
+		}
+		if inUser {
+			envFile.UserLines = append(envFile.UserLines, scanner.Text())
+		}
+
 		// Skip empty lines
 		if line == "" {
 			continue
@@ -94,18 +277,43 @@ func Read(path string) (*EnvFile, error) {
 			continue
 		}
 
+		// Parse per-variable provenance
+		if strings.HasPrefix(line, varSourcePrefix) {
+			parts := strings.SplitN(strings.TrimPrefix(line, varSourcePrefix), " ", 2)
+			if len(parts) == 2 {
+				if envFile.Provenance == nil {
+					envFile.Provenance = make(map[string]string)
+				}
+				envFile.Provenance[parts[0]] = parts[1]
+			}
+			continue
+		}
+
 		// Skip other comment lines
 		if strings.HasPrefix(line, "#") {
 			continue
 		}
 
 		// Parse key=value pairs
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
-			envFile.Vars[parts[0]] = parts[1]
+		rawKey, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNum)
+		}
+		key, err := parseKey(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		envFile.Vars[key] = value
+		if inUser {
+			envFile.UserVars[key] = value
 		}
 	}
 
+	// Trailing blank lines are not part of the section
+	for n := len(envFile.UserLines); n > 0 && strings.TrimSpace(envFile.UserLines[n-1]) == ""; n-- {
+		envFile.UserLines = envFile.UserLines[:n-1]
+	}
+
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("scan file: %w", err)
 	}
@@ -113,6 +321,30 @@ func Read(path string) (*EnvFile, error) {
 	return envFile, nil
 }
 
+// parseKey returns the variable name from the left of "=", without
+// surrounding whitespace or matching quotes. Besides letters, digits and
+// underscores, names may contain dots and dashes ("APP.DB.URL",
+// "app-db-url"), as some frameworks use. Colons are rejected, so a store
+// reference like "prod::db" is never mistaken for a variable name.
+func parseKey(raw string) (string, error) {
+	key := strings.TrimSpace(raw)
+	if len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0] {
+		key = key[1 : len(key)-1]
+	}
+	if key == "" {
+		return "", fmt.Errorf("empty variable name")
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '.' || r == '-'):
+		default:
+			return "", fmt.Errorf("invalid variable name %q", key)
+		}
+	}
+	return key, nil
+}
+
 // IsExpired checks if a file's TTL has passed
 func IsExpired(path string) (bool, error) {
 	envFile, err := Read(path)
@@ -332,6 +332,279 @@ CONNECTION_STRING=Server=localhost;User=admin;Password=p@ss=w0rd
 	}
 }
 
+func TestRead_DottedAndQuotedKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, ".env.test")
+
+	content := `APP.DB.URL=postgres://localhost/db
+app-db-url=postgres://localhost/other
+"QUOTED.KEY"=one
+'single-quoted'=two
+  SPACED_KEY = three
+`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"APP.DB.URL":    "postgres://localhost/db",
+		"app-db-url":    "postgres://localhost/other",
+		"QUOTED.KEY":    "one",
+		"single-quoted": "two",
+		"SPACED_KEY":    " three",
+	}
+	for key, want := range expected {
+		if got, ok := envFile.Vars[key]; !ok || got != want {
+			t.Errorf("Vars[%q] = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+	if len(envFile.Vars) != len(expected) {
+		t.Errorf("Expected %d vars, got %d: %v", len(expected), len(envFile.Vars), envFile.Vars)
+	}
+}
+
+func TestRead_InvalidLines(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing equals":   "API_KEY=ok\nNOT_A_PAIR\n",
+		"namespaced ref":   "prod::db=value\n",
+		"empty key":        "=value\n",
+		"leading digit":    "1KEY=value\n",
+		"unbalanced quote": "\"KEY=value\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), ".env.test")
+			if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+			if _, err := Read(testFile); err == nil {
+				t.Errorf("Read(%q) succeeded, want an error", content)
+			}
+		})
+	}
+}
+
+func TestWriteWithProvenance(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, ".env.test")
+
+	vars := map[string]string{
+		"API_KEY":   "from-vercel",
+		"LOCAL_URL": "http://localhost:3000",
+	}
+	provenance := map[string]string{
+		"API_KEY":   "vercel:my-app",
+		"LOCAL_URL": "dotenv:.env.shared",
+	}
+
+	if err := WriteWithProvenance(testFile, vars, provenance, time.Hour, "vercel:my-app,dotenv:.env.shared"); err != nil {
+		t.Fatalf("WriteWithProvenance failed: %v", err)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if !contains(string(content), "# secrets-var-source: API_KEY vercel:my-app\nAPI_KEY=from-vercel") {
+		t.Errorf("provenance line should precede its variable, got:\n%s", content)
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if envFile.Source != "vercel:my-app,dotenv:.env.shared" {
+		t.Errorf("Expected combined source, got %s", envFile.Source)
+	}
+	if len(envFile.Vars) != 2 {
+		t.Errorf("Expected 2 vars, got %d", len(envFile.Vars))
+	}
+	for key, want := range provenance {
+		if got := envFile.Provenance[key]; got != want {
+			t.Errorf("Provenance[%s] = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestRead_NoProvenance(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, ".env.test")
+
+	if err := WriteWithTTL(testFile, map[string]string{"KEY": "value"}, time.Hour, "vercel"); err != nil {
+		t.Fatalf("WriteWithTTL failed: %v", err)
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if envFile.Provenance != nil {
+		t.Errorf("Expected no provenance, got %v", envFile.Provenance)
+	}
+}
+
+func TestWriteManagedSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, ".env.test")
+
+	content := `# secrets-managed: true
+# secrets-ttl: 2024-01-15T10:00:00Z
+# secrets-source: vercel
+API_KEY=old
+DATABASE_URL=postgres://localhost/db
+`
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if err := WriteManagedSection(testFile, map[string]string{"API_KEY": "new", "ADDED": "1"}); err != nil {
+		t.Fatalf("WriteManagedSection failed: %v", err)
+	}
+
+	got, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	want := `# secrets-managed: true
+# secrets-ttl: 2024-01-15T10:00:00Z
+# secrets-source: vercel
+API_KEY=new
+DATABASE_URL=postgres://localhost/db
+ADDED=1
+`
+	if string(got) != want {
+		t.Errorf("unexpected content:\n%s\nwant:\n%s", got, want)
+	}
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600 to be preserved, got %o", info.Mode().Perm())
+	}
+}
+
+func TestWriteManagedSection_Unmanaged(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, ".env")
+	if err := os.WriteFile(testFile, []byte("API_KEY=hand-written\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if err := WriteManagedSection(testFile, map[string]string{"API_KEY": "new"}); err == nil {
+		t.Error("expected error for unmanaged file")
+	}
+
+	got, _ := os.ReadFile(testFile)
+	if string(got) != "API_KEY=hand-written\n" {
+		t.Errorf("unmanaged file was modified: %q", got)
+	}
+}
+
+func TestWriteMerged(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, ".env.test")
+
+	content := `# secrets-managed: true
+# secrets-ttl: 2024-01-15T10:00:00Z
+# secrets-source: vercel
+API_KEY=old
+DATABASE_URL=postgres://localhost/db
+HAND_ADDED=managed-by-hand
+
+# secrets-user: variables below this line are kept by 'secrets env --merge'
+# local overrides
+DEBUG=true
+API_KEY=my-own-key
+`
+	if err := os.WriteFile(testFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	pulled := map[string]string{
+		"API_KEY":      "new",
+		"DATABASE_URL": "postgres://prod/db",
+		"ADDED":        "1",
+	}
+	if err := WriteMerged(testFile, pulled, nil, time.Hour, "vercel"); err != nil {
+		t.Fatalf("WriteMerged failed: %v", err)
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if time.Until(envFile.ExpiresAt) < 59*time.Minute {
+		t.Errorf("TTL header not rewritten, expires at %v", envFile.ExpiresAt)
+	}
+
+	want := map[string]string{
+		"API_KEY":      "my-own-key",
+		"DATABASE_URL": "postgres://prod/db",
+		"HAND_ADDED":   "managed-by-hand",
+		"ADDED":        "1",
+		"DEBUG":        "true",
+	}
+	if len(envFile.Vars) != len(want) {
+		t.Errorf("Expected %d vars, got %v", len(want), envFile.Vars)
+	}
+	for key, value := range want {
+		if got := envFile.Vars[key]; got != value {
+			t.Errorf("Vars[%s] = %q, want %q", key, got, value)
+		}
+	}
+
+	got, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if !contains(string(got), "# secrets-user: variables below this line are kept by 'secrets env --merge'\n# local overrides\nDEBUG=true\nAPI_KEY=my-own-key\n") {
+		t.Errorf("user section not preserved verbatim:\n%s", got)
+	}
+
+	// A second merge with fewer vars keeps everything and changes nothing
+	if err := WriteMerged(testFile, map[string]string{"ADDED": "2"}, nil, time.Hour, "vercel"); err != nil {
+		t.Fatalf("second WriteMerged failed: %v", err)
+	}
+	envFile, err = Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	want["ADDED"] = "2"
+	for key, value := range want {
+		if got := envFile.Vars[key]; got != value {
+			t.Errorf("after second merge, Vars[%s] = %q, want %q", key, got, value)
+		}
+	}
+	if len(envFile.UserLines) != 3 {
+		t.Errorf("Expected 3 user lines, got %q", envFile.UserLines)
+	}
+}
+
+func TestWriteMerged_NoFile(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), ".env.test")
+
+	if err := WriteMerged(testFile, map[string]string{"KEY": "value"}, nil, time.Hour, "vercel"); err != nil {
+		t.Fatalf("WriteMerged failed: %v", err)
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if envFile.Vars["KEY"] != "value" {
+		t.Errorf("Expected KEY=value, got %v", envFile.Vars)
+	}
+	if envFile.UserVars == nil {
+		t.Error("Expected an empty user section to be written")
+	}
+}
+
 // Helper function
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && containsAny(s, substr))
@@ -0,0 +1,102 @@
+package envfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joelhooks/agent-secrets/internal/output"
+)
+
+// FormatShell renders vars as export lines for eval, sorted by name, e.g.
+//
+//	export API_KEY='it'\''s'
+//
+// Names that are not valid shell identifiers are rejected rather than
+// written, since eval would run whatever follows them.
+func FormatShell(vars map[string]string) (string, error) {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		if !isShellName(key) {
+			return "", fmt.Errorf("cannot export %q: not a valid shell variable name", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(output.BuildEnvExport(key, vars[key]) + "\n")
+	}
+	return b.String(), nil
+}
+
+// FormatEnv renders vars as KEY="value" lines, sorted by name, with each
+// value double-quoted and escaped so that Unquote returns it unchanged,
+// including newlines and surrounding whitespace. Names that Read would
+// reject are an error.
+func FormatEnv(vars map[string]string) (string, error) {
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		if parsed, err := parseKey(key); err != nil || parsed != key {
+			return "", fmt.Errorf("cannot export %q: not a valid variable name", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + "=" + strconv.Quote(vars[key]) + "\n")
+	}
+	return b.String(), nil
+}
+
+// Unquote returns a value read from an env file without its surrounding
+// quotes. Double-quoted values have Go escapes like \n decoded, as written
+// by FormatEnv; single-quoted values are taken literally. Other values, and
+// double-quoted ones with invalid escapes, are returned as is.
+func Unquote(value string) string {
+	if len(value) < 2 || value[len(value)-1] != value[0] {
+		return value
+	}
+	switch value[0] {
+	case '"':
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	case '\'':
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// FormatJSON renders vars as an indented JSON object of names to values.
+func FormatJSON(vars map[string]string) ([]byte, error) {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// isShellName reports whether name is a POSIX shell variable name.
+func isShellName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
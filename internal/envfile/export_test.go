@@ -0,0 +1,144 @@
+package envfile
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatShell(t *testing.T) {
+	vars := map[string]string{
+		"PLAIN":  "value",
+		"QUOTED": `it's "quoted"`,
+		"SHELLY": "$(echo pwned) `id` $HOME; rm -rf /",
+		"MULTI":  "line one\nline two",
+	}
+
+	got, err := FormatShell(vars)
+	if err != nil {
+		t.Fatalf("FormatShell failed: %v", err)
+	}
+	want := "export MULTI='line one\nline two'\n" +
+		"export PLAIN='value'\n" +
+		`export QUOTED='it'\''s "quoted"'` + "\n" +
+		"export SHELLY='$(echo pwned) `id` $HOME; rm -rf /'\n"
+	if got != want {
+		t.Errorf("unexpected output:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+
+	// Evaluating the output restores every value verbatim
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	script := filepath.Join(t.TempDir(), "check.sh")
+	body := got + `printf '%s\0' "$MULTI" "$PLAIN" "$QUOTED" "$SHELLY"`
+	if err := os.WriteFile(script, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(sh, script).Output()
+	if err != nil {
+		t.Fatalf("sh failed: %v", err)
+	}
+	wantOut := vars["MULTI"] + "\x00" + vars["PLAIN"] + "\x00" + vars["QUOTED"] + "\x00" + vars["SHELLY"] + "\x00"
+	if string(out) != wantOut {
+		t.Errorf("values changed by eval:\nwant %q\ngot  %q", wantOut, out)
+	}
+}
+
+func TestFormatShell_InvalidName(t *testing.T) {
+	for _, name := range []string{"APP.DB.URL", "app-db-url", "X;id", "1ST", ""} {
+		if _, err := FormatShell(map[string]string{name: "value"}); err == nil {
+			t.Errorf("expected error exporting %q", name)
+		}
+	}
+}
+
+func TestFormatEnv_RoundTrip(t *testing.T) {
+	vars := map[string]string{
+		"PLAIN":      "value",
+		"QUOTED":     `it's "quoted"`,
+		"MULTI":      "line one\nline two\r\n",
+		"SPACED":     "  padded \t",
+		"HASH":       "# not a comment",
+		"APP.DB.URL": "postgres://localhost/db?a=b",
+		"BYTES":      "\xff\x00",
+	}
+
+	got, err := FormatEnv(vars)
+	if err != nil {
+		t.Fatalf("FormatEnv failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(got), 0600); err != nil {
+		t.Fatal(err)
+	}
+	envFile, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v\n%s", err, got)
+	}
+	if len(envFile.Vars) != len(vars) {
+		t.Fatalf("read %d vars, want %d:\n%s", len(envFile.Vars), len(vars), got)
+	}
+	for key, want := range vars {
+		if value := Unquote(envFile.Vars[key]); value != want {
+			t.Errorf("%s = %q, want %q", key, value, want)
+		}
+	}
+}
+
+func TestFormatEnv_InvalidName(t *testing.T) {
+	for _, name := range []string{"prod::db", "X=Y", `"X"`, "1ST", ""} {
+		if _, err := FormatEnv(map[string]string{name: "value"}); err == nil {
+			t.Errorf("expected error exporting %q", name)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := map[string]string{
+		`plain`:       "plain",
+		`"a\nb"`:      "a\nb",
+		`'a\nb'`:      `a\nb`,
+		`"unbalanced`: `"unbalanced`,
+		`"bad \q"`:    `"bad \q"`,
+		`"`:           `"`,
+		`'it'\''s'`:   `it'\''s`,
+		`"mixed'`:     `"mixed'`,
+		`""`:          "",
+		`''`:          "",
+		`'quoted'`:    "quoted",
+		`a`:           "a",
+		``:            "",
+	}
+	for in, want := range tests {
+		if got := Unquote(in); got != want {
+			t.Errorf("Unquote(%s) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFormatJSON(t *testing.T) {
+	vars := map[string]string{
+		"API_KEY":    `sk-"quoted"\path`,
+		"APP.DB.URL": "postgres://u:p@host/db?x=1&y=<2>",
+	}
+
+	data, err := FormatJSON(vars)
+	if err != nil {
+		t.Fatalf("FormatJSON failed: %v", err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if len(got) != 2 || got["API_KEY"] != vars["API_KEY"] || got["APP.DB.URL"] != vars["APP.DB.URL"] {
+		t.Errorf("round trip mismatch: %v", got)
+	}
+
+	if data, _ := FormatJSON(nil); string(data) != "{}\n" {
+		t.Errorf("expected an empty object, got %q", data)
+	}
+}
@@ -0,0 +1,90 @@
+package envfile
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Parse reads a plain .env file, such as one kept by hand or written by a
+// provider CLI, and returns its variables. Names follow the rules Read uses
+// and values are unquoted with Unquote, so every adapter reading env files
+// agrees with what FormatEnv writes.
+//
+// Section markers take the form "# [name]". If section is set, only
+// variables outside any section and those under a matching marker are
+// returned, and a missing section is an error. If section is empty, every
+// variable in the file is returned regardless of markers.
+func Parse(path, section string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	current := ""
+	foundSection := false
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip empty lines
+		if line == "" {
+			continue
+		}
+
+		// Section markers and comments
+		if strings.HasPrefix(line, "#") {
+			if name, ok := parseSectionMarker(line); ok {
+				current = name
+				if name == section {
+					foundSection = true
+				}
+			}
+			continue
+		}
+
+		// Skip variables belonging to other sections
+		if section != "" && current != "" && current != section {
+			continue
+		}
+
+		rawKey, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid env line %d: %q (expected KEY=value)", lineNum, line)
+		}
+		key, err := parseKey(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env line %d: %w", lineNum, err)
+		}
+		vars[key] = Unquote(strings.TrimSpace(value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading env file: %w", err)
+	}
+
+	if section != "" && !foundSection {
+		return nil, fmt.Errorf("section %q not found in %s", section, path)
+	}
+
+	return vars, nil
+}
+
+// parseSectionMarker extracts the section name from a "# [name]" comment.
+func parseSectionMarker(line string) (string, bool) {
+	body := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	if len(body) < 3 || body[0] != '[' || body[len(body)-1] != ']' {
+		return "", false
+	}
+	name := strings.TrimSpace(body[1 : len(body)-1])
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
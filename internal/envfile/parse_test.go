@@ -0,0 +1,175 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "basic key-value pairs",
+			content: `DATABASE_URL=postgresql://localhost/mydb
+API_KEY=abc123
+PORT=3000`,
+			want: map[string]string{
+				"DATABASE_URL": "postgresql://localhost/mydb",
+				"API_KEY":      "abc123",
+				"PORT":         "3000",
+			},
+			wantErr: false,
+		},
+		{
+			name: "with double quotes",
+			content: `NAME="My Application"
+DESCRIPTION="A test app"`,
+			want: map[string]string{
+				"NAME":        "My Application",
+				"DESCRIPTION": "A test app",
+			},
+			wantErr: false,
+		},
+		{
+			name: "with single quotes",
+			content: `PATH='/usr/local/bin'
+HOME='/home/user'`,
+			want: map[string]string{
+				"PATH": "/usr/local/bin",
+				"HOME": "/home/user",
+			},
+			wantErr: false,
+		},
+		{
+			name: "with comments and empty lines",
+			content: `# This is a comment
+DATABASE_URL=postgresql://localhost/mydb
+
+# Another comment
+API_KEY=abc123
+
+`,
+			want: map[string]string{
+				"DATABASE_URL": "postgresql://localhost/mydb",
+				"API_KEY":      "abc123",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty file",
+			content: `
+# Just comments
+`,
+			want:    map[string]string{},
+			wantErr: false,
+		},
+		{
+			name: "values with equals signs",
+			content: `BASE64_TOKEN=dGVzdD0xMjM=
+COMPLEX=key=value=pair`,
+			want: map[string]string{
+				"BASE64_TOKEN": "dGVzdD0xMjM=",
+				"COMPLEX":      "key=value=pair",
+			},
+			wantErr: false,
+		},
+		{
+			name: "escapes in double quotes",
+			content: `CERT="line1\nline2"
+RAW='line1\nline2'`,
+			want: map[string]string{
+				"CERT": "line1\nline2",
+				"RAW":  `line1\nline2`,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "invalid format - no equals",
+			content: `INVALID LINE WITHOUT EQUALS`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			content: `prod::db=value`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a temporary file
+			tmpDir := t.TempDir()
+			tmpFile := filepath.Join(tmpDir, "test.env")
+			if err := os.WriteFile(tmpFile, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("failed to write temp file: %v", err)
+			}
+
+			// Parse the file
+			got, err := Parse(tmpFile, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if len(got) != len(tt.want) {
+					t.Errorf("Parse() got %d entries, want %d", len(got), len(tt.want))
+				}
+				for k, v := range tt.want {
+					if got[k] != v {
+						t.Errorf("Parse() key %q: got %q, want %q", k, got[k], v)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestParse_Sections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "SHARED=1\n# [production]\nDB=prod\n# [development]\nDB=dev\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(path, "production")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != 2 || got["SHARED"] != "1" || got["DB"] != "prod" {
+		t.Errorf("Parse(production) = %v, want SHARED=1 DB=prod", got)
+	}
+
+	if _, err := Parse(path, "staging"); err == nil {
+		t.Error("expected error for a missing section")
+	}
+}
+
+func TestParseSectionMarker(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{"# [production]", "production", true},
+		{"#[dev]", "dev", true},
+		{"# [ spaced ]", "spaced", true},
+		{"# []", "", false},
+		{"# plain comment", "", false},
+		{"# [unterminated", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseSectionMarker(tt.line)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseSectionMarker(%q) = (%q, %v), want (%q, %v)", tt.line, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
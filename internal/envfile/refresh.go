@@ -0,0 +1,105 @@
+package envfile
+
+import (
+	"context"
+	"time"
+)
+
+// maxRefreshLead caps how long before expiry a Refresher rewrites the file
+const maxRefreshLead = time.Minute
+
+// PullFunc fetches fresh vars and, optionally, the source of each one.
+type PullFunc func() (vars, provenance map[string]string, err error)
+
+// RefreshEvent reports one refresh attempt. On failure Err is set and the
+// file is left as it was.
+type RefreshEvent struct {
+	Path      string
+	VarCount  int
+	ExpiresAt time.Time
+	Err       error
+}
+
+// Refresher keeps an env file from expiring by pulling and rewriting it
+// shortly before its TTL runs out.
+type Refresher struct {
+	path   string
+	pull   PullFunc
+	ttl    time.Duration
+	source string
+	merge  bool
+}
+
+// NewRefresher creates a Refresher that rewrites path with the vars from
+// pull and a fresh TTL header. With merge, files are written with
+// WriteMerged so manual vars survive each refresh.
+func NewRefresher(path string, pull PullFunc, ttl time.Duration, source string, merge bool) *Refresher {
+	return &Refresher{
+		path:   path,
+		pull:   pull,
+		ttl:    ttl,
+		source: source,
+		merge:  merge,
+	}
+}
+
+// Lead returns how long before expiry the file is refreshed: a tenth of the
+// TTL, at most a minute.
+func (r *Refresher) Lead() time.Duration {
+	lead := r.ttl / 10
+	if lead > maxRefreshLead {
+		lead = maxRefreshLead
+	}
+	return lead
+}
+
+// Run refreshes the file until ctx is cancelled, calling onEvent after each
+// attempt. It assumes the file has just been written, so the first refresh
+// happens one TTL minus Lead from now. A failed pull is retried after Lead,
+// while the previous file is still valid.
+func (r *Refresher) Run(ctx context.Context, onEvent func(RefreshEvent)) {
+	wait := r.ttl - r.Lead()
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		event := r.refresh()
+		if onEvent != nil {
+			onEvent(event)
+		}
+
+		wait = r.ttl - r.Lead()
+		if event.Err != nil {
+			wait = r.Lead()
+		}
+	}
+}
+
+// refresh pulls and rewrites the file once.
+func (r *Refresher) refresh() RefreshEvent {
+	event := RefreshEvent{Path: r.path}
+
+	vars, provenance, err := r.pull()
+	if err != nil {
+		event.Err = err
+		return event
+	}
+
+	write := WriteWithProvenance
+	if r.merge {
+		write = WriteMerged
+	}
+	if err := write(r.path, vars, provenance, r.ttl, r.source); err != nil {
+		event.Err = err
+		return event
+	}
+
+	event.VarCount = len(vars)
+	event.ExpiresAt = time.Now().Add(r.ttl)
+	return event
+}
@@ -0,0 +1,149 @@
+package envfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAdapter returns a new value on every pull
+type fakeAdapter struct {
+	mu    sync.Mutex
+	pulls int
+	fail  bool
+}
+
+func (a *fakeAdapter) Pull(project, scope string) (map[string]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pulls++
+	if a.fail {
+		return nil, errors.New("source unavailable")
+	}
+	return map[string]string{"API_KEY": fmt.Sprintf("v%d", a.pulls)}, nil
+}
+
+func (a *fakeAdapter) pull() (map[string]string, map[string]string, error) {
+	vars, err := a.Pull("my-app", "development")
+	return vars, nil, err
+}
+
+func TestRefresher_Run(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), ".env.test")
+	ttl := 50 * time.Millisecond
+	if err := WriteWithTTL(testFile, map[string]string{"API_KEY": "v0"}, ttl, "fake"); err != nil {
+		t.Fatalf("WriteWithTTL failed: %v", err)
+	}
+
+	adapter := &fakeAdapter{}
+	r := NewRefresher(testFile, adapter.pull, ttl, "fake", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []RefreshEvent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, func(e RefreshEvent) {
+			events = append(events, e)
+			if len(events) == 2 {
+				cancel()
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not complete two refreshes")
+	}
+
+	for i, e := range events {
+		if e.Err != nil {
+			t.Errorf("refresh %d failed: %v", i, e.Err)
+		}
+		if e.VarCount != 1 {
+			t.Errorf("refresh %d wrote %d vars, want 1", i, e.VarCount)
+		}
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if envFile.Vars["API_KEY"] != "v2" {
+		t.Errorf("expected the second refresh's value, got %q", envFile.Vars["API_KEY"])
+	}
+	if envFile.Source != "fake" {
+		t.Errorf("expected source header to be kept, got %q", envFile.Source)
+	}
+}
+
+func TestRefresher_PullError(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), ".env.test")
+	ttl := 50 * time.Millisecond
+	if err := WriteWithTTL(testFile, map[string]string{"API_KEY": "v0"}, ttl, "fake"); err != nil {
+		t.Fatalf("WriteWithTTL failed: %v", err)
+	}
+
+	adapter := &fakeAdapter{fail: true}
+	r := NewRefresher(testFile, adapter.pull, ttl, "fake", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events []RefreshEvent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, func(e RefreshEvent) {
+			events = append(events, e)
+			if len(events) == 2 {
+				cancel()
+			}
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not retry the failed pull")
+	}
+
+	for i, e := range events {
+		if e.Err == nil {
+			t.Errorf("refresh %d: expected an error", i)
+		}
+	}
+
+	envFile, err := Read(testFile)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if envFile.Vars["API_KEY"] != "v0" {
+		t.Errorf("failed refresh modified the file: %v", envFile.Vars)
+	}
+}
+
+func TestRefresher_Lead(t *testing.T) {
+	tests := []struct {
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{time.Second, 100 * time.Millisecond},
+		{time.Minute, 6 * time.Second},
+		{time.Hour, time.Minute},
+		{24 * time.Hour, time.Minute},
+	}
+	for _, tt := range tests {
+		r := NewRefresher("", nil, tt.ttl, "", false)
+		if got := r.Lead(); got != tt.want {
+			t.Errorf("Lead() for ttl %v = %v, want %v", tt.ttl, got, tt.want)
+		}
+	}
+}
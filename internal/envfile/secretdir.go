@@ -0,0 +1,210 @@
+package envfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the sidecar file that records the TTL of a secrets
+// directory written by WriteSecretDir.
+const ManifestName = ".secrets-manifest.json"
+
+// ManagedFilesDir is the directory, beside a managed env file, that
+// WriteManagedFile writes file contents to.
+const ManagedFilesDir = ".secrets-files"
+
+// Manifest describes a directory of secret files, one file per secret, in
+// the style of Docker and Compose secrets.
+type Manifest struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Source    string    `json:"source,omitempty"`
+	Files     []string  `json:"files"` // File names relative to the directory
+}
+
+// WriteSecretDir writes each secret to its own file in dir, named after the
+// secret with the value as its only content, and records the TTL in a
+// manifest. Files are created 0600 and the directory 0700. Files from a
+// previous write that are no longer selected are removed.
+func WriteSecretDir(dir string, secrets map[string]string, ttl time.Duration, source string) error {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		if err := validateFileName(name); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	previous, err := ReadManifest(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Write the manifest first so cleanup can find every file even if a
+	// later write fails
+	manifest := Manifest{
+		ExpiresAt: time.Now().Add(ttl),
+		Source:    source,
+		Files:     names,
+	}
+	if err := writeManifest(dir, &manifest); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := writeSecretFile(filepath.Join(dir, name), secrets[name]); err != nil {
+			return fmt.Errorf("write %s: %w", name, err)
+		}
+	}
+
+	if previous != nil {
+		selected := make(map[string]bool, len(names))
+		for _, name := range names {
+			selected[name] = true
+		}
+		for _, name := range previous.Files {
+			if !selected[name] {
+				if err := Wipe(filepath.Join(dir, name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReadManifest reads the manifest of a secrets directory. The error satisfies
+// os.IsNotExist if dir has no manifest.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	for _, name := range manifest.Files {
+		if err := validateFileName(name); err != nil {
+			return nil, fmt.Errorf("parse manifest: %w", err)
+		}
+	}
+	return &manifest, nil
+}
+
+// IsDirExpired checks if a secrets directory's TTL has passed
+func IsDirExpired(dir string) (bool, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return false, err
+	}
+
+	// If no TTL was set, consider it not expired
+	if manifest.ExpiresAt.IsZero() {
+		return false, nil
+	}
+
+	return time.Now().After(manifest.ExpiresAt), nil
+}
+
+// WipeDir removes the secret files listed in dir's manifest and the manifest
+// itself, then the directory if nothing else is left in it.
+func WipeDir(dir string) error {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+
+	for _, name := range manifest.Files {
+		if err := Wipe(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("wipe %s: %w", name, err)
+		}
+	}
+	if err := Wipe(filepath.Join(dir, ManifestName)); err != nil {
+		return err
+	}
+
+	// Leave the directory if it holds anything we did not write
+	_ = os.Remove(dir)
+	return nil
+}
+
+// WriteManagedFile writes content to a file named name in ManagedFilesDir
+// beside the managed env file at envPath, and sets the variable name in
+// the env file to the file's path, for binary values that cannot be
+// inlined. It returns the path written. The file is created 0600 and the
+// directory 0700.
+func WriteManagedFile(envPath, name string, content []byte) (string, error) {
+	if err := validateFileName(name); err != nil {
+		return "", err
+	}
+
+	envPath, err := filepath.Abs(envPath)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(filepath.Dir(envPath), ManagedFilesDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("create %s: %w", dir, err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := writeSecretFile(path, string(content)); err != nil {
+		return "", fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := WriteManagedSection(envPath, map[string]string{name: path}); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func writeManifest(dir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := writeSecretFile(filepath.Join(dir, ManifestName), string(append(data, '\n'))); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// writeSecretFile replaces path with content, readable only by the owner.
+func writeSecretFile(path, content string) error {
+	// Write to a temp file and rename so readers never see a partial file,
+	// and so an existing file with looser permissions is not reused
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0600); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp, 0600); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// validateFileName rejects secret names that cannot be used as a file name
+// inside the secrets directory.
+func validateFileName(name string) error {
+	if name == "" || name == "." || name == ".." || name == ManifestName ||
+		strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return fmt.Errorf("invalid secret file name %q", name)
+	}
+	return nil
+}
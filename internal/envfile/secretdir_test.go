@@ -0,0 +1,210 @@
+package envfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteSecretDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+
+	secrets := map[string]string{
+		"db_password": "hunter2",
+		"api_key":     "sk_test_12345\nwith a second line",
+	}
+	if err := WriteSecretDir(dir, secrets, 1*time.Hour, "prod"); err != nil {
+		t.Fatalf("WriteSecretDir failed: %v", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("directory permissions = %o, want 0700", perm)
+	}
+
+	for name, value := range secrets {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("secret file %s not created: %v", name, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s permissions = %o, want 0600", name, perm)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != value {
+			t.Errorf("%s content = %q, want %q", name, content, value)
+		}
+	}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if manifest.Source != "prod" {
+		t.Errorf("Source = %q, want %q", manifest.Source, "prod")
+	}
+	if len(manifest.Files) != 2 || manifest.Files[0] != "api_key" || manifest.Files[1] != "db_password" {
+		t.Errorf("Files = %v", manifest.Files)
+	}
+	if time.Until(manifest.ExpiresAt) < 59*time.Minute {
+		t.Errorf("ExpiresAt = %v, want about an hour from now", manifest.ExpiresAt)
+	}
+
+	// No temp files are left behind
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 2 secret files and a manifest, got %d entries", len(entries))
+	}
+}
+
+func TestWriteSecretDir_RemovesDeselected(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := WriteSecretDir(dir, map[string]string{"a": "1", "b": "2"}, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSecretDir(dir, map[string]string{"b": "3"}, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Error("expected deselected secret file to be removed")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "3" {
+		t.Errorf("b content = %q, want %q", content, "3")
+	}
+}
+
+func TestWriteSecretDir_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "..", "../escape", "a/b", ManifestName} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := WriteSecretDir(dir, map[string]string{name: "x"}, time.Hour, ""); err == nil {
+				t.Errorf("expected error for name %q", name)
+			}
+		})
+	}
+}
+
+func TestIsDirExpired(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		expected bool
+	}{
+		{"not expired", 1 * time.Hour, false},
+		{"expired", -1 * time.Hour, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := WriteSecretDir(dir, map[string]string{"key": "value"}, tt.ttl, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			expired, err := IsDirExpired(dir)
+			if err != nil {
+				t.Fatalf("IsDirExpired failed: %v", err)
+			}
+			if expired != tt.expected {
+				t.Errorf("IsDirExpired = %v, want %v", expired, tt.expected)
+			}
+		})
+	}
+
+	if _, err := IsDirExpired(t.TempDir()); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error without a manifest, got %v", err)
+	}
+}
+
+func TestWipeDir(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "secrets")
+	if err := WriteSecretDir(dir, map[string]string{"a": "1", "b": "2"}, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WipeDir(dir); err != nil {
+		t.Fatalf("WipeDir failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("expected empty secrets directory to be removed")
+	}
+
+	// Files the manifest doesn't list are kept, along with the directory
+	if err := WriteSecretDir(dir, map[string]string{"a": "1"}, time.Hour, ""); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "README")
+	if err := os.WriteFile(other, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WipeDir(dir); err != nil {
+		t.Fatalf("WipeDir failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Error("expected secret file to be removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expected unrelated file to be kept: %v", err)
+	}
+}
+
+func TestWriteManagedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	envPath := filepath.Join(tmpDir, ".env.local")
+	content := "# secrets-managed: true\n# secrets-ttl: 2024-01-15T10:00:00Z\nAPI_KEY=abc\n"
+	if err := os.WriteFile(envPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key := []byte{0x00, 0xff, '\n', 'k', 'e', 'y', 0x80}
+	path, err := WriteManagedFile(envPath, "TLS_KEY", key)
+	if err != nil {
+		t.Fatalf("WriteManagedFile failed: %v", err)
+	}
+	if want := filepath.Join(tmpDir, ManagedFilesDir, "TLS_KEY"); path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(key) {
+		t.Errorf("file holds %q, want %q", got, key)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("file mode = %o, want 600", info.Mode().Perm())
+	}
+
+	// The env file references the path rather than inlining the bytes
+	env, err := Read(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Vars["TLS_KEY"] != path || env.Vars["API_KEY"] != "abc" {
+		t.Errorf("env vars = %v", env.Vars)
+	}
+
+	if _, err := WriteManagedFile(envPath, "../escape", key); err == nil {
+		t.Error("expected error for a name outside the files directory")
+	}
+}
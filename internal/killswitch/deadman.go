@@ -0,0 +1,72 @@
+package killswitch
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/audit"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// maxDeadManPoll bounds how often a dead-man switch checks its file, so a
+// long interval is still enforced promptly.
+const maxDeadManPoll = time.Minute
+
+// NewDeadManMonitor creates a monitor for machines that cannot reach a
+// heartbeat endpoint. Instead of polling a URL it checks the modification
+// time of path, and activates failAction as soon as the file has not been
+// touched (see TouchKeepAlive) for longer than interval, or is missing.
+// Start it like any other HeartbeatMonitor.
+func NewDeadManMonitor(
+	path string,
+	interval time.Duration,
+	failAction types.KillswitchOptions,
+	killswitch *Killswitch,
+	auditLogger *audit.Logger,
+) *HeartbeatMonitor {
+	poll := min(interval/4, maxDeadManPoll)
+	if poll <= 0 {
+		poll = interval
+	}
+	h := NewHeartbeatMonitor(types.HeartbeatConfig{
+		Enabled:          true,
+		Interval:         poll,
+		FailAction:       failAction,
+		FailureThreshold: 1,
+	}, killswitch, auditLogger)
+	h.probe = func() error { return checkKeepAlive(path, interval, time.Now()) }
+	return h
+}
+
+// checkKeepAlive fails if path was last modified more than interval before
+// now.
+func checkKeepAlive(path string, interval time.Duration, now time.Time) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("%w: keep-alive file: %v", types.ErrHeartbeatFailed, err)
+	}
+	if age := now.Sub(info.ModTime()); age > interval {
+		return fmt.Errorf("%w: keep-alive file %s not touched for %v (limit %v)",
+			types.ErrHeartbeatFailed, path, age.Round(time.Second), interval)
+	}
+	return nil
+}
+
+// TouchKeepAlive resets a dead-man switch by setting the modification time
+// of path to now, creating the file if needed. It returns the time set.
+func TouchKeepAlive(path string) (time.Time, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open keep-alive file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return time.Time{}, fmt.Errorf("failed to close keep-alive file: %w", err)
+	}
+
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return time.Time{}, fmt.Errorf("failed to touch keep-alive file: %w", err)
+	}
+	return now, nil
+}
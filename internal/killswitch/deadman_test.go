@@ -0,0 +1,100 @@
+package killswitch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestCheckKeepAlive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keepalive")
+	if err := checkKeepAlive(path, time.Hour, time.Now()); !errors.Is(err, types.ErrHeartbeatFailed) {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+
+	touched, err := TouchKeepAlive(path)
+	if err != nil {
+		t.Fatalf("TouchKeepAlive failed: %v", err)
+	}
+	if err := checkKeepAlive(path, time.Hour, touched.Add(59*time.Minute)); err != nil {
+		t.Errorf("expected a file touched within the interval to pass, got %v", err)
+	}
+	if err := checkKeepAlive(path, time.Hour, touched.Add(61*time.Minute)); !errors.Is(err, types.ErrHeartbeatFailed) {
+		t.Errorf("expected a stale file to fail, got %v", err)
+	}
+}
+
+func TestDeadManMonitor(t *testing.T) {
+	_, ks, st, auditLogger, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	if err := st.Add("test-secret", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "keepalive")
+	if _, err := TouchKeepAlive(path); err != nil {
+		t.Fatalf("TouchKeepAlive failed: %v", err)
+	}
+	dm := NewDeadManMonitor(path, 200*time.Millisecond, types.KillswitchOptions{WipeStore: true}, ks, auditLogger)
+	defer dm.Stop()
+	dm.Start()
+
+	// Touching the file more often than the interval keeps the switch armed
+	for i := 0; i < 6; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := TouchKeepAlive(path); err != nil {
+			t.Fatalf("TouchKeepAlive failed: %v", err)
+		}
+	}
+	if !dm.IsRunning() {
+		t.Fatal("dead-man switch fired although the file was kept fresh")
+	}
+	if secrets, _ := st.List(); len(secrets) != 1 {
+		t.Fatalf("expected store untouched, got %d secrets", len(secrets))
+	}
+
+	// Once the file goes stale the fail action runs
+	deadline := time.Now().Add(5 * time.Second)
+	for dm.IsRunning() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the dead-man switch to fire on a stale file")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if secrets, _ := st.List(); len(secrets) != 0 {
+		t.Fatalf("expected store to be wiped, got %d secrets", len(secrets))
+	}
+}
+
+func TestDeadManMonitor_StaleFileFires(t *testing.T) {
+	_, ks, _, auditLogger, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	path := filepath.Join(t.TempDir(), "keepalive")
+	if _, err := TouchKeepAlive(path); err != nil {
+		t.Fatalf("TouchKeepAlive failed: %v", err)
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("failed to age keep-alive file: %v", err)
+	}
+
+	dm := NewDeadManMonitor(path, time.Hour, types.KillswitchOptions{RevokeAll: true}, ks, auditLogger)
+	if !dm.beat() {
+		t.Error("expected a stale keep-alive file to fire the switch")
+	}
+
+	// A fresh touch means the next check passes
+	dm = NewDeadManMonitor(path, time.Hour, types.KillswitchOptions{RevokeAll: true}, ks, auditLogger)
+	if _, err := TouchKeepAlive(path); err != nil {
+		t.Fatalf("TouchKeepAlive failed: %v", err)
+	}
+	if dm.beat() {
+		t.Error("expected a freshly touched file not to fire the switch")
+	}
+}
@@ -3,7 +3,10 @@ package killswitch
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"net/http"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,7 +14,8 @@ import (
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
-// HeartbeatMonitor monitors a remote endpoint and triggers killswitch on failure.
+// HeartbeatMonitor monitors one or more remote endpoints and triggers the
+// killswitch when fewer than a quorum of them respond.
 type HeartbeatMonitor struct {
 	config      types.HeartbeatConfig
 	killswitch  *Killswitch
@@ -21,6 +25,9 @@ type HeartbeatMonitor struct {
 	stopOnce    sync.Once
 	mu          sync.Mutex
 	running     bool
+	failures    int          // Consecutive failed heartbeats
+	headers     http.Header  // config.Headers with env vars expanded, set by Start
+	probe       func() error // Replaces the HTTP check; see NewDeadManMonitor
 }
 
 // NewHeartbeatMonitor creates a new heartbeat monitor.
@@ -48,11 +55,21 @@ func (h *HeartbeatMonitor) Start() {
 		return
 	}
 	h.running = true
+	h.headers = expandHeaders(h.config.Headers)
 	h.mu.Unlock()
 
 	go h.monitorLoop()
 }
 
+// expandHeaders expands environment variables in header values.
+func expandHeaders(headers map[string]string) http.Header {
+	expanded := make(http.Header, len(headers))
+	for name, value := range headers {
+		expanded.Set(name, os.ExpandEnv(value))
+	}
+	return expanded
+}
+
 // Stop stops the heartbeat monitor gracefully.
 func (h *HeartbeatMonitor) Stop() {
 	h.stopOnce.Do(func() {
@@ -62,34 +79,20 @@ func (h *HeartbeatMonitor) Stop() {
 
 // monitorLoop is the main monitoring loop that runs in a goroutine.
 func (h *HeartbeatMonitor) monitorLoop() {
-	ticker := time.NewTicker(h.config.Interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(h.nextInterval())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			if err := h.check(); err != nil {
-				// Log heartbeat failure
-				entry := audit.NewEntry(types.ActionHeartbeatFail, false).
-					WithDetails(err.Error()).
-					Build()
-				_ = h.auditLogger.Log(entry)
-
-				// Trigger killswitch with configured fail action
-				if err := h.killswitch.Activate(h.config.FailAction); err != nil {
-					// Log killswitch failure but don't retry
-					entry := audit.NewEntry(types.ActionKillswitch, false).
-						WithDetails(fmt.Sprintf("triggered by heartbeat failure: %v", err)).
-						Build()
-					_ = h.auditLogger.Log(entry)
-				}
-
-				// Stop monitoring after first failure
+		case <-timer.C:
+			if h.beat() {
+				// Stop monitoring once the killswitch has fired
 				h.mu.Lock()
 				h.running = false
 				h.mu.Unlock()
 				return
 			}
+			timer.Reset(h.nextInterval())
 		case <-h.done:
 			h.mu.Lock()
 			h.running = false
@@ -99,9 +102,127 @@ func (h *HeartbeatMonitor) monitorLoop() {
 	}
 }
 
-// check performs a single heartbeat check against the configured URL.
+// nextInterval returns how long to wait before the next heartbeat: Interval
+// after a success, growing by BackoffFactor with each consecutive failure
+// up to MaxInterval, then jittered.
+func (h *HeartbeatMonitor) nextInterval() time.Duration {
+	h.mu.Lock()
+	failures := h.failures
+	h.mu.Unlock()
+
+	interval := h.config.Interval
+	if maxInterval := h.config.MaxInterval; maxInterval > interval {
+		factor := h.config.BackoffFactor
+		if factor == 0 {
+			factor = 2
+		}
+		for i := 0; i < failures && interval < maxInterval; i++ {
+			interval = time.Duration(float64(interval) * factor)
+		}
+		interval = min(interval, maxInterval)
+	}
+
+	band := interval * time.Duration(h.config.Jitter) / 100
+	if band <= 0 {
+		return interval
+	}
+	return interval + time.Duration(rand.Int64N(int64(2*band)+1)) - band
+}
+
+// beat runs one heartbeat check. Each failure is audited with the current
+// streak; a success resets it. Once the streak reaches the failure
+// threshold the killswitch is activated and beat reports true.
+func (h *HeartbeatMonitor) beat() bool {
+	err := h.check()
+
+	h.mu.Lock()
+	if err == nil {
+		h.failures = 0
+		h.mu.Unlock()
+		return false
+	}
+	h.failures++
+	streak := h.failures
+	h.mu.Unlock()
+
+	threshold := h.config.FailuresToTrigger()
+	entry := audit.NewEntry(types.ActionHeartbeatFail, false).
+		WithDetails(fmt.Sprintf("%v (consecutive failure %d of %d)", err, streak, threshold)).
+		Build()
+	_ = h.auditLogger.Log(entry)
+	if streak < threshold {
+		return false
+	}
+
+	// Trigger killswitch with configured fail action
+	action := h.config.FailAction
+	action.Trigger = types.TriggerHeartbeat
+	if err := h.killswitch.Activate(action); err != nil {
+		// Log killswitch failure but don't retry
+		entry := audit.NewEntry(types.ActionKillswitch, false).
+			WithDetails(fmt.Sprintf("triggered by heartbeat failure: %v", err)).
+			Build()
+		_ = h.auditLogger.Log(entry)
+	}
+	return true
+}
+
+// check polls every heartbeat endpoint concurrently and fails when fewer
+// than the required number of them succeed.
 func (h *HeartbeatMonitor) check() error {
-	resp, err := h.client.Get(h.config.URL)
+	if h.probe != nil {
+		return h.probe()
+	}
+
+	endpoints := h.config.Endpoints()
+	errs := make([]error, len(endpoints))
+
+	var wg sync.WaitGroup
+	for i, url := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = h.checkURL(url)
+		}()
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", endpoints[i], err))
+		}
+	}
+
+	succeeded := len(endpoints) - len(failures)
+	if required := h.config.RequiredSuccesses(); succeeded < required {
+		if len(endpoints) == 1 {
+			return errs[0]
+		}
+		return fmt.Errorf("%w: %d of %d endpoints succeeded, quorum is %d (%s)",
+			types.ErrHeartbeatFailed, succeeded, len(endpoints), required, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// checkURL performs a single heartbeat check against url. Errors describe
+// the request without its headers.
+func (h *HeartbeatMonitor) checkURL(url string) error {
+	method := h.config.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", types.ErrHeartbeatFailed, err)
+	}
+	h.mu.Lock()
+	for name, values := range h.headers {
+		req.Header[name] = values
+	}
+	h.mu.Unlock()
+
+	resp, err := h.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("%w: %v", types.ErrHeartbeatFailed, err)
 	}
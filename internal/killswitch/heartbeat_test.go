@@ -1,10 +1,13 @@
 package killswitch
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -57,6 +60,7 @@ func setupHeartbeatTest(t *testing.T) (*HeartbeatMonitor, *Killswitch, *store.St
 
 	// Create killswitch
 	ks := NewKillswitch(lm, re, st, auditLogger)
+	ks.Arm(0)
 
 	// Create heartbeat config (will be updated with server URL in tests)
 	hbConfig := types.HeartbeatConfig{
@@ -66,6 +70,8 @@ func setupHeartbeatTest(t *testing.T) (*HeartbeatMonitor, *Killswitch, *store.St
 		FailAction: types.KillswitchOptions{
 			RevokeAll: true,
 		},
+		// Trip on the first failure; threshold tests raise this
+		FailureThreshold: 1,
 	}
 
 	// Create heartbeat monitor
@@ -185,6 +191,46 @@ func TestHeartbeatMonitor_FailureTriggersKillswitch(t *testing.T) {
 	}
 }
 
+func TestHeartbeatMonitor_FailureRotationTrigger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hm, _, st, auditLogger, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	if err := st.Add("test-secret", "secret-value", "echo 'rotated'"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	hm.config.URL = server.URL
+	hm.config.FailAction = types.KillswitchOptions{
+		RotateAll: true,
+	}
+
+	hm.Start()
+	time.Sleep(300 * time.Millisecond)
+
+	entries, err := auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+
+	found := false
+	for _, entry := range entries {
+		if entry.Action == types.ActionSecretRotate && entry.SecretName == "test-secret" {
+			found = true
+			if entry.Trigger != types.TriggerHeartbeat {
+				t.Errorf("expected trigger %q, got %q", types.TriggerHeartbeat, entry.Trigger)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected rotation audit entry")
+	}
+}
+
 func TestHeartbeatMonitor_NetworkError(t *testing.T) {
 	hm, _, st, auditLogger, cleanup := setupHeartbeatTest(t)
 	defer cleanup()
@@ -324,3 +370,330 @@ func TestHeartbeatMonitor_StartStopIdempotent(t *testing.T) {
 		t.Error("expected monitor to be stopped")
 	}
 }
+
+// statusServer is a heartbeat endpoint whose status code can be changed.
+func statusServer(t *testing.T, status int) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var code atomic.Int64
+	code.Store(int64(status))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(code.Load()))
+	}))
+	t.Cleanup(server.Close)
+	return server, &code
+}
+
+func TestHeartbeatMonitor_Check_Quorum(t *testing.T) {
+	a, _ := statusServer(t, http.StatusOK)
+	b, _ := statusServer(t, http.StatusOK)
+	c, cCode := statusServer(t, http.StatusInternalServerError)
+
+	hm, _, _, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	hm.config.URLs = []string{a.URL, b.URL, c.URL}
+	hm.config.Quorum = 2
+
+	// One failing endpoint out of three still meets the quorum
+	if err := hm.check(); err != nil {
+		t.Errorf("expected check to pass with 2 of 3 endpoints up, got: %v", err)
+	}
+
+	// Without an explicit quorum, a majority is required
+	hm.config.Quorum = 0
+	if err := hm.check(); err != nil {
+		t.Errorf("expected check to pass with a majority up, got: %v", err)
+	}
+
+	hm.config.Quorum = 3
+	if err := hm.check(); err == nil {
+		t.Error("expected check to fail when all 3 endpoints are required")
+	}
+
+	cCode.Store(http.StatusOK)
+	if err := hm.check(); err != nil {
+		t.Errorf("expected check to pass with all endpoints up, got: %v", err)
+	}
+}
+
+func TestHeartbeatMonitor_QuorumTripsKillswitch(t *testing.T) {
+	a, _ := statusServer(t, http.StatusOK)
+	b, bCode := statusServer(t, http.StatusOK)
+	c, _ := statusServer(t, http.StatusInternalServerError)
+
+	hm, _, st, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	if err := st.Add("test-secret", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	// The single URL and URLs are polled together
+	hm.config.URL = a.URL
+	hm.config.URLs = []string{b.URL, c.URL}
+	hm.config.Quorum = 2
+	hm.config.FailAction = types.KillswitchOptions{
+		WipeStore: true,
+	}
+
+	hm.Start()
+
+	// One endpoint down keeps the switch from firing
+	time.Sleep(300 * time.Millisecond)
+	if !hm.IsRunning() {
+		t.Fatal("expected monitor to keep running with a quorum of endpoints up")
+	}
+	if secrets, _ := st.List(); len(secrets) != 1 {
+		t.Fatalf("expected store untouched, got %d secrets", len(secrets))
+	}
+
+	// A second endpoint down loses the quorum
+	bCode.Store(http.StatusInternalServerError)
+	time.Sleep(300 * time.Millisecond)
+
+	if hm.IsRunning() {
+		t.Error("expected monitor to stop after losing the quorum")
+	}
+	secrets, err := st.List()
+	if err != nil {
+		t.Fatalf("failed to list secrets: %v", err)
+	}
+	if len(secrets) != 0 {
+		t.Fatalf("expected store to be wiped, got %d secrets", len(secrets))
+	}
+}
+
+func TestHeartbeatMonitor_FailureThreshold(t *testing.T) {
+	server, code := statusServer(t, http.StatusInternalServerError)
+
+	hm, _, st, auditLogger, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	if err := st.Add("test-secret", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	// Zero means the default threshold
+	hm.config.URL = server.URL
+	hm.config.FailureThreshold = 0
+	hm.config.FailAction = types.KillswitchOptions{
+		WipeStore: true,
+	}
+	threshold := types.DefaultHeartbeatFailureThreshold
+
+	for i := 1; i < threshold; i++ {
+		if hm.beat() {
+			t.Fatalf("killswitch fired after %d of %d failures", i, threshold)
+		}
+	}
+	if secrets, _ := st.List(); len(secrets) != 1 {
+		t.Fatalf("expected store untouched below the threshold, got %d secrets", len(secrets))
+	}
+
+	// A success resets the streak
+	code.Store(http.StatusOK)
+	if hm.beat() {
+		t.Fatal("killswitch fired on a successful heartbeat")
+	}
+	code.Store(http.StatusInternalServerError)
+	for i := 1; i < threshold; i++ {
+		if hm.beat() {
+			t.Fatalf("killswitch fired after %d failures following a success", i)
+		}
+	}
+
+	if !hm.beat() {
+		t.Fatalf("expected killswitch to fire at %d consecutive failures", threshold)
+	}
+	if secrets, _ := st.List(); len(secrets) != 0 {
+		t.Fatalf("expected store to be wiped, got %d secrets", len(secrets))
+	}
+
+	// Every failure is audited with its streak
+	action := types.ActionHeartbeatFail
+	entries, err := auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) != 2*threshold-1 {
+		t.Fatalf("expected %d heartbeat failures audited, got %d", 2*threshold-1, len(entries))
+	}
+	want := fmt.Sprintf("consecutive failure %d of %d", threshold, threshold)
+	if last := entries[len(entries)-1]; !strings.Contains(last.Details, want) {
+		t.Errorf("expected last failure to record %q, got %q", want, last.Details)
+	}
+}
+
+func TestHeartbeatMonitor_FailureThresholdLoop(t *testing.T) {
+	server, _ := statusServer(t, http.StatusInternalServerError)
+
+	hm, _, _, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	hm.config.URL = server.URL
+	hm.config.FailureThreshold = 1000
+
+	// Failures below the threshold keep the monitor running
+	hm.Start()
+	time.Sleep(300 * time.Millisecond)
+	if !hm.IsRunning() {
+		t.Error("expected monitor to keep running below the failure threshold")
+	}
+}
+
+func TestHeartbeatMonitor_Backoff(t *testing.T) {
+	server, code := statusServer(t, http.StatusInternalServerError)
+
+	hm, _, _, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	hm.config.URL = server.URL
+	hm.config.Interval = time.Second
+	hm.config.MaxInterval = 10 * time.Second
+	hm.config.FailureThreshold = 1000
+
+	if got := hm.nextInterval(); got != time.Second {
+		t.Fatalf("expected base interval before any failure, got %v", got)
+	}
+
+	// Each failure doubles the interval, up to MaxInterval
+	for _, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second} {
+		hm.beat()
+		if got := hm.nextInterval(); got != want {
+			t.Errorf("expected interval %v, got %v", want, got)
+		}
+	}
+
+	// A success snaps back to the base interval
+	code.Store(http.StatusOK)
+	hm.beat()
+	if got := hm.nextInterval(); got != time.Second {
+		t.Errorf("expected base interval after a success, got %v", got)
+	}
+
+	// BackoffFactor sets the growth rate
+	hm.config.BackoffFactor = 3
+	code.Store(http.StatusInternalServerError)
+	hm.beat()
+	if got := hm.nextInterval(); got != 3*time.Second {
+		t.Errorf("expected interval 3s with factor 3, got %v", got)
+	}
+
+	// Without a MaxInterval the interval stays fixed
+	hm.config.MaxInterval = 0
+	if got := hm.nextInterval(); got != time.Second {
+		t.Errorf("expected fixed interval without max_interval, got %v", got)
+	}
+}
+
+func TestHeartbeatMonitor_BackoffJitter(t *testing.T) {
+	hm, _, _, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	hm.config.Interval = time.Second
+	hm.config.Jitter = 10
+
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 50; i++ {
+		got := hm.nextInterval()
+		if got < 900*time.Millisecond || got > 1100*time.Millisecond {
+			t.Fatalf("interval %v outside ±10%% of 1s", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected jittered intervals to vary")
+	}
+}
+
+func TestHeartbeatMonitor_BackoffLoop(t *testing.T) {
+	var requests atomic.Int64
+	var code atomic.Int64
+	code.Store(http.StatusInternalServerError)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(code.Load()))
+	}))
+	defer server.Close()
+
+	hm, _, _, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+
+	hm.config.URL = server.URL
+	hm.config.Interval = 10 * time.Millisecond
+	hm.config.MaxInterval = time.Hour
+	hm.config.FailureThreshold = 1000
+
+	// Polls at 10, 30, 70, 150 and 310ms; a fixed interval would make ~40
+	hm.Start()
+	time.Sleep(400 * time.Millisecond)
+	if n := requests.Load(); n < 3 || n > 6 {
+		t.Errorf("expected the failing endpoint to be polled 3-6 times with backoff, got %d", n)
+	}
+	if !hm.IsRunning() {
+		t.Fatal("expected monitor to keep running below the failure threshold")
+	}
+}
+
+func TestHeartbeatMonitor_Headers(t *testing.T) {
+	var methods atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods.Store(r.Method)
+		if r.URL.RawQuery == "down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Setenv("HEARTBEAT_TOKEN", "s3cret")
+
+	// Without the header the proxy's 401 is a failure
+	hm, _, _, _, cleanup := setupHeartbeatTest(t)
+	defer cleanup()
+	hm.config.URL = server.URL
+	if err := hm.check(); err == nil {
+		t.Error("expected check to fail without the auth header")
+	}
+
+	// With it, expanded from the environment at start, the check passes
+	authed, _, _, auditLogger, authedCleanup := setupHeartbeatTest(t)
+	defer authedCleanup()
+	authed.config.URL = server.URL
+	authed.config.Method = http.MethodPost
+	authed.config.Headers = map[string]string{"Authorization": "Bearer $HEARTBEAT_TOKEN"}
+	authed.config.Interval = time.Hour
+	authed.Start()
+	authed.Stop()
+
+	if err := authed.check(); err != nil {
+		t.Errorf("expected check to pass with the auth header, got: %v", err)
+	}
+	if got := methods.Load(); got != http.MethodPost {
+		t.Errorf("expected a POST heartbeat, got %v", got)
+	}
+
+	// Header values never reach the audit log
+	authed.config.URL = server.URL + "?down"
+	authed.config.FailureThreshold = 1000
+	if authed.beat() {
+		t.Fatal("killswitch fired below the failure threshold")
+	}
+	entries, err := auditLogger.Query(audit.QueryFilter{})
+	if err != nil {
+		t.Fatalf("failed to query audit log: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("expected the failed heartbeat to be audited")
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Details, "s3cret") {
+			t.Errorf("audit entry leaks the header value: %q", entry.Details)
+		}
+	}
+}
@@ -2,8 +2,14 @@
 package killswitch
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/lease"
@@ -12,12 +18,35 @@ import (
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
+// webhookTimeout bounds a killswitch webhook delivery.
+const webhookTimeout = 10 * time.Second
+
 // Killswitch provides multi-factor emergency revocation capabilities.
 type Killswitch struct {
 	leaseManager     *lease.Manager
 	rotationExecutor *rotation.Executor
 	store            *store.Store
 	auditLogger      *audit.Logger
+	webhook          string // Notified after each activation; empty disables
+	client           *http.Client
+
+	// Destructive actions only run while the switch is armed. A zero
+	// armedUntil means armed until Disarm.
+	mu         sync.Mutex
+	armed      bool
+	armedUntil time.Time
+	armWindow  time.Duration    // Default for Arm; zero never expires
+	now        func() time.Time // Replaced in tests
+}
+
+// WebhookPayload is the JSON body POSTed to the killswitch webhook.
+type WebhookPayload struct {
+	Trigger       types.RotationTrigger `json:"trigger"`
+	Timestamp     time.Time             `json:"timestamp"`
+	LeasesRevoked int                   `json:"leases_revoked"`
+	StoreWiped    bool                  `json:"store_wiped"`
+	Host          string                `json:"host"`
+	Downgraded    bool                  `json:"downgraded,omitempty"` // Destructive actions skipped while unarmed
 }
 
 // NewKillswitch creates a new killswitch with the provided dependencies.
@@ -32,27 +61,107 @@ func NewKillswitch(
 		rotationExecutor: rotationExecutor,
 		store:            store,
 		auditLogger:      auditLogger,
+		client:           &http.Client{Timeout: webhookTimeout},
+		now:              time.Now,
+	}
+}
+
+// SetArmWindow sets how long Arm arms the switch when called without a
+// window. Zero, the default, arms it until Disarm.
+func (k *Killswitch) SetArmWindow(window time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.armWindow = window
+}
+
+// Arm allows Activate to run destructive actions (RotateAll, WipeStore) for
+// window, or the arm window if window is not positive. It returns when the
+// arming expires, or the zero time if it does not.
+func (k *Killswitch) Arm(window time.Duration) time.Time {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if window <= 0 {
+		window = k.armWindow
 	}
+	k.armed = true
+	k.armedUntil = time.Time{}
+	if window > 0 {
+		k.armedUntil = k.now().Add(window)
+	}
+	return k.armedUntil
+}
+
+// Disarm stops Activate from running destructive actions.
+func (k *Killswitch) Disarm() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.armed = false
+	k.armedUntil = time.Time{}
+}
+
+// Armed reports whether destructive actions are allowed now, and until
+// when (the zero time if the arming does not expire).
+func (k *Killswitch) Armed() (bool, time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.armed && !k.armedUntil.IsZero() && !k.now().Before(k.armedUntil) {
+		k.armed = false
+		k.armedUntil = time.Time{}
+	}
+	return k.armed, k.armedUntil
+}
+
+// SetWebhook makes every activation POST a WebhookPayload to url once its
+// actions are done.
+func (k *Killswitch) SetWebhook(url string) {
+	k.webhook = url
 }
 
 // Activate triggers the killswitch with the specified options.
 // Operations are attempted in order: RevokeAll, RotateAll, WipeStore.
 // All operations are attempted even if one fails; errors are collected and combined.
+// Unless the switch is armed, RotateAll and WipeStore are downgraded to
+// RevokeAll and a warning is audited.
 func (k *Killswitch) Activate(options types.KillswitchOptions) error {
 	var errs []string
 	details := []string{}
 
+	trigger := options.Trigger
+	if trigger == "" {
+		trigger = types.TriggerKillswitch
+	}
+	payload := WebhookPayload{Trigger: trigger}
+
+	if armed, _ := k.Armed(); !armed && (options.RotateAll || options.WipeStore) {
+		var skipped []string
+		if options.RotateAll {
+			skipped = append(skipped, "rotate_all")
+		}
+		if options.WipeStore {
+			skipped = append(skipped, "wipe_store")
+		}
+		_ = k.auditLogger.Log(audit.NewEntry(types.ActionKillswitch, false).
+			WithDetails(fmt.Sprintf("killswitch not armed: %s downgraded to revoke_all", strings.Join(skipped, ", "))).
+			Build())
+		options.RotateAll = false
+		options.WipeStore = false
+		options.RevokeAll = true
+		payload.Downgraded = true
+	}
+
 	// Track what operations were requested
 	if options.RevokeAll {
+		active := len(k.leaseManager.List())
 		if err := k.leaseManager.RevokeAll(); err != nil {
 			errs = append(errs, fmt.Sprintf("revoke failed: %v", err))
 		} else {
 			details = append(details, "all leases revoked")
+			payload.LeasesRevoked = active
 		}
 	}
 
 	if options.RotateAll {
-		results, err := k.rotationExecutor.RotateAll()
+		results, err := k.rotationExecutor.RotateAll(trigger)
 		if err != nil {
 			errs = append(errs, fmt.Sprintf("rotate failed: %v", err))
 		} else {
@@ -77,6 +186,7 @@ func (k *Killswitch) Activate(options types.KillswitchOptions) error {
 			errs = append(errs, fmt.Sprintf("wipe failed: %v", err))
 		} else {
 			details = append(details, "store wiped")
+			payload.StoreWiped = true
 		}
 	}
 
@@ -87,6 +197,14 @@ func (k *Killswitch) Activate(options types.KillswitchOptions) error {
 		Build()
 	_ = k.auditLogger.Log(entry)
 
+	// Notify only after the actions, so a slow or failing webhook cannot
+	// hold them up; delivery failures are audited, not returned
+	if k.webhook != "" {
+		payload.Timestamp = time.Now()
+		payload.Host, _ = os.Hostname()
+		k.notify(payload)
+	}
+
 	// Return combined errors if any
 	if len(errs) > 0 {
 		return fmt.Errorf("killswitch partial failure: %s", strings.Join(errs, "; "))
@@ -94,3 +212,29 @@ func (k *Killswitch) Activate(options types.KillswitchOptions) error {
 
 	return nil
 }
+
+// notify POSTs payload to the webhook and audits the outcome.
+func (k *Killswitch) notify(payload WebhookPayload) {
+	err := k.postWebhook(payload)
+	entry := audit.NewEntry(types.ActionWebhookNotify, err == nil)
+	if err != nil {
+		entry = entry.WithDetails(fmt.Sprintf("killswitch webhook failed: %v", err))
+	}
+	_ = k.auditLogger.Log(entry.Build())
+}
+
+func (k *Killswitch) postWebhook(payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := k.client.Post(k.webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,8 +1,12 @@
 package killswitch
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,6 +59,7 @@ func setupTest(t *testing.T) (*Killswitch, *lease.Manager, *store.Store, *audit.
 
 	// Create killswitch
 	ks := NewKillswitch(lm, re, st, auditLogger)
+	ks.Arm(0)
 
 	cleanup := func() {
 		auditLogger.Close()
@@ -193,6 +198,24 @@ func TestKillswitch_Activate_RotateAll(t *testing.T) {
 	if entries[0].Action != types.ActionKillswitch {
 		t.Errorf("expected action %s, got %s", types.ActionKillswitch, entries[0].Action)
 	}
+
+	// The rotation itself is attributed to the killswitch
+	entries, err = auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Action == types.ActionSecretRotate && entry.SecretName == "test-secret" {
+			found = true
+			if entry.Trigger != types.TriggerKillswitch {
+				t.Errorf("expected trigger %q, got %q", types.TriggerKillswitch, entry.Trigger)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected rotation audit entry")
+	}
 }
 
 func TestKillswitch_Activate_AllOptions(t *testing.T) {
@@ -273,3 +296,178 @@ func TestKillswitch_Activate_NoOptions(t *testing.T) {
 		t.Errorf("expected action %s, got %s", types.ActionKillswitch, entries[0].Action)
 	}
 }
+
+func TestKillswitch_Unarmed(t *testing.T) {
+	ks, lm, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+	ks.Disarm()
+
+	if err := st.Add("secret-1", "value-1", "echo rotated"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if _, err := lm.Acquire("secret-1", "client-1", 5*time.Minute); err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	// Destructive actions are downgraded to revoking leases
+	if err := ks.Activate(types.KillswitchOptions{WipeStore: true, RotateAll: true}); err != nil {
+		t.Fatalf("killswitch activation failed: %v", err)
+	}
+	secrets, err := st.List()
+	if err != nil {
+		t.Fatalf("failed to list secrets: %v", err)
+	}
+	if len(secrets) != 1 {
+		t.Errorf("expected the store left intact, got %d secrets", len(secrets))
+	}
+	if len(lm.List()) != 0 {
+		t.Errorf("expected leases revoked, got %d active", len(lm.List()))
+	}
+
+	entries, err := auditLogger.Query(audit.QueryFilter{})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	warned := false
+	for _, entry := range entries {
+		if entry.Action == types.ActionSecretRotate {
+			t.Error("expected no rotation while unarmed")
+		}
+		if entry.Action == types.ActionKillswitch && !entry.Success &&
+			strings.Contains(entry.Details, "rotate_all, wipe_store downgraded") {
+			warned = true
+		}
+	}
+	if !warned {
+		t.Errorf("expected a downgrade warning audited, got %+v", entries)
+	}
+
+	// Once armed, the same activation wipes the store
+	ks.Arm(0)
+	if err := ks.Activate(types.KillswitchOptions{WipeStore: true}); err != nil {
+		t.Fatalf("killswitch activation failed: %v", err)
+	}
+	if secrets, _ := st.List(); len(secrets) != 0 {
+		t.Errorf("expected the store wiped once armed, got %d secrets", len(secrets))
+	}
+}
+
+func TestKillswitch_ArmExpiry(t *testing.T) {
+	ks, _, st, _, cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	ks.now = func() time.Time { return now }
+	ks.SetArmWindow(time.Hour)
+
+	if until := ks.Arm(0); !until.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the arm window to apply, got %v", until)
+	}
+	if until := ks.Arm(10 * time.Minute); !until.Equal(now.Add(10 * time.Minute)) {
+		t.Errorf("expected an explicit window to override, got %v", until)
+	}
+	if armed, _ := ks.Armed(); !armed {
+		t.Fatal("expected armed within the window")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if armed, _ := ks.Armed(); armed {
+		t.Fatal("expected arming to expire")
+	}
+
+	if err := st.Add("secret-1", "value-1", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := ks.Activate(types.KillswitchOptions{WipeStore: true}); err != nil {
+		t.Fatalf("killswitch activation failed: %v", err)
+	}
+	if secrets, _ := st.List(); len(secrets) != 1 {
+		t.Errorf("expected the store left intact after arming expired, got %d secrets", len(secrets))
+	}
+}
+
+func TestKillswitch_Webhook(t *testing.T) {
+	payloads := make(chan WebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		payloads <- payload
+	}))
+	defer server.Close()
+
+	ks, lm, st, _, cleanup := setupTest(t)
+	defer cleanup()
+	ks.SetWebhook(server.URL)
+
+	if err := st.Add("test-secret", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	for _, client := range []string{"client-1", "client-2"} {
+		if _, err := lm.Acquire("test-secret", client, 5*time.Minute); err != nil {
+			t.Fatalf("failed to acquire lease: %v", err)
+		}
+	}
+
+	before := time.Now()
+	if err := ks.Activate(types.KillswitchOptions{RevokeAll: true, WipeStore: true, Trigger: types.TriggerHeartbeat}); err != nil {
+		t.Fatalf("killswitch activation failed: %v", err)
+	}
+
+	select {
+	case payload := <-payloads:
+		host, _ := os.Hostname()
+		if payload.Trigger != types.TriggerHeartbeat || payload.LeasesRevoked != 2 || !payload.StoreWiped || payload.Host != host {
+			t.Errorf("unexpected payload: %+v", payload)
+		}
+		if payload.Timestamp.Before(before) {
+			t.Errorf("expected timestamp after %v, got %v", before, payload.Timestamp)
+		}
+	default:
+		t.Fatal("expected the webhook to be notified before Activate returns")
+	}
+}
+
+func TestKillswitch_WebhookUnreachable(t *testing.T) {
+	// A server that is closed before the killswitch fires
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	ks, lm, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+	ks.SetWebhook(url)
+
+	if err := st.Add("test-secret", "secret-value", ""); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if _, err := lm.Acquire("test-secret", "client-1", 5*time.Minute); err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	if err := ks.Activate(types.KillswitchOptions{RevokeAll: true, WipeStore: true}); err != nil {
+		t.Fatalf("expected the killswitch to succeed without its webhook, got: %v", err)
+	}
+	if leases := lm.List(); len(leases) != 0 {
+		t.Errorf("expected 0 active leases, got %d", len(leases))
+	}
+	if secrets, _ := st.List(); len(secrets) != 0 {
+		t.Errorf("expected store to be wiped, got %d secrets", len(secrets))
+	}
+
+	// The failed delivery is audited after the activation
+	entries, err := auditLogger.Tail(2)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != types.ActionKillswitch || !entries[0].Success {
+		t.Fatalf("expected a successful killswitch entry, got %+v", entries)
+	}
+	if entries[1].Action != types.ActionWebhookNotify || entries[1].Success {
+		t.Errorf("expected a failed webhook entry, got %+v", entries[1])
+	}
+}
@@ -3,9 +3,13 @@ package lease
 import (
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/joelhooks/agent-secrets/internal/audit"
@@ -13,13 +17,26 @@ import (
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
-// Manager handles lease lifecycle with TTL-based access control.
+// MaxReasonLength is the longest reason, in characters, a lease may carry.
+const MaxReasonLength = 256
+
+// leaseShards is the number of buckets NewManager spreads leases across.
+const leaseShards = 32
+
+// Manager handles lease lifecycle with TTL-based access control. Leases are
+// sharded by ID, each shard with its own lock, so operations on different
+// leases do not serialize.
 type Manager struct {
-	mu          sync.RWMutex
-	leases      map[string]*types.Lease
+	shards      []*leaseShard
+	acquired    atomic.Uint64 // Leases granted since creation
+	quotaMu     sync.Mutex    // Held from the quota check to the insert in Acquire
+	saveMu      sync.Mutex    // Serializes Save so the last write is the newest
 	cfg         *config.Config
 	auditLogger *audit.Logger
 
+	hookMu sync.RWMutex
+	onEnd  func(types.Lease) // Optional; see SetEndHook, guarded by hookMu
+
 	// Cleanup loop control
 	cleanupDone chan struct{}
 	cleanupStop chan struct{}
@@ -27,13 +44,21 @@ type Manager struct {
 
 // NewManager creates a new lease manager and loads persisted leases.
 func NewManager(cfg *config.Config, auditLogger *audit.Logger) (*Manager, error) {
+	return newManager(cfg, auditLogger, leaseShards)
+}
+
+// newManager is NewManager with the number of shards.
+func newManager(cfg *config.Config, auditLogger *audit.Logger, shards int) (*Manager, error) {
 	m := &Manager{
-		leases:      make(map[string]*types.Lease),
+		shards:      make([]*leaseShard, shards),
 		cfg:         cfg,
 		auditLogger: auditLogger,
 		cleanupDone: make(chan struct{}),
 		cleanupStop: make(chan struct{}),
 	}
+	for i := range m.shards {
+		m.shards[i] = &leaseShard{leases: make(map[string]*types.Lease)}
+	}
 
 	// Load persisted leases
 	if err := m.Load(); err != nil {
@@ -46,8 +71,90 @@ func NewManager(cfg *config.Config, auditLogger *audit.Logger) (*Manager, error)
 	return m, nil
 }
 
+// leaseShard is one bucket of the manager's leases.
+type leaseShard struct {
+	mu     sync.RWMutex
+	leases map[string]*types.Lease
+}
+
+// shard returns the shard holding leaseID.
+func (m *Manager) shard(leaseID string) *leaseShard {
+	h := fnv.New32a()
+	h.Write([]byte(leaseID))
+	return m.shards[h.Sum32()%uint32(len(m.shards))]
+}
+
+// each calls fn with every lease, holding each shard's read lock in turn.
+// fn must not keep the lease or call back into the manager.
+func (m *Manager) each(fn func(*types.Lease)) {
+	for _, s := range m.shards {
+		s.mu.RLock()
+		for _, lease := range s.leases {
+			fn(lease)
+		}
+		s.mu.RUnlock()
+	}
+}
+
+// revokeWhere revokes every unrevoked lease matching match and returns
+// copies of the leases it revoked.
+func (m *Manager) revokeWhere(match func(*types.Lease) bool) []types.Lease {
+	var ended []types.Lease
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for _, lease := range s.leases {
+			if !lease.Revoked && match(lease) {
+				lease.Revoked = true
+				ended = append(ended, *lease)
+			}
+		}
+		s.mu.Unlock()
+	}
+	return ended
+}
+
+// SetEndHook sets a function called with each lease once it is revoked or
+// expires, after the manager's lock is released. It is not called for
+// leases dropped on Load because they expired while the daemon was down.
+func (m *Manager) SetEndHook(hook func(types.Lease)) {
+	m.hookMu.Lock()
+	defer m.hookMu.Unlock()
+	m.onEnd = hook
+}
+
+// ended passes leases that just ended to the end hook, if any.
+func (m *Manager) ended(leases []types.Lease) {
+	m.hookMu.RLock()
+	hook := m.onEnd
+	m.hookMu.RUnlock()
+
+	if hook == nil {
+		return
+	}
+	for _, lease := range leases {
+		hook(lease)
+	}
+}
+
 // Acquire creates a new lease for the specified secret.
 func (m *Manager) Acquire(secretName, clientID string, ttl time.Duration) (*types.Lease, error) {
+	return m.AcquireFrom(secretName, clientID, ttl, types.Peer{})
+}
+
+// AcquireFrom creates a new lease for the specified secret, recording the
+// transport peer that requested it on the lease and in the audit log.
+func (m *Manager) AcquireFrom(secretName, clientID string, ttl time.Duration, peer types.Peer) (*types.Lease, error) {
+	return m.AcquireWithReason(secretName, clientID, "", ttl, peer)
+}
+
+// AcquireWithReason is AcquireFrom with the client's stated reason for the
+// lease, which is kept on the lease and in the audit log. The reason is
+// optional and at most MaxReasonLength characters.
+func (m *Manager) AcquireWithReason(secretName, clientID, reason string, ttl time.Duration, peer types.Peer) (*types.Lease, error) {
+	if utf8.RuneCountInString(reason) > MaxReasonLength {
+		return nil, fmt.Errorf("%w: %d characters (max %d)", types.ErrLeaseReasonTooLong, utf8.RuneCountInString(reason), MaxReasonLength)
+	}
+
 	// Validate TTL
 	if ttl <= 0 {
 		ttl = m.cfg.DefaultLeaseTTL
@@ -56,11 +163,32 @@ func (m *Manager) Acquire(secretName, clientID string, ttl time.Duration) (*type
 		entry := audit.NewEntry(types.ActionLeaseAcquire, false).
 			WithSecret(secretName).
 			WithClient(clientID).
+			WithPeer(peer).
 			WithDetails(fmt.Sprintf("TTL %v exceeds max %v", ttl, m.cfg.MaxLeaseTTL)).
 			Build()
 		_ = m.auditLogger.Log(entry)
 		return nil, types.ErrInvalidTTL
 	}
+	requested := ttl
+	ttl = m.jitter(ttl)
+
+	// The quota is checked across all shards, so hold quotaMu until the new
+	// lease is in place to keep concurrent acquires from overshooting it
+	limit := m.cfg.MaxLeasesPerClient
+	if limit > 0 {
+		m.quotaMu.Lock()
+	}
+	if limit > 0 && m.activeForClient(clientID) >= limit {
+		m.quotaMu.Unlock()
+		entry := audit.NewEntry(types.ActionLeaseAcquire, false).
+			WithSecret(secretName).
+			WithClient(clientID).
+			WithPeer(peer).
+			WithDetails(fmt.Sprintf("client lease limit of %d reached", limit)).
+			Build()
+		_ = m.auditLogger.Log(entry)
+		return nil, types.ErrLeaseQuotaExceeded
+	}
 
 	now := time.Now()
 	lease := &types.Lease{
@@ -70,11 +198,21 @@ func (m *Manager) Acquire(secretName, clientID string, ttl time.Duration) (*type
 		CreatedAt:  now,
 		ExpiresAt:  now.Add(ttl),
 		Revoked:    false,
+		Reason:     reason,
+		RemoteAddr: peer.RemoteAddr,
+		ClientCN:   peer.ClientCN,
+
+		PeerIdentity: peer.Identity(),
 	}
 
-	m.mu.Lock()
-	m.leases[lease.ID] = lease
-	m.mu.Unlock()
+	s := m.shard(lease.ID)
+	s.mu.Lock()
+	s.leases[lease.ID] = lease
+	s.mu.Unlock()
+	if limit > 0 {
+		m.quotaMu.Unlock()
+	}
+	m.acquired.Add(1)
 
 	// Persist and log
 	_ = m.Save()
@@ -83,19 +221,60 @@ func (m *Manager) Acquire(secretName, clientID string, ttl time.Duration) (*type
 		WithSecret(secretName).
 		WithClient(clientID).
 		WithLease(lease.ID).
-		WithDetails(fmt.Sprintf("TTL: %v", ttl)).
+		WithPeer(peer).
+		WithDetails(leaseAcquireDetails(ttl, requested, reason)).
 		Build()
 	_ = m.auditLogger.Log(entry)
 
 	return lease, nil
 }
 
+// activeForClient counts the active leases held by clientID.
+func (m *Manager) activeForClient(clientID string) int {
+	n := 0
+	m.each(func(lease *types.Lease) {
+		if lease.ClientID == clientID && IsValid(lease) {
+			n++
+		}
+	})
+	return n
+}
+
+// jitter randomizes ttl within ±LeaseTTLJitter percent, never exceeding
+// MaxLeaseTTL.
+func (m *Manager) jitter(ttl time.Duration) time.Duration {
+	band := ttl * time.Duration(m.cfg.LeaseTTLJitter) / 100
+	if band <= 0 {
+		return ttl
+	}
+
+	ttl += time.Duration(rand.Int64N(int64(2*band)+1)) - band
+	if ttl > m.cfg.MaxLeaseTTL {
+		ttl = m.cfg.MaxLeaseTTL
+	}
+	return ttl
+}
+
+// leaseAcquireDetails describes the granted TTL and the client's reason for
+// the audit log.
+func leaseAcquireDetails(granted, requested time.Duration, reason string) string {
+	details := fmt.Sprintf("TTL: %v", granted)
+	if granted != requested {
+		details += fmt.Sprintf(" (requested %v)", requested)
+	}
+	if reason != "" {
+		details += fmt.Sprintf(", reason: %q", reason)
+	}
+	return details
+}
+
 // Revoke marks a lease as revoked.
 func (m *Manager) Revoke(leaseID string) error {
-	m.mu.Lock()
-	lease, exists := m.leases[leaseID]
+	s := m.shard(leaseID)
+	s.mu.Lock()
+	lease, exists := s.leases[leaseID]
 	if !exists {
-		m.mu.Unlock()
+		s.mu.Unlock()
 		entry := audit.NewEntry(types.ActionLeaseRevoke, false).
 			WithLease(leaseID).
 			WithDetails("lease not found").
@@ -104,72 +283,227 @@ func (m *Manager) Revoke(leaseID string) error {
 		return types.ErrLeaseNotFound
 	}
 
+	wasRevoked := lease.Revoked
 	lease.Revoked = true
-	m.mu.Unlock()
+	ended := *lease
+	s.mu.Unlock()
 
 	_ = m.Save()
 
 	entry := audit.NewEntry(types.ActionLeaseRevoke, true).
-		WithSecret(lease.SecretName).
-		WithClient(lease.ClientID).
+		WithSecret(ended.SecretName).
+		WithClient(ended.ClientID).
 		WithLease(leaseID).
+		WithPeer(types.Peer{RemoteAddr: ended.RemoteAddr, ClientCN: ended.ClientCN}).
 		Build()
 	_ = m.auditLogger.Log(entry)
 
+	if !wasRevoked {
+		m.ended([]types.Lease{ended})
+	}
 	return nil
 }
 
-// RevokeAll revokes all active leases (for killswitch).
-func (m *Manager) RevokeAll() error {
-	m.mu.Lock()
-	count := 0
-	for _, lease := range m.leases {
-		if !lease.Revoked {
-			lease.Revoked = true
-			count++
+// Renew extends a valid lease to expire ttl from now (DefaultLeaseTTL if ttl
+// is not positive), without handing out the value again. A renewal never
+// shortens a lease, and the lease's total lifetime since it was created may
+// not exceed MaxLeaseTTL. Renewals are not jittered.
+func (m *Manager) Renew(leaseID string, ttl time.Duration) (*types.Lease, error) {
+	if ttl <= 0 {
+		ttl = m.cfg.DefaultLeaseTTL
+	}
+
+	s := m.shard(leaseID)
+	s.mu.Lock()
+	lease, exists := s.leases[leaseID]
+	now := time.Now()
+	var err error
+	var details string
+	switch {
+	case !exists:
+		err, details = types.ErrLeaseNotFound, "lease not found"
+	case lease.Revoked:
+		err, details = types.NewLeaseError(leaseID, lease.SecretName, types.ErrLeaseRevoked), "lease has been revoked"
+	case !now.Before(lease.ExpiresAt):
+		err, details = types.NewLeaseError(leaseID, lease.SecretName, types.ErrLeaseExpired), "lease has expired"
+	case now.Add(ttl).Sub(lease.CreatedAt) > m.cfg.MaxLeaseTTL:
+		err = types.ErrInvalidTTL
+		details = fmt.Sprintf("lifetime %v exceeds max %v", now.Add(ttl).Sub(lease.CreatedAt).Round(time.Second), m.cfg.MaxLeaseTTL)
+	}
+	if err != nil {
+		builder := audit.NewEntry(types.ActionLeaseRenew, false).
+			WithLease(leaseID).
+			WithDetails(details)
+		if exists {
+			builder = builder.WithSecret(lease.SecretName).WithClient(lease.ClientID)
 		}
+		s.mu.Unlock()
+		_ = m.auditLogger.Log(builder.Build())
+		return nil, err
+	}
+
+	if expiresAt := now.Add(ttl); expiresAt.After(lease.ExpiresAt) {
+		lease.ExpiresAt = expiresAt
 	}
-	m.mu.Unlock()
+	renewed := *lease
+	s.mu.Unlock()
+
+	_ = m.Save()
+
+	entry := audit.NewEntry(types.ActionLeaseRenew, true).
+		WithSecret(renewed.SecretName).
+		WithClient(renewed.ClientID).
+		WithLease(leaseID).
+		WithPeer(types.Peer{RemoteAddr: renewed.RemoteAddr, ClientCN: renewed.ClientCN}).
+		WithDetails(fmt.Sprintf("TTL: %v", ttl)).
+		Build()
+	_ = m.auditLogger.Log(entry)
+
+	return &renewed, nil
+}
+
+// Authorize checks that a lease is active and was granted for secretName,
+// so its holder may read the value again. Every check is audited as
+// ActionLeaseFetch, whether or not it succeeds.
+func (m *Manager) Authorize(leaseID, secretName string) (*types.Lease, error) {
+	s := m.shard(leaseID)
+	s.mu.RLock()
+	lease, exists := s.leases[leaseID]
+	var leaseCopy types.Lease
+	if exists {
+		leaseCopy = *lease
+	}
+	s.mu.RUnlock()
+
+	var err error
+	var details string
+	switch {
+	case !exists:
+		err, details = types.ErrLeaseNotFound, "lease not found"
+	case leaseCopy.Revoked:
+		err, details = types.NewLeaseError(leaseID, leaseCopy.SecretName, types.ErrLeaseRevoked), "lease has been revoked"
+	case !time.Now().Before(leaseCopy.ExpiresAt):
+		err, details = types.NewLeaseError(leaseID, leaseCopy.SecretName, types.ErrLeaseExpired), "lease has expired"
+	case leaseCopy.SecretName != secretName:
+		err = types.NewLeaseError(leaseID, leaseCopy.SecretName, types.ErrLeaseWrongSecret)
+		details = fmt.Sprintf("lease is for %q, not %q", leaseCopy.SecretName, secretName)
+	}
+
+	builder := audit.NewEntry(types.ActionLeaseFetch, err == nil).
+		WithSecret(secretName).
+		WithLease(leaseID)
+	if exists {
+		builder = builder.WithClient(leaseCopy.ClientID).
+			WithPeer(types.Peer{RemoteAddr: leaseCopy.RemoteAddr, ClientCN: leaseCopy.ClientCN})
+	}
+	if details != "" {
+		builder = builder.WithDetails(details)
+	}
+	_ = m.auditLogger.Log(builder.Build())
+
+	if err != nil {
+		return nil, err
+	}
+	return &leaseCopy, nil
+}
+
+// RevokeAll revokes all active leases (for killswitch).
+func (m *Manager) RevokeAll() error {
+	ended := m.revokeWhere(func(*types.Lease) bool { return true })
 
 	_ = m.Save()
 
 	entry := audit.NewEntry(types.ActionKillswitch, true).
-		WithDetails(fmt.Sprintf("revoked %d leases", count)).
+		WithDetails(fmt.Sprintf("revoked %d leases", len(ended))).
 		Build()
 	_ = m.auditLogger.Log(entry)
 
+	m.ended(ended)
 	return nil
 }
 
 // RevokeBySecret revokes all leases for a specific secret.
 func (m *Manager) RevokeBySecret(secretName string) error {
-	m.mu.Lock()
-	count := 0
-	for _, lease := range m.leases {
-		if lease.SecretName == secretName && !lease.Revoked {
-			lease.Revoked = true
-			count++
-		}
-	}
-	m.mu.Unlock()
+	ended := m.revokeWhere(func(lease *types.Lease) bool {
+		return lease.SecretName == secretName
+	})
 
 	_ = m.Save()
 
 	entry := audit.NewEntry(types.ActionLeaseRevoke, true).
 		WithSecret(secretName).
-		WithDetails(fmt.Sprintf("revoked %d leases", count)).
+		WithDetails(fmt.Sprintf("revoked %d leases", len(ended))).
 		Build()
 	_ = m.auditLogger.Log(entry)
 
+	m.ended(ended)
 	return nil
 }
 
+// RevokeByClient revokes all active leases held by clientID and returns the
+// number revoked, cutting off one agent without the killswitch.
+func (m *Manager) RevokeByClient(clientID string) int {
+	ended := m.revokeWhere(func(lease *types.Lease) bool {
+		return lease.ClientID == clientID
+	})
+
+	_ = m.Save()
+
+	entry := audit.NewEntry(types.ActionLeaseRevoke, true).
+		WithClient(clientID).
+		WithDetails(fmt.Sprintf("revoked %d leases for client %q", len(ended), clientID)).
+		Build()
+	_ = m.auditLogger.Log(entry)
+
+	m.ended(ended)
+	return len(ended)
+}
+
+// RevokeByClientCN revokes all leases acquired with the given client
+// certificate common name and returns the number revoked.
+func (m *Manager) RevokeByClientCN(cn string) (int, error) {
+	ended := m.revokeWhere(func(lease *types.Lease) bool {
+		return lease.ClientCN == cn
+	})
+
+	_ = m.Save()
+
+	entry := audit.NewEntry(types.ActionLeaseRevoke, true).
+		WithPeer(types.Peer{ClientCN: cn}).
+		WithDetails(fmt.Sprintf("revoked %d leases for client CN %q", len(ended), cn)).
+		Build()
+	_ = m.auditLogger.Log(entry)
+
+	m.ended(ended)
+	return len(ended), nil
+}
+
+// RevokeByNamespace revokes all leases on secrets in the given namespace
+// and returns the number revoked.
+func (m *Manager) RevokeByNamespace(namespace string) (int, error) {
+	ended := m.revokeWhere(func(lease *types.Lease) bool {
+		ns, _ := types.SplitRef(lease.SecretName)
+		return ns == namespace
+	})
+
+	_ = m.Save()
+
+	entry := audit.NewEntry(types.ActionLeaseRevoke, true).
+		WithDetails(fmt.Sprintf("revoked %d leases in namespace %q", len(ended), namespace)).
+		Build()
+	_ = m.auditLogger.Log(entry)
+
+	m.ended(ended)
+	return len(ended), nil
+}
+
 // Get retrieves a lease by ID.
 func (m *Manager) Get(leaseID string) (*types.Lease, error) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	s := m.shard(leaseID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	lease, exists := m.leases[leaseID]
+	lease, exists := s.leases[leaseID]
 	if !exists {
 		return nil, types.ErrLeaseNotFound
 	}
@@ -181,47 +515,71 @@ func (m *Manager) Get(leaseID string) (*types.Lease, error) {
 
 // List returns all active (non-expired, non-revoked) leases.
 func (m *Manager) List() []*types.Lease {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	var active []*types.Lease
-	for _, lease := range m.leases {
+	m.each(func(lease *types.Lease) {
 		if IsValid(lease) {
 			// Return a copy
 			leaseCopy := *lease
 			active = append(active, &leaseCopy)
 		}
-	}
+	})
 
 	return active
 }
 
+// Snapshot summarizes the active (non-expired, non-revoked) leases, grouped
+// by namespace and by secret.
+func (m *Manager) Snapshot() types.LeaseMetrics {
+	metrics := types.LeaseMetrics{
+		ByNamespace:   make(map[string]int),
+		BySecret:      make(map[string]int),
+		TotalAcquired: m.acquired.Load(),
+		MaxPerClient:  m.cfg.MaxLeasesPerClient,
+	}
+	m.each(func(lease *types.Lease) {
+		if !IsValid(lease) {
+			return
+		}
+
+		ns, _ := types.SplitRef(lease.SecretName)
+		metrics.Active++
+		metrics.ByNamespace[ns]++
+		metrics.BySecret[lease.SecretName]++
+		if metrics.SoonestExpiry.IsZero() || lease.ExpiresAt.Before(metrics.SoonestExpiry) {
+			metrics.SoonestExpiry = lease.ExpiresAt
+		}
+	})
+
+	return metrics
+}
+
 // CleanupExpired removes expired leases and logs expirations.
 func (m *Manager) CleanupExpired() {
-	m.mu.Lock()
-	var expired []string
-	for id, lease := range m.leases {
-		if !lease.Revoked && IsExpired(lease) {
-			expired = append(expired, id)
-
-			entry := audit.NewEntry(types.ActionLeaseExpire, true).
-				WithSecret(lease.SecretName).
-				WithClient(lease.ClientID).
-				WithLease(id).
-				Build()
-			_ = m.auditLogger.Log(entry)
+	var ended []types.Lease
+	for _, s := range m.shards {
+		s.mu.Lock()
+		for id, lease := range s.leases {
+			if !lease.Revoked && IsExpired(lease) {
+				ended = append(ended, *lease)
+				delete(s.leases, id)
+			}
 		}
+		s.mu.Unlock()
 	}
 
-	// Remove expired leases
-	for _, id := range expired {
-		delete(m.leases, id)
+	for _, lease := range ended {
+		entry := audit.NewEntry(types.ActionLeaseExpire, true).
+			WithSecret(lease.SecretName).
+			WithClient(lease.ClientID).
+			WithLease(lease.ID).
+			Build()
+		_ = m.auditLogger.Log(entry)
 	}
-	m.mu.Unlock()
 
-	if len(expired) > 0 {
+	if len(ended) > 0 {
 		_ = m.Save()
 	}
+	m.ended(ended)
 }
 
 // StartCleanupLoop starts a background goroutine that periodically cleans up expired leases.
@@ -250,16 +608,16 @@ func (m *Manager) StopCleanupLoop() {
 
 // Save persists non-expired, non-revoked leases to disk.
 func (m *Manager) Save() error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
 
 	// Only persist active leases
-	var toSave []*types.Lease
-	for _, lease := range m.leases {
+	var toSave []types.Lease
+	m.each(func(lease *types.Lease) {
 		if !lease.Revoked && !IsExpired(lease) {
-			toSave = append(toSave, lease)
+			toSave = append(toSave, *lease)
 		}
-	}
+	})
 
 	data, err := json.MarshalIndent(toSave, "", "  ")
 	if err != nil {
@@ -285,13 +643,13 @@ func (m *Manager) Load() error {
 		return fmt.Errorf("failed to unmarshal leases: %w", err)
 	}
 
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	// Load only non-expired, non-revoked leases
 	for _, lease := range leases {
 		if !lease.Revoked && !IsExpired(lease) {
-			m.leases[lease.ID] = lease
+			s := m.shard(lease.ID)
+			s.mu.Lock()
+			s.leases[lease.ID] = lease
+			s.mu.Unlock()
 		}
 	}
 
@@ -1,7 +1,12 @@
 package lease
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -43,8 +48,13 @@ func TestNewManager(t *testing.T) {
 		t.Fatal("NewManager returned nil")
 	}
 
-	if mgr.leases == nil {
-		t.Error("leases map is nil")
+	if len(mgr.shards) != leaseShards {
+		t.Errorf("got %d shards, want %d", len(mgr.shards), leaseShards)
+	}
+	for i, s := range mgr.shards {
+		if s.leases == nil {
+			t.Errorf("shard %d leases map is nil", i)
+		}
 	}
 }
 
@@ -162,6 +172,85 @@ func TestGet(t *testing.T) {
 	}
 }
 
+func TestAcquireWithReason(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease, err := mgr.AcquireWithReason("test-secret", "client-1", "ci deploy", time.Hour, types.Peer{})
+	if err != nil {
+		t.Fatalf("AcquireWithReason() failed: %v", err)
+	}
+	if lease.Reason != "ci deploy" {
+		t.Errorf("Reason = %q, want %q", lease.Reason, "ci deploy")
+	}
+	if retrieved, _ := mgr.Get(lease.ID); retrieved.Reason != "ci deploy" {
+		t.Errorf("stored Reason = %q, want %q", retrieved.Reason, "ci deploy")
+	}
+
+	entries, err := mgr.auditLogger.Tail(1)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != types.ActionLeaseAcquire || !strings.Contains(entries[0].Details, `reason: "ci deploy"`) {
+		t.Errorf("audit entry = %+v, want reason in details", entries)
+	}
+
+	// The reason is optional but bounded
+	if lease, err := mgr.Acquire("test-secret", "client-1", time.Hour); err != nil || lease.Reason != "" {
+		t.Errorf("Acquire() = %+v, %v", lease, err)
+	}
+	if _, err := mgr.AcquireWithReason("test-secret", "client-1", strings.Repeat("x", MaxReasonLength+1), time.Hour, types.Peer{}); !errors.Is(err, types.ErrLeaseReasonTooLong) {
+		t.Errorf("oversized reason error = %v, want %v", err, types.ErrLeaseReasonTooLong)
+	}
+	if _, err := mgr.AcquireWithReason("test-secret", "client-1", strings.Repeat("é", MaxReasonLength), time.Hour, types.Peer{}); err != nil {
+		t.Errorf("reason of %d characters rejected: %v", MaxReasonLength, err)
+	}
+}
+
+func TestAcquireEnforcesClientQuota(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	mgr.cfg.MaxLeasesPerClient = 2
+
+	var leases []*types.Lease
+	for i := 0; i < 2; i++ {
+		lease, err := mgr.Acquire("test-secret", "client-1", time.Hour)
+		if err != nil {
+			t.Fatalf("Acquire() %d failed: %v", i, err)
+		}
+		leases = append(leases, lease)
+	}
+
+	if _, err := mgr.Acquire("test-secret", "client-1", time.Hour); err != types.ErrLeaseQuotaExceeded {
+		t.Fatalf("Acquire() past quota error = %v, want %v", err, types.ErrLeaseQuotaExceeded)
+	}
+
+	// The quota is per client
+	if _, err := mgr.Acquire("test-secret", "client-2", time.Hour); err != nil {
+		t.Errorf("Acquire() for another client failed: %v", err)
+	}
+
+	// Revoking a lease frees its slot
+	if err := mgr.Revoke(leases[0].ID); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if _, err := mgr.Acquire("test-secret", "client-1", time.Hour); err != nil {
+		t.Errorf("Acquire() after revoke failed: %v", err)
+	}
+
+	if got := mgr.Snapshot().MaxPerClient; got != 2 {
+		t.Errorf("Snapshot().MaxPerClient = %d, want 2", got)
+	}
+}
+
+func TestAcquireUnlimitedByDefault(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	for i := 0; i < 50; i++ {
+		if _, err := mgr.Acquire("test-secret", "client-1", time.Hour); err != nil {
+			t.Fatalf("Acquire() %d failed: %v", i, err)
+		}
+	}
+}
+
 func TestRevoke(t *testing.T) {
 	mgr, _ := setupTestManager(t)
 
@@ -194,6 +283,157 @@ func TestRevoke(t *testing.T) {
 	}
 }
 
+func TestRenew(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease, err := mgr.Acquire("test-secret", "client-1", 1*time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	originalExpiry := lease.ExpiresAt
+
+	renewed, err := mgr.Renew(lease.ID, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Renew() failed: %v", err)
+	}
+	if !renewed.ExpiresAt.After(originalExpiry) {
+		t.Errorf("ExpiresAt = %v, want after %v", renewed.ExpiresAt, originalExpiry)
+	}
+	if renewed.ID != lease.ID || !renewed.CreatedAt.Equal(lease.CreatedAt) {
+		t.Errorf("renewal changed the lease identity: %+v", renewed)
+	}
+
+	stored, err := mgr.Get(lease.ID)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if !stored.ExpiresAt.Equal(renewed.ExpiresAt) {
+		t.Errorf("stored ExpiresAt = %v, want %v", stored.ExpiresAt, renewed.ExpiresAt)
+	}
+
+	// A shorter TTL never shortens the lease
+	again, err := mgr.Renew(lease.ID, time.Minute)
+	if err != nil {
+		t.Fatalf("Renew() failed: %v", err)
+	}
+	if !again.ExpiresAt.Equal(renewed.ExpiresAt) {
+		t.Errorf("ExpiresAt = %v, want unchanged %v", again.ExpiresAt, renewed.ExpiresAt)
+	}
+
+	if _, err := mgr.Renew("non-existent", time.Hour); err != types.ErrLeaseNotFound {
+		t.Errorf("Renew() error = %v, want %v", err, types.ErrLeaseNotFound)
+	}
+}
+
+func TestRenewEnforcesMaxTTL(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease, err := mgr.Acquire("test-secret", "client-1", 23*time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	originalExpiry := lease.ExpiresAt
+
+	// The limit applies to the whole lifetime, not just the renewal
+	if _, err := mgr.Renew(lease.ID, 24*time.Hour); !errors.Is(err, types.ErrInvalidTTL) {
+		t.Errorf("Renew() error = %v, want %v", err, types.ErrInvalidTTL)
+	}
+	stored, err := mgr.Get(lease.ID)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if !stored.ExpiresAt.Equal(originalExpiry) {
+		t.Error("rejected renewal changed ExpiresAt")
+	}
+
+	if _, err := mgr.Renew(lease.ID, 23*time.Hour+30*time.Minute); err != nil {
+		t.Errorf("Renew() within max lifetime failed: %v", err)
+	}
+}
+
+func TestRenewRejectsExpiredAndRevoked(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	expired, err := mgr.Acquire("test-secret", "client-1", 1*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := mgr.Renew(expired.ID, time.Hour); !errors.Is(err, types.ErrLeaseExpired) {
+		t.Errorf("Renew(expired) error = %v, want %v", err, types.ErrLeaseExpired)
+	}
+
+	revoked, err := mgr.Acquire("test-secret", "client-1", time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	if err := mgr.Revoke(revoked.ID); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if _, err := mgr.Renew(revoked.ID, time.Hour); !errors.Is(err, types.ErrLeaseRevoked) {
+		t.Errorf("Renew(revoked) error = %v, want %v", err, types.ErrLeaseRevoked)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease, err := mgr.Acquire("test-secret", "client-1", time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	got, err := mgr.Authorize(lease.ID, "test-secret")
+	if err != nil {
+		t.Fatalf("Authorize() failed: %v", err)
+	}
+	if got.ID != lease.ID || got.SecretName != "test-secret" {
+		t.Errorf("Authorize() = %+v, want lease %s", got, lease.ID)
+	}
+
+	if _, err := mgr.Authorize(lease.ID, "other-secret"); !errors.Is(err, types.ErrLeaseWrongSecret) {
+		t.Errorf("Authorize(other secret) error = %v, want %v", err, types.ErrLeaseWrongSecret)
+	}
+	if _, err := mgr.Authorize("nonexistent", "test-secret"); !errors.Is(err, types.ErrLeaseNotFound) {
+		t.Errorf("Authorize(nonexistent) error = %v, want %v", err, types.ErrLeaseNotFound)
+	}
+
+	expired, err := mgr.Acquire("test-secret", "client-1", 1*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := mgr.Authorize(expired.ID, "test-secret"); !errors.Is(err, types.ErrLeaseExpired) {
+		t.Errorf("Authorize(expired) error = %v, want %v", err, types.ErrLeaseExpired)
+	}
+
+	if err := mgr.Revoke(lease.ID); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	if _, err := mgr.Authorize(lease.ID, "test-secret"); !errors.Is(err, types.ErrLeaseRevoked) {
+		t.Errorf("Authorize(revoked) error = %v, want %v", err, types.ErrLeaseRevoked)
+	}
+
+	// Every check is audited, successful or not
+	entries, err := mgr.auditLogger.Tail(20)
+	if err != nil {
+		t.Fatalf("Tail() failed: %v", err)
+	}
+	var succeeded, failed int
+	for _, entry := range entries {
+		if entry.Action != types.ActionLeaseFetch {
+			continue
+		}
+		if entry.Success {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+	if succeeded != 1 || failed != 4 {
+		t.Errorf("audited %d successful and %d failed fetches, want 1 and 4", succeeded, failed)
+	}
+}
+
 func TestRevokeAll(t *testing.T) {
 	mgr, _ := setupTestManager(t)
 
@@ -259,6 +499,170 @@ func TestRevokeBySecret(t *testing.T) {
 	}
 }
 
+func TestAcquireFromRecordsPeer(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	peer := types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "agent-a"}
+	lease, err := mgr.AcquireFrom("secret-1", "client-1", 1*time.Hour, peer)
+	if err != nil {
+		t.Fatalf("AcquireFrom() failed: %v", err)
+	}
+
+	retrieved, _ := mgr.Get(lease.ID)
+	if retrieved.RemoteAddr != peer.RemoteAddr || retrieved.ClientCN != peer.ClientCN {
+		t.Errorf("expected peer %+v on lease, got addr=%q cn=%q", peer, retrieved.RemoteAddr, retrieved.ClientCN)
+	}
+
+	entries, err := mgr.auditLogger.Tail(1)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if entries[0].ClientCN != "agent-a" || entries[0].RemoteAddr != "10.0.0.1:5000" {
+		t.Errorf("expected peer in audit entry, got %+v", entries[0])
+	}
+}
+
+func TestAcquireWithJitter(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	mgr.cfg.LeaseTTLJitter = 20
+
+	const ttl = 1 * time.Hour
+	minTTL, maxTTL := 48*time.Minute, 72*time.Minute
+
+	expiries := make(map[time.Duration]bool)
+	for i := 0; i < 50; i++ {
+		lease, err := mgr.Acquire("secret-1", "client-1", ttl)
+		if err != nil {
+			t.Fatalf("Acquire() failed: %v", err)
+		}
+		granted := lease.ExpiresAt.Sub(lease.CreatedAt)
+		if granted < minTTL || granted > maxTTL {
+			t.Errorf("granted TTL %v outside [%v, %v]", granted, minTTL, maxTTL)
+		}
+		expiries[granted] = true
+	}
+	if len(expiries) < 10 {
+		t.Errorf("expected a spread of expiries, got %d distinct TTLs", len(expiries))
+	}
+}
+
+func TestAcquireWithJitterNeverExceedsMax(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	mgr.cfg.LeaseTTLJitter = 50
+
+	below := false
+	for i := 0; i < 50; i++ {
+		lease, err := mgr.Acquire("secret-1", "client-1", mgr.cfg.MaxLeaseTTL)
+		if err != nil {
+			t.Fatalf("Acquire() failed: %v", err)
+		}
+		granted := lease.ExpiresAt.Sub(lease.CreatedAt)
+		if granted > mgr.cfg.MaxLeaseTTL {
+			t.Fatalf("granted TTL %v exceeds max %v", granted, mgr.cfg.MaxLeaseTTL)
+		}
+		if granted < mgr.cfg.MaxLeaseTTL {
+			below = true
+		}
+	}
+	if !below {
+		t.Error("expected jitter to shorten some leases requested at the max TTL")
+	}
+}
+
+func TestAcquireWithoutJitter(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease, err := mgr.Acquire("secret-1", "client-1", 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	if granted := lease.ExpiresAt.Sub(lease.CreatedAt); granted != 30*time.Minute {
+		t.Errorf("granted TTL = %v, want exactly 30m without jitter", granted)
+	}
+}
+
+func TestRevokeByClientCN(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	peerA := types.Peer{RemoteAddr: "10.0.0.1:5000", ClientCN: "agent-a"}
+	peerB := types.Peer{RemoteAddr: "10.0.0.2:5000", ClientCN: "agent-b"}
+
+	lease1, _ := mgr.AcquireFrom("secret-1", "client-1", 1*time.Hour, peerA)
+	lease2, _ := mgr.AcquireFrom("secret-2", "client-1", 1*time.Hour, peerA)
+	lease3, _ := mgr.AcquireFrom("secret-1", "client-2", 1*time.Hour, peerB)
+
+	count, err := mgr.RevokeByClientCN("agent-a")
+	if err != nil {
+		t.Fatalf("RevokeByClientCN() failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 leases revoked, got %d", count)
+	}
+
+	for _, id := range []string{lease1.ID, lease2.ID} {
+		if retrieved, _ := mgr.Get(id); !retrieved.Revoked {
+			t.Errorf("lease %s for agent-a should be revoked", id)
+		}
+	}
+	if retrieved, _ := mgr.Get(lease3.ID); retrieved.Revoked {
+		t.Error("lease for agent-b should not be revoked")
+	}
+}
+
+func TestRevokeByClient(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease1, _ := mgr.Acquire("secret-1", "agent-a", 1*time.Hour)
+	lease2, _ := mgr.Acquire("secret-2", "agent-a", 1*time.Hour)
+	lease3, _ := mgr.Acquire("secret-1", "agent-b", 1*time.Hour)
+
+	if count := mgr.RevokeByClient("agent-a"); count != 2 {
+		t.Errorf("expected 2 leases revoked, got %d", count)
+	}
+
+	for _, id := range []string{lease1.ID, lease2.ID} {
+		if retrieved, _ := mgr.Get(id); !retrieved.Revoked {
+			t.Errorf("lease %s for agent-a should be revoked", id)
+		}
+	}
+	if retrieved, _ := mgr.Get(lease3.ID); retrieved.Revoked {
+		t.Error("lease for agent-b should not be revoked")
+	}
+
+	// Already-revoked leases are not counted again
+	if count := mgr.RevokeByClient("agent-a"); count != 0 {
+		t.Errorf("expected 0 leases revoked on repeat, got %d", count)
+	}
+}
+
+func TestRevokeByNamespace(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	lease1, _ := mgr.Acquire("prod::db", "client-1", 1*time.Hour)
+	lease2, _ := mgr.Acquire("prod::api", "client-2", 1*time.Hour)
+	lease3, _ := mgr.Acquire("stage::db", "client-1", 1*time.Hour)
+	lease4, _ := mgr.Acquire("db", "client-1", 1*time.Hour)
+
+	count, err := mgr.RevokeByNamespace("prod")
+	if err != nil {
+		t.Fatalf("RevokeByNamespace() failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 leases revoked, got %d", count)
+	}
+
+	for _, id := range []string{lease1.ID, lease2.ID} {
+		if retrieved, _ := mgr.Get(id); !retrieved.Revoked {
+			t.Errorf("lease %s in prod should be revoked", id)
+		}
+	}
+	for _, id := range []string{lease3.ID, lease4.ID} {
+		if retrieved, _ := mgr.Get(id); retrieved.Revoked {
+			t.Errorf("lease %s outside prod should not be revoked", id)
+		}
+	}
+}
+
 func TestList(t *testing.T) {
 	mgr, _ := setupTestManager(t)
 
@@ -282,6 +686,80 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestSnapshot(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	// Empty manager
+	snap := mgr.Snapshot()
+	if snap.Active != 0 || snap.TotalAcquired != 0 || !snap.SoonestExpiry.IsZero() {
+		t.Errorf("empty snapshot = %+v", snap)
+	}
+
+	leases := []struct {
+		secret string
+		ttl    time.Duration
+	}{
+		{"api_key", 1 * time.Hour},
+		{"api_key", 2 * time.Hour},
+		{"prod::db_password", 10 * time.Minute},
+		{"prod::deploy_key", 3 * time.Hour},
+		{"staging::db_password", 30 * time.Minute},
+	}
+	var acquired []*types.Lease
+	for _, l := range leases {
+		lease, err := mgr.Acquire(l.secret, "client", l.ttl)
+		if err != nil {
+			t.Fatalf("Acquire(%s) failed: %v", l.secret, err)
+		}
+		acquired = append(acquired, lease)
+	}
+
+	// Revoked leases are excluded from active counts but not from the total
+	if err := mgr.Revoke(acquired[1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	snap = mgr.Snapshot()
+	if snap.Active != 4 {
+		t.Errorf("Active = %d, want 4", snap.Active)
+	}
+	if snap.TotalAcquired != 5 {
+		t.Errorf("TotalAcquired = %d, want 5", snap.TotalAcquired)
+	}
+
+	wantNS := map[string]int{types.DefaultNamespace: 1, "prod": 2, "staging": 1}
+	if len(snap.ByNamespace) != len(wantNS) {
+		t.Errorf("ByNamespace = %v, want %v", snap.ByNamespace, wantNS)
+	}
+	for ns, n := range wantNS {
+		if snap.ByNamespace[ns] != n {
+			t.Errorf("ByNamespace[%q] = %d, want %d", ns, snap.ByNamespace[ns], n)
+		}
+	}
+
+	wantSecrets := map[string]int{"api_key": 1, "prod::db_password": 1, "prod::deploy_key": 1, "staging::db_password": 1}
+	if len(snap.BySecret) != len(wantSecrets) {
+		t.Errorf("BySecret = %v, want %v", snap.BySecret, wantSecrets)
+	}
+	for secret, n := range wantSecrets {
+		if snap.BySecret[secret] != n {
+			t.Errorf("BySecret[%q] = %d, want %d", secret, snap.BySecret[secret], n)
+		}
+	}
+
+	if !snap.SoonestExpiry.Equal(acquired[2].ExpiresAt) {
+		t.Errorf("SoonestExpiry = %v, want %v (prod::db_password)", snap.SoonestExpiry, acquired[2].ExpiresAt)
+	}
+
+	// Revoking the soonest lease moves the expiry to the next one
+	if err := mgr.Revoke(acquired[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if snap = mgr.Snapshot(); !snap.SoonestExpiry.Equal(acquired[4].ExpiresAt) {
+		t.Errorf("SoonestExpiry = %v, want %v (staging::db_password)", snap.SoonestExpiry, acquired[4].ExpiresAt)
+	}
+}
+
 func TestCleanupExpired(t *testing.T) {
 	mgr, _ := setupTestManager(t)
 
@@ -304,6 +782,49 @@ func TestCleanupExpired(t *testing.T) {
 	}
 }
 
+func TestEndHook(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	var ended []string
+	mgr.SetEndHook(func(lease types.Lease) {
+		ended = append(ended, lease.ID)
+	})
+
+	revoked, err := mgr.Acquire("test-secret", "client-1", time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	expiring, err := mgr.Acquire("test-secret", "client-2", 1*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+
+	if err := mgr.Revoke(revoked.ID); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	// A second revoke does not end the lease again
+	if err := mgr.Revoke(revoked.ID); err != nil {
+		t.Fatalf("Revoke() failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mgr.CleanupExpired()
+
+	if len(ended) != 2 || ended[0] != revoked.ID || ended[1] != expiring.ID {
+		t.Errorf("ended = %v, want [%s %s]", ended, revoked.ID, expiring.ID)
+	}
+
+	ended = nil
+	if _, err := mgr.Acquire("other-secret", "client-1", time.Hour); err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	if err := mgr.RevokeAll(); err != nil {
+		t.Fatalf("RevokeAll() failed: %v", err)
+	}
+	if len(ended) != 1 {
+		t.Errorf("RevokeAll ended %d leases, want 1", len(ended))
+	}
+}
+
 func TestSaveLoad(t *testing.T) {
 	mgr, tmpDir := setupTestManager(t)
 
@@ -472,3 +993,142 @@ func TestIsExpiredHelper(t *testing.T) {
 		t.Error("TimeRemaining() should return positive duration for valid lease")
 	}
 }
+
+func TestConcurrentAcquireRevoke(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+
+	const workers, perWorker = 16, 25
+	var mu sync.Mutex
+	kept := make(map[string]bool)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				lease, err := mgr.Acquire(fmt.Sprintf("secret-%d", i%4), fmt.Sprintf("client-%d", w), time.Hour)
+				if err != nil {
+					t.Errorf("Acquire() failed: %v", err)
+					return
+				}
+				// Revoke every third lease while other workers acquire
+				if i%3 == 0 {
+					if err := mgr.Revoke(lease.ID); err != nil {
+						t.Errorf("Revoke() failed: %v", err)
+					}
+					continue
+				}
+				mu.Lock()
+				kept[lease.ID] = true
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	active := mgr.List()
+	if len(active) != len(kept) {
+		t.Fatalf("List() returned %d leases, want %d", len(active), len(kept))
+	}
+	for _, lease := range active {
+		if !kept[lease.ID] {
+			t.Errorf("unexpected active lease %s", lease.ID)
+		}
+	}
+	if got := mgr.Snapshot().TotalAcquired; got != workers*perWorker {
+		t.Errorf("TotalAcquired = %d, want %d", got, workers*perWorker)
+	}
+
+	// The last save holds every active lease
+	mgr2, err := NewManager(mgr.cfg, mgr.auditLogger)
+	if err != nil {
+		t.Fatalf("NewManager() failed: %v", err)
+	}
+	for id := range kept {
+		if _, err := mgr2.Get(id); err != nil {
+			t.Errorf("lease %s not persisted: %v", id, err)
+		}
+	}
+}
+
+func TestConcurrentAcquireEnforcesClientQuota(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	mgr.cfg.MaxLeasesPerClient = 5
+
+	var granted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := mgr.Acquire("test-secret", "client-1", time.Hour); err == nil {
+				granted.Add(1)
+			} else if err != types.ErrLeaseQuotaExceeded {
+				t.Errorf("Acquire() error = %v, want %v", err, types.ErrLeaseQuotaExceeded)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := granted.Load(); got != 5 {
+		t.Errorf("granted %d leases, want the quota of 5", got)
+	}
+}
+
+// BenchmarkManagerContention runs Get and List from many goroutines while
+// leases are acquired, with a single shard (the old single lock) and with
+// the default sharding.
+func BenchmarkManagerContention(b *testing.B) {
+	for _, shards := range []int{1, leaseShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			tmpDir := b.TempDir()
+			cfg := &config.Config{
+				Directory:       tmpDir,
+				LeasesPath:      filepath.Join(tmpDir, "leases.json"),
+				AuditPath:       filepath.Join(tmpDir, "audit.log"),
+				DefaultLeaseTTL: 1 * time.Hour,
+				MaxLeaseTTL:     24 * time.Hour,
+			}
+			auditLogger, err := audit.New(cfg.AuditPath)
+			if err != nil {
+				b.Fatalf("failed to create audit logger: %v", err)
+			}
+			defer auditLogger.Close()
+
+			mgr, err := newManager(cfg, auditLogger, shards)
+			if err != nil {
+				b.Fatalf("failed to create manager: %v", err)
+			}
+			var ids []string
+			for i := 0; i < 256; i++ {
+				lease, err := mgr.Acquire("test-secret", "client-1", time.Hour)
+				if err != nil {
+					b.Fatal(err)
+				}
+				ids = append(ids, lease.ID)
+			}
+
+			var n atomic.Uint64
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					i := n.Add(1)
+					switch {
+					case i%500 == 0:
+						if _, err := mgr.Acquire("test-secret", "client-2", time.Hour); err != nil {
+							b.Error(err)
+						}
+					case i%50 == 0:
+						mgr.List()
+					default:
+						if _, err := mgr.Get(ids[i%uint64(len(ids))]); err != nil {
+							b.Error(err)
+						}
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package mcp serves the daemon's operations as Model Context Protocol tools
+// over stdio, so LLM agents can list, lease and revoke secrets without
+// shelling out to the CLI.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// ProtocolVersion is the MCP revision the server implements.
+const ProtocolVersion = "2025-06-18"
+
+// MCP method names
+const (
+	MethodInitialize  = "initialize"
+	MethodInitialized = "notifications/initialized"
+	MethodPing        = "ping"
+	MethodToolsList   = "tools/list"
+	MethodToolsCall   = "tools/call"
+)
+
+// codeParseError is the JSON-RPC code for frames that are not valid JSON
+const codeParseError = -32700
+
+// maxFrameSize bounds a single newline-delimited frame read from stdin
+const maxFrameSize = 4 << 20
+
+// CallFunc sends a JSON-RPC request to the daemon. It returns an error for
+// transport failures and RPC errors alike.
+type CallFunc func(method string, params interface{}) (*types.RPCResponse, error)
+
+// request is an incoming JSON-RPC frame. ID is kept raw so it can be echoed
+// back unchanged; it is absent for notifications.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Server answers MCP requests by calling the daemon.
+type Server struct {
+	call     CallFunc
+	version  string
+	clientID string
+}
+
+// NewServer creates a Server that calls the daemon through call. version is
+// reported to clients; clientID is recorded on the leases it acquires.
+func NewServer(call CallFunc, version, clientID string) *Server {
+	return &Server{
+		call:     call,
+		version:  version,
+		clientID: clientID,
+	}
+}
+
+// Serve reads newline-delimited JSON-RPC frames from r and writes responses
+// to w until r is exhausted. Frames are handled in order.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFrameSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.Handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := s.write(w, resp); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read request: %w", err)
+	}
+	return nil
+}
+
+// write encodes resp as one line on w.
+func (s *Server) write(w io.Writer, resp *types.RPCResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encode response: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write response: %w", err)
+	}
+	return nil
+}
+
+// Handle processes one frame and returns its response, or nil for
+// notifications, which get none.
+func (s *Server) Handle(frame []byte) *types.RPCResponse {
+	var req request
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return errorResponse(nil, codeParseError, fmt.Sprintf("parse error: %v", err))
+	}
+	notification := len(req.ID) == 0
+
+	var result interface{}
+	var rpcErr *types.RPCError
+	switch req.Method {
+	case MethodInitialize:
+		result = s.initialize()
+	case MethodInitialized:
+		return nil
+	case MethodPing:
+		result = struct{}{}
+	case MethodToolsList:
+		result = map[string]interface{}{"tools": tools}
+	case MethodToolsCall:
+		result, rpcErr = s.callTool(req.Params)
+	default:
+		rpcErr = &types.RPCError{Code: types.RPCMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+
+	if notification {
+		return nil
+	}
+	resp := &types.RPCResponse{JSONRPC: "2.0", ID: req.ID}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+// initialize answers the MCP handshake.
+func (s *Server) initialize() map[string]interface{} {
+	return map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    "agent-secrets",
+			"version": s.version,
+		},
+		"instructions": "Use lease to read a secret: it returns the value with a lease that expires. " +
+			"Revoke the lease when done. Secret values cannot be read any other way.",
+	}
+}
+
+// errorResponse builds a JSON-RPC error response.
+func errorResponse(id json.RawMessage, code int, message string) *types.RPCResponse {
+	resp := &types.RPCResponse{
+		JSONRPC: "2.0",
+		Error:   &types.RPCError{Code: code, Message: message},
+	}
+	if len(id) > 0 {
+		resp.ID = id
+	}
+	return resp
+}
@@ -0,0 +1,287 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// fakeDaemon records calls and answers them from results
+type fakeDaemon struct {
+	calls   []string
+	params  []interface{}
+	results map[string]interface{}
+	errs    map[string]error
+}
+
+func (d *fakeDaemon) call(method string, params interface{}) (*types.RPCResponse, error) {
+	d.calls = append(d.calls, method)
+	d.params = append(d.params, params)
+	if err := d.errs[method]; err != nil {
+		return nil, err
+	}
+	return &types.RPCResponse{JSONRPC: "2.0", Result: d.results[method], ID: 1}, nil
+}
+
+// response is a decoded output frame
+type response struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *types.RPCError `json:"error"`
+}
+
+type callResult struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent"`
+	IsError           bool            `json:"isError"`
+}
+
+// serve feeds frames to a server backed by d and returns its responses.
+func serve(t *testing.T, d *fakeDaemon, frames ...string) []response {
+	t.Helper()
+	var out bytes.Buffer
+	s := NewServer(d.call, "test", "mcp-test")
+	if err := s.Serve(strings.NewReader(strings.Join(frames, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve failed: %v", err)
+	}
+
+	var resps []response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid response frame: %v", err)
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+// toolCall builds a tools/call frame.
+func toolCall(id int, name, args string) string {
+	return `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"method":"tools/call","params":{"name":"` + name + `","arguments":` + args + `}}`
+}
+
+func decodeCall(t *testing.T, r response) callResult {
+	t.Helper()
+	if r.Error != nil {
+		t.Fatalf("unexpected RPC error: %+v", r.Error)
+	}
+	var res callResult
+	if err := json.Unmarshal(r.Result, &res); err != nil {
+		t.Fatalf("invalid tools/call result: %v", err)
+	}
+	return res
+}
+
+func TestServe_Handshake(t *testing.T) {
+	resps := serve(t, &fakeDaemon{},
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+	)
+	// The notification gets no response
+	if len(resps) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(resps))
+	}
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    struct {
+			Tools *struct{} `json:"tools"`
+		} `json:"capabilities"`
+	}
+	if err := json.Unmarshal(resps[0].Result, &init); err != nil {
+		t.Fatalf("invalid initialize result: %v", err)
+	}
+	if init.ProtocolVersion != ProtocolVersion || init.Capabilities.Tools == nil {
+		t.Errorf("unexpected initialize result: %s", resps[0].Result)
+	}
+
+	var list struct {
+		Tools []Tool `json:"tools"`
+	}
+	if err := json.Unmarshal(resps[1].Result, &list); err != nil {
+		t.Fatalf("invalid tools/list result: %v", err)
+	}
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		if !json.Valid(tool.InputSchema) {
+			t.Errorf("tool %s has an invalid schema", tool.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "list,lease,revoke,status,scan" {
+		t.Errorf("tools = %s", got)
+	}
+}
+
+func TestServe_Lease(t *testing.T) {
+	d := &fakeDaemon{results: map[string]interface{}{
+		daemon.MethodLease: daemon.LeaseResult{LeaseID: "lease-1", Value: "s3cret", ExpiresAt: time.Unix(0, 0).UTC()},
+	}}
+	resps := serve(t, d, toolCall(1, "lease", `{"name":"github_token","ttl":"30m","reason":"deploy"}`))
+
+	if len(d.calls) != 1 || d.calls[0] != daemon.MethodLease {
+		t.Fatalf("expected one %s call, got %v", daemon.MethodLease, d.calls)
+	}
+	want := daemon.LeaseParams{SecretName: "github_token", ClientID: "mcp-test", TTL: "30m", Reason: "deploy"}
+	if d.params[0] != want {
+		t.Errorf("params = %+v, want %+v", d.params[0], want)
+	}
+
+	res := decodeCall(t, resps[0])
+	if res.IsError {
+		t.Fatalf("unexpected tool error: %+v", res)
+	}
+	var lease daemon.LeaseResult
+	if err := json.Unmarshal(res.StructuredContent, &lease); err != nil {
+		t.Fatalf("invalid structured content: %v", err)
+	}
+	if lease.LeaseID != "lease-1" || lease.Value != "s3cret" {
+		t.Errorf("unexpected lease: %+v", lease)
+	}
+	if len(res.Content) != 1 || res.Content[0].Type != "text" || !strings.Contains(res.Content[0].Text, "lease-1") {
+		t.Errorf("unexpected text content: %+v", res.Content)
+	}
+}
+
+func TestServe_LeaseDefaultTTL(t *testing.T) {
+	d := &fakeDaemon{}
+	serve(t, d, toolCall(1, "lease", `{"name":"github_token"}`))
+
+	if p, ok := d.params[0].(daemon.LeaseParams); !ok || p.TTL != defaultLeaseTTL {
+		t.Errorf("params = %+v, want TTL %s", d.params[0], defaultLeaseTTL)
+	}
+}
+
+func TestServe_DaemonCalls(t *testing.T) {
+	tests := []struct {
+		tool   string
+		args   string
+		method string
+		params interface{}
+	}{
+		{"list", `{}`, daemon.MethodList, daemon.ListParams{}},
+		{"revoke", `{"lease_id":"lease-1"}`, daemon.MethodRevoke, daemon.RevokeParams{LeaseID: "lease-1"}},
+		{"status", `{}`, daemon.MethodStatus, daemon.StatusParams{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			d := &fakeDaemon{results: map[string]interface{}{tt.method: map[string]interface{}{"ok": true}}}
+			resps := serve(t, d, toolCall(1, tt.tool, tt.args))
+
+			if len(d.calls) != 1 || d.calls[0] != tt.method {
+				t.Fatalf("expected one %s call, got %v", tt.method, d.calls)
+			}
+			if !reflect.DeepEqual(d.params[0], tt.params) {
+				t.Errorf("params = %+v, want %+v", d.params[0], tt.params)
+			}
+			res := decodeCall(t, resps[0])
+			if res.IsError || string(res.StructuredContent) != `{"ok":true}` {
+				t.Errorf("unexpected result: %+v", res)
+			}
+		})
+	}
+}
+
+func TestServe_DaemonError(t *testing.T) {
+	d := &fakeDaemon{errs: map[string]error{
+		daemon.MethodLease: errors.New("RPC error -32001: secret not found"),
+	}}
+	resps := serve(t, d, toolCall(1, "lease", `{"name":"missing"}`))
+
+	res := decodeCall(t, resps[0])
+	if !res.IsError {
+		t.Fatal("expected a tool error")
+	}
+	if len(res.Content) != 1 || !strings.Contains(res.Content[0].Text, "secret not found") {
+		t.Errorf("unexpected error content: %+v", res.Content)
+	}
+}
+
+func TestServe_InvalidCalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		code  int
+	}{
+		{"get is refused", toolCall(1, "get", `{"name":"github_token"}`), types.RPCInvalidParams},
+		{"unknown tool", toolCall(1, "delete", `{}`), types.RPCInvalidParams},
+		{"lease without name", toolCall(1, "lease", `{}`), types.RPCInvalidParams},
+		{"revoke without lease", toolCall(1, "revoke", `{}`), types.RPCInvalidParams},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`, types.RPCMethodNotFound},
+		{"parse error", `{not json`, codeParseError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeDaemon{}
+			resps := serve(t, d, tt.frame)
+			if len(resps) != 1 || resps[0].Error == nil {
+				t.Fatalf("expected an error response, got %+v", resps)
+			}
+			if resps[0].Error.Code != tt.code {
+				t.Errorf("code = %d, want %d", resps[0].Error.Code, tt.code)
+			}
+			if len(d.calls) != 0 {
+				t.Errorf("daemon should not be called, got %v", d.calls)
+			}
+		})
+	}
+}
+
+func TestServe_Scan(t *testing.T) {
+	dir := t.TempDir()
+	key := "AKIA" + "IOSFODNN7EXAMPLE"
+	if err := os.WriteFile(filepath.Join(dir, "config.py"), []byte("aws_key = \""+key+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &fakeDaemon{}
+	args, _ := json.Marshal(map[string]string{"path": dir})
+	resps := serve(t, d, toolCall(1, "scan", string(args)))
+
+	res := decodeCall(t, resps[0])
+	if res.IsError {
+		t.Fatalf("unexpected tool error: %+v", res)
+	}
+	var data struct {
+		Count    int `json:"count"`
+		Findings []struct {
+			File string `json:"file"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal(res.StructuredContent, &data); err != nil {
+		t.Fatalf("invalid structured content: %v", err)
+	}
+	if data.Count == 0 || len(data.Findings) != data.Count {
+		t.Errorf("expected findings, got %s", res.StructuredContent)
+	}
+	if strings.Contains(string(res.StructuredContent), key) {
+		t.Error("scan result contains the unredacted value")
+	}
+	if len(d.calls) != 0 {
+		t.Errorf("scan should not call the daemon, got %v", d.calls)
+	}
+}
+
+func TestTools_NoValues(t *testing.T) {
+	for _, tool := range tools {
+		if strings.Contains(string(tool.InputSchema), `"value"`) {
+			t.Errorf("tool %s schema mentions a value field", tool.Name)
+		}
+	}
+}
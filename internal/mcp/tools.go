@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/joelhooks/agent-secrets/internal/daemon"
+	"github.com/joelhooks/agent-secrets/internal/scanner"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// Tool describes a tool in tools/list. Descriptions and schemas only ever
+// name secrets' fields, never their values.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// Tool names
+const (
+	ToolList   = "list"
+	ToolLease  = "lease"
+	ToolRevoke = "revoke"
+	ToolStatus = "status"
+	ToolScan   = "scan"
+)
+
+// defaultLeaseTTL matches the default of 'secrets lease'
+const defaultLeaseTTL = "1h"
+
+// scanExcludes are skipped by the scan tool, as by 'secrets scan'
+var scanExcludes = []string{"node_modules", ".git", ".hg", ".svn", "vendor", "dist", "build"}
+
+var tools = []Tool{
+	{
+		Name:        ToolList,
+		Description: "List the names and metadata of stored secrets. Values are never included; use lease to read one.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{}}`),
+	},
+	{
+		Name:        ToolLease,
+		Description: "Acquire a time-bounded lease on a secret. Returns the secret value and a lease_id; revoke the lease when finished.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"name":{"type":"string","description":"Secret name, optionally namespace::name"},` +
+			`"ttl":{"type":"string","description":"Lease duration, e.g. 30m or 1h (default 1h)"},` +
+			`"reason":{"type":"string","description":"Why the secret is needed, recorded in the audit log"}` +
+			`},"required":["name"]}`),
+	},
+	{
+		Name:        ToolRevoke,
+		Description: "Revoke a lease before it expires.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"lease_id":{"type":"string","description":"Lease ID returned by lease"}` +
+			`},"required":["lease_id"]}`),
+	},
+	{
+		Name:        ToolStatus,
+		Description: "Show the daemon status: uptime, secret count and active leases.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{}}`),
+	},
+	{
+		Name:        ToolScan,
+		Description: "Scan a file or directory for hardcoded secrets. Matched values are redacted.",
+		InputSchema: json.RawMessage(`{"type":"object","properties":{` +
+			`"path":{"type":"string","description":"File or directory to scan"},` +
+			`"recursive":{"type":"boolean","description":"Scan directories recursively (default true)"}` +
+			`},"required":["path"]}`),
+	},
+}
+
+// callParams are the params of tools/call
+type callParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// toolResult is the result of tools/call. Failures of the tool itself, such
+// as an unknown secret, are reported here with IsError so the model can see
+// them; only malformed calls are JSON-RPC errors.
+type toolResult struct {
+	Content           []content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	IsError           bool        `json:"isError,omitempty"`
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type leaseArgs struct {
+	Name   string `json:"name"`
+	TTL    string `json:"ttl"`
+	Reason string `json:"reason"`
+}
+
+type revokeArgs struct {
+	LeaseID string `json:"lease_id"`
+}
+
+type scanArgs struct {
+	Path      string `json:"path"`
+	Recursive *bool  `json:"recursive"`
+}
+
+// callTool runs a tools/call request.
+func (s *Server) callTool(raw json.RawMessage) (interface{}, *types.RPCError) {
+	var p callParams
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, invalidParams("invalid tools/call params: %v", err)
+	}
+	if len(p.Arguments) == 0 {
+		p.Arguments = json.RawMessage(`{}`)
+	}
+
+	switch p.Name {
+	case ToolList:
+		return s.daemonCall(daemon.MethodList, daemon.ListParams{}), nil
+
+	case ToolLease:
+		var args leaseArgs
+		if err := json.Unmarshal(p.Arguments, &args); err != nil {
+			return nil, invalidParams("invalid lease arguments: %v", err)
+		}
+		if args.Name == "" {
+			return nil, invalidParams("lease requires a name")
+		}
+		if args.TTL == "" {
+			args.TTL = defaultLeaseTTL
+		}
+		return s.daemonCall(daemon.MethodLease, daemon.LeaseParams{
+			SecretName: args.Name,
+			ClientID:   s.clientID,
+			TTL:        args.TTL,
+			Reason:     args.Reason,
+		}), nil
+
+	case ToolRevoke:
+		var args revokeArgs
+		if err := json.Unmarshal(p.Arguments, &args); err != nil {
+			return nil, invalidParams("invalid revoke arguments: %v", err)
+		}
+		if args.LeaseID == "" {
+			return nil, invalidParams("revoke requires a lease_id")
+		}
+		return s.daemonCall(daemon.MethodRevoke, daemon.RevokeParams{LeaseID: args.LeaseID}), nil
+
+	case ToolStatus:
+		return s.daemonCall(daemon.MethodStatus, daemon.StatusParams{}), nil
+
+	case ToolScan:
+		var args scanArgs
+		if err := json.Unmarshal(p.Arguments, &args); err != nil {
+			return nil, invalidParams("invalid scan arguments: %v", err)
+		}
+		if args.Path == "" {
+			return nil, invalidParams("scan requires a path")
+		}
+		return scan(args), nil
+
+	case "get":
+		return nil, invalidParams("secrets cannot be read directly; use the lease tool")
+
+	default:
+		return nil, invalidParams("unknown tool: %s", p.Name)
+	}
+}
+
+// daemonCall runs method on the daemon and wraps the outcome as a tool result.
+func (s *Server) daemonCall(method string, params interface{}) toolResult {
+	resp, err := s.call(method, params)
+	if err != nil {
+		return errorResult(err)
+	}
+	return structuredResult(resp.Result)
+}
+
+// scan runs the secret scanner locally; it needs no daemon.
+func scan(args scanArgs) toolResult {
+	recursive := true
+	if args.Recursive != nil {
+		recursive = *args.Recursive
+	}
+
+	result, err := scanner.NewScanner(scanner.DefaultPatterns(), scanExcludes).
+		WithRecursive(recursive).
+		Scan(args.Path)
+	if err != nil {
+		return errorResult(fmt.Errorf("scan failed: %w", err))
+	}
+	return structuredResult(map[string]interface{}{
+		"findings":      scanner.Report{Findings: result.Findings},
+		"count":         len(result.Findings),
+		"scanned_files": result.ScannedFiles,
+	})
+}
+
+// structuredResult returns v as both structured content and its JSON text,
+// for clients that only read text content.
+func structuredResult(v interface{}) toolResult {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorResult(fmt.Errorf("failed to encode result: %w", err))
+	}
+	return toolResult{
+		Content:           []content{{Type: "text", Text: string(data)}},
+		StructuredContent: json.RawMessage(data),
+	}
+}
+
+// errorResult reports a failed tool call to the model.
+func errorResult(err error) toolResult {
+	return toolResult{
+		Content: []content{{Type: "text", Text: err.Error()}},
+		IsError: true,
+	}
+}
+
+// invalidParams builds a JSON-RPC invalid params error.
+func invalidParams(format string, args ...interface{}) *types.RPCError {
+	return &types.RPCError{Code: types.RPCInvalidParams, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,41 @@
+package output
+
+import "os"
+
+// ANSI escape sequences for the human output markers
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// NoColor disables colored output (--no-color). Color is also off when the
+// NO_COLOR environment variable is set or stdout is not a terminal.
+var NoColor bool
+
+// stdoutIsTerminal reports whether stdout is a terminal; replaced in tests.
+var stdoutIsTerminal = func() bool {
+	return isTerminal(os.Stdout.Fd())
+}
+
+// colorEnabled reports whether human output should be colored.
+func colorEnabled() bool {
+	return !NoColor && os.Getenv("NO_COLOR") == "" && stdoutIsTerminal()
+}
+
+func colorize(color, s string) string {
+	if !colorEnabled() {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// green marks success.
+func green(s string) string { return colorize(colorGreen, s) }
+
+// red marks errors.
+func red(s string) string { return colorize(colorRed, s) }
+
+// yellow marks warnings and dangerous actions.
+func yellow(s string) string { return colorize(colorYellow, s) }
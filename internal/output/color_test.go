@@ -0,0 +1,59 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+// forceTerminal makes colorEnabled see stdout as a terminal or not.
+func forceTerminal(t *testing.T, tty bool) {
+	t.Helper()
+	orig := stdoutIsTerminal
+	stdoutIsTerminal = func() bool { return tty }
+	t.Cleanup(func() { stdoutIsTerminal = orig })
+}
+
+func TestColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		tty     bool
+		noColor bool
+		env     string
+		want    bool
+	}{
+		{"terminal", true, false, "", true},
+		{"not a terminal", false, false, "", false},
+		{"NO_COLOR set", true, false, "1", false},
+		{"--no-color", true, true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forceTerminal(t, tt.tty)
+			t.Setenv("NO_COLOR", tt.env)
+			NoColor = tt.noColor
+			defer func() { NoColor = false }()
+
+			for _, marked := range []string{green("✓"), red("✗ Error:"), yellow("⚠")} {
+				if got := strings.Contains(marked, "\033["); got != tt.want {
+					t.Errorf("%q: escape codes present = %v, want %v", marked, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestColor_Codes(t *testing.T) {
+	forceTerminal(t, true)
+	t.Setenv("NO_COLOR", "")
+
+	if got := green("✓"); got != colorGreen+"✓"+colorReset {
+		t.Errorf("expected green success marker, got %q", got)
+	}
+	if got := red("✗"); got != colorRed+"✗"+colorReset {
+		t.Errorf("expected red error marker, got %q", got)
+	}
+	if got := yellow("⚠"); got != colorYellow+"⚠"+colorReset {
+		t.Errorf("expected yellow warning marker, got %q", got)
+	}
+}
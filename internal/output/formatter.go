@@ -14,6 +14,7 @@ const (
 	ModeJSON  OutputMode = "json"
 	ModeTable OutputMode = "table"
 	ModeRaw   OutputMode = "raw"
+	ModeYAML  OutputMode = "yaml"
 )
 
 // Formatter handles output formatting
@@ -39,6 +40,8 @@ func GetFormatter(mode OutputMode) Formatter {
 		return &TableFormatter{}
 	case ModeRaw:
 		return &RawFormatter{}
+	case ModeYAML:
+		return &YAMLFormatter{}
 	default:
 		// Fallback to JSON for unknown modes
 		return &JSONFormatter{}
@@ -57,9 +60,9 @@ func ValidateMode(mode string) error {
 	}
 
 	switch OutputMode(mode) {
-	case ModeJSON, ModeTable, ModeRaw:
+	case ModeJSON, ModeTable, ModeRaw, ModeYAML:
 		return nil
 	default:
-		return fmt.Errorf("invalid output mode: %s (must be json, table, or raw)", mode)
+		return fmt.Errorf("invalid output mode: %s (must be json, yaml, table, or raw)", mode)
 	}
 }
@@ -13,6 +13,7 @@ func TestValidateMode(t *testing.T) {
 		{"json", false},       // Valid mode
 		{"table", false},      // Valid mode
 		{"raw", false},        // Valid mode
+		{"yaml", false},       // Valid mode
 		{"invalid", true},     // Invalid mode
 		{"JSON", true},        // Case sensitive
 		{"Table", true},       // Case sensitive
@@ -36,6 +37,7 @@ func TestGetFormatter(t *testing.T) {
 		{ModeJSON, "*output.JSONFormatter"},
 		{ModeTable, "*output.TableFormatter"},
 		{ModeRaw, "*output.RawFormatter"},
+		{ModeYAML, "*output.YAMLFormatter"},
 		{"", "*output.JSONFormatter"}, // Auto-detect (non-TTY in tests)
 		{"invalid", "*output.JSONFormatter"}, // Fallback
 	}
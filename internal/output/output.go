@@ -1,6 +1,6 @@
 // Package output provides HATEOAS-style responses for the secrets CLI.
 // By default, all output is JSON for agent consumption.
-// Use --human flag for human-readable output.
+// Use --output yaml or --output table (alias --human) for other formats.
 package output
 
 import (
@@ -14,35 +14,40 @@ import (
 
 // Action represents a possible next action (HATEOAS-style)
 type Action struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Command     string `json:"command"`
-	Dangerous   bool   `json:"dangerous,omitempty"`
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Command     string `json:"command" yaml:"command"`
+	Dangerous   bool   `json:"dangerous,omitempty" yaml:"dangerous,omitempty"`
 }
 
 // Response is the standard CLI response format
 type Response struct {
-	Success  bool        `json:"success"`
-	Message  string      `json:"message,omitempty"`
-	Data     interface{} `json:"data,omitempty"`
-	Error    string      `json:"error,omitempty"`
-	ExitCode int         `json:"exit_code,omitempty"`
-	Actions  []Action    `json:"actions,omitempty"`
-	Update   *UpdateInfo `json:"update,omitempty"`
+	Success  bool        `json:"success" yaml:"success"`
+	Message  string      `json:"message,omitempty" yaml:"message,omitempty"`
+	Data     interface{} `json:"data,omitempty" yaml:"data,omitempty"`
+	Error    string      `json:"error,omitempty" yaml:"error,omitempty"`
+	ExitCode int         `json:"exit_code,omitempty" yaml:"exit_code,omitempty"`
+	Actions  []Action    `json:"actions,omitempty" yaml:"actions,omitempty"`
+	Update   *UpdateInfo `json:"update,omitempty" yaml:"update,omitempty"`
+
+	table *tableData // Set by WithTable
 }
 
 // UpdateInfo contains version update information
 type UpdateInfo struct {
-	Available      bool   `json:"available"`
-	CurrentVersion string `json:"current_version"`
-	LatestVersion  string `json:"latest_version,omitempty"`
-	Command        string `json:"command,omitempty"`
+	Available      bool   `json:"available" yaml:"available"`
+	CurrentVersion string `json:"current_version" yaml:"current_version"`
+	LatestVersion  string `json:"latest_version,omitempty" yaml:"latest_version,omitempty"`
+	Command        string `json:"command,omitempty" yaml:"command,omitempty"`
 }
 
 // Global flags for output modes
 var (
-	HumanMode    bool   // Deprecated: use OutputFormat instead
-	OutputFormat string // json, table, or raw
+	OutputFormat string // json, yaml, table, or raw; empty auto-detects
+	Quiet        bool   // Print only data; errors go to stderr
+
+	Template              string // Go template applied to the response; overrides OutputFormat
+	AllowValuesInTemplate bool   // Permit templates that reference secret values
 )
 
 // Version info (set by ldflags)
@@ -54,16 +59,33 @@ var (
 
 // Print outputs the response using the configured formatter
 func Print(r Response) {
-	// Handle legacy HumanMode flag for backwards compatibility
-	var mode OutputMode
-	if HumanMode {
-		mode = ModeTable
-	} else if OutputFormat != "" {
-		mode = OutputMode(OutputFormat)
+	if Template != "" {
+		formatter, err := NewTemplateFormatter(Template, AllowValuesInTemplate)
+		if err == nil {
+			err = formatter.Format(r)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+			printJSON(r)
+		}
+		return
+	}
+
+	if Quiet {
+		// Scripts rely on the exit code for failures, so only the error
+		// message is kept, on stderr
+		if !r.Success {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", r.Error)
+			return
+		}
+		if r.Data == nil {
+			return
+		}
+		r = quietResponse(r)
 	}
-	// If neither flag is set, GetFormatter will auto-detect
 
-	formatter := GetFormatter(mode)
+	// If no format is set, GetFormatter will auto-detect
+	formatter := GetFormatter(OutputMode(OutputFormat))
 	if err := formatter.Format(r); err != nil {
 		// Fallback to simple error print if formatting fails
 		fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
@@ -71,6 +93,17 @@ func Print(r Response) {
 	}
 }
 
+// quietResponse strips r down to its outcome and data for --quiet,
+// dropping the message, actions and update notice.
+func quietResponse(r Response) Response {
+	return Response{
+		Success: r.Success,
+		Data:    r.Data,
+		Error:   r.Error,
+		table:   r.table,
+	}
+}
+
 // Success creates a successful response
 func Success(message string, data interface{}, actions ...Action) Response {
 	return Response{
@@ -149,18 +182,18 @@ func printJSON(r Response) error {
 func printHuman(r Response) {
 	if r.Success {
 		if r.Message != "" {
-			fmt.Printf("✓ %s\n", r.Message)
+			fmt.Printf("%s %s\n", green("✓"), r.Message)
 		}
 		if r.Data != nil {
 			printData(r.Data)
 		}
 	} else {
-		fmt.Printf("✗ Error: %s\n", r.Error)
+		fmt.Printf("%s %s\n", red("✗ Error:"), r.Error)
 	}
 
 	// Print update warning
 	if r.Update != nil && r.Update.Available {
-		fmt.Printf("\n⚠ Update available: %s → %s\n", r.Update.CurrentVersion, r.Update.LatestVersion)
+		fmt.Printf("\n%s %s → %s\n", yellow("⚠ Update available:"), r.Update.CurrentVersion, r.Update.LatestVersion)
 		fmt.Printf("  Run: %s\n", r.Update.Command)
 	}
 
@@ -170,7 +203,7 @@ func printHuman(r Response) {
 		for _, a := range r.Actions {
 			prefix := "→"
 			if a.Dangerous {
-				prefix = "⚠"
+				prefix = yellow("⚠")
 			}
 			fmt.Printf("  %s %s\n", prefix, a.Description)
 			fmt.Printf("    $ %s\n", a.Command)
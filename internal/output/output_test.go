@@ -0,0 +1,86 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// capturePrint runs Print(r) and returns what it wrote to stdout and stderr.
+func capturePrint(t *testing.T, r Response) (stdout, stderr string) {
+	t.Helper()
+
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	origOut, origErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = outW, errW
+	defer func() { os.Stdout, os.Stderr = origOut, origErr }()
+
+	Print(r)
+	outW.Close()
+	errW.Close()
+
+	out, _ := io.ReadAll(outR)
+	errOut, _ := io.ReadAll(errR)
+	return string(out), string(errOut)
+}
+
+func setQuiet(t *testing.T) {
+	t.Helper()
+	Quiet, OutputFormat = true, string(ModeJSON)
+	t.Cleanup(func() { Quiet, OutputFormat = false, "" })
+}
+
+func TestPrint_Quiet(t *testing.T) {
+	setQuiet(t)
+
+	r := Success("Lease granted", map[string]interface{}{"lease_id": "lease-123"}, ActionStatus())
+	r.Update = &UpdateInfo{Available: true, CurrentVersion: "1.0.0", LatestVersion: "1.1.0"}
+	stdout, stderr := capturePrint(t, r)
+	if stderr != "" {
+		t.Errorf("expected nothing on stderr, got %q", stderr)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(stdout), &fields); err != nil {
+		t.Fatalf("expected JSON on stdout, got %q: %v", stdout, err)
+	}
+	for key := range fields {
+		if key != "success" && key != "data" && key != "error" {
+			t.Errorf("unexpected field %q in quiet output: %s", key, stdout)
+		}
+	}
+	var data map[string]string
+	if err := json.Unmarshal(fields["data"], &data); err != nil || data["lease_id"] != "lease-123" {
+		t.Errorf("expected the data kept, got %s", fields["data"])
+	}
+}
+
+func TestPrint_QuietWithoutData(t *testing.T) {
+	setQuiet(t)
+
+	stdout, stderr := capturePrint(t, Success("Secret added", nil, ActionStatus()))
+	if stdout != "" || stderr != "" {
+		t.Errorf("expected no output, got stdout %q stderr %q", stdout, stderr)
+	}
+}
+
+func TestPrint_QuietError(t *testing.T) {
+	setQuiet(t)
+
+	stdout, stderr := capturePrint(t, Error(errors.New("secret not found"), ActionStatus()))
+	if stdout != "" {
+		t.Errorf("expected nothing on stdout, got %q", stdout)
+	}
+	if stderr != "Error: secret not found\n" {
+		t.Errorf("expected the error on stderr, got %q", stderr)
+	}
+}
@@ -4,9 +4,90 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
+// MaxCellWidth is the widest a Table cell may be; longer values are
+// truncated with an ellipsis.
+const MaxCellWidth = 40
+
+// tableData is a table shown in place of a response's data by
+// TableFormatter. It never appears in JSON or YAML output.
+type tableData struct {
+	headers []string
+	rows    [][]string
+}
+
+// WithTable returns r with a table that human output shows instead of the
+// data. Machine-readable formats still print the data.
+func (r Response) WithTable(headers []string, rows [][]string) Response {
+	r.table = &tableData{headers: headers, rows: rows}
+	return r
+}
+
+// Table renders rows under headers with aligned columns, indented to match
+// the rest of the human output. Cells wider than MaxCellWidth are truncated.
+// A table without rows shows its headers and "(none)".
+func Table(headers []string, rows [][]string) string {
+	if len(headers) == 0 {
+		return ""
+	}
+
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	cells := make([][]string, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]string, len(headers))
+		for i := range headers {
+			if i < len(row) {
+				cells[r][i] = truncateCell(row[i])
+			}
+			if w := utf8.RuneCountInString(cells[r][i]); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		line := "  "
+		for i, cell := range row {
+			if i < len(row)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+2)
+			}
+			line += cell
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	writeRow(headers)
+	separator := make([]string, len(headers))
+	for i, w := range widths {
+		separator[i] = strings.Repeat("-", w)
+	}
+	writeRow(separator)
+	if len(cells) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, row := range cells {
+		writeRow(row)
+	}
+	return b.String()
+}
+
+// truncateCell shortens s to MaxCellWidth runes, keeping it on one line.
+func truncateCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if utf8.RuneCountInString(s) <= MaxCellWidth {
+		return s
+	}
+	return string([]rune(s)[:MaxCellWidth-1]) + "…"
+}
+
 // TableFormatter outputs human-readable tables
 type TableFormatter struct{}
 
@@ -14,20 +95,22 @@ type TableFormatter struct{}
 func (f *TableFormatter) Format(r Response) error {
 	if r.Success {
 		if r.Message != "" {
-			fmt.Printf("✓ %s\n", r.Message)
+			fmt.Printf("%s %s\n", green("✓"), r.Message)
 		}
-		if r.Data != nil {
+		if r.table != nil {
+			fmt.Print(Table(r.table.headers, r.table.rows))
+		} else if r.Data != nil {
 			if err := printTable(r.Data); err != nil {
 				return err
 			}
 		}
 	} else {
-		fmt.Printf("✗ Error: %s\n", r.Error)
+		fmt.Printf("%s %s\n", red("✗ Error:"), r.Error)
 	}
 
 	// Print update warning
 	if r.Update != nil && r.Update.Available {
-		fmt.Printf("\n⚠ Update available: %s → %s\n", r.Update.CurrentVersion, r.Update.LatestVersion)
+		fmt.Printf("\n%s %s → %s\n", yellow("⚠ Update available:"), r.Update.CurrentVersion, r.Update.LatestVersion)
 		fmt.Printf("  Run: %s\n", r.Update.Command)
 	}
 
@@ -37,7 +120,7 @@ func (f *TableFormatter) Format(r Response) error {
 		for _, a := range r.Actions {
 			prefix := "→"
 			if a.Dangerous {
-				prefix = "⚠"
+				prefix = yellow("⚠")
 			}
 			fmt.Printf("  %s %s\n", prefix, a.Description)
 			fmt.Printf("    $ %s\n", a.Command)
@@ -85,16 +168,19 @@ func printTable(data interface{}) error {
 func printMapAsTable(m map[string]interface{}) {
 	// Find max key length for alignment
 	maxLen := 0
+	keys := make([]string, 0, len(m))
 	for key := range m {
+		keys = append(keys, key)
 		if len(key) > maxLen {
 			maxLen = len(key)
 		}
 	}
+	sort.Strings(keys)
 
 	// Print each key-value pair
-	for key, val := range m {
+	for _, key := range keys {
 		padding := strings.Repeat(" ", maxLen-len(key))
-		fmt.Printf("  %s:%s %v\n", key, padding, formatValue(val))
+		fmt.Printf("  %s:%s %v\n", key, padding, formatValue(m[key]))
 	}
 }
 
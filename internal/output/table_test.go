@@ -0,0 +1,96 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	got := Table(
+		[]string{"NAME", "NAMESPACE", "CREATED"},
+		[][]string{
+			{"api_key", "default", "2024-01-02 15:04"},
+			{"db", "production", "-"},
+		},
+	)
+	want := "" +
+		"  NAME     NAMESPACE   CREATED\n" +
+		"  -------  ----------  ----------------\n" +
+		"  api_key  default     2024-01-02 15:04\n" +
+		"  db       production  -\n"
+	if got != want {
+		t.Errorf("unexpected table:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestTable_Alignment(t *testing.T) {
+	got := Table(
+		[]string{"OK", "DETAILS"},
+		[][]string{{"✓ yes", "short"}, {"✗ no", "longer detail"}},
+	)
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, separator and 2 rows, got %q", got)
+	}
+
+	// Every row's second column starts where the header's does, counting
+	// runes rather than bytes
+	col := strings.Index(lines[0], "DETAILS")
+	for _, line := range lines[2:] {
+		runes := []rune(line)
+		if len(runes) <= col || runes[col-1] != ' ' || runes[col] == ' ' {
+			t.Errorf("column misaligned in %q", line)
+		}
+	}
+}
+
+func TestTable_Truncate(t *testing.T) {
+	long := strings.Repeat("x", MaxCellWidth+10)
+	got := Table([]string{"DETAILS"}, [][]string{{long}, {"two\nlines"}})
+
+	lines := strings.Split(strings.TrimRight(got, "\n"), "\n")
+	cell := strings.TrimSpace(lines[2])
+	if n := len([]rune(cell)); n != MaxCellWidth {
+		t.Errorf("expected cell truncated to %d runes, got %d", MaxCellWidth, n)
+	}
+	if !strings.HasSuffix(cell, "…") {
+		t.Errorf("expected truncated cell to end with an ellipsis, got %q", cell)
+	}
+	if strings.TrimSpace(lines[3]) != "two lines" {
+		t.Errorf("expected newlines flattened, got %q", lines[3])
+	}
+}
+
+func TestTable_Empty(t *testing.T) {
+	if got := Table(nil, nil); got != "" {
+		t.Errorf("expected nothing without headers, got %q", got)
+	}
+
+	got := Table([]string{"NAME", "NAMESPACE"}, nil)
+	if !strings.HasPrefix(got, "  NAME  NAMESPACE\n") {
+		t.Errorf("expected headers in an empty table, got %q", got)
+	}
+	if !strings.Contains(got, "(none)") {
+		t.Errorf("expected an empty table to say so, got %q", got)
+	}
+
+	// Short rows are padded rather than dropped
+	got = Table([]string{"A", "B"}, [][]string{{"1"}})
+	if !strings.HasSuffix(got, "  1\n") {
+		t.Errorf("expected a short row rendered, got %q", got)
+	}
+}
+
+func TestWithTable_NotInJSON(t *testing.T) {
+	r := Success("1 secrets", map[string]interface{}{"count": 1}).
+		WithTable([]string{"NAME"}, [][]string{{"api_key"}})
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if strings.Contains(string(data), "api_key") || strings.Contains(string(data), "NAME") {
+		t.Errorf("expected the table left out of JSON, got %s", data)
+	}
+}
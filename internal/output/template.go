@@ -0,0 +1,208 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// valueFields are response keys that carry secret values. Templates that
+// reference them are rejected unless values are explicitly allowed.
+var valueFields = map[string]bool{
+	"value": true,
+}
+
+// TemplateFormatter renders the response through a Go text/template, so
+// callers can extract single fields (e.g. {{.Data.lease_id}}) without a
+// JSON parser. Data is exposed by its JSON keys.
+type TemplateFormatter struct {
+	tmpl *template.Template
+	w    io.Writer
+}
+
+// NewTemplateFormatter parses text as a template. Unless allowValues is set,
+// templates that reference a secret value field, or print whole objects that
+// may contain one, are rejected.
+func NewTemplateFormatter(text string, allowValues bool) (*TemplateFormatter, error) {
+	tmpl, err := template.New("output").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	if !allowValues {
+		// Check every tree, including those from define and block, which
+		// {{template}} can run with the whole response as dot
+		for _, t := range tmpl.Templates() {
+			if t.Tree == nil {
+				continue
+			}
+			if field, ok := findValueReference(t.Tree.Root, true); ok {
+				return nil, fmt.Errorf("template references %s, which may print secret values (use --allow-values-in-template to permit)", field)
+			}
+		}
+	}
+
+	return &TemplateFormatter{tmpl: tmpl, w: os.Stdout}, nil
+}
+
+// ValidateTemplate checks that text parses and passes the value guard.
+func ValidateTemplate(text string, allowValues bool) error {
+	if text == "" {
+		return nil
+	}
+	_, err := NewTemplateFormatter(text, allowValues)
+	return err
+}
+
+// Format implements the Formatter interface for template output
+func (f *TemplateFormatter) Format(r Response) error {
+	// Normalize Data to its JSON form so struct results and maps are
+	// addressed the same way
+	if r.Data != nil {
+		raw, err := json.Marshal(r.Data)
+		if err != nil {
+			return fmt.Errorf("encode data: %w", err)
+		}
+		var data interface{}
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return fmt.Errorf("decode data: %w", err)
+		}
+		r.Data = data
+	}
+
+	var b strings.Builder
+	if err := f.tmpl.Execute(&b, r); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(f.w, out)
+	return err
+}
+
+// findValueReference walks a template tree and reports the first reference
+// that could print a secret value: a value field, an index by a value key or
+// by a key only known at run time, or a whole {{.}} / {{.Data}} object that
+// would expose every field. top is false once dot has been rebound by range
+// or with, or inside an if condition where nothing is printed.
+func findValueReference(node parse.Node, top bool) (string, bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return "", false
+		}
+		for _, child := range n.Nodes {
+			if field, ok := findValueReference(child, top); ok {
+				return field, true
+			}
+		}
+	case *parse.ActionNode:
+		return findValueReference(n.Pipe, top)
+	case *parse.PipeNode:
+		if n == nil {
+			return "", false
+		}
+		for _, cmd := range n.Cmds {
+			if field, ok := findValueReference(cmd, top); ok {
+				return field, true
+			}
+		}
+	case *parse.CommandNode:
+		// index and len only look inside the object
+		lookup := false
+		if ident, ok := n.Args[0].(*parse.IdentifierNode); ok {
+			lookup = ident.Ident == "index" || ident.Ident == "len"
+		}
+		for i, arg := range n.Args {
+			if field, ok := wholeObject(arg); top && ok && !lookup {
+				return field, true
+			}
+			if lookup && i > 1 && !constant(arg) {
+				return "an index key computed at run time", true
+			}
+			if field, ok := findValueReference(arg, top); ok {
+				return field, true
+			}
+		}
+	case *parse.FieldNode:
+		return identReference(n.Ident)
+	case *parse.ChainNode:
+		if field, ok := identReference(n.Field); ok {
+			return field, true
+		}
+		return findValueReference(n.Node, top)
+	case *parse.VariableNode:
+		return identReference(n.Ident[1:])
+	case *parse.StringNode:
+		if valueFields[strings.ToLower(n.Text)] {
+			return fmt.Sprintf("%q", n.Text), true
+		}
+	case *parse.TemplateNode:
+		// The defined template is checked on its own; passing it an object
+		// prints nothing by itself
+		return findValueReference(n.Pipe, false)
+	case *parse.IfNode:
+		return findBranchReference(&n.BranchNode, false, top, top)
+	case *parse.RangeNode:
+		return findBranchReference(&n.BranchNode, top, false, top)
+	case *parse.WithNode:
+		// With a whole object, dot inside is still that object
+		inner := false
+		if len(n.Pipe.Cmds) == 1 && len(n.Pipe.Cmds[0].Args) == 1 {
+			_, inner = wholeObject(n.Pipe.Cmds[0].Args[0])
+		}
+		return findBranchReference(&n.BranchNode, false, inner && top, top)
+	}
+	return "", false
+}
+
+// findBranchReference checks the condition, body and else branch of an
+// if/range/with node, each with its own top setting.
+func findBranchReference(n *parse.BranchNode, pipeTop, listTop, elseTop bool) (string, bool) {
+	if field, ok := findValueReference(n.Pipe, pipeTop); ok {
+		return field, true
+	}
+	if field, ok := findValueReference(n.List, listTop); ok {
+		return field, true
+	}
+	return findValueReference(n.ElseList, elseTop)
+}
+
+// wholeObject reports whether arg is the whole response or its entire Data.
+func wholeObject(arg parse.Node) (string, bool) {
+	switch a := arg.(type) {
+	case *parse.DotNode:
+		return "{{.}}", true
+	case *parse.FieldNode:
+		if len(a.Ident) == 1 && a.Ident[0] == "Data" {
+			return "{{.Data}}", true
+		}
+	}
+	return "", false
+}
+
+// constant reports whether arg is a literal, whose value is known when the
+// template is parsed.
+func constant(arg parse.Node) bool {
+	switch arg.(type) {
+	case *parse.StringNode, *parse.NumberNode, *parse.BoolNode, *parse.NilNode:
+		return true
+	}
+	return false
+}
+
+func identReference(idents []string) (string, bool) {
+	for _, ident := range idents {
+		if valueFields[strings.ToLower(ident)] {
+			return "." + strings.Join(idents, "."), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func renderTemplate(t *testing.T, text string, allowValues bool, r Response) (string, error) {
+	t.Helper()
+	f, err := NewTemplateFormatter(text, allowValues)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	f.w = &b
+	if err := f.Format(r); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	return b.String(), nil
+}
+
+func TestTemplateFormatter_ExtractsFields(t *testing.T) {
+	type nested struct {
+		LeaseID string `json:"lease_id"`
+		Client  struct {
+			ID string `json:"id"`
+		} `json:"client"`
+	}
+	structData := nested{LeaseID: "lease-123"}
+	structData.Client.ID = "agent-1"
+
+	tests := []struct {
+		name string
+		tmpl string
+		resp Response
+		want string
+	}{
+		{
+			name: "map field",
+			tmpl: "{{.Data.lease_id}}",
+			resp: Success("ok", map[string]interface{}{"lease_id": "lease-123"}),
+			want: "lease-123\n",
+		},
+		{
+			name: "nested struct field by json key",
+			tmpl: "{{.Data.client.id}}",
+			resp: Success("ok", structData),
+			want: "agent-1\n",
+		},
+		{
+			name: "top-level response fields",
+			tmpl: "{{.Success}} {{.Message}}",
+			resp: Success("done", nil),
+			want: "true done\n",
+		},
+		{
+			name: "range over names",
+			tmpl: "{{range .Data.vars}}{{.}},{{end}}",
+			resp: Success("ok", map[string]interface{}{"vars": []string{"A", "B"}}),
+			want: "A,B,\n",
+		},
+		{
+			name: "index and with on data",
+			tmpl: `{{index .Data "lease_id"}} {{with .Data}}{{.lease_id}}{{end}}`,
+			resp: Success("ok", map[string]interface{}{"lease_id": "lease-123"}),
+			want: "lease-123 lease-123\n",
+		},
+		{
+			name: "defined template",
+			tmpl: `{{define "id"}}{{.lease_id}}{{end}}{{template "id" .Data}}`,
+			resp: Success("ok", map[string]interface{}{"lease_id": "lease-123"}),
+			want: "lease-123\n",
+		},
+		{
+			name: "index by number",
+			tmpl: "{{index .Data.vars 1}}",
+			resp: Success("ok", map[string]interface{}{"vars": []string{"A", "B"}}),
+			want: "B\n",
+		},
+		{
+			name: "missing key",
+			tmpl: "{{.Data.nope}}",
+			resp: Success("ok", map[string]interface{}{}),
+			want: "<no value>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(t, tt.tmpl, false, tt.resp)
+			if err != nil {
+				t.Fatalf("NewTemplateFormatter() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_BlocksValues(t *testing.T) {
+	tests := []string{
+		"{{.Data.value}}",
+		"{{.Data.Value}}",
+		`{{index .Data "value"}}`,
+		"{{with .Data}}{{.value}}{{end}}",
+		"{{if .Success}}{{.Data.value}}{{end}}",
+		"{{.}}",
+		"{{.Data}}",
+		"{{with .Data}}{{.}}{{end}}",
+		"{{range $k, $v := .Data}}{{$v}}{{end}}",
+		"{{$d := .Data}}{{$d.value}}",
+		"{{.Data | printf \"%v\"}}",
+		`{{define "v"}}{{.Data.value}}{{end}}{{template "v" .}}`,
+		`{{define "all"}}{{.}}{{end}}{{template "all" .Data}}`,
+		`{{block "v" .}}{{index .Data "value"}}{{end}}`,
+		`{{$k := "value"}}{{index .Data $k}}`,
+		`{{index .Data (printf "%s%s" "val" "ue")}}`,
+		`{{range .Data.keys}}{{index $.Data .}}{{end}}`,
+	}
+
+	for _, tmpl := range tests {
+		t.Run(tmpl, func(t *testing.T) {
+			err := ValidateTemplate(tmpl, false)
+			if err == nil {
+				t.Fatal("expected template to be blocked")
+			}
+			if !strings.Contains(err.Error(), "--allow-values-in-template") {
+				t.Errorf("error should mention the override flag, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplateFormatter_AllowValues(t *testing.T) {
+	resp := Success("ok", map[string]interface{}{"value": "s3cret"})
+	got, err := renderTemplate(t, "{{.Data.value}}", true, resp)
+	if err != nil {
+		t.Fatalf("NewTemplateFormatter() error = %v", err)
+	}
+	if got != "s3cret\n" {
+		t.Errorf("got %q, want %q", got, "s3cret\n")
+	}
+}
+
+func TestValidateTemplate_ParseError(t *testing.T) {
+	if err := ValidateTemplate("{{.Data.lease_id", false); err == nil || !strings.Contains(err.Error(), "invalid template") {
+		t.Errorf("expected parse error, got %v", err)
+	}
+	if err := ValidateTemplate("", false); err != nil {
+		t.Errorf("empty template should be valid, got %v", err)
+	}
+}
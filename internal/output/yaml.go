@@ -0,0 +1,59 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// YAMLFormatter outputs YAML format, for tooling that prefers it to JSON
+type YAMLFormatter struct{}
+
+// Format implements the Formatter interface for YAML output
+func (f *YAMLFormatter) Format(r Response) error {
+	data, err := marshalYAML(r)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// marshalYAML renders v as block-style YAML. It goes through JSON first so
+// the keys, omitted fields and values match the JSON output exactly,
+// including for data structs that only carry json tags.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, so it parses into a node tree that keeps key order
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	clearStyle(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearStyle drops the flow and quoting styles parsed from JSON, leaving the
+// encoder to quote only the strings that need it.
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.yaml.in/yaml/v3"
+)
+
+func TestMarshalYAML_RoundTrip(t *testing.T) {
+	r := Success("Lease granted", map[string]interface{}{
+		"lease_id": "lease-123",
+		"ttl":      "1h",
+		"count":    2,
+		"version":  "true", // A string that YAML would read as a bool unquoted
+		"note":     "line one\nline two",
+	}, ActionStatus(), Action{Name: "revoke", Description: "Revoke it", Command: "secrets revoke lease-123", Dangerous: true})
+	r.Update = &UpdateInfo{Available: true, CurrentVersion: "1.0.0", LatestVersion: "1.1.0", Command: "secrets update"}
+
+	data, err := marshalYAML(r)
+	if err != nil {
+		t.Fatalf("marshalYAML failed: %v", err)
+	}
+	if strings.Contains(string(data), "{") {
+		t.Errorf("expected block style, got:\n%s", data)
+	}
+
+	var got Response
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("failed to parse YAML: %v\n%s", err, data)
+	}
+
+	// Data decodes generically, so compare it the way JSON sees it
+	want := r
+	wantData, _ := json.Marshal(r.Data)
+	gotData, _ := json.Marshal(got.Data)
+	if string(wantData) != string(gotData) {
+		t.Errorf("data mismatch:\nwant %s\ngot  %s", wantData, gotData)
+	}
+	want.Data, got.Data = nil, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip mismatch:\nwant %+v\ngot  %+v", want, got)
+	}
+}
+
+func TestMarshalYAML_MatchesJSONKeys(t *testing.T) {
+	// Data structs with only json tags keep their JSON field names
+	type lease struct {
+		LeaseID    string `json:"lease_id"`
+		SecretName string `json:"secret_name"`
+		Reason     string `json:"reason,omitempty"`
+	}
+	r := ErrorMsg("lease expired")
+	r.Data = []lease{{LeaseID: "lease-1", SecretName: "api_key"}}
+
+	data, err := marshalYAML(r)
+	if err != nil {
+		t.Fatalf("marshalYAML failed: %v", err)
+	}
+	want := `success: false
+data:
+  - lease_id: lease-1
+    secret_name: api_key
+error: lease expired
+exit_code: 1
+`
+	if string(data) != want {
+		t.Errorf("unexpected YAML:\nwant:\n%s\ngot:\n%s", want, data)
+	}
+}
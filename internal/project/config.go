@@ -2,16 +2,21 @@
 package project
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 const (
 	// DefaultProjectConfigFile is the project-local config filename.
 	DefaultProjectConfigFile = ".secrets.json"
+	// DefaultLocalConfigFile holds per-developer overrides merged over the
+	// project config. It should be gitignored.
+	DefaultLocalConfigFile = ".secrets.local.json"
 	// DefaultEnvFile is the default output filename for environment variables.
 	DefaultEnvFile = ".env.local"
 )
@@ -19,15 +24,17 @@ const (
 // ProjectConfig represents the schema for .secrets.json.
 // This file lives in project roots and defines how secrets are sourced.
 type ProjectConfig struct {
-	// Source is the credential provider ("vercel", "doppler", etc).
+	// Source is the credential provider ("vercel", "doppler", "dotenv").
 	Source string `json:"source"`
 
 	// Project is the source-specific project identifier.
 	// For Vercel: the project slug.
 	// For Doppler: the project name.
+	// For dotenv: the path to the .env file, relative to the project directory.
 	Project string `json:"project"`
 
 	// Scope is the environment scope ("development", "preview", "production").
+	// For dotenv it is an optional section name and may be empty.
 	Scope string `json:"scope"`
 
 	// TTL is the time-to-live duration string (e.g., "1h", "30m").
@@ -40,6 +47,32 @@ type ProjectConfig struct {
 	// EnvFile is the output filename for environment variables.
 	// Defaults to ".env.local" if not specified.
 	EnvFile string `json:"env_file,omitempty"`
+
+	// Sources optionally lists several sources to merge, in order, instead of
+	// the single Source/Project/Scope fields. Later entries override earlier
+	// ones when they define the same variable.
+	Sources []SourceSpec `json:"sources,omitempty"`
+}
+
+// SourceSpec identifies one source to pull from.
+// Fields have the same meaning as the single-source fields of ProjectConfig.
+type SourceSpec struct {
+	Source  string `json:"source"`
+	Project string `json:"project"`
+	Scope   string `json:"scope"`
+}
+
+// Label returns a short "source:project" identifier used for provenance.
+func (s SourceSpec) Label() string {
+	return s.Source + ":" + s.Project
+}
+
+// LocalOverrides are the fields a .secrets.local.json file may set. Empty
+// fields leave the project config unchanged.
+type LocalOverrides struct {
+	Scope   string `json:"scope,omitempty"`
+	TTL     string `json:"ttl,omitempty"`
+	EnvFile string `json:"env_file,omitempty"`
 }
 
 // ConfigError represents a configuration validation error.
@@ -52,55 +85,104 @@ func (e *ConfigError) Error() string {
 	return fmt.Sprintf("project config: %s %s", e.Field, e.Message)
 }
 
-// Validate checks if the configuration is valid.
+// ValidationErrors is every ConfigError found by Validate.
+type ValidationErrors []*ConfigError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks if the configuration is valid. It reports every invalid
+// field, as a ValidationErrors, rather than stopping at the first.
 func (c *ProjectConfig) Validate() error {
-	if c.Source == "" {
-		return &ConfigError{Field: "source", Message: "cannot be empty"}
+	var errs ValidationErrors
+	if c.Sources != nil {
+		if len(c.Sources) == 0 {
+			errs = append(errs, &ConfigError{Field: "sources", Message: "cannot be empty"})
+		}
+		if c.Source != "" {
+			errs = append(errs, &ConfigError{Field: "sources", Message: "cannot be combined with source"})
+		}
+		for i, spec := range c.Sources {
+			errs = append(errs, validateSource(fmt.Sprintf("sources[%d].", i), spec)...)
+		}
+	} else {
+		errs = append(errs, validateSource("", c.SourceSpec())...)
+	}
+
+	if c.TTL == "" {
+		errs = append(errs, &ConfigError{Field: "ttl", Message: "cannot be empty"})
+	} else if _, err := c.ParseTTL(); err != nil {
+		// Validate TTL format
+		errs = append(errs, &ConfigError{Field: "ttl", Message: err.Error()})
+	}
+
+	if len(errs) > 0 {
+		return errs
 	}
+	return nil
+}
+
+// validateSource checks a single source entry. prefix is prepended to field
+// names in errors (e.g. "sources[1].").
+func validateSource(prefix string, spec SourceSpec) []*ConfigError {
+	var errs []*ConfigError
 
 	// Validate known sources
 	validSources := map[string]bool{
 		"vercel":  true,
 		"doppler": true,
+		"dotenv":  true,
 	}
-	if !validSources[c.Source] {
-		return &ConfigError{
-			Field:   "source",
-			Message: fmt.Sprintf("must be one of: vercel, doppler (got %q)", c.Source),
-		}
-	}
-
-	if c.Project == "" {
-		return &ConfigError{Field: "project", Message: "cannot be empty"}
+	if spec.Source == "" {
+		errs = append(errs, &ConfigError{Field: prefix + "source", Message: "cannot be empty"})
+	} else if !validSources[spec.Source] {
+		errs = append(errs, &ConfigError{
+			Field:   prefix + "source",
+			Message: fmt.Sprintf("must be one of: vercel, doppler, dotenv (got %q)", spec.Source),
+		})
 	}
 
-	if c.Scope == "" {
-		return &ConfigError{Field: "scope", Message: "cannot be empty"}
+	if spec.Project == "" {
+		errs = append(errs, &ConfigError{Field: prefix + "project", Message: "cannot be empty"})
 	}
 
-	// Validate known scopes
-	validScopes := map[string]bool{
-		"development": true,
-		"preview":     true,
-		"production":  true,
-	}
-	if !validScopes[c.Scope] {
-		return &ConfigError{
-			Field:   "scope",
-			Message: fmt.Sprintf("must be one of: development, preview, production (got %q)", c.Scope),
+	// dotenv scopes are free-form section names and optional
+	if spec.Source != "dotenv" {
+		// Validate known scopes
+		validScopes := map[string]bool{
+			"development": true,
+			"preview":     true,
+			"production":  true,
+		}
+		if spec.Scope == "" {
+			errs = append(errs, &ConfigError{Field: prefix + "scope", Message: "cannot be empty"})
+		} else if !validScopes[spec.Scope] {
+			errs = append(errs, &ConfigError{
+				Field:   prefix + "scope",
+				Message: fmt.Sprintf("must be one of: development, preview, production (got %q)", spec.Scope),
+			})
 		}
 	}
 
-	if c.TTL == "" {
-		return &ConfigError{Field: "ttl", Message: "cannot be empty"}
-	}
+	return errs
+}
 
-	// Validate TTL format
-	if _, err := c.ParseTTL(); err != nil {
-		return &ConfigError{Field: "ttl", Message: err.Error()}
-	}
+// SourceSpec returns the single-source fields as a SourceSpec.
+func (c *ProjectConfig) SourceSpec() SourceSpec {
+	return SourceSpec{Source: c.Source, Project: c.Project, Scope: c.Scope}
+}
 
-	return nil
+// SourceList returns the sources to pull from, in merge order.
+func (c *ProjectConfig) SourceList() []SourceSpec {
+	if len(c.Sources) > 0 {
+		return c.Sources
+	}
+	return []SourceSpec{c.SourceSpec()}
 }
 
 // ParseTTL parses the TTL string into a time.Duration.
@@ -133,6 +215,66 @@ func (c *ProjectConfig) GetEnvFile() string {
 
 // Load reads a ProjectConfig from the specified path.
 func Load(path string) (*ProjectConfig, error) {
+	cfg, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadWithOverrides reads the project config in dir and merges the optional
+// .secrets.local.json from the same directory over it. Only the merged
+// result is validated, so the base file may leave out a field the local
+// file provides.
+func LoadWithOverrides(dir string) (*ProjectConfig, error) {
+	cfg, err := parse(filepath.Join(dir, DefaultProjectConfigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := loadLocalOverrides(filepath.Join(dir, DefaultLocalConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	if overrides != nil {
+		if err := cfg.ApplyOverrides(*overrides); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ApplyOverrides replaces config fields with the non-empty override fields.
+// Scope cannot be overridden on a multi-source config, since it would be
+// ambiguous which source it applies to.
+func (c *ProjectConfig) ApplyOverrides(o LocalOverrides) error {
+	if o.Scope != "" {
+		if len(c.Sources) > 0 {
+			return &ConfigError{Field: "scope", Message: "cannot be overridden when sources is set"}
+		}
+		c.Scope = o.Scope
+	}
+	if o.TTL != "" {
+		c.TTL = o.TTL
+	}
+	if o.EnvFile != "" {
+		c.EnvFile = o.EnvFile
+	}
+	return nil
+}
+
+// parse reads a ProjectConfig from path without validating it.
+func parse(path string) (*ProjectConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config: %w", err)
@@ -143,16 +285,35 @@ func Load(path string) (*ProjectConfig, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, err
+	return &cfg, nil
+}
+
+// loadLocalOverrides reads a local overrides file. A missing file yields nil.
+// Unknown fields are rejected so that typos do not silently fall back to the
+// project config.
+func loadLocalOverrides(path string) (*LocalOverrides, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", DefaultLocalConfigFile, err)
 	}
 
-	return &cfg, nil
+	var o LocalOverrides
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&o); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", DefaultLocalConfigFile, err)
+	}
+
+	return &o, nil
 }
 
 // FindProjectConfig walks up the directory tree from the current working directory
 // looking for a .secrets.json file. Returns the config and the directory path where
-// it was found, or an error if not found.
+// it was found, or an error if not found. A .secrets.local.json next to it is
+// merged over the config (see LoadWithOverrides).
 func FindProjectConfig() (*ProjectConfig, string, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -164,7 +325,7 @@ func FindProjectConfig() (*ProjectConfig, string, error) {
 		configPath := filepath.Join(dir, DefaultProjectConfigFile)
 		if _, err := os.Stat(configPath); err == nil {
 			// Found it
-			cfg, err := Load(configPath)
+			cfg, err := LoadWithOverrides(dir)
 			if err != nil {
 				return nil, "", err
 			}
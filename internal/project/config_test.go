@@ -1,6 +1,7 @@
 package project
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -45,6 +46,25 @@ func TestProjectConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid dotenv config without scope",
+			config: ProjectConfig{
+				Source:  "dotenv",
+				Project: ".env.shared",
+				TTL:     "1h",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid dotenv config with custom section scope",
+			config: ProjectConfig{
+				Source:  "dotenv",
+				Project: ".env.shared",
+				Scope:   "staging",
+				TTL:     "1h",
+			},
+			wantErr: false,
+		},
 		{
 			name: "missing source",
 			config: ProjectConfig{
@@ -140,6 +160,50 @@ func TestProjectConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "ttl exceeds maximum",
 		},
+		{
+			name: "valid multiple sources",
+			config: ProjectConfig{
+				Sources: []SourceSpec{
+					{Source: "vercel", Project: "my-app", Scope: "development"},
+					{Source: "dotenv", Project: ".env.shared"},
+				},
+				TTL: "1h",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty sources array",
+			config: ProjectConfig{
+				Sources: []SourceSpec{},
+				TTL:     "1h",
+			},
+			wantErr: true,
+			errMsg:  "sources cannot be empty",
+		},
+		{
+			name: "sources combined with source",
+			config: ProjectConfig{
+				Source:  "vercel",
+				Project: "my-app",
+				Scope:   "development",
+				Sources: []SourceSpec{{Source: "dotenv", Project: ".env"}},
+				TTL:     "1h",
+			},
+			wantErr: true,
+			errMsg:  "cannot be combined with source",
+		},
+		{
+			name: "invalid entry in sources",
+			config: ProjectConfig{
+				Sources: []SourceSpec{
+					{Source: "dotenv", Project: ".env"},
+					{Source: "vercel", Project: "my-app", Scope: "staging"},
+				},
+				TTL: "1h",
+			},
+			wantErr: true,
+			errMsg:  "sources[1].scope must be one of",
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,6 +226,48 @@ func TestProjectConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestProjectConfig_ValidateReportsAllErrors(t *testing.T) {
+	cfg := ProjectConfig{Source: "vercel", Project: "my-app", Scope: "staging", TTL: "48h"}
+
+	var errs ValidationErrors
+	if err := cfg.Validate(); !errors.As(err, &errs) {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	if errs[0].Field != "scope" || errs[1].Field != "ttl" {
+		t.Errorf("fields = %q, %q, want scope, ttl", errs[0].Field, errs[1].Field)
+	}
+}
+
+func TestProjectConfig_SourceList(t *testing.T) {
+	single := ProjectConfig{Source: "vercel", Project: "my-app", Scope: "development"}
+	if got := single.SourceList(); len(got) != 1 || got[0] != single.SourceSpec() {
+		t.Errorf("single-source SourceList() = %v", got)
+	}
+
+	multi := ProjectConfig{Sources: []SourceSpec{
+		{Source: "vercel", Project: "my-app", Scope: "development"},
+		{Source: "dotenv", Project: ".env.shared"},
+	}}
+	got := multi.SourceList()
+	if len(got) != 2 || got[0].Source != "vercel" || got[1].Label() != "dotenv:.env.shared" {
+		t.Errorf("multi-source SourceList() = %v", got)
+	}
+}
+
+func TestLoad_EmptySourcesArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".secrets.json")
+	if err := os.WriteFile(path, []byte(`{"sources": [], "ttl": "1h"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil || !contains(err.Error(), "sources cannot be empty") {
+		t.Errorf("expected empty sources error, got %v", err)
+	}
+}
+
 func TestProjectConfig_ParseTTL(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -502,6 +608,135 @@ func TestFindProjectConfig(t *testing.T) {
 	}
 }
 
+func TestLoadWithOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		local   string // Empty means no local file
+		want    ProjectConfig
+		wantErr string
+	}{
+		{
+			name: "no local file",
+			base: `{"source": "vercel", "project": "app", "scope": "production", "ttl": "1h"}`,
+			want: ProjectConfig{Source: "vercel", Project: "app", Scope: "production", TTL: "1h"},
+		},
+		{
+			name:  "local overrides scope and ttl",
+			base:  `{"source": "vercel", "project": "app", "scope": "production", "ttl": "1h", "env_file": ".env"}`,
+			local: `{"scope": "development", "ttl": "15m"}`,
+			want:  ProjectConfig{Source: "vercel", Project: "app", Scope: "development", TTL: "15m", EnvFile: ".env"},
+		},
+		{
+			name:  "local overrides env_file",
+			base:  `{"source": "vercel", "project": "app", "scope": "development", "ttl": "1h"}`,
+			local: `{"env_file": ".env.dev"}`,
+			want:  ProjectConfig{Source: "vercel", Project: "app", Scope: "development", TTL: "1h", EnvFile: ".env.dev"},
+		},
+		{
+			name:  "local supplies field missing from base",
+			base:  `{"source": "vercel", "project": "app", "scope": "development"}`,
+			local: `{"ttl": "30m"}`,
+			want:  ProjectConfig{Source: "vercel", Project: "app", Scope: "development", TTL: "30m"},
+		},
+		{
+			name:    "merged config is validated",
+			base:    `{"source": "vercel", "project": "app", "scope": "development", "ttl": "1h"}`,
+			local:   `{"ttl": "48h"}`,
+			wantErr: "exceeds maximum",
+		},
+		{
+			name:    "invalid scope override",
+			base:    `{"source": "vercel", "project": "app", "scope": "development", "ttl": "1h"}`,
+			local:   `{"scope": "staging"}`,
+			wantErr: "scope must be one of",
+		},
+		{
+			name:    "unknown local field",
+			base:    `{"source": "vercel", "project": "app", "scope": "development", "ttl": "1h"}`,
+			local:   `{"source": "doppler"}`,
+			wantErr: "unknown field",
+		},
+		{
+			name:    "scope override with multiple sources",
+			base:    `{"sources": [{"source": "vercel", "project": "app", "scope": "production"}], "ttl": "1h"}`,
+			local:   `{"scope": "development"}`,
+			wantErr: "cannot be overridden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, DefaultProjectConfigFile), []byte(tt.base), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.local != "" {
+				if err := os.WriteFile(filepath.Join(dir, DefaultLocalConfigFile), []byte(tt.local), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg, err := LoadWithOverrides(dir)
+			if tt.wantErr != "" {
+				if err == nil || !contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadWithOverrides() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadWithOverrides() unexpected error = %v", err)
+			}
+
+			if cfg.Source != tt.want.Source || cfg.Project != tt.want.Project || cfg.Scope != tt.want.Scope ||
+				cfg.TTL != tt.want.TTL || cfg.EnvFile != tt.want.EnvFile {
+				t.Errorf("LoadWithOverrides() = %+v, want %+v", *cfg, tt.want)
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Errorf("merged config failed Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestFindProjectConfig_LocalOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeValidConfig(t, filepath.Join(tmpDir, DefaultProjectConfigFile))
+	local := `{"scope": "preview", "ttl": "10m"}`
+	if err := os.WriteFile(filepath.Join(tmpDir, DefaultLocalConfigFile), []byte(local), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Start from a subdirectory; the local file sits beside the found config
+	subDir := filepath.Join(tmpDir, "subdir")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	origDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(origDir); err != nil {
+			t.Errorf("failed to restore working dir: %v", err)
+		}
+	}()
+	if err := os.Chdir(subDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := FindProjectConfig()
+	if err != nil {
+		t.Fatalf("FindProjectConfig() error = %v", err)
+	}
+	if cfg.Scope != "preview" || cfg.TTL != "10m" {
+		t.Errorf("got scope %q ttl %q, want preview 10m", cfg.Scope, cfg.TTL)
+	}
+	if cfg.Project != "test-project" {
+		t.Errorf("Project = %q, want base value test-project", cfg.Project)
+	}
+}
+
 func TestProjectConfig_Save(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -3,20 +3,27 @@ package rotation
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/envfile"
 	"github.com/joelhooks/agent-secrets/internal/store"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
 // Executor handles rotation hook execution with audit logging.
 type Executor struct {
-	mu          sync.Mutex
+	mu      sync.Mutex             // Guards locks
+	locks   map[string]*sync.Mutex // Per secret, so one secret's hooks never overlap
+	writeMu sync.Mutex             // Serializes store writes and propagation
+
 	cfg         *config.Config
 	store       *store.Store
 	auditLogger *audit.Logger
@@ -25,16 +32,38 @@ type Executor struct {
 // NewExecutor creates a new rotation executor.
 func NewExecutor(cfg *config.Config, st *store.Store, auditLogger *audit.Logger) *Executor {
 	return &Executor{
+		locks:       make(map[string]*sync.Mutex),
 		cfg:         cfg,
 		store:       st,
 		auditLogger: auditLogger,
 	}
 }
 
-// Rotate executes the rotation hook for a single secret.
-func (e *Executor) Rotate(secretName string) (*types.RotationResult, error) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+// timedOutError is the RotationResult error for a hook that ran out of time.
+const timedOutError = "command timed out"
+
+// hookValuePrefix starts the stdout line a rotation hook uses to hand back
+// the new value.
+const hookValuePrefix = "SECRET_VALUE="
+
+// redactedValue replaces a captured value in hook output.
+const redactedValue = "[redacted]"
+
+// Environment variables passed to rotation hooks.
+const (
+	rotateEnvName         = "SECRET_NAME"
+	rotateEnvCurrentValue = "SECRET_CURRENT_VALUE"
+)
+
+// Rotate executes the rotation hook for a single secret. The trigger is
+// recorded in the audit log. The hook sees the secret in SECRET_NAME and its
+// current value in SECRET_CURRENT_VALUE, which is redacted from the
+// captured output. If the hook emits a new value on stdout (see
+// types.RotationResult), it replaces the stored value before the secret is
+// marked rotated.
+func (e *Executor) Rotate(secretName string, trigger types.RotationTrigger) (*types.RotationResult, error) {
+	unlock := e.lockSecret(secretName)
+	defer unlock()
 
 	// Get secret metadata
 	secrets, err := e.store.List()
@@ -59,90 +88,257 @@ func (e *Executor) Rotate(secretName string) (*types.RotationResult, error) {
 		return nil, types.NewSecretError(secretName, types.ErrNoRotationHook)
 	}
 
+	current, err := e.store.Get(secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret: %w", err)
+	}
+
 	// Execute the command
 	result := &types.RotationResult{
 		SecretName: secretName,
 		ExecutedAt: time.Now(),
+		Trigger:    trigger,
+		ExitCode:   -1,
 	}
+	result.OldFingerprint, _ = e.store.Fingerprint(secretName)
+	defer func() {
+		result.NewFingerprint, _ = e.store.Fingerprint(secretName)
+	}()
 
 	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.RotationTimeout)
 	defer cancel()
 
 	// Use sh -c to support shell features
 	cmd := exec.CommandContext(ctx, "sh", "-c", secret.RotateVia)
+	cmd.Env = append(store.ChildEnv(),
+		rotateEnvName+"="+secretName,
+		rotateEnvCurrentValue+"="+current,
+	)
 	var outBuf, errBuf bytes.Buffer
 	cmd.Stdout = &outBuf
 	cmd.Stderr = &errBuf
 
 	err = cmd.Run()
+	result.Duration = time.Since(result.ExecutedAt)
+	if cmd.ProcessState != nil && cmd.ProcessState.Exited() {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
 
-	// Combine stdout and stderr for output
-	combinedOutput := outBuf.String()
+	// Combine stdout and stderr for output, keeping the current and any new
+	// value out of the result and the audit log
+	value, emitted, stdout := hookValue(outBuf.String())
+	combinedOutput := stdout
 	if errBuf.Len() > 0 {
 		if len(combinedOutput) > 0 {
 			combinedOutput += "\n"
 		}
 		combinedOutput += errBuf.String()
 	}
+	for _, v := range []string{current, value} {
+		if v != "" {
+			combinedOutput = strings.ReplaceAll(combinedOutput, v, redactedValue)
+		}
+	}
 	result.Output = combinedOutput
 
 	if err != nil {
 		// Check if it was a timeout
 		if ctx.Err() == context.DeadlineExceeded {
-			result.Error = "command timed out"
+			result.Error = timedOutError
 			result.Success = false
-			e.logAudit(secretName, false, result.Output, types.ErrRotationTimeout.Error())
+			e.logAudit(secretName, trigger, false, result.Output, types.ErrRotationTimeout.Error())
 			return result, types.NewRotationError(secretName, secret.RotateVia, result.Output, types.ErrRotationTimeout)
 		}
 
 		// Command failed
 		result.Error = err.Error()
 		result.Success = false
-		e.logAudit(secretName, false, result.Output, err.Error())
+		e.logAudit(secretName, trigger, false, result.Output, err.Error())
 		return result, types.NewRotationError(secretName, secret.RotateVia, result.Output, types.ErrRotationFailed)
 	}
 
+	// Hooks of different secrets run in parallel, but their results are
+	// written one at a time
+	e.writeMu.Lock()
+	defer e.writeMu.Unlock()
+
+	// Store the value the hook handed back, if any
+	if emitted {
+		if err := validateHookValue(secret, value); err != nil {
+			result.Error = err.Error()
+			result.Success = false
+			e.logAudit(secretName, trigger, false, result.Output, result.Error)
+			return result, types.NewRotationError(secretName, secret.RotateVia, result.Output, types.ErrRotationFailed)
+		}
+		if err := e.store.Update(secretName, value, nil); err != nil {
+			result.Error = fmt.Sprintf("rotation succeeded but failed to store the new value: %v", err)
+			result.Success = false
+			e.logAudit(secretName, trigger, false, result.Output, result.Error)
+			return result, fmt.Errorf("failed to store rotated value: %w", err)
+		}
+		result.ValueUpdated = true
+	}
+
 	// Success - mark as rotated
 	result.Success = true
 	if err := e.store.MarkRotated(secretName); err != nil {
 		result.Error = fmt.Sprintf("rotation succeeded but failed to update store: %v", err)
 		result.Success = false
-		e.logAudit(secretName, false, result.Output, result.Error)
+		e.logAudit(secretName, trigger, false, result.Output, result.Error)
 		return result, fmt.Errorf("failed to mark rotated: %w", err)
 	}
 
-	e.logAudit(secretName, true, result.Output, "")
+	e.logAudit(secretName, trigger, true, result.Output, "")
+
+	// Refresh dependent env files; failures are audited but do not undo
+	// the rotation
+	result.Propagated = e.propagate(secret)
 	return result, nil
 }
 
-// RotateAll executes rotation hooks for all secrets that have them configured.
-func (e *Executor) RotateAll() ([]types.RotationResult, error) {
+// hookValue extracts the new value a rotation hook emitted on stdout, as a
+// JSON object {"value": "..."} or a SECRET_VALUE=<value> line (the last one
+// wins). It also returns stdout with the value redacted. emitted is false
+// if the hook handed back no value.
+func hookValue(stdout string) (value string, emitted bool, redacted string) {
+	if trimmed := strings.TrimSpace(stdout); strings.HasPrefix(trimmed, "{") {
+		var out struct {
+			Value *string `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &out); err == nil && out.Value != nil {
+			return *out.Value, true, redactedValue
+		}
+	}
+
+	lines := strings.Split(stdout, "\n")
+	for i, line := range lines {
+		v, found := strings.CutPrefix(strings.TrimSuffix(line, "\r"), hookValuePrefix)
+		if !found {
+			continue
+		}
+		value, emitted = v, true
+		lines[i] = hookValuePrefix + redactedValue
+	}
+	if !emitted {
+		return "", false, stdout
+	}
+	return value, true, strings.Join(lines, "\n")
+}
+
+// validateHookValue checks that a value emitted by a rotation hook can
+// replace the secret's value. Binary secrets take base64.
+func validateHookValue(secret *types.Secret, value string) error {
+	if value == "" {
+		return fmt.Errorf("rotation hook emitted an empty value")
+	}
+	if secret.Binary {
+		if _, err := store.DecodeBinary(value); err != nil {
+			return fmt.Errorf("rotation hook emitted an invalid value for binary secret: %w", err)
+		}
+	}
+	return nil
+}
+
+// propagate writes the secret's current value into each of its managed env
+// files and returns the paths that were updated. Binary secrets are never
+// inlined: their contents go to a file beside the env file, and the
+// variable holds its path.
+func (e *Executor) propagate(secret *types.Secret) []string {
+	if len(secret.PropagateTo) == 0 {
+		return nil
+	}
+
+	var value string
+	var data []byte
+	var getErr error
+	if secret.Binary {
+		data, getErr = e.store.GetBinary(secret.Name)
+	} else {
+		value, getErr = e.store.Get(secret.Name)
+	}
+
+	var updated []string
+	for _, target := range secret.PropagateTo {
+		err := getErr
+		if err == nil && secret.Binary {
+			_, err = envfile.WriteManagedFile(target.Path, target.Var, data)
+		} else if err == nil {
+			err = envfile.WriteManagedSection(target.Path, map[string]string{target.Var: value})
+		}
+		e.logPropagation(secret.Name, target, err)
+		if err == nil {
+			updated = append(updated, target.Path)
+		}
+	}
+	return updated
+}
+
+// lockSecret serializes rotations of one secret and returns the unlock
+// function.
+func (e *Executor) lockSecret(name string) func() {
+	e.mu.Lock()
+	lock, ok := e.locks[name]
+	if !ok {
+		lock = &sync.Mutex{}
+		e.locks[name] = lock
+	}
+	e.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// RotateAll executes rotation hooks for all secrets that have them
+// configured, running up to cfg.RotationConcurrency hooks at once. Results,
+// including failures, are sorted by secret name.
+func (e *Executor) RotateAll(trigger types.RotationTrigger) ([]types.RotationResult, error) {
 	secrets, err := e.store.List()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list secrets: %w", err)
 	}
 
-	var results []types.RotationResult
+	var names []string
 	for _, secret := range secrets {
 		if secret.RotateVia == "" {
 			continue // Skip secrets without rotation hooks
 		}
+		names = append(names, secret.Name)
+	}
+	sort.Strings(names)
 
-		result, err := e.Rotate(secret.Name)
-		if err != nil {
-			// Still add to results, but continue with other secrets
-			if result != nil {
-				results = append(results, *result)
-			}
-			continue
-		}
+	rotated := make([]*types.RotationResult, len(names))
+	sem := make(chan struct{}, e.concurrency())
+	var wg sync.WaitGroup
+	for i, name := range names {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
 
-		results = append(results, *result)
+			// Failed rotations still have a result; continue with the others
+			rotated[i], _ = e.Rotate(name, trigger)
+		}()
 	}
+	wg.Wait()
 
+	var results []types.RotationResult
+	for _, result := range rotated {
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
 	return results, nil
 }
 
+// concurrency returns how many rotation hooks RotateAll may run at once.
+func (e *Executor) concurrency() int {
+	if e.cfg.RotationConcurrency > 0 {
+		return e.cfg.RotationConcurrency
+	}
+	return config.DefaultRotationConcurrency
+}
+
 // CanRotate checks if a secret has a rotation hook configured.
 func (e *Executor) CanRotate(secretName string) bool {
 	secrets, err := e.store.List()
@@ -159,8 +355,22 @@ func (e *Executor) CanRotate(secretName string) bool {
 	return false
 }
 
+// logPropagation writes an audit entry for one env file propagation.
+func (e *Executor) logPropagation(secretName string, target types.EnvTarget, err error) {
+	builder := audit.NewEntry(types.ActionSecretPropagate, err == nil).
+		WithSecret(secretName)
+
+	details := fmt.Sprintf("%s in %s", target.Var, target.Path)
+	if err != nil {
+		details = fmt.Sprintf("%s: error: %v", details, err)
+	}
+
+	// Best effort logging - don't fail rotation on audit failure
+	_ = e.auditLogger.Log(builder.WithDetails(details).Build())
+}
+
 // logAudit writes an audit entry for a rotation attempt.
-func (e *Executor) logAudit(secretName string, success bool, output, errMsg string) {
+func (e *Executor) logAudit(secretName string, trigger types.RotationTrigger, success bool, output, errMsg string) {
 	details := output
 	if errMsg != "" {
 		details = fmt.Sprintf("error: %s\noutput: %s", errMsg, output)
@@ -172,6 +382,7 @@ func (e *Executor) logAudit(secretName string, success bool, output, errMsg stri
 		SecretName: secretName,
 		Success:    success,
 		Details:    details,
+		Trigger:    trigger,
 	}
 
 	// Best effort logging - don't fail rotation on audit failure
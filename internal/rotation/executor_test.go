@@ -2,13 +2,16 @@ package rotation
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/config"
+	"github.com/joelhooks/agent-secrets/internal/envfile"
 	"github.com/joelhooks/agent-secrets/internal/store"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
@@ -82,7 +85,7 @@ func TestRotate_Success(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	result, err := executor.Rotate("test_secret")
+	result, err := executor.Rotate("test_secret", types.TriggerManual)
 
 	if err != nil {
 		t.Fatalf("expected no error, got: %v", err)
@@ -136,7 +139,7 @@ func TestRotate_CommandFailure(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	result, err := executor.Rotate("failing_secret")
+	result, err := executor.Rotate("failing_secret", types.TriggerManual)
 
 	if err == nil {
 		t.Fatal("expected error, got nil")
@@ -164,6 +167,222 @@ func TestRotate_CommandFailure(t *testing.T) {
 	}
 }
 
+func TestRotate_ResultDetails(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := st.Add("failing_secret", "test_value", "sleep 0.05; exit 7"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	fingerprint, err := st.Fingerprint("failing_secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	result, _ := executor.Rotate("failing_secret", types.TriggerScheduled)
+	if result == nil {
+		t.Fatal("expected result, got nil")
+	}
+
+	if result.Trigger != types.TriggerScheduled {
+		t.Errorf("expected trigger %q, got %q", types.TriggerScheduled, result.Trigger)
+	}
+	if result.ExitCode != 7 {
+		t.Errorf("expected exit code 7, got %d", result.ExitCode)
+	}
+	if result.Duration < 50*time.Millisecond {
+		t.Errorf("expected duration of at least 50ms, got %v", result.Duration)
+	}
+	if result.OldFingerprint != fingerprint || result.NewFingerprint != fingerprint {
+		t.Errorf("expected unchanged fingerprint %q, got %q -> %q", fingerprint, result.OldFingerprint, result.NewFingerprint)
+	}
+}
+
+func TestRotate_CapturesValue(t *testing.T) {
+	tests := []struct {
+		name string
+		hook string
+		want string
+	}{
+		{"line", "echo 'minting...'; echo 'SECRET_VALUE=new-token'", "new-token"},
+		{"last line wins", "echo SECRET_VALUE=first; echo SECRET_VALUE=second", "second"},
+		{"json", `echo '{"value": "json-token", "expires": "never"}'`, "json-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, st, auditLogger, cleanup := setupTest(t)
+			defer cleanup()
+
+			if err := st.Add("captured", "old-token", tt.hook); err != nil {
+				t.Fatalf("failed to add secret: %v", err)
+			}
+
+			executor := NewExecutor(cfg, st, auditLogger)
+			result, err := executor.Rotate("captured", types.TriggerManual)
+			if err != nil {
+				t.Fatalf("Rotate failed: %v", err)
+			}
+			if !result.ValueUpdated {
+				t.Error("expected ValueUpdated")
+			}
+			if result.OldFingerprint == result.NewFingerprint {
+				t.Error("expected fingerprints to differ after capturing a value")
+			}
+
+			value, err := st.Get("captured")
+			if err != nil {
+				t.Fatalf("failed to get secret: %v", err)
+			}
+			if value != tt.want {
+				t.Errorf("stored value = %q, want %q", value, tt.want)
+			}
+
+			// The new value never reaches the result or the audit log
+			if strings.Contains(result.Output, tt.want) {
+				t.Errorf("output leaks the value: %q", result.Output)
+			}
+			entries, err := auditLogger.Tail(10)
+			if err != nil {
+				t.Fatalf("failed to read audit log: %v", err)
+			}
+			for _, entry := range entries {
+				if strings.Contains(entry.Details, tt.want) {
+					t.Errorf("audit entry leaks the value: %q", entry.Details)
+				}
+			}
+
+			secret := secretByName(t, st, "captured")
+			if secret.LastRotated.IsZero() {
+				t.Error("expected LastRotated to be set")
+			}
+		})
+	}
+}
+
+func TestRotate_NoValueKeepsStored(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := st.Add("unchanged", "original", `echo 'rotated upstream'; echo '{"status": "ok"}' >&2`); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	result, err := executor.Rotate("unchanged", types.TriggerManual)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if result.ValueUpdated {
+		t.Error("expected ValueUpdated to be false")
+	}
+	if result.OldFingerprint != result.NewFingerprint {
+		t.Error("expected fingerprints to match")
+	}
+	if !strings.Contains(result.Output, "rotated upstream") {
+		t.Errorf("expected hook output, got %q", result.Output)
+	}
+
+	value, err := st.Get("unchanged")
+	if err != nil {
+		t.Fatalf("failed to get secret: %v", err)
+	}
+	if value != "original" {
+		t.Errorf("stored value = %q, want original", value)
+	}
+}
+
+func TestRotate_InvalidValue(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := st.Add("empty", "original", "echo SECRET_VALUE="); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := st.AddBinary("binary", []byte("original"), "echo 'SECRET_VALUE=not base64!'", time.Time{}); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	for _, name := range []string{"empty", "binary"} {
+		result, err := executor.Rotate(name, types.TriggerManual)
+		if !errors.Is(err, types.ErrRotationFailed) {
+			t.Errorf("%s: expected ErrRotationFailed, got %v", name, err)
+		}
+		if result == nil || result.Success || result.ValueUpdated {
+			t.Errorf("%s: expected a failed result, got %+v", name, result)
+		}
+		if !secretByName(t, st, name).LastRotated.IsZero() {
+			t.Errorf("%s: expected LastRotated to stay unset", name)
+		}
+	}
+}
+
+func TestRotate_HookEnvironment(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	hook := `echo "rotating $SECRET_NAME"; echo "current: $SECRET_CURRENT_VALUE"; echo "$SECRET_CURRENT_VALUE" >&2`
+	if err := st.Add("env_secret", "s3cr3t-current", hook); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	result, err := executor.Rotate("env_secret", types.TriggerManual)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	if !strings.Contains(result.Output, "rotating env_secret") {
+		t.Errorf("expected SECRET_NAME in output, got %q", result.Output)
+	}
+	if !strings.Contains(result.Output, "current: "+redactedValue) {
+		t.Errorf("expected the hook to see SECRET_CURRENT_VALUE, got %q", result.Output)
+	}
+	if strings.Contains(result.Output, "s3cr3t-current") {
+		t.Errorf("output leaks the current value: %q", result.Output)
+	}
+
+	entries, err := auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Details, "s3cr3t-current") {
+			t.Errorf("audit entry leaks the current value: %q", entry.Details)
+		}
+	}
+}
+
+func TestRotate_HookEnvironmentOmitsPassphrase(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+	t.Setenv(store.PassphraseEnv, "identity-passphrase")
+
+	hook := `echo "passphrase: ${` + store.PassphraseEnv + `-unset}"`
+	if err := st.Add("env_secret", "s3cr3t-current", hook); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	result, err := executor.Rotate("env_secret", types.TriggerManual)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "passphrase: unset") {
+		t.Errorf("expected the hook not to see %s, got %q", store.PassphraseEnv, result.Output)
+	}
+
+	secret := types.Secret{Name: "db_creds", GenerateVia: `echo "${` + store.PassphraseEnv + `-unset}"`}
+	value, err := executor.Generate(secret, "agent-1")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if value != "unset" {
+		t.Errorf("expected generate_via not to see %s, got %q", store.PassphraseEnv, value)
+	}
+}
+
 func TestRotate_Timeout(t *testing.T) {
 	cfg, st, auditLogger, cleanup := setupTest(t)
 	defer cleanup()
@@ -177,7 +396,7 @@ func TestRotate_Timeout(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	result, err := executor.Rotate("slow_secret")
+	result, err := executor.Rotate("slow_secret", types.TriggerManual)
 
 	if err == nil {
 		t.Fatal("expected timeout error, got nil")
@@ -195,6 +414,10 @@ func TestRotate_Timeout(t *testing.T) {
 		t.Errorf("expected timeout error, got: %s", result.Error)
 	}
 
+	if result.ExitCode != -1 {
+		t.Errorf("expected exit code -1 for a killed hook, got %d", result.ExitCode)
+	}
+
 	if !errors.Is(err, types.ErrRotationTimeout) {
 		t.Error("expected ErrRotationTimeout")
 	}
@@ -210,7 +433,7 @@ func TestRotate_NoRotationHook(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	result, err := executor.Rotate("no_rotation")
+	result, err := executor.Rotate("no_rotation", types.TriggerManual)
 
 	if err == nil {
 		t.Fatal("expected error for missing rotation hook, got nil")
@@ -230,7 +453,7 @@ func TestRotate_SecretNotFound(t *testing.T) {
 	defer cleanup()
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	result, err := executor.Rotate("nonexistent")
+	result, err := executor.Rotate("nonexistent", types.TriggerManual)
 
 	if err == nil {
 		t.Fatal("expected error for nonexistent secret, got nil")
@@ -267,7 +490,7 @@ func TestRotateAll(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	results, err := executor.RotateAll()
+	results, err := executor.RotateAll(types.TriggerManual)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -307,7 +530,7 @@ func TestRotateAll_WithFailures(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	results, err := executor.RotateAll()
+	results, err := executor.RotateAll(types.TriggerManual)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -337,6 +560,56 @@ func TestRotateAll_WithFailures(t *testing.T) {
 	}
 }
 
+func TestRotateAll_Parallel(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	const secrets = 8
+	const hookTime = 300 * time.Millisecond
+	for i := 0; i < secrets; i++ {
+		name := fmt.Sprintf("slow_%d", i)
+		if err := st.Add(name, "value", "sleep 0.3; echo SECRET_VALUE=rotated-"+name); err != nil {
+			t.Fatalf("failed to add secret: %v", err)
+		}
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	start := time.Now()
+	results, err := executor.RotateAll(types.TriggerManual)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("RotateAll failed: %v", err)
+	}
+
+	// Eight 300ms hooks, four at a time, take two rounds rather than eight
+	serial := secrets * hookTime
+	if elapsed >= serial/2 {
+		t.Errorf("RotateAll took %v, want well under the serial %v", elapsed, serial)
+	}
+	if elapsed < 2*hookTime {
+		t.Errorf("RotateAll took %v, want at least %v with %d at a time", elapsed, 2*hookTime, config.DefaultRotationConcurrency)
+	}
+
+	if len(results) != secrets {
+		t.Fatalf("expected %d results, got %d", secrets, len(results))
+	}
+	for i, result := range results {
+		if want := fmt.Sprintf("slow_%d", i); result.SecretName != want {
+			t.Errorf("result %d is %s, want %s", i, result.SecretName, want)
+		}
+		if !result.Success || !result.ValueUpdated {
+			t.Errorf("%s: expected a successful rotation storing a value, got %+v", result.SecretName, result)
+		}
+		value, err := st.Get(result.SecretName)
+		if err != nil {
+			t.Fatalf("failed to get %s: %v", result.SecretName, err)
+		}
+		if value != "rotated-"+result.SecretName {
+			t.Errorf("%s: stored value %q", result.SecretName, value)
+		}
+	}
+}
+
 func TestCanRotate(t *testing.T) {
 	cfg, st, auditLogger, cleanup := setupTest(t)
 	defer cleanup()
@@ -376,7 +649,7 @@ func TestRotate_OutputCapture(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	result, err := executor.Rotate("output_test")
+	result, err := executor.Rotate("output_test", types.TriggerManual)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -406,12 +679,12 @@ func TestRotate_ConcurrentExecution(t *testing.T) {
 	done := make(chan error, 2)
 
 	go func() {
-		_, err := executor.Rotate("concurrent_secret")
+		_, err := executor.Rotate("concurrent_secret", types.TriggerManual)
 		done <- err
 	}()
 
 	go func() {
-		_, err := executor.Rotate("concurrent_secret")
+		_, err := executor.Rotate("concurrent_secret", types.TriggerManual)
 		done <- err
 	}()
 
@@ -433,7 +706,7 @@ func TestRotate_AuditLogging(t *testing.T) {
 	}
 
 	executor := NewExecutor(cfg, st, auditLogger)
-	_, err := executor.Rotate("audit_test")
+	_, err := executor.Rotate("audit_test", types.TriggerManual)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -456,6 +729,9 @@ func TestRotate_AuditLogging(t *testing.T) {
 			if !entry.Success {
 				t.Error("expected successful audit entry")
 			}
+			if entry.Trigger != types.TriggerManual {
+				t.Errorf("expected trigger %q, got %q", types.TriggerManual, entry.Trigger)
+			}
 			break
 		}
 	}
@@ -464,3 +740,120 @@ func TestRotate_AuditLogging(t *testing.T) {
 		t.Error("expected to find rotation audit entry")
 	}
 }
+
+func TestRotate_PropagatesToEnvFiles(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	envDir := t.TempDir()
+	envPath := filepath.Join(envDir, ".env.local")
+	vars := map[string]string{
+		"API_KEY":   "old_value",
+		"UNRELATED": "keep_me",
+	}
+	if err := envfile.WriteWithTTL(envPath, vars, time.Hour, "vercel"); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+	before, err := envfile.Read(envPath)
+	if err != nil {
+		t.Fatalf("failed to read env file: %v", err)
+	}
+
+	if err := st.Add("api_key", "old_value", "echo 'rotated'"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	missing := filepath.Join(envDir, "missing.env")
+	if err := st.SetPropagation("api_key", []types.EnvTarget{
+		{Path: envPath, Var: "API_KEY"},
+		{Path: missing, Var: "API_KEY"},
+	}); err != nil {
+		t.Fatalf("failed to set propagation: %v", err)
+	}
+
+	// The hook stores the new value before exiting
+	if err := st.Update("api_key", "new_value", nil); err != nil {
+		t.Fatalf("failed to update secret: %v", err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	result, err := executor.Rotate("api_key", types.TriggerManual)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Propagated) != 1 || result.Propagated[0] != envPath {
+		t.Errorf("expected propagation to %s only, got %v", envPath, result.Propagated)
+	}
+
+	after, err := envfile.Read(envPath)
+	if err != nil {
+		t.Fatalf("failed to read env file: %v", err)
+	}
+	if after.Vars["API_KEY"] != "new_value" {
+		t.Errorf("expected API_KEY=new_value, got %q", after.Vars["API_KEY"])
+	}
+	if after.Vars["UNRELATED"] != "keep_me" {
+		t.Errorf("expected UNRELATED to be untouched, got %q", after.Vars["UNRELATED"])
+	}
+	if !after.ExpiresAt.Equal(before.ExpiresAt) || after.Source != "vercel" {
+		t.Errorf("expected TTL header to be intact, got expires=%v source=%q", after.ExpiresAt, after.Source)
+	}
+
+	// Each propagation is audited, including the failed one
+	entries, err := auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	outcomes := map[bool]int{}
+	for _, entry := range entries {
+		if entry.Action == types.ActionSecretPropagate && entry.SecretName == "api_key" {
+			outcomes[entry.Success]++
+		}
+	}
+	if outcomes[true] != 1 || outcomes[false] != 1 {
+		t.Errorf("expected one successful and one failed propagation entry, got %v", outcomes)
+	}
+}
+
+func TestRotate_PropagatesBinaryAsFile(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	envPath := filepath.Join(t.TempDir(), ".env.local")
+	if err := envfile.WriteWithTTL(envPath, map[string]string{"OTHER": "x"}, time.Hour, "vercel"); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+
+	key := []byte{0x00, 0x01, 0xfe, 0xff, '\n'}
+	if err := st.AddBinary("tls_key", key, "echo 'rotated'", time.Time{}); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := st.SetPropagation("tls_key", []types.EnvTarget{{Path: envPath, Var: "TLS_KEY"}}); err != nil {
+		t.Fatalf("failed to set propagation: %v", err)
+	}
+
+	executor := NewExecutor(cfg, st, auditLogger)
+	result, err := executor.Rotate("tls_key", types.TriggerManual)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Propagated) != 1 {
+		t.Fatalf("expected one propagation, got %v", result.Propagated)
+	}
+
+	// The env file references a file holding the exact bytes
+	env, err := envfile.Read(envPath)
+	if err != nil {
+		t.Fatalf("failed to read env file: %v", err)
+	}
+	path := env.Vars["TLS_KEY"]
+	if path != filepath.Join(filepath.Dir(envPath), envfile.ManagedFilesDir, "TLS_KEY") {
+		t.Fatalf("TLS_KEY = %q, want a path to the key file", path)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(key) {
+		t.Errorf("key file holds %q, want %q", got, key)
+	}
+}
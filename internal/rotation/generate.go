@@ -0,0 +1,119 @@
+package rotation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/audit"
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// Environment variables passed to lease hooks.
+const (
+	hookEnvSecret   = "AGENT_SECRETS_SECRET"
+	hookEnvClientID = "AGENT_SECRETS_CLIENT_ID"
+	hookEnvLeaseID  = "AGENT_SECRETS_LEASE_ID"
+	hookEnvValue    = "AGENT_SECRETS_VALUE"
+)
+
+// Generate runs the secret's GenerateVia hook and returns its stdout, with
+// surrounding whitespace trimmed, as a value minted for one lease. The hook
+// sees the secret and client in AGENT_SECRETS_SECRET and
+// AGENT_SECRETS_CLIENT_ID, and is bounded by the rotation timeout. The
+// attempt is audited without the value.
+func (e *Executor) Generate(secret types.Secret, clientID string) (string, error) {
+	stdout, stderr, err := e.runHook(secret.GenerateVia,
+		hookEnvSecret+"="+secret.Name,
+		hookEnvClientID+"="+clientID,
+	)
+	value := strings.TrimSpace(stdout)
+	if err == nil && value == "" {
+		err = fmt.Errorf("%w: no output", types.ErrGenerateFailed)
+	} else if err != nil {
+		err = fmt.Errorf("%w: %v", types.ErrGenerateFailed, err)
+	}
+
+	builder := audit.NewEntry(types.ActionSecretGenerate, err == nil).
+		WithSecret(secret.Name).
+		WithClient(clientID)
+	if err != nil {
+		builder = builder.WithDetails(hookDetails(err, stderr))
+	}
+	_ = e.auditLogger.Log(builder.Build())
+
+	if err != nil {
+		return "", types.NewSecretError(secret.Name, err)
+	}
+	return value, nil
+}
+
+// RevokeGenerated runs the secret's RevokeVia hook for a lease whose value
+// came from Generate. The hook sees the lease in AGENT_SECRETS_LEASE_ID
+// (empty if no lease was granted) and the value in AGENT_SECRETS_VALUE,
+// which is empty if the daemon restarted since the value was generated.
+func (e *Executor) RevokeGenerated(secret types.Secret, lse types.Lease, value string) error {
+	if secret.RevokeVia == "" {
+		return nil
+	}
+
+	_, stderr, err := e.runHook(secret.RevokeVia,
+		hookEnvSecret+"="+secret.Name,
+		hookEnvClientID+"="+lse.ClientID,
+		hookEnvLeaseID+"="+lse.ID,
+		hookEnvValue+"="+value,
+	)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", types.ErrRevokeHookFailed, err)
+	}
+
+	builder := audit.NewEntry(types.ActionLeaseRevokeHook, err == nil).
+		WithSecret(secret.Name).
+		WithClient(lse.ClientID).
+		WithLease(lse.ID)
+	if err != nil {
+		builder = builder.WithDetails(hookDetails(err, stderr))
+	}
+	_ = e.auditLogger.Log(builder.Build())
+
+	if err != nil {
+		return types.NewSecretError(secret.Name, err)
+	}
+	return nil
+}
+
+// runHook runs command with sh -c and the extra environment, bounded by the
+// rotation timeout.
+func (e *Executor) runHook(command string, env ...string) (stdout, stderr string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.RotationTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(store.ChildEnv(), env...)
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	// Don't wait on children of a killed shell that still hold its output
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return outBuf.String(), errBuf.String(), fmt.Errorf("timed out after %v", e.cfg.RotationTimeout)
+		}
+		return outBuf.String(), errBuf.String(), err
+	}
+	return outBuf.String(), errBuf.String(), nil
+}
+
+// hookDetails describes a failed hook for the audit log. Only stderr is
+// included, since stdout may hold a credential.
+func hookDetails(err error, stderr string) string {
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return fmt.Sprintf("error: %v\nstderr: %s", err, stderr)
+	}
+	return fmt.Sprintf("error: %v", err)
+}
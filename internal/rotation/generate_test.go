@@ -0,0 +1,90 @@
+package rotation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/audit"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestGenerate(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+	executor := NewExecutor(cfg, st, auditLogger)
+
+	secret := types.Secret{Name: "db_creds", GenerateVia: `echo "user-$AGENT_SECRETS_CLIENT_ID"`}
+	value, err := executor.Generate(secret, "agent-1")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if value != "user-agent-1" {
+		t.Errorf("value = %q, want user-agent-1", value)
+	}
+
+	action := types.ActionSecretGenerate
+	entries, err := auditLogger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].Success {
+		t.Fatalf("audit entries = %+v, want one successful generate", entries)
+	}
+	if strings.Contains(entries[0].Details, "user-agent-1") {
+		t.Error("audit entry contains the generated value")
+	}
+}
+
+func TestGenerate_Failures(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+	cfg.RotationTimeout = 100 * time.Millisecond
+	executor := NewExecutor(cfg, st, auditLogger)
+
+	for name, command := range map[string]string{
+		"exit status": "echo oops >&2; exit 1",
+		"no output":   "true",
+		"timeout":     "sleep 5",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := executor.Generate(types.Secret{Name: "db_creds", GenerateVia: command}, "agent-1")
+			if !errors.Is(err, types.ErrGenerateFailed) {
+				t.Errorf("Generate error = %v, want ErrGenerateFailed", err)
+			}
+		})
+	}
+}
+
+func TestRevokeGenerated(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+	executor := NewExecutor(cfg, st, auditLogger)
+
+	out := filepath.Join(t.TempDir(), "revoked")
+	secret := types.Secret{
+		Name:        "db_creds",
+		GenerateVia: "echo minted",
+		RevokeVia:   `printf '%s %s' "$AGENT_SECRETS_LEASE_ID" "$AGENT_SECRETS_VALUE" > ` + out,
+	}
+	lse := types.Lease{ID: "lease-1", SecretName: "db_creds", ClientID: "agent-1"}
+	if err := executor.RevokeGenerated(secret, lse, "minted"); err != nil {
+		t.Fatalf("RevokeGenerated failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "lease-1 minted" {
+		t.Errorf("revoke hook saw %q, want %q", data, "lease-1 minted")
+	}
+
+	secret.RevokeVia = "exit 2"
+	if err := executor.RevokeGenerated(secret, lse, "minted"); !errors.Is(err, types.ErrRevokeHookFailed) {
+		t.Errorf("RevokeGenerated error = %v, want ErrRevokeHookFailed", err)
+	}
+}
@@ -0,0 +1,102 @@
+package rotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// Rotation outcomes recorded in a Report.
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeTimedOut  = "timed_out"
+)
+
+// Report summarizes a batch of rotations, such as one RotateAll run. Hook
+// output is left out, since it may hold a credential.
+type Report struct {
+	GeneratedAt time.Time     `json:"generated_at"`
+	Rotations   []ReportEntry `json:"rotations"`
+	Totals      ReportTotals  `json:"totals"`
+}
+
+// ReportEntry is the outcome of one attempted rotation.
+type ReportEntry struct {
+	Secret         string                `json:"secret"`
+	Trigger        types.RotationTrigger `json:"trigger"`
+	Outcome        string                `json:"outcome"`
+	ExitCode       int                   `json:"exit_code"`
+	DurationMs     int64                 `json:"duration_ms"`
+	ExecutedAt     time.Time             `json:"executed_at"`
+	OldFingerprint string                `json:"old_fingerprint,omitempty"`
+	NewFingerprint string                `json:"new_fingerprint,omitempty"`
+	Propagated     []string              `json:"propagated,omitempty"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// ReportTotals counts the entries of a Report by outcome.
+type ReportTotals struct {
+	Attempted int `json:"attempted"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"` // Includes timeouts
+}
+
+// NewReport builds a report with one entry per result, in order.
+func NewReport(results []types.RotationResult) *Report {
+	report := &Report{
+		GeneratedAt: time.Now(),
+		Rotations:   make([]ReportEntry, 0, len(results)),
+	}
+	for _, result := range results {
+		entry := ReportEntry{
+			Secret:         result.SecretName,
+			Trigger:        result.Trigger,
+			Outcome:        outcome(result),
+			ExitCode:       result.ExitCode,
+			DurationMs:     result.Duration.Milliseconds(),
+			ExecutedAt:     result.ExecutedAt,
+			OldFingerprint: result.OldFingerprint,
+			NewFingerprint: result.NewFingerprint,
+			Propagated:     result.Propagated,
+			Error:          result.Error,
+		}
+		report.Rotations = append(report.Rotations, entry)
+
+		report.Totals.Attempted++
+		if result.Success {
+			report.Totals.Succeeded++
+		} else {
+			report.Totals.Failed++
+		}
+	}
+	return report
+}
+
+// outcome classifies a rotation result.
+func outcome(result types.RotationResult) string {
+	switch {
+	case result.Success:
+		return OutcomeSucceeded
+	case result.Error == timedOutError:
+		return OutcomeTimedOut
+	default:
+		return OutcomeFailed
+	}
+}
+
+// WriteReport writes report to path as indented JSON, readable only by the
+// owner.
+func WriteReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package rotation
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestReport_FromRotateAll(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := st.Add("good", "v1", "echo rotated"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Add("bad", "v2", "exit 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Add("static", "v3", ""); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, err := st.Fingerprint("good")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := NewExecutor(cfg, st, auditLogger).RotateAll(types.TriggerManual)
+	if err != nil {
+		t.Fatalf("RotateAll failed: %v", err)
+	}
+	report := NewReport(results)
+
+	// One entry per attempted rotation; secrets without hooks are skipped
+	if len(report.Rotations) != 2 {
+		t.Fatalf("report has %d entries, want 2: %+v", len(report.Rotations), report.Rotations)
+	}
+	entries := make(map[string]ReportEntry)
+	for _, entry := range report.Rotations {
+		entries[entry.Secret] = entry
+	}
+
+	good := entries["good"]
+	if good.Outcome != OutcomeSucceeded || good.ExitCode != 0 || good.Error != "" {
+		t.Errorf("good entry = %+v", good)
+	}
+	if good.Trigger != types.TriggerManual {
+		t.Errorf("good trigger = %q, want %q", good.Trigger, types.TriggerManual)
+	}
+	if good.OldFingerprint != fingerprint || good.NewFingerprint != fingerprint {
+		t.Errorf("good fingerprints = %q -> %q, want %q unchanged", good.OldFingerprint, good.NewFingerprint, fingerprint)
+	}
+
+	bad := entries["bad"]
+	if bad.Outcome != OutcomeFailed || bad.ExitCode != 3 || bad.Error == "" {
+		t.Errorf("bad entry = %+v", bad)
+	}
+
+	// Totals match the individual entries
+	want := ReportTotals{Attempted: 2, Succeeded: 1, Failed: 1}
+	if report.Totals != want {
+		t.Errorf("totals = %+v, want %+v", report.Totals, want)
+	}
+}
+
+func TestReport_TimedOut(t *testing.T) {
+	report := NewReport([]types.RotationResult{
+		{SecretName: "slow", Error: timedOutError, ExitCode: -1},
+	})
+	if got := report.Rotations[0].Outcome; got != OutcomeTimedOut {
+		t.Errorf("outcome = %q, want %q", got, OutcomeTimedOut)
+	}
+	if report.Totals.Failed != 1 {
+		t.Errorf("timeouts should count as failed: %+v", report.Totals)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	report := NewReport([]types.RotationResult{
+		{SecretName: "token", Success: true, Output: "sk_live_leaked", Trigger: types.TriggerManual},
+	})
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteReport(path, report); err != nil {
+		t.Fatalf("WriteReport failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("report mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if len(decoded.Rotations) != 1 || decoded.Rotations[0].Secret != "token" || decoded.Totals.Succeeded != 1 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+	if strings.Contains(string(data), "sk_live_leaked") {
+		t.Error("report includes hook output")
+	}
+}
@@ -0,0 +1,150 @@
+package rotation
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// Backoff bounds for retrying a failed scheduled rotation. The delay doubles
+// with each consecutive failure.
+const (
+	defaultRetryMin = time.Minute
+	defaultRetryMax = time.Hour
+)
+
+// Scheduler runs rotation hooks automatically on the schedule set by each
+// secret's RotationPolicy. Scheduled rotations are audited by the executor
+// with TriggerScheduled.
+type Scheduler struct {
+	executor *Executor
+	now      func() time.Time // Replaced in tests
+
+	retryMin time.Duration
+	retryMax time.Duration
+
+	mu      sync.Mutex
+	retries map[string]retryState // Secrets whose last scheduled rotation failed
+}
+
+// retryState tracks the backoff of a failing scheduled rotation.
+type retryState struct {
+	failures int
+	next     time.Time // No retry before this, unless past the policy's MaxAge
+}
+
+// NewScheduler creates a scheduler that rotates through executor.
+func NewScheduler(executor *Executor) *Scheduler {
+	return &Scheduler{
+		executor: executor,
+		now:      time.Now,
+		retryMin: defaultRetryMin,
+		retryMax: defaultRetryMax,
+		retries:  make(map[string]retryState),
+	}
+}
+
+// Due returns the secrets whose scheduled rotation is due now, sorted by
+// name. A rotation that failed is not due again until its backoff passes,
+// unless the secret has outlived its policy's MaxAge.
+func (s *Scheduler) Due() ([]string, error) {
+	secrets, err := s.executor.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	scheduled := make(map[string]bool)
+	var due []string
+	for _, secret := range secrets {
+		policy := secret.RotationPolicy
+		if policy == nil || secret.RotateVia == "" {
+			continue
+		}
+		scheduled[secret.Name] = true
+
+		last := secret.LastRotated
+		if last.IsZero() {
+			last = secret.CreatedAt
+		}
+		if now.Before(last.Add(policy.Interval)) {
+			continue
+		}
+		if retry, ok := s.retries[secret.Name]; ok && now.Before(retry.next) {
+			if policy.MaxAge == 0 || now.Before(last.Add(policy.MaxAge)) {
+				continue
+			}
+		}
+		due = append(due, secret.Name)
+	}
+
+	// Forget backoff for secrets that are gone or no longer scheduled
+	for name := range s.retries {
+		if !scheduled[name] {
+			delete(s.retries, name)
+		}
+	}
+
+	sort.Strings(due)
+	return due, nil
+}
+
+// RunDue rotates every secret that is due and returns the results. A
+// failure delays that secret's next attempt with exponential backoff; it
+// does not stop the others.
+func (s *Scheduler) RunDue() []types.RotationResult {
+	due, err := s.Due()
+	if err != nil {
+		return nil
+	}
+
+	var results []types.RotationResult
+	for _, name := range due {
+		result, err := s.executor.Rotate(name, types.TriggerScheduled)
+		s.record(name, err)
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results
+}
+
+// record updates the backoff of a secret after a scheduled rotation.
+func (s *Scheduler) record(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		delete(s.retries, name)
+		return
+	}
+
+	retry := s.retries[name]
+	retry.failures++
+	delay := s.retryMin
+	for i := 1; i < retry.failures && delay < s.retryMax; i++ {
+		delay *= 2
+	}
+	retry.next = s.now().Add(min(delay, s.retryMax))
+	s.retries[name] = retry
+}
+
+// Run calls RunDue every interval until done is closed.
+func (s *Scheduler) Run(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.RunDue()
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package rotation
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/store"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// fakeClock is a settable clock that is safe to read from Run's goroutine.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// setupScheduler adds a secret rotated by hook with the given policy, and
+// returns a scheduler on a fake clock starting at the secret's creation.
+func setupScheduler(t *testing.T, hook string, policy types.RotationPolicy) (*Scheduler, *fakeClock, *store.Store, time.Time) {
+	t.Helper()
+
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	t.Cleanup(cleanup)
+
+	if err := st.Add("scheduled", "value", hook); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := st.SetRotationPolicy("scheduled", &policy); err != nil {
+		t.Fatalf("failed to set rotation policy: %v", err)
+	}
+	created := secretByName(t, st, "scheduled").CreatedAt
+
+	clock := &fakeClock{now: created}
+	scheduler := NewScheduler(NewExecutor(cfg, st, auditLogger))
+	scheduler.now = clock.Now
+	return scheduler, clock, st, created
+}
+
+func secretByName(t *testing.T, st *store.Store, name string) types.Secret {
+	t.Helper()
+
+	secrets, err := st.List()
+	if err != nil {
+		t.Fatalf("failed to list secrets: %v", err)
+	}
+	for _, s := range secrets {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("secret %q not found", name)
+	return types.Secret{}
+}
+
+func TestScheduler_Due(t *testing.T) {
+	scheduler, clock, st, created := setupScheduler(t, "echo rotated", types.RotationPolicy{Interval: time.Hour})
+
+	// Secrets without a policy are never scheduled
+	if err := st.Add("unscheduled", "value", "echo rotated"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+
+	clock.Set(created.Add(30 * time.Minute))
+	due, err := scheduler.Due()
+	if err != nil {
+		t.Fatalf("Due failed: %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("expected nothing due before the interval, got %v", due)
+	}
+
+	clock.Set(created.Add(time.Hour))
+	due, err = scheduler.Due()
+	if err != nil {
+		t.Fatalf("Due failed: %v", err)
+	}
+	if len(due) != 1 || due[0] != "scheduled" {
+		t.Errorf("expected [scheduled] due, got %v", due)
+	}
+}
+
+func TestScheduler_RunDue(t *testing.T) {
+	scheduler, clock, st, created := setupScheduler(t, "echo rotated", types.RotationPolicy{Interval: time.Hour})
+
+	clock.Set(created.Add(2 * time.Hour))
+	results := scheduler.RunDue()
+	if len(results) != 1 {
+		t.Fatalf("expected 1 rotation, got %d", len(results))
+	}
+	if !results[0].Success {
+		t.Errorf("expected success, got: %s", results[0].Error)
+	}
+	if results[0].Trigger != types.TriggerScheduled {
+		t.Errorf("expected trigger %q, got %q", types.TriggerScheduled, results[0].Trigger)
+	}
+
+	secret := secretByName(t, st, "scheduled")
+	if secret.LastRotated.IsZero() {
+		t.Error("expected LastRotated to be set")
+	}
+
+	// The interval is now measured from the rotation
+	clock.Set(secret.LastRotated.Add(30 * time.Minute))
+	if due, _ := scheduler.Due(); len(due) != 0 {
+		t.Errorf("expected nothing due after rotating, got %v", due)
+	}
+}
+
+func TestScheduler_Backoff(t *testing.T) {
+	scheduler, clock, _, created := setupScheduler(t, "exit 1", types.RotationPolicy{Interval: time.Hour})
+
+	now := created.Add(time.Hour)
+	clock.Set(now)
+	results := scheduler.RunDue()
+	if len(results) != 1 || results[0].Success {
+		t.Fatalf("expected 1 failed rotation, got %+v", results)
+	}
+
+	// First retry after retryMin
+	if due, _ := scheduler.Due(); len(due) != 0 {
+		t.Errorf("expected backoff after a failure, got %v", due)
+	}
+	now = now.Add(scheduler.retryMin)
+	clock.Set(now)
+	if due, _ := scheduler.Due(); len(due) != 1 {
+		t.Fatalf("expected retry after %v, got %v", scheduler.retryMin, due)
+	}
+
+	// Second failure doubles the delay
+	scheduler.RunDue()
+	now = now.Add(scheduler.retryMin)
+	clock.Set(now)
+	if due, _ := scheduler.Due(); len(due) != 0 {
+		t.Errorf("expected doubled backoff after two failures, got %v", due)
+	}
+	now = now.Add(scheduler.retryMin)
+	clock.Set(now)
+	if due, _ := scheduler.Due(); len(due) != 1 {
+		t.Errorf("expected retry after %v, got %v", 2*scheduler.retryMin, due)
+	}
+
+	// Failures are capped at retryMax
+	for i := 0; i < 20; i++ {
+		scheduler.record("scheduled", types.ErrRotationFailed)
+	}
+	retry := scheduler.retries["scheduled"]
+	if got := retry.next.Sub(now); got != scheduler.retryMax {
+		t.Errorf("expected backoff capped at %v, got %v", scheduler.retryMax, got)
+	}
+}
+
+func TestScheduler_MaxAgeOverridesBackoff(t *testing.T) {
+	scheduler, clock, _, created := setupScheduler(t, "exit 1", types.RotationPolicy{Interval: time.Hour, MaxAge: 2 * time.Hour})
+	scheduler.retryMin = 24 * time.Hour
+
+	clock.Set(created.Add(90 * time.Minute))
+	scheduler.RunDue()
+	if due, _ := scheduler.Due(); len(due) != 0 {
+		t.Errorf("expected backoff before max age, got %v", due)
+	}
+
+	clock.Set(created.Add(2 * time.Hour))
+	if due, _ := scheduler.Due(); len(due) != 1 {
+		t.Errorf("expected retry past max age despite backoff, got %v", due)
+	}
+}
+
+func TestScheduler_FailuresAudited(t *testing.T) {
+	cfg, st, auditLogger, cleanup := setupTest(t)
+	defer cleanup()
+
+	if err := st.Add("scheduled", "value", "exit 1"); err != nil {
+		t.Fatalf("failed to add secret: %v", err)
+	}
+	if err := st.SetRotationPolicy("scheduled", &types.RotationPolicy{Interval: time.Hour}); err != nil {
+		t.Fatalf("failed to set rotation policy: %v", err)
+	}
+
+	scheduler := NewScheduler(NewExecutor(cfg, st, auditLogger))
+	scheduler.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	scheduler.RunDue()
+
+	entries, err := auditLogger.Tail(10)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Action == types.ActionSecretRotate && entry.SecretName == "scheduled" {
+			found = true
+			if entry.Success {
+				t.Error("expected failed audit entry")
+			}
+			if entry.Trigger != types.TriggerScheduled {
+				t.Errorf("expected trigger %q, got %q", types.TriggerScheduled, entry.Trigger)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected to find scheduled rotation audit entry")
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	scheduler, clock, st, created := setupScheduler(t, "echo rotated", types.RotationPolicy{Interval: time.Hour})
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Run(10*time.Millisecond, done)
+		close(stopped)
+	}()
+	defer func() {
+		close(done)
+		<-stopped
+	}()
+
+	// Nothing is due yet, so the loop must not rotate
+	time.Sleep(50 * time.Millisecond)
+	if !secretByName(t, st, "scheduled").LastRotated.IsZero() {
+		t.Fatal("expected no rotation before the interval")
+	}
+
+	clock.Set(created.Add(time.Hour))
+	deadline := time.Now().Add(5 * time.Second)
+	for secretByName(t, st, "scheduled").LastRotated.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the loop to rotate the secret")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultBaselineFile is the conventional name of a scanner baseline.
+const DefaultBaselineFile = ".secrets-baseline.json"
+
+// baselineVersion is the current baseline file format version.
+const baselineVersion = 1
+
+// Fingerprint identifies an accepted finding without storing its value.
+// Line is kept for reference; matching relies on File, PatternName, and
+// ValueHash so that findings survive line-number drift.
+type Fingerprint struct {
+	File        string `json:"file"` // Relative to the baseline file's directory
+	Line        int    `json:"line"`
+	PatternName string `json:"pattern_name"`
+	ValueHash   string `json:"value_hash"` // Hex SHA-256 of the matched value
+}
+
+// Baseline is a set of accepted findings that scans should not report.
+type Baseline struct {
+	Version      int           `json:"version"`
+	Fingerprints []Fingerprint `json:"fingerprints"`
+
+	// dir is the directory that fingerprint paths are relative to.
+	dir string
+}
+
+// fingerprintKey groups fingerprints that match the same secret in a file.
+type fingerprintKey struct {
+	file, pattern, hash string
+}
+
+// LoadBaseline reads a baseline file. A missing file yields an empty baseline.
+func LoadBaseline(path string) (*Baseline, error) {
+	b := &Baseline{Version: baselineVersion, dir: baseDir(path)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	if b.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d in %s", b.Version, path)
+	}
+
+	return b, nil
+}
+
+// WriteBaseline records findings as the accepted baseline at path.
+func WriteBaseline(path string, findings []Finding) error {
+	b := &Baseline{Version: baselineVersion, dir: baseDir(path)}
+	for _, f := range findings {
+		b.Fingerprints = append(b.Fingerprints, b.fingerprint(f))
+	}
+	if b.Fingerprints == nil {
+		b.Fingerprints = []Fingerprint{}
+	}
+
+	sort.Slice(b.Fingerprints, func(i, j int) bool {
+		fi, fj := b.Fingerprints[i], b.Fingerprints[j]
+		if fi.File != fj.File {
+			return fi.File < fj.File
+		}
+		if fi.Line != fj.Line {
+			return fi.Line < fj.Line
+		}
+		return fi.PatternName < fj.PatternName
+	})
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
+
+// Filter returns the findings not covered by the baseline and the number
+// suppressed. Each fingerprint suppresses at most one finding: an exact line
+// match is preferred, otherwise the same secret elsewhere in the file (e.g.
+// after lines were inserted above it).
+func (b *Baseline) Filter(findings []Finding) ([]Finding, int) {
+	remaining := make(map[fingerprintKey][]int)
+	for _, fp := range b.Fingerprints {
+		key := fingerprintKey{fp.File, fp.PatternName, fp.ValueHash}
+		remaining[key] = append(remaining[key], fp.Line)
+	}
+
+	suppressed := make([]bool, len(findings))
+	keys := make([]fingerprintKey, len(findings))
+	for i, f := range findings {
+		fp := b.fingerprint(f)
+		keys[i] = fingerprintKey{fp.File, fp.PatternName, fp.ValueHash}
+	}
+
+	// First pass: exact line matches
+	for i, f := range findings {
+		lines := remaining[keys[i]]
+		for j, line := range lines {
+			if line == f.Line {
+				remaining[keys[i]] = append(lines[:j], lines[j+1:]...)
+				suppressed[i] = true
+				break
+			}
+		}
+	}
+
+	// Second pass: the same secret on a different line
+	for i := range findings {
+		if suppressed[i] {
+			continue
+		}
+		if lines := remaining[keys[i]]; len(lines) > 0 {
+			remaining[keys[i]] = lines[1:]
+			suppressed[i] = true
+		}
+	}
+
+	kept := make([]Finding, 0, len(findings))
+	count := 0
+	for i, f := range findings {
+		if suppressed[i] {
+			count++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, count
+}
+
+// fingerprint computes the baseline fingerprint of a finding.
+func (b *Baseline) fingerprint(f Finding) Fingerprint {
+	file := f.File
+	if abs, err := filepath.Abs(f.File); err == nil {
+		if rel, err := filepath.Rel(b.dir, abs); err == nil {
+			file = rel
+		}
+	}
+
+	sum := sha256.Sum256([]byte(f.Value))
+	return Fingerprint{
+		File:        filepath.ToSlash(file),
+		Line:        f.Line,
+		PatternName: f.PatternName,
+		ValueHash:   hex.EncodeToString(sum[:]),
+	}
+}
+
+// baseDir returns the absolute directory containing the baseline at path.
+func baseDir(path string) string {
+	dir := filepath.Dir(path)
+	if abs, err := filepath.Abs(dir); err == nil {
+		return abs
+	}
+	return dir
+}
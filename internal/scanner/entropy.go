@@ -0,0 +1,122 @@
+package scanner
+
+import (
+	"math"
+	"regexp"
+)
+
+const (
+	// DefaultEntropyThreshold is the Shannon entropy, in bits per character,
+	// above which a token is reported.
+	DefaultEntropyThreshold = 4.5
+
+	// DefaultEntropyMinLength is the shortest token checked for entropy.
+	DefaultEntropyMinLength = 20
+
+	// EntropyPatternName is the PatternName of high-entropy findings.
+	EntropyPatternName = "High Entropy String"
+
+	// singleClassCutoff is the length below which tokens made of a single
+	// character class (e.g. only letters) are skipped. Long identifiers and
+	// encoded prose are single-class far more often than real credentials.
+	singleClassCutoff = 40
+)
+
+// tokenRegex splits a line into candidate tokens: runs of characters that
+// appear in keys and base64/base64url encodings.
+var tokenRegex = regexp.MustCompile(`[A-Za-z0-9+/=_\-]+`)
+
+// entropyDetector flags tokens whose Shannon entropy exceeds a threshold.
+type entropyDetector struct {
+	threshold float64
+	minLen    int
+	severity  Severity
+}
+
+// WithEntropyDetection enables detection of high-entropy strings that do not
+// match any pattern. Tokens of at least minLen characters with more than
+// threshold bits of entropy per character are reported with SeverityMedium.
+func (s *Scanner) WithEntropyDetection(threshold float64, minLen int) *Scanner {
+	s.entropy = &entropyDetector{
+		threshold: threshold,
+		minLen:    minLen,
+		severity:  SeverityMedium,
+	}
+	return s
+}
+
+// scanLine returns high-entropy tokens in line as [start, end) index pairs,
+// skipping any that overlap a span in matched.
+func (d *entropyDetector) scanLine(line string, matched [][]int) [][]int {
+	var hits [][]int
+	for _, loc := range tokenRegex.FindAllStringIndex(line, -1) {
+		token := line[loc[0]:loc[1]]
+		if len(token) < d.minLen {
+			continue
+		}
+		if overlaps(loc, matched) {
+			continue
+		}
+		if len(token) < singleClassCutoff && charClasses(token) == 1 {
+			continue
+		}
+		if shannonEntropy(token) > d.threshold {
+			hits = append(hits, loc)
+		}
+	}
+	return hits
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// charClasses counts how many of letters, digits, and symbols appear in s.
+func charClasses(s string) int {
+	var letter, digit, symbol bool
+	for _, r := range s {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			letter = true
+		case r >= '0' && r <= '9':
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{letter, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
+
+// overlaps reports whether loc intersects any span in spans.
+func overlaps(loc []int, spans [][]int) bool {
+	for _, span := range spans {
+		if loc[0] < span[1] && span[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
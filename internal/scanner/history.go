@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hunkRegex captures the starting line in the new file from a unified diff
+// hunk header, e.g. "@@ -10,2 +12,3 @@".
+var hunkRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ScanGitHistory scans lines added by each commit in the git repository at
+// repoPath, newest first, so that secrets removed from the working tree are
+// still found. At most maxCommits commits are examined; zero or less means
+// the entire history. Findings carry the commit SHA and author, and File is
+// the path within repoPath at that commit. Merge commits are skipped since
+// their changes are scanned in the commits being merged.
+func (s *Scanner) ScanGitHistory(repoPath string, maxCommits int) (ScanResult, error) {
+	start := time.Now()
+	result := ScanResult{
+		Findings: []Finding{},
+		Root:     repoPath,
+	}
+
+	baseline, err := s.loadBaseline()
+	if err != nil {
+		return result, err
+	}
+
+	args := []string{"log", "--no-merges", "--format=%H%x00%an <%ae>"}
+	if maxCommits > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", maxCommits))
+	}
+	out, err := runGit(repoPath, args...)
+	if err != nil {
+		return result, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	files := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		sha, author, _ := strings.Cut(line, "\x00")
+
+		diff, err := runGit(repoPath, "show", "--format=", "--unified=0", "--no-color",
+			"--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/", sha)
+		if err != nil {
+			return result, fmt.Errorf("failed to read commit %s: %w", sha, err)
+		}
+
+		findings, skipped, err := s.scanDiff(repoPath, diff, files)
+		if err != nil {
+			return result, fmt.Errorf("failed to scan commit %s: %w", sha, err)
+		}
+		result.addSkipped(skipped)
+		for i := range findings {
+			findings[i].Commit = sha
+			findings[i].Author = author
+		}
+
+		result.Findings = append(result.Findings, findings...)
+		result.ScannedCommits++
+	}
+	result.ScannedFiles = len(files)
+
+	if baseline != nil {
+		result.Findings, result.Suppressed = baseline.Filter(result.Findings)
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// scanDiff scans the added lines of a unified diff with zero context lines,
+// returning the findings and what was skipped for ignore comments or length.
+// Without context, an ignore comment on the line above only applies if it was
+// added in the same hunk. Every file touched is recorded in files.
+func (s *Scanner) scanDiff(repoPath string, diff []byte, files map[string]bool) ([]Finding, skipCounts, error) {
+	var findings []Finding
+	var skipped skipCounts
+	var filePath string        // empty while in a skipped file
+	inHeader := false          // between "diff --git" and the first hunk
+	var above *ignoreDirective // directive on the previous added line
+	lineNum := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), int(s.maxSize))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			filePath = ""
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "+++ "):
+			filePath = ""
+			name := diffPath(strings.TrimPrefix(line, "+++ "))
+			if name == "" {
+				continue // deleted file
+			}
+			native := filepath.FromSlash(name)
+			if s.isExcluded(native) || isBinaryFile(native) {
+				continue
+			}
+			filePath = filepath.Join(repoPath, native)
+			files[filePath] = true
+		case strings.HasPrefix(line, "@@"):
+			inHeader = false
+			above = nil
+			if m := hunkRegex.FindStringSubmatch(line); m != nil {
+				lineNum, _ = strconv.Atoi(m[1])
+			}
+		case !inHeader && strings.HasPrefix(line, "+"):
+			if filePath == "" {
+				continue
+			}
+			if s.lineTooLong(len(line) - 1) {
+				skipped.longLines++
+				above = nil
+				lineNum++
+				continue
+			}
+			current := parseIgnore(line[1:])
+			lineFindings, n := filterIgnored(s.scanLine(filePath, lineNum, line[1:]), current, above)
+			findings = append(findings, lineFindings...)
+			skipped.ignored += n
+			above = current
+			lineNum++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, skipCounts{}, err
+	}
+	return findings, skipped, nil
+}
+
+// diffPath extracts the path from the target of a "+++" diff header,
+// returning "" for /dev/null. Paths with unusual characters are quoted by git.
+func diffPath(header string) string {
+	if header == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(header, `"`) {
+		if unquoted, err := strconv.Unquote(header); err == nil {
+			header = unquoted
+		}
+	}
+	return strings.TrimPrefix(header, "b/")
+}
+
+// runGit runs a git command in dir and returns its standard output.
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
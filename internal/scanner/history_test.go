@@ -0,0 +1,179 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates an empty git repository with a fixed author identity.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	git(t, dir, "config", "user.name", "Test Author")
+	git(t, dir, "config", "user.email", "author@example.com")
+	git(t, dir, "config", "commit.gpgsign", "false")
+	return dir
+}
+
+// git runs a git command in dir and returns its trimmed output.
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestScanGitHistory_DeletedSecret(t *testing.T) {
+	dir := gitRepo(t)
+
+	// First commit introduces a secret on line 3
+	writeScanFile(t, filepath.Join(dir, "config", "app.env"), "PORT=8080\nDEBUG=false\nGITHUB_TOKEN="+fixtureToken+"\n")
+	writeScanFile(t, filepath.Join(dir, "README.md"), "# app\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "add config")
+	introduced := git(t, dir, "rev-parse", "HEAD")
+
+	// Second commit removes it again
+	if err := os.Remove(filepath.Join(dir, "config", "app.env")); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "remove config")
+
+	s := NewScanner(DefaultPatterns(), nil).WithRecursive(true)
+
+	// The working tree no longer contains the secret
+	current, err := s.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countPattern(current.Findings, "GitHub Personal Access Token"); n != 0 {
+		t.Fatalf("expected no findings in working tree, got %d", n)
+	}
+
+	result, err := s.ScanGitHistory(dir, 0)
+	if err != nil {
+		t.Fatalf("ScanGitHistory: %v", err)
+	}
+	if result.ScannedCommits != 2 {
+		t.Errorf("ScannedCommits = %d, want 2", result.ScannedCommits)
+	}
+
+	var found *Finding
+	for i, f := range result.Findings {
+		if f.PatternName == "GitHub Personal Access Token" {
+			if found != nil {
+				t.Fatalf("secret reported more than once: %+v", result.Findings)
+			}
+			found = &result.Findings[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("secret not found in history: %+v", result.Findings)
+	}
+
+	if found.Commit != introduced {
+		t.Errorf("Commit = %q, want %q", found.Commit, introduced)
+	}
+	if found.Author != "Test Author <author@example.com>" {
+		t.Errorf("Author = %q", found.Author)
+	}
+	if want := filepath.Join(dir, "config", "app.env"); found.File != want {
+		t.Errorf("File = %q, want %q", found.File, want)
+	}
+	if found.Line != 3 || found.Column != 14 {
+		t.Errorf("position = %d:%d, want 3:14", found.Line, found.Column)
+	}
+
+	// Only the newest commit, which deleted the secret
+	result, err = s.ScanGitHistory(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ScannedCommits != 1 || len(result.Findings) != 0 {
+		t.Errorf("max 1 commit: got %d commits, %d findings", result.ScannedCommits, len(result.Findings))
+	}
+}
+
+func TestScanGitHistory_Excludes(t *testing.T) {
+	dir := gitRepo(t)
+
+	writeScanFile(t, filepath.Join(dir, "vendor", "lib", "keys.go"), "const key = \""+fixtureToken+"\"\n")
+	writeScanFile(t, filepath.Join(dir, "main.go"), "const key = \""+newToken+"\"\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+
+	result, err := NewScanner(DefaultPatterns(), []string{"vendor"}).ScanGitHistory(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range result.Findings {
+		if strings.Contains(f.File, "vendor") {
+			t.Errorf("excluded path reported: %s", f.File)
+		}
+	}
+	if n := countPattern(result.Findings, "GitHub Personal Access Token"); n != 1 {
+		t.Errorf("expected 1 finding outside vendor, got %d", n)
+	}
+}
+
+func TestScanGitHistory_ModifiedLines(t *testing.T) {
+	dir := gitRepo(t)
+
+	path := filepath.Join(dir, "settings.py")
+	writeScanFile(t, path, "A = 1\nB = 2\nC = 3\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+
+	// Replace line 2 with a secret; only the added line is scanned
+	writeScanFile(t, path, "A = 1\nB = \""+fixtureToken+"\"\nC = 3\n")
+	git(t, dir, "commit", "-q", "-am", "add token")
+
+	result, err := NewScanner(DefaultPatterns(), nil).ScanGitHistory(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countPattern(result.Findings, "GitHub Personal Access Token") != 1 {
+		t.Fatalf("expected 1 finding, got %+v", result.Findings)
+	}
+	for _, f := range result.Findings {
+		if f.PatternName == "GitHub Personal Access Token" && f.Line != 2 {
+			t.Errorf("Line = %d, want 2", f.Line)
+		}
+	}
+}
+
+func TestScanGitHistory_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	if _, err := NewScanner(DefaultPatterns(), nil).ScanGitHistory(t.TempDir(), 0); err == nil {
+		t.Fatal("expected error for a directory that is not a git repository")
+	}
+}
+
+func TestDiffPath(t *testing.T) {
+	tests := map[string]string{
+		"b/src/main.go":              "src/main.go",
+		"/dev/null":                  "",
+		`"b/caf\303\251/secret.txt"`: "café/secret.txt",
+	}
+
+	for header, want := range tests {
+		if got := diffPath(header); got != want {
+			t.Errorf("diffPath(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
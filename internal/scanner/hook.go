@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HookMarker identifies a git hook written by InstallHook, so it can be
+// replaced without --force.
+const HookMarker = "# secrets-managed: pre-commit"
+
+// ErrHookExists is returned by InstallHook when a hook not written by it is
+// already installed.
+var ErrHookExists = errors.New("a pre-commit hook not managed by secrets already exists")
+
+// PreCommitHook returns a pre-commit hook script that scans the staged files
+// and blocks the commit if any finding is at or above failOn.
+func PreCommitHook(failOn Severity) string {
+	return `#!/bin/sh
+` + HookMarker + `
+# Installed by "secrets install-hook". Blocks commits that stage secrets of
+# ` + failOn.String() + ` severity or above; bypass with "git commit --no-verify".
+if ! command -v secrets >/dev/null 2>&1; then
+	echo "pre-commit: secrets is not installed or not in PATH" >&2
+	exit 1
+fi
+exec secrets scan --staged --format json --fail-on ` + failOn.String() + ` --no-update-check
+`
+}
+
+// InstallHook writes PreCommitHook(failOn) to path. An existing hook is only
+// replaced if it was written by InstallHook, or if force is set. It reports
+// whether an existing hook was replaced.
+func InstallHook(path string, failOn Severity, force bool) (bool, error) {
+	existing, err := os.ReadFile(path)
+	replaced := err == nil
+	switch {
+	case replaced:
+		if !force && !strings.Contains(string(existing), HookMarker) {
+			return false, fmt.Errorf("%w: %s", ErrHookExists, path)
+		}
+	case !os.IsNotExist(err):
+		return false, fmt.Errorf("failed to read existing hook: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(PreCommitHook(failOn)), 0755); err != nil {
+		return false, fmt.Errorf("failed to write hook: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0755); err != nil {
+		return false, fmt.Errorf("failed to make hook executable: %w", err)
+	}
+
+	return replaced, nil
+}
+
+// HookPath returns the path of the pre-commit hook for the git repository
+// containing dir, honoring core.hooksPath.
+func HookPath(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--path-format=absolute", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return "", fmt.Errorf("failed to find git hooks directory: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallHook(t *testing.T) {
+	const foreign = "#!/bin/sh\nnpm test\n"
+
+	tests := []struct {
+		name         string
+		existing     string // empty for no existing hook
+		force        bool
+		wantErr      error
+		wantReplaced bool
+	}{
+		{name: "no existing hook"},
+		{name: "managed hook is updated", existing: PreCommitHook(SeverityCritical), wantReplaced: true},
+		{name: "foreign hook is kept", existing: foreign, wantErr: ErrHookExists},
+		{name: "foreign hook with force", existing: foreign, force: true, wantReplaced: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "hooks", "pre-commit")
+			if tt.existing != "" {
+				writeScanFile(t, path, tt.existing)
+			}
+
+			replaced, err := InstallHook(path, SeverityHigh, tt.force)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("InstallHook error = %v, want %v", err, tt.wantErr)
+			}
+			if replaced != tt.wantReplaced {
+				t.Errorf("replaced = %v, want %v", replaced, tt.wantReplaced)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil {
+				if string(content) != tt.existing {
+					t.Errorf("existing hook was modified:\n%s", content)
+				}
+				return
+			}
+
+			if string(content) != PreCommitHook(SeverityHigh) {
+				t.Errorf("unexpected hook content:\n%s", content)
+			}
+			if !strings.Contains(string(content), "secrets scan --staged --format json --fail-on high") {
+				t.Errorf("hook does not run a staged scan:\n%s", content)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0111 == 0 {
+				t.Errorf("hook is not executable: %v", info.Mode())
+			}
+		})
+	}
+}
+
+func TestHookPath(t *testing.T) {
+	dir := gitRepo(t)
+
+	got, err := HookPath(dir)
+	if err != nil {
+		t.Fatalf("HookPath: %v", err)
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, ".git", "hooks", "pre-commit"); got != want {
+		t.Errorf("HookPath = %q, want %q", got, want)
+	}
+
+	git(t, dir, "config", "core.hooksPath", "githooks")
+	got, err = HookPath(dir)
+	if err != nil {
+		t.Fatalf("HookPath: %v", err)
+	}
+	if want := filepath.Join(root, "githooks", "pre-commit"); got != want {
+		t.Errorf("HookPath with core.hooksPath = %q, want %q", got, want)
+	}
+}
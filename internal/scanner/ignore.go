@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"strings"
+)
+
+// IgnoreDirective is the comment marker that suppresses findings on its own
+// line and the line below it. It may be followed by a comma-separated list of
+// pattern names (or their rule ids), e.g.
+//
+//	token = "ghp_..." // secrets:ignore GitHub Personal Access Token
+//	# secrets:ignore aws-access-key-id, generic-api-key
+const IgnoreDirective = "secrets:ignore"
+
+// ignoreDirective is a parsed secrets:ignore comment.
+type ignoreDirective struct {
+	patterns map[string]bool // lower-cased names and rule ids; empty ignores all
+}
+
+// parseIgnore returns the ignore directive in line, or nil if there is none.
+func parseIgnore(line string) *ignoreDirective {
+	idx := strings.Index(line, IgnoreDirective)
+	if idx < 0 {
+		return nil
+	}
+
+	rest := line[idx+len(IgnoreDirective):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' && !isCommentCloser(rest) {
+		return nil // e.g. "secrets:ignored"
+	}
+
+	rest = strings.TrimSpace(rest)
+	for _, closer := range []string{"*/", "-->"} {
+		rest = strings.TrimSpace(strings.TrimSuffix(rest, closer))
+	}
+
+	d := &ignoreDirective{patterns: make(map[string]bool)}
+	for _, name := range strings.Split(rest, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			d.patterns[name] = true
+		}
+	}
+	return d
+}
+
+// covers reports whether the directive ignores findings of pattern.
+func (d *ignoreDirective) covers(pattern string) bool {
+	if len(d.patterns) == 0 {
+		return true
+	}
+	return d.patterns[strings.ToLower(pattern)] || d.patterns[ruleID(pattern)]
+}
+
+// filterIgnored removes findings covered by any of the directives (nil
+// entries are skipped) and returns the rest with the number removed.
+func filterIgnored(findings []Finding, directives ...*ignoreDirective) ([]Finding, int) {
+	var active []*ignoreDirective
+	for _, d := range directives {
+		if d != nil {
+			active = append(active, d)
+		}
+	}
+	if len(active) == 0 || len(findings) == 0 {
+		return findings, 0
+	}
+
+	kept := findings[:0]
+	ignored := 0
+	for _, f := range findings {
+		covered := false
+		for _, d := range active {
+			if d.covers(f.PatternName) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			ignored++
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, ignored
+}
+
+func isCommentCloser(s string) bool {
+	return strings.HasPrefix(s, "*/") || strings.HasPrefix(s, "-->")
+}
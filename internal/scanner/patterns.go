@@ -1,6 +1,12 @@
 package scanner
 
-import "regexp"
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
 
 // Severity represents the severity level of a detected secret.
 type Severity int
@@ -28,6 +34,28 @@ func (s Severity) String() string {
 	}
 }
 
+// AtLeast reports whether s is at least as severe as min. Severities are
+// ordered from SeverityLow up to SeverityCritical.
+func (s Severity) AtLeast(min Severity) bool {
+	return s >= min
+}
+
+// ParseSeverity parses a severity name ("low", "medium", "high", "critical").
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return SeverityLow, nil
+	case "medium":
+		return SeverityMedium, nil
+	case "high":
+		return SeverityHigh, nil
+	case "critical":
+		return SeverityCritical, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q (must be low, medium, high, or critical)", s)
+	}
+}
+
 // Pattern represents a secret detection pattern.
 type Pattern struct {
 	Name        string
@@ -36,6 +64,66 @@ type Pattern struct {
 	Description string
 }
 
+// patternSpec is the JSON form of a Pattern in a custom patterns file.
+type patternSpec struct {
+	Name        string `json:"name"`
+	Regex       string `json:"regex"`
+	Description string `json:"description"`
+	Severity    string `json:"severity"`
+}
+
+// LoadPatterns reads custom patterns from a JSON file containing an array of
+// {name, regex, description, severity} objects. Severity defaults to medium.
+// The result can be appended to DefaultPatterns.
+func LoadPatterns(path string) ([]Pattern, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patterns file: %w", err)
+	}
+
+	var specs []patternSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse patterns file %s: %w", path, err)
+	}
+
+	patterns := make([]Pattern, 0, len(specs))
+	for i, spec := range specs {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("pattern %d in %s: name is required", i, path)
+		}
+		if spec.Regex == "" {
+			return nil, fmt.Errorf("pattern %q: regex is required", spec.Name)
+		}
+
+		re, err := regexp.Compile(spec.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: invalid regex: %w", spec.Name, err)
+		}
+
+		severity := SeverityMedium
+		if spec.Severity != "" {
+			severity, err = ParseSeverity(spec.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("pattern %q: %w", spec.Name, err)
+			}
+		}
+
+		description := spec.Description
+		if description == "" {
+			description = spec.Name
+		}
+
+		patterns = append(patterns, Pattern{
+			Name:        spec.Name,
+			Regex:       re,
+			Severity:    severity,
+			Description: description,
+		})
+	}
+
+	return patterns, nil
+}
+
 // DefaultPatterns returns the default set of secret detection patterns.
 func DefaultPatterns() []Pattern {
 	return []Pattern{
@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Report presents findings in command output. It marshals to a flat JSON
+// array with one object per finding, and prints for people grouped by file,
+// then line.
+type Report struct {
+	Findings    []Finding
+	ShowSecrets bool // Show full values instead of redacted ones
+	Verified    bool // Include the results of Verify
+}
+
+// FileFindings are the findings in one file, in line order.
+type FileFindings struct {
+	File     string
+	Findings []Finding
+}
+
+// GroupByFile groups findings by file, sorted by path, with each file's
+// findings sorted by line and column.
+func GroupByFile(findings []Finding) []FileFindings {
+	byFile := make(map[string][]Finding)
+	for _, f := range findings {
+		byFile[f.File] = append(byFile[f.File], f)
+	}
+
+	groups := make([]FileFindings, 0, len(byFile))
+	for file, fs := range byFile {
+		sort.SliceStable(fs, func(i, j int) bool {
+			if fs[i].Line != fs[j].Line {
+				return fs[i].Line < fs[j].Line
+			}
+			return fs[i].Column < fs[j].Column
+		})
+		groups = append(groups, FileFindings{File: file, Findings: fs})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].File < groups[j].File
+	})
+	return groups
+}
+
+// MarshalJSON encodes the findings as a flat array, in scan order.
+func (r Report) MarshalJSON() ([]byte, error) {
+	data := make([]map[string]interface{}, len(r.Findings))
+	for i, f := range r.Findings {
+		data[i] = map[string]interface{}{
+			"file":         f.File,
+			"line":         f.Line,
+			"column":       f.Column,
+			"pattern_name": f.PatternName,
+			"value":        r.value(f),
+			"severity":     f.Severity.String(),
+		}
+		if f.Commit != "" {
+			data[i]["commit"] = f.Commit
+			data[i]["author"] = f.Author
+		}
+		if r.Verified {
+			data[i]["verified"] = f.Verified
+			if f.Verified {
+				data[i]["active"] = f.Active
+			}
+			if f.VerifyError != "" {
+				data[i]["verify_error"] = f.VerifyError
+			}
+		}
+	}
+	return json.Marshal(data)
+}
+
+// String renders the findings for human output: a header per file with
+// its finding count, and the file's findings indented beneath it.
+func (r Report) String() string {
+	var b strings.Builder
+	for _, group := range GroupByFile(r.Findings) {
+		noun := "findings"
+		if len(group.Findings) == 1 {
+			noun = "finding"
+		}
+		fmt.Fprintf(&b, "\n    %s (%d %s)", group.File, len(group.Findings), noun)
+
+		for _, f := range group.Findings {
+			fmt.Fprintf(&b, "\n      %d:%d  %s  %s  [%s]", f.Line, f.Column, f.PatternName, r.value(f), f.Severity)
+			if f.Commit != "" {
+				fmt.Fprintf(&b, "  %s by %s", shortCommit(f.Commit), f.Author)
+			}
+			if r.Verified && f.Verified {
+				if f.Active {
+					b.WriteString("  active")
+				} else {
+					b.WriteString("  inactive")
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+func (r Report) value(f Finding) string {
+	if r.ShowSecrets {
+		return f.Value
+	}
+	return f.Redacted()
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
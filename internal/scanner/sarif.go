@@ -0,0 +1,234 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifBaseID names the root that result URIs are relative to.
+	sarifBaseID = "SRCROOT"
+)
+
+// SARIF 2.1.0 document types, limited to the properties the scanner emits.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                   `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult               `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+	Region           sarifRegion      `json:"region"`
+}
+
+type sarifArtifactLoc struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// ToSARIF serializes a scan result as a SARIF 2.1.0 log for code scanning
+// tools. Each pattern becomes a rule, and file URIs are relative to the
+// enclosing git repository (or the scan root outside a repository).
+// Secret values are not included.
+func ToSARIF(result ScanResult) ([]byte, error) {
+	root := repoRoot(result.Root)
+
+	// One rule per pattern name, in a stable order
+	ruleIndex := make(map[string]int)
+	var names []string
+	severities := make(map[string]Severity)
+	for _, f := range result.Findings {
+		if _, ok := severities[f.PatternName]; !ok {
+			names = append(names, f.PatternName)
+		}
+		severities[f.PatternName] = f.Severity
+	}
+	sort.Strings(names)
+
+	rules := make([]sarifRule, 0, len(names))
+	for i, name := range names {
+		ruleIndex[name] = i
+		rules = append(rules, sarifRule{
+			ID:                   ruleID(name),
+			Name:                 name,
+			ShortDescription:     sarifMessage{Text: name},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(severities[name])},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(result.Findings))
+	for _, f := range result.Findings {
+		line := f.Line
+		if line < 1 {
+			line = 1
+		}
+		results = append(results, sarifResult{
+			RuleID:    ruleID(f.PatternName),
+			RuleIndex: ruleIndex[f.PatternName],
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{Text: fmt.Sprintf("Possible exposed secret: %s", f.PatternName)},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLoc{
+						URI:       relativeURI(root, f.File),
+						URIBaseID: sarifBaseID,
+					},
+					Region: sarifRegion{StartLine: line, StartColumn: f.Column},
+				},
+			}},
+		})
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "agent-secrets",
+			InformationURI: "https://github.com/joelhooks/agent-secrets",
+			Rules:          rules,
+		}},
+		Results: results,
+	}
+	if root != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLoc{
+			sarifBaseID: {URI: fileURI(root)},
+		}
+	}
+
+	return json.MarshalIndent(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+}
+
+// sarifLevel maps a severity to a SARIF result level.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// ruleID converts a pattern name to a stable rule id, e.g.
+// "AWS Access Key ID" -> "aws-access-key-id".
+func ruleID(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// repoRoot returns the nearest directory at or above dir that contains a
+// .git entry, or dir itself if there is none.
+func repoRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return abs
+		}
+	}
+}
+
+// relativeURI returns path relative to root as a URI reference with forward
+// slashes, escaping characters that are not valid in a URI path.
+func relativeURI(root, path string) string {
+	rel := path
+	if root != "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			if r, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(r, "..") {
+				rel = r
+			}
+		}
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+}
+
+// fileURI returns the file:// URI of directory dir, with a trailing slash
+// as SARIF requires for base URIs.
+func fileURI(dir string) string {
+	p := filepath.ToSlash(dir)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive paths
+	}
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
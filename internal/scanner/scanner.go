@@ -2,10 +2,15 @@ package scanner
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,30 +22,70 @@ type Finding struct {
 	PatternName string
 	Value       string
 	Severity    Severity
+
+	// Set for findings from git history
+	Commit string
+	Author string
+
+	// Set by Verify
+	Verified    bool   // A live check with the provider was conclusive
+	Active      bool   // The provider accepted the credential (if Verified)
+	VerifyError string // Why a check was inconclusive
+}
+
+// Redacted returns Value reduced to its first 4 and last 2 characters,
+// e.g. "ghp_…xu", so findings can be reported without exposing the secret.
+// Values too short to redact meaningfully are masked entirely.
+func (f Finding) Redacted() string {
+	runes := []rune(f.Value)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + "…" + string(runes[len(runes)-2:])
 }
 
 // ScanResult represents the result of a scan operation.
 type ScanResult struct {
-	Findings     []Finding
-	ScannedFiles int
-	Duration     time.Duration
+	Findings        []Finding
+	ScannedFiles    int
+	ScannedCommits  int    // Commits examined by ScanGitHistory
+	Suppressed      int    // Findings hidden by the baseline
+	IgnoredFindings int    // Findings skipped by secrets:ignore comments
+	LongLines       int    // Lines skipped for exceeding the max line length
+	Root            string // Scanned directory, or the directory of the scanned file
+	Duration        time.Duration
 }
 
 // Scanner scans files for exposed secrets.
 type Scanner struct {
-	patterns  []Pattern
-	excludes  []string
-	maxSize   int64 // max file size in bytes
-	recursive bool
+	patterns    []Pattern
+	excludes    []string
+	maxSize     int64 // max file size in bytes
+	recursive   bool
+	concurrency int              // files scanned in parallel
+	entropy     *entropyDetector // nil unless entropy detection is enabled
+	baseline    string           // baseline file path, empty if none
+	maxLineLen  int              // longer lines are skipped, 0 for no limit
+	window      int              // longer lines are scanned in windows of this size
+	overlap     int              // bytes shared by consecutive windows
 }
 
+// Defaults for WithLineWindow.
+const (
+	defaultLineWindow  = 64 * 1024
+	defaultLineOverlap = 4 * 1024
+)
+
 // NewScanner creates a new Scanner with the specified patterns and exclusions.
 func NewScanner(patterns []Pattern, excludes []string) *Scanner {
 	return &Scanner{
-		patterns:  patterns,
-		excludes:  excludes,
-		maxSize:   10 * 1024 * 1024, // 10MB default
-		recursive: false,
+		patterns:    patterns,
+		excludes:    excludes,
+		maxSize:     10 * 1024 * 1024, // 10MB default
+		recursive:   false,
+		concurrency: runtime.NumCPU(),
+		window:      defaultLineWindow,
+		overlap:     defaultLineOverlap,
 	}
 }
 
@@ -50,12 +95,54 @@ func (s *Scanner) WithMaxSize(maxBytes int64) *Scanner {
 	return s
 }
 
+// WithMaxLineLength skips lines longer than n bytes, such as minified or
+// generated code, where matching every pattern is slow and findings are
+// rarely useful. Skipped lines are counted in ScanResult.LongLines. Zero, the
+// default, scans every line.
+func (s *Scanner) WithMaxLineLength(n int) *Scanner {
+	s.maxLineLen = n
+	return s
+}
+
+// WithLineWindow bounds the memory used per line: lines longer than size
+// bytes, such as minified JS or JSON blobs, are scanned in windows of size
+// bytes, each overlapping the previous one by overlap bytes, so matches up to
+// overlap bytes long are found across window boundaries. Sizes below 1 keep
+// the default of 64KB; the overlap is capped at half the size.
+func (s *Scanner) WithLineWindow(size, overlap int) *Scanner {
+	if size < 1 {
+		size = defaultLineWindow
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap > size/2 {
+		overlap = size / 2
+	}
+	s.window, s.overlap = size, overlap
+	return s
+}
+
 // WithRecursive enables or disables recursive scanning.
 func (s *Scanner) WithRecursive(recursive bool) *Scanner {
 	s.recursive = recursive
 	return s
 }
 
+// WithConcurrency sets how many files are scanned in parallel when scanning
+// a directory. Values below 1 use runtime.NumCPU().
+func (s *Scanner) WithConcurrency(n int) *Scanner {
+	s.concurrency = n
+	return s
+}
+
+// WithBaseline suppresses findings recorded in the baseline file at path.
+// A missing baseline file suppresses nothing.
+func (s *Scanner) WithBaseline(path string) *Scanner {
+	s.baseline = path
+	return s
+}
+
 // Scan scans the specified path (file or directory) for secrets.
 func (s *Scanner) Scan(path string) (ScanResult, error) {
 	start := time.Now()
@@ -63,33 +150,120 @@ func (s *Scanner) Scan(path string) (ScanResult, error) {
 		Findings: []Finding{},
 	}
 
+	baseline, err := s.loadBaseline()
+	if err != nil {
+		return result, err
+	}
+
 	info, err := os.Stat(path)
 	if err != nil {
 		return result, fmt.Errorf("failed to stat path: %w", err)
 	}
 
+	result.Root = path
+	if !info.IsDir() {
+		result.Root = filepath.Dir(path)
+	}
+
 	if info.IsDir() {
 		if err := s.scanDirectory(path, &result); err != nil {
 			return result, err
 		}
 	} else {
-		findings, err := s.ScanFile(path)
+		findings, skipped, err := s.scanFile(path)
 		if err != nil {
 			return result, err
 		}
 		result.Findings = append(result.Findings, findings...)
+		result.addSkipped(skipped)
 		result.ScannedFiles = 1
 	}
 
+	if baseline != nil {
+		result.Findings, result.Suppressed = baseline.Filter(result.Findings)
+	}
+
 	result.Duration = time.Since(start)
 	return result, nil
 }
 
-// scanDirectory scans all files in a directory.
+// loadBaseline loads the configured baseline, or returns nil if none is set.
+func (s *Scanner) loadBaseline() (*Baseline, error) {
+	if s.baseline == "" {
+		return nil, nil
+	}
+	return LoadBaseline(s.baseline)
+}
+
+// scanDirectory scans all files in a directory, fanning out across the
+// scanner's worker pool. Findings are sorted by file, then line.
 func (s *Scanner) scanDirectory(dirPath string, result *ScanResult) error {
+	paths, err := s.collectFiles(dirPath, nil)
+	if err != nil {
+		return err
+	}
+
+	s.scanFiles(paths, result)
+	return nil
+}
+
+// scanFiles scans paths across the scanner's worker pool, adding to result.
+// Unreadable files are skipped. Findings are sorted by file, then line.
+func (s *Scanner) scanFiles(paths []string, result *ScanResult) {
+	workers := s.concurrency
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				findings, skipped, err := s.scanFile(path)
+				if err != nil {
+					// Skip unreadable files but continue scanning
+					continue
+				}
+
+				mu.Lock()
+				result.Findings = append(result.Findings, findings...)
+				result.addSkipped(skipped)
+				result.ScannedFiles++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Each file's findings were appended together in line order, so a stable
+	// sort keeps same-line findings in pattern order
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		fi, fj := result.Findings[i], result.Findings[j]
+		if fi.File != fj.File {
+			return fi.File < fj.File
+		}
+		return fi.Line < fj.Line
+	})
+}
+
+// collectFiles appends the paths of files to scan under dirPath to paths,
+// skipping excluded entries and descending only if the scanner is recursive.
+func (s *Scanner) collectFiles(dirPath string, paths []string) ([]string, error) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
-		return fmt.Errorf("failed to read directory: %w", err)
+		return nil, fmt.Errorf("failed to read directory: %w", err)
 	}
 
 	for _, entry := range entries {
@@ -102,85 +276,232 @@ func (s *Scanner) scanDirectory(dirPath string, result *ScanResult) error {
 
 		if entry.IsDir() {
 			if s.recursive {
-				if err := s.scanDirectory(fullPath, result); err != nil {
-					return err
+				if paths, err = s.collectFiles(fullPath, paths); err != nil {
+					return nil, err
 				}
 			}
 			continue
 		}
 
-		// Scan file
-		findings, err := s.ScanFile(fullPath)
-		if err != nil {
-			// Log error but continue scanning
-			continue
-		}
-
-		result.Findings = append(result.Findings, findings...)
-		result.ScannedFiles++
+		paths = append(paths, fullPath)
 	}
 
-	return nil
+	return paths, nil
+}
+
+// skipCounts tallies what a scan of one file or diff left out.
+type skipCounts struct {
+	ignored   int // matches skipped by secrets:ignore comments
+	longLines int // lines over the max line length
+}
+
+// addSkipped adds the counts from one file to the result.
+func (r *ScanResult) addSkipped(c skipCounts) {
+	r.IgnoredFindings += c.ignored
+	r.LongLines += c.longLines
 }
 
-// ScanFile scans a single file for secrets.
+// ScanFile scans a single file for secrets. Matches on a line carrying a
+// secrets:ignore comment, or directly below one, are skipped, as are lines
+// over the max line length. Lines of any length are scanned without reading
+// them whole; see WithLineWindow.
 func (s *Scanner) ScanFile(filePath string) ([]Finding, error) {
+	findings, _, err := s.scanFile(filePath)
+	return findings, err
+}
+
+// ScanReader scans text read from r as if it were the file at name, in the
+// same way as ScanFile.
+func (s *Scanner) ScanReader(r io.Reader, name string) ([]Finding, error) {
+	findings, _, err := s.scanReader(r, name)
+	return findings, err
+}
+
+// scanFile implements ScanFile, also returning what was skipped.
+func (s *Scanner) scanFile(filePath string) ([]Finding, skipCounts, error) {
 	// Check file size
 	info, err := os.Stat(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+		return nil, skipCounts{}, fmt.Errorf("failed to stat file: %w", err)
 	}
 
 	if info.Size() > s.maxSize {
-		return nil, fmt.Errorf("file too large: %d bytes (max: %d)", info.Size(), s.maxSize)
+		return nil, skipCounts{}, fmt.Errorf("file too large: %d bytes (max: %d)", info.Size(), s.maxSize)
 	}
 
 	// Skip binary files
 	if isBinaryFile(filePath) {
-		return nil, nil
+		return nil, skipCounts{}, nil
 	}
 
 	// Open file
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, skipCounts{}, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer file.Close()
 
+	return s.scanReader(file, filePath)
+}
+
+// scanReader scans r line by line, reporting findings against filePath.
+// Long lines are read and scanned a window at a time (see WithLineWindow).
+func (s *Scanner) scanReader(r io.Reader, filePath string) ([]Finding, skipCounts, error) {
 	var findings []Finding
-	scanner := bufio.NewScanner(file)
+	var skipped skipCounts
+	reader := bufio.NewReaderSize(r, s.window)
 	lineNum := 0
+	var above *ignoreDirective // directive on the previous line
 
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
+	for {
+		var lineFindings []Finding
+		var current *ignoreDirective
+		lastEnd := make(map[string]int) // end of the last finding per pattern
+		tooLong := false
 
-		// Check each pattern
-		for _, pattern := range s.patterns {
-			matches := pattern.Regex.FindAllStringIndex(line, -1)
-			if matches == nil {
-				continue
+		length, err := s.readLine(reader, func(offset int, window string, last bool) {
+			if tooLong || s.lineTooLong(offset+len(window)) {
+				tooLong = true
+				return
 			}
-
-			for _, match := range matches {
-				value := line[match[0]:match[1]]
-				findings = append(findings, Finding{
-					File:        filePath,
-					Line:        lineNum,
-					Column:      match[0] + 1, // 1-indexed
-					PatternName: pattern.Name,
-					Value:       value,
-					Severity:    pattern.Severity,
-				})
+			if current == nil {
+				current = parseIgnore(window)
 			}
+			for _, f := range s.scanLine(filePath, lineNum+1, window) {
+				start := f.Column - 1
+				// Matches starting in the overlap are left to the next
+				// window, which sees more of them
+				if !last && start >= len(window)-s.overlap {
+					continue
+				}
+				// Skip matches already found in the previous window
+				if offset+start < lastEnd[f.PatternName] {
+					continue
+				}
+				f.Column += offset
+				lastEnd[f.PatternName] = offset + start + len(f.Value)
+				lineFindings = append(lineFindings, f)
+			}
+		})
+		if err == io.EOF {
+			break
 		}
+		if err != nil {
+			return nil, skipCounts{}, fmt.Errorf("failed to scan file: %w", err)
+		}
+		lineNum++
+
+		if tooLong || s.lineTooLong(length) {
+			skipped.longLines++
+			above = nil
+			continue
+		}
+
+		lineFindings, n := filterIgnored(lineFindings, current, above)
+		findings = append(findings, lineFindings...)
+		skipped.ignored += n
+		above = current
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to scan file: %w", err)
+	return findings, skipped, nil
+}
+
+// readLine reads the next line from r, without its line ending, and passes
+// it to fn: whole if it is at most s.window bytes, otherwise in windows of
+// s.window bytes that overlap by s.overlap, each with its offset in the line
+// and whether it is the last. It returns the length of the line, or io.EOF
+// if there are no more lines.
+func (s *Scanner) readLine(r *bufio.Reader, fn func(offset int, window string, last bool)) (int, error) {
+	var buf []byte
+	offset := 0
+	read := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(chunk) > 0 {
+			read = true
+		}
+		buf = append(buf, chunk...)
+
+		// Hold back the line ending and one byte so the last window is
+		// never empty
+		for len(buf) > s.window+2 {
+			fn(offset, string(buf[:s.window]), false)
+			step := s.window - s.overlap
+			buf = append(buf[:0], buf[step:]...)
+			offset += step
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if err == io.EOF && !read {
+			return 0, io.EOF
+		}
+
+		buf = bytes.TrimSuffix(buf, []byte("\n"))
+		buf = bytes.TrimSuffix(buf, []byte("\r"))
+		if len(buf) > s.window {
+			fn(offset, string(buf[:s.window]), false)
+			step := s.window - s.overlap
+			buf = buf[step:]
+			offset += step
+		}
+		fn(offset, string(buf), true)
+		return offset + len(buf), nil
+	}
+}
+
+// lineTooLong reports whether a line of length bytes exceeds the max line
+// length.
+func (s *Scanner) lineTooLong(length int) bool {
+	return s.maxLineLen > 0 && length > s.maxLineLen
+}
+
+// scanLine checks one line of a file against the patterns and, if enabled,
+// for high-entropy tokens.
+func (s *Scanner) scanLine(filePath string, lineNum int, line string) []Finding {
+	var findings []Finding
+
+	// Check each pattern
+	var matched [][]int
+	for _, pattern := range s.patterns {
+		matches := pattern.Regex.FindAllStringIndex(line, -1)
+		if matches == nil {
+			continue
+		}
+		matched = append(matched, matches...)
+
+		for _, match := range matches {
+			value := line[match[0]:match[1]]
+			findings = append(findings, Finding{
+				File:        filePath,
+				Line:        lineNum,
+				Column:      match[0] + 1, // 1-indexed
+				PatternName: pattern.Name,
+				Value:       value,
+				Severity:    pattern.Severity,
+			})
+		}
+	}
+
+	// Check remaining tokens for high entropy
+	if s.entropy != nil {
+		for _, loc := range s.entropy.scanLine(line, matched) {
+			findings = append(findings, Finding{
+				File:        filePath,
+				Line:        lineNum,
+				Column:      loc[0] + 1, // 1-indexed
+				PatternName: EntropyPatternName,
+				Value:       line[loc[0]:loc[1]],
+				Severity:    s.entropy.severity,
+			})
+		}
 	}
 
-	return findings, nil
+	return findings
 }
 
 // isExcluded checks if a path matches any exclusion pattern.
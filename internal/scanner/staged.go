@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ScanStaged scans the files staged for commit in the git repository
+// containing repoPath, as they are in the working tree. Staged deletions are
+// skipped, and excludes apply to paths relative to the repository root.
+func (s *Scanner) ScanStaged(repoPath string) (ScanResult, error) {
+	start := time.Now()
+	result := ScanResult{
+		Findings: []Finding{},
+		Root:     repoPath,
+	}
+
+	baseline, err := s.loadBaseline()
+	if err != nil {
+		return result, err
+	}
+
+	root, staged, err := stagedFiles(repoPath)
+	if err != nil {
+		return result, err
+	}
+	result.Root = root
+
+	var paths []string
+	for _, name := range staged {
+		if !s.isExcluded(name) {
+			paths = append(paths, filepath.Join(root, name))
+		}
+	}
+	s.scanFiles(paths, &result)
+
+	if baseline != nil {
+		result.Findings, result.Suppressed = baseline.Filter(result.Findings)
+	}
+
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// stagedFiles returns the root of the git repository containing repoPath and
+// the paths, relative to it, of files added, copied, modified or renamed in
+// the index.
+func stagedFiles(repoPath string) (string, []string, error) {
+	top, err := runGit(repoPath, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find repository root: %w", err)
+	}
+	root := strings.TrimSpace(string(top))
+
+	// NUL-terminated output needs no unquoting of unusual file names
+	out, err := runGit(repoPath, "diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+
+	var names []string
+	for _, name := range bytes.Split(out, []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, filepath.FromSlash(string(name)))
+		}
+	}
+	return root, names, nil
+}
@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestScanStaged_OnlyStagedFiles(t *testing.T) {
+	dir := gitRepo(t)
+
+	// Committed earlier, not part of the next commit
+	writeScanFile(t, filepath.Join(dir, "old.env"), "OLD="+fixtureToken+"\n")
+	writeScanFile(t, filepath.Join(dir, "gone.env"), "A=1\n")
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", "initial")
+
+	// Staged: a new file, a modified file in a subdirectory, and a deletion
+	writeScanFile(t, filepath.Join(dir, "new.env"), "NEW="+newToken+"\n")
+	writeScanFile(t, filepath.Join(dir, "sub dir", "keys.txt"), "AWS="+fixtureAWSKey+"\n")
+	writeScanFile(t, filepath.Join(dir, "vendor", "lib.env"), "LIB="+newToken+"\n")
+	git(t, dir, "add", "new.env", "sub dir/keys.txt", "vendor/lib.env")
+	git(t, dir, "rm", "-q", "gone.env")
+
+	// Untracked and unstaged changes are not scanned
+	writeScanFile(t, filepath.Join(dir, "untracked.env"), "U="+fixtureToken+"\n")
+	writeScanFile(t, filepath.Join(dir, "old.env"), "OLD="+fixtureToken+"\nMORE="+newToken+"\n")
+
+	s := NewScanner(DefaultPatterns(), []string{"vendor"})
+	result, err := s.ScanStaged(dir)
+	if err != nil {
+		t.Fatalf("ScanStaged: %v", err)
+	}
+
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Root != root {
+		t.Errorf("Root = %q, want %q", result.Root, root)
+	}
+	if result.ScannedFiles != 2 {
+		t.Errorf("ScannedFiles = %d, want 2", result.ScannedFiles)
+	}
+
+	var files []string
+	for _, f := range result.Findings {
+		files = append(files, f.File)
+	}
+	sort.Strings(files)
+	want := []string{filepath.Join(root, "new.env"), filepath.Join(root, "sub dir", "keys.txt")}
+	if len(files) != len(want) || files[0] != want[0] || files[1] != want[1] {
+		t.Errorf("findings in %v, want %v", files, want)
+	}
+}
+
+func TestScanStaged_NothingStaged(t *testing.T) {
+	dir := gitRepo(t)
+	writeScanFile(t, filepath.Join(dir, "untracked.env"), "U="+fixtureToken+"\n")
+
+	result, err := NewScanner(DefaultPatterns(), nil).ScanStaged(dir)
+	if err != nil {
+		t.Fatalf("ScanStaged: %v", err)
+	}
+	if result.ScannedFiles != 0 || len(result.Findings) != 0 {
+		t.Errorf("expected nothing scanned, got %d files, %d findings", result.ScannedFiles, len(result.Findings))
+	}
+}
+
+func TestScanStaged_NotARepository(t *testing.T) {
+	if _, err := NewScanner(DefaultPatterns(), nil).ScanStaged(t.TempDir()); err == nil {
+		t.Error("expected error outside a git repository")
+	}
+}
@@ -0,0 +1,250 @@
+package scanner
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitHubAPI   = "https://api.github.com"
+	defaultSTSEndpoint = "https://sts.amazonaws.com/"
+
+	// stsRegion is the signing region of the global STS endpoint.
+	stsRegion = "us-east-1"
+
+	verifyTimeout = 10 * time.Second
+
+	awsSecretKeyLen = 40
+)
+
+// githubVerifiable are the GitHub token patterns accepted by GET /user.
+var githubVerifiable = map[string]bool{
+	"GitHub Personal Access Token": true,
+	"GitHub OAuth Token":           true,
+	"GitHub User-to-Server Token":  true,
+}
+
+// Verifier checks whether credentials in findings are still accepted by
+// their provider.
+type Verifier struct {
+	client      *http.Client
+	githubAPI   string
+	stsEndpoint string
+}
+
+// NewVerifier creates a Verifier that calls the public GitHub and AWS APIs.
+func NewVerifier() *Verifier {
+	return &Verifier{
+		client:      &http.Client{Timeout: verifyTimeout},
+		githubAPI:   defaultGitHubAPI,
+		stsEndpoint: defaultSTSEndpoint,
+	}
+}
+
+// Verify checks findings against their providers using NewVerifier.
+func Verify(findings []Finding) []Finding {
+	return NewVerifier().Verify(findings)
+}
+
+// Verify returns findings with Verified and Active set for credentials that
+// have a known check: GitHub tokens are tried against GET /user and AWS keys
+// against STS GetCallerIdentity (using an AWS secret key found in the same
+// file). Findings without a check, or whose check was inconclusive, have
+// Verified false; VerifyError explains failed checks. Each distinct value is
+// checked once. Secret values are never included in errors.
+func (v *Verifier) Verify(findings []Finding) []Finding {
+	type outcome struct {
+		active bool
+		err    error
+	}
+	checked := make(map[string]outcome)
+
+	out := make([]Finding, len(findings))
+	for i, f := range findings {
+		out[i] = f
+
+		var check func() (bool, error)
+		key := f.PatternName + "\x00" + f.Value
+		switch {
+		case githubVerifiable[f.PatternName]:
+			check = func() (bool, error) { return v.checkGitHub(f.Value) }
+		case f.PatternName == "AWS Access Key ID":
+			secret, ok := awsSecretFor(f, findings)
+			if !ok {
+				out[i].VerifyError = "no AWS secret access key found in the same file"
+				continue
+			}
+			key += "\x00" + secret
+			check = func() (bool, error) { return v.checkAWS(f.Value, secret) }
+		default:
+			continue
+		}
+
+		result, ok := checked[key]
+		if !ok {
+			result.active, result.err = check()
+			checked[key] = result
+		}
+
+		if result.err != nil {
+			out[i].VerifyError = result.err.Error()
+			continue
+		}
+		out[i].Verified = true
+		out[i].Active = result.active
+	}
+
+	return out
+}
+
+// checkGitHub reports whether GitHub accepts token.
+func (v *Verifier) checkGitHub(token string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(v.githubAPI, "/")+"/user", nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "agent-secrets")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("github request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusUnauthorized:
+		return false, nil
+	default:
+		return false, fmt.Errorf("github returned unexpected status %d", resp.StatusCode)
+	}
+}
+
+// checkAWS reports whether AWS accepts the access key pair, by calling STS
+// GetCallerIdentity, which requires no permissions.
+func (v *Verifier) checkAWS(accessKeyID, secretKey string) (bool, error) {
+	body := "Action=GetCallerIdentity&Version=2011-06-15"
+	req, err := http.NewRequest(http.MethodPost, v.stsEndpoint, strings.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSv4(req, body, accessKeyID, secretKey, stsRegion, "sts", time.Now())
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("sts request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return true, nil
+	case resp.StatusCode == http.StatusForbidden &&
+		(strings.Contains(string(data), "InvalidClientTokenId") || strings.Contains(string(data), "SignatureDoesNotMatch")):
+		return false, nil
+	default:
+		return false, fmt.Errorf("sts returned unexpected status %d", resp.StatusCode)
+	}
+}
+
+// awsSecretFor finds the AWS secret key closest to an access key finding in
+// the same file.
+func awsSecretFor(key Finding, findings []Finding) (string, bool) {
+	best, bestDist := "", -1
+	for _, f := range findings {
+		if f.File != key.File || f.PatternName != "AWS Secret Access Key" {
+			continue
+		}
+		// The match includes the variable name; the key is the last 40
+		// characters before any closing quote
+		value := strings.TrimRight(f.Value, `'"`)
+		if len(value) < awsSecretKeyLen {
+			continue
+		}
+		secret := value[len(value)-awsSecretKeyLen:]
+
+		dist := f.Line - key.Line
+		if dist < 0 {
+			dist = -dist
+		}
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = secret, dist
+		}
+	}
+	return best, bestDist >= 0
+}
+
+// signAWSv4 adds AWS Signature Version 4 headers to req.
+func signAWSv4(req *http.Request, body, accessKeyID, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	payloadHash := sha256Hex(body)
+	host := req.URL.Host
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	signedHeaders := "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL),
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + host,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// canonicalQuery returns the query string sorted and encoded for signing.
+func canonicalQuery(u *url.URL) string {
+	return strings.ReplaceAll(u.Query().Encode(), "+", "%20")
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
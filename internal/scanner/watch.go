@@ -0,0 +1,277 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long a watched tree must be quiet before changed
+// files are rescanned, so that a burst of saves triggers a single scan.
+const DefaultWatchDebounce = 300 * time.Millisecond
+
+// WatchEvent reports new findings in a changed file.
+type WatchEvent struct {
+	File     string
+	Findings []Finding // Findings not present when the file was last scanned
+}
+
+// Watcher rescans files under a root as they change, reporting only findings
+// that were not already present in the file.
+type Watcher struct {
+	scanner  *Scanner
+	root     string
+	debounce time.Duration
+	fsw      *fsnotify.Watcher
+	baseline *Baseline
+
+	// known counts the findings last reported per file, keyed by pattern
+	// and value so that secrets moving between lines are not reported again
+	known map[string]map[findingKey]int
+}
+
+// findingKey identifies a secret within a file independent of its position.
+type findingKey struct {
+	pattern, value string
+}
+
+// Watch scans root and returns a Watcher that reports new findings as files
+// under it change, along with the result of the initial scan. Directories are
+// watched recursively if the scanner is recursive, and excluded paths are
+// skipped. Call Run to process changes.
+func (s *Scanner) Watch(root string, debounce time.Duration) (*Watcher, ScanResult, error) {
+	result, err := s.Scan(root)
+	if err != nil {
+		return nil, result, err
+	}
+
+	baseline, err := s.loadBaseline()
+	if err != nil {
+		return nil, result, err
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, result, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	w := &Watcher{
+		scanner:  s,
+		root:     root,
+		debounce: debounce,
+		fsw:      fsw,
+		baseline: baseline,
+		known:    make(map[string]map[findingKey]int),
+	}
+	for _, f := range result.Findings {
+		w.remember(f.File, []Finding{f})
+	}
+
+	if err := w.add(root); err != nil {
+		fsw.Close()
+		return nil, result, err
+	}
+
+	return w, result, nil
+}
+
+// Run processes file changes until ctx is cancelled, calling report for each
+// changed file with new findings. The watcher is closed when Run returns.
+func (w *Watcher) Run(ctx context.Context, report func(WatchEvent)) error {
+	defer w.fsw.Close()
+
+	pending := make(map[string]bool)
+	quiet := time.NewTimer(w.debounce)
+	quiet.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if w.scanner.isExcluded(event.Name) {
+				continue
+			}
+			// Editors that save atomically remove or rename the file and create
+			// it again; every event just marks the path for a rescan
+			pending[event.Name] = true
+			quiet.Reset(w.debounce)
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watch failed: %w", err)
+
+		case <-quiet.C:
+			for path := range pending {
+				for _, event := range w.rescan(path) {
+					report(event)
+				}
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// Close stops watching. It is only needed if Run is never called.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+// add watches dir and, for recursive scanners, its subdirectories.
+func (w *Watcher) add(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat path: %w", err)
+	}
+	if !info.IsDir() {
+		// Watch the parent so atomic saves of the file are seen
+		return w.fsw.Add(filepath.Dir(dir))
+	}
+
+	if err := w.fsw.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	if !w.scanner.recursive {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() && !w.scanner.isExcluded(path) {
+			if err := w.add(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rescan scans a changed path and returns events for files with new
+// findings. New directories are added to the watch and their files scanned,
+// since files created before the watch was added raise no events.
+func (w *Watcher) rescan(path string) []WatchEvent {
+	info, err := os.Stat(path)
+	if err != nil {
+		// Removed (or renamed away); forget it so a re-created file with the
+		// same secret is reported again
+		delete(w.known, path)
+		return nil
+	}
+
+	if !w.inScope(path) {
+		return nil
+	}
+
+	if !info.IsDir() {
+		if event, ok := w.rescanFile(path); ok {
+			return []WatchEvent{event}
+		}
+		return nil
+	}
+
+	if !w.scanner.recursive || path == w.root {
+		return nil
+	}
+	if err := w.add(path); err != nil {
+		return nil
+	}
+
+	var events []WatchEvent
+	_ = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if w.scanner.isExcluded(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			if event, ok := w.rescanFile(p); ok {
+				events = append(events, event)
+			}
+		}
+		return nil
+	})
+	return events
+}
+
+// rescanFile scans one file and returns an event if it has new findings.
+func (w *Watcher) rescanFile(path string) (WatchEvent, bool) {
+	findings, _, err := w.scanner.scanFile(path)
+	if err != nil {
+		return WatchEvent{}, false
+	}
+	if w.baseline != nil {
+		findings, _ = w.baseline.Filter(findings)
+	}
+
+	fresh := w.diff(path, findings)
+	delete(w.known, path)
+	w.remember(path, findings)
+
+	if len(fresh) == 0 {
+		return WatchEvent{}, false
+	}
+	return WatchEvent{File: path, Findings: fresh}, true
+}
+
+// inScope reports whether path is the watched file, or a file in the watched
+// directory (or below it when recursive).
+func (w *Watcher) inScope(path string) bool {
+	info, err := os.Stat(w.root)
+	if err == nil && !info.IsDir() {
+		return path == w.root
+	}
+	if !w.scanner.recursive {
+		return filepath.Dir(path) == w.root
+	}
+	return true
+}
+
+// diff returns the findings in path beyond those already known.
+func (w *Watcher) diff(path string, findings []Finding) []Finding {
+	seen := make(map[findingKey]int)
+	for k, n := range w.known[path] {
+		seen[k] = n
+	}
+
+	var fresh []Finding
+	for _, f := range findings {
+		key := findingKey{f.PatternName, f.Value}
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		fresh = append(fresh, f)
+	}
+	return fresh
+}
+
+// remember adds findings to the known set for path.
+func (w *Watcher) remember(path string, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	if w.known[path] == nil {
+		w.known[path] = make(map[findingKey]int)
+	}
+	for _, f := range findings {
+		w.known[path][findingKey{f.PatternName, f.Value}]++
+	}
+}
@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testDebounce = 50 * time.Millisecond
+
+// startWatch watches dir and returns a channel of reported events.
+func startWatch(t *testing.T, s *Scanner, dir string) (ScanResult, <-chan WatchEvent) {
+	t.Helper()
+
+	w, result, err := s.Watch(dir, testDebounce)
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan WatchEvent, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := w.Run(ctx, func(e WatchEvent) { events <- e }); err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	return result, events
+}
+
+func nextEvent(t *testing.T, events <-chan WatchEvent) WatchEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for watch event")
+		return WatchEvent{}
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan WatchEvent) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event for %s: %+v", e.File, e.Findings)
+	case <-time.After(10 * testDebounce):
+	}
+}
+
+func TestWatch_NewFileTriggersFinding(t *testing.T) {
+	dir := t.TempDir()
+	writeScanFile(t, filepath.Join(dir, "existing.env"), "A="+fixtureToken+"\n")
+
+	result, events := startWatch(t, NewScanner(DefaultPatterns(), nil).WithRecursive(true), dir)
+	if countPattern(result.Findings, "GitHub Personal Access Token") != 1 {
+		t.Fatalf("initial scan findings = %+v", result.Findings)
+	}
+
+	path := filepath.Join(dir, "config.env")
+	writeScanFile(t, path, "GH="+newToken+"\n")
+
+	e := nextEvent(t, events)
+	if e.File != path {
+		t.Errorf("event file = %q, want %q", e.File, path)
+	}
+	if len(e.Findings) != 1 || e.Findings[0].Value != newToken {
+		t.Errorf("event findings = %+v", e.Findings)
+	}
+
+	// Saving again without a new secret reports nothing
+	writeScanFile(t, path, "# edited\nGH="+newToken+"\n")
+	expectNoEvent(t, events)
+}
+
+func TestWatch_IgnoredFilesDoNotTrigger(t *testing.T) {
+	dir := t.TempDir()
+	writeScanFile(t, filepath.Join(dir, "node_modules", "pkg", "index.js"), "module.exports = 1\n")
+	writeScanFile(t, filepath.Join(dir, "fixture.env"), "A=1\n")
+
+	s := NewScanner(DefaultPatterns(), []string{"node_modules"}).WithRecursive(true)
+	_, events := startWatch(t, s, dir)
+
+	// Excluded path
+	writeScanFile(t, filepath.Join(dir, "node_modules", "pkg", "index.js"), "const t = \""+fixtureToken+"\"\n")
+	// Ignore comment
+	writeScanFile(t, filepath.Join(dir, "fixture.env"), "A="+fixtureToken+" # secrets:ignore\n")
+	expectNoEvent(t, events)
+
+	// A real change afterwards is still reported
+	path := filepath.Join(dir, "real.env")
+	writeScanFile(t, path, "GH="+newToken+"\n")
+	if e := nextEvent(t, events); e.File != path {
+		t.Errorf("event file = %q, want %q", e.File, path)
+	}
+}
+
+func TestWatch_AtomicRenameSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settings.env")
+	writeScanFile(t, path, "A=1\n")
+
+	_, events := startWatch(t, NewScanner(DefaultPatterns(), nil).WithRecursive(true), dir)
+
+	// Editors write a temporary file and rename it over the original
+	tmp := filepath.Join(dir, ".settings.env.swp")
+	writeScanFile(t, tmp, "A=1\nGH="+fixtureToken+"\n")
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+
+	e := nextEvent(t, events)
+	if e.File != path || len(e.Findings) != 1 || e.Findings[0].Line != 2 {
+		t.Errorf("event = %+v, want one finding on line 2 of %s", e, path)
+	}
+
+	// Delete and re-create with the same secret: a new file, reported again
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * testDebounce)
+	writeScanFile(t, path, "GH="+fixtureToken+"\n")
+	if e := nextEvent(t, events); e.File != path {
+		t.Errorf("event file = %q, want %q", e.File, path)
+	}
+}
+
+func TestWatch_NewDirectory(t *testing.T) {
+	dir := t.TempDir()
+	_, events := startWatch(t, NewScanner(DefaultPatterns(), nil).WithRecursive(true), dir)
+
+	// Files created along with their directory are still found
+	path := filepath.Join(dir, "a", "b", "keys.env")
+	writeScanFile(t, path, "GH="+fixtureToken+"\n")
+
+	if e := nextEvent(t, events); e.File != path {
+		t.Errorf("event file = %q, want %q", e.File, path)
+	}
+}
@@ -0,0 +1,232 @@
+// Package service installs the daemon as a per-user service that starts at
+// login: a systemd user unit on Linux or a launchd agent on macOS.
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the service name without a profile.
+const DefaultName = "agent-secrets"
+
+// launchdLabelPrefix namespaces launchd labels, which must be unique
+const launchdLabelPrefix = "com.joelhooks."
+
+// ErrExists is returned by Install when the unit file exists and force is
+// not set.
+var ErrExists = errors.New("service unit already exists")
+
+// Unit describes the daemon process a service runs.
+type Unit struct {
+	// Name identifies the service, e.g. agent-secrets or agent-secrets-work
+	Name string
+	// Executable is the absolute path of the secrets binary
+	Executable string
+	// Args follow the executable, e.g. ["serve"]
+	Args []string
+	// Env is set in the daemon's environment
+	Env map[string]string
+	// SocketPath is the socket the daemon listens on, noted in a comment
+	SocketPath string
+	// LogFile receives launchd's stdout and stderr; systemd uses the journal
+	LogFile string
+}
+
+// Path returns where the unit file for name lives on goos.
+func Path(goos, name string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+
+	switch goos {
+	case "linux":
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			configDir = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configDir, "systemd", "user", name+".service"), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", launchdLabelPrefix+name+".plist"), nil
+	default:
+		return "", fmt.Errorf("unsupported platform %q: services are supported on linux (systemd) and darwin (launchd)", goos)
+	}
+}
+
+// Render returns the unit file contents for goos.
+func Render(goos string, u Unit) (string, error) {
+	switch goos {
+	case "linux":
+		return SystemdUnit(u), nil
+	case "darwin":
+		return LaunchdPlist(u), nil
+	default:
+		return "", fmt.Errorf("unsupported platform %q: services are supported on linux (systemd) and darwin (launchd)", goos)
+	}
+}
+
+// SystemdUnit renders u as a systemd user unit.
+func SystemdUnit(u Unit) string {
+	var b strings.Builder
+	if u.SocketPath != "" {
+		fmt.Fprintf(&b, "# Socket: %s\n", u.SocketPath)
+	}
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=agent-secrets daemon\n")
+	b.WriteString("\n[Service]\n")
+	b.WriteString("Type=simple\n")
+	execStart := []string{systemdQuote(u.Executable)}
+	for _, arg := range u.Args {
+		execStart = append(execStart, systemdQuote(arg))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(execStart, " "))
+	for _, key := range sortedKeys(u.Env) {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(key+"="+u.Env[key]))
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	b.WriteString("\n[Install]\n")
+	b.WriteString("WantedBy=default.target\n")
+	return b.String()
+}
+
+// systemdQuote double-quotes s if systemd would otherwise split or
+// interpret it.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$%;") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, `%`, `%%`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// LaunchdPlist renders u as a launchd agent property list.
+func LaunchdPlist(u Unit) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	plistKey(&b, "Label", launchdLabelPrefix+u.Name)
+
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{u.Executable}, u.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+
+	if len(u.Env) > 0 {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		for _, key := range sortedKeys(u.Env) {
+			fmt.Fprintf(&b, "\t\t<key>%s</key>\n\t\t<string>%s</string>\n", xmlEscape(key), xmlEscape(u.Env[key]))
+		}
+		b.WriteString("\t</dict>\n")
+	}
+
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	if u.LogFile != "" {
+		plistKey(&b, "StandardOutPath", u.LogFile)
+		plistKey(&b, "StandardErrorPath", u.LogFile)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// plistKey writes a string-valued key.
+func plistKey(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "\t<key>%s</key>\n\t<string>%s</string>\n", key, xmlEscape(value))
+}
+
+// xmlEscape escapes s for use as XML character data.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// sortedKeys returns the keys of m in order, for stable output.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Install writes the unit file for u and returns its path. An existing
+// file is only replaced with force.
+func Install(goos string, u Unit, force bool) (string, error) {
+	path, err := Path(goos, u.Name)
+	if err != nil {
+		return "", err
+	}
+	contents, err := Render(goos, u)
+	if err != nil {
+		return "", err
+	}
+
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return path, ErrExists
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		return "", fmt.Errorf("write unit file: %w", err)
+	}
+	return path, nil
+}
+
+// Uninstall removes the unit file for name and returns its path. It
+// reports whether there was one to remove.
+func Uninstall(goos, name string) (string, bool, error) {
+	path, err := Path(goos, name)
+	if err != nil {
+		return "", false, err
+	}
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return path, false, nil
+		}
+		return path, false, fmt.Errorf("remove unit file: %w", err)
+	}
+	return path, true, nil
+}
+
+// EnableCommands returns the commands that load and start an installed
+// unit.
+func EnableCommands(goos, name, path string) []string {
+	switch goos {
+	case "linux":
+		return []string{
+			"systemctl --user daemon-reload",
+			"systemctl --user enable --now " + name + ".service",
+		}
+	case "darwin":
+		return []string{"launchctl load -w " + path}
+	default:
+		return nil
+	}
+}
+
+// DisableCommands returns the commands that stop a unit before Uninstall.
+func DisableCommands(goos, name, path string) []string {
+	switch goos {
+	case "linux":
+		return []string{"systemctl --user disable --now " + name + ".service"}
+	case "darwin":
+		return []string{"launchctl unload -w " + path}
+	default:
+		return nil
+	}
+}
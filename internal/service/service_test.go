@@ -0,0 +1,182 @@
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testUnit() Unit {
+	return Unit{
+		Name:       DefaultName,
+		Executable: "/usr/local/bin/secrets",
+		Args:       []string{"serve"},
+		Env:        map[string]string{"AGENT_SECRETS_SOCKET": "/home/me/.agent-secrets/agent-secrets.sock"},
+		SocketPath: "/home/me/.agent-secrets/agent-secrets.sock",
+		LogFile:    "/home/me/.agent-secrets/daemon.log",
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	got := SystemdUnit(testUnit())
+
+	for _, want := range []string{
+		"ExecStart=/usr/local/bin/secrets serve\n",
+		"Environment=AGENT_SECRETS_SOCKET=/home/me/.agent-secrets/agent-secrets.sock\n",
+		"# Socket: /home/me/.agent-secrets/agent-secrets.sock\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("unit missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSystemdUnit_Quoting(t *testing.T) {
+	u := testUnit()
+	u.Executable = "/Users/me/My Tools/secrets"
+	u.Args = []string{"serve", "--config", "/tmp/100%/config.json"}
+	u.Env = map[string]string{"AGENT_SECRETS_DIR": "/home/me/secret $dir"}
+
+	got := SystemdUnit(u)
+	if want := `ExecStart="/Users/me/My Tools/secrets" serve --config "/tmp/100%%/config.json"`; !strings.Contains(got, want) {
+		t.Errorf("expected %s in:\n%s", want, got)
+	}
+	if want := `Environment="AGENT_SECRETS_DIR=/home/me/secret $$dir"`; !strings.Contains(got, want) {
+		t.Errorf("expected %s in:\n%s", want, got)
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	got := LaunchdPlist(testUnit())
+
+	// The plist must be well-formed XML
+	dec := xml.NewDecoder(strings.NewReader(got))
+	dec.Strict = true
+	for {
+		if _, err := dec.Token(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			t.Fatalf("invalid plist XML: %v\n%s", err, got)
+		}
+	}
+
+	for _, want := range []string{
+		"<string>com.joelhooks.agent-secrets</string>",
+		"<key>ProgramArguments</key>\n\t<array>\n\t\t<string>/usr/local/bin/secrets</string>\n\t\t<string>serve</string>\n\t</array>",
+		"<key>AGENT_SECRETS_SOCKET</key>\n\t\t<string>/home/me/.agent-secrets/agent-secrets.sock</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>StandardErrorPath</key>\n\t<string>/home/me/.agent-secrets/daemon.log</string>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("plist missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestLaunchdPlist_Escaping(t *testing.T) {
+	u := testUnit()
+	u.Executable = "/Users/me/R&D/secrets"
+
+	got := LaunchdPlist(u)
+	if !strings.Contains(got, "<string>/Users/me/R&amp;D/secrets</string>") {
+		t.Errorf("executable not escaped:\n%s", got)
+	}
+}
+
+func TestPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	got, err := Path("linux", DefaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".config", "systemd", "user", "agent-secrets.service"); got != want {
+		t.Errorf("linux path = %s, want %s", got, want)
+	}
+
+	got, err = Path("darwin", "agent-secrets-work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "Library", "LaunchAgents", "com.joelhooks.agent-secrets-work.plist"); got != want {
+		t.Errorf("darwin path = %s, want %s", got, want)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+	got, err = Path("linux", DefaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "xdg", "systemd", "user", "agent-secrets.service"); got != want {
+		t.Errorf("linux path with XDG_CONFIG_HOME = %s, want %s", got, want)
+	}
+
+	if _, err := Path("windows", DefaultName); err == nil {
+		t.Error("expected an error for an unsupported platform")
+	}
+}
+
+func TestInstallUninstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	path, err := Install("linux", testUnit(), false)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unit not written: %v", err)
+	}
+	if !strings.Contains(string(data), "ExecStart=/usr/local/bin/secrets serve") {
+		t.Errorf("unexpected unit:\n%s", data)
+	}
+
+	// An existing unit is kept unless forced
+	u := testUnit()
+	u.Executable = "/opt/bin/secrets"
+	if _, err := Install("linux", u, false); !errors.Is(err, ErrExists) {
+		t.Errorf("expected ErrExists, got %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if strings.Contains(string(data), "/opt/bin/secrets") {
+		t.Error("existing unit was overwritten without force")
+	}
+	if _, err := Install("linux", u, true); err != nil {
+		t.Fatalf("forced Install failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "ExecStart=/opt/bin/secrets serve") {
+		t.Errorf("forced Install did not replace the unit:\n%s", data)
+	}
+
+	if _, removed, err := Uninstall("linux", DefaultName); err != nil || !removed {
+		t.Fatalf("Uninstall = %v, %v; want removed", removed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unit still exists: %v", err)
+	}
+	if _, removed, err := Uninstall("linux", DefaultName); err != nil || removed {
+		t.Errorf("second Uninstall = %v, %v; want nothing removed", removed, err)
+	}
+}
+
+func TestEnableCommands(t *testing.T) {
+	linux := EnableCommands("linux", "agent-secrets", "/ignored")
+	if len(linux) != 2 || linux[1] != "systemctl --user enable --now agent-secrets.service" {
+		t.Errorf("linux commands = %v", linux)
+	}
+	darwin := EnableCommands("darwin", "agent-secrets", "/Users/me/Library/LaunchAgents/com.joelhooks.agent-secrets.plist")
+	if len(darwin) != 1 || darwin[0] != "launchctl load -w /Users/me/Library/LaunchAgents/com.joelhooks.agent-secrets.plist" {
+		t.Errorf("darwin commands = %v", darwin)
+	}
+}
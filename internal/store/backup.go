@@ -0,0 +1,137 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// BackupVersion is the backup format version written by ExportBackup.
+const BackupVersion = 1
+
+// BackupManifest describes a backup written by ExportBackup.
+type BackupManifest struct {
+	Version int `json:"version"`
+	// Recipient is the fingerprint of the recipient the secrets are
+	// encrypted to (see RecipientFingerprint).
+	Recipient string    `json:"recipient"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// backupFile is the on-disk backup format: the manifest and the encrypted
+// secrets file exactly as stored.
+type backupFile struct {
+	Manifest BackupManifest `json:"manifest"`
+	Secrets  []byte         `json:"secrets"`
+}
+
+// RecipientFingerprint returns a short, stable identifier for a recipient,
+// or "" if the recipient has no string encoding.
+func RecipientFingerprint(r age.Recipient) string {
+	s := recipientString(r)
+	if s == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ExportBackup writes the encrypted secrets file and a manifest to w. The
+// secrets are not decrypted; restoring them requires the store's identity.
+func (s *Store) ExportBackup(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	ciphertext, err := os.ReadFile(s.cfg.SecretsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	backup := backupFile{
+		Manifest: BackupManifest{
+			Version:   BackupVersion,
+			Recipient: RecipientFingerprint(s.recipient),
+			CreatedAt: time.Now().UTC(),
+		},
+		Secrets: ciphertext,
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(backup); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// ImportBackup replaces the store's secrets with a backup written by
+// ExportBackup. It returns types.ErrRecipientMismatch if the backup was
+// encrypted to a different identity than the store's; use
+// ImportBackupWithIdentity to restore such a backup.
+func (s *Store) ImportBackup(r io.Reader) error {
+	return s.importBackup(r, nil)
+}
+
+// ImportBackupWithIdentity replaces the store's secrets with a backup that
+// identity can decrypt, re-encrypting them to the store's own recipient.
+func (s *Store) ImportBackupWithIdentity(r io.Reader, identity age.Identity) error {
+	return s.importBackup(r, identity)
+}
+
+func (s *Store) importBackup(r io.Reader, identity age.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	var backup backupFile
+	if err := json.NewDecoder(r).Decode(&backup); err != nil {
+		return fmt.Errorf("%w: invalid backup: %v", types.ErrStoreCorrupted, err)
+	}
+	if backup.Manifest.Version != BackupVersion {
+		return fmt.Errorf("unsupported backup version %d", backup.Manifest.Version)
+	}
+
+	reencrypt := identity != nil
+	if !reencrypt {
+		if backup.Manifest.Recipient != RecipientFingerprint(s.recipient) {
+			return fmt.Errorf("%w: backup recipient %s, store recipient %s",
+				types.ErrRecipientMismatch, backup.Manifest.Recipient, RecipientFingerprint(s.recipient))
+		}
+		identity = s.identity
+	}
+
+	// Decrypt and check before touching the store, so a bad backup leaves
+	// it intact
+	data, err := decodeStore(backup.Secrets, identity)
+	if err != nil {
+		return fmt.Errorf("failed to decode backup: %w", err)
+	}
+
+	previousSecrets, previousRecipients := s.secrets, s.recipients
+	s.secrets, s.recipients = data.Secrets, data.Recipients
+	if reencrypt {
+		err = s.saveUnlocked()
+	} else {
+		err = writeFileAtomic(s.cfg.SecretsPath, backup.Secrets, 0600)
+	}
+	if err != nil {
+		s.secrets, s.recipients = previousSecrets, previousRecipients
+		return fmt.Errorf("failed to restore secrets file: %w", err)
+	}
+
+	return nil
+}
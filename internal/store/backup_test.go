@@ -0,0 +1,162 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_BackupRoundTrip(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+
+	secrets := map[string]string{
+		"github_token":               "ghp_test123",
+		types.JoinRef("prod", "db"):  "prod-password",
+		types.JoinRef("stage", "db"): "stage-password",
+	}
+	for name, value := range secrets {
+		if err := store.Add(name, value, "echo rotated"); err != nil {
+			t.Fatalf("Add %s failed: %v", name, err)
+		}
+	}
+
+	var backup bytes.Buffer
+	if err := store.ExportBackup(&backup); err != nil {
+		t.Fatalf("ExportBackup failed: %v", err)
+	}
+
+	var file backupFile
+	if err := json.Unmarshal(backup.Bytes(), &file); err != nil {
+		t.Fatalf("backup is not JSON: %v", err)
+	}
+	if file.Manifest.Version != BackupVersion {
+		t.Errorf("Version = %d, want %d", file.Manifest.Version, BackupVersion)
+	}
+	if file.Manifest.Recipient != RecipientFingerprint(store.recipient) || file.Manifest.Recipient == "" {
+		t.Errorf("Recipient = %q, want store fingerprint", file.Manifest.Recipient)
+	}
+	if file.Manifest.CreatedAt.IsZero() {
+		t.Error("CreatedAt not set")
+	}
+	if bytes.Contains(backup.Bytes(), []byte("prod-password")) {
+		t.Error("backup contains a plaintext secret value")
+	}
+
+	// Lose the secrets, then restore them
+	if err := store.WipeAll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ImportBackup(bytes.NewReader(backup.Bytes())); err != nil {
+		t.Fatalf("ImportBackup failed: %v", err)
+	}
+
+	// Reload from disk to check what was written
+	restored := New(cfg)
+	if err := restored.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	list, err := restored.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(secrets) {
+		t.Fatalf("restored %d secrets, want %d", len(list), len(secrets))
+	}
+	for name, want := range secrets {
+		got, err := restored.Get(name)
+		if err != nil {
+			t.Errorf("Get %s failed: %v", name, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, secret := range list {
+		if secret.RotateVia != "echo rotated" || secret.CreatedAt.IsZero() {
+			t.Errorf("metadata not preserved for %s: %+v", secret.Name, secret)
+		}
+	}
+}
+
+func TestStore_ImportBackup_RecipientMismatch(t *testing.T) {
+	source := New(testConfig(t))
+	if err := source.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.Add(types.JoinRef("prod", "api_key"), "sk_live_abc", ""); err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if err := source.ExportBackup(&backup); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh store has a new identity
+	target := New(testConfig(t))
+	if err := target.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := target.Add("existing", "keep-me", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err := target.ImportBackup(bytes.NewReader(backup.Bytes()))
+	if !errors.Is(err, types.ErrRecipientMismatch) {
+		t.Fatalf("ImportBackup error = %v, want ErrRecipientMismatch", err)
+	}
+	if value, err := target.Get("existing"); err != nil || value != "keep-me" {
+		t.Errorf("store modified by rejected import: %q, %v", value, err)
+	}
+
+	// With the backup's identity the secrets are re-encrypted to the target
+	if err := target.ImportBackupWithIdentity(bytes.NewReader(backup.Bytes()), source.identity); err != nil {
+		t.Fatalf("ImportBackupWithIdentity failed: %v", err)
+	}
+	reloaded := New(target.cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load with target identity failed: %v", err)
+	}
+	if value, err := reloaded.Get(types.JoinRef("prod", "api_key")); err != nil || value != "sk_live_abc" {
+		t.Errorf("restored value = %q, %v", value, err)
+	}
+	if _, err := reloaded.Get("existing"); err == nil {
+		t.Error("expected restore to replace existing secrets")
+	}
+}
+
+func TestStore_ImportBackup_WrongIdentity(t *testing.T) {
+	source := New(testConfig(t))
+	if err := source.Init(); err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if err := source.ExportBackup(&backup); err != nil {
+		t.Fatal(err)
+	}
+
+	target := New(testConfig(t))
+	if err := target.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := target.ImportBackupWithIdentity(bytes.NewReader(backup.Bytes()), target.identity); err == nil {
+		t.Error("expected error decrypting with the wrong identity")
+	}
+}
+
+func TestStore_Backup_NotInitialized(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.ExportBackup(&bytes.Buffer{}); err != types.ErrStoreNotInitialized {
+		t.Errorf("ExportBackup error = %v, want ErrStoreNotInitialized", err)
+	}
+	if err := store.ImportBackup(bytes.NewReader(nil)); err != types.ErrStoreNotInitialized {
+		t.Errorf("ImportBackup error = %v, want ErrStoreNotInitialized", err)
+	}
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// EncodeBinary returns data in the base64 form binary secrets are stored
+// and leased in.
+func EncodeBinary(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// DecodeBinary returns the bytes of a binary secret's value, e.g. one
+// received in a lease.
+func DecodeBinary(value string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid binary secret value: %w", err)
+	}
+	return data, nil
+}
+
+// AddFile adds the contents of the file at path as a new binary secret.
+func (s *Store) AddFile(ref, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return s.AddBinary(ref, data, "", time.Time{})
+}
+
+// AddBinary adds data as a new binary secret, stored base64-encoded with
+// Binary set. rotateVia and expiresAt are as for AddWithExpiry.
+func (s *Store) AddBinary(ref string, data []byte, rotateVia string, expiresAt time.Time) error {
+	now := time.Now()
+	return s.add(types.Secret{
+		Name:      ref,
+		CreatedAt: now,
+		UpdatedAt: now,
+		RotateVia: rotateVia,
+		ExpiresAt: expiresAt,
+		Binary:    true,
+	}, EncodeBinary(data))
+}
+
+// GetBinary returns the decoded contents of a binary secret. The value of
+// a text secret is returned as is.
+func (s *Store) GetBinary(ref string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[ref]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return nil, types.NewSecretError(ref, types.ErrSecretNotFound)
+	}
+
+	if !secret.Binary {
+		return []byte(secret.Value), nil
+	}
+	data, err := DecodeBinary(secret.Value)
+	if err != nil {
+		return nil, types.NewSecretError(ref, err)
+	}
+	return data, nil
+}
+
+// GetFile writes the contents of a secret to outPath, readable only by the
+// owner, replacing any file already there.
+func (s *Store) GetFile(ref, outPath string) error {
+	data, err := s.GetBinary(ref)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(outPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_AddFile_RoundTrip(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every byte value, NULs and invalid UTF-8 included, plus random noise
+	content := make([]byte, 256, 256+4096)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	noise := make([]byte, 4096)
+	if _, err := rand.Read(noise); err != nil {
+		t.Fatal(err)
+	}
+	content = append(content, noise...)
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(in, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddFile("prod::tls_key", in); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+
+	secret, err := store.GetMetadata("prod::tls_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !secret.Binary {
+		t.Error("Binary not set on a file secret")
+	}
+
+	// The decoded bytes survive a reload unchanged
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "restored.key")
+	if err := reloaded.GetFile("prod::tls_key", out); err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	restored, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored, content) {
+		t.Errorf("restored %d bytes differ from the original %d", len(restored), len(content))
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("restored file mode = %o, want 600", perm)
+	}
+
+	// The stored value is the base64 form, as handed out in leases
+	value, err := reloaded.Get("prod::tls_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, err := DecodeBinary(value); err != nil || !bytes.Equal(decoded, content) {
+		t.Errorf("DecodeBinary(value) = %d bytes, %v", len(decoded), err)
+	}
+}
+
+func TestStore_GetFile_TextSecret(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("token", "plain text", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "token")
+	if err := store.GetFile("token", out); err != nil {
+		t.Fatalf("GetFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "plain text" {
+		t.Errorf("file holds %q, want the value as is", data)
+	}
+}
+
+func TestStore_AddFile_Errors(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.AddFile("missing", filepath.Join(t.TempDir(), "nope")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	if err := store.AddBinary("dup", []byte{0}, "", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddBinary("dup", []byte{1}, "", time.Time{}); !errors.Is(err, types.ErrSecretExists) {
+		t.Errorf("duplicate AddBinary = %v, want ErrSecretExists", err)
+	}
+	if err := store.GetFile("absent", filepath.Join(t.TempDir(), "out")); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("GetFile(absent) = %v, want ErrSecretNotFound", err)
+	}
+	if _, err := DecodeBinary("not base64!"); err == nil {
+		t.Error("expected error decoding an invalid value")
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// FindDuplicates groups the refs of secrets that hold the same value,
+// compared by SHA-256 fingerprint. Only groups of two or more are returned,
+// each sorted by ref and ordered by its first ref. No values or
+// fingerprints are exposed.
+func (s *Store) FindDuplicates() ([][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	now := time.Now()
+	byValue := make(map[[sha256.Size]byte][]string)
+	for ref, secret := range s.secrets {
+		if secret == nil || isExpired(secret, now) {
+			continue
+		}
+		sum := sha256.Sum256([]byte(secret.Value))
+		byValue[sum] = append(byValue[sum], ref)
+	}
+
+	groups := make([][]string, 0)
+	for _, refs := range byValue {
+		if len(refs) < 2 {
+			continue
+		}
+		sort.Strings(refs)
+		groups = append(groups, refs)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups, nil
+}
+
+// Fingerprint returns a short HMAC-SHA256 fingerprint of a secret's value,
+// so reports can show whether a value changed without revealing it. The key
+// is derived from the store's identity, so a fingerprint cannot be checked
+// against guessed values without the identity, and the same value has
+// different fingerprints in different stores. Rotating the identity changes
+// every fingerprint.
+func (s *Store) Fingerprint(ref string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return "", types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[ref]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return "", types.NewSecretError(ref, types.ErrSecretNotFound)
+	}
+
+	mac := hmac.New(sha256.New, s.fingerprintKey())
+	mac.Write([]byte(secret.Value))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+// fingerprintKey derives the fingerprint HMAC key from the identity's
+// encoding: the private key, or the plugin stub for plugin identities.
+func (s *Store) fingerprintKey() []byte {
+	var encoded string
+	if id, ok := s.identity.(fmt.Stringer); ok {
+		encoded = id.String()
+	}
+	mac := hmac.New(sha256.New, []byte(encoded))
+	mac.Write([]byte("agent-secrets value fingerprint"))
+	return mac.Sum(nil)
+}
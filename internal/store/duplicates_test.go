@@ -0,0 +1,113 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_FindDuplicates(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]string{
+		"prod::stripe_key": "sk_live_shared",
+		"billing_key":      "sk_live_shared",
+		"db_password":      "unique-value",
+	} {
+		if err := store.Add(name, value, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Expired secrets are not reported
+	if err := store.AddWithExpiry("old_copy", "unique-value", "", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := store.FindDuplicates()
+	if err != nil {
+		t.Fatalf("FindDuplicates failed: %v", err)
+	}
+	want := [][]string{{"billing_key", "prod::stripe_key"}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("FindDuplicates = %v, want %v", groups, want)
+	}
+}
+
+func TestStore_FindDuplicates_None(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("only", "value", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := store.FindDuplicates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("FindDuplicates = %v, want none", groups)
+	}
+}
+
+func TestStore_Fingerprint(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"a": "same", "b": "same", "c": "other"} {
+		if err := store.Add(name, value, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	a, err := store.Fingerprint("a")
+	if err != nil {
+		t.Fatalf("Fingerprint failed: %v", err)
+	}
+	if len(a) != 32 {
+		t.Errorf("fingerprint %q has length %d, want 32", a, len(a))
+	}
+	if sum := sha256.Sum256([]byte("same")); strings.HasPrefix(hex.EncodeToString(sum[:]), a[:16]) {
+		t.Error("fingerprint is an unkeyed hash of the value")
+	}
+	if b, _ := store.Fingerprint("b"); b != a {
+		t.Errorf("equal values have fingerprints %q and %q", a, b)
+	}
+	if c, _ := store.Fingerprint("c"); c == a {
+		t.Error("different values share a fingerprint")
+	}
+	if _, err := store.Fingerprint("missing"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("Fingerprint(missing) error = %v, want ErrSecretNotFound", err)
+	}
+
+	// Stable for the same identity, different under another
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := reloaded.Fingerprint("a"); again != a {
+		t.Errorf("fingerprint changed on reload: %q -> %q", a, again)
+	}
+	other := New(testConfig(t))
+	if err := other.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Add("a", "same", ""); err != nil {
+		t.Fatal(err)
+	}
+	if o, _ := other.Fingerprint("a"); o == a {
+		t.Error("stores with different identities share a fingerprint")
+	}
+}
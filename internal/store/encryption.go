@@ -2,15 +2,32 @@
 package store
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"strings"
 
 	"filippo.io/age"
+	"filippo.io/age/plugin"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
 
+// pluginUI relays plugin messages (e.g. "touch your YubiKey") to stderr.
+// Plugins that need interactive input such as a PIN will fail, since the
+// daemon has no terminal.
+var pluginUI = &plugin.ClientUI{
+	DisplayMessage: func(name, message string) error {
+		fmt.Fprintf(os.Stderr, "age-plugin-%s: %s\n", name, message)
+		return nil
+	},
+	WaitTimer: func(name string) {
+		fmt.Fprintf(os.Stderr, "age-plugin-%s: waiting for hardware token...\n", name)
+	},
+}
+
 // GenerateIdentity creates a new age X25519 identity and saves it to the specified path.
 func GenerateIdentity(path string) (*age.X25519Identity, error) {
 	identity, err := age.GenerateX25519Identity()
@@ -31,6 +48,16 @@ func GenerateIdentity(path string) (*age.X25519Identity, error) {
 
 // LoadIdentity loads an age identity from the specified path.
 func LoadIdentity(path string) (*age.X25519Identity, error) {
+	data, err := readIdentity(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseX25519Identity(data)
+}
+
+// readIdentity reads the identity file at path, decrypting it first if it is
+// passphrase-protected.
+func readIdentity(path string) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -38,7 +65,13 @@ func LoadIdentity(path string) (*age.X25519Identity, error) {
 		}
 		return nil, fmt.Errorf("failed to read identity: %w", err)
 	}
+	if IsPassphraseProtected(data) {
+		return decryptIdentity(path, data)
+	}
+	return data, nil
+}
 
+func parseX25519Identity(data []byte) (*age.X25519Identity, error) {
 	// Parse the identity from the armored format
 	identity, err := age.ParseX25519Identity(string(data))
 	if err != nil {
@@ -48,14 +81,89 @@ func LoadIdentity(path string) (*age.X25519Identity, error) {
 	return identity, nil
 }
 
-// Encrypt encrypts plaintext bytes using the provided age recipient.
-func Encrypt(plaintext []byte, recipient age.Recipient) ([]byte, error) {
-	if recipient == nil {
-		return nil, fmt.Errorf("%w: recipient is nil", types.ErrEncryptionFailed)
+// LoadIdentityFile loads the identity at path along with the recipient used to
+// encrypt to it. The file is either a native X25519 identity or a plugin
+// identity stub ("AGE-PLUGIN-NAME-1..."), as written by tools like
+// age-plugin-yubikey, and may be passphrase-protected (see
+// GenerateIdentityWithPassphrase). For plugin identities the private key stays on the
+// token and decryption is routed through the age-plugin-NAME binary.
+func LoadIdentityFile(path string) (age.Identity, age.Recipient, error) {
+	data, err := readIdentity(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	identityLine, recipientLine := parseIdentityStub(data)
+	if !strings.HasPrefix(identityLine, "AGE-PLUGIN-") {
+		identity, err := parseX25519Identity(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return identity, identity.Recipient(), nil
+	}
+
+	identity, err := plugin.NewIdentity(identityLine, pluginUI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", types.ErrInvalidIdentity, err)
+	}
+
+	// Fail early with an actionable message rather than on first decrypt
+	binary := "age-plugin-" + identity.Name()
+	if _, err := exec.LookPath(binary); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s is not in PATH; install it to use the identity in %s", types.ErrPluginNotFound, binary, path)
+	}
+
+	// Prefer the stub's recorded recipient; not every plugin can encrypt to
+	// its own identity encoding.
+	var recipient age.Recipient = identity.Recipient()
+	if recipientLine != "" {
+		r, err := plugin.NewRecipient(recipientLine, pluginUI)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid recipient %q: %v", types.ErrInvalidIdentity, recipientLine, err)
+		}
+		recipient = r
+	}
+
+	return identity, recipient, nil
+}
+
+// parseIdentityStub returns the first identity line in an identity file and
+// the recipient from a "# Recipient: age1..." comment, if present.
+func parseIdentityStub(data []byte) (identity, recipient string) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			if value, ok := strings.CutPrefix(comment, "Recipient:"); ok && recipient == "" {
+				recipient = strings.TrimSpace(value)
+			}
+			continue
+		}
+		if identity == "" {
+			identity = line
+		}
+	}
+	return identity, recipient
+}
+
+// Encrypt encrypts plaintext bytes to the provided age recipients. Any one of
+// the matching identities can decrypt the result.
+func Encrypt(plaintext []byte, recipients ...age.Recipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", types.ErrEncryptionFailed)
+	}
+	for _, recipient := range recipients {
+		if recipient == nil {
+			return nil, fmt.Errorf("%w: recipient is nil", types.ErrEncryptionFailed)
+		}
 	}
 
 	var buf bytes.Buffer
-	w, err := age.Encrypt(&buf, recipient)
+	w, err := age.Encrypt(&buf, recipients...)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
 	}
@@ -71,6 +179,19 @@ func Encrypt(plaintext []byte, recipient age.Recipient) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// ParseRecipient parses an age public key, either a native X25519 recipient
+// ("age1...") or a plugin recipient ("age1NAME1...").
+func ParseRecipient(s string) (age.Recipient, error) {
+	if r, err := age.ParseX25519Recipient(s); err == nil {
+		return r, nil
+	}
+	r, err := plugin.NewRecipient(s, pluginUI)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid recipient %q", types.ErrInvalidIdentity, s)
+	}
+	return r, nil
+}
+
 // Decrypt decrypts ciphertext bytes using the provided age identity.
 func Decrypt(ciphertext []byte, identity age.Identity) ([]byte, error) {
 	if identity == nil {
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -123,6 +124,52 @@ func TestEncryptDecrypt_EmptyData(t *testing.T) {
 	}
 }
 
+func TestEncryptDecrypt_MultipleRecipients(t *testing.T) {
+	alice, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("shared secret")
+	ciphertext, err := Encrypt(plaintext, alice.Recipient(), bob.Recipient())
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	for _, identity := range []*age.X25519Identity{alice, bob} {
+		decrypted, err := Decrypt(ciphertext, identity)
+		if err != nil {
+			t.Fatalf("Decrypt with %s failed: %v", identity.Recipient(), err)
+		}
+		if string(decrypted) != string(plaintext) {
+			t.Errorf("decrypted = %q, want %q", decrypted, plaintext)
+		}
+	}
+}
+
+func TestParseRecipient(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ParseRecipient(identity.Recipient().String())
+	if err != nil {
+		t.Fatalf("ParseRecipient failed: %v", err)
+	}
+	if r.(*age.X25519Recipient).String() != identity.Recipient().String() {
+		t.Errorf("parsed recipient = %v", r)
+	}
+
+	if _, err := ParseRecipient("age1notakey"); !errors.Is(err, types.ErrInvalidIdentity) {
+		t.Errorf("expected ErrInvalidIdentity, got %v", err)
+	}
+}
+
 func TestEncrypt_NilRecipient(t *testing.T) {
 	_, err := Encrypt([]byte("data"), nil)
 	if err == nil {
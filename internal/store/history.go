@@ -0,0 +1,117 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// VersionEntry is a previous value of a secret, kept in its history.
+type VersionEntry struct {
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"` // When the value was set
+}
+
+// pushHistory records the current value before it is overwritten, keeping
+// at most limit entries.
+func (s *secretWithValue) pushHistory(limit int) {
+	if limit <= 0 {
+		return
+	}
+	s.History = append(s.History, VersionEntry{Value: s.Value, UpdatedAt: s.UpdatedAt})
+	s.trimHistory(limit)
+}
+
+// trimHistory drops the oldest entries beyond limit and returns how many
+// were dropped.
+func (s *secretWithValue) trimHistory(limit int) int {
+	excess := len(s.History) - max(limit, 0)
+	if excess <= 0 {
+		return 0
+	}
+	s.History = append([]VersionEntry(nil), s.History[excess:]...)
+	return excess
+}
+
+// Versions lists the versions of a secret, newest first, starting with the
+// current value as version 0. No values are returned.
+func (s *Store) Versions(ref string) ([]types.SecretVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[ref]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return nil, types.NewSecretError(ref, types.ErrSecretNotFound)
+	}
+
+	versions := []types.SecretVersion{{Version: 0, UpdatedAt: secret.UpdatedAt}}
+	for n := 1; n <= len(secret.History); n++ {
+		versions = append(versions, types.SecretVersion{
+			Version:   n,
+			UpdatedAt: secret.History[len(secret.History)-n].UpdatedAt,
+		})
+	}
+	return versions, nil
+}
+
+// GetVersion returns the value of version n of a secret: 0 is the current
+// value, 1 the previous one, and so on.
+func (s *Store) GetVersion(ref string, n int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return "", types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[ref]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return "", types.NewSecretError(ref, types.ErrSecretNotFound)
+	}
+
+	if n == 0 {
+		return secret.Value, nil
+	}
+	if n < 0 || n > len(secret.History) {
+		return "", types.NewSecretError(ref, fmt.Errorf("%w: version %d (have %d previous)", types.ErrVersionNotFound, n, len(secret.History)))
+	}
+	return secret.History[len(secret.History)-n].Value, nil
+}
+
+// Rollback restores the previous value of a secret. The current value is
+// discarded and the restored one leaves the history, so repeated rollbacks
+// step further back.
+func (s *Store) Rollback(ref string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[ref]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return types.NewSecretError(ref, types.ErrSecretNotFound)
+	}
+	if len(secret.History) == 0 {
+		return types.NewSecretError(ref, fmt.Errorf("%w: no previous value to roll back to", types.ErrVersionNotFound))
+	}
+
+	previous := *secret
+	last := secret.History[len(secret.History)-1]
+	secret.Value = last.Value
+	secret.History = secret.History[:len(secret.History)-1]
+	secret.UpdatedAt = time.Now()
+
+	if err := s.saveUnlocked(); err != nil {
+		// Keep memory in line with the file on disk
+		*secret = previous
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_Rollback(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HistoryLimit = 5
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("api_key", "v1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update("api_key", "v2", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// History is part of the encrypted file
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := reloaded.GetVersion("api_key", 1); err != nil || value != "v1" {
+		t.Fatalf("GetVersion(1) = %q, %v; want v1", value, err)
+	}
+
+	if err := reloaded.Rollback("api_key"); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if value, err := reloaded.Get("api_key"); err != nil || value != "v1" {
+		t.Errorf("Get after rollback = %q, %v; want v1", value, err)
+	}
+
+	// Nothing older than v1
+	if err := reloaded.Rollback("api_key"); !errors.Is(err, types.ErrVersionNotFound) {
+		t.Errorf("second Rollback error = %v, want ErrVersionNotFound", err)
+	}
+	if err := reloaded.Rollback("missing"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("Rollback(missing) error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestStore_History_Cap(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HistoryLimit = 3
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("token", "v0", ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := store.Update("token", fmt.Sprintf("v%d", i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := store.Versions("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 4 { // current plus three previous
+		t.Fatalf("Versions = %d entries, want 4", len(versions))
+	}
+	for n, want := range []string{"v5", "v4", "v3", "v2"} {
+		if value, err := store.GetVersion("token", n); err != nil || value != want {
+			t.Errorf("GetVersion(%d) = %q, %v; want %s", n, value, err, want)
+		}
+	}
+	if _, err := store.GetVersion("token", 4); !errors.Is(err, types.ErrVersionNotFound) {
+		t.Errorf("GetVersion(4) error = %v, want ErrVersionNotFound", err)
+	}
+
+	// Updates that leave the value alone add no version
+	rotateVia := "rotate.sh"
+	if err := store.Update("token", "v5", &rotateVia); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := store.GetVersion("token", 1); err != nil || value != "v4" {
+		t.Errorf("GetVersion(1) after same-value update = %q, %v; want v4", value, err)
+	}
+
+	// Lowering the limit takes effect on compaction
+	cfg.HistoryLimit = 1
+	reclaimed, err := store.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reclaimed != 2 {
+		t.Errorf("Compact reclaimed %d, want 2", reclaimed)
+	}
+	if versions, _ := store.Versions("token"); len(versions) != 2 {
+		t.Errorf("Versions after compaction = %d entries, want 2", len(versions))
+	}
+}
+
+func TestStore_History_Disabled(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("token", "v1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update("token", "v2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Rollback("token"); !errors.Is(err, types.ErrVersionNotFound) {
+		t.Errorf("Rollback error = %v, want ErrVersionNotFound", err)
+	}
+}
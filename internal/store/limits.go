@@ -0,0 +1,59 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// saveWithinLimits saves like saveUnlocked, but first refuses with a
+// UserError if the change being saved takes the store past the configured
+// MaxSecrets or MaxStoreBytes. added is how many secrets the change added;
+// the secret count is only checked when it is positive, so a store already
+// over a lowered limit can still be updated and shrunk. Callers undo their
+// change when it fails.
+func (s *Store) saveWithinLimits(added int) error {
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	if limit := s.cfg.MaxSecrets; limit > 0 && added > 0 {
+		if count := s.countLive(); count > limit {
+			return types.NewUserError(
+				"Secret limit reached",
+				fmt.Sprintf("The store is limited to %d secrets (max_secrets) and this would make %d. The limit catches automation that keeps adding secrets.", limit, count),
+				"Delete secrets you no longer need, or raise max_secrets in the config",
+				"secrets list",
+			).WithContext("Limit", strconv.Itoa(limit))
+		}
+	}
+
+	plaintext, err := s.marshalUnlocked()
+	if err != nil {
+		return err
+	}
+	if limit := s.cfg.MaxStoreBytes; limit > 0 && len(plaintext) > limit {
+		return types.NewUserError(
+			"Store size limit reached",
+			fmt.Sprintf("The store is limited to %d bytes (max_store_bytes) and this would make it %d. Nothing was written.", limit, len(plaintext)),
+			"Delete or compact secrets you no longer need, or raise max_store_bytes in the config",
+			"secrets compact --help",
+		).WithContext("Limit", strconv.Itoa(limit))
+	}
+
+	return s.writePlaintext(plaintext)
+}
+
+// countLive returns the number of secrets that have not expired.
+func (s *Store) countLive() int {
+	now := time.Now()
+	n := 0
+	for _, secret := range s.secrets {
+		if secret != nil && !isExpired(secret, now) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_MaxSecrets(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxSecrets = 3
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < cfg.MaxSecrets; i++ {
+		if err := store.Add(fmt.Sprintf("secret_%d", i), "value", ""); err != nil {
+			t.Fatalf("Add #%d within the limit failed: %v", i, err)
+		}
+	}
+
+	var userErr *types.UserError
+	if err := store.Add("one_too_many", "value", ""); !errors.As(err, &userErr) {
+		t.Fatalf("Add past the limit = %v, want UserError", err)
+	}
+	if _, err := store.Get("one_too_many"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("refused secret is in memory: %v", err)
+	}
+
+	// Updates don't add secrets, so they are still allowed
+	if err := store.Update("secret_0", "new value", nil); err != nil {
+		t.Errorf("Update at the limit failed: %v", err)
+	}
+
+	// Bulk adds past the limit are refused as a whole
+	if _, _, err := store.BulkAdd(map[string]string{"A": "1"}, "", ""); !errors.As(err, &userErr) {
+		t.Errorf("BulkAdd past the limit = %v, want UserError", err)
+	}
+	if _, err := store.Get("A"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("refused import is in memory: %v", err)
+	}
+
+	// Deleting makes room again
+	if err := store.Delete("secret_1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("one_too_many", "value", ""); err != nil {
+		t.Errorf("Add after freeing a slot failed: %v", err)
+	}
+}
+
+func TestStore_MaxStoreBytes(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxStoreBytes = 2048
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill the store until the next add is refused
+	value := strings.Repeat("x", 200)
+	var added int
+	var err error
+	for added = 0; added < 100; added++ {
+		if err = store.Add(fmt.Sprintf("secret_%d", added), value, ""); err != nil {
+			break
+		}
+	}
+	var userErr *types.UserError
+	if !errors.As(err, &userErr) {
+		t.Fatalf("cumulative adds were never refused (last error %v)", err)
+	}
+	if added == 0 {
+		t.Fatal("the first add was refused")
+	}
+
+	before, err := os.ReadFile(cfg.SecretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Refused changes leave the file and memory untouched
+	if err := store.Add("oversized", strings.Repeat("y", 4096), ""); !errors.As(err, &userErr) {
+		t.Errorf("oversized Add = %v, want UserError", err)
+	}
+	if err := store.Update("secret_0", strings.Repeat("y", 4096), nil); !errors.As(err, &userErr) {
+		t.Errorf("oversized Update = %v, want UserError", err)
+	}
+	if _, _, err := store.BulkAdd(map[string]string{"BIG": strings.Repeat("y", 4096)}, "", ""); !errors.As(err, &userErr) {
+		t.Errorf("oversized BulkAdd = %v, want UserError", err)
+	}
+
+	after, err := os.ReadFile(cfg.SecretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("secrets file was rewritten by a refused change")
+	}
+	if got, err := store.Get("secret_0"); err != nil || got != value {
+		t.Errorf("Get(secret_0) = %q, %v; want the old value", got, err)
+	}
+	if _, err := store.Get("oversized"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("refused secret is in memory: %v", err)
+	}
+
+	// Smaller values still fit
+	if err := store.Update("secret_0", "small", nil); err != nil {
+		t.Errorf("shrinking Update failed: %v", err)
+	}
+}
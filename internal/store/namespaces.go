@@ -0,0 +1,75 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// ListNamespaces returns the namespaces that hold at least one secret,
+// sorted. Secrets without a namespace prefix count toward
+// types.DefaultNamespace.
+func (s *Store) ListNamespaces() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	for ref, secret := range s.secrets {
+		if secret == nil || isExpired(secret, now) {
+			continue
+		}
+		ns, _ := types.SplitRef(ref)
+		seen[ns] = true
+	}
+
+	namespaces := make([]string, 0, len(seen))
+	for ns := range seen {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// DeleteNamespace deletes every secret in namespace and returns how many
+// were deleted. Leases are not touched; callers holding a lease manager
+// revoke those separately.
+func (s *Store) DeleteNamespace(namespace string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return 0, types.ErrStoreNotInitialized
+	}
+	if namespace == "" {
+		namespace = types.DefaultNamespace
+	}
+
+	deleted := make(map[string]*secretWithValue)
+	for ref, secret := range s.secrets {
+		if ns, _ := types.SplitRef(ref); ns == namespace {
+			deleted[ref] = secret
+		}
+	}
+	if len(deleted) == 0 {
+		return 0, fmt.Errorf("%w: %s", types.ErrNamespaceNotFound, namespace)
+	}
+
+	for ref := range deleted {
+		delete(s.secrets, ref)
+	}
+	if err := s.saveUnlocked(); err != nil {
+		// Keep memory in line with the file on disk
+		for ref, secret := range deleted {
+			s.secrets[ref] = secret
+		}
+		return 0, err
+	}
+	return len(deleted), nil
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_Namespaces(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"plain", "prod::db", "prod::api", "stage::db"} {
+		if err := store.Add(ref, "value", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	namespaces, err := store.ListNamespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{types.DefaultNamespace, "prod", "stage"}; !reflect.DeepEqual(namespaces, want) {
+		t.Errorf("ListNamespaces() = %v, want %v", namespaces, want)
+	}
+
+	n, err := store.DeleteNamespace("prod")
+	if err != nil {
+		t.Fatalf("DeleteNamespace failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("deleted %d secrets, want 2", n)
+	}
+
+	// Other namespaces, including the default, survive a reload
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"plain", "stage::db"} {
+		if _, err := reloaded.Get(ref); err != nil {
+			t.Errorf("Get(%s) after deleting prod: %v", ref, err)
+		}
+	}
+	for _, ref := range []string{"prod::db", "prod::api"} {
+		if _, err := reloaded.Get(ref); !errors.Is(err, types.ErrSecretNotFound) {
+			t.Errorf("Get(%s) = %v, want ErrSecretNotFound", ref, err)
+		}
+	}
+	if namespaces, _ := reloaded.ListNamespaces(); !reflect.DeepEqual(namespaces, []string{types.DefaultNamespace, "stage"}) {
+		t.Errorf("namespaces after delete = %v", namespaces)
+	}
+
+	if _, err := store.DeleteNamespace("prod"); !errors.Is(err, types.ErrNamespaceNotFound) {
+		t.Errorf("deleting an empty namespace = %v, want ErrNamespaceNotFound", err)
+	}
+}
+
+func TestStore_DeleteNamespace_Default(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range []string{"plain", "prod::db"} {
+		if err := store.Add(ref, "value", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := store.DeleteNamespace(""); err != nil || n != 1 {
+		t.Fatalf("DeleteNamespace(\"\") = %d, %v; want 1", n, err)
+	}
+	if _, err := store.Get("prod::db"); err != nil {
+		t.Errorf("prod::db was deleted with the default namespace: %v", err)
+	}
+}
+
+func TestStore_Namespaces_NotInitialized(t *testing.T) {
+	store := New(testConfig(t))
+	if _, err := store.ListNamespaces(); err != types.ErrStoreNotInitialized {
+		t.Errorf("expected ErrStoreNotInitialized, got %v", err)
+	}
+	if _, err := store.DeleteNamespace("prod"); err != types.ErrStoreNotInitialized {
+		t.Errorf("expected ErrStoreNotInitialized, got %v", err)
+	}
+}
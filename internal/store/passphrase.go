@@ -0,0 +1,152 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// PassphraseEnv is the environment variable the default Passphrase reads,
+// so the daemon can unlock a protected identity without a terminal.
+const PassphraseEnv = "AGENT_SECRETS_PASSPHRASE"
+
+// PassphraseFunc returns the passphrase for the protected identity file at
+// path.
+type PassphraseFunc func(path string) (string, error)
+
+// Passphrase is called whenever a passphrase-protected identity is loaded.
+// It defaults to EnvPassphrase; the CLI replaces it with one that prompts on
+// the terminal.
+var Passphrase PassphraseFunc = EnvPassphrase
+
+// scryptWorkFactor overrides age's default scrypt work factor when non-zero.
+// Tests lower it to keep key derivation fast.
+var scryptWorkFactor int
+
+// EnvPassphrase returns the passphrase from PassphraseEnv, or
+// ErrPassphraseRequired if it is unset.
+func EnvPassphrase(path string) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return "", fmt.Errorf("%w: set %s to unlock %s", types.ErrPassphraseRequired, PassphraseEnv, path)
+}
+
+// ChildEnv returns the current environment without PassphraseEnv, for
+// commands the daemon or CLI runs on the user's behalf. Those commands get
+// the secrets they need, never the key to all of them.
+func ChildEnv() []string {
+	env := os.Environ()
+	filtered := env[:0]
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, PassphraseEnv+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
+
+// GenerateIdentityWithPassphrase creates a new age X25519 identity and saves
+// it to path encrypted with passphrase, in the armored format written by
+// "age -p -a". Loading it with LoadIdentity or LoadIdentityFile asks
+// Passphrase for the passphrase.
+func GenerateIdentityWithPassphrase(path, passphrase string) (*age.X25519Identity, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w: passphrase must not be empty", types.ErrEncryptionFailed)
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+	data, err := protectIdentity(identity, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write with secure permissions (0600)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+// protectIdentity returns identity encrypted with passphrase as an armored
+// age file.
+func protectIdentity(identity *age.X25519Identity, passphrase string) ([]byte, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+	if scryptWorkFactor != 0 {
+		recipient.SetWorkFactor(scryptWorkFactor)
+	}
+
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+	if _, err := io.WriteString(w, identity.String()); err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+	if err := armored.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// IsPassphraseProtected reports whether data is an age-encrypted identity
+// file, armored or binary, rather than a plain identity.
+func IsPassphraseProtected(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return bytes.HasPrefix(data, []byte(armor.Header)) || bytes.HasPrefix(data, []byte("age-encryption.org/"))
+}
+
+// decryptIdentity decrypts the protected identity file at path with the
+// passphrase returned by Passphrase.
+func decryptIdentity(path string, data []byte) ([]byte, error) {
+	if Passphrase == nil {
+		return nil, fmt.Errorf("%w: %s", types.ErrPassphraseRequired, path)
+	}
+	passphrase, err := Passphrase(path)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrInvalidIdentity, err)
+	}
+
+	data = bytes.TrimLeft(data, " \t\r\n")
+	var src io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte(armor.Header)) {
+		src = armor.NewReader(src)
+	}
+	r, err := age.Decrypt(src, identity)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, fmt.Errorf("%w for %s", types.ErrWrongPassphrase, path)
+		}
+		return nil, fmt.Errorf("%w: %v", types.ErrInvalidIdentity, err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrInvalidIdentity, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// usePassphrase makes Passphrase return passphrase for the rest of the test,
+// with a cheap scrypt work factor.
+func usePassphrase(t *testing.T, passphrase string) {
+	t.Helper()
+	oldFunc, oldFactor := Passphrase, scryptWorkFactor
+	Passphrase = func(string) (string, error) { return passphrase, nil }
+	scryptWorkFactor = 10
+	t.Cleanup(func() {
+		Passphrase, scryptWorkFactor = oldFunc, oldFactor
+	})
+}
+
+func TestGenerateIdentityWithPassphrase(t *testing.T) {
+	usePassphrase(t, "correct horse")
+	path := filepath.Join(t.TempDir(), "identity.age")
+
+	identity, err := GenerateIdentityWithPassphrase(path, "correct horse")
+	if err != nil {
+		t.Fatalf("GenerateIdentityWithPassphrase failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), identity.String()) {
+		t.Fatal("identity file contains the plaintext key")
+	}
+	if !IsPassphraseProtected(data) {
+		t.Error("IsPassphraseProtected = false for a protected identity")
+	}
+
+	loaded, err := LoadIdentity(path)
+	if err != nil {
+		t.Fatalf("LoadIdentity failed: %v", err)
+	}
+	if loaded.String() != identity.String() {
+		t.Error("LoadIdentity returned a different identity")
+	}
+
+	_, recipient, err := LoadIdentityFile(path)
+	if err != nil {
+		t.Fatalf("LoadIdentityFile failed: %v", err)
+	}
+	if r, ok := recipient.(*age.X25519Recipient); !ok || r.String() != identity.Recipient().String() {
+		t.Error("LoadIdentityFile returned a different recipient")
+	}
+}
+
+func TestLoadIdentity_WrongPassphrase(t *testing.T) {
+	usePassphrase(t, "correct horse")
+	path := filepath.Join(t.TempDir(), "identity.age")
+	if _, err := GenerateIdentityWithPassphrase(path, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	usePassphrase(t, "battery staple")
+	if _, err := LoadIdentity(path); !errors.Is(err, types.ErrWrongPassphrase) {
+		t.Errorf("LoadIdentity error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestLoadIdentity_PassphraseFromEnv(t *testing.T) {
+	usePassphrase(t, "correct horse")
+	path := filepath.Join(t.TempDir(), "identity.age")
+	if _, err := GenerateIdentityWithPassphrase(path, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	Passphrase = EnvPassphrase
+	t.Setenv(PassphraseEnv, "")
+	if _, err := LoadIdentity(path); !errors.Is(err, types.ErrPassphraseRequired) {
+		t.Errorf("LoadIdentity without %s error = %v, want ErrPassphraseRequired", PassphraseEnv, err)
+	}
+
+	t.Setenv(PassphraseEnv, "correct horse")
+	if _, err := LoadIdentity(path); err != nil {
+		t.Errorf("LoadIdentity with %s failed: %v", PassphraseEnv, err)
+	}
+}
+
+func TestStore_InitWithPassphrase(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		passphrase string
+	}{
+		{"protected", "correct horse"},
+		{"unprotected", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			usePassphrase(t, tt.passphrase)
+			asked := false
+			Passphrase = func(string) (string, error) {
+				asked = true
+				return tt.passphrase, nil
+			}
+
+			cfg := testConfig(t)
+			store := New(cfg)
+			if err := store.InitWithPassphrase(tt.passphrase); err != nil {
+				t.Fatalf("InitWithPassphrase failed: %v", err)
+			}
+			if err := store.Add("token", "value", ""); err != nil {
+				t.Fatal(err)
+			}
+
+			reloaded := New(cfg)
+			if err := reloaded.Load(); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if value, err := reloaded.Get("token"); err != nil || value != "value" {
+				t.Errorf("Get(token) = %q, %v", value, err)
+			}
+			if want := tt.passphrase != ""; asked != want {
+				t.Errorf("passphrase asked = %v, want %v", asked, want)
+			}
+		})
+	}
+}
+
+func TestStore_RotateIdentity_KeepsPassphrase(t *testing.T) {
+	usePassphrase(t, "correct horse")
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.InitWithPassphrase("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("token", "value", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RotateIdentity(""); err != nil {
+		t.Fatalf("RotateIdentity failed: %v", err)
+	}
+	data, err := os.ReadFile(cfg.IdentityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsPassphraseProtected(data) {
+		t.Fatal("rotated identity is not passphrase-protected")
+	}
+
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load after rotation failed: %v", err)
+	}
+	if value, err := reloaded.Get("token"); err != nil || value != "value" {
+		t.Errorf("Get(token) = %q, %v", value, err)
+	}
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/plugin"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// fakePluginName is the name of the fake hardware-token plugin. The test
+// binary acts as age-plugin-fakekey when invoked under that name.
+const fakePluginName = "fakekey"
+
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == "age-plugin-"+fakePluginName {
+		p, _ := plugin.New(fakePluginName)
+		p.HandleRecipient(func(data []byte) (age.Recipient, error) {
+			return fakeTokenKey{}, nil
+		})
+		p.HandleIdentity(func(data []byte) (age.Identity, error) {
+			return fakeTokenKey{}, nil
+		})
+		os.Exit(p.Main())
+	}
+	os.Exit(m.Run())
+}
+
+// fakeTokenKey stands in for a key held on a hardware token.
+type fakeTokenKey struct{}
+
+func (fakeTokenKey) Wrap(fileKey []byte) ([]*age.Stanza, error) {
+	return []*age.Stanza{{Type: fakePluginName, Body: xorKey(fileKey)}}, nil
+}
+
+func (fakeTokenKey) Unwrap(stanzas []*age.Stanza) ([]byte, error) {
+	for _, s := range stanzas {
+		if s.Type == fakePluginName {
+			return xorKey(s.Body), nil
+		}
+	}
+	return nil, age.ErrIncorrectIdentity
+}
+
+func xorKey(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5a
+	}
+	return out
+}
+
+// installFakePlugin links the test binary into PATH as age-plugin-fakekey.
+func installFakePlugin(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake plugin binary requires a POSIX PATH lookup")
+	}
+
+	binDir := t.TempDir()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(exe, filepath.Join(binDir, "age-plugin-"+fakePluginName)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// writePluginStub writes an identity stub in the age-plugin-yubikey format.
+func writePluginStub(t *testing.T, path string) {
+	t.Helper()
+
+	stub := "#       Serial: 12345678, Slot: 1\n" +
+		"#    Recipient: " + plugin.EncodeRecipient(fakePluginName, nil) + "\n" +
+		plugin.EncodeIdentity(fakePluginName, nil) + "\n"
+	if err := os.WriteFile(path, []byte(stub), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadIdentityFile_X25519(t *testing.T) {
+	identityPath := filepath.Join(t.TempDir(), "identity.age")
+	generated, err := GenerateIdentity(identityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, recipient, err := LoadIdentityFile(identityPath)
+	if err != nil {
+		t.Fatalf("LoadIdentityFile failed: %v", err)
+	}
+	if _, ok := identity.(*age.X25519Identity); !ok {
+		t.Errorf("expected X25519 identity, got %T", identity)
+	}
+	if recipient.(*age.X25519Recipient).String() != generated.Recipient().String() {
+		t.Error("recipient doesn't match generated identity")
+	}
+}
+
+func TestLoadIdentityFile_PluginRoundTrip(t *testing.T) {
+	installFakePlugin(t)
+
+	identityPath := filepath.Join(t.TempDir(), "identity.age")
+	writePluginStub(t, identityPath)
+
+	identity, recipient, err := LoadIdentityFile(identityPath)
+	if err != nil {
+		t.Fatalf("LoadIdentityFile failed: %v", err)
+	}
+	if _, ok := identity.(*plugin.Identity); !ok {
+		t.Fatalf("expected plugin identity, got %T", identity)
+	}
+
+	plaintext := []byte("hardware-backed secret")
+	ciphertext, err := Encrypt(plaintext, recipient)
+	if err != nil {
+		t.Fatalf("Encrypt via plugin failed: %v", err)
+	}
+	if !bytes.Contains(ciphertext, []byte("-> "+fakePluginName)) {
+		t.Error("expected file key to be wrapped by the plugin")
+	}
+
+	decrypted, err := Decrypt(ciphertext, identity)
+	if err != nil {
+		t.Fatalf("Decrypt via plugin failed: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("round trip mismatch: got %q", decrypted)
+	}
+}
+
+func TestLoadIdentityFile_PluginMissing(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	identityPath := filepath.Join(t.TempDir(), "identity.age")
+	writePluginStub(t, identityPath)
+
+	_, _, err := LoadIdentityFile(identityPath)
+	if !errors.Is(err, types.ErrPluginNotFound) {
+		t.Fatalf("expected ErrPluginNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "age-plugin-"+fakePluginName) {
+		t.Errorf("expected error to name the missing binary, got %q", err)
+	}
+}
+
+func TestStore_PluginIdentity(t *testing.T) {
+	installFakePlugin(t)
+
+	cfg := testConfig(t)
+	writePluginStub(t, cfg.IdentityPath)
+
+	// Init keeps the existing plugin stub rather than generating a key
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := store.Add("api_key", "sk-123", ""); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	value, err := reloaded.Get("api_key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value != "sk-123" {
+		t.Errorf("expected sk-123, got %q", value)
+	}
+}
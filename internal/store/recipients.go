@@ -0,0 +1,109 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// Recipients returns the recipients the store is encrypted to, the local
+// identity's first.
+func (s *Store) Recipients() ([]types.Recipient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	recipients := []types.Recipient{{
+		Recipient:   recipientString(s.recipient),
+		Fingerprint: RecipientFingerprint(s.recipient),
+		Local:       true,
+	}}
+	for _, r := range s.recipients {
+		parsed, err := ParseRecipient(r)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, types.Recipient{Recipient: r, Fingerprint: RecipientFingerprint(parsed)})
+	}
+	return recipients, nil
+}
+
+// AddRecipient adds an age public key that can decrypt the store, and
+// re-encrypts the store to include it.
+func (s *Store) AddRecipient(pubkey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	pubkey = strings.TrimSpace(pubkey)
+	if _, err := ParseRecipient(pubkey); err != nil {
+		return err
+	}
+	if pubkey == recipientString(s.recipient) || s.recipientIndex(pubkey) >= 0 {
+		return fmt.Errorf("%w: %s", types.ErrRecipientExists, pubkey)
+	}
+
+	s.recipients = append(s.recipients, pubkey)
+	if err := s.saveUnlocked(); err != nil {
+		s.recipients = s.recipients[:len(s.recipients)-1]
+		return err
+	}
+	return nil
+}
+
+// RemoveRecipient removes a recipient added with AddRecipient and
+// re-encrypts the store without it. The local identity's recipient cannot be
+// removed. Earlier copies of the store remain readable by the removed key.
+func (s *Store) RemoveRecipient(pubkey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	pubkey = strings.TrimSpace(pubkey)
+	if pubkey == recipientString(s.recipient) {
+		return fmt.Errorf("cannot remove the store's own recipient %s", pubkey)
+	}
+	i := s.recipientIndex(pubkey)
+	if i < 0 {
+		return fmt.Errorf("%w: %s", types.ErrRecipientNotFound, pubkey)
+	}
+
+	previous := s.recipients
+	s.recipients = append(append([]string{}, previous[:i]...), previous[i+1:]...)
+	if err := s.saveUnlocked(); err != nil {
+		s.recipients = previous
+		return err
+	}
+	return nil
+}
+
+// recipientIndex returns the position of pubkey in the additional
+// recipients, or -1.
+func (s *Store) recipientIndex(pubkey string) int {
+	for i, r := range s.recipients {
+		if r == pubkey {
+			return i
+		}
+	}
+	return -1
+}
+
+// recipientString returns the public key encoding of a recipient, or "" if it
+// has none.
+func recipientString(r age.Recipient) string {
+	if s, ok := r.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return ""
+}
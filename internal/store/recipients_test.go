@@ -0,0 +1,122 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_AddRecipient_DecryptsUnderEither(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("shared_token", "team-value", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A teammate's identity, kept in a separate file
+	teammatePath := filepath.Join(t.TempDir(), "teammate.age")
+	teammate, err := GenerateIdentity(teammatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddRecipient(teammate.Recipient().String()); err != nil {
+		t.Fatalf("AddRecipient failed: %v", err)
+	}
+
+	for name, path := range map[string]string{"owner": cfg.IdentityPath, "teammate": teammatePath} {
+		t.Run(name, func(t *testing.T) {
+			other := *cfg
+			other.IdentityPath = path
+			s := New(&other)
+			if err := s.Load(); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			value, err := s.Get("shared_token")
+			if err != nil || value != "team-value" {
+				t.Errorf("Get = %q, %v", value, err)
+			}
+		})
+	}
+
+	// The recipient list survives a reload
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	recipients, err := reloaded.Recipients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 2 || !recipients[0].Local || recipients[1].Recipient != teammate.Recipient().String() {
+		t.Errorf("Recipients = %+v", recipients)
+	}
+}
+
+func TestStore_RemoveRecipient(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("shared_token", "team-value", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	teammate, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey := teammate.Recipient().String()
+	if err := store.AddRecipient(pubkey); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RemoveRecipient(pubkey); err != nil {
+		t.Fatalf("RemoveRecipient failed: %v", err)
+	}
+
+	if _, err := VerifyFile(cfg.SecretsPath, teammate); !errors.Is(err, types.ErrDecryptionFailed) {
+		t.Errorf("removed recipient can still decrypt: %v", err)
+	}
+	if _, err := VerifyFile(cfg.SecretsPath, store.identity); err != nil {
+		t.Errorf("owner cannot decrypt after removal: %v", err)
+	}
+
+	if err := store.RemoveRecipient(pubkey); !errors.Is(err, types.ErrRecipientNotFound) {
+		t.Errorf("second RemoveRecipient error = %v, want ErrRecipientNotFound", err)
+	}
+	if err := store.RemoveRecipient(store.recipient.(*age.X25519Recipient).String()); err == nil {
+		t.Error("expected error removing the store's own recipient")
+	}
+}
+
+func TestStore_AddRecipient_Invalid(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.AddRecipient("not-a-key"); !errors.Is(err, types.ErrInvalidIdentity) {
+		t.Errorf("AddRecipient error = %v, want ErrInvalidIdentity", err)
+	}
+	own := store.recipient.(*age.X25519Recipient).String()
+	if err := store.AddRecipient(own); !errors.Is(err, types.ErrRecipientExists) {
+		t.Errorf("AddRecipient(own) error = %v, want ErrRecipientExists", err)
+	}
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddRecipient(other.Recipient().String()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddRecipient(" " + other.Recipient().String() + "\n"); !errors.Is(err, types.ErrRecipientExists) {
+		t.Errorf("duplicate AddRecipient error = %v, want ErrRecipientExists", err)
+	}
+}
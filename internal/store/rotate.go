@@ -0,0 +1,125 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// RetiredIdentitiesDir is the directory, beside the identity file, that
+// RotateIdentity archives replaced identities to.
+const RetiredIdentitiesDir = "retired-identities"
+
+// RotateIdentity replaces the store's identity with a freshly generated one
+// written to newIdentityPath (the current identity path if empty), and
+// re-encrypts every secret to it. The old identity is archived under
+// RetiredIdentitiesDir and can no longer decrypt the store. Additional
+// recipients are kept.
+//
+// Each file is replaced by renaming a temp file into place, and the secrets
+// are encrypted to both identities until the new one is installed, so
+// stopping partway always leaves a store readable by the identity on disk.
+// Only native X25519 identities can be rotated; plugin identities live on
+// hardware and are rotated with their plugin. A passphrase-protected identity
+// is replaced by one protected with the passphrase Passphrase returns.
+func (s *Store) RotateIdentity(newIdentityPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+	if _, ok := s.identity.(*age.X25519Identity); !ok {
+		return fmt.Errorf("%w: only X25519 identities can be rotated, not plugin identities", types.ErrInvalidIdentity)
+	}
+
+	oldIdentityPath := s.cfg.IdentityPath
+	if newIdentityPath == "" {
+		newIdentityPath = oldIdentityPath
+	}
+
+	next, err := age.GenerateX25519Identity()
+	if err != nil {
+		return fmt.Errorf("%w: %v", types.ErrEncryptionFailed, err)
+	}
+
+	// A protected identity is replaced by one protected the same way
+	identityData := []byte(next.String())
+	if current, err := os.ReadFile(oldIdentityPath); err == nil && IsPassphraseProtected(current) {
+		if Passphrase == nil {
+			return fmt.Errorf("%w: %s", types.ErrPassphraseRequired, newIdentityPath)
+		}
+		passphrase, err := Passphrase(newIdentityPath)
+		if err != nil {
+			return err
+		}
+		if identityData, err = protectIdentity(next, passphrase); err != nil {
+			return err
+		}
+	}
+
+	plaintext, err := json.MarshalIndent(storeData{
+		Version:    storeVersion,
+		Secrets:    s.secrets,
+		Recipients: s.recipients,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+	extra, err := s.extraRecipients()
+	if err != nil {
+		return err
+	}
+
+	// Readable by both identities while the identity file is swapped
+	both := append([]age.Recipient{s.recipient, next.Recipient()}, extra...)
+	if err := s.writeSecrets(plaintext, both); err != nil {
+		return err
+	}
+
+	if err := archiveIdentity(oldIdentityPath); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(newIdentityPath, identityData, 0600); err != nil {
+		return fmt.Errorf("failed to write identity: %w", err)
+	}
+	if newIdentityPath != oldIdentityPath {
+		if err := os.Remove(oldIdentityPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old identity: %w", err)
+		}
+	}
+
+	s.identity = next
+	s.recipient = next.Recipient()
+	s.cfg.IdentityPath = newIdentityPath
+
+	// Drop the old identity from the secrets file
+	if err := s.writeSecrets(plaintext, append([]age.Recipient{s.recipient}, extra...)); err != nil {
+		return fmt.Errorf("new identity installed, but the store is still readable by the old one: %w", err)
+	}
+	return nil
+}
+
+// archiveIdentity copies the identity file at path into RetiredIdentitiesDir,
+// named for the current time.
+func archiveIdentity(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read identity: %w", err)
+	}
+
+	dir := filepath.Join(filepath.Dir(path), RetiredIdentitiesDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	name := fmt.Sprintf("identity-%s.age", time.Now().UTC().Format("20060102T150405.000Z"))
+	if err := writeFileAtomic(filepath.Join(dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to archive identity: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_RotateIdentity(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{"db_password": "hunter2", "api_key": "sk_live"} {
+		if err := store.Add(name, value, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	teammate, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddRecipient(teammate.Recipient().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := LoadIdentity(cfg.IdentityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RotateIdentity(""); err != nil {
+		t.Fatalf("RotateIdentity failed: %v", err)
+	}
+
+	// A fresh load with the new identity file reads every secret
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load after rotation failed: %v", err)
+	}
+	if value, err := reloaded.Get("db_password"); err != nil || value != "hunter2" {
+		t.Errorf("Get(db_password) = %q, %v", value, err)
+	}
+	if value, err := reloaded.Get("api_key"); err != nil || value != "sk_live" {
+		t.Errorf("Get(api_key) = %q, %v", value, err)
+	}
+
+	current, err := LoadIdentity(cfg.IdentityPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.String() == old.String() {
+		t.Fatal("identity file was not replaced")
+	}
+
+	// The old identity can no longer decrypt; the teammate still can
+	if _, err := VerifyFile(cfg.SecretsPath, old); !errors.Is(err, types.ErrDecryptionFailed) {
+		t.Errorf("old identity decrypt error = %v, want ErrDecryptionFailed", err)
+	}
+	if _, err := VerifyFile(cfg.SecretsPath, teammate); err != nil {
+		t.Errorf("additional recipient lost access: %v", err)
+	}
+
+	// The old identity is archived
+	archived, err := filepath.Glob(filepath.Join(filepath.Dir(cfg.IdentityPath), RetiredIdentitiesDir, "identity-*.age"))
+	if err != nil || len(archived) != 1 {
+		t.Fatalf("archived identities = %v, %v", archived, err)
+	}
+	data, err := os.ReadFile(archived[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != old.String() {
+		t.Error("archive does not hold the old identity")
+	}
+}
+
+func TestStore_RotateIdentity_NewPath(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("token", "value", ""); err != nil {
+		t.Fatal(err)
+	}
+	oldPath := cfg.IdentityPath
+
+	newPath := filepath.Join(cfg.Directory, "identity-2.age")
+	if err := store.RotateIdentity(newPath); err != nil {
+		t.Fatalf("RotateIdentity failed: %v", err)
+	}
+	if cfg.IdentityPath != newPath {
+		t.Errorf("IdentityPath = %s, want %s", cfg.IdentityPath, newPath)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("old identity file still present: %v", err)
+	}
+
+	// The store keeps working in memory under the new identity
+	if err := store.Add("second", "value", ""); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := reloaded.Get("second"); err != nil || value != "value" {
+		t.Errorf("Get(second) = %q, %v", value, err)
+	}
+}
+
+func TestStore_RotateIdentity_NotInitialized(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.RotateIdentity(""); err != types.ErrStoreNotInitialized {
+		t.Errorf("expected ErrStoreNotInitialized, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// Search returns metadata for the secrets whose reference, namespace, bare
+// name or labels match query, sorted by name. Values are never searched or
+// returned. A query containing "*" is a glob that must match a whole field
+// ("prod::*", "*_KEY", "env=prod*"); any other query matches fields that
+// contain it. Matching ignores case, and labels match as "key=value".
+func (s *Store) Search(query string) ([]types.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	match := substringMatcher(query)
+	if strings.Contains(query, "*") {
+		match = globMatcher(query)
+	}
+
+	now := time.Now()
+	var secrets []types.Secret
+	for ref, secret := range s.secrets {
+		if secret == nil || isExpired(secret, now) {
+			continue
+		}
+		if searchFieldsMatch(ref, secret.Labels, match) {
+			secrets = append(secrets, secret.Secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
+
+// searchFieldsMatch reports whether any searchable field of a secret matches.
+func searchFieldsMatch(ref string, labels map[string]string, match func(string) bool) bool {
+	namespace, name := types.SplitRef(ref)
+	if match(ref) || match(namespace) || match(name) {
+		return true
+	}
+	for key, value := range labels {
+		if match(key + "=" + value) {
+			return true
+		}
+	}
+	return false
+}
+
+func substringMatcher(query string) func(string) bool {
+	query = strings.ToLower(query)
+	return func(field string) bool {
+		return strings.Contains(strings.ToLower(field), query)
+	}
+}
+
+// globMatcher matches whole fields against query, where "*" matches any run
+// of characters, including none.
+func globMatcher(query string) func(string) bool {
+	parts := strings.Split(query, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re := regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+	return re.MatchString
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestStore_Search(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for ref, value := range map[string]string{
+		"STRIPE_KEY":                          "sk_live_stripe",
+		"GITHUB_TOKEN":                        "ghp_token",
+		types.JoinRef("prod", "DATABASE_URL"): "postgres://prod",
+		types.JoinRef("prod", "API_KEY"):      "sk_live_api",
+		types.JoinRef("stage", "API_KEY"):     "sk_test_api",
+	} {
+		if err := store.Add(ref, value, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SetLabels("GITHUB_TOKEN", map[string]string{"team": "platform"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Substrings match anywhere, ignoring case
+		{"key", []string{"STRIPE_KEY", "prod::API_KEY", "stage::API_KEY"}},
+		{"prod", []string{"prod::API_KEY", "prod::DATABASE_URL"}},
+		{"platform", []string{"GITHUB_TOKEN"}},
+		// Globs must match a whole field
+		{"*_KEY", []string{"STRIPE_KEY", "prod::API_KEY", "stage::API_KEY"}},
+		{"prod::*", []string{"prod::API_KEY", "prod::DATABASE_URL"}},
+		{"API*", []string{"prod::API_KEY", "stage::API_KEY"}},
+		{"KEY*", nil},
+		{"team=*", []string{"GITHUB_TOKEN"}},
+		{"no-such-secret", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := store.Search(tt.query)
+			if err != nil {
+				t.Fatalf("Search(%q) failed: %v", tt.query, err)
+			}
+			var names []string
+			for _, secret := range results {
+				names = append(names, secret.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, names, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Search_NoValues(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("api_key", "sk_live_secret_value", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values are not searched...
+	if results, err := store.Search("sk_live"); err != nil || len(results) != 0 {
+		t.Errorf("Search by value = %v, %v; want no results", results, err)
+	}
+
+	// ...nor returned
+	results, err := store.Search("api")
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search(api) = %v, %v", results, err)
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk_live_secret_value") {
+		t.Errorf("search results leak the value: %s", data)
+	}
+}
+
+func TestStore_Search_NotInitialized(t *testing.T) {
+	store := New(testConfig(t))
+	if _, err := store.Search("x"); err != types.ErrStoreNotInitialized {
+		t.Errorf("expected ErrStoreNotInitialized, got %v", err)
+	}
+}
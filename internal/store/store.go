@@ -4,11 +4,16 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/config"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
@@ -17,21 +22,29 @@ import (
 type storeData struct {
 	Version int                         `json:"version"`
 	Secrets map[string]*secretWithValue `json:"secrets"`
+	// Recipients are additional age public keys the file is encrypted to,
+	// besides the local identity's own recipient.
+	Recipients []string `json:"recipients,omitempty"`
 }
 
 // secretWithValue combines metadata with the actual secret value.
 type secretWithValue struct {
 	types.Secret
 	Value string `json:"value"`
+	// History holds previous values, oldest first (see Config.HistoryLimit)
+	History []VersionEntry `json:"history,omitempty"`
 }
 
 // Store manages encrypted secret storage using Age encryption.
 type Store struct {
 	mu                  sync.RWMutex
-	identity            *age.X25519Identity
+	identity            age.Identity
+	recipient           age.Recipient
+	recipients          []string // Additional recipients (see AddRecipient)
 	secrets             map[string]*secretWithValue
 	cfg                 *config.Config
 	skipPermissionCheck bool
+	auditLogger         *audit.Logger // Optional; records purged secrets
 }
 
 // New creates a new Store instance with the provided configuration.
@@ -52,9 +65,31 @@ func NewWithOptions(cfg *config.Config, skipPermissionCheck bool) *Store {
 	}
 }
 
+// SetAuditLogger sets the logger PurgeExpired records removals to.
+func (s *Store) SetAuditLogger(logger *audit.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditLogger = logger
+}
+
+// Loaded reports whether the store's identity is loaded, so it can serve
+// secrets.
+func (s *Store) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.identity != nil
+}
+
 // Init initializes the store by generating an identity if it doesn't exist
 // and creating an empty encrypted secrets file.
 func (s *Store) Init() error {
+	return s.InitWithPassphrase("")
+}
+
+// InitWithPassphrase is like Init, but a newly generated identity is
+// encrypted with passphrase (see GenerateIdentityWithPassphrase). An existing
+// identity is loaded as-is. An empty passphrase behaves like Init.
+func (s *Store) InitWithPassphrase(passphrase string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -66,18 +101,26 @@ func (s *Store) Init() error {
 	// Check if identity exists
 	if _, err := os.Stat(s.cfg.IdentityPath); os.IsNotExist(err) {
 		// Generate new identity
-		identity, err := GenerateIdentity(s.cfg.IdentityPath)
+		var identity *age.X25519Identity
+		var err error
+		if passphrase != "" {
+			identity, err = GenerateIdentityWithPassphrase(s.cfg.IdentityPath, passphrase)
+		} else {
+			identity, err = GenerateIdentity(s.cfg.IdentityPath)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to generate identity: %w", err)
 		}
 		s.identity = identity
+		s.recipient = identity.Recipient()
 	} else {
-		// Load existing identity
-		identity, err := LoadIdentity(s.cfg.IdentityPath)
+		// Load existing identity (file or plugin-backed)
+		identity, recipient, err := LoadIdentityFile(s.cfg.IdentityPath)
 		if err != nil {
 			return fmt.Errorf("failed to load identity: %w", err)
 		}
 		s.identity = identity
+		s.recipient = recipient
 	}
 
 	// Initialize empty secrets map
@@ -104,12 +147,13 @@ func (s *Store) Load() error {
 		return err
 	}
 
-	// Load identity
-	identity, err := LoadIdentity(s.cfg.IdentityPath)
+	// Load identity (file or plugin-backed)
+	identity, recipient, err := LoadIdentityFile(s.cfg.IdentityPath)
 	if err != nil {
 		return fmt.Errorf("failed to load identity: %w", err)
 	}
 	s.identity = identity
+	s.recipient = recipient
 
 	// Check if secrets file exists
 	if _, err := os.Stat(s.cfg.SecretsPath); os.IsNotExist(err) {
@@ -146,6 +190,7 @@ func (s *Store) Load() error {
 	if s.secrets == nil {
 		s.secrets = make(map[string]*secretWithValue)
 	}
+	s.recipients = data.Recipients
 
 	return nil
 }
@@ -163,33 +208,133 @@ func (s *Store) saveUnlocked() error {
 		return types.ErrStoreNotInitialized
 	}
 
-	// Marshal to JSON
+	plaintext, err := s.marshalUnlocked()
+	if err != nil {
+		return err
+	}
+	return s.writePlaintext(plaintext)
+}
+
+// marshalUnlocked serializes the store to the JSON that is encrypted to
+// disk.
+func (s *Store) marshalUnlocked() ([]byte, error) {
 	data := storeData{
-		Version: 1,
-		Secrets: s.secrets,
+		Version:    storeVersion,
+		Secrets:    s.secrets,
+		Recipients: s.recipients,
 	}
 
 	plaintext, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal secrets: %w", err)
+		return nil, fmt.Errorf("failed to marshal secrets: %w", err)
 	}
+	return plaintext, nil
+}
+
+// writePlaintext encrypts the serialized store and writes it to disk.
+func (s *Store) writePlaintext(plaintext []byte) error {
+	// Encrypt to the local identity and every additional recipient
+	extra, err := s.extraRecipients()
+	if err != nil {
+		return err
+	}
+	return s.writeSecrets(plaintext, append([]age.Recipient{s.recipient}, extra...))
+}
+
+// extraRecipients parses the additional recipients added with AddRecipient.
+func (s *Store) extraRecipients() ([]age.Recipient, error) {
+	recipients := make([]age.Recipient, 0, len(s.recipients))
+	for _, r := range s.recipients {
+		recipient, err := ParseRecipient(r)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
 
-	// Encrypt
-	ciphertext, err := Encrypt(plaintext, s.identity.Recipient())
+// writeSecrets encrypts plaintext to recipients and replaces the secrets
+// file with it.
+func (s *Store) writeSecrets(plaintext []byte, recipients []age.Recipient) error {
+	ciphertext, err := Encrypt(plaintext, recipients...)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt secrets: %w", err)
 	}
 
 	// Write with secure permissions (0600)
-	if err := os.WriteFile(s.cfg.SecretsPath, ciphertext, 0600); err != nil {
+	if err := writeFileAtomic(s.cfg.SecretsPath, ciphertext, 0600); err != nil {
 		return fmt.Errorf("failed to write secrets file: %w", err)
 	}
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temp file beside path and renames it into
+// place, so path never holds a partial write.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
 // Add adds a new secret to the store.
 func (s *Store) Add(name, value, rotateVia string) error {
+	return s.AddWithExpiry(name, value, rotateVia, time.Time{})
+}
+
+// AddWithExpiry adds a new secret that expires at expiresAt, after which it
+// is no longer returned and PurgeExpired deletes it. A zero expiresAt never
+// expires. An expired secret not yet purged can be replaced under its name.
+func (s *Store) AddWithExpiry(name, value, rotateVia string, expiresAt time.Time) error {
+	now := time.Now()
+	return s.add(types.Secret{
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+		RotateVia: rotateVia,
+		ExpiresAt: expiresAt,
+	}, value)
+}
+
+// AddSecret adds a new secret together with its hooks, labels, propagation
+// targets and rotation policy, saving once. CreatedAt and UpdatedAt are set
+// to now. The value of a binary secret is its base64-encoded content.
+func (s *Store) AddSecret(secret types.Secret, value string) error {
+	if secret.RotationPolicy != nil {
+		if err := checkRotationPolicy(secret.Name, secret.RotateVia, secret.RotationPolicy); err != nil {
+			return err
+		}
+		// Never share the caller's policy; readers hold the stored pointer
+		copied := *secret.RotationPolicy
+		secret.RotationPolicy = &copied
+	}
+	if len(secret.Labels) == 0 {
+		secret.Labels = nil
+	} else {
+		secret.Labels = maps.Clone(secret.Labels)
+	}
+	secret.PropagateTo = slices.Clone(secret.PropagateTo)
+
+	now := time.Now()
+	secret.CreatedAt = now
+	secret.UpdatedAt = now
+	return s.add(secret, value)
+}
+
+// add stores a new secret unless its name holds one that has not expired.
+func (s *Store) add(secret types.Secret, value string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -198,22 +343,85 @@ func (s *Store) Add(name, value, rotateVia string) error {
 	}
 
 	// Check if secret already exists
-	if _, exists := s.secrets[name]; exists {
-		return types.NewSecretError(name, types.ErrSecretExists)
+	previous, exists := s.secrets[secret.Name]
+	if exists && !isExpired(previous, time.Now()) {
+		return types.NewSecretError(secret.Name, types.ErrSecretExists)
+	}
+
+	s.secrets[secret.Name] = &secretWithValue{Secret: secret, Value: value}
+
+	if err := s.saveWithinLimits(1); err != nil {
+		// Keep memory in line with the file on disk
+		if exists {
+			s.secrets[secret.Name] = previous
+		} else {
+			delete(s.secrets, secret.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// BulkAdd adds each variable in vars as a secret named by the variable in
+// namespace (see types.JoinRef), all with the same rotation hook, and saves
+// once. Names that already hold a secret are skipped rather than failing
+// the import. added and skipped are the sorted secret references.
+func (s *Store) BulkAdd(vars map[string]string, namespace, rotateVia string) (added, skipped []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return nil, nil, types.ErrStoreNotInitialized
+	}
+	if strings.Contains(namespace, types.NamespaceSeparator) {
+		return nil, nil, fmt.Errorf("invalid namespace %q", namespace)
 	}
 
+	keys := make([]string, 0, len(vars))
+	for key := range vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	now := time.Now()
-	s.secrets[name] = &secretWithValue{
-		Secret: types.Secret{
-			Name:      name,
-			CreatedAt: now,
-			UpdatedAt: now,
-			RotateVia: rotateVia,
-		},
-		Value: value,
+	replaced := make(map[string]*secretWithValue)
+	for _, key := range keys {
+		ref := types.JoinRef(namespace, key)
+		existing, exists := s.secrets[ref]
+		if exists && !isExpired(existing, now) {
+			skipped = append(skipped, ref)
+			continue
+		}
+		if exists {
+			replaced[ref] = existing
+		}
+		s.secrets[ref] = &secretWithValue{
+			Secret: types.Secret{
+				Name:      ref,
+				CreatedAt: now,
+				UpdatedAt: now,
+				RotateVia: rotateVia,
+			},
+			Value: vars[key],
+		}
+		added = append(added, ref)
+	}
+	if len(added) == 0 {
+		return added, skipped, nil
 	}
 
-	return s.saveUnlocked()
+	if err := s.saveWithinLimits(len(added)); err != nil {
+		// Keep memory in line with the file on disk
+		for _, ref := range added {
+			if previous, ok := replaced[ref]; ok {
+				s.secrets[ref] = previous
+			} else {
+				delete(s.secrets, ref)
+			}
+		}
+		return nil, nil, err
+	}
+	return added, skipped, nil
 }
 
 // Get returns the decrypted value of a secret.
@@ -226,13 +434,30 @@ func (s *Store) Get(name string) (string, error) {
 	}
 
 	secret, exists := s.secrets[name]
-	if !exists {
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
 		return "", types.NewSecretError(name, types.ErrSecretNotFound)
 	}
 
 	return secret.Value, nil
 }
 
+// GetMetadata returns a secret's metadata, without its value.
+func (s *Store) GetMetadata(name string) (types.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return types.Secret{}, types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[name]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return types.Secret{}, types.NewSecretError(name, types.ErrSecretNotFound)
+	}
+
+	return secret.Secret, nil
+}
+
 // Delete removes a secret from the store.
 func (s *Store) Delete(name string) error {
 	s.mu.Lock()
@@ -250,6 +475,52 @@ func (s *Store) Delete(name string) error {
 	return s.saveUnlocked()
 }
 
+// Rename moves a secret to a new reference, which may be in another
+// namespace. The value, rotation config, and timestamps are kept; only
+// UpdatedAt changes.
+func (s *Store) Rename(oldRef, newRef string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	if _, name := types.SplitRef(newRef); name == "" {
+		return fmt.Errorf("invalid secret name %q", newRef)
+	}
+
+	// Expired secrets read as missing, so one may be renamed onto but not
+	// renamed itself
+	now := time.Now()
+	secret, exists := s.secrets[oldRef]
+	if !exists || secret == nil || isExpired(secret, now) {
+		return types.NewSecretError(oldRef, types.ErrSecretNotFound)
+	}
+	replaced, exists := s.secrets[newRef]
+	if exists && replaced != nil && !isExpired(replaced, now) {
+		return types.NewSecretError(newRef, types.ErrSecretExists)
+	}
+
+	previous := *secret
+	delete(s.secrets, oldRef)
+	secret.Name = newRef
+	secret.UpdatedAt = now
+	s.secrets[newRef] = secret
+
+	if err := s.saveUnlocked(); err != nil {
+		// Keep memory in line with the file on disk
+		delete(s.secrets, newRef)
+		if exists {
+			s.secrets[newRef] = replaced
+		}
+		*secret = previous
+		s.secrets[oldRef] = secret
+		return err
+	}
+	return nil
+}
+
 // List returns metadata for all secrets (without values).
 func (s *Store) List() ([]types.Secret, error) {
 	s.mu.RLock()
@@ -259,16 +530,55 @@ func (s *Store) List() ([]types.Secret, error) {
 		return nil, types.ErrStoreNotInitialized
 	}
 
+	now := time.Now()
 	secrets := make([]types.Secret, 0, len(s.secrets))
 	for _, secret := range s.secrets {
-		secrets = append(secrets, secret.Secret)
+		if secret != nil && !isExpired(secret, now) {
+			secrets = append(secrets, secret.Secret)
+		}
 	}
 
 	return secrets, nil
 }
 
+// ListWithoutRotation returns metadata for secrets that have no rotation
+// hook configured, sorted by name.
+func (s *Store) ListWithoutRotation() ([]types.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	now := time.Now()
+	secrets := make([]types.Secret, 0)
+	for _, secret := range s.secrets {
+		if secret != nil && secret.RotateVia == "" && !isExpired(secret, now) {
+			secrets = append(secrets, secret.Secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	return secrets, nil
+}
+
 // Update updates an existing secret's value and optionally its rotation config.
 func (s *Store) Update(name, value string, rotateVia *string) error {
+	return s.update(name, value, rotateVia, nil)
+}
+
+// UpdateWithExpiry updates a secret like Update and also sets when it
+// expires. A zero expiresAt removes the expiry.
+func (s *Store) UpdateWithExpiry(name, value string, rotateVia *string, expiresAt time.Time) error {
+	return s.update(name, value, rotateVia, &expiresAt)
+}
+
+// update implements Update and UpdateWithExpiry, leaving the expiry
+// unchanged if expiresAt is nil.
+func (s *Store) update(name, value string, rotateVia *string, expiresAt *time.Time) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -277,20 +587,185 @@ func (s *Store) Update(name, value string, rotateVia *string) error {
 	}
 
 	secret, exists := s.secrets[name]
-	if !exists {
+	if !exists || secret == nil {
 		return types.NewSecretError(name, types.ErrSecretNotFound)
 	}
 
+	previous := *secret
+	if value != secret.Value {
+		secret.pushHistory(s.cfg.HistoryLimit)
+	}
 	secret.Value = value
 	secret.UpdatedAt = time.Now()
 
 	if rotateVia != nil {
 		secret.RotateVia = *rotateVia
 	}
+	if expiresAt != nil {
+		secret.ExpiresAt = *expiresAt
+	}
+
+	if err := s.saveWithinLimits(0); err != nil {
+		// Keep memory in line with the file on disk
+		*secret = previous
+		return err
+	}
+	return nil
+}
+
+// SetPropagation replaces the env files that a secret is propagated to
+// after rotation. An empty list disables propagation.
+func (s *Store) SetPropagation(name string, targets []types.EnvTarget) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[name]
+	if !exists || secret == nil {
+		return types.NewSecretError(name, types.ErrSecretNotFound)
+	}
+
+	secret.PropagateTo = targets
+	secret.UpdatedAt = time.Now()
+
+	return s.saveUnlocked()
+}
+
+// SetLeaseHooks sets the commands that mint a fresh value for each lease of
+// a secret and revoke it when the lease ends (see types.Secret.GenerateVia).
+// An empty generateVia serves the stored value again.
+func (s *Store) SetLeaseHooks(name, generateVia, revokeVia string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[name]
+	if !exists || secret == nil {
+		return types.NewSecretError(name, types.ErrSecretNotFound)
+	}
+
+	secret.GenerateVia = generateVia
+	secret.RevokeVia = revokeVia
+	secret.UpdatedAt = time.Now()
+
+	return s.saveUnlocked()
+}
+
+// SetRotationPolicy schedules automatic rotation of a secret through its
+// RotateVia hook. A nil policy turns scheduled rotation off.
+func (s *Store) SetRotationPolicy(name string, policy *types.RotationPolicy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[name]
+	if !exists || secret == nil || isExpired(secret, time.Now()) {
+		return types.NewSecretError(name, types.ErrSecretNotFound)
+	}
+
+	if policy != nil {
+		if err := checkRotationPolicy(name, secret.RotateVia, policy); err != nil {
+			return err
+		}
+		// Never share the caller's policy; readers hold the stored pointer
+		copied := *policy
+		policy = &copied
+	}
+
+	previous := secret.RotationPolicy
+	secret.RotationPolicy = policy
+	if err := s.saveUnlocked(); err != nil {
+		// Keep memory in line with the file on disk
+		secret.RotationPolicy = previous
+		return err
+	}
+	return nil
+}
+
+// checkRotationPolicy validates policy for the secret name with the given
+// rotation hook.
+func checkRotationPolicy(name, rotateVia string, policy *types.RotationPolicy) error {
+	if rotateVia == "" {
+		return types.NewSecretError(name, types.ErrNoRotationHook)
+	}
+	if policy.Interval <= 0 {
+		return fmt.Errorf("rotation interval must be positive, got %v", policy.Interval)
+	}
+	if policy.MaxAge != 0 && policy.MaxAge < policy.Interval {
+		return fmt.Errorf("rotation max age %v is shorter than the interval %v", policy.MaxAge, policy.Interval)
+	}
+	return nil
+}
+
+// SetLabels replaces a secret's labels. An empty map removes them.
+func (s *Store) SetLabels(name string, labels map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return types.ErrStoreNotInitialized
+	}
+
+	secret, exists := s.secrets[name]
+	if !exists || secret == nil {
+		return types.NewSecretError(name, types.ErrSecretNotFound)
+	}
+
+	if len(labels) == 0 {
+		secret.Labels = nil
+	} else {
+		secret.Labels = maps.Clone(labels)
+	}
+	secret.UpdatedAt = time.Now()
 
 	return s.saveUnlocked()
 }
 
+// ListByLabel returns metadata for secrets carrying every key/value pair in
+// selector, sorted by name. Secrets without labels never match a non-empty
+// selector; an empty selector matches all secrets.
+func (s *Store) ListByLabel(selector map[string]string) ([]types.Secret, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.identity == nil {
+		return nil, types.ErrStoreNotInitialized
+	}
+
+	now := time.Now()
+	secrets := make([]types.Secret, 0)
+	for _, secret := range s.secrets {
+		if secret == nil || isExpired(secret, now) || !matchLabels(secret.Labels, selector) {
+			continue
+		}
+		secrets = append(secrets, secret.Secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+
+	return secrets, nil
+}
+
+// matchLabels reports whether labels has every key/value pair in selector.
+func matchLabels(labels, selector map[string]string) bool {
+	for key, want := range selector {
+		if got, ok := labels[key]; !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
 // MarkRotated updates the last rotated timestamp for a secret.
 func (s *Store) MarkRotated(name string) error {
 	s.mu.Lock()
@@ -301,7 +776,7 @@ func (s *Store) MarkRotated(name string) error {
 	}
 
 	secret, exists := s.secrets[name]
-	if !exists {
+	if !exists || secret == nil {
 		return types.NewSecretError(name, types.ErrSecretNotFound)
 	}
 
@@ -324,3 +799,75 @@ func (s *Store) WipeAll() error {
 	s.secrets = make(map[string]*secretWithValue)
 	return s.saveUnlocked()
 }
+
+// Compact removes entries that no longer hold a secret, drops history beyond
+// the configured limit (e.g. after it was lowered), and rewrites the
+// encrypted file, returning the number of entries removed. Current values
+// are preserved unchanged.
+func (s *Store) Compact() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return 0, types.ErrStoreNotInitialized
+	}
+
+	reclaimed := 0
+	for name, secret := range s.secrets {
+		// A null entry in the file decodes to nil and carries no secret
+		if secret == nil {
+			delete(s.secrets, name)
+			reclaimed++
+			continue
+		}
+		reclaimed += secret.trimHistory(s.cfg.HistoryLimit)
+	}
+
+	if err := s.saveUnlocked(); err != nil {
+		return 0, err
+	}
+	return reclaimed, nil
+}
+
+// PurgeExpired deletes secrets whose expiry has passed, recording each to
+// the audit log if one is set, and returns the number deleted.
+func (s *Store) PurgeExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.identity == nil {
+		return 0
+	}
+
+	now := time.Now()
+	var purged []string
+	for name, secret := range s.secrets {
+		if isExpired(secret, now) {
+			delete(s.secrets, name)
+			purged = append(purged, name)
+		}
+	}
+	if len(purged) == 0 {
+		return 0
+	}
+	sort.Strings(purged)
+
+	// Purged secrets stay gone from memory even if the save fails; they are
+	// hidden anyway, and the next purge after a reload retries.
+	err := s.saveUnlocked()
+	if s.auditLogger != nil {
+		for _, name := range purged {
+			builder := audit.NewEntry(types.ActionSecretExpire, err == nil).WithSecret(name)
+			if err != nil {
+				builder = builder.WithDetails(fmt.Sprintf("failed to save store: %v", err))
+			}
+			_ = s.auditLogger.Log(builder.Build())
+		}
+	}
+	return len(purged)
+}
+
+// isExpired reports whether secret has an expiry at or before now.
+func isExpired(secret *secretWithValue, now time.Time) bool {
+	return secret != nil && !secret.ExpiresAt.IsZero() && !now.Before(secret.ExpiresAt)
+}
@@ -1,11 +1,15 @@
 package store
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/audit"
 	"github.com/joelhooks/agent-secrets/internal/config"
 	"github.com/joelhooks/agent-secrets/internal/types"
 )
@@ -62,7 +66,7 @@ func TestStore_Init_ExistingIdentity(t *testing.T) {
 	}
 
 	// Verify same identity is used
-	if store.identity.Recipient().String() != identity.Recipient().String() {
+	if store.identity.(*age.X25519Identity).Recipient().String() != identity.Recipient().String() {
 		t.Error("different identity loaded")
 	}
 }
@@ -115,6 +119,63 @@ func TestStore_Add_Duplicate(t *testing.T) {
 	}
 }
 
+func TestStore_AddSecret(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := map[string]string{"env": "prod"}
+	policy := &types.RotationPolicy{Interval: 24 * time.Hour}
+	err := store.AddSecret(types.Secret{
+		Name:           "db_creds",
+		RotateVia:      "rotate.sh",
+		PropagateTo:    []types.EnvTarget{{Path: "/tmp/.env.local", Var: "DB"}},
+		Labels:         labels,
+		GenerateVia:    "mint.sh",
+		RevokeVia:      "revoke.sh",
+		RotationPolicy: policy,
+	}, "secret123")
+	if err != nil {
+		t.Fatalf("AddSecret failed: %v", err)
+	}
+	labels["env"] = "dev"
+	policy.Interval = time.Minute
+
+	// Everything lands in the one save
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	meta, err := reloaded.GetMetadata("db_creds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.RotateVia != "rotate.sh" || meta.GenerateVia != "mint.sh" || meta.RevokeVia != "revoke.sh" {
+		t.Errorf("unexpected hooks: %+v", meta)
+	}
+	if len(meta.PropagateTo) != 1 || meta.Labels["env"] != "prod" {
+		t.Errorf("unexpected propagation or labels: %+v", meta)
+	}
+	if meta.RotationPolicy == nil || meta.RotationPolicy.Interval != 24*time.Hour {
+		t.Errorf("RotationPolicy = %+v, want a 24h interval", meta.RotationPolicy)
+	}
+	if meta.CreatedAt.IsZero() {
+		t.Error("CreatedAt not set")
+	}
+
+	// An invalid policy stores nothing
+	err = store.AddSecret(types.Secret{Name: "nohook", RotationPolicy: &types.RotationPolicy{Interval: time.Hour}}, "v")
+	if !errors.Is(err, types.ErrNoRotationHook) {
+		t.Errorf("expected ErrNoRotationHook, got %v", err)
+	}
+	if _, err := store.Get("nohook"); err == nil {
+		t.Error("secret with an invalid policy was stored")
+	}
+}
+
 func TestStore_Get_NotFound(t *testing.T) {
 	cfg := testConfig(t)
 	store := New(cfg)
@@ -172,6 +233,131 @@ func TestStore_Delete_NotFound(t *testing.T) {
 	}
 }
 
+func TestStore_Rename(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("db_password", "secret123", "./rotate.sh"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MarkRotated("db_password"); err != nil {
+		t.Fatal(err)
+	}
+	targets := []types.EnvTarget{{Path: "/app/.env.local", Var: "DB_PASSWORD"}}
+	if err := store.SetPropagation("db_password", targets); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := store.List()
+	before := list[0]
+
+	// Move into a namespace, then between namespaces
+	refs := []string{"db_password", types.JoinRef("prod", "db_password"), types.JoinRef("stage", "db")}
+	for i := 1; i < len(refs); i++ {
+		if err := store.Rename(refs[i-1], refs[i]); err != nil {
+			t.Fatalf("Rename(%q, %q) failed: %v", refs[i-1], refs[i], err)
+		}
+	}
+
+	// Reload to check what was written
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range refs[:len(refs)-1] {
+		if _, err := reloaded.Get(ref); err == nil {
+			t.Errorf("old reference %q still resolves", ref)
+		}
+	}
+	value, err := reloaded.Get(types.JoinRef("stage", "db"))
+	if err != nil || value != "secret123" {
+		t.Fatalf("renamed secret = %q, %v", value, err)
+	}
+
+	list, _ = reloaded.List()
+	if len(list) != 1 {
+		t.Fatalf("expected 1 secret, got %d", len(list))
+	}
+	after := list[0]
+	if after.Name != "stage::db" {
+		t.Errorf("Name = %q, want stage::db", after.Name)
+	}
+	if !after.CreatedAt.Equal(before.CreatedAt) || !after.LastRotated.Equal(before.LastRotated) {
+		t.Errorf("timestamps not preserved: before %+v, after %+v", before, after)
+	}
+	if after.RotateVia != "./rotate.sh" || len(after.PropagateTo) != 1 || after.PropagateTo[0] != targets[0] {
+		t.Errorf("rotation config not preserved: %+v", after)
+	}
+	if !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Errorf("UpdatedAt not advanced: before %v, after %v", before.UpdatedAt, after.UpdatedAt)
+	}
+}
+
+func TestStore_Rename_Conflicts(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"api_key", "prod::api_key"} {
+		if err := store.Add(name, "value-"+name, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var secretErr *types.SecretError
+	err := store.Rename("api_key", "prod::api_key")
+	if !isSecretError(err, &secretErr) || secretErr.Err != types.ErrSecretExists {
+		t.Errorf("rename onto existing secret: got %v, want ErrSecretExists", err)
+	}
+	err = store.Rename("missing", "other")
+	if !isSecretError(err, &secretErr) || secretErr.Err != types.ErrSecretNotFound {
+		t.Errorf("rename of missing secret: got %v, want ErrSecretNotFound", err)
+	}
+	if err := store.Rename("api_key", "prod::"); err == nil {
+		t.Error("expected error renaming to an empty name")
+	}
+
+	// Both secrets are untouched
+	for _, name := range []string{"api_key", "prod::api_key"} {
+		if value, err := store.Get(name); err != nil || value != "value-"+name {
+			t.Errorf("%s = %q, %v after failed renames", name, value, err)
+		}
+	}
+}
+
+func TestStore_Rename_Expired(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"api_key", "old_token"} {
+		if err := store.Add(name, "value-"+name, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.secrets["old_token"].ExpiresAt = time.Now().Add(-time.Minute)
+
+	// An expired secret cannot be renamed, as if it were not there
+	var secretErr *types.SecretError
+	err := store.Rename("old_token", "new_token")
+	if !isSecretError(err, &secretErr) || secretErr.Err != types.ErrSecretNotFound {
+		t.Errorf("rename of expired secret: got %v, want ErrSecretNotFound", err)
+	}
+	if _, err := store.GetMetadata("new_token"); err == nil {
+		t.Error("expired secret was renamed")
+	}
+
+	// Nor does it block a rename onto its name
+	if err := store.Rename("api_key", "old_token"); err != nil {
+		t.Fatalf("rename onto expired secret failed: %v", err)
+	}
+	if value, err := store.Get("old_token"); err != nil || value != "value-api_key" {
+		t.Errorf("old_token = %q, %v, want the renamed value", value, err)
+	}
+}
+
 func TestStore_List(t *testing.T) {
 	cfg := testConfig(t)
 	store := New(cfg)
@@ -240,6 +426,216 @@ func TestStore_List_Empty(t *testing.T) {
 	}
 }
 
+func TestStore_ListWithoutRotation(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mix of secrets with and without rotation hooks
+	secrets := []struct {
+		name      string
+		rotateVia string
+	}{
+		{"github_token", "gh auth refresh"},
+		{"db_password", ""},
+		{"stripe_key", "./rotate-stripe.sh"},
+		{"api_key", ""},
+		{"prod::deploy_key", ""},
+	}
+
+	for _, s := range secrets {
+		if err := store.Add(s.name, "value", s.rotateVia); err != nil {
+			t.Fatalf("Add(%q) failed: %v", s.name, err)
+		}
+	}
+
+	list, err := store.ListWithoutRotation()
+	if err != nil {
+		t.Fatalf("ListWithoutRotation failed: %v", err)
+	}
+
+	want := []string{"api_key", "db_password", "prod::deploy_key"}
+	if len(list) != len(want) {
+		t.Fatalf("expected %d secrets, got %d: %+v", len(want), len(list), list)
+	}
+	for i, name := range want {
+		if list[i].Name != name {
+			t.Errorf("list[%d] = %q, want %q", i, list[i].Name, name)
+		}
+		if list[i].RotateVia != "" {
+			t.Errorf("secret %q has rotation hook %q", list[i].Name, list[i].RotateVia)
+		}
+	}
+
+	// A hook added later removes the secret from the list
+	hook := "./rotate-db.sh"
+	if err := store.Update("db_password", "value", &hook); err != nil {
+		t.Fatal(err)
+	}
+	list, err = store.ListWithoutRotation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("expected 2 secrets after adding a hook, got %d", len(list))
+	}
+}
+
+func TestStore_ListWithoutRotation_NotInitialized(t *testing.T) {
+	store := New(testConfig(t))
+
+	if _, err := store.ListWithoutRotation(); err == nil {
+		t.Fatal("expected error on uninitialized store")
+	}
+}
+
+func TestStore_ListByLabel(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	labeled := map[string]map[string]string{
+		"prod_billing": {"env": "prod", "team": "billing"},
+		"prod_search":  {"env": "prod", "team": "search"},
+		"dev_billing":  {"env": "dev", "team": "billing"},
+	}
+	for name, labels := range labeled {
+		if err := store.Add(name, "v", ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SetLabels(name, labels); err != nil {
+			t.Fatalf("SetLabels(%s) failed: %v", name, err)
+		}
+	}
+	if err := store.Add("unlabeled", "v", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Labels persist across a reload
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector map[string]string
+		want     []string
+	}{
+		{map[string]string{"env": "prod"}, []string{"prod_billing", "prod_search"}},
+		{map[string]string{"env": "prod", "team": "billing"}, []string{"prod_billing"}},
+		{map[string]string{"team": "billing"}, []string{"dev_billing", "prod_billing"}},
+		{map[string]string{"env": "staging"}, nil},
+		{map[string]string{"owner": ""}, nil},
+	}
+	for _, tt := range tests {
+		secrets, err := reloaded.ListByLabel(tt.selector)
+		if err != nil {
+			t.Fatalf("ListByLabel(%v) failed: %v", tt.selector, err)
+		}
+		var names []string
+		for _, s := range secrets {
+			names = append(names, s.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("ListByLabel(%v) = %v, want %v", tt.selector, names, tt.want)
+		}
+	}
+
+	// Clearing labels drops the secret from selective queries
+	if err := reloaded.SetLabels("prod_search", nil); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := reloaded.ListByLabel(map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "prod_billing" {
+		t.Errorf("after clearing labels, ListByLabel = %+v", secrets)
+	}
+
+	if err := reloaded.SetLabels("missing", map[string]string{"env": "prod"}); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("SetLabels(missing) error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestStore_SetRotationPolicy(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("rotated", "v", "echo rotated"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("static", "v", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &types.RotationPolicy{Interval: 720 * time.Hour, MaxAge: 1080 * time.Hour}
+	if err := store.SetRotationPolicy("rotated", policy); err != nil {
+		t.Fatalf("SetRotationPolicy failed: %v", err)
+	}
+	// The store keeps its own copy
+	policy.Interval = time.Minute
+
+	// Policies persist across a reload
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	secrets, err := reloaded.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range secrets {
+		if s.Name != "rotated" {
+			continue
+		}
+		if s.RotationPolicy == nil || s.RotationPolicy.Interval != 720*time.Hour || s.RotationPolicy.MaxAge != 1080*time.Hour {
+			t.Errorf("RotationPolicy = %+v, want interval 720h and max age 1080h", s.RotationPolicy)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		policy *types.RotationPolicy
+		want   error
+	}{
+		{"no hook", "static", &types.RotationPolicy{Interval: time.Hour}, types.ErrNoRotationHook},
+		{"missing", "missing", &types.RotationPolicy{Interval: time.Hour}, types.ErrSecretNotFound},
+		{"zero interval", "rotated", &types.RotationPolicy{}, nil},
+		{"max age below interval", "rotated", &types.RotationPolicy{Interval: time.Hour, MaxAge: time.Minute}, nil},
+	}
+	for _, tt := range tests {
+		err := reloaded.SetRotationPolicy(tt.secret, tt.policy)
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		} else if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// A nil policy turns scheduling off
+	if err := reloaded.SetRotationPolicy("rotated", nil); err != nil {
+		t.Fatalf("clearing policy failed: %v", err)
+	}
+	secrets, err = reloaded.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range secrets {
+		if s.RotationPolicy != nil {
+			t.Errorf("%s: expected no policy, got %+v", s.Name, s.RotationPolicy)
+		}
+	}
+}
+
 func TestStore_Update(t *testing.T) {
 	cfg := testConfig(t)
 	store := New(cfg)
@@ -456,6 +852,61 @@ func TestStore_LoadSave(t *testing.T) {
 	}
 }
 
+func TestStore_ProfilesIndependent(t *testing.T) {
+	t.Setenv(config.EnvDir, t.TempDir())
+
+	work, err := config.LoadProfile("work")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+	personal, err := config.LoadProfile("personal")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+
+	workStore := New(work)
+	personalStore := New(personal)
+	if err := workStore.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := personalStore.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := workStore.Add("api_key", "work-value", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := personalStore.Add("api_key", "personal-value", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each profile reloads only its own secrets, under its own identity
+	for cfg, want := range map[*config.Config]string{work: "work-value", personal: "personal-value"} {
+		st := New(cfg)
+		if err := st.Load(); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		got, err := st.Get("api_key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("profile %s: expected %q, got %q", cfg.Directory, want, got)
+		}
+	}
+
+	if err := workStore.WipeAll(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := New(personal)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if list, _ := reloaded.List(); len(list) != 1 {
+		t.Errorf("expected wiping one profile to leave the other alone, got %d secrets", len(list))
+	}
+}
+
 func TestStore_Load_EmptyFile(t *testing.T) {
 	cfg := testConfig(t)
 
@@ -511,6 +962,263 @@ func TestStore_Load_NoSecretsFile(t *testing.T) {
 	}
 }
 
+func TestStore_Compact(t *testing.T) {
+	cfg := testConfig(t)
+	store1 := New(cfg)
+
+	if err := store1.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"api_key", "db_password", "old_token", "stale_key"} {
+		if err := store1.Add(name, name+"-v1", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store1.Update("api_key", "api_key-v2", nil); err != nil {
+		t.Fatal(err)
+	}
+	rotateVia := "rotate.sh"
+	if err := store1.Update("db_password", "db_password-v2", &rotateVia); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.Delete("old_token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.Delete("stale_key"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries written as null hold no secret
+	store1.secrets["orphan"] = nil
+	if err := store1.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	store2 := New(cfg)
+	if err := store2.Load(); err != nil {
+		t.Fatal(err)
+	}
+	reclaimed, err := store2.Compact()
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if reclaimed != 1 {
+		t.Errorf("expected 1 entry reclaimed, got %d", reclaimed)
+	}
+
+	// Compaction is persisted and live secrets are intact
+	store3 := New(cfg)
+	if err := store3.Load(); err != nil {
+		t.Fatal(err)
+	}
+	list, err := store3.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 secrets, got %d", len(list))
+	}
+
+	want := map[string]string{"api_key": "api_key-v2", "db_password": "db_password-v2"}
+	for name, value := range want {
+		got, err := store3.Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != value {
+			t.Errorf("%s: expected %q, got %q", name, value, got)
+		}
+	}
+	for _, s := range list {
+		if s.Name == "db_password" && s.RotateVia != rotateVia {
+			t.Errorf("expected RotateVia %q, got %q", rotateVia, s.RotateVia)
+		}
+	}
+
+	// Compacting again is a no-op
+	if reclaimed, err := store3.Compact(); err != nil || reclaimed != 0 {
+		t.Errorf("second Compact = %d, %v; want 0, nil", reclaimed, err)
+	}
+}
+
+func TestStore_NullEntries(t *testing.T) {
+	cfg := testConfig(t)
+	store1 := New(cfg)
+
+	if err := store1.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store1.Add("api_key", "api_key-v1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file with an entry written as null loads, and the entry reads as
+	// missing everywhere instead of panicking
+	store1.secrets["orphan"] = nil
+	if err := store1.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	store2 := New(cfg)
+	if err := store2.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := store2.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 1 || list[0].Name != "api_key" {
+		t.Errorf("List = %+v, want only api_key", list)
+	}
+	unrotated, err := store2.ListWithoutRotation()
+	if err != nil {
+		t.Fatalf("ListWithoutRotation failed: %v", err)
+	}
+	if len(unrotated) != 1 || unrotated[0].Name != "api_key" {
+		t.Errorf("ListWithoutRotation = %+v, want only api_key", unrotated)
+	}
+
+	if _, err := store2.Get("orphan"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("Get error = %v, want ErrSecretNotFound", err)
+	}
+	if err := store2.Update("orphan", "value", nil); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("Update error = %v, want ErrSecretNotFound", err)
+	}
+	if err := store2.SetLabels("orphan", map[string]string{"team": "ops"}); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("SetLabels error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestStore_Compact_NotInitialized(t *testing.T) {
+	store := New(testConfig(t))
+	if _, err := store.Compact(); err != types.ErrStoreNotInitialized {
+		t.Errorf("expected ErrStoreNotInitialized, got %v", err)
+	}
+}
+
+func TestStore_Expiry_HiddenBeforePurge(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if err := store.AddWithExpiry("old_key", "stale", "", past); err != nil {
+		t.Fatalf("AddWithExpiry failed: %v", err)
+	}
+	if err := store.AddWithExpiry("live_key", "fresh", "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get("old_key"); !errors.Is(err, types.ErrSecretNotFound) {
+		t.Errorf("Get(expired) error = %v, want ErrSecretNotFound", err)
+	}
+	if value, err := store.Get("live_key"); err != nil || value != "fresh" {
+		t.Errorf("Get(live) = %q, %v", value, err)
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "live_key" {
+		t.Errorf("List = %+v, want only live_key", list)
+	}
+	without, err := store.ListWithoutRotation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(without) != 1 {
+		t.Errorf("ListWithoutRotation returned %d secrets, want 1", len(without))
+	}
+
+	// The expired name can be reused before the purge runs
+	if err := store.Add("old_key", "replacement", ""); err != nil {
+		t.Fatalf("Add over expired secret failed: %v", err)
+	}
+	if value, err := store.Get("old_key"); err != nil || value != "replacement" {
+		t.Errorf("Get(replaced) = %q, %v", value, err)
+	}
+}
+
+func TestStore_PurgeExpired(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	logger, err := audit.New(filepath.Join(cfg.Directory, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	store.SetAuditLogger(logger)
+
+	past := time.Now().Add(-time.Second)
+	for _, name := range []string{"temp_a", "temp_b"} {
+		if err := store.AddWithExpiry(name, "v", "", past); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Add("permanent", "v", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddWithExpiry("later", "v", "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if purged := store.PurgeExpired(); purged != 2 {
+		t.Errorf("PurgeExpired = %d, want 2", purged)
+	}
+	if purged := store.PurgeExpired(); purged != 0 {
+		t.Errorf("second PurgeExpired = %d, want 0", purged)
+	}
+
+	// The purge is persisted
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.secrets) != 2 || reloaded.secrets["permanent"] == nil || reloaded.secrets["later"] == nil {
+		t.Errorf("after purge, stored secrets = %v", reloaded.secrets)
+	}
+
+	action := types.ActionSecretExpire
+	entries, err := logger.Query(audit.QueryFilter{Action: &action})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].SecretName != "temp_a" || entries[1].SecretName != "temp_b" || !entries[0].Success {
+		t.Errorf("audit entries = %+v, want temp_a and temp_b", entries)
+	}
+}
+
+func TestStore_UpdateWithExpiry(t *testing.T) {
+	store := New(testConfig(t))
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddWithExpiry("token", "v1", "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update keeps the expiry; UpdateWithExpiry with zero clears it
+	if err := store.Update("token", "v2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if store.secrets["token"].ExpiresAt.IsZero() {
+		t.Error("Update cleared the expiry")
+	}
+	if err := store.UpdateWithExpiry("token", "v3", nil, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if !store.secrets["token"].ExpiresAt.IsZero() {
+		t.Error("UpdateWithExpiry(zero) kept the expiry")
+	}
+}
+
 func TestStore_NotInitialized(t *testing.T) {
 	cfg := testConfig(t)
 	store := New(cfg)
@@ -584,3 +1292,58 @@ func isSecretError(err error, target **types.SecretError) bool {
 	}
 	return ok
 }
+
+func TestStore_BulkAdd(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(types.JoinRef("prod", "API_KEY"), "existing", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	added, skipped, err := store.BulkAdd(map[string]string{
+		"API_KEY":      "imported",
+		"DATABASE_URL": "postgres://localhost/db",
+		"APP.DB.URL":   "postgres://localhost/other",
+	}, "prod", "rotate.sh")
+	if err != nil {
+		t.Fatalf("BulkAdd failed: %v", err)
+	}
+
+	wantAdded := []string{"prod::APP.DB.URL", "prod::DATABASE_URL"}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("added = %v, want %v", added, wantAdded)
+	}
+	if want := []string{"prod::API_KEY"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %v, want %v", skipped, want)
+	}
+
+	// Added secrets are saved; skipped ones keep their value
+	reloaded := New(cfg)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := reloaded.Get("prod::DATABASE_URL"); err != nil || value != "postgres://localhost/db" {
+		t.Errorf("Get(prod::DATABASE_URL) = %q, %v", value, err)
+	}
+	if value, err := reloaded.Get("prod::API_KEY"); err != nil || value != "existing" {
+		t.Errorf("skipped secret was overwritten: %q, %v", value, err)
+	}
+	list, err := reloaded.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range list {
+		if secret.Name == "prod::DATABASE_URL" && secret.RotateVia != "rotate.sh" {
+			t.Errorf("RotateVia = %q, want rotate.sh", secret.RotateVia)
+		}
+	}
+
+	// Importing the same file again adds nothing
+	added, skipped, err = reloaded.BulkAdd(map[string]string{"DATABASE_URL": "other"}, "prod", "")
+	if err != nil || len(added) != 0 || len(skipped) != 1 {
+		t.Errorf("second BulkAdd = %v, %v, %v", added, skipped, err)
+	}
+}
@@ -0,0 +1,179 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+// storeVersion is the secrets file format version written by Save.
+const storeVersion = 1
+
+// StoreInfo summarizes an encrypted secrets file checked by VerifyFile.
+type StoreInfo struct {
+	Version int `json:"version"`
+	Secrets int `json:"secrets"`
+	// Namespaces counts the namespaces holding at least one secret.
+	Namespaces int `json:"namespaces"`
+	// Stale counts null entries, which Compact would remove.
+	Stale int `json:"stale"`
+	// Backup is the manifest when the file is a backup written by
+	// ExportBackup rather than a secrets file.
+	Backup *BackupManifest `json:"backup,omitempty"`
+}
+
+// IntegrityError lists the structural problems found in a decryptable
+// secrets file. It wraps types.ErrStoreCorrupted.
+type IntegrityError struct {
+	Problems []string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("%v: %s", types.ErrStoreCorrupted, strings.Join(e.Problems, "; "))
+}
+
+func (e *IntegrityError) Unwrap() error {
+	return types.ErrStoreCorrupted
+}
+
+// Verify checks the store's secrets file on disk with VerifyFile, using the
+// loaded identity or, before Load, the configured identity file. A missing
+// or empty secrets file is an empty store, as in Load. Nothing is modified.
+func (s *Store) Verify() error {
+	s.mu.RLock()
+	identity := s.identity
+	s.mu.RUnlock()
+
+	if identity == nil {
+		var err error
+		if identity, _, err = LoadIdentityFile(s.cfg.IdentityPath); err != nil {
+			return fmt.Errorf("failed to load identity: %w", err)
+		}
+	}
+
+	if info, err := os.Stat(s.cfg.SecretsPath); os.IsNotExist(err) || (err == nil && info.Size() == 0) {
+		return nil
+	}
+	_, err := VerifyFile(s.cfg.SecretsPath, identity)
+	return err
+}
+
+// VerifyFile decrypts the secrets file or backup at path with identity and
+// checks that its contents are a well-formed store, without loading it into a
+// Store or modifying anything. Failures wrap types.ErrStoreCorrupted or, if
+// the file cannot be decrypted, types.ErrDecryptionFailed. When the file
+// parses but has structural problems, the error is an *IntegrityError
+// listing all of them and the returned info is still filled in.
+func VerifyFile(path string, identity age.Identity) (*StoreInfo, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	info := &StoreInfo{}
+	ciphertext := raw
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '{' {
+		var backup backupFile
+		if err := json.Unmarshal(raw, &backup); err != nil {
+			return nil, fmt.Errorf("%w: invalid backup: %v", types.ErrStoreCorrupted, err)
+		}
+		info.Backup = &backup.Manifest
+		ciphertext = backup.Secrets
+	}
+
+	data, err := parseStore(ciphertext, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	info.Version = data.Version
+	namespaces := make(map[string]bool)
+	for key, secret := range data.Secrets {
+		if secret == nil {
+			info.Stale++
+			continue
+		}
+		info.Secrets++
+		ns, _ := types.SplitRef(key)
+		namespaces[ns] = true
+	}
+	info.Namespaces = len(namespaces)
+
+	if problems := checkStore(data); len(problems) > 0 {
+		return info, &IntegrityError{Problems: problems}
+	}
+	return info, nil
+}
+
+// decodeStore decrypts a secrets file and runs the integrity checks of
+// checkStore.
+func decodeStore(ciphertext []byte, identity age.Identity) (*storeData, error) {
+	data, err := parseStore(ciphertext, identity)
+	if err != nil {
+		return nil, err
+	}
+	if problems := checkStore(data); len(problems) > 0 {
+		return nil, &IntegrityError{Problems: problems}
+	}
+	return data, nil
+}
+
+// parseStore decrypts a secrets file and parses it as JSON of a known
+// version.
+func parseStore(ciphertext []byte, identity age.Identity) (*storeData, error) {
+	plaintext, err := Decrypt(ciphertext, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	var data storeData
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON: %v", types.ErrStoreCorrupted, err)
+	}
+	if data.Version < 1 || data.Version > storeVersion {
+		return nil, fmt.Errorf("%w: unsupported store version %d", types.ErrStoreCorrupted, data.Version)
+	}
+
+	if data.Secrets == nil {
+		data.Secrets = make(map[string]*secretWithValue)
+	}
+	return &data, nil
+}
+
+// checkStore returns the structural problems in parsed store data, sorted:
+// every entry must have a non-empty name matching the key it is stored
+// under, a namespaced key must name a secret within its namespace, and every
+// recipient must parse.
+func checkStore(data *storeData) []string {
+	var problems []string
+	for key, secret := range data.Secrets {
+		if secret == nil {
+			continue
+		}
+		if secret.Name == "" {
+			problems = append(problems, fmt.Sprintf("secret stored under %q has an empty name", key))
+			continue
+		}
+		if secret.Name != key {
+			problems = append(problems, fmt.Sprintf("secret %q is stored under key %q", secret.Name, key))
+			continue
+		}
+		if _, name := types.SplitRef(key); name == "" {
+			problems = append(problems, fmt.Sprintf("secret key %q has a namespace but no name", key))
+		}
+	}
+	sort.Strings(problems)
+
+	for _, r := range data.Recipients {
+		if _, err := ParseRecipient(r); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
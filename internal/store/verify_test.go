@@ -0,0 +1,205 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/joelhooks/agent-secrets/internal/types"
+)
+
+func TestVerifyFile(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"api_key", types.JoinRef("prod", "db")} {
+		if err := store.Add(name, "value-"+name, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := os.ReadFile(cfg.SecretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := VerifyFile(cfg.SecretsPath, store.identity)
+	if err != nil {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	if info.Secrets != 2 || info.Namespaces != 2 || info.Version != storeVersion || info.Backup != nil {
+		t.Errorf("info = %+v, want 2 secrets in 2 namespaces at version %d", info, storeVersion)
+	}
+
+	after, err := os.ReadFile(cfg.SecretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("VerifyFile modified the secrets file")
+	}
+
+	// A backup verifies the same way and reports its manifest
+	backupPath := filepath.Join(t.TempDir(), "backup.json")
+	var backup bytes.Buffer
+	if err := store.ExportBackup(&backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(backupPath, backup.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err = VerifyFile(backupPath, store.identity)
+	if err != nil {
+		t.Fatalf("VerifyFile on backup failed: %v", err)
+	}
+	if info.Secrets != 2 || info.Backup == nil || info.Backup.Recipient != RecipientFingerprint(store.recipient) {
+		t.Errorf("backup info = %+v", info)
+	}
+}
+
+func TestVerifyFile_Corrupt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		plaintext string
+		wantErr   error
+		reason    string
+	}{
+		{"invalid JSON", `{"version": 1, "secrets": {`, types.ErrStoreCorrupted, "invalid JSON"},
+		{"unknown version", `{"version": 7, "secrets": {}}`, types.ErrStoreCorrupted, "unsupported store version 7"},
+		{"mismatched key", `{"version": 1, "secrets": {"a": {"name": "b", "value": "x"}}}`, types.ErrStoreCorrupted, `secret "b" is stored under key "a"`},
+		{"empty name", `{"version": 1, "secrets": {"a": {"name": "", "value": "x"}}}`, types.ErrStoreCorrupted, "empty name"},
+		{"namespace without name", `{"version": 1, "secrets": {"prod::": {"name": "prod::", "value": "x"}}}`, types.ErrStoreCorrupted, "has a namespace but no name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := Encrypt([]byte(tt.plaintext), identity.Recipient())
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "secrets.age")
+			if err := os.WriteFile(path, ciphertext, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = VerifyFile(path, identity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyFile error = %v, want %v", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("VerifyFile error = %v, want reason %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestVerifyFile_ReportsAllProblems(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := `{"version": 1, "secrets": {
+		"ok": {"name": "ok", "value": "x"},
+		"prod::db": {"name": "prod::db", "value": "x"},
+		"a": {"name": "b", "value": "x"},
+		"c": {"name": "", "value": "x"},
+		"gone": null
+	}}`
+	ciphertext, err := Encrypt([]byte(plaintext), identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "secrets.age")
+	if err := os.WriteFile(path, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := VerifyFile(path, identity)
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) || !errors.Is(err, types.ErrStoreCorrupted) {
+		t.Fatalf("VerifyFile error = %v, want IntegrityError", err)
+	}
+	if len(integrityErr.Problems) != 2 {
+		t.Errorf("problems = %q, want 2", integrityErr.Problems)
+	}
+
+	// Counts are reported alongside the problems
+	if info == nil || info.Secrets != 4 || info.Namespaces != 2 || info.Stale != 1 {
+		t.Errorf("info = %+v, want 4 secrets in 2 namespaces and 1 stale", info)
+	}
+}
+
+func TestStore_Verify(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+
+	// An empty store verifies before anything is written
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Verify(); err != nil {
+		t.Fatalf("Verify on empty store failed: %v", err)
+	}
+
+	if err := store.Add("api_key", "secret", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Verify(); err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	// Replace the file with a malformed one that still decrypts; a store
+	// that was never loaded verifies with the configured identity
+	bad, err := Encrypt([]byte(`{"version": 1, "secrets": {"a": {"name": "b", "value": "x"}}}`), store.recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.SecretsPath, bad, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(cfg).Verify(); !errors.Is(err, types.ErrStoreCorrupted) {
+		t.Errorf("Verify error = %v, want ErrStoreCorrupted", err)
+	}
+}
+
+func TestVerifyFile_Tampered(t *testing.T) {
+	cfg := testConfig(t)
+	store := New(cfg)
+	if err := store.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add("api_key", "secret", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext, err := os.ReadFile(cfg.SecretsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext[len(ciphertext)-1] ^= 0xff
+	tampered := filepath.Join(t.TempDir(), "secrets.age")
+	if err := os.WriteFile(tampered, ciphertext, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(tampered, store.identity); !errors.Is(err, types.ErrDecryptionFailed) {
+		t.Errorf("tampered file error = %v, want ErrDecryptionFailed", err)
+	}
+
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(cfg.SecretsPath, other); !errors.Is(err, types.ErrDecryptionFailed) {
+		t.Errorf("wrong identity error = %v, want ErrDecryptionFailed", err)
+	}
+}
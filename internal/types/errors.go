@@ -13,23 +13,37 @@ var (
 	ErrSecretExists       = errors.New("secret already exists")
 	ErrStoreNotInitialized = errors.New("store not initialized")
 	ErrStoreCorrupted     = errors.New("store data corrupted")
+	ErrVersionNotFound    = errors.New("secret version not found")
+	ErrNamespaceNotFound  = errors.New("namespace not found")
 
 	// Encryption errors
 	ErrEncryptionFailed   = errors.New("encryption failed")
 	ErrDecryptionFailed   = errors.New("decryption failed")
 	ErrInvalidIdentity    = errors.New("invalid age identity")
 	ErrIdentityNotFound   = errors.New("identity file not found")
+	ErrPluginNotFound     = errors.New("age plugin not found")
+	ErrPassphraseRequired = errors.New("identity is passphrase-protected and no passphrase was given")
+	ErrWrongPassphrase    = errors.New("incorrect identity passphrase")
+	ErrRecipientMismatch  = errors.New("backup is encrypted to a different identity")
+	ErrRecipientExists    = errors.New("recipient already added")
+	ErrRecipientNotFound  = errors.New("recipient not found")
 
 	// Lease errors
 	ErrLeaseNotFound      = errors.New("lease not found")
 	ErrLeaseExpired       = errors.New("lease has expired")
 	ErrLeaseRevoked       = errors.New("lease has been revoked")
 	ErrInvalidTTL         = errors.New("invalid TTL duration")
+	ErrPeerMismatch       = errors.New("lease belongs to a different client identity")
+	ErrLeaseQuotaExceeded = errors.New("client has reached its active lease limit")
+	ErrLeaseReasonTooLong = errors.New("lease reason is too long")
+	ErrLeaseWrongSecret   = errors.New("lease was granted for a different secret")
 
 	// Rotation errors
 	ErrRotationFailed     = errors.New("rotation hook failed")
 	ErrNoRotationHook     = errors.New("no rotation hook configured")
 	ErrRotationTimeout    = errors.New("rotation hook timed out")
+	ErrGenerateFailed     = errors.New("generate hook failed")
+	ErrRevokeHookFailed   = errors.New("revoke hook failed")
 
 	// Killswitch errors
 	ErrKillswitchActive   = errors.New("killswitch is active")
@@ -49,6 +63,7 @@ var (
 
 	// Adapter errors
 	ErrAdapterFailed      = errors.New("adapter operation failed")
+	ErrPushNotSupported   = errors.New("adapter does not support push")
 )
 
 // SecretError wraps an error with the secret name for context.
@@ -197,7 +212,7 @@ func RPCErrorFromError(err error) *RPCError {
 	code := RPCInternalError
 
 	switch {
-	case errors.Is(err, ErrSecretNotFound):
+	case errors.Is(err, ErrSecretNotFound), errors.Is(err, ErrNamespaceNotFound):
 		code = RPCSecretNotFound
 	case errors.Is(err, ErrLeaseNotFound):
 		code = RPCLeaseNotFound
@@ -205,6 +220,8 @@ func RPCErrorFromError(err error) *RPCError {
 		code = RPCLeaseExpired
 	case errors.Is(err, ErrRotationFailed), errors.Is(err, ErrRotationTimeout):
 		code = RPCRotationFailed
+	case errors.Is(err, ErrPeerMismatch), errors.Is(err, ErrLeaseWrongSecret):
+		code = RPCUnauthorized
 	case errors.Is(err, ErrEncryptionFailed):
 		code = RPCEncryptionError
 	case errors.Is(err, ErrDecryptionFailed):
@@ -0,0 +1,28 @@
+package types
+
+import "strings"
+
+// NamespaceSeparator separates a namespace from a secret name in a secret
+// reference, e.g. "prod::DATABASE_URL".
+const NamespaceSeparator = "::"
+
+// DefaultNamespace is the namespace of secrets whose names carry no prefix.
+const DefaultNamespace = "default"
+
+// SplitRef splits a secret reference into its namespace and bare name.
+// References without a namespace prefix belong to DefaultNamespace.
+func SplitRef(ref string) (namespace, name string) {
+	if ns, n, ok := strings.Cut(ref, NamespaceSeparator); ok && ns != "" {
+		return ns, n
+	}
+	return DefaultNamespace, ref
+}
+
+// JoinRef builds a secret reference from a namespace and bare name.
+// Secrets in DefaultNamespace are referenced by their bare name.
+func JoinRef(namespace, name string) string {
+	if namespace == "" || namespace == DefaultNamespace {
+		return name
+	}
+	return namespace + NamespaceSeparator + name
+}
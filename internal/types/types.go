@@ -12,6 +12,58 @@ type Secret struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	RotateVia   string    `json:"rotate_via,omitempty"` // Command to execute for rotation
 	LastRotated time.Time `json:"last_rotated,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"` // Zero if the secret never expires
+
+	// PropagateTo lists managed env files refreshed after each rotation.
+	PropagateTo []EnvTarget `json:"propagate_to,omitempty"`
+
+	// Labels are free-form key/value tags for filtering, like env=prod.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// GenerateVia is a command whose output is served as the value of each
+	// lease instead of the stored value, for dynamic credentials minted per
+	// use. RevokeVia is run when such a lease is revoked or expires.
+	GenerateVia string `json:"generate_via,omitempty"`
+	RevokeVia   string `json:"revoke_via,omitempty"`
+
+	// Binary marks a secret added from a file, such as a TLS key; its value
+	// is the file's contents, base64-encoded.
+	Binary bool `json:"binary,omitempty"`
+
+	// RotationPolicy schedules automatic rotation through RotateVia.
+	RotationPolicy *RotationPolicy `json:"rotation_policy,omitempty"`
+}
+
+// RotationPolicy schedules a secret's rotation hook to run automatically.
+type RotationPolicy struct {
+	// Interval is how long after the last rotation (or creation, if never
+	// rotated) the next rotation is due.
+	Interval time.Duration `json:"interval"`
+	// MaxAge, if set, bounds how long retries of a failing rotation back
+	// off: once the secret is this old, every check retries it.
+	MaxAge time.Duration `json:"max_age,omitempty"`
+}
+
+// EnvTarget names a variable in a managed env file that mirrors a secret.
+type EnvTarget struct {
+	Path string `json:"path"` // Absolute path to the env file
+	Var  string `json:"var"`  // Variable name in the file
+}
+
+// Recipient is an age public key the store is encrypted to.
+type Recipient struct {
+	Recipient   string `json:"recipient"`
+	Fingerprint string `json:"fingerprint"`
+	// Local is set for the recipient of the store's own identity, which is
+	// always included and cannot be removed.
+	Local bool `json:"local"`
+}
+
+// SecretVersion describes one version of a secret's value, without the
+// value. Version 0 is the current value, 1 the one before it, and so on.
+type SecretVersion struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Lease represents a time-bounded access grant to a secret.
@@ -22,6 +74,47 @@ type Lease struct {
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
 	Revoked   bool      `json:"revoked"`
+	Reason    string    `json:"reason,omitempty"` // Why the client asked for the lease, for the audit trail
+
+	// Peer identity for leases acquired over the TCP transport.
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	ClientCN   string `json:"client_cn,omitempty"`
+
+	// PeerIdentity is the Peer.Identity of the acquiring client; remote
+	// peers may only act on leases carrying their own.
+	PeerIdentity string `json:"peer_identity,omitempty"`
+}
+
+// Peer identifies the transport-level client of a request.
+// It is empty for Unix socket connections, whose peer is the socket owner.
+type Peer struct {
+	RemoteAddr string `json:"remote_addr,omitempty"` // Remote address of the TCP connection
+	ClientCN   string `json:"client_cn,omitempty"`   // Common name of the verified client certificate
+	UID        string `json:"uid,omitempty"`         // User ID of a Unix socket peer, if the OS reports it
+	TokenID    string `json:"token_id,omitempty"`    // Fingerprint of the auth token a TCP client presented
+}
+
+// IsRemote returns true if the peer connected over the TCP transport.
+func (p Peer) IsRemote() bool {
+	return p.RemoteAddr != ""
+}
+
+// Identity names the peer for authorization policies: "cn:<name>" for the
+// certificate common name of a TCP client, "token:<id>" for a
+// token-authenticated TCP client, or "uid:<n>" for a Unix socket peer. The
+// prefixes keep a certificate CN from passing for another kind of peer. It
+// is empty if none is known.
+func (p Peer) Identity() string {
+	switch {
+	case p.ClientCN != "":
+		return "cn:" + p.ClientCN
+	case p.TokenID != "":
+		return "token:" + p.TokenID
+	case p.UID != "":
+		return "uid:" + p.UID
+	default:
+		return ""
+	}
 }
 
 // LeaseRequest represents a request to acquire a lease on a secret.
@@ -46,31 +139,79 @@ type AuditEntry struct {
 	LeaseID   string    `json:"lease_id,omitempty"`
 	Details   string    `json:"details,omitempty"`
 	Success   bool      `json:"success"`
+	RemoteAddr string   `json:"remote_addr,omitempty"`
+	ClientCN  string    `json:"client_cn,omitempty"`
+	Trigger   RotationTrigger `json:"trigger,omitempty"` // What started a rotation
 }
 
 // Action represents the type of operation being audited.
 type Action string
 
 const (
-	ActionSecretAdd     Action = "secret_add"
-	ActionSecretDelete  Action = "secret_delete"
-	ActionSecretRotate  Action = "secret_rotate"
-	ActionLeaseAcquire  Action = "lease_acquire"
-	ActionLeaseRevoke   Action = "lease_revoke"
-	ActionLeaseExpire   Action = "lease_expire"
-	ActionKillswitch    Action = "killswitch"
-	ActionDaemonStart   Action = "daemon_start"
-	ActionDaemonStop    Action = "daemon_stop"
-	ActionHeartbeatFail Action = "heartbeat_fail"
+	ActionSecretAdd       Action = "secret_add"
+	ActionSecretDelete    Action = "secret_delete"
+	ActionSecretRename    Action = "secret_rename"
+	ActionSecretExpire    Action = "secret_expire"
+	ActionSecretRotate    Action = "secret_rotate"
+	ActionSecretRollback  Action = "secret_rollback"
+	ActionSecretGenerate  Action = "secret_generate"
+	ActionSecretExport    Action = "secret_export"
+	ActionLeaseAcquire    Action = "lease_acquire"
+	ActionLeaseRevoke     Action = "lease_revoke"
+	ActionLeaseRenew      Action = "lease_renew"
+	ActionLeaseExpire     Action = "lease_expire"
+	ActionLeaseFetch      Action = "lease_fetch"
+	ActionLeaseRevokeHook Action = "lease_revoke_hook"
+	ActionKillswitch      Action = "killswitch"
+	ActionKillswitchArm   Action = "killswitch_arm"
+	ActionDaemonStart     Action = "daemon_start"
+	ActionDaemonStop      Action = "daemon_stop"
+	ActionHeartbeatFail   Action = "heartbeat_fail"
+	ActionKeepAlive       Action = "keepalive"
+	ActionWebhookNotify   Action = "webhook_notify"
+	ActionSecretPropagate Action = "secret_propagate"
+	ActionAuthFailed      Action = "auth_failed"
+	ActionConnClosed      Action = "connection_closed"
+	ActionConnError       Action = "connection_error"
+)
+
+// RotationTrigger identifies what started a rotation.
+type RotationTrigger string
+
+const (
+	TriggerManual     RotationTrigger = "manual"     // secrets rotate
+	TriggerScheduled  RotationTrigger = "scheduled"  // A rotation schedule
+	TriggerKillswitch RotationTrigger = "killswitch" // Killswitch activation
+	TriggerHeartbeat  RotationTrigger = "heartbeat"  // Killswitch fired by a failed heartbeat
 )
 
 // RotationResult contains the outcome of a rotation hook execution.
+//
+// A hook that mints a new credential hands it back on stdout, either as a
+// line SECRET_VALUE=<value> (the last such line wins) or as the JSON object
+// {"value": "<value>"} as its whole output. The value replaces the stored
+// one before the secret is marked rotated, and is redacted from Output.
+// Binary secrets take the value base64-encoded. A hook that emits no value
+// leaves the stored value unchanged.
 type RotationResult struct {
 	SecretName string    `json:"secret_name"`
 	Success    bool      `json:"success"`
 	Output     string    `json:"output,omitempty"`
 	Error      string    `json:"error,omitempty"`
 	ExecutedAt time.Time `json:"executed_at"`
+	Propagated []string  `json:"propagated,omitempty"` // Env files refreshed with the new value
+
+	Trigger  RotationTrigger `json:"trigger"`
+	ExitCode int             `json:"exit_code"` // -1 if the hook did not exit on its own
+	Duration time.Duration   `json:"duration"`  // How long the hook ran
+
+	// Fingerprints of the value before and after the hook (see
+	// store.Store.Fingerprint); they differ when the hook stored a new value
+	OldFingerprint string `json:"old_fingerprint,omitempty"`
+	NewFingerprint string `json:"new_fingerprint,omitempty"`
+
+	// ValueUpdated is set when the hook emitted a new value, now stored
+	ValueUpdated bool `json:"value_updated,omitempty"`
 }
 
 // KillswitchOptions controls killswitch behavior.
@@ -78,16 +219,70 @@ type KillswitchOptions struct {
 	RevokeAll  bool `json:"revoke_all"`
 	RotateAll  bool `json:"rotate_all"`
 	WipeStore  bool `json:"wipe_store"`
+
+	// Trigger is recorded on rotations; defaults to TriggerKillswitch
+	Trigger RotationTrigger `json:"-"`
 }
 
 // HeartbeatConfig configures optional remote heartbeat monitoring.
 type HeartbeatConfig struct {
 	Enabled  bool          `json:"enabled"`
 	URL      string        `json:"url,omitempty"`
+	URLs     []string      `json:"urls,omitempty"`   // Further endpoints, polled alongside URL
+	Quorum   int           `json:"quorum,omitempty"` // Endpoints that must succeed; 0 means a majority
 	Interval time.Duration `json:"interval"`
 	Timeout  time.Duration `json:"timeout"`
 	// FailAction determines what happens on heartbeat failure
 	FailAction KillswitchOptions `json:"fail_action"`
+	// FailureThreshold is how many consecutive failed heartbeats trigger
+	// FailAction. 0 means DefaultHeartbeatFailureThreshold.
+	FailureThreshold int `json:"failure_threshold,omitempty"`
+
+	// Backoff: after each failed heartbeat the poll interval is multiplied
+	// by BackoffFactor (0 means 2), up to MaxInterval, and it returns to
+	// Interval after a success. Backoff is off unless MaxInterval exceeds
+	// Interval. Jitter randomizes every interval by up to that percentage
+	// in either direction; zero disables it.
+	MaxInterval   time.Duration `json:"max_interval,omitempty"`
+	BackoffFactor float64       `json:"backoff_factor,omitempty"`
+	Jitter        int           `json:"jitter,omitempty"`
+
+	// Method is the HTTP method of heartbeat requests; empty means GET.
+	// Headers are sent with every request, e.g. an auth token. $VAR and
+	// ${VAR} in header values are expanded from the environment when the
+	// monitor starts. Header values are never logged.
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// DefaultHeartbeatFailureThreshold is the number of consecutive failed
+// heartbeats that trigger the killswitch when FailureThreshold is unset.
+const DefaultHeartbeatFailureThreshold = 3
+
+// FailuresToTrigger returns FailureThreshold, or the default if unset.
+func (c HeartbeatConfig) FailuresToTrigger() int {
+	if c.FailureThreshold > 0 {
+		return c.FailureThreshold
+	}
+	return DefaultHeartbeatFailureThreshold
+}
+
+// Endpoints returns every URL to poll: URL, if set, followed by URLs.
+func (c HeartbeatConfig) Endpoints() []string {
+	var endpoints []string
+	if c.URL != "" {
+		endpoints = append(endpoints, c.URL)
+	}
+	return append(endpoints, c.URLs...)
+}
+
+// RequiredSuccesses returns how many endpoints must succeed for a heartbeat
+// to pass: Quorum if set, otherwise a majority of Endpoints.
+func (c HeartbeatConfig) RequiredSuccesses() int {
+	if c.Quorum > 0 {
+		return c.Quorum
+	}
+	return len(c.Endpoints())/2 + 1
 }
 
 // DaemonStatus represents the current state of the daemon.
@@ -97,6 +292,17 @@ type DaemonStatus struct {
 	SecretsCount  int           `json:"secrets_count"`
 	ActiveLeases  int           `json:"active_leases"`
 	Heartbeat     *HeartbeatConfig `json:"heartbeat,omitempty"`
+	Leases        *LeaseMetrics    `json:"leases,omitempty"`
+}
+
+// LeaseMetrics is a point-in-time summary of active leases.
+type LeaseMetrics struct {
+	Active        int            `json:"active"`
+	ByNamespace   map[string]int `json:"by_namespace"`
+	BySecret      map[string]int `json:"by_secret"`
+	SoonestExpiry time.Time      `json:"soonest_expiry,omitempty"` // Zero when there are no active leases
+	TotalAcquired uint64         `json:"total_acquired"`           // Since the manager was created
+	MaxPerClient  int            `json:"max_per_client,omitempty"` // Active leases allowed per client ID; zero means unlimited
 }
 
 // RPCRequest represents a JSON-RPC 2.0 request.
@@ -105,6 +311,9 @@ type RPCRequest struct {
 	Method  string      `json:"method"`
 	Params  interface{} `json:"params,omitempty"`
 	ID      interface{} `json:"id"`
+
+	// Auth carries the bearer token on token-authenticated TCP connections
+	Auth string `json:"auth,omitempty"`
 }
 
 // RPCResponse represents a JSON-RPC 2.0 response.
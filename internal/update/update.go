@@ -19,11 +19,13 @@ import (
 const (
 	repoOwner              = "joelhooks"
 	repoName               = "agent-secrets"
-	apiURL                 = "https://api.github.com/repos/joelhooks/agent-secrets/releases/latest"
 	DefaultUpdateCheckFile = "update-check.json"
 	CacheDuration          = 24 * time.Hour
 )
 
+// apiURL is the GitHub endpoint for the latest release (overridable in tests)
+var apiURL = "https://api.github.com/repos/joelhooks/agent-secrets/releases/latest"
+
 // ReleaseInfo represents GitHub release information
 type ReleaseInfo struct {
 	TagName string  `json:"tag_name"`
@@ -181,42 +183,76 @@ func CheckForUpdate(currentVersion string) (*output.UpdateInfo, error) {
 	}, nil
 }
 
-// DoUpdate performs the self-update
-func DoUpdate(currentVersion string) error {
-	if currentVersion == "dev" {
-		return fmt.Errorf("cannot update dev build")
-	}
+// UpdatePlan describes what an update would do without performing it
+type UpdatePlan struct {
+	Available      bool   `json:"available"`
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version"`
+	AssetName      string `json:"asset_name"`
+	DownloadURL    string `json:"download_url,omitempty"`
+	OS             string `json:"os"`
+	Arch           string `json:"arch"`
+}
 
+// PlanUpdate resolves the latest release and the asset matching the current
+// OS/arch. It never downloads or replaces anything, so it is safe for dry runs.
+// DownloadURL is empty when the release has no matching asset.
+func PlanUpdate(currentVersion string) (*UpdatePlan, error) {
 	latest, err := getLatestRelease()
 	if err != nil {
-		return fmt.Errorf("failed to fetch latest release: %w", err)
+		return nil, fmt.Errorf("failed to fetch latest release: %w", err)
 	}
 
+	return planFor(latest, currentVersion, runtime.GOOS, runtime.GOARCH), nil
+}
+
+// planFor builds an update plan for a release and platform
+func planFor(latest *ReleaseInfo, currentVersion, goos, goarch string) *UpdatePlan {
 	// Strip 'v' prefix for comparison
 	latestVersion := strings.TrimPrefix(latest.TagName, "v")
 	current := strings.TrimPrefix(currentVersion, "v")
 
-	if latestVersion == current {
-		return fmt.Errorf("already at latest version %s", currentVersion)
+	plan := &UpdatePlan{
+		Available:      latestVersion != current,
+		CurrentVersion: currentVersion,
+		LatestVersion:  latest.TagName,
+		AssetName:      fmt.Sprintf("secrets_%s_%s_%s", latestVersion, goos, goarch),
+		OS:             goos,
+		Arch:           goarch,
 	}
 
-	// Find the asset for current OS/arch
-	assetName := fmt.Sprintf("secrets_%s_%s_%s", strings.TrimPrefix(latest.TagName, "v"), runtime.GOOS, runtime.GOARCH)
-	var downloadURL string
-
+	// Find the asset for the OS/arch
 	for _, asset := range latest.Assets {
-		if strings.Contains(asset.Name, assetName) {
-			downloadURL = asset.BrowserDownloadURL
+		if strings.Contains(asset.Name, plan.AssetName) {
+			plan.DownloadURL = asset.BrowserDownloadURL
 			break
 		}
 	}
 
-	if downloadURL == "" {
-		return fmt.Errorf("no binary found for %s/%s", runtime.GOOS, runtime.GOARCH)
+	return plan
+}
+
+// DoUpdate performs the self-update
+func DoUpdate(currentVersion string) error {
+	if currentVersion == "dev" {
+		return fmt.Errorf("cannot update dev build")
+	}
+
+	plan, err := PlanUpdate(currentVersion)
+	if err != nil {
+		return err
+	}
+
+	if !plan.Available {
+		return fmt.Errorf("already at latest version %s", currentVersion)
+	}
+
+	if plan.DownloadURL == "" {
+		return fmt.Errorf("no binary found for %s/%s", plan.OS, plan.Arch)
 	}
 
 	// Download the new binary
-	tmpFile, err := downloadBinary(downloadURL)
+	tmpFile, err := downloadBinary(plan.DownloadURL)
 	if err != nil {
 		return fmt.Errorf("failed to download binary: %w", err)
 	}
@@ -338,7 +374,7 @@ func CheckForUpdateInBackground(currentVersion string) {
 		}
 
 		// Only print in human mode
-		if output.HumanMode {
+		if output.OutputFormat == string(output.ModeTable) && !output.Quiet {
 			fmt.Fprintf(os.Stderr, "\n⚠ Update available: %s → %s\n", info.CurrentVersion, info.LatestVersion)
 			fmt.Fprintf(os.Stderr, "  Run: %s\n\n", info.Command)
 		}
@@ -2,8 +2,12 @@ package update
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -312,3 +316,105 @@ func TestLoadCacheRoundTrip(t *testing.T) {
 		}
 	})
 }
+
+// stubReleaseAPI points apiURL at a test server serving the given release.
+// It returns a counter of requests that hit anything other than the API path.
+func stubReleaseAPI(t *testing.T, release ReleaseInfo) *int {
+	t.Helper()
+
+	otherHits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/latest" {
+			otherHits++
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(release)
+	}))
+	t.Cleanup(server.Close)
+
+	originalURL := apiURL
+	apiURL = server.URL + "/releases/latest"
+	t.Cleanup(func() { apiURL = originalURL })
+
+	return &otherHits
+}
+
+func TestPlanUpdate_SelectsPlatformAsset(t *testing.T) {
+	wantAsset := fmt.Sprintf("secrets_0.3.0_%s_%s", runtime.GOOS, runtime.GOARCH)
+	release := ReleaseInfo{
+		TagName: "v0.3.0",
+		Assets: []Asset{
+			{Name: "secrets_0.3.0_plan9_mips.tar.gz", BrowserDownloadURL: "https://example.com/wrong"},
+			{Name: wantAsset + ".tar.gz", BrowserDownloadURL: "https://example.com/right"},
+		},
+	}
+	otherHits := stubReleaseAPI(t, release)
+
+	// Downloads go to the temp dir and the check cache under HOME, so
+	// point both at a dir we can watch
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+	t.Setenv("HOME", tmpDir)
+	before, _ := os.ReadDir(tmpDir)
+
+	plan, err := PlanUpdate("v0.2.0")
+	if err != nil {
+		t.Fatalf("PlanUpdate failed: %v", err)
+	}
+
+	if !plan.Available {
+		t.Error("expected update to be available")
+	}
+	if plan.CurrentVersion != "v0.2.0" || plan.LatestVersion != "v0.3.0" {
+		t.Errorf("versions = %s -> %s, want v0.2.0 -> v0.3.0", plan.CurrentVersion, plan.LatestVersion)
+	}
+	if plan.AssetName != wantAsset {
+		t.Errorf("AssetName = %q, want %q", plan.AssetName, wantAsset)
+	}
+	if plan.DownloadURL != "https://example.com/right" {
+		t.Errorf("DownloadURL = %q, want the platform asset URL", plan.DownloadURL)
+	}
+
+	// Dry run must never download anything
+	if *otherHits != 0 {
+		t.Errorf("expected no download requests, got %d", *otherHits)
+	}
+	after, _ := os.ReadDir(tmpDir)
+	if len(after) != len(before) {
+		t.Error("PlanUpdate wrote files to disk")
+	}
+}
+
+func TestPlanUpdate_NoMatchingAsset(t *testing.T) {
+	stubReleaseAPI(t, ReleaseInfo{
+		TagName: "v0.3.0",
+		Assets: []Asset{
+			{Name: "secrets_0.3.0_plan9_mips.tar.gz", BrowserDownloadURL: "https://example.com/wrong"},
+		},
+	})
+
+	plan, err := PlanUpdate("v0.2.0")
+	if err != nil {
+		t.Fatalf("PlanUpdate failed: %v", err)
+	}
+
+	if plan.DownloadURL != "" {
+		t.Errorf("expected empty DownloadURL, got %q", plan.DownloadURL)
+	}
+	if plan.AssetName == "" {
+		t.Error("expected AssetName to report the expected asset")
+	}
+}
+
+func TestPlanUpdate_AlreadyLatest(t *testing.T) {
+	stubReleaseAPI(t, ReleaseInfo{TagName: "v0.3.0"})
+
+	plan, err := PlanUpdate("0.3.0")
+	if err != nil {
+		t.Fatalf("PlanUpdate failed: %v", err)
+	}
+	if plan.Available {
+		t.Error("expected no update when versions match")
+	}
+}